@@ -0,0 +1,186 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/ider"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+)
+
+var ZINC_CACHED_API_KEYS = cachedAPIKeys{keys: map[string]*meta.APIKey{}}
+
+type cachedAPIKeys struct {
+	keys map[string]*meta.APIKey
+	lock sync.RWMutex
+}
+
+func (t *cachedAPIKeys) Get(id string) (*meta.APIKey, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	key, ok := t.keys[id]
+	return key, ok
+}
+
+func (t *cachedAPIKeys) Set(id string, key *meta.APIKey) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.keys[id] = key
+}
+
+func (t *cachedAPIKeys) Delete(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.keys, id)
+}
+
+func initAPIKeyCache() error {
+	keys, err := metadata.APIKey.List(0, 0)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		ZINC_CACHED_API_KEYS.Set(key.ID, key)
+	}
+	return nil
+}
+
+// CreateAPIKey creates a key with the role for the owner, the secret is returned only once,
+// an expiration of zero means the key never expires
+func CreateAPIKey(name, role, owner string, expiration time.Duration) (*meta.APIKey, string, error) {
+	if name == "" {
+		return nil, "", errors.New(errors.ErrorTypeInvalidArgument, "api key name is required")
+	}
+	role = strings.ToLower(role)
+	if role == "" {
+		return nil, "", errors.New(errors.ErrorTypeInvalidArgument, "api key role is required")
+	}
+	if role != "admin" {
+		if _, ok, err := GetRole(role); err != nil || !ok {
+			return nil, "", errors.New(errors.ErrorTypeInvalidArgument, "api key role ["+role+"] does not exist")
+		}
+	}
+	if expiration < 0 {
+		return nil, "", errors.New(errors.ErrorTypeInvalidArgument, "api key expiration should be positive")
+	}
+
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &meta.APIKey{
+		ID:        ider.Generate(),
+		Name:      name,
+		Role:      role,
+		Owner:     owner,
+		Hash:      hashAPIKeySecret(secret),
+		CreatedAt: time.Now(),
+	}
+	if expiration > 0 {
+		expiredAt := key.CreatedAt.Add(expiration)
+		key.Expiration = &expiredAt
+	}
+	if err := metadata.APIKey.Set(key.ID, *key); err != nil {
+		return nil, "", err
+	}
+	ZINC_CACHED_API_KEYS.Set(key.ID, key)
+	return key, secret, nil
+}
+
+// GetAPIKeys returns the keys sorted by creation time, the hashes are removed
+func GetAPIKeys() ([]*meta.APIKey, error) {
+	keys, err := metadata.APIKey.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		key.Hash = ""
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// GetAPIKey returns the key by id
+func GetAPIKey(id string) (*meta.APIKey, bool, error) {
+	key, err := metadata.APIKey.Get(id)
+	if err != nil {
+		if errors.Is(err, errors.ErrKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return key, true, nil
+}
+
+// DeleteAPIKey revokes the key, the requests using it are rejected right away
+func DeleteAPIKey(id string) error {
+	ZINC_CACHED_API_KEYS.Delete(id)
+	return metadata.APIKey.Delete(id)
+}
+
+// VerifyAPIKey verifies the credentials of the Authorization: ApiKey header, base64(id:secret)
+func VerifyAPIKey(encoded string) (*meta.APIKey, bool) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, false
+	}
+	id, secret, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return nil, false
+	}
+	key, ok := ZINC_CACHED_API_KEYS.Get(id)
+	if !ok {
+		return nil, false
+	}
+	if key.Expiration != nil && time.Now().After(*key.Expiration) {
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.Hash)) != 1 {
+		return nil, false
+	}
+	return key, true
+}
+
+// EncodeAPIKey returns the credentials for the Authorization: ApiKey header
+func EncodeAPIKey(id, secret string) string {
+	return base64.StdEncoding.EncodeToString([]byte(id + ":" + secret))
+}
+
+func generateAPIKeySecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKeySecret hashes the secret, the secrets are random so a salted slow hash isn't needed
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	_, err := CreateRole("testapikeyrole", "Test Api Key Role", []string{"search.SearchDSL"})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, DeleteRole("testapikeyrole"))
+	}()
+
+	key, secret, err := CreateAPIKey("shipper", "TestApiKeyRole", "admin", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "testapikeyrole", key.Role)
+	assert.Nil(t, key.Expiration)
+	assert.NotContains(t, key.Hash, secret)
+
+	t.Run("verify", func(t *testing.T) {
+		got, ok := VerifyAPIKey(EncodeAPIKey(key.ID, secret))
+		assert.True(t, ok)
+		assert.Equal(t, key.ID, got.ID)
+		_, ok = VerifyAPIKey(EncodeAPIKey(key.ID, secret+"x"))
+		assert.False(t, ok)
+		_, ok = VerifyAPIKey("not base64")
+		assert.False(t, ok)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		keys, err := GetAPIKeys()
+		assert.NoError(t, err)
+		found := false
+		for _, k := range keys {
+			if k.ID == key.ID {
+				found = true
+				assert.Empty(t, k.Hash)
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		expired, expiredSecret, err := CreateAPIKey("expired", "admin", "admin", time.Millisecond)
+		assert.NoError(t, err)
+		assert.NotNil(t, expired.Expiration)
+		time.Sleep(5 * time.Millisecond)
+		_, ok := VerifyAPIKey(EncodeAPIKey(expired.ID, expiredSecret))
+		assert.False(t, ok)
+		assert.NoError(t, DeleteAPIKey(expired.ID))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := CreateAPIKey("", "admin", "admin", 0)
+		assert.Error(t, err)
+		_, _, err = CreateAPIKey("shipper", "not_exists", "admin", 0)
+		assert.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		assert.NoError(t, DeleteAPIKey(key.ID))
+		_, ok := VerifyAPIKey(EncodeAPIKey(key.ID, secret))
+		assert.False(t, ok)
+		_, ok, err := GetAPIKey(key.ID)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -25,6 +25,9 @@ func VerifyCredentials(userID, password string) (*meta.User, bool) {
 	userID = strings.ToLower(userID)
 	user, ok := ZINC_CACHED_USERS.Get(userID)
 	if !ok {
+		if LDAPEnabled() {
+			return VerifyLDAPCredentials(userID, password)
+		}
 		return user, false
 	}
 
@@ -0,0 +1,75 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// ContextUserKey is the key of the authenticated user in the request context
+const ContextUserKey = "zinc.auth.user"
+
+// SetContextUser stores the authenticated user of the request, for an api key
+// it is the owner of the key with the role of the key
+func SetContextUser(c *gin.Context, user *meta.User) {
+	c.Set(ContextUserKey, user)
+}
+
+// GetContextUser returns the authenticated user of the request
+func GetContextUser(c *gin.Context) (*meta.User, bool) {
+	v, ok := c.Get(ContextUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*meta.User)
+	return user, ok
+}
+
+// GetContextPrivileges returns the index privileges of the authenticated user,
+// nil means the user can access all the documents
+func GetContextPrivileges(c *gin.Context) []*meta.RoleIndices {
+	user, ok := GetContextUser(c)
+	if !ok {
+		return nil
+	}
+	return RoleIndices(user.Role)
+}
+
+// ContextIndexPermissionKey is the key of the permission of the request when it is granted only on some indexes
+const ContextIndexPermissionKey = "zinc.auth.index_permission"
+
+// SetContextIndexPermission marks the permission of the request as granted only on some indexes,
+// the handlers check the indexes named in the request body with AuthorizeContextIndexNames
+func SetContextIndexPermission(c *gin.Context, permission string) {
+	c.Set(ContextIndexPermissionKey, permission)
+}
+
+// AuthorizeContextIndexNames checks the user has the permission of the request on the index names,
+// the names are returned as is when the permission is granted on all the indexes
+func AuthorizeContextIndexNames(c *gin.Context, names []string, resolve func(name string) []string) ([]string, error) {
+	permission := c.GetString(ContextIndexPermissionKey)
+	if permission == "" {
+		return names, nil
+	}
+	user, ok := GetContextUser(c)
+	if !ok {
+		return nil, indexPermissionError(permission, strings.Join(names, ","))
+	}
+	return AuthorizeIndexNames(user.Role, permission, names, resolve)
+}
@@ -22,5 +22,8 @@ import (
 )
 
 func DeleteUser(id string) error {
+	if err := RevokeUserSessions(id); err != nil {
+		return err
+	}
 	return metadata.User.Delete(strings.ToLower(id))
 }
@@ -36,6 +36,12 @@ func init() {
 	if err := initPermissionCache(); err != nil {
 		log.Print(err)
 	}
+	if err := initAPIKeyCache(); err != nil {
+		log.Print(err)
+	}
+	if err := initSessionCache(); err != nil {
+		log.Print(err)
+	}
 }
 
 func isFirstStart() (bool, error) {
@@ -63,6 +69,8 @@ func initPermissionCache() error {
 
 	for _, role := range roles {
 		ZINC_CACHED_PERMISSIONS.Set(role.ID, strArrayToMap(role.Permission))
+		ZINC_CACHED_ROLE_INDICES.Set(role.ID, role.Indices)
+		ZINC_CACHED_ROLE_RATE_LIMITS.Set(role.ID, role.RateLimit)
 	}
 
 	return nil
@@ -0,0 +1,115 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package auth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// HasIndexPermission returns whether the role has the permission on some indexes
+// through the privileges of its role indices
+func HasIndexPermission(roleID, permission string) bool {
+	for _, privilege := range RoleIndices(roleID) {
+		if matchPermission(privilege.Privileges, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyIndexPermission returns whether the role has the permission on the index,
+// either for all the indexes or through the privileges of the matching role indices
+func VerifyIndexPermission(roleID, permission, index string) bool {
+	if VerifyRoleHasPermission(roleID, permission) {
+		return true
+	}
+	return verifyIndexPrivileges(RoleIndices(roleID), permission, index)
+}
+
+func verifyIndexPrivileges(privileges []*meta.RoleIndices, permission, index string) bool {
+	for _, privilege := range privileges {
+		if !matchPermission(privilege.Privileges, permission) {
+			continue
+		}
+		for _, name := range privilege.Names {
+			if ok, _ := path.Match(name, index); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchPermission(patterns []string, permission string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, permission); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeIndexNames checks the role has the permission on the index names of a request,
+// resolve returns the indexes behind a wildcard name or an alias.
+// A wildcard name is narrowed to the authorized indexes it matches, no names means all the indexes,
+// an alias is authorized by its own name or when all its indexes are authorized
+func AuthorizeIndexNames(roleID, permission string, names []string, resolve func(name string) []string) ([]string, error) {
+	if VerifyRoleHasPermission(roleID, permission) {
+		return names, nil
+	}
+	privileges := RoleIndices(roleID)
+	if len(names) == 0 {
+		names = []string{"*"}
+	}
+	authorized := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			for _, index := range resolve(name) {
+				if verifyIndexPrivileges(privileges, permission, index) {
+					authorized = append(authorized, index)
+				}
+			}
+			continue
+		}
+		if verifyIndexPrivileges(privileges, permission, name) {
+			authorized = append(authorized, name)
+			continue
+		}
+		indexes := resolve(name)
+		if len(indexes) == 0 {
+			return nil, indexPermissionError(permission, name)
+		}
+		for _, index := range indexes {
+			if !verifyIndexPrivileges(privileges, permission, index) {
+				return nil, indexPermissionError(permission, name)
+			}
+		}
+		authorized = append(authorized, name)
+	}
+	if len(authorized) == 0 {
+		return nil, indexPermissionError(permission, strings.Join(names, ","))
+	}
+	return authorized, nil
+}
+
+func indexPermissionError(permission, index string) error {
+	return errors.New(errors.ErrorTypeSecurityException, fmt.Sprintf("action [%s] is unauthorized for the index [%s]", permission, index))
+}
@@ -0,0 +1,84 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestAuthorizeIndexNames(t *testing.T) {
+	_, err := SaveRole(&meta.Role{
+		ID:         "logsreader",
+		Name:       "Logs Reader",
+		Permission: []string{"cat.Health"},
+		Indices: []*meta.RoleIndices{
+			{Names: []string{"logs-*"}, Privileges: []string{"search.*", "document.Get"}},
+		},
+	})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, DeleteRole("logsreader"))
+	}()
+
+	resolve := func(name string) []string {
+		switch name {
+		case "*", "l*":
+			return []string{"logs-a", "logs-b", "metrics-a"}
+		case "logs":
+			return []string{"logs-a", "logs-b"}
+		case "all":
+			return []string{"logs-a", "metrics-a"}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		permission string
+		names      []string
+		want       []string
+		wantErr    bool
+	}{
+		{name: "global permission", permission: "cat.Health", names: []string{"metrics-a"}, want: []string{"metrics-a"}},
+		{name: "matching index", permission: "search.SearchDSL", names: []string{"logs-a"}, want: []string{"logs-a"}},
+		{name: "unauthorized index", permission: "search.SearchDSL", names: []string{"logs-a", "metrics-a"}, wantErr: true},
+		{name: "unauthorized permission", permission: "document.Delete", names: []string{"logs-a"}, wantErr: true},
+		{name: "wildcard is narrowed", permission: "search.SearchDSL", names: []string{"l*"}, want: []string{"logs-a", "logs-b"}},
+		{name: "no names", permission: "document.Get", names: nil, want: []string{"logs-a", "logs-b"}},
+		{name: "alias of authorized indexes", permission: "search.SearchDSL", names: []string{"logs"}, want: []string{"logs"}},
+		{name: "alias of unauthorized indexes", permission: "search.SearchDSL", names: []string{"all"}, wantErr: true},
+		{name: "wildcard without authorized indexes", permission: "search.SearchDSL", names: []string{"metrics-*"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AuthorizeIndexNames("logsreader", tt.permission, tt.names, resolve)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.True(t, HasIndexPermission("logsreader", "search.SearchDSL"))
+	assert.False(t, HasIndexPermission("logsreader", "index.Delete"))
+	assert.True(t, VerifyIndexPermission("logsreader", "document.Get", "logs-x"))
+	assert.False(t, VerifyIndexPermission("logsreader", "document.Get", "metrics-x"))
+}
@@ -0,0 +1,377 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// jwtLeeway tolerates the clock skew between the identity provider and zinc
+const jwtLeeway = time.Minute
+
+// jwksMinRefreshInterval limits the refreshes caused by the tokens with an unknown key id
+const jwksMinRefreshInterval = time.Minute
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// VerifyJWT verifies the signature and the claims of the bearer token,
+// it returns the user of the token with the role mapped from the role claim
+func VerifyJWT(token string) (*meta.User, error) {
+	cfg := config.Global.JWT
+	if cfg.Secret == "" && cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("jwt authentication is not configured")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+	header := new(jwtHeader)
+	if err := decodeJWTPart(parts[0], header); err != nil {
+		return nil, fmt.Errorf("malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature")
+	}
+	if err := verifyJWTSignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims")
+	}
+	if err := verifyJWTClaims(claims); err != nil {
+		return nil, err
+	}
+
+	userID, _ := claims[cfg.UserClaim].(string)
+	if userID == "" {
+		return nil, fmt.Errorf("token has no [%s] claim", cfg.UserClaim)
+	}
+	role := mapJWTRole(claims[cfg.RoleClaim])
+	if role == "" {
+		return nil, fmt.Errorf("token has no role")
+	}
+	return &meta.User{ID: userID, Name: userID, Role: role}, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func verifyJWTSignature(header *jwtHeader, signed string, signature []byte) error {
+	if len(header.Alg) != 5 {
+		return fmt.Errorf("unsupported token algorithm [%s]", header.Alg)
+	}
+	var hashFunc crypto.Hash
+	switch header.Alg[2:] {
+	case "256":
+		hashFunc = crypto.SHA256
+	case "384":
+		hashFunc = crypto.SHA384
+	case "512":
+		hashFunc = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm [%s]", header.Alg)
+	}
+
+	switch header.Alg[:2] {
+	case "HS":
+		if config.Global.JWT.Secret == "" {
+			return fmt.Errorf("unsupported token algorithm [%s]", header.Alg)
+		}
+		mac := hmac.New(newHash(hashFunc), []byte(config.Global.JWT.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("invalid token signature")
+		}
+		return nil
+	case "RS", "ES":
+		key, err := ZINC_JWKS.Get(header.Kid)
+		if err != nil {
+			return err
+		}
+		h := hashFunc.New()
+		h.Write([]byte(signed))
+		digest := h.Sum(nil)
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			if header.Alg[0] != 'R' || rsa.VerifyPKCS1v15(key, hashFunc, digest, signature) != nil {
+				return fmt.Errorf("invalid token signature")
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if header.Alg[0] != 'E' || len(signature) != 2*size {
+				return fmt.Errorf("invalid token signature")
+			}
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if !ecdsa.Verify(key, digest, r, s) {
+				return fmt.Errorf("invalid token signature")
+			}
+		default:
+			return fmt.Errorf("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported token algorithm [%s]", header.Alg)
+	}
+}
+
+func newHash(h crypto.Hash) func() hash.Hash {
+	switch h {
+	case crypto.SHA384:
+		return sha512.New384
+	case crypto.SHA512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+func verifyJWTClaims(claims map[string]interface{}) error {
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("token has no expiration")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return fmt.Errorf("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if issuer := config.Global.JWT.Issuer; issuer != "" && claims["iss"] != issuer {
+		return fmt.Errorf("invalid token issuer")
+	}
+	if audience := config.Global.JWT.Audience; audience != "" {
+		found := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			found = aud == audience
+		case []interface{}:
+			for _, v := range aud {
+				if v == audience {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid token audience")
+		}
+	}
+	return nil
+}
+
+// mapJWTRole returns the role of the first claim value mapped to a role,
+// the claim can be a string or a list like the groups of the user
+func mapJWTRole(claim interface{}) string {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, vv := range v {
+			if s, ok := vv.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	mapping := config.Global.JWT.RoleMapping
+	for _, value := range values {
+		if len(mapping) == 0 && value != "" {
+			return strings.ToLower(value)
+		}
+		for _, m := range mapping {
+			if k, role, ok := strings.Cut(m, ":"); ok && strings.TrimSpace(k) == value {
+				return strings.ToLower(strings.TrimSpace(role))
+			}
+		}
+	}
+	return strings.ToLower(config.Global.JWT.DefaultRole)
+}
+
+// ZINC_JWKS caches the public keys of the JWKS url
+var ZINC_JWKS = &jwksCache{client: &http.Client{Timeout: 10 * time.Second}}
+
+type jwksCache struct {
+	client    *http.Client
+	url       string
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	lock      sync.RWMutex
+}
+
+// Get returns the key by id, the keys are refreshed periodically and when the key id is unknown
+func (t *jwksCache) Get(kid string) (crypto.PublicKey, error) {
+	url := config.Global.JWT.JWKSURL
+	if url == "" {
+		return nil, fmt.Errorf("jwks url is not configured")
+	}
+
+	t.lock.RLock()
+	key, ok := t.lookup(kid)
+	stale := t.url != url || time.Since(t.fetchedAt) > config.Global.JWT.JWKSRefreshInterval
+	recent := t.url == url && time.Since(t.fetchedAt) < jwksMinRefreshInterval
+	t.lock.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+	if !ok && !stale && recent {
+		return nil, fmt.Errorf("unknown token key [%s]", kid)
+	}
+
+	if err := t.refresh(url); err != nil {
+		if ok {
+			// keep using the cached keys if the identity provider is unavailable
+			return key, nil
+		}
+		return nil, err
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if key, ok := t.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token key [%s]", kid)
+}
+
+// lookup returns the key by id, the only key is used for the tokens without key id
+func (t *jwksCache) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(t.keys) == 1 {
+		for _, key := range t.keys {
+			return key, true
+		}
+	}
+	key, ok := t.keys[kid]
+	return key, ok
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (t *jwksCache) refresh(url string) error {
+	resp, err := t.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %s", err.Error())
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return fmt.Errorf("parse jwks: %s", err.Error())
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	t.lock.Lock()
+	t.url = url
+	t.keys = keys
+	t.fetchedAt = time.Now()
+	t.lock.Unlock()
+	return nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve [%s]", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type [%s]", k.Kty)
+	}
+}
@@ -0,0 +1,226 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func signJWT(t *testing.T, header, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	h, err := json.Marshal(header)
+	assert.NoError(t, err)
+	c, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	old := config.Global.JWT
+	defer func() { config.Global.JWT = old }()
+
+	secret := "testjwtsecret"
+	hs256 := func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		token   string
+		role    string
+		wantErr bool
+	}{
+		{
+			name:  "hs256",
+			token: signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "Admin", "exp": exp}, hs256),
+			role:  "admin",
+		},
+		{
+			name:    "expired",
+			token:   signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "admin", "exp": time.Now().Add(-time.Hour).Unix()}, hs256),
+			wantErr: true,
+		},
+		{
+			name:    "no expiration",
+			token:   signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "admin"}, hs256),
+			wantErr: true,
+		},
+		{
+			name: "wrong signature",
+			token: signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "admin", "exp": exp}, func(signed []byte) []byte {
+				return []byte("signature")
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "none algorithm",
+			token:   signJWT(t, map[string]interface{}{"alg": "none"}, map[string]interface{}{"sub": "alice", "role": "admin", "exp": exp}, func([]byte) []byte { return nil }),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "abc.def",
+			wantErr: true,
+		},
+		{
+			name: "role mapping",
+			setup: func() {
+				config.Global.JWT.RoleClaim = "groups"
+				config.Global.JWT.RoleMapping = []string{"ops:admin", "dev:reader"}
+			},
+			token: signJWT(t, header, map[string]interface{}{"sub": "alice", "groups": []string{"sales", "dev"}, "exp": exp}, hs256),
+			role:  "reader",
+		},
+		{
+			name: "unmapped role",
+			setup: func() {
+				config.Global.JWT.RoleMapping = []string{"ops:admin"}
+			},
+			token:   signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "dev", "exp": exp}, hs256),
+			wantErr: true,
+		},
+		{
+			name: "default role",
+			setup: func() {
+				config.Global.JWT.DefaultRole = "reader"
+			},
+			token: signJWT(t, header, map[string]interface{}{"sub": "alice", "exp": exp}, hs256),
+			role:  "reader",
+		},
+		{
+			name: "issuer and audience",
+			setup: func() {
+				config.Global.JWT.Issuer = "https://idp"
+				config.Global.JWT.Audience = "zinc"
+			},
+			token: signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "admin", "iss": "https://idp", "aud": []string{"other", "zinc"}, "exp": exp}, hs256),
+			role:  "admin",
+		},
+		{
+			name: "wrong audience",
+			setup: func() {
+				config.Global.JWT.Audience = "zinc"
+			},
+			token:   signJWT(t, header, map[string]interface{}{"sub": "alice", "role": "admin", "aud": "other", "exp": exp}, hs256),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Global.JWT = old
+			config.Global.JWT.Secret = secret
+			config.Global.JWT.UserClaim = "sub"
+			config.Global.JWT.RoleClaim = "role"
+			if tt.setup != nil {
+				tt.setup()
+			}
+			user, err := VerifyJWT(tt.token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", user.ID)
+			assert.Equal(t, tt.role, user.Role)
+		})
+	}
+}
+
+func TestVerifyJWTWithJWKS(t *testing.T) {
+	old := config.Global.JWT
+	defer func() { config.Global.JWT = old }()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		data, _ := json.Marshal(map[string]interface{}{
+			"keys": []map[string]interface{}{
+				{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+			},
+		})
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	config.Global.JWT.Secret = ""
+	config.Global.JWT.JWKSURL = server.URL
+	config.Global.JWT.JWKSRefreshInterval = time.Hour
+	config.Global.JWT.UserClaim = "sub"
+	config.Global.JWT.RoleClaim = "role"
+	claims := map[string]interface{}{"sub": "bob", "role": "admin", "exp": time.Now().Add(time.Hour).Unix()}
+
+	rs256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+		return sig
+	}
+	es256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		assert.NoError(t, err)
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	user, err := VerifyJWT(signJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa1"}, claims, rs256))
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", user.ID)
+
+	user, err = VerifyJWT(signJWT(t, map[string]interface{}{"alg": "ES256", "kid": "ec1"}, claims, es256))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", user.Role)
+
+	// the rsa key can't verify the es token
+	_, err = VerifyJWT(signJWT(t, map[string]interface{}{"alg": "ES256", "kid": "rsa1"}, claims, es256))
+	assert.Error(t, err)
+
+	// hs tokens are rejected without a secret
+	_, err = VerifyJWT(signJWT(t, map[string]interface{}{"alg": "HS256"}, claims, func(signed []byte) []byte { return signed }))
+	assert.Error(t, err)
+
+	// unknown key ids don't refresh the keys more than once a minute
+	_, err = VerifyJWT(signJWT(t, map[string]interface{}{"alg": "RS256", "kid": "unknown"}, claims, rs256))
+	assert.Error(t, err)
+	assert.Equal(t, 1, fetches)
+}
@@ -0,0 +1,276 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// ZINC_LDAP authenticates the users missing in zinc against the configured ldap server
+var ZINC_LDAP = &ldapBackend{}
+
+// LDAPEnabled returns whether the ldap authentication is configured
+func LDAPEnabled() bool {
+	return config.Global.LDAP.URL != ""
+}
+
+// VerifyLDAPCredentials binds as the user found by the user filter,
+// the role is mapped from the groups of the user
+func VerifyLDAPCredentials(userID, password string) (*meta.User, bool) {
+	user, err := ZINC_LDAP.Authenticate(userID, password)
+	if err != nil {
+		var lerr *ldapError
+		if !errors.As(err, &lerr) || lerr.Code != ldapResultInvalidCredentials {
+			log.Error().Err(err).Str("user", userID).Msg("ldap authentication failed")
+		}
+		return nil, false
+	}
+	return user, true
+}
+
+type ldapCachedUser struct {
+	user     *meta.User
+	password [32]byte
+	expires  time.Time
+}
+
+type ldapBackend struct {
+	pool  chan *ldapConn
+	cache map[string]ldapCachedUser
+	lock  sync.Mutex
+}
+
+var errLDAPInvalidCredentials = &ldapError{Code: ldapResultInvalidCredentials, Message: "invalid credentials"}
+
+// Authenticate verifies the password of the user, the verified credentials are cached for ZINC_LDAP_CACHE_TTL
+func (t *ldapBackend) Authenticate(userID, password string) (*meta.User, error) {
+	cfg := config.Global.LDAP
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("ldap is not configured")
+	}
+	// the empty password is an unauthenticated bind which always succeeds
+	if userID == "" || password == "" {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	hash := sha256.Sum256([]byte(password))
+	if user, ok := t.cached(userID, hash); ok {
+		return user, nil
+	}
+
+	conn, err := t.get()
+	if err != nil {
+		return nil, err
+	}
+	user, err := t.authenticate(conn, userID, password)
+	var lerr *ldapError
+	if err != nil && !errors.As(err, &lerr) {
+		// the connection is broken
+		conn.Close()
+		return nil, err
+	}
+	t.put(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CacheTTL > 0 {
+		t.lock.Lock()
+		if t.cache == nil {
+			t.cache = make(map[string]ldapCachedUser)
+		}
+		t.cache[userID] = ldapCachedUser{user: user, password: hash, expires: time.Now().Add(cfg.CacheTTL)}
+		t.lock.Unlock()
+	}
+	return user, nil
+}
+
+func (t *ldapBackend) cached(userID string, hash [32]byte) (*meta.User, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	cached, ok := t.cache[userID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(cached.expires) {
+		delete(t.cache, userID)
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare(cached.password[:], hash[:]) != 1 {
+		return nil, false
+	}
+	return cached.user, true
+}
+
+func (t *ldapBackend) authenticate(conn *ldapConn, userID, password string) (*meta.User, error) {
+	cfg := config.Global.LDAP
+	filter := strings.ReplaceAll(cfg.UserFilter, "%s", escapeLDAPFilterValue(userID))
+	attributes := []string{cfg.NameAttribute}
+	if cfg.GroupAttribute != "" {
+		attributes = append(attributes, cfg.GroupAttribute)
+	}
+	entries, err := conn.Search(cfg.SearchBase, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		return nil, errLDAPInvalidCredentials
+	}
+	entry := entries[0]
+
+	// bind as the user then restore the service account for the next searches
+	bindErr := conn.Bind(entry.DN, password)
+	if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+		return nil, fmt.Errorf("ldap: rebind service account: %w", err)
+	}
+	if bindErr != nil {
+		return nil, bindErr
+	}
+
+	groups := entry.Get(cfg.GroupAttribute)
+	if cfg.GroupFilter != "" {
+		base := cfg.GroupSearchBase
+		if base == "" {
+			base = cfg.SearchBase
+		}
+		filter := strings.ReplaceAll(cfg.GroupFilter, "%s", escapeLDAPFilterValue(entry.DN))
+		groupEntries, err := conn.Search(base, filter, []string{"cn"})
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groupEntries {
+			groups = append(groups, group.DN)
+		}
+	}
+
+	role := mapLDAPRole(groups)
+	if role == "" {
+		return nil, fmt.Errorf("ldap user [%s] has no mapped role", userID)
+	}
+	name := userID
+	if names := entry.Get(cfg.NameAttribute); len(names) > 0 {
+		name = names[0]
+	}
+	return &meta.User{ID: userID, Name: name, Role: role}, nil
+}
+
+// mapLDAPRole returns the role of the first mapping matching the DN or the CN of a group
+func mapLDAPRole(groups []string) string {
+	for _, mapping := range strings.Split(config.Global.LDAP.RoleMapping, ";") {
+		i := strings.LastIndexByte(mapping, ':')
+		if i <= 0 {
+			continue
+		}
+		group, role := strings.TrimSpace(mapping[:i]), strings.ToLower(strings.TrimSpace(mapping[i+1:]))
+		for _, dn := range groups {
+			if strings.EqualFold(dn, group) || strings.EqualFold(ldapCN(dn), group) {
+				return role
+			}
+		}
+	}
+	return strings.ToLower(config.Global.LDAP.DefaultRole)
+}
+
+// ldapCN returns the value of the first RDN, like ops of cn=ops,ou=groups,dc=example,dc=org
+func ldapCN(dn string) string {
+	rdn, _, _ := strings.Cut(dn, ",")
+	_, value, ok := strings.Cut(rdn, "=")
+	if !ok {
+		return dn
+	}
+	return strings.TrimSpace(value)
+}
+
+// get returns a pooled connection bound as the service account
+func (t *ldapBackend) get() (*ldapConn, error) {
+	cfg := config.Global.LDAP
+	t.lock.Lock()
+	if t.pool == nil {
+		size := cfg.PoolSize
+		if size < 1 {
+			size = 1
+		}
+		t.pool = make(chan *ldapConn, size)
+	}
+	pool := t.pool
+	t.lock.Unlock()
+
+	for {
+		select {
+		case conn := <-pool:
+			if conn.url == cfg.URL {
+				return conn, nil
+			}
+			conn.Close()
+		default:
+			tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} // #nosec G402
+			conn, err := dialLDAP(cfg.URL, cfg.StartTLS, tlsConfig, cfg.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			if cfg.BindDN != "" {
+				if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+					conn.Close()
+					return nil, fmt.Errorf("ldap: bind service account: %w", err)
+				}
+			}
+			return conn, nil
+		}
+	}
+}
+
+// put returns the connection to the pool, it is closed if the pool is full
+func (t *ldapBackend) put(conn *ldapConn) {
+	t.lock.Lock()
+	pool := t.pool
+	t.lock.Unlock()
+	select {
+	case pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// Reset closes the pooled connections and clears the cached credentials
+func (t *ldapBackend) Reset() {
+	t.lock.Lock()
+	pool := t.pool
+	t.pool = nil
+	t.cache = nil
+	t.lock.Unlock()
+	if pool == nil {
+		return
+	}
+	for {
+		select {
+		case conn := <-pool:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
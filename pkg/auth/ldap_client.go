@@ -0,0 +1,550 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the subset of LDAPv3 (RFC 4511) needed to bind and search the users
+
+const (
+	berTagInteger     = 0x02
+	berTagOctetString = 0x04
+	berTagEnumerated  = 0x0a
+	berTagBoolean     = 0x01
+	berTagSequence    = 0x30
+	berTagSet         = 0x31
+
+	ldapBindRequest       = 0x60
+	ldapBindResponse      = 0x61
+	ldapUnbindRequest     = 0x42
+	ldapSearchRequest     = 0x63
+	ldapSearchResultEntry = 0x64
+	ldapSearchResultDone  = 0x65
+	ldapExtendedRequest   = 0x77
+	ldapExtendedResponse  = 0x78
+
+	ldapResultSuccess            = 0
+	ldapResultInvalidCredentials = 49
+
+	ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+)
+
+// ldapError is a non success result of the ldap server
+type ldapError struct {
+	Code    int
+	Message string
+}
+
+func (e *ldapError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+type berPacket struct {
+	tag  byte
+	data []byte
+}
+
+func berEncode(tag byte, content ...[]byte) []byte {
+	size := 0
+	for _, c := range content {
+		size += len(c)
+	}
+	buf := make([]byte, 0, size+6)
+	buf = append(buf, tag)
+	if size < 0x80 {
+		buf = append(buf, byte(size))
+	} else {
+		var length []byte
+		for n := size; n > 0; n >>= 8 {
+			length = append([]byte{byte(n)}, length...)
+		}
+		buf = append(buf, 0x80|byte(len(length)))
+		buf = append(buf, length...)
+	}
+	for _, c := range content {
+		buf = append(buf, c...)
+	}
+	return buf
+}
+
+func berInt(tag byte, v int) []byte {
+	var b []byte
+	for n := v; ; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+		if n < 0x80 && n >= -0x80 {
+			break
+		}
+	}
+	return berEncode(tag, b)
+}
+
+func berString(tag byte, s string) []byte {
+	return berEncode(tag, []byte(s))
+}
+
+func berBool(v bool) []byte {
+	if v {
+		return berEncode(berTagBoolean, []byte{0xff})
+	}
+	return berEncode(berTagBoolean, []byte{0})
+}
+
+// berRead reads a packet from the connection
+func berRead(r *bufio.Reader) (*berPacket, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	size, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length := int(size)
+	if size&0x80 != 0 {
+		n := int(size & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("ldap: invalid packet length")
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return &berPacket{tag: tag, data: data}, nil
+}
+
+// children parses the content of a constructed packet
+func (p *berPacket) children() ([]*berPacket, error) {
+	var packets []*berPacket
+	data := p.data
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("ldap: truncated packet")
+		}
+		tag, length, offset := data[0], int(data[1]), 2
+		if length&0x80 != 0 {
+			n := length & 0x7f
+			if n == 0 || n > 4 || len(data) < 2+n {
+				return nil, fmt.Errorf("ldap: invalid packet length")
+			}
+			length = 0
+			for _, b := range data[2 : 2+n] {
+				length = length<<8 | int(b)
+			}
+			offset += n
+		}
+		if length < 0 || len(data) < offset+length {
+			return nil, fmt.Errorf("ldap: truncated packet")
+		}
+		packets = append(packets, &berPacket{tag: tag, data: data[offset : offset+length]})
+		data = data[offset+length:]
+	}
+	return packets, nil
+}
+
+func (p *berPacket) int() int {
+	v := 0
+	for i, b := range p.data {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+// ldapResult reads the result code and the diagnostic message of a response
+func ldapResult(op *berPacket) error {
+	fields, err := op.children()
+	if err != nil {
+		return err
+	}
+	if len(fields) < 3 {
+		return fmt.Errorf("ldap: invalid result")
+	}
+	if code := fields[0].int(); code != ldapResultSuccess {
+		return &ldapError{Code: code, Message: string(fields[2].data)}
+	}
+	return nil
+}
+
+type ldapEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Get returns the values of the attribute, the names are case insensitive
+func (e *ldapEntry) Get(name string) []string {
+	return e.Attributes[strings.ToLower(name)]
+}
+
+type ldapConn struct {
+	url     string
+	conn    net.Conn
+	reader  *bufio.Reader
+	msgID   int
+	timeout time.Duration
+}
+
+// dialLDAP connects to ldap:// or ldaps:// url, the plain connections are upgraded by StartTLS if required
+func dialLDAP(rawURL string, startTLS bool, tlsConfig *tls.Config, timeout time.Duration) (*ldapConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid url: %s", err.Error())
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		conn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	default:
+		return nil, fmt.Errorf("ldap: unsupported url scheme [%s]", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ldapConn{url: rawURL, conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+	if startTLS && u.Scheme == "ldap" {
+		if err := c.startTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *ldapConn) startTLS(tlsConfig *tls.Config) error {
+	ops, err := c.request(berEncode(ldapExtendedRequest, berString(0x80, ldapStartTLSOID)), ldapExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := ldapResult(ops[0]); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, tlsConfig)
+	_ = tlsConn.SetDeadline(time.Now().Add(c.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// request sends the operation and reads the responses until the final one
+func (c *ldapConn) request(op []byte, final byte) ([]*berPacket, error) {
+	c.msgID++
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(berEncode(berTagSequence, berInt(berTagInteger, c.msgID), op)); err != nil {
+		return nil, err
+	}
+
+	var ops []*berPacket
+	for {
+		msg, err := berRead(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := msg.children()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < 2 || fields[0].int() != c.msgID {
+			continue
+		}
+		ops = append(ops, fields[1])
+		if fields[1].tag == final {
+			return ops, nil
+		}
+	}
+}
+
+// Bind authenticates the connection by simple bind, the empty dn and password bind anonymously
+func (c *ldapConn) Bind(dn, password string) error {
+	ops, err := c.request(berEncode(ldapBindRequest,
+		berInt(berTagInteger, 3),
+		berString(berTagOctetString, dn),
+		berString(0x80, password),
+	), ldapBindResponse)
+	if err != nil {
+		return err
+	}
+	return ldapResult(ops[len(ops)-1])
+}
+
+// Search searches the subtree of the base with the filter
+func (c *ldapConn) Search(base, filter string, attributes []string) ([]*ldapEntry, error) {
+	compiled, err := compileLDAPFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([][]byte, 0, len(attributes))
+	for _, attr := range attributes {
+		attrs = append(attrs, berString(berTagOctetString, attr))
+	}
+	ops, err := c.request(berEncode(ldapSearchRequest,
+		berString(berTagOctetString, base),
+		berInt(berTagEnumerated, 2), // wholeSubtree
+		berInt(berTagEnumerated, 0), // neverDerefAliases
+		berInt(berTagInteger, 0),
+		berInt(berTagInteger, int(c.timeout/time.Second)),
+		berBool(false),
+		compiled,
+		berEncode(berTagSequence, attrs...),
+	), ldapSearchResultDone)
+	if err != nil {
+		return nil, err
+	}
+	if err := ldapResult(ops[len(ops)-1]); err != nil {
+		return nil, err
+	}
+
+	var entries []*ldapEntry
+	for _, op := range ops {
+		if op.tag != ldapSearchResultEntry {
+			continue
+		}
+		entry, err := parseLDAPEntry(op)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func parseLDAPEntry(op *berPacket) (*ldapEntry, error) {
+	fields, err := op.children()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("ldap: invalid search entry")
+	}
+	entry := &ldapEntry{DN: string(fields[0].data), Attributes: make(map[string][]string)}
+	attrs, err := fields[1].children()
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		parts, err := attr.children()
+		if err != nil || len(parts) < 2 {
+			return nil, fmt.Errorf("ldap: invalid search entry attribute")
+		}
+		values, err := parts[1].children()
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(string(parts[0].data))
+		for _, v := range values {
+			entry.Attributes[name] = append(entry.Attributes[name], string(v.data))
+		}
+	}
+	return entry, nil
+}
+
+// Close unbinds and closes the connection
+func (c *ldapConn) Close() {
+	c.msgID++
+	_ = c.conn.SetDeadline(time.Now().Add(time.Second))
+	_, _ = c.conn.Write(berEncode(berTagSequence, berInt(berTagInteger, c.msgID), berEncode(ldapUnbindRequest)))
+	_ = c.conn.Close()
+}
+
+// compileLDAPFilter encodes the string filter (RFC 4515), like (&(objectClass=person)(uid=alice))
+func compileLDAPFilter(filter string) ([]byte, error) {
+	compiled, rest, err := parseLDAPFilter(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("ldap: invalid filter [%s]", filter)
+	}
+	return compiled, nil
+}
+
+func parseLDAPFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") || len(s) < 2 {
+		return nil, "", fmt.Errorf("ldap: invalid filter [%s]", s)
+	}
+	s = s[1:]
+	switch s[0] {
+	case '&', '|':
+		tag := byte(0xa0)
+		if s[0] == '|' {
+			tag = 0xa1
+		}
+		s = s[1:]
+		var children [][]byte
+		for strings.HasPrefix(s, "(") {
+			child, rest, err := parseLDAPFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			children = append(children, child)
+			s = rest
+		}
+		if !strings.HasPrefix(s, ")") {
+			return nil, "", fmt.Errorf("ldap: unclosed filter")
+		}
+		return berEncode(tag, children...), s[1:], nil
+	case '!':
+		child, rest, err := parseLDAPFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("ldap: unclosed filter")
+		}
+		return berEncode(0xa2, child), rest[1:], nil
+	default:
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return nil, "", fmt.Errorf("ldap: unclosed filter")
+		}
+		item, err := compileLDAPFilterItem(s[:end])
+		if err != nil {
+			return nil, "", err
+		}
+		return item, s[end+1:], nil
+	}
+}
+
+func compileLDAPFilterItem(item string) ([]byte, error) {
+	i := strings.IndexByte(item, '=')
+	if i <= 0 {
+		return nil, fmt.Errorf("ldap: invalid filter item [%s]", item)
+	}
+	attr, value, tag := item[:i], item[i+1:], byte(0xa3)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = 0xa5
+	case '<':
+		tag = 0xa6
+	case '~':
+		tag = 0xa8
+	}
+	if tag != 0xa3 {
+		attr = attr[:len(attr)-1]
+	}
+	if attr == "" {
+		return nil, fmt.Errorf("ldap: invalid filter item [%s]", item)
+	}
+
+	if tag == 0xa3 && value == "*" {
+		return berString(0x87, attr), nil
+	}
+	if tag == 0xa3 && strings.Contains(value, "*") {
+		parts := strings.Split(value, "*")
+		var subs [][]byte
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+			v, err := unescapeLDAPFilterValue(part)
+			if err != nil {
+				return nil, err
+			}
+			switch i {
+			case 0:
+				subs = append(subs, berString(0x80, v))
+			case len(parts) - 1:
+				subs = append(subs, berString(0x82, v))
+			default:
+				subs = append(subs, berString(0x81, v))
+			}
+		}
+		return berEncode(0xa4, berString(berTagOctetString, attr), berEncode(berTagSequence, subs...)), nil
+	}
+
+	v, err := unescapeLDAPFilterValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return berEncode(tag, berString(berTagOctetString, attr), berString(berTagOctetString, v)), nil
+}
+
+func unescapeLDAPFilterValue(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("ldap: invalid filter escape [%s]", s)
+		}
+		b, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("ldap: invalid filter escape [%s]", s)
+		}
+		sb.Write(b)
+		i += 2
+	}
+	return sb.String(), nil
+}
+
+// escapeLDAPFilterValue escapes the special characters of the value used in the filters
+func escapeLDAPFilterValue(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&sb, `\%02x`, c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,219 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"bufio"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+)
+
+type fakeLDAPEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// fakeLDAPServer serves the binds and the equality searches of the entries
+func fakeLDAPServer(t *testing.T, entries []fakeLDAPEntry, dials *int32) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	result := func(tag byte, code int) []byte {
+		return berEncode(tag, berInt(berTagEnumerated, code), berString(berTagOctetString, ""), berString(berTagOctetString, ""))
+	}
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := berRead(r)
+			if err != nil {
+				return
+			}
+			fields, _ := msg.children()
+			id := fields[0].int()
+			reply := func(op []byte) {
+				_, _ = conn.Write(berEncode(berTagSequence, berInt(berTagInteger, id), op))
+			}
+			op := fields[1]
+			params, _ := op.children()
+			switch op.tag {
+			case ldapUnbindRequest:
+				return
+			case ldapBindRequest:
+				dn, password := string(params[1].data), string(params[2].data)
+				code := ldapResultInvalidCredentials
+				if dn == "" && password == "" {
+					code = ldapResultSuccess
+				}
+				for _, e := range entries {
+					if e.dn == dn && e.password == password {
+						code = ldapResultSuccess
+					}
+				}
+				reply(result(ldapBindResponse, code))
+			case ldapSearchRequest:
+				filter := params[6]
+				assert.Equal(t, byte(0xa3), filter.tag)
+				ava, _ := filter.children()
+				attr, value := string(ava[0].data), string(ava[1].data)
+				for _, e := range entries {
+					if !hasLDAPValue(e.attributes[attr], value) {
+						continue
+					}
+					var attrs [][]byte
+					for name, values := range e.attributes {
+						var vals [][]byte
+						for _, v := range values {
+							vals = append(vals, berString(berTagOctetString, v))
+						}
+						attrs = append(attrs, berEncode(berTagSequence, berString(berTagOctetString, name), berEncode(berTagSet, vals...)))
+					}
+					reply(berEncode(ldapSearchResultEntry, berString(berTagOctetString, e.dn), berEncode(berTagSequence, attrs...)))
+				}
+				reply(result(ldapSearchResultDone, ldapResultSuccess))
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(dials, 1)
+			go serve(conn)
+		}
+	}()
+	return "ldap://" + ln.Addr().String()
+}
+
+func hasLDAPValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVerifyLDAPCredentials(t *testing.T) {
+	old := config.Global.LDAP
+	defer func() {
+		config.Global.LDAP = old
+		ZINC_LDAP.Reset()
+	}()
+
+	var dials int32
+	url := fakeLDAPServer(t, []fakeLDAPEntry{
+		{dn: "cn=zinc,dc=example,dc=org", password: "service"},
+		{dn: "uid=alice,ou=people,dc=example,dc=org", password: "alicepass", attributes: map[string][]string{
+			"uid": {"alice"}, "cn": {"Alice"}, "memberOf": {"cn=ops,ou=groups,dc=example,dc=org"},
+		}},
+		{dn: "uid=bob,ou=people,dc=example,dc=org", password: "bobpass", attributes: map[string][]string{
+			"uid": {"bob"}, "cn": {"Bob"}, "memberOf": {"cn=sales,ou=groups,dc=example,dc=org"},
+		}},
+		{dn: "cn=dev,ou=groups,dc=example,dc=org", attributes: map[string][]string{
+			"member": {"uid=bob,ou=people,dc=example,dc=org"},
+		}},
+	}, &dials)
+
+	config.Global.LDAP = old
+	config.Global.LDAP.URL = url
+	config.Global.LDAP.BindDN = "cn=zinc,dc=example,dc=org"
+	config.Global.LDAP.BindPassword = "service"
+	config.Global.LDAP.SearchBase = "dc=example,dc=org"
+	config.Global.LDAP.UserFilter = "(uid=%s)"
+	config.Global.LDAP.NameAttribute = "cn"
+	config.Global.LDAP.GroupAttribute = "memberOf"
+	config.Global.LDAP.RoleMapping = "cn=ops,ou=groups,dc=example,dc=org:admin;dev:reader"
+	config.Global.LDAP.PoolSize = 2
+	config.Global.LDAP.Timeout = 5 * time.Second
+	config.Global.LDAP.CacheTTL = 0
+	ZINC_LDAP.Reset()
+
+	t.Run("group attribute", func(t *testing.T) {
+		user, ok := VerifyCredentials("alice", "alicepass")
+		assert.True(t, ok)
+		assert.Equal(t, "alice", user.ID)
+		assert.Equal(t, "Alice", user.Name)
+		assert.Equal(t, "admin", user.Role)
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		_, ok := VerifyCredentials("alice", "wrong")
+		assert.False(t, ok)
+		_, ok = VerifyCredentials("alice", "")
+		assert.False(t, ok)
+		_, ok = VerifyCredentials("nobody", "alicepass")
+		assert.False(t, ok)
+	})
+
+	t.Run("no mapped role", func(t *testing.T) {
+		_, ok := VerifyCredentials("bob", "bobpass")
+		assert.False(t, ok)
+	})
+
+	t.Run("group filter", func(t *testing.T) {
+		config.Global.LDAP.GroupFilter = "(member=%s)"
+		defer func() { config.Global.LDAP.GroupFilter = "" }()
+		user, ok := VerifyCredentials("bob", "bobpass")
+		assert.True(t, ok)
+		assert.Equal(t, "reader", user.Role)
+	})
+
+	t.Run("connection pool", func(t *testing.T) {
+		before := atomic.LoadInt32(&dials)
+		for i := 0; i < 3; i++ {
+			_, ok := VerifyCredentials("alice", "alicepass")
+			assert.True(t, ok)
+		}
+		assert.Equal(t, before, atomic.LoadInt32(&dials))
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		config.Global.LDAP.CacheTTL = time.Minute
+		_, ok := VerifyCredentials("alice", "alicepass")
+		assert.True(t, ok)
+		config.Global.LDAP.URL = "ldap://127.0.0.1:1"
+		_, ok = VerifyCredentials("alice", "alicepass")
+		assert.True(t, ok)
+		_, ok = VerifyCredentials("alice", "wrong")
+		assert.False(t, ok)
+	})
+}
+
+func TestCompileLDAPFilter(t *testing.T) {
+	for _, filter := range []string{
+		"(uid=alice)",
+		"(&(objectClass=person)(|(uid=a*b*c)(mail=*))(!(cn>=x)))",
+		`(cn=a\2ab)`,
+	} {
+		_, err := compileLDAPFilter(filter)
+		assert.NoError(t, err, filter)
+	}
+	for _, filter := range []string{"uid=alice", "(uid=alice", "(&(uid=a)", "(=a)", `(cn=a\2)`} {
+		_, err := compileLDAPFilter(filter)
+		assert.Error(t, err, filter)
+	}
+	assert.Equal(t, `a\2a\28b\29\5c`, escapeLDAPFilterValue(`a*(b)\`))
+}
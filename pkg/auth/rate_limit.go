@@ -0,0 +1,119 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// The rate limit buckets, a heavy importer doesn't use the tokens of the queries
+const (
+	RateLimitSearch = "search"
+	RateLimitIndex  = "index"
+)
+
+// rateLimitIdle removes the buckets unused for a while, they are full again by then
+const rateLimitIdle = 10 * time.Minute
+
+var ZINC_RATE_LIMITER = &rateLimiter{buckets: map[string]*tokenBucket{}}
+
+type rateLimiter struct {
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	lock      sync.Mutex
+}
+
+type tokenBucket struct {
+	limit  meta.RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// Allow takes a token from the bucket of the principal, a user or an api key, with the limit of its role.
+// It returns the wait until a token is available when the bucket is empty
+func (l *rateLimiter) Allow(principal, roleID, bucket string) (bool, time.Duration) {
+	limit := RoleRateLimit(roleID, bucket)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Rate
+	}
+
+	now := time.Now()
+	key := bucket + "/" + principal
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.prune(now)
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * float64(limit.Rate)
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / float64(limit.Rate) * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Reset removes all the buckets
+func (l *rateLimiter) Reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.buckets = map[string]*tokenBucket{}
+}
+
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < rateLimitIdle {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > rateLimitIdle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RoleRateLimit returns the limit of the bucket for the role, the limit of the role overrides the global one
+func RoleRateLimit(roleID, bucket string) meta.RateLimit {
+	if roleLimit, ok := ZINC_CACHED_ROLE_RATE_LIMITS.Get(strings.ToLower(roleID)); ok && roleLimit != nil {
+		switch {
+		case bucket == RateLimitSearch && roleLimit.Search != nil:
+			return *roleLimit.Search
+		case bucket == RateLimitIndex && roleLimit.Index != nil:
+			return *roleLimit.Index
+		}
+	}
+	switch bucket {
+	case RateLimitSearch:
+		return meta.RateLimit{Rate: config.Global.RateLimit.SearchRate, Burst: config.Global.RateLimit.SearchBurst}
+	case RateLimitIndex:
+		return meta.RateLimit{Rate: config.Global.RateLimit.IndexRate, Burst: config.Global.RateLimit.IndexBurst}
+	}
+	return meta.RateLimit{}
+}
@@ -0,0 +1,79 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestRateLimiter(t *testing.T) {
+	old := config.Global.RateLimit
+	defer func() {
+		config.Global.RateLimit = old
+		ZINC_RATE_LIMITER.Reset()
+	}()
+	config.Global.RateLimit.SearchRate = 1
+	config.Global.RateLimit.SearchBurst = 2
+	config.Global.RateLimit.IndexRate = 0
+
+	_, err := SaveRole(&meta.Role{
+		ID:        "limitedrole",
+		Name:      "Limited Role",
+		RateLimit: &meta.RoleRateLimit{Search: &meta.RateLimit{Rate: 1}, Index: &meta.RateLimit{Rate: 1}},
+	})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, DeleteRole("limitedrole"))
+	}()
+
+	t.Run("global limit", func(t *testing.T) {
+		ok, _ := ZINC_RATE_LIMITER.Allow("user:a", "admin", RateLimitSearch)
+		assert.True(t, ok)
+		ok, _ = ZINC_RATE_LIMITER.Allow("user:a", "admin", RateLimitSearch)
+		assert.True(t, ok)
+		ok, wait := ZINC_RATE_LIMITER.Allow("user:a", "admin", RateLimitSearch)
+		assert.False(t, ok)
+		assert.Greater(t, wait.Seconds(), 0.0)
+
+		ok, _ = ZINC_RATE_LIMITER.Allow("user:b", "admin", RateLimitSearch)
+		assert.True(t, ok, "the principals have their own buckets")
+		for i := 0; i < 5; i++ {
+			ok, _ = ZINC_RATE_LIMITER.Allow("user:a", "admin", RateLimitIndex)
+			assert.True(t, ok, "the index requests are not limited")
+		}
+	})
+
+	t.Run("role limit", func(t *testing.T) {
+		ok, _ := ZINC_RATE_LIMITER.Allow("user:c", "limitedrole", RateLimitIndex)
+		assert.True(t, ok)
+		ok, _ = ZINC_RATE_LIMITER.Allow("user:c", "limitedrole", RateLimitIndex)
+		assert.False(t, ok)
+		ok, _ = ZINC_RATE_LIMITER.Allow("user:c", "limitedrole", RateLimitSearch)
+		assert.True(t, ok, "the search bucket is separated from the index bucket")
+		ok, _ = ZINC_RATE_LIMITER.Allow("user:c", "limitedrole", RateLimitSearch)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid role limit", func(t *testing.T) {
+		_, err := SaveRole(&meta.Role{ID: "invalidlimit", RateLimit: &meta.RoleRateLimit{Search: &meta.RateLimit{Rate: -1}}})
+		assert.Error(t, err)
+	})
+}
@@ -16,6 +16,7 @@
 package auth
 
 import (
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 var ZINC_CACHED_PERMISSIONS = cachedPermissions{pm: map[string]map[string]struct{}{}}
@@ -51,6 +53,58 @@ func (t *cachedPermissions) Delete(id string) {
 	delete(t.pm, id)
 }
 
+var ZINC_CACHED_ROLE_RATE_LIMITS = cachedRoleRateLimits{limits: map[string]*meta.RoleRateLimit{}}
+
+type cachedRoleRateLimits struct {
+	limits map[string]*meta.RoleRateLimit
+	lock   sync.RWMutex
+}
+
+func (t *cachedRoleRateLimits) Get(id string) (*meta.RoleRateLimit, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	limit, ok := t.limits[id]
+	return limit, ok
+}
+
+func (t *cachedRoleRateLimits) Set(id string, limit *meta.RoleRateLimit) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.limits[id] = limit
+}
+
+func (t *cachedRoleRateLimits) Delete(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.limits, id)
+}
+
+var ZINC_CACHED_ROLE_INDICES = cachedRoleIndices{indices: map[string][]*meta.RoleIndices{}}
+
+type cachedRoleIndices struct {
+	indices map[string][]*meta.RoleIndices
+	lock    sync.RWMutex
+}
+
+func (t *cachedRoleIndices) Get(id string) ([]*meta.RoleIndices, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	indices, ok := t.indices[id]
+	return indices, ok
+}
+
+func (t *cachedRoleIndices) Set(id string, indices []*meta.RoleIndices) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.indices[id] = indices
+}
+
+func (t *cachedRoleIndices) Delete(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.indices, id)
+}
+
 func strArrayToMap(ss []string) map[string]struct{} {
 	m := map[string]struct{}{}
 	for _, v := range ss {
@@ -59,11 +113,28 @@ func strArrayToMap(ss []string) map[string]struct{} {
 	return m
 }
 
+// CreateRole creates or updates the role, the index privileges and the rate limits of an existing role are kept
 func CreateRole(id, name string, permissions []string) (*meta.Role, error) {
-	id = strings.ToLower(id)
+	role := &meta.Role{ID: id, Name: name, Permission: permissions}
+	if existingRole, ok, _ := GetRole(strings.ToLower(id)); ok {
+		role.Indices = existingRole.Indices
+		role.RateLimit = existingRole.RateLimit
+	}
+	return SaveRole(role)
+}
+
+// SaveRole creates or updates the role with its permissions and index privileges
+func SaveRole(role *meta.Role) (*meta.Role, error) {
+	id := strings.ToLower(role.ID)
 	if id == "admin" {
 		return nil, errors.New(errors.ErrorTypeInvalidArgument, "role id admin not allowed")
 	}
+	if err := validateRoleIndices(role.Indices); err != nil {
+		return nil, err
+	}
+	if err := validateRoleRateLimit(role.RateLimit); err != nil {
+		return nil, err
+	}
 	var newRole *meta.Role
 	existingRole, roleExists, err := GetRole(id)
 	if err != nil && !errors.Is(err, errors.ErrKeyNotFound) {
@@ -72,14 +143,18 @@ func CreateRole(id, name string, permissions []string) (*meta.Role, error) {
 
 	if roleExists {
 		newRole = existingRole
-		newRole.Name = name
-		newRole.Permission = permissions
+		newRole.Name = role.Name
+		newRole.Permission = role.Permission
+		newRole.Indices = role.Indices
+		newRole.RateLimit = role.RateLimit
 		newRole.UpdatedAt = time.Now()
 	} else {
 		newRole = &meta.Role{
 			ID:         id,
-			Name:       name,
-			Permission: permissions,
+			Name:       role.Name,
+			Permission: role.Permission,
+			Indices:    role.Indices,
+			RateLimit:  role.RateLimit,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
@@ -90,11 +165,77 @@ func CreateRole(id, name string, permissions []string) (*meta.Role, error) {
 		return nil, err
 	}
 
-	ZINC_CACHED_PERMISSIONS.Set(newRole.ID, strArrayToMap(permissions))
+	ZINC_CACHED_PERMISSIONS.Set(newRole.ID, strArrayToMap(newRole.Permission))
+	ZINC_CACHED_ROLE_INDICES.Set(newRole.ID, newRole.Indices)
+	ZINC_CACHED_ROLE_RATE_LIMITS.Set(newRole.ID, newRole.RateLimit)
 
 	return newRole, nil
 }
 
+func validateRoleIndices(indices []*meta.RoleIndices) error {
+	for _, privilege := range indices {
+		if privilege == nil || len(privilege.Names) == 0 {
+			return errors.New(errors.ErrorTypeInvalidArgument, "role indices names are required")
+		}
+		if err := validateRoleQuery(privilege.Query); err != nil {
+			return err
+		}
+		patterns := append(append([]string{}, privilege.Names...), privilege.Privileges...)
+		if fs := privilege.FieldSecurity; fs != nil {
+			if len(fs.Grant) == 0 {
+				return errors.New(errors.ErrorTypeInvalidArgument, "role indices field_security grant is required")
+			}
+			patterns = append(append(patterns, fs.Grant...), fs.Except...)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.New(errors.ErrorTypeInvalidArgument, "role indices invalid pattern ["+pattern+"]")
+			}
+		}
+	}
+	return nil
+}
+
+func validateRoleRateLimit(limit *meta.RoleRateLimit) error {
+	if limit == nil {
+		return nil
+	}
+	for _, v := range []*meta.RateLimit{limit.Search, limit.Index} {
+		if v != nil && (v.Rate < 0 || v.Burst < 0) {
+			return errors.New(errors.ErrorTypeInvalidArgument, "role rate_limit rate and burst should not be negative")
+		}
+	}
+	return nil
+}
+
+// validateRoleQuery checks the document level security query is an object or a json string of an object
+func validateRoleQuery(query interface{}) error {
+	if query == nil {
+		return nil
+	}
+	if s, ok := query.(string); ok {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil || len(v) == 0 {
+			return errors.New(errors.ErrorTypeInvalidArgument, "role indices query should be a query object")
+		}
+		return nil
+	}
+	if v, ok := query.(map[string]interface{}); !ok || len(v) == 0 {
+		return errors.New(errors.ErrorTypeInvalidArgument, "role indices query should be a query object")
+	}
+	return nil
+}
+
+// RoleIndices returns the index privileges of the role, nil means the role can access all the documents
+func RoleIndices(roleID string) []*meta.RoleIndices {
+	roleID = strings.ToLower(roleID)
+	if roleID == "admin" {
+		return nil
+	}
+	indices, _ := ZINC_CACHED_ROLE_INDICES.Get(roleID)
+	return indices
+}
+
 func GetRoles() ([]*meta.Role, error) {
 	return metadata.Role.List(0, 0)
 }
@@ -117,5 +258,7 @@ func SetRole(id string, role meta.Role) error {
 func DeleteRole(id string) error {
 	id = strings.ToLower(id)
 	ZINC_CACHED_PERMISSIONS.Delete(id)
+	ZINC_CACHED_ROLE_INDICES.Delete(id)
+	ZINC_CACHED_ROLE_RATE_LIMITS.Delete(id)
 	return metadata.Role.Delete(id)
 }
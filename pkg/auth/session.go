@@ -0,0 +1,219 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/ider"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+)
+
+var ZINC_CACHED_SESSIONS = cachedSessions{sessions: map[string]*meta.Session{}}
+
+type cachedSessions struct {
+	sessions map[string]*meta.Session
+	lock     sync.RWMutex
+}
+
+func (t *cachedSessions) Get(id string) (*meta.Session, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	session, ok := t.sessions[id]
+	return session, ok
+}
+
+func (t *cachedSessions) Set(id string, session *meta.Session) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.sessions[id] = session
+}
+
+func (t *cachedSessions) Delete(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.sessions, id)
+}
+
+func (t *cachedSessions) ListByUser(userID string) []*meta.Session {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	sessions := make([]*meta.Session, 0)
+	for _, session := range t.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// initSessionCache loads the sessions, the expired sessions are removed
+func initSessionCache() error {
+	sessions, err := metadata.Session.List(0, 0)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, session := range sessions {
+		if now.After(session.ExpiresAt) {
+			_ = metadata.Session.Delete(session.ID)
+			continue
+		}
+		ZINC_CACHED_SESSIONS.Set(session.ID, session)
+	}
+	return nil
+}
+
+var (
+	errInvalidSessionToken = errors.New(errors.ErrorTypeInvalidArgument, "invalid session token")
+	errSessionTokenExpired = errors.New(errors.ErrorTypeInvalidArgument, "session token expired")
+)
+
+// CreateSession starts a session of the authenticated user,
+// it returns the access token of the Authorization: Bearer header and the refresh token
+func CreateSession(user *meta.User) (*meta.Session, string, string, error) {
+	accessSecret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", "", err
+	}
+	refreshSecret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", "", err
+	}
+	now := time.Now()
+	session := &meta.Session{
+		ID:              ider.Generate(),
+		UserID:          user.ID,
+		Name:            user.Name,
+		Role:            user.Role,
+		AccessHash:      hashAPIKeySecret(accessSecret),
+		RefreshHash:     hashAPIKeySecret(refreshSecret),
+		AccessExpiresAt: now.Add(config.Global.Session.AccessTokenTTL),
+		ExpiresAt:       now.Add(config.Global.Session.RefreshTokenTTL),
+		CreatedAt:       now,
+	}
+	if err := metadata.Session.Set(session.ID, *session); err != nil {
+		return nil, "", "", err
+	}
+	ZINC_CACHED_SESSIONS.Set(session.ID, session)
+	return session, session.ID + "." + accessSecret, session.ID + "." + refreshSecret, nil
+}
+
+// RefreshSession exchanges the refresh token for a new access token, the previous access token is revoked
+func RefreshSession(refreshToken string) (*meta.Session, string, error) {
+	session, secret, err := lookupSession(refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if !matchSessionSecret(secret, session.RefreshHash) {
+		return nil, "", errInvalidSessionToken
+	}
+	accessSecret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+	refreshed := *session
+	refreshed.AccessHash = hashAPIKeySecret(accessSecret)
+	refreshed.AccessExpiresAt = time.Now().Add(config.Global.Session.AccessTokenTTL)
+	if refreshed.AccessExpiresAt.After(refreshed.ExpiresAt) {
+		refreshed.AccessExpiresAt = refreshed.ExpiresAt
+	}
+	if err := metadata.Session.Set(refreshed.ID, refreshed); err != nil {
+		return nil, "", err
+	}
+	ZINC_CACHED_SESSIONS.Set(refreshed.ID, &refreshed)
+	return &refreshed, refreshed.ID + "." + accessSecret, nil
+}
+
+// VerifySessionToken verifies the access token of a session, the user gets its current role
+// when it is a zinc user, the users authenticated by ldap keep the role of the login
+func VerifySessionToken(accessToken string) (*meta.User, error) {
+	session, secret, err := lookupSession(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if !matchSessionSecret(secret, session.AccessHash) {
+		return nil, errInvalidSessionToken
+	}
+	if time.Now().After(session.AccessExpiresAt) {
+		return nil, errSessionTokenExpired
+	}
+	if user, ok := ZINC_CACHED_USERS.Get(session.UserID); ok {
+		return user, nil
+	}
+	return &meta.User{ID: session.UserID, Name: session.Name, Role: session.Role}, nil
+}
+
+// RevokeSession ends the session of the access or refresh token
+func RevokeSession(token string) error {
+	session, secret, err := lookupSession(token)
+	if err != nil {
+		return err
+	}
+	if !matchSessionSecret(secret, session.AccessHash) && !matchSessionSecret(secret, session.RefreshHash) {
+		return errInvalidSessionToken
+	}
+	return deleteSession(session.ID)
+}
+
+// RevokeUserSessions ends all the sessions of the user
+func RevokeUserSessions(userID string) error {
+	for _, session := range ZINC_CACHED_SESSIONS.ListByUser(strings.ToLower(userID)) {
+		if err := deleteSession(session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsSessionToken returns whether the bearer token is a session token instead of a JWT,
+// a session token is the session id and the secret separated by a dot
+func IsSessionToken(token string) bool {
+	return strings.Count(token, ".") == 1
+}
+
+// lookupSession returns the session of the token and the secret of the token,
+// an expired session is removed
+func lookupSession(token string) (*meta.Session, string, error) {
+	id, secret, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || id == "" || secret == "" {
+		return nil, "", errInvalidSessionToken
+	}
+	session, ok := ZINC_CACHED_SESSIONS.Get(id)
+	if !ok {
+		return nil, "", errInvalidSessionToken
+	}
+	if time.Now().After(session.ExpiresAt) {
+		_ = deleteSession(session.ID)
+		return nil, "", errSessionTokenExpired
+	}
+	return session, secret, nil
+}
+
+func matchSessionSecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(hash)) == 1
+}
+
+func deleteSession(id string) error {
+	ZINC_CACHED_SESSIONS.Delete(id)
+	return metadata.Session.Delete(id)
+}
@@ -0,0 +1,107 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestSession(t *testing.T) {
+	user := &meta.User{ID: "sessionuser", Name: "Session User", Role: "admin"}
+
+	t.Run("verify and refresh", func(t *testing.T) {
+		session, accessToken, refreshToken, err := CreateSession(user)
+		assert.NoError(t, err)
+		assert.True(t, IsSessionToken(accessToken))
+
+		got, err := VerifySessionToken(accessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, "sessionuser", got.ID)
+		assert.Equal(t, "admin", got.Role)
+
+		_, err = VerifySessionToken(refreshToken)
+		assert.Error(t, err)
+
+		refreshed, newAccessToken, err := RefreshSession(refreshToken)
+		assert.NoError(t, err)
+		assert.Equal(t, session.ID, refreshed.ID)
+		_, err = VerifySessionToken(accessToken)
+		assert.Error(t, err)
+		_, err = VerifySessionToken(newAccessToken)
+		assert.NoError(t, err)
+
+		_, _, err = RefreshSession(newAccessToken)
+		assert.Error(t, err)
+
+		assert.NoError(t, RevokeSession(refreshToken))
+		_, err = VerifySessionToken(newAccessToken)
+		assert.Error(t, err)
+		assert.Error(t, RevokeSession(refreshToken))
+	})
+
+	t.Run("expired access token", func(t *testing.T) {
+		old := config.Global.Session
+		defer func() { config.Global.Session = old }()
+		config.Global.Session.AccessTokenTTL = -time.Second
+
+		_, accessToken, refreshToken, err := CreateSession(user)
+		assert.NoError(t, err)
+		_, err = VerifySessionToken(accessToken)
+		assert.ErrorIs(t, err, errSessionTokenExpired)
+
+		config.Global.Session.AccessTokenTTL = time.Hour
+		_, accessToken, err = RefreshSession(refreshToken)
+		assert.NoError(t, err)
+		_, err = VerifySessionToken(accessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		old := config.Global.Session
+		defer func() { config.Global.Session = old }()
+		config.Global.Session.RefreshTokenTTL = -time.Second
+
+		session, accessToken, refreshToken, err := CreateSession(user)
+		assert.NoError(t, err)
+		_, err = VerifySessionToken(accessToken)
+		assert.Error(t, err)
+		_, _, err = RefreshSession(refreshToken)
+		assert.Error(t, err)
+		_, ok := ZINC_CACHED_SESSIONS.Get(session.ID)
+		assert.False(t, ok)
+	})
+
+	t.Run("revoke user sessions", func(t *testing.T) {
+		_, accessToken, _, err := CreateSession(user)
+		assert.NoError(t, err)
+		assert.NoError(t, RevokeUserSessions(user.ID))
+		_, err = VerifySessionToken(accessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := VerifySessionToken("invalid")
+		assert.Error(t, err)
+		_, err = VerifySessionToken("unknown.secret")
+		assert.Error(t, err)
+	})
+}
@@ -26,6 +26,12 @@ const (
 	BooleanValuesSource
 )
 
+// ValueSource is the source of the aggregated values, it also implements
+// the bluge value source interface of the value type, e.g. search.TextValueSource
+type ValueSource interface {
+	Fields() []string
+}
+
 type SearchAggregation interface {
 	AddAggregation(name string, aggregation search.Aggregation)
 }
@@ -16,6 +16,7 @@
 package aggregation
 
 import (
+	"context"
 	"math"
 	"sort"
 	"strconv"
@@ -26,6 +27,7 @@ import (
 )
 
 type DateHistogramAggregation struct {
+	ctx              context.Context
 	src              search.FieldSource
 	size             int
 	calendarInterval string
@@ -58,6 +60,7 @@ func NewDateHistogramAggregation(
 	size int,
 ) *DateHistogramAggregation {
 	rv := &DateHistogramAggregation{
+		ctx:              context.Background(),
 		src:              field,
 		size:             size,
 		calendarInterval: calendarInterval,
@@ -88,6 +91,7 @@ func (t *DateHistogramAggregation) Fields() []string {
 
 func (t *DateHistogramAggregation) Calculator() search.Calculator {
 	return &DateHistogramCalculator{
+		ctx:              t.ctx,
 		src:              t.src,
 		size:             t.size,
 		calendarInterval: t.calendarInterval,
@@ -111,7 +115,14 @@ func (t *DateHistogramAggregation) AddAggregation(name string, aggregation searc
 	t.aggregations[name] = aggregation
 }
 
+// SetContext sets the context of the search, the calculators stop merging
+// and creating buckets when it is cancelled
+func (t *DateHistogramAggregation) SetContext(ctx context.Context) {
+	t.ctx = ctx
+}
+
 type DateHistogramCalculator struct {
+	ctx              context.Context
 	src              interface{}
 	size             int
 	calendarInterval string
@@ -167,6 +178,9 @@ func (a *DateHistogramCalculator) Merge(other search.Calculator) {
 		// now, walk all of the other buckets
 		// if we have a local match, merge otherwise append
 		for i := range other.bucketsList {
+			if a.ctx.Err() != nil {
+				break // the search is cancelled, keep the buckets merged so far
+			}
 			var foundLocal bool
 			for j := range a.bucketsList {
 				if other.bucketsList[i].Name() == a.bucketsList[j].Name() {
@@ -203,7 +217,7 @@ func (a *DateHistogramCalculator) Finish() {
 	// Replenish bucket
 	if a.minDocCount == 0 {
 		if a.calendarInterval != "" {
-			for value := a.minValue; value < a.maxValue; {
+			for value := a.minValue; value < a.maxValue && a.ctx.Err() == nil; {
 				termStr := a.bucketKey(value)
 				if _, ok := a.bucketsMap[termStr]; !ok {
 					a.bucketsList = append(a.bucketsList, search.NewBucket(termStr, a.aggregations))
@@ -224,7 +238,7 @@ func (a *DateHistogramCalculator) Finish() {
 				value = t.UnixNano()
 			}
 		} else {
-			for value := a.minValue; value < a.maxValue; value += a.fixedInterval {
+			for value := a.minValue; value < a.maxValue && a.ctx.Err() == nil; value += a.fixedInterval {
 				termStr := a.bucketKey(value)
 				if _, ok := a.bucketsMap[termStr]; !ok {
 					a.bucketsList = append(a.bucketsList, search.NewBucket(termStr, a.aggregations))
@@ -16,6 +16,7 @@
 package aggregation
 
 import (
+	"context"
 	"math"
 	"sort"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 )
 
 type HistogramAggregation struct {
+	ctx         context.Context
 	src         search.FieldSource
 	size        int
 	interval    float64
@@ -58,6 +60,7 @@ func NewHistogramAggregation(
 	size int,
 ) *HistogramAggregation {
 	rv := &HistogramAggregation{
+		ctx:            context.Background(),
 		src:            field,
 		size:           size,
 		interval:       interval,
@@ -86,6 +89,7 @@ func (t *HistogramAggregation) Fields() []string {
 
 func (t *HistogramAggregation) Calculator() search.Calculator {
 	return &HistogramCalculator{
+		ctx:            t.ctx,
 		src:            t.src,
 		size:           t.size,
 		interval:       t.interval,
@@ -107,7 +111,14 @@ func (t *HistogramAggregation) AddAggregation(name string, aggregation search.Ag
 	t.aggregations[name] = aggregation
 }
 
+// SetContext sets the context of the search, the calculators stop merging
+// and creating buckets when it is cancelled
+func (t *HistogramAggregation) SetContext(ctx context.Context) {
+	t.ctx = ctx
+}
+
 type HistogramCalculator struct {
+	ctx         context.Context
 	src         interface{}
 	size        int
 	interval    float64
@@ -161,6 +172,9 @@ func (a *HistogramCalculator) Merge(other search.Calculator) {
 		// now, walk all of the other buckets
 		// if we have a local match, merge otherwise append
 		for i := range other.bucketsList {
+			if a.ctx.Err() != nil {
+				break // the search is cancelled, keep the buckets merged so far
+			}
 			var foundLocal bool
 			for j := range a.bucketsList {
 				if other.bucketsList[i].Name() == a.bucketsList[j].Name() {
@@ -194,7 +208,7 @@ func (a *HistogramCalculator) Finish() {
 	}
 	// check bucket
 	if a.minDocCount == 0 {
-		for value := a.minValue; value < a.maxValue; value += a.interval {
+		for value := a.minValue; value < a.maxValue && a.ctx.Err() == nil; value += a.interval {
 			termStr := a.bucketKey(value)
 			if _, ok := a.bucketsMap[termStr]; !ok {
 				a.bucketsList = append(a.bucketsList, search.NewBucket(termStr, a.aggregations))
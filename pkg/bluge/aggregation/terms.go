@@ -16,6 +16,7 @@
 package aggregation
 
 import (
+	"context"
 	"sort"
 	"strconv"
 
@@ -24,7 +25,8 @@ import (
 )
 
 type TermsAggregation struct {
-	src     search.FieldSource
+	ctx     context.Context
+	src     ValueSource
 	srcType int
 	size    int
 
@@ -38,8 +40,9 @@ type TermsAggregation struct {
 // NewTermsAggregation returns a termsAggregation
 // field use to set the field use to terms aggregation
 // valueType use to set the value type, can be diy.TextValueSource / diy.TextValuesSource / diy.NumericValueSource / diy.NumericValuesSource
-func NewTermsAggregation(field search.FieldSource, valueType int, size int) *TermsAggregation {
+func NewTermsAggregation(field ValueSource, valueType int, size int) *TermsAggregation {
 	rv := &TermsAggregation{
+		ctx:     context.Background(),
 		src:     field,
 		srcType: valueType,
 		size:    size,
@@ -64,6 +67,7 @@ func (t *TermsAggregation) Fields() []string {
 
 func (t *TermsAggregation) Calculator() search.Calculator {
 	return &TermsCalculator{
+		ctx:          t.ctx,
 		src:          t.src,
 		srcType:      t.srcType,
 		size:         t.size,
@@ -79,7 +83,13 @@ func (t *TermsAggregation) AddAggregation(name string, aggregation search.Aggreg
 	t.aggregations[name] = aggregation
 }
 
+// SetContext sets the context of the search, the calculators stop merging buckets when it is cancelled
+func (t *TermsAggregation) SetContext(ctx context.Context) {
+	t.ctx = ctx
+}
+
 type TermsCalculator struct {
+	ctx     context.Context
 	src     interface{}
 	srcType int
 	size    int
@@ -229,6 +239,9 @@ func (a *TermsCalculator) Merge(other search.Calculator) {
 		// now, walk all of the other buckets
 		// if we have a local match, merge otherwise append
 		for i := range other.bucketsList {
+			if a.ctx.Err() != nil {
+				break // the search is cancelled, keep the buckets merged so far
+			}
 			var foundLocal bool
 			for j := range a.bucketsList {
 				if other.bucketsList[i].Name() == a.bucketsList[j].Name() {
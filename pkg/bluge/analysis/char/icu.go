@@ -0,0 +1,32 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package char
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+)
+
+type ICUNormalizerCharFilter struct {
+	normalizer *token.ICUNormalizer
+}
+
+func NewICUNormalizerCharFilter(normalizer *token.ICUNormalizer) *ICUNormalizerCharFilter {
+	return &ICUNormalizerCharFilter{normalizer: normalizer}
+}
+
+func (t *ICUNormalizerCharFilter) Filter(input []byte) []byte {
+	return t.normalizer.Normalize(input)
+}
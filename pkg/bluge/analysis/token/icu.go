@@ -0,0 +1,117 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/blugelabs/bluge/analysis"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ICUNormalizer normalizes the text to the unicode form like the icu normalizer,
+// the nfkc_cf form is the nfkc form with the case folding
+type ICUNormalizer struct {
+	form     norm.Form
+	caseFold bool
+}
+
+// NewICUNormalizer returns the normalizer of the name nfc, nfkc or nfkc_cf,
+// the mode decompose uses the decomposed form instead
+func NewICUNormalizer(name, mode string) (*ICUNormalizer, error) {
+	n := &ICUNormalizer{}
+	name, mode = strings.ToLower(name), strings.ToLower(mode)
+	if name == "" {
+		name = "nfkc_cf"
+	}
+	if mode == "" {
+		mode = "compose"
+	}
+	if mode != "compose" && mode != "decompose" {
+		return nil, fmt.Errorf("mode [%s] is not supported", mode)
+	}
+	switch name {
+	case "nfc":
+		n.form = norm.NFC
+		if mode == "decompose" {
+			n.form = norm.NFD
+		}
+	case "nfkc", "nfkc_cf":
+		n.form = norm.NFKC
+		if mode == "decompose" {
+			n.form = norm.NFKD
+		}
+		n.caseFold = name == "nfkc_cf"
+	default:
+		return nil, fmt.Errorf("name [%s] is not supported", name)
+	}
+	return n, nil
+}
+
+func (n *ICUNormalizer) Normalize(input []byte) []byte {
+	if n.caseFold {
+		// the caser is stateful, so it can not be shared between goroutines
+		input = cases.Fold().Bytes(n.form.Bytes(input))
+	}
+	return n.form.Bytes(input)
+}
+
+type ICUNormalizerTokenFilter struct {
+	normalizer *ICUNormalizer
+}
+
+func NewICUNormalizerTokenFilter(normalizer *ICUNormalizer) *ICUNormalizerTokenFilter {
+	return &ICUNormalizerTokenFilter{normalizer: normalizer}
+}
+
+func (t *ICUNormalizerTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		token.Term = t.normalizer.Normalize(token.Term)
+	}
+
+	return input
+}
+
+// ICUFoldingTokenFilter folds the terms like the icu folding,
+// it removes the accents, folds the case and normalizes the terms to the nfkc form
+type ICUFoldingTokenFilter struct{}
+
+func NewICUFoldingTokenFilter() *ICUFoldingTokenFilter {
+	return &ICUFoldingTokenFilter{}
+}
+
+func (t *ICUFoldingTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		token.Term = ICUFold(token.Term)
+	}
+
+	return input
+}
+
+// ICUFold removes the nonspacing marks of the decomposed text, folds the case and composes it again
+func ICUFold(input []byte) []byte {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), cases.Fold(), norm.NFKC)
+	output, _, err := transform.Bytes(t, input)
+	if err != nil {
+		return input
+	}
+	return output
+}
@@ -0,0 +1,127 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"bytes"
+
+	"github.com/blugelabs/bluge/analysis"
+)
+
+// EdgeNgramFilter builds the ngrams anchored to the front or the back of the tokens,
+// the ngrams keep the position of the original token
+type EdgeNgramFilter struct {
+	back             bool
+	minLength        int
+	maxLength        int
+	preserveOriginal bool
+}
+
+func NewEdgeNgramFilter(back bool, minLength, maxLength int, preserveOriginal bool) *EdgeNgramFilter {
+	return &EdgeNgramFilter{
+		back:             back,
+		minLength:        minLength,
+		maxLength:        maxLength,
+		preserveOriginal: preserveOriginal,
+	}
+}
+
+func (s *EdgeNgramFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	var skipped int
+	for _, token := range input {
+		runes := bytes.Runes(token.Term)
+		grams := ngramBuilder{token: token, skipped: skipped}
+		for size := s.minLength; size <= s.maxLength && size <= len(runes); size++ {
+			if s.back {
+				rv = grams.append(rv, runes[len(runes)-size:])
+			} else {
+				rv = grams.append(rv, runes[:size])
+			}
+		}
+		if s.preserveOriginal && (len(runes) < s.minLength || len(runes) > s.maxLength) {
+			rv = grams.append(rv, runes)
+		}
+		skipped = grams.carry()
+	}
+	return rv
+}
+
+// NgramFilter builds the ngrams of the tokens, the ngrams keep the position of the original token
+type NgramFilter struct {
+	minLength        int
+	maxLength        int
+	preserveOriginal bool
+}
+
+func NewNgramFilter(minLength, maxLength int, preserveOriginal bool) *NgramFilter {
+	return &NgramFilter{
+		minLength:        minLength,
+		maxLength:        maxLength,
+		preserveOriginal: preserveOriginal,
+	}
+}
+
+func (s *NgramFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	var skipped int
+	for _, token := range input {
+		runes := bytes.Runes(token.Term)
+		grams := ngramBuilder{token: token, skipped: skipped}
+		for i := range runes {
+			for size := s.minLength; size <= s.maxLength && i+size <= len(runes); size++ {
+				rv = grams.append(rv, runes[i:i+size])
+			}
+		}
+		if s.preserveOriginal && (len(runes) < s.minLength || len(runes) > s.maxLength) {
+			rv = grams.append(rv, runes)
+		}
+		skipped = grams.carry()
+	}
+	return rv
+}
+
+// ngramBuilder gives the position of the token to its first ngram,
+// the position of a token without ngrams is carried to the next token
+type ngramBuilder struct {
+	token   *analysis.Token
+	skipped int
+	emitted bool
+}
+
+func (b *ngramBuilder) append(rv analysis.TokenStream, runes []rune) analysis.TokenStream {
+	token := &analysis.Token{
+		Start:   b.token.Start,
+		End:     b.token.End,
+		Type:    b.token.Type,
+		KeyWord: b.token.KeyWord,
+		Term:    analysis.BuildTermFromRunes(runes),
+	}
+	if !b.emitted {
+		token.PositionIncr = b.token.PositionIncr + b.skipped
+		b.skipped = 0
+		b.emitted = true
+	}
+	return append(rv, token)
+}
+
+// carry returns the position increment for the next token
+func (b *ngramBuilder) carry() int {
+	if b.emitted {
+		return 0
+	}
+	return b.skipped + b.token.PositionIncr
+}
@@ -24,18 +24,30 @@ import (
 type RegexpTokenFilter struct {
 	r           *regexp.Regexp
 	replacement []byte
+	all         bool
 }
 
-func NewRegexpTokenFilter(r *regexp.Regexp, replacement []byte) *RegexpTokenFilter {
+// NewRegexpTokenFilter replaces the matches of the terms, only the first match if all is false
+func NewRegexpTokenFilter(r *regexp.Regexp, replacement []byte, all bool) *RegexpTokenFilter {
 	return &RegexpTokenFilter{
 		r:           r,
 		replacement: replacement,
+		all:         all,
 	}
 }
 
 func (t *RegexpTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
 	for _, token := range input {
-		token.Term = t.r.ReplaceAll(token.Term, t.replacement)
+		if t.all {
+			token.Term = t.r.ReplaceAll(token.Term, t.replacement)
+			continue
+		}
+		if match := t.r.FindSubmatchIndex(token.Term); match != nil {
+			term := make([]byte, 0, len(token.Term))
+			term = append(term, token.Term[:match[0]]...)
+			term = t.r.Expand(term, t.replacement, token.Term, match)
+			token.Term = append(term, token.Term[match[1]:]...)
+		}
 	}
 
 	return input
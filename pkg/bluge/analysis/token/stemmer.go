@@ -0,0 +1,159 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/snowballstem"
+	"github.com/blevesearch/snowballstem/arabic"
+	"github.com/blevesearch/snowballstem/danish"
+	"github.com/blevesearch/snowballstem/dutch"
+	"github.com/blevesearch/snowballstem/english"
+	"github.com/blevesearch/snowballstem/finnish"
+	"github.com/blevesearch/snowballstem/french"
+	"github.com/blevesearch/snowballstem/german"
+	"github.com/blevesearch/snowballstem/hungarian"
+	"github.com/blevesearch/snowballstem/irish"
+	"github.com/blevesearch/snowballstem/italian"
+	"github.com/blevesearch/snowballstem/norwegian"
+	"github.com/blevesearch/snowballstem/porter"
+	"github.com/blevesearch/snowballstem/portuguese"
+	"github.com/blevesearch/snowballstem/romanian"
+	"github.com/blevesearch/snowballstem/russian"
+	"github.com/blevesearch/snowballstem/spanish"
+	"github.com/blevesearch/snowballstem/swedish"
+	"github.com/blevesearch/snowballstem/tamil"
+	"github.com/blevesearch/snowballstem/turkish"
+	"github.com/blugelabs/bluge/analysis"
+)
+
+var snowballStemmers = map[string]func(*snowballstem.Env) bool{
+	"arabic":     arabic.Stem,
+	"danish":     danish.Stem,
+	"dutch":      dutch.Stem,
+	"english":    english.Stem,
+	"finnish":    finnish.Stem,
+	"french":     french.Stem,
+	"german":     german.Stem,
+	"hungarian":  hungarian.Stem,
+	"irish":      irish.Stem,
+	"italian":    italian.Stem,
+	"norwegian":  norwegian.Stem,
+	"porter":     porter.Stem,
+	"portuguese": portuguese.Stem,
+	"romanian":   romanian.Stem,
+	"russian":    russian.Stem,
+	"spanish":    spanish.Stem,
+	"swedish":    swedish.Stem,
+	"tamil":      tamil.Stem,
+	"turkish":    turkish.Stem,
+}
+
+// SnowballStemmerFilter stems the tokens with the snowball stemmer of the language,
+// the tokens marked as keyword are kept
+type SnowballStemmerFilter struct {
+	stem func(*snowballstem.Env) bool
+}
+
+func NewSnowballStemmerFilter(language string) (*SnowballStemmerFilter, error) {
+	stem, ok := snowballStemmers[strings.ToLower(language)]
+	if !ok {
+		return nil, fmt.Errorf("snowball stemmer doesn't support language [%s]", language)
+	}
+	return &SnowballStemmerFilter{stem: stem}, nil
+}
+
+func (f *SnowballStemmerFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		if token.KeyWord {
+			continue
+		}
+		env := snowballstem.NewEnv(string(token.Term))
+		f.stem(env)
+		token.Term = []byte(env.Current())
+	}
+	return input
+}
+
+// KeywordAwareFilter runs the stemmer only on the tokens not marked as keyword,
+// the stemmer must map every token to one token
+type KeywordAwareFilter struct {
+	stemmer analysis.TokenFilter
+}
+
+func NewKeywordAwareFilter(stemmer analysis.TokenFilter) *KeywordAwareFilter {
+	return &KeywordAwareFilter{stemmer: stemmer}
+}
+
+func (f *KeywordAwareFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for i, token := range input {
+		if !token.KeyWord {
+			f.stemmer.Filter(input[i : i+1])
+		}
+	}
+	return input
+}
+
+// StemmerOverrideFilter replaces the terms by the rules and marks them as keyword,
+// so the following stemmers keep them
+type StemmerOverrideFilter struct {
+	rules map[string][]byte
+}
+
+func NewStemmerOverrideFilter() *StemmerOverrideFilter {
+	return &StemmerOverrideFilter{rules: make(map[string][]byte)}
+}
+
+// AddRules parses the rules like `running, runs => run`, empty lines and lines start with # are ignored
+func (f *StemmerOverrideFilter) AddRules(rules []string) error {
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		parts := strings.Split(rule, "=>")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid rule [%s], it should be like [word, word => stem]", rule)
+		}
+		stem := strings.TrimSpace(parts[1])
+		if stem == "" {
+			return fmt.Errorf("invalid rule [%s], the replacement is empty", rule)
+		}
+		for _, word := range strings.Split(parts[0], ",") {
+			word = strings.TrimSpace(word)
+			if word == "" {
+				return fmt.Errorf("invalid rule [%s], the word is empty", rule)
+			}
+			f.rules[word] = []byte(stem)
+		}
+	}
+	return nil
+}
+
+func (f *StemmerOverrideFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		if token.KeyWord {
+			continue
+		}
+		if stem, ok := f.rules[string(token.Term)]; ok {
+			token.Term = append([]byte(nil), stem...)
+			token.KeyWord = true
+		}
+	}
+	return input
+}
@@ -16,6 +16,8 @@
 package token
 
 import (
+	"bytes"
+
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/token"
 )
@@ -24,3 +26,33 @@ func NewStopTokenFilter(stopwords []string) analysis.TokenFilter {
 	rv := StopWords(stopwords)
 	return token.NewStopTokensFilter(rv)
 }
+
+// NewIgnoreCaseStopTokenFilter removes the stopwords regardless of the case of the tokens
+func NewIgnoreCaseStopTokenFilter(stopwords []string) analysis.TokenFilter {
+	rv := analysis.NewTokenMap()
+	for word := range StopWords(stopwords) {
+		rv.AddToken(string(bytes.ToLower([]byte(word))))
+	}
+	return &IgnoreCaseStopTokenFilter{stopTokens: rv}
+}
+
+type IgnoreCaseStopTokenFilter struct {
+	stopTokens analysis.TokenMap
+}
+
+func (f *IgnoreCaseStopTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	var j, skipped int
+	for _, token := range input {
+		_, isStopToken := f.stopTokens[string(bytes.ToLower(token.Term))]
+		if !isStopToken {
+			token.PositionIncr += skipped
+			skipped = 0
+			input[j] = token
+			j++
+		} else {
+			skipped += token.PositionIncr
+		}
+	}
+
+	return input[:j]
+}
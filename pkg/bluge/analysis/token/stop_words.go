@@ -31,6 +31,7 @@ import (
 	"github.com/blugelabs/bluge/analysis/lang/fa"
 	"github.com/blugelabs/bluge/analysis/lang/fi"
 	"github.com/blugelabs/bluge/analysis/lang/fr"
+	"github.com/blugelabs/bluge/analysis/lang/ga"
 	"github.com/blugelabs/bluge/analysis/lang/gl"
 	"github.com/blugelabs/bluge/analysis/lang/hi"
 	"github.com/blugelabs/bluge/analysis/lang/hu"
@@ -78,10 +79,12 @@ func loadLanguageStopWords(rv *analysis.TokenMap, language string) bool {
 		dict = bn.StopWords()
 	case "_br_", "_brazilian_": // _brazilian_ (Brazilian Portuguese)
 		dict = br.StopWords()
-	case "_ca_", "catalan_":
+	case "_ca_", "_catalan_":
 		dict = ca.StopWords()
 	case "_cjk_": // _cjk_ (Chinese, Japanese, and Korean)
 		// none
+	case "_none_": // disable the stopwords
+		// none
 	case "_ckb_", "_sorani_":
 		dict = ckb.StopWords()
 	case "_cs_", "_czech_":
@@ -107,7 +110,7 @@ func loadLanguageStopWords(rv *analysis.TokenMap, language string) bool {
 	case "_fr_", "_french_":
 		dict = fr.StopWords()
 	case "_ga_", "_irish_":
-		dict = fa.StopWords()
+		dict = ga.StopWords()
 	case "_gl_", "_galician_":
 		dict = gl.StopWords()
 	case "_hi_", "_hindi_":
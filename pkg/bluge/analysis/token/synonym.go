@@ -0,0 +1,197 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blugelabs/bluge/analysis"
+)
+
+// SynonymTokenFilter replaces the terms matching a rule by the synonyms of the rule, a rule can match
+// a sequence of terms and the synonyms can have many terms, all the synonyms start at the position of the match
+type SynonymTokenFilter struct {
+	rules    map[string][][][]byte
+	maxTerms int
+}
+
+func NewSynonymTokenFilter() *SynonymTokenFilter {
+	return &SynonymTokenFilter{rules: make(map[string][][][]byte)}
+}
+
+// AddRule adds the synonyms of the terms, the synonyms of the same terms are merged
+func (t *SynonymTokenFilter) AddRule(terms []string, synonyms [][]string) {
+	key := strings.Join(terms, " ")
+	for _, synonym := range synonyms {
+		words := make([][]byte, 0, len(synonym))
+		for _, word := range synonym {
+			words = append(words, []byte(word))
+		}
+		t.rules[key] = append(t.rules[key], words)
+	}
+	if len(terms) > t.maxTerms {
+		t.maxTerms = len(terms)
+	}
+}
+
+func (t *SynonymTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	if len(t.rules) == 0 {
+		return input
+	}
+
+	output := make(analysis.TokenStream, 0, len(input))
+	key := new(bytes.Buffer)
+	for i := 0; i < len(input); {
+		var synonyms [][][]byte
+		matched := 0
+		for n := t.maxTerms; n > 0; n-- {
+			if i+n > len(input) {
+				continue
+			}
+			key.Reset()
+			for j := i; j < i+n; j++ {
+				if j > i {
+					key.WriteByte(' ')
+				}
+				key.Write(input[j].Term)
+			}
+			if v, ok := t.rules[key.String()]; ok {
+				synonyms = v
+				matched = n
+				break
+			}
+		}
+		if matched == 0 {
+			output = append(output, input[i])
+			i++
+			continue
+		}
+
+		first, last := input[i], input[i+matched-1]
+		maxLen := 0
+		for _, synonym := range synonyms {
+			if len(synonym) > maxLen {
+				maxLen = len(synonym)
+			}
+		}
+		// the k-th terms of the synonyms are at the same position
+		for k := 0; k < maxLen; k++ {
+			incr := 1
+			if k == 0 {
+				incr = first.PositionIncr
+			}
+			for _, synonym := range synonyms {
+				if k >= len(synonym) {
+					continue
+				}
+				output = append(output, &analysis.Token{
+					Start:        first.Start,
+					End:          last.End,
+					Term:         append([]byte(nil), synonym[k]...),
+					PositionIncr: incr,
+					Type:         first.Type,
+				})
+				incr = 0
+			}
+		}
+		i += matched
+	}
+
+	return output
+}
+
+// ParseSolrSynonyms parses the rules in the solr format, the empty lines and the lines starting with # are skipped:
+//
+//	`i-pod, ipod, i pod` the equivalent synonyms, each of them is replaced by all of them when expand,
+//	                     else by the first one
+//	`k8s, kube => kubernetes` the explicit mapping, the left side is replaced by the right side
+func ParseSolrSynonyms(filter *SynonymTokenFilter, rules []string, expand bool) error {
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if from, to, ok := strings.Cut(rule, "=>"); ok {
+			inputs, outputs := synonymWords(from), synonymWords(to)
+			if len(inputs) == 0 || len(outputs) == 0 {
+				return fmt.Errorf("invalid synonym rule [%s]", rule)
+			}
+			for _, input := range inputs {
+				filter.AddRule(input, outputs)
+			}
+			continue
+		}
+		addEquivalentSynonyms(filter, synonymWords(rule), expand)
+	}
+	return nil
+}
+
+var wordnetSynonym = regexp.MustCompile(`^s\((\d+),\d+,'(.*)',\w,\d+,\d+\)\.$`)
+
+// ParseWordnetSynonyms parses the rules in the wordnet prolog format, the words of the same synset are equivalent:
+//
+//	s(100000001,1,'abstain',v,1,0).
+//	s(100000001,2,'refrain',v,1,0).
+func ParseWordnetSynonyms(filter *SynonymTokenFilter, rules []string, expand bool) error {
+	var synset string
+	var words [][]string
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		m := wordnetSynonym.FindStringSubmatch(rule)
+		if m == nil {
+			return fmt.Errorf("invalid synonym rule [%s]", rule)
+		}
+		if m[1] != synset {
+			addEquivalentSynonyms(filter, words, expand)
+			synset, words = m[1], nil
+		}
+		if word := strings.Fields(strings.ReplaceAll(m[2], "''", "'")); len(word) > 0 {
+			words = append(words, word)
+		}
+	}
+	addEquivalentSynonyms(filter, words, expand)
+	return nil
+}
+
+func addEquivalentSynonyms(filter *SynonymTokenFilter, words [][]string, expand bool) {
+	if len(words) < 2 {
+		return
+	}
+	outputs := words
+	if !expand {
+		outputs = words[:1]
+	}
+	for _, input := range words {
+		filter.AddRule(input, outputs)
+	}
+}
+
+// synonymWords splits the comma separated synonyms, a synonym can have many words
+func synonymWords(s string) [][]string {
+	var words [][]string
+	for _, v := range strings.Split(s, ",") {
+		if word := strings.Fields(v); len(word) > 0 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,65 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package tokenizer
+
+import (
+	"regexp"
+
+	"github.com/blugelabs/bluge/analysis"
+)
+
+// PatternTokenizer splits the text on the matches of the pattern when group is -1,
+// otherwise the tokens are the captured group of the matches
+type PatternTokenizer struct {
+	r     *regexp.Regexp
+	group int
+}
+
+func NewPatternTokenizer(r *regexp.Regexp, group int) *PatternTokenizer {
+	return &PatternTokenizer{r: r, group: group}
+}
+
+func (t *PatternTokenizer) Tokenize(input []byte) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0)
+	matches := t.r.FindAllSubmatchIndex(input, -1)
+	if t.group < 0 {
+		start := 0
+		for _, match := range matches {
+			rv = appendToken(rv, input, start, match[0])
+			start = match[1]
+		}
+		return appendToken(rv, input, start, len(input))
+	}
+	for _, match := range matches {
+		if 2*t.group+1 < len(match) && match[2*t.group] >= 0 {
+			rv = appendToken(rv, input, match[2*t.group], match[2*t.group+1])
+		}
+	}
+	return rv
+}
+
+func appendToken(rv analysis.TokenStream, input []byte, start, end int) analysis.TokenStream {
+	if start >= end {
+		return rv
+	}
+	return append(rv, &analysis.Token{
+		Term:         input[start:end],
+		Start:        start,
+		End:          end,
+		PositionIncr: 1,
+		Type:         analysis.AlphaNumeric,
+	})
+}
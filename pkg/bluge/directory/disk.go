@@ -26,6 +26,12 @@ import (
 // rootPath: the root path of data
 // indexName: the name of the index to use.
 func GetDiskConfig(rootPath string, indexName string, timeRange ...int64) bluge.Config {
+	return bluge.DefaultConfigWithIndexConfig(GetDiskIndexConfig(rootPath, indexName, timeRange...))
+}
+
+// GetDiskIndexConfig returns the bluge index config of GetDiskConfig,
+// to tune the index options like merge plan before open it
+func GetDiskIndexConfig(rootPath string, indexName string, timeRange ...int64) index.Config {
 	config := index.DefaultConfig(path.Join(rootPath, indexName))
 	config = config.WithPersisterNapTimeMSec(50)
 	if len(timeRange) == 2 {
@@ -33,5 +39,5 @@ func GetDiskConfig(rootPath string, indexName string, timeRange ...int64) bluge.
 			config = config.WithTimeRange(timeRange[0], timeRange[1])
 		}
 	}
-	return bluge.DefaultConfigWithIndexConfig(config)
+	return config
 }
@@ -0,0 +1,355 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package profile
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+	segment "github.com/blugelabs/bluge_segment_api"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the profiler
+func NewContext(ctx context.Context, p *Profiler) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the profiler of the context, nil if the search is not profiled
+func FromContext(ctx context.Context) *Profiler {
+	p, _ := ctx.Value(contextKey{}).(*Profiler)
+	return p
+}
+
+// Profiler collects the timings of a search, it is safe to call the methods of a nil profiler
+type Profiler struct {
+	ids         []string
+	shards      []*Shard
+	parse       atomic.Int64
+	collect     atomic.Int64
+	fetch       atomic.Int64
+	aggregation atomic.Int64
+	lock        sync.Mutex
+}
+
+// New creates a profiler, ids are the names of the shards in the order of the readers
+func New(ids ...string) *Profiler {
+	return &Profiler{ids: ids}
+}
+
+// Shard returns the profile of the n-th reader
+func (p *Profiler) Shard(n int) *Shard {
+	if p == nil {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for len(p.shards) <= n {
+		i := len(p.shards)
+		id := fmt.Sprintf("[%d]", i)
+		if i < len(p.ids) {
+			id = p.ids[i]
+		}
+		p.shards = append(p.shards, &Shard{id: id})
+	}
+	return p.shards[n]
+}
+
+func (p *Profiler) AddParse(d time.Duration) {
+	if p != nil {
+		p.parse.Add(int64(d))
+	}
+}
+
+func (p *Profiler) AddCollect(d time.Duration) {
+	if p != nil {
+		p.collect.Add(int64(d))
+	}
+}
+
+func (p *Profiler) AddFetch(d time.Duration) {
+	if p != nil {
+		p.fetch.Add(int64(d))
+	}
+}
+
+func (p *Profiler) AddAggregation(d time.Duration) {
+	if p != nil {
+		p.aggregation.Add(int64(d))
+	}
+}
+
+// Response returns the profile section of the search response
+func (p *Profiler) Response() *meta.SearchProfile {
+	if p == nil {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	resp := &meta.SearchProfile{
+		Phases: meta.ProfilePhases{
+			ParseTimeInNanos:       p.parse.Load(),
+			CollectTimeInNanos:     p.collect.Load(),
+			FetchTimeInNanos:       p.fetch.Load(),
+			AggregationTimeInNanos: p.aggregation.Load(),
+		},
+		Shards: make([]meta.ProfileShard, 0, len(p.shards)),
+	}
+	for _, shard := range p.shards {
+		resp.Shards = append(resp.Shards, shard.response())
+	}
+	return resp
+}
+
+// Shard collects the timings of the search on one reader
+type Shard struct {
+	id      string
+	query   *Query
+	collect atomic.Int64
+}
+
+// WrapQuery wraps the query of the shard for profiling
+func (s *Shard) WrapQuery(q bluge.Query) bluge.Query {
+	if s == nil {
+		return q
+	}
+	s.query = NewQuery(q)
+	return s.query
+}
+
+// WrapIterator times the collection of the documents
+func (s *Shard) WrapIterator(dmi search.DocumentMatchIterator) search.DocumentMatchIterator {
+	if s == nil {
+		return dmi
+	}
+	return &Iterator{DocumentMatchIterator: dmi, shard: s}
+}
+
+func (s *Shard) AddCollect(d time.Duration) {
+	if s != nil {
+		s.collect.Add(int64(d))
+	}
+}
+
+func (s *Shard) response() meta.ProfileShard {
+	search := meta.ProfileSearch{
+		Query: []meta.ProfileQuery{},
+		Collector: []meta.ProfileCollector{{
+			Name:        "TopNSearch",
+			Reason:      "search_top_hits",
+			TimeInNanos: s.collect.Load(),
+		}},
+	}
+	if s.query != nil {
+		search.Query = append(search.Query, s.query.response())
+		search.RewriteTime = s.query.buildSearcher.Load()
+	}
+	return meta.ProfileShard{ID: s.id, Searches: []meta.ProfileSearch{search}}
+}
+
+// Iterator times the Next calls of the wrapped iterator
+type Iterator struct {
+	search.DocumentMatchIterator
+	shard *Shard
+}
+
+func (it *Iterator) Next() (*search.DocumentMatch, error) {
+	start := time.Now()
+	next, err := it.DocumentMatchIterator.Next()
+	it.shard.AddCollect(time.Since(start))
+	return next, err
+}
+
+// Query wraps a bluge query and collects the timings of its searcher,
+// the clauses of boolean queries are wrapped as children
+type Query struct {
+	query         bluge.Query
+	typ           string
+	description   string
+	children      []*Query
+	buildSearcher atomic.Int64
+	nextDoc       atomic.Int64
+	nextDocCount  atomic.Int64
+	advance       atomic.Int64
+	advanceCount  atomic.Int64
+	score         atomic.Int64
+	scoreCount    atomic.Int64
+}
+
+func NewQuery(q bluge.Query) *Query {
+	p := &Query{query: q, typ: queryType(q)}
+	if bq, ok := q.(*bluge.BooleanQuery); ok {
+		nq := bluge.NewBooleanQuery().SetMinShould(bq.MinShould()).SetBoost(bq.Boost())
+		clauses := make([]string, 0, len(bq.Musts())+len(bq.Shoulds())+len(bq.MustNots()))
+		for _, sub := range bq.Musts() {
+			child := NewQuery(sub)
+			p.children = append(p.children, child)
+			nq.AddMust(child)
+			clauses = append(clauses, "+"+child.description)
+		}
+		for _, sub := range bq.Shoulds() {
+			child := NewQuery(sub)
+			p.children = append(p.children, child)
+			nq.AddShould(child)
+			clauses = append(clauses, child.description)
+		}
+		for _, sub := range bq.MustNots() {
+			child := NewQuery(sub)
+			p.children = append(p.children, child)
+			nq.AddMustNot(child)
+			clauses = append(clauses, "-"+child.description)
+		}
+		p.query = nq
+		p.description = "(" + strings.Join(clauses, " ") + ")"
+	} else {
+		p.description = queryDescription(q)
+	}
+	return p
+}
+
+func (q *Query) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	if options.SimilarityForField != nil {
+		similarityForField := options.SimilarityForField
+		options.SimilarityForField = func(field string) search.Similarity {
+			return &similarity{Similarity: similarityForField(field), query: q}
+		}
+	}
+	start := time.Now()
+	s, err := q.query.Searcher(i, options)
+	q.buildSearcher.Add(int64(time.Since(start)))
+	if err != nil {
+		return nil, err
+	}
+	// keep the match none searcher, the boolean query optimizes it away
+	if _, ok := s.(*searcher.MatchNoneSearcher); ok {
+		return s, nil
+	}
+	return &Searcher{Searcher: s, query: q}, nil
+}
+
+func (q *Query) response() meta.ProfileQuery {
+	resp := meta.ProfileQuery{
+		Type:        q.typ,
+		Description: q.description,
+		TimeInNanos: q.buildSearcher.Load() + q.nextDoc.Load() + q.advance.Load(),
+		Breakdown: map[string]int64{
+			"build_searcher": q.buildSearcher.Load(),
+			"next_doc":       q.nextDoc.Load(),
+			"next_doc_count": q.nextDocCount.Load(),
+			"advance":        q.advance.Load(),
+			"advance_count":  q.advanceCount.Load(),
+			"score":          q.score.Load(),
+			"score_count":    q.scoreCount.Load(),
+		},
+	}
+	for _, child := range q.children {
+		resp.Children = append(resp.Children, child.response())
+	}
+	return resp
+}
+
+// Searcher times the iterations of the wrapped searcher, scoring is included
+// as bluge scores the documents while iterating
+type Searcher struct {
+	search.Searcher
+	query *Query
+}
+
+func (s *Searcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	start := time.Now()
+	next, err := s.Searcher.Next(ctx)
+	s.query.nextDoc.Add(int64(time.Since(start)))
+	s.query.nextDocCount.Add(1)
+	return next, err
+}
+
+func (s *Searcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	start := time.Now()
+	next, err := s.Searcher.Advance(ctx, number)
+	s.query.advance.Add(int64(time.Since(start)))
+	s.query.advanceCount.Add(1)
+	return next, err
+}
+
+type similarity struct {
+	search.Similarity
+	query *Query
+}
+
+func (s *similarity) Scorer(boost float64, collectionStats segment.CollectionStats, termStats segment.TermStats) search.Scorer {
+	return &scorer{Scorer: s.Similarity.Scorer(boost, collectionStats, termStats), query: s.query}
+}
+
+// scorer times the scoring of the documents
+type scorer struct {
+	search.Scorer
+	query *Query
+}
+
+func (s *scorer) Score(freq int, norm float64) float64 {
+	start := time.Now()
+	score := s.Scorer.Score(freq, norm)
+	s.query.score.Add(int64(time.Since(start)))
+	s.query.scoreCount.Add(1)
+	return score
+}
+
+func queryType(q bluge.Query) string {
+	t := reflect.TypeOf(q)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func queryDescription(q bluge.Query) string {
+	var value string
+	switch v := q.(type) {
+	case *bluge.PrefixQuery:
+		value = v.Prefix() + "*"
+	case interface{ Term() string }:
+		value = v.Term()
+	case interface{ Match() string }:
+		value = v.Match()
+	case interface{ Wildcard() string }:
+		value = v.Wildcard()
+	case interface{ Regexp() string }:
+		value = "/" + v.Regexp() + "/"
+	case interface{ Phrase() string }:
+		value = "\"" + v.Phrase() + "\""
+	case *bluge.MatchAllQuery:
+		return "*:*"
+	default:
+		return queryType(q)
+	}
+	if v, ok := q.(interface{ Field() string }); ok && v.Field() != "" {
+		return v.Field() + ":" + value
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blugelabs/bluge"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfiler(t *testing.T) {
+	writer, err := bluge.OpenWriter(bluge.InMemoryOnlyConfig())
+	assert.NoError(t, err)
+	defer writer.Close()
+	for _, id := range []string{"1", "2", "3"} {
+		doc := bluge.NewDocument(id).AddField(bluge.NewTextField("name", "doc"+id).StoreValue())
+		if id != "3" {
+			doc.AddField(bluge.NewKeywordField("tag", "zinc"))
+		}
+		assert.NoError(t, writer.Update(doc.ID(), doc))
+	}
+	reader, err := writer.Reader()
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	p := New("[index][0]")
+	ctx := NewContext(context.Background(), p)
+	assert.Equal(t, p, FromContext(ctx))
+	assert.Nil(t, FromContext(context.Background()))
+
+	shard := FromContext(ctx).Shard(0)
+	q := bluge.NewBooleanQuery().
+		AddMust(bluge.NewTermQuery("zinc").SetField("tag")).
+		AddShould(bluge.NewWildcardQuery("doc*").SetField("name")).
+		AddMustNot(bluge.NewTermQuery("doc2").SetField("name"))
+	dmi, err := reader.Search(ctx, bluge.NewTopNSearch(10, shard.WrapQuery(q)))
+	assert.NoError(t, err)
+	dmi = shard.WrapIterator(dmi)
+	var ids []string
+	next, err := dmi.Next()
+	for err == nil && next != nil {
+		_ = next.VisitStoredFields(func(field string, value []byte) bool {
+			if field == "_id" {
+				ids = append(ids, string(value))
+			}
+			return true
+		})
+		next, err = dmi.Next()
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids)
+
+	resp := p.Response()
+	assert.Len(t, resp.Shards, 1)
+	assert.Equal(t, "[index][0]", resp.Shards[0].ID)
+	search := resp.Shards[0].Searches[0]
+	assert.Greater(t, search.Collector[0].TimeInNanos, int64(0))
+	assert.Equal(t, search.Query[0].Breakdown["build_searcher"], search.RewriteTime)
+
+	root := search.Query[0]
+	assert.Equal(t, "BooleanQuery", root.Type)
+	assert.Equal(t, "(+tag:zinc name:doc* -name:doc2)", root.Description)
+	assert.Len(t, root.Children, 3)
+	assert.Equal(t, "TermQuery", root.Children[0].Type)
+	assert.Equal(t, "WildcardQuery", root.Children[1].Type)
+	assert.Greater(t, root.Breakdown["next_doc_count"], int64(0))
+	assert.Greater(t, root.Children[0].Breakdown["score_count"], int64(0))
+	assert.GreaterOrEqual(t, root.TimeInNanos, root.Breakdown["next_doc"])
+}
+
+func TestProfiler_Nil(t *testing.T) {
+	var p *Profiler
+	p.AddParse(1)
+	p.AddCollect(1)
+	p.AddFetch(1)
+	p.AddAggregation(1)
+	assert.Nil(t, p.Shard(0))
+	assert.Nil(t, p.Response())
+
+	q := bluge.NewMatchAllQuery()
+	assert.Equal(t, q, p.Shard(0).WrapQuery(q))
+}
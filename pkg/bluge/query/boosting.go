@@ -0,0 +1,164 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"strconv"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+// BoostingQuery matches the documents of the positive query, the score of the documents
+// which also match the negative query is multiplied by the negative boost
+type BoostingQuery struct {
+	positive      bluge.Query
+	negative      bluge.Query
+	negativeBoost float64
+	boost         float64
+}
+
+func NewBoostingQuery(positive, negative bluge.Query, negativeBoost float64) *BoostingQuery {
+	return &BoostingQuery{positive: positive, negative: negative, negativeBoost: negativeBoost, boost: 1}
+}
+
+func (q *BoostingQuery) SetBoost(boost float64) *BoostingQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *BoostingQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *BoostingQuery) Positive() bluge.Query {
+	return q.positive
+}
+
+func (q *BoostingQuery) Negative() bluge.Query {
+	return q.negative
+}
+
+func (q *BoostingQuery) NegativeBoost() float64 {
+	return q.negativeBoost
+}
+
+func (q *BoostingQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	positive, err := q.positive.Searcher(i, options)
+	if err != nil {
+		return nil, err
+	}
+	negativeOptions := options
+	negativeOptions.Explain = false
+	negative, err := q.negative.Searcher(i, negativeOptions)
+	if err != nil {
+		_ = positive.Close()
+		return nil, err
+	}
+	return &boostingSearcher{
+		positive:      positive,
+		negative:      negative,
+		negativeBoost: q.negativeBoost,
+		boost:         q.boost,
+		explain:       options.Explain,
+	}, nil
+}
+
+func (q *BoostingQuery) String() string {
+	s := "boosting(" + String(q.positive) + ", negative: " + String(q.negative) +
+		", negative_boost: " + strconv.FormatFloat(q.negativeBoost, 'f', -1, 64) + ")"
+	return boostString(s, q.boost)
+}
+
+type boostingSearcher struct {
+	positive      search.Searcher
+	negative      search.Searcher
+	negativeBoost float64
+	boost         float64
+	explain       bool
+	current       *search.DocumentMatch // current match of the negative query
+	negativeDone  bool
+}
+
+func (s *boostingSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	dm, err := s.positive.Next(ctx)
+	if err != nil || dm == nil {
+		return nil, err
+	}
+	return s.score(ctx, dm)
+}
+
+func (s *boostingSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	dm, err := s.positive.Advance(ctx, number)
+	if err != nil || dm == nil {
+		return nil, err
+	}
+	return s.score(ctx, dm)
+}
+
+// score demotes the match if the document also matches the negative query
+func (s *boostingSearcher) score(ctx *search.Context, dm *search.DocumentMatch) (*search.DocumentMatch, error) {
+	if !s.negativeDone && (s.current == nil || s.current.Number < dm.Number) {
+		if s.current != nil {
+			ctx.DocumentMatchPool.Put(s.current)
+		}
+		var err error
+		if s.current, err = s.negative.Advance(ctx, dm.Number); err != nil {
+			return nil, err
+		}
+		s.negativeDone = s.current == nil
+	}
+
+	if s.current != nil && s.current.Number == dm.Number {
+		dm.Score *= s.negativeBoost
+		if s.explain {
+			dm.Explanation = search.NewExplanation(dm.Score, "product of:", dm.Explanation,
+				search.NewExplanation(s.negativeBoost, "negative_boost, matched the negative query"))
+		}
+	}
+	if s.boost != 1 {
+		dm.Score *= s.boost
+		if s.explain {
+			dm.Explanation = search.NewExplanation(dm.Score, "computed as boost * score",
+				search.NewExplanation(s.boost, "boost"), dm.Explanation)
+		}
+	}
+	return dm, nil
+}
+
+func (s *boostingSearcher) Close() error {
+	err := s.positive.Close()
+	if e := s.negative.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+func (s *boostingSearcher) Count() uint64 {
+	return s.positive.Count()
+}
+
+func (s *boostingSearcher) Min() int {
+	return s.positive.Min()
+}
+
+func (s *boostingSearcher) Size() int {
+	return s.positive.Size() + s.negative.Size()
+}
+
+func (s *boostingSearcher) DocumentMatchPoolSize() int {
+	return s.positive.DocumentMatchPoolSize() + s.negative.DocumentMatchPoolSize()
+}
@@ -0,0 +1,110 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+// ConstantScoreQuery matches the documents of the filter, the filter doesn't score
+// and every match gets the boost as the score
+type ConstantScoreQuery struct {
+	filter bluge.Query
+	boost  float64
+}
+
+func NewConstantScoreQuery(filter bluge.Query) *ConstantScoreQuery {
+	return &ConstantScoreQuery{filter: filter, boost: 1}
+}
+
+func (q *ConstantScoreQuery) SetBoost(boost float64) *ConstantScoreQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *ConstantScoreQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *ConstantScoreQuery) Filter() bluge.Query {
+	return q.filter
+}
+
+func (q *ConstantScoreQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	filterOptions := options
+	filterOptions.Score = "none"
+	filterOptions.Explain = false
+	filter, err := q.filter.Searcher(i, filterOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &constantScoreSearcher{filter: filter, boost: q.boost, explain: options.Explain}, nil
+}
+
+func (q *ConstantScoreQuery) String() string {
+	return boostString("ConstantScore("+String(q.filter)+")", q.boost)
+}
+
+type constantScoreSearcher struct {
+	filter  search.Searcher
+	boost   float64
+	explain bool
+}
+
+func (s *constantScoreSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	dm, err := s.filter.Next(ctx)
+	if err != nil || dm == nil {
+		return nil, err
+	}
+	return s.score(dm), nil
+}
+
+func (s *constantScoreSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	dm, err := s.filter.Advance(ctx, number)
+	if err != nil || dm == nil {
+		return nil, err
+	}
+	return s.score(dm), nil
+}
+
+func (s *constantScoreSearcher) score(dm *search.DocumentMatch) *search.DocumentMatch {
+	dm.Score = s.boost
+	if s.explain {
+		dm.Explanation = search.NewExplanation(s.boost, "constant score")
+	}
+	return dm
+}
+
+func (s *constantScoreSearcher) Close() error {
+	return s.filter.Close()
+}
+
+func (s *constantScoreSearcher) Count() uint64 {
+	return s.filter.Count()
+}
+
+func (s *constantScoreSearcher) Min() int {
+	return s.filter.Min()
+}
+
+func (s *constantScoreSearcher) Size() int {
+	return s.filter.Size()
+}
+
+func (s *constantScoreSearcher) DocumentMatchPoolSize() int {
+	return s.filter.DocumentMatchPoolSize()
+}
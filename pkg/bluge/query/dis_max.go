@@ -0,0 +1,134 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+)
+
+// DisMaxQuery matches the documents matching any of the queries, the score is the
+// maximum score of the matching queries plus tie_breaker times the other scores
+type DisMaxQuery struct {
+	queries    []bluge.Query
+	tieBreaker float64
+	boost      float64
+}
+
+func NewDisMaxQuery(queries ...bluge.Query) *DisMaxQuery {
+	return &DisMaxQuery{queries: queries, boost: 1}
+}
+
+func (q *DisMaxQuery) AddQuery(queries ...bluge.Query) *DisMaxQuery {
+	q.queries = append(q.queries, queries...)
+	return q
+}
+
+func (q *DisMaxQuery) Queries() []bluge.Query {
+	return q.queries
+}
+
+func (q *DisMaxQuery) SetTieBreaker(tieBreaker float64) *DisMaxQuery {
+	q.tieBreaker = tieBreaker
+	return q
+}
+
+func (q *DisMaxQuery) TieBreaker() float64 {
+	return q.tieBreaker
+}
+
+func (q *DisMaxQuery) SetBoost(boost float64) *DisMaxQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *DisMaxQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *DisMaxQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	searchers := make([]search.Searcher, 0, len(q.queries))
+	for _, query := range q.queries {
+		s, err := query.Searcher(i, options)
+		if err != nil {
+			for _, s := range searchers {
+				_ = s.Close()
+			}
+			return nil, err
+		}
+		if _, ok := s.(*searcher.MatchNoneSearcher); ok {
+			continue
+		}
+		searchers = append(searchers, s)
+	}
+	if len(searchers) == 0 {
+		return searcher.NewMatchNoneSearcher(i, options)
+	}
+	return searcher.NewDisjunctionSearcher(i, searchers, 1, &disMaxScorer{tieBreaker: q.tieBreaker, boost: q.boost}, options)
+}
+
+func (q *DisMaxQuery) String() string {
+	clauses := make([]string, 0, len(q.queries))
+	for _, query := range q.queries {
+		clauses = append(clauses, clauseString(query))
+	}
+	s := "(" + strings.Join(clauses, " | ") + ")"
+	if q.tieBreaker != 0 {
+		s += "~" + strconv.FormatFloat(q.tieBreaker, 'f', -1, 64)
+	}
+	return boostString(s, q.boost)
+}
+
+type disMaxScorer struct {
+	tieBreaker float64
+	boost      float64
+}
+
+func (s *disMaxScorer) ScoreComposite(constituents []*search.DocumentMatch) float64 {
+	return s.score(constituents) * s.boost
+}
+
+func (s *disMaxScorer) score(constituents []*search.DocumentMatch) float64 {
+	var max, sum float64
+	for _, constituent := range constituents {
+		sum += constituent.Score
+		if constituent.Score > max {
+			max = constituent.Score
+		}
+	}
+	return max + s.tieBreaker*(sum-max)
+}
+
+func (s *disMaxScorer) ExplainComposite(constituents []*search.DocumentMatch) *search.Explanation {
+	children := make([]*search.Explanation, 0, len(constituents))
+	for _, constituent := range constituents {
+		children = append(children, constituent.Explanation)
+	}
+	description := "max of:"
+	if s.tieBreaker != 0 {
+		description = "max plus " + strconv.FormatFloat(s.tieBreaker, 'f', -1, 64) + " times others of:"
+	}
+	explanation := search.NewExplanation(s.score(constituents), description, children...)
+	if s.boost == 1 {
+		return explanation
+	}
+	return search.NewExplanation(explanation.Value*s.boost, "computed as boost * max",
+		search.NewExplanation(s.boost, "boost"), explanation)
+}
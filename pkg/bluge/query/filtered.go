@@ -0,0 +1,121 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+// FilteredQuery matches the documents of the query which also match the filter,
+// the score and explanation only come from the query
+type FilteredQuery struct {
+	query  bluge.Query
+	filter bluge.Query
+}
+
+func NewFilteredQuery(query, filter bluge.Query) *FilteredQuery {
+	return &FilteredQuery{query: query, filter: filter}
+}
+
+func (q *FilteredQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	child, err := q.query.Searcher(i, options)
+	if err != nil {
+		return nil, err
+	}
+	filterOptions := options
+	filterOptions.Explain = false
+	filter, err := q.filter.Searcher(i, filterOptions)
+	if err != nil {
+		_ = child.Close()
+		return nil, err
+	}
+	return &filteredSearcher{child: child, filter: filter}, nil
+}
+
+type filteredSearcher struct {
+	child         search.Searcher
+	filter        search.Searcher
+	current       *search.DocumentMatch // current match of the query
+	currentFilter *search.DocumentMatch // current match of the filter
+	next          uint64                // next document number to visit
+}
+
+func (s *filteredSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	return s.Advance(ctx, s.next)
+}
+
+// Advance leapfrogs the query and the filter until both are on the same document
+func (s *filteredSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	var err error
+	for {
+		if s.current == nil || s.current.Number < number {
+			if s.current != nil {
+				ctx.DocumentMatchPool.Put(s.current)
+			}
+			if s.current == nil && number == 0 {
+				// advance on an empty reader panics, start with next
+				s.current, err = s.child.Next(ctx)
+			} else {
+				s.current, err = s.child.Advance(ctx, number)
+			}
+			if err != nil || s.current == nil {
+				return nil, err
+			}
+		}
+		number = s.current.Number
+
+		if s.currentFilter == nil || s.currentFilter.Number < number {
+			if s.currentFilter != nil {
+				ctx.DocumentMatchPool.Put(s.currentFilter)
+			}
+			if s.currentFilter, err = s.filter.Advance(ctx, number); err != nil || s.currentFilter == nil {
+				return nil, err
+			}
+		}
+		if s.currentFilter.Number == number {
+			next := s.current
+			s.current = nil
+			s.next = number + 1
+			return next, nil
+		}
+		number = s.currentFilter.Number
+	}
+}
+
+func (s *filteredSearcher) Close() error {
+	err := s.child.Close()
+	if e := s.filter.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+func (s *filteredSearcher) Count() uint64 {
+	return s.filter.Count()
+}
+
+func (s *filteredSearcher) Min() int {
+	return s.child.Min()
+}
+
+func (s *filteredSearcher) Size() int {
+	return s.child.Size() + s.filter.Size()
+}
+
+func (s *filteredSearcher) DocumentMatchPoolSize() int {
+	return s.child.DocumentMatchPoolSize() + s.filter.DocumentMatchPoolSize()
+}
@@ -0,0 +1,345 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+const (
+	ScoreModeMultiply = "multiply"
+	ScoreModeSum      = "sum"
+	ScoreModeAvg      = "avg"
+	ScoreModeFirst    = "first"
+	ScoreModeMax      = "max"
+	ScoreModeMin      = "min"
+
+	BoostModeMultiply = "multiply"
+	BoostModeReplace  = "replace"
+	BoostModeSum      = "sum"
+	BoostModeAvg      = "avg"
+	BoostModeMax      = "max"
+	BoostModeMin      = "min"
+)
+
+// Function is a function of the function_score query, it only applies to the documents matching the filter
+type Function struct {
+	Filter bluge.Query   // optional
+	Weight float64       // default 1
+	Score  ScoreFunction // optional, only the weight is used if nil
+}
+
+// FunctionScoreQuery modifies the score of the documents matched by the query
+type FunctionScoreQuery struct {
+	query       bluge.Query
+	functions   []*Function
+	scoreMode   string
+	boostMode   string
+	maxBoost    float64
+	minScore    float64
+	hasMinScore bool
+	boost       float64
+}
+
+func NewFunctionScoreQuery(query bluge.Query) *FunctionScoreQuery {
+	return &FunctionScoreQuery{
+		query:     query,
+		scoreMode: ScoreModeMultiply,
+		boostMode: BoostModeMultiply,
+		maxBoost:  math.MaxFloat32,
+		boost:     1,
+	}
+}
+
+func (q *FunctionScoreQuery) AddFunction(f *Function) *FunctionScoreQuery {
+	q.functions = append(q.functions, f)
+	return q
+}
+
+func (q *FunctionScoreQuery) SetScoreMode(mode string) *FunctionScoreQuery {
+	q.scoreMode = mode
+	return q
+}
+
+func (q *FunctionScoreQuery) SetBoostMode(mode string) *FunctionScoreQuery {
+	q.boostMode = mode
+	return q
+}
+
+func (q *FunctionScoreQuery) SetMaxBoost(maxBoost float64) *FunctionScoreQuery {
+	q.maxBoost = maxBoost
+	return q
+}
+
+func (q *FunctionScoreQuery) SetMinScore(minScore float64) *FunctionScoreQuery {
+	q.minScore = minScore
+	q.hasMinScore = true
+	return q
+}
+
+func (q *FunctionScoreQuery) SetBoost(boost float64) *FunctionScoreQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *FunctionScoreQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	child, err := q.query.Searcher(i, options)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &functionScoreSearcher{
+		query:   q,
+		child:   child,
+		filters: make([]search.Searcher, len(q.functions)),
+		current: make([]*search.DocumentMatch, len(q.functions)),
+		done:    make([]bool, len(q.functions)),
+		values:  make(DocValues),
+		explain: options.Explain,
+	}
+
+	fields := make([]string, 0)
+	for n, f := range q.functions {
+		if f.Filter != nil {
+			filterOptions := options
+			filterOptions.Explain = false
+			if s.filters[n], err = f.Filter.Searcher(i, filterOptions); err != nil {
+				_ = s.Close()
+				return nil, err
+			}
+		}
+		if f.Score != nil {
+			fields = append(fields, f.Score.Fields()...)
+		}
+	}
+	if len(fields) > 0 {
+		dvReader, err := i.DocumentValueReader(fields)
+		if err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+		s.visit = func(number uint64, visitor func(field string, term []byte)) error {
+			return dvReader.VisitDocumentValues(number, visitor)
+		}
+	}
+
+	return s, nil
+}
+
+type functionScoreSearcher struct {
+	query   *FunctionScoreQuery
+	child   search.Searcher
+	filters []search.Searcher
+	current []*search.DocumentMatch // current match of each filter
+	done    []bool                  // filter is exhausted
+	visit   func(number uint64, visitor func(field string, term []byte)) error
+	values  DocValues
+	explain bool
+}
+
+func (s *functionScoreSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	next, err := s.child.Next(ctx)
+	for err == nil && next != nil {
+		var ok bool
+		if ok, err = s.score(ctx, next); err != nil || ok {
+			break
+		}
+		ctx.DocumentMatchPool.Put(next)
+		next, err = s.child.Next(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+func (s *functionScoreSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	next, err := s.child.Advance(ctx, number)
+	if err != nil || next == nil {
+		return nil, err
+	}
+	ok, err := s.score(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return next, nil
+	}
+	ctx.DocumentMatchPool.Put(next)
+	return s.Next(ctx)
+}
+
+// score sets the score of the document and returns false if the score is less than min_score
+func (s *functionScoreSearcher) score(ctx *search.Context, doc *search.DocumentMatch) (bool, error) {
+	q := s.query
+	if s.visit != nil {
+		for field := range s.values {
+			s.values[field] = s.values[field][:0]
+		}
+		if err := s.visit(doc.Number, func(field string, term []byte) {
+			s.values[field] = append(s.values[field], term)
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	var matched int
+	var fnScore, weightSum float64
+	for n, f := range q.functions {
+		if s.filters[n] != nil {
+			ok, err := s.match(ctx, n, doc.Number)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		v := f.Weight
+		if f.Score != nil {
+			v *= f.Score.Score(doc.Score, s.values)
+		}
+		matched++
+		if matched == 1 {
+			fnScore = v
+			weightSum = f.Weight
+			if q.scoreMode == ScoreModeFirst {
+				break
+			}
+			continue
+		}
+		switch q.scoreMode {
+		case ScoreModeSum:
+			fnScore += v
+		case ScoreModeAvg:
+			fnScore += v
+			weightSum += f.Weight
+		case ScoreModeMax:
+			fnScore = math.Max(fnScore, v)
+		case ScoreModeMin:
+			fnScore = math.Min(fnScore, v)
+		default:
+			fnScore *= v
+		}
+	}
+	if matched == 0 {
+		fnScore = 1
+	} else if q.scoreMode == ScoreModeAvg && weightSum != 0 {
+		fnScore /= weightSum
+	}
+	if fnScore > q.maxBoost {
+		fnScore = q.maxBoost
+	}
+
+	queryScore := doc.Score
+	var score float64
+	switch q.boostMode {
+	case BoostModeReplace:
+		score = fnScore
+	case BoostModeSum:
+		score = queryScore + fnScore
+	case BoostModeAvg:
+		score = (queryScore + fnScore) / 2
+	case BoostModeMax:
+		score = math.Max(queryScore, fnScore)
+	case BoostModeMin:
+		score = math.Min(queryScore, fnScore)
+	default:
+		score = queryScore * fnScore
+	}
+	doc.Score = score * q.boost
+
+	if s.explain {
+		doc.Explanation = search.NewExplanation(doc.Score,
+			fmt.Sprintf("function score, score mode [%s], boost mode [%s], boost [%v]", q.scoreMode, q.boostMode, q.boost),
+			doc.Explanation,
+			search.NewExplanation(fnScore, fmt.Sprintf("functions score, %d of %d functions matched", matched, len(q.functions))),
+		)
+	}
+
+	return !q.hasMinScore || doc.Score >= q.minScore, nil
+}
+
+// match returns true if the document matches the filter of the function
+func (s *functionScoreSearcher) match(ctx *search.Context, n int, number uint64) (bool, error) {
+	if s.done[n] {
+		return false, nil
+	}
+	current := s.current[n]
+	if current == nil || current.Number < number {
+		if current != nil {
+			ctx.DocumentMatchPool.Put(current)
+		}
+		var err error
+		current, err = s.filters[n].Advance(ctx, number)
+		if err != nil {
+			return false, err
+		}
+		s.current[n] = current
+		if current == nil {
+			s.done[n] = true
+			return false, nil
+		}
+	}
+	return current.Number == number, nil
+}
+
+func (s *functionScoreSearcher) Close() error {
+	var err error
+	if s.child != nil {
+		err = s.child.Close()
+	}
+	for _, filter := range s.filters {
+		if filter != nil {
+			if e := filter.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+func (s *functionScoreSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *functionScoreSearcher) Min() int {
+	return s.child.Min()
+}
+
+func (s *functionScoreSearcher) Size() int {
+	size := s.child.Size()
+	for _, filter := range s.filters {
+		if filter != nil {
+			size += filter.Size()
+		}
+	}
+	return size
+}
+
+func (s *functionScoreSearcher) DocumentMatchPoolSize() int {
+	size := s.child.DocumentMatchPoolSize()
+	for _, filter := range s.filters {
+		if filter != nil {
+			size += filter.DocumentMatchPoolSize()
+		}
+	}
+	return size
+}
@@ -0,0 +1,87 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+// MinScoreQuery matches the documents of the query scoring at least the min score,
+// the other documents are neither collected nor counted
+type MinScoreQuery struct {
+	query    bluge.Query
+	minScore float64
+}
+
+func NewMinScoreQuery(query bluge.Query, minScore float64) *MinScoreQuery {
+	return &MinScoreQuery{query: query, minScore: minScore}
+}
+
+func (q *MinScoreQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	child, err := q.query.Searcher(i, options)
+	if err != nil {
+		return nil, err
+	}
+	return &minScoreSearcher{child: child, minScore: q.minScore}, nil
+}
+
+type minScoreSearcher struct {
+	child    search.Searcher
+	minScore float64
+}
+
+func (s *minScoreSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	next, err := s.child.Next(ctx)
+	return s.filter(ctx, next, err)
+}
+
+func (s *minScoreSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	next, err := s.child.Advance(ctx, number)
+	return s.filter(ctx, next, err)
+}
+
+// filter skips the documents until one of them scores enough
+func (s *minScoreSearcher) filter(ctx *search.Context, next *search.DocumentMatch, err error) (*search.DocumentMatch, error) {
+	for err == nil && next != nil {
+		if next.Score >= s.minScore {
+			return next, nil
+		}
+		ctx.DocumentMatchPool.Put(next)
+		next, err = s.child.Next(ctx)
+	}
+	return nil, err
+}
+
+func (s *minScoreSearcher) Close() error {
+	return s.child.Close()
+}
+
+func (s *minScoreSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *minScoreSearcher) Min() int {
+	return s.child.Min()
+}
+
+func (s *minScoreSearcher) Size() int {
+	return s.child.Size()
+}
+
+func (s *minScoreSearcher) DocumentMatchPoolSize() int {
+	return s.child.DocumentMatchPoolSize()
+}
@@ -0,0 +1,82 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+)
+
+// NamedQuery names the query so the hits can tell whether it matched, it searches as the query
+type NamedQuery struct {
+	name  string
+	query bluge.Query
+}
+
+func NewNamedQuery(name string, query bluge.Query) *NamedQuery {
+	return &NamedQuery{name: name, query: query}
+}
+
+func (q *NamedQuery) Name() string {
+	return q.name
+}
+
+func (q *NamedQuery) Query() bluge.Query {
+	return q.query
+}
+
+func (q *NamedQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	return q.query.Searcher(i, options)
+}
+
+// NamedQueries returns the named queries of the query tree in order,
+// the queries inside the nested queries are skipped as they match the nested objects
+func NamedQueries(q bluge.Query) []*NamedQuery {
+	var named []*NamedQuery
+	var walk func(q bluge.Query)
+	walk = func(q bluge.Query) {
+		switch q := q.(type) {
+		case *NamedQuery:
+			named = append(named, q)
+			walk(q.query)
+		case *bluge.BooleanQuery:
+			for _, sub := range q.Musts() {
+				walk(sub)
+			}
+			for _, sub := range q.Shoulds() {
+				walk(sub)
+			}
+		case *FilteredQuery:
+			walk(q.query)
+			walk(q.filter)
+		case *ConstantScoreQuery:
+			walk(q.filter)
+		case *DisMaxQuery:
+			for _, sub := range q.queries {
+				walk(sub)
+			}
+		case *BoostingQuery:
+			walk(q.positive)
+			walk(q.negative)
+		case *FunctionScoreQuery:
+			walk(q.query)
+		case *MinScoreQuery:
+			walk(q.query)
+		}
+	}
+	walk(q)
+	return named
+}
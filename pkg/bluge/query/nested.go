@@ -0,0 +1,246 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// nested score modes
+const (
+	NestedScoreModeAvg  = "avg"
+	NestedScoreModeMax  = "max"
+	NestedScoreModeMin  = "min"
+	NestedScoreModeSum  = "sum"
+	NestedScoreModeNone = "none"
+)
+
+// NestedQuery matches the parent documents which have a nested object of the path matching the query.
+// The nested objects are indexed as hidden documents with the path and the parent id, the query runs
+// on them and the matches are joined back to the parent documents.
+type NestedQuery struct {
+	path      string
+	query     bluge.Query
+	scoreMode string
+	boost     float64
+}
+
+func NewNestedQuery(path string, query bluge.Query) *NestedQuery {
+	return &NestedQuery{path: path, query: query, scoreMode: NestedScoreModeAvg, boost: 1}
+}
+
+func (q *NestedQuery) SetScoreMode(scoreMode string) *NestedQuery {
+	q.scoreMode = scoreMode
+	return q
+}
+
+func (q *NestedQuery) SetBoost(boost float64) *NestedQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *NestedQuery) Path() string {
+	return q.path
+}
+
+func (q *NestedQuery) Query() bluge.Query {
+	return q.query
+}
+
+// ChildQuery returns the query which matches the nested documents of the path
+func (q *NestedQuery) ChildQuery() bluge.Query {
+	return NewFilteredQuery(q.query, bluge.NewTermQuery(q.path).SetField(meta.NestedPathFieldName))
+}
+
+func (q *NestedQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	child, err := q.ChildQuery().Searcher(i, options)
+	if err != nil {
+		return nil, err
+	}
+	defer child.Close()
+
+	dvReader, err := i.DocumentValueReader([]string{meta.NestedParentFieldName})
+	if err != nil {
+		return nil, err
+	}
+
+	// collect the matches of the nested documents by the parent id
+	parents := make(map[string]*nestedMatch)
+	ctx := search.NewSearchContext(child.DocumentMatchPoolSize(), 0)
+	var parentID string
+	dm, err := child.Next(ctx)
+	for err == nil && dm != nil {
+		parentID = ""
+		err = dvReader.VisitDocumentValues(dm.Number, func(field string, term []byte) {
+			parentID = string(term)
+		})
+		if err != nil {
+			break
+		}
+		if parentID != "" {
+			parent, ok := parents[parentID]
+			if !ok {
+				parent = &nestedMatch{min: math.MaxFloat64}
+				parents[parentID] = parent
+			}
+			parent.add(dm.Score, dm.Explanation)
+		}
+		ctx.DocumentMatchPool.Put(dm)
+		dm, err = child.Next(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// find the parent documents
+	matches := make([]*nestedMatch, 0, len(parents))
+	for id, parent := range parents {
+		postings, err := i.PostingsIterator([]byte(id), "_id", false, false, false)
+		if err != nil {
+			return nil, err
+		}
+		posting, err := postings.Next()
+		if err == nil && posting != nil {
+			parent.number = posting.Number()
+			matches = append(matches, parent)
+		}
+		_ = postings.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].number < matches[j].number })
+
+	return &nestedSearcher{
+		indexReader: i,
+		matches:     matches,
+		scoreMode:   q.scoreMode,
+		boost:       q.boost,
+		explain:     options.Explain,
+	}, nil
+}
+
+func (q *NestedQuery) String() string {
+	return boostString("nested("+q.path+", "+String(q.query)+", score_mode: "+q.scoreMode+")", q.boost)
+}
+
+// nestedMatch is the matches of the nested documents of a parent document
+type nestedMatch struct {
+	number      uint64
+	count       int
+	sum         float64
+	max         float64
+	min         float64
+	explanation *search.Explanation // explanation of the best match
+}
+
+func (m *nestedMatch) add(score float64, explanation *search.Explanation) {
+	m.count++
+	m.sum += score
+	if score > m.max || m.count == 1 {
+		m.max = score
+		m.explanation = explanation
+	}
+	if score < m.min {
+		m.min = score
+	}
+}
+
+func (m *nestedMatch) score(scoreMode string) float64 {
+	switch scoreMode {
+	case NestedScoreModeMax:
+		return m.max
+	case NestedScoreModeMin:
+		return m.min
+	case NestedScoreModeSum:
+		return m.sum
+	case NestedScoreModeNone:
+		return 0
+	default:
+		return m.sum / float64(m.count)
+	}
+}
+
+type nestedSearcher struct {
+	indexReader search.Reader
+	matches     []*nestedMatch
+	current     int
+	scoreMode   string
+	boost       float64
+	explain     bool
+}
+
+func (s *nestedSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	if s.current >= len(s.matches) {
+		return nil, nil
+	}
+	match := s.matches[s.current]
+	s.current++
+
+	dm := ctx.DocumentMatchPool.Get()
+	dm.SetReader(s.indexReader)
+	dm.Number = match.number
+	dm.Score = match.score(s.scoreMode) * s.boost
+	if s.explain {
+		children := make([]*search.Explanation, 0, 1)
+		if match.explanation != nil {
+			children = append(children, match.explanation)
+		}
+		dm.Explanation = search.NewExplanation(dm.Score,
+			"score mode ["+s.scoreMode+"] of "+strconv.Itoa(match.count)+" matching nested documents, boost "+
+				strconv.FormatFloat(s.boost, 'g', -1, 64)+", best match:", children...)
+	}
+	return dm, nil
+}
+
+func (s *nestedSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	s.current += sort.Search(len(s.matches)-s.current, func(i int) bool {
+		return s.matches[s.current+i].number >= number
+	})
+	return s.Next(ctx)
+}
+
+func (s *nestedSearcher) Close() error {
+	return nil
+}
+
+func (s *nestedSearcher) Count() uint64 {
+	return uint64(len(s.matches))
+}
+
+func (s *nestedSearcher) Min() int {
+	return 0
+}
+
+func (s *nestedSearcher) Size() int {
+	return len(s.matches) * 64
+}
+
+func (s *nestedSearcher) DocumentMatchPoolSize() int {
+	return 1
+}
+
+// NestedDocumentsQuery matches the hidden documents of the nested objects
+func NestedDocumentsQuery() bluge.Query {
+	return bluge.NewWildcardQuery("*").SetField(meta.NestedPathFieldName)
+}
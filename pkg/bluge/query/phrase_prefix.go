@@ -0,0 +1,107 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+)
+
+// PhrasePrefixQuery matches the phrase of the terms followed by a term starting with the prefix,
+// the prefix is expanded to at most maxExpansions terms of the field in each segment
+type PhrasePrefixQuery struct {
+	field         string
+	terms         []string
+	prefix        string
+	slop          int
+	maxExpansions int
+}
+
+func NewPhrasePrefixQuery(field string, terms []string, prefix string) *PhrasePrefixQuery {
+	return &PhrasePrefixQuery{field: field, terms: terms, prefix: prefix, maxExpansions: 50}
+}
+
+func (q *PhrasePrefixQuery) SetSlop(slop int) *PhrasePrefixQuery {
+	q.slop = slop
+	return q
+}
+
+func (q *PhrasePrefixQuery) SetMaxExpansions(maxExpansions int) *PhrasePrefixQuery {
+	q.maxExpansions = maxExpansions
+	return q
+}
+
+func (q *PhrasePrefixQuery) Field() string {
+	return q.field
+}
+
+func (q *PhrasePrefixQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	expansions, err := q.expand(i)
+	if err != nil {
+		return nil, err
+	}
+	if len(expansions) == 0 {
+		return searcher.NewMatchNoneSearcher(i, options)
+	}
+	terms := make([][]string, 0, len(q.terms)+1)
+	for _, term := range q.terms {
+		terms = append(terms, []string{term})
+	}
+	terms = append(terms, expansions)
+	return searcher.NewSloppyMultiPhraseSearcher(i, terms, q.field, q.slop, nil, options)
+}
+
+// expand returns the first maxExpansions terms of the field starting with the prefix
+func (q *PhrasePrefixQuery) expand(i search.Reader) ([]string, error) {
+	start := []byte(q.prefix)
+	dict, err := i.DictionaryIterator(q.field, nil, start, prefixEnd(start))
+	if err != nil {
+		return nil, err
+	}
+	defer dict.Close()
+
+	terms := make([]string, 0)
+	entry, err := dict.Next()
+	for err == nil && entry != nil && len(terms) < q.maxExpansions {
+		terms = append(terms, entry.Term())
+		entry, err = dict.Next()
+	}
+	return terms, err
+}
+
+func (q *PhrasePrefixQuery) String() string {
+	terms := append(append([]string{}, q.terms...), q.prefix+"*")
+	s := fieldString(q.field) + strconv.Quote(strings.Join(terms, " "))
+	if q.slop > 0 {
+		s += "~" + strconv.Itoa(q.slop)
+	}
+	return s
+}
+
+// prefixEnd returns the first key after all the keys starting with the prefix, nil if there is no end
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
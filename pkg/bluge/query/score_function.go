@@ -0,0 +1,239 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"math"
+
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/blugelabs/bluge/numeric/geo"
+)
+
+// DocValues is the doc values of the current document keyed by field
+type DocValues map[string][][]byte
+
+// Int64s returns the full precision numeric values of the field
+func (v DocValues) Int64s(field string) []int64 {
+	terms := v[field]
+	if len(terms) == 0 {
+		return nil
+	}
+	values := make([]int64, 0, len(terms))
+	for _, term := range terms {
+		coded := numeric.PrefixCoded(term)
+		if shift, err := coded.Shift(); err != nil || shift != 0 {
+			continue
+		}
+		if i, err := coded.Int64(); err == nil {
+			values = append(values, i)
+		}
+	}
+	return values
+}
+
+// Int64 returns the first full precision numeric value of the field
+func (v DocValues) Int64(field string) (int64, bool) {
+	for _, term := range v[field] {
+		coded := numeric.PrefixCoded(term)
+		if shift, err := coded.Shift(); err != nil || shift != 0 {
+			continue
+		}
+		if i, err := coded.Int64(); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Number returns the first value of a numeric field
+func (v DocValues) Number(field string) (float64, bool) {
+	i, ok := v.Int64(field)
+	if !ok {
+		return 0, false
+	}
+	return numeric.Int64ToFloat64(i), true
+}
+
+// Text returns the first value of a keyword field
+func (v DocValues) Text(field string) (string, bool) {
+	terms := v[field]
+	if len(terms) == 0 {
+		return "", false
+	}
+	return string(terms[0]), true
+}
+
+// Numbers returns the values of a numeric field
+func (v DocValues) Numbers(field string) []float64 {
+	values := v.Int64s(field)
+	numbers := make([]float64, len(values))
+	for i, value := range values {
+		numbers[i] = numeric.Int64ToFloat64(value)
+	}
+	return numbers
+}
+
+// GeoPoints returns the values of a geo_point field as [lon, lat] pairs
+func (v DocValues) GeoPoints(field string) [][2]float64 {
+	values := v.Int64s(field)
+	points := make([][2]float64, len(values))
+	for i, value := range values {
+		points[i] = [2]float64{geo.MortonUnhashLon(uint64(value)), geo.MortonUnhashLat(uint64(value))}
+	}
+	return points
+}
+
+// ScoreFunction computes a score of the document from the query score and its doc values
+type ScoreFunction interface {
+	Fields() []string
+	Score(score float64, values DocValues) float64
+}
+
+// FieldValueFactorFunction scores the document by the value of a numeric field
+type FieldValueFactorFunction struct {
+	Field      string
+	Factor     float64
+	Modifier   string
+	Missing    float64
+	HasMissing bool
+}
+
+func (f *FieldValueFactorFunction) Fields() []string {
+	return []string{f.Field}
+}
+
+func (f *FieldValueFactorFunction) Score(_ float64, values DocValues) float64 {
+	v, ok := values.Number(f.Field)
+	if !ok {
+		if !f.HasMissing {
+			return 1
+		}
+		v = f.Missing
+	}
+
+	v *= f.Factor
+	switch f.Modifier {
+	case "log":
+		v = math.Log10(v)
+	case "log1p":
+		v = math.Log10(v + 1)
+	case "log2p":
+		v = math.Log10(v + 2)
+	case "ln":
+		v = math.Log(v)
+	case "ln1p":
+		v = math.Log1p(v)
+	case "ln2p":
+		v = math.Log(v + 2)
+	case "square":
+		v = v * v
+	case "sqrt":
+		v = math.Sqrt(v)
+	case "reciprocal":
+		v = 1 / v
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+const (
+	DecayGauss  = "gauss"
+	DecayExp    = "exp"
+	DecayLinear = "linear"
+
+	FieldTypeNumeric  = "numeric"
+	FieldTypeKeyword  = "keyword"
+	FieldTypeDate     = "date"
+	FieldTypeGeoPoint = "geo_point"
+)
+
+// DecayFunction scores the document by the distance of a field value from the origin.
+// Date values are measured in nanoseconds and geo_point values in meters.
+type DecayFunction struct {
+	Field          string
+	Type           string
+	Curve          string
+	Origin         float64
+	OriginLon      float64
+	OriginLat      float64
+	Scale          float64
+	Offset         float64
+	Decay          float64
+	MultiValueMode string
+}
+
+func (f *DecayFunction) Fields() []string {
+	return []string{f.Field}
+}
+
+func (f *DecayFunction) Score(_ float64, values DocValues) float64 {
+	var distances []float64
+	switch f.Type {
+	case FieldTypeGeoPoint:
+		points := values.GeoPoints(f.Field)
+		distances = make([]float64, len(points))
+		for i, point := range points {
+			distances[i] = geo.Haversin(f.OriginLon, f.OriginLat, point[0], point[1]) * 1000
+		}
+	case FieldTypeDate:
+		dates := values.Int64s(f.Field)
+		distances = make([]float64, len(dates))
+		for i, date := range dates {
+			distances[i] = math.Abs(float64(date) - f.Origin)
+		}
+	default:
+		numbers := values.Numbers(f.Field)
+		distances = make([]float64, len(numbers))
+		for i, number := range numbers {
+			distances[i] = math.Abs(number - f.Origin)
+		}
+	}
+	if len(distances) == 0 {
+		return 1
+	}
+
+	var distance float64
+	for i, d := range distances {
+		d = math.Max(0, d-f.Offset)
+		if i == 0 {
+			distance = d
+			continue
+		}
+		switch f.MultiValueMode {
+		case "max":
+			distance = math.Max(distance, d)
+		case "avg", "sum":
+			distance += d
+		default:
+			distance = math.Min(distance, d)
+		}
+	}
+	if f.MultiValueMode == "avg" {
+		distance /= float64(len(distances))
+	}
+
+	switch f.Curve {
+	case DecayExp:
+		return math.Exp(math.Log(f.Decay) / f.Scale * distance)
+	case DecayLinear:
+		s := f.Scale / (1 - f.Decay)
+		return math.Max(0, (s-distance)/s)
+	default:
+		return math.Exp(math.Log(f.Decay) * distance * distance / (f.Scale * f.Scale))
+	}
+}
@@ -0,0 +1,908 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxScriptStack is the max depth of the evaluation stack, it keeps the evaluation on the goroutine stack
+const maxScriptStack = 64
+
+type scriptOp uint8
+
+const (
+	scriptOpConst scriptOp = iota
+	scriptOpScore
+	scriptOpField
+	scriptOpNeg
+	scriptOpNot
+	scriptOpAdd
+	scriptOpSub
+	scriptOpMul
+	scriptOpDiv
+	scriptOpMod
+	scriptOpEq
+	scriptOpNe
+	scriptOpLt
+	scriptOpLe
+	scriptOpGt
+	scriptOpGe
+	scriptOpAnd
+	scriptOpOr
+	scriptOpFunc1
+	scriptOpFunc2
+	scriptOpJumpIfFalse
+	scriptOpJump
+	scriptOpStrConst
+	scriptOpStrField
+	scriptOpToStr
+	scriptOpConcat
+	scriptOpStrCmp
+)
+
+type scriptInstruction struct {
+	op    scriptOp
+	arg   int
+	value float64
+}
+
+type scriptField struct {
+	name    string
+	date    bool
+	keyword bool
+}
+
+var scriptFunctions1 = map[string]int{
+	"abs": 0, "ceil": 1, "floor": 2, "round": 3, "sqrt": 4, "exp": 5,
+	"log": 6, "ln": 6, "log10": 7, "log1p": 8, "sin": 9, "cos": 10, "tan": 11, "signum": 12,
+}
+
+var scriptFunctions2 = map[string]int{
+	"pow": 0, "min": 1, "max": 2, "saturation": 3, "atan2": 4,
+}
+
+var scriptConstants = map[string]float64{
+	"PI": math.Pi,
+	"E":  math.E,
+}
+
+// Script is a compiled arithmetic expression evaluated for every hit, e.g.
+//
+//	_score * log(1 + doc['views'].value)
+//	params.boost * Math.sqrt(views) + (likes > 100 ? 1 : 0)
+//
+// Scripts only read the query score, numeric and date doc values and params,
+// date values are epoch milliseconds and missing values are 0.
+//
+// Value scripts also read keyword doc values and string literals, the + operator
+// concatenates strings and numbers, e.g. doc['first'].value + ' ' + doc['last'].value
+type Script struct {
+	source   string
+	code     []scriptInstruction
+	fields   []scriptField
+	strings  []string
+	stack    int
+	strStack int
+	text     bool // the result is a string
+}
+
+// NewScript compiles the script source, fieldType returns the mapping type of the field
+func NewScript(source string, params map[string]float64, fieldType func(field string) string) (*Script, error) {
+	return compileScript(&scriptParser{
+		source:    source,
+		params:    params,
+		fieldType: fieldType,
+		script:    &Script{source: source},
+	})
+}
+
+// NewValueScript compiles the script source of a computed value, unlike NewScript
+// the script can read keyword fields and return a string
+func NewValueScript(source string, params map[string]float64, fieldType func(field string) string) (*Script, error) {
+	return compileScript(&scriptParser{
+		source:    source,
+		params:    params,
+		fieldType: fieldType,
+		script:    &Script{source: source},
+		text:      true,
+	})
+}
+
+// ValidateScript checks the syntax of the script source, the params and the fields
+// are resolved when the script is used by a query
+func ValidateScript(source string) error {
+	_, err := compileScript(&scriptParser{
+		source:    source,
+		fieldType: func(string) string { return FieldTypeNumeric },
+		script:    &Script{source: source},
+		lenient:   true,
+		text:      true,
+	})
+	return err
+}
+
+// ScriptFields returns the fields read by the script source, the doc values and the bare identifiers
+func ScriptFields(source string) ([]string, error) {
+	script, err := compileScript(&scriptParser{
+		source:    source,
+		fieldType: func(string) string { return FieldTypeNumeric },
+		script:    &Script{source: source},
+		lenient:   true,
+		text:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return script.Fields(), nil
+}
+
+func compileScript(p *scriptParser) (*Script, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == scriptTokenEOF {
+		return nil, fmt.Errorf("script is empty")
+	}
+	if err := p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != scriptTokenEOF {
+		return nil, p.errorf("unexpected token [%s]", p.tok.text)
+	}
+	if p.script.stack > maxScriptStack || p.script.strStack > maxScriptStack {
+		return nil, fmt.Errorf("script [%s] is too complex", p.source)
+	}
+	p.script.text = p.text && p.last
+	return p.script, nil
+}
+
+func (s *Script) Source() string {
+	return s.source
+}
+
+func (s *Script) Fields() []string {
+	fields := make([]string, len(s.fields))
+	for i, f := range s.fields {
+		fields[i] = f.name
+	}
+	return fields
+}
+
+// Score returns the value of the script, negative and invalid values are 0
+func (s *Script) Score(score float64, values DocValues) float64 {
+	v := s.Eval(score, values)
+	if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+		return 0
+	}
+	return v
+}
+
+// IsText returns true if the script returns a string
+func (s *Script) IsText() bool {
+	return s.text
+}
+
+// Eval evaluates the script with the score and doc values of a document
+func (s *Script) Eval(score float64, values DocValues) float64 {
+	v, _ := s.run(score, values)
+	return v
+}
+
+// Text evaluates the script and returns the result as a string, numbers are formatted
+func (s *Script) Text(score float64, values DocValues) string {
+	v, str := s.run(score, values)
+	if s.text {
+		return str
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// run evaluates the script, strings are kept on a separate stack which is only
+// allocated by the scripts reading strings
+func (s *Script) run(score float64, values DocValues) (float64, string) {
+	var stack [maxScriptStack]float64
+	var strs []string
+	if s.strStack > 0 {
+		strs = make([]string, 0, s.strStack)
+	}
+	sp := 0
+	for pc := 0; pc < len(s.code); pc++ {
+		in := &s.code[pc]
+		switch in.op {
+		case scriptOpConst:
+			stack[sp] = in.value
+			sp++
+		case scriptOpScore:
+			stack[sp] = score
+			sp++
+		case scriptOpField:
+			f := &s.fields[in.arg]
+			var v float64
+			if f.date {
+				if i, ok := values.Int64(f.name); ok {
+					v = float64(i / 1e6)
+				}
+			} else {
+				v, _ = values.Number(f.name)
+			}
+			stack[sp] = v
+			sp++
+		case scriptOpNeg:
+			stack[sp-1] = -stack[sp-1]
+		case scriptOpNot:
+			stack[sp-1] = scriptBool(stack[sp-1] == 0)
+		case scriptOpFunc1:
+			stack[sp-1] = scriptFunc1(in.arg, stack[sp-1])
+		case scriptOpJumpIfFalse:
+			sp--
+			if stack[sp] == 0 {
+				pc = in.arg - 1
+			}
+		case scriptOpJump:
+			pc = in.arg - 1
+		case scriptOpStrConst:
+			strs = append(strs, s.strings[in.arg])
+		case scriptOpStrField:
+			v, _ := values.Text(s.fields[in.arg].name)
+			strs = append(strs, v)
+		case scriptOpToStr:
+			sp--
+			strs = append(strs, strconv.FormatFloat(stack[sp], 'f', -1, 64))
+		case scriptOpConcat:
+			n := len(strs)
+			if in.arg == 1 {
+				// the left operand was converted after the right one
+				strs[n-2] = strs[n-1] + strs[n-2]
+			} else {
+				strs[n-2] += strs[n-1]
+			}
+			strs = strs[:n-1]
+		case scriptOpStrCmp:
+			n := len(strs)
+			a, b := strs[n-2], strs[n-1]
+			strs = strs[:n-2]
+			var v bool
+			switch scriptOp(in.arg) {
+			case scriptOpEq:
+				v = a == b
+			case scriptOpNe:
+				v = a != b
+			case scriptOpLt:
+				v = a < b
+			case scriptOpLe:
+				v = a <= b
+			case scriptOpGt:
+				v = a > b
+			case scriptOpGe:
+				v = a >= b
+			}
+			stack[sp] = scriptBool(v)
+			sp++
+		default:
+			sp--
+			a, b := stack[sp-1], stack[sp]
+			var v float64
+			switch in.op {
+			case scriptOpAdd:
+				v = a + b
+			case scriptOpSub:
+				v = a - b
+			case scriptOpMul:
+				v = a * b
+			case scriptOpDiv:
+				v = a / b
+			case scriptOpMod:
+				v = math.Mod(a, b)
+			case scriptOpEq:
+				v = scriptBool(a == b)
+			case scriptOpNe:
+				v = scriptBool(a != b)
+			case scriptOpLt:
+				v = scriptBool(a < b)
+			case scriptOpLe:
+				v = scriptBool(a <= b)
+			case scriptOpGt:
+				v = scriptBool(a > b)
+			case scriptOpGe:
+				v = scriptBool(a >= b)
+			case scriptOpAnd:
+				v = scriptBool(a != 0 && b != 0)
+			case scriptOpOr:
+				v = scriptBool(a != 0 || b != 0)
+			case scriptOpFunc2:
+				v = scriptFunc2(in.arg, a, b)
+			}
+			stack[sp-1] = v
+		}
+	}
+	if len(strs) > 0 {
+		return 0, strs[len(strs)-1]
+	}
+	if sp == 0 {
+		return 0, ""
+	}
+	return stack[sp-1], ""
+}
+
+func scriptBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func scriptFunc1(fn int, v float64) float64 {
+	switch fn {
+	case 0:
+		return math.Abs(v)
+	case 1:
+		return math.Ceil(v)
+	case 2:
+		return math.Floor(v)
+	case 3:
+		return math.Round(v)
+	case 4:
+		return math.Sqrt(v)
+	case 5:
+		return math.Exp(v)
+	case 6:
+		return math.Log(v)
+	case 7:
+		return math.Log10(v)
+	case 8:
+		return math.Log1p(v)
+	case 9:
+		return math.Sin(v)
+	case 10:
+		return math.Cos(v)
+	case 11:
+		return math.Tan(v)
+	case 12:
+		if v > 0 {
+			return 1
+		} else if v < 0 {
+			return -1
+		}
+		return 0
+	}
+	return math.NaN()
+}
+
+func scriptFunc2(fn int, a, b float64) float64 {
+	switch fn {
+	case 0:
+		return math.Pow(a, b)
+	case 1:
+		return math.Min(a, b)
+	case 2:
+		return math.Max(a, b)
+	case 3:
+		return a / (b + a)
+	case 4:
+		return math.Atan2(a, b)
+	}
+	return math.NaN()
+}
+
+type scriptTokenKind uint8
+
+const (
+	scriptTokenEOF scriptTokenKind = iota
+	scriptTokenNumber
+	scriptTokenIdent
+	scriptTokenString
+	scriptTokenOperator
+)
+
+type scriptToken struct {
+	kind scriptTokenKind
+	text string
+	pos  int
+}
+
+type scriptParser struct {
+	source    string
+	pos       int
+	tok       scriptToken
+	params    map[string]float64
+	fieldType func(field string) string
+	script    *Script
+	depth     int  // current stack depth
+	strDepth  int  // current string stack depth
+	nesting   int  // current nesting of expressions
+	lenient   bool // undefined params are allowed
+	text      bool // strings and keyword fields are allowed
+	last      bool // the last parsed expression is a string
+}
+
+func (p *scriptParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("script [%s] compile error at position %d: %s", p.source, p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token
+func (p *scriptParser) next() error {
+	s := p.source
+	for p.pos < len(s) && (s[p.pos] == ' ' || s[p.pos] == '\t' || s[p.pos] == '\n' || s[p.pos] == '\r') {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(s) {
+		p.tok = scriptToken{kind: scriptTokenEOF, pos: start}
+		return nil
+	}
+
+	c := s[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.' && p.pos+1 < len(s) && s[p.pos+1] >= '0' && s[p.pos+1] <= '9':
+		for p.pos < len(s) && (s[p.pos] >= '0' && s[p.pos] <= '9' || s[p.pos] == '.') {
+			p.pos++
+		}
+		if p.pos < len(s) && (s[p.pos] == 'e' || s[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(s) && (s[p.pos] == '+' || s[p.pos] == '-') {
+				p.pos++
+			}
+			for p.pos < len(s) && s[p.pos] >= '0' && s[p.pos] <= '9' {
+				p.pos++
+			}
+		}
+		p.tok = scriptToken{kind: scriptTokenNumber, text: s[start:p.pos], pos: start}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(s) && (s[p.pos] == '_' || s[p.pos] >= 'a' && s[p.pos] <= 'z' || s[p.pos] >= 'A' && s[p.pos] <= 'Z' || s[p.pos] >= '0' && s[p.pos] <= '9') {
+			p.pos++
+		}
+		p.tok = scriptToken{kind: scriptTokenIdent, text: s[start:p.pos], pos: start}
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(s[p.pos+1:], c)
+		if end < 0 {
+			p.tok.pos = start
+			return p.errorf("unterminated string")
+		}
+		p.pos += end + 2
+		p.tok = scriptToken{kind: scriptTokenString, text: s[start+1 : p.pos-1], pos: start}
+	default:
+		if p.pos+1 < len(s) {
+			switch s[p.pos : p.pos+2] {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				p.pos += 2
+				p.tok = scriptToken{kind: scriptTokenOperator, text: s[start:p.pos], pos: start}
+				return nil
+			}
+		}
+		if !strings.ContainsRune("+-*/%()<>!?:,.[]", rune(c)) {
+			p.tok.pos = start
+			return p.errorf("unexpected character [%c]", c)
+		}
+		p.pos++
+		p.tok = scriptToken{kind: scriptTokenOperator, text: s[start:p.pos], pos: start}
+	}
+	return nil
+}
+
+func (p *scriptParser) is(op string) bool {
+	return p.tok.kind == scriptTokenOperator && p.tok.text == op
+}
+
+func (p *scriptParser) expect(op string) error {
+	if !p.is(op) {
+		if p.tok.kind == scriptTokenEOF {
+			return p.errorf("expected [%s] but reached the end of the script", op)
+		}
+		return p.errorf("expected [%s] but found [%s]", op, p.tok.text)
+	}
+	return p.next()
+}
+
+func (p *scriptParser) emit(op scriptOp, arg int, value float64) int {
+	switch op {
+	case scriptOpConst, scriptOpScore, scriptOpField:
+		p.depth++
+	case scriptOpNeg, scriptOpNot, scriptOpFunc1, scriptOpJump:
+	case scriptOpStrConst, scriptOpStrField:
+		p.strDepth++
+	case scriptOpToStr:
+		p.depth--
+		p.strDepth++
+	case scriptOpConcat:
+		p.strDepth--
+	case scriptOpStrCmp:
+		p.strDepth -= 2
+		p.depth++
+	default:
+		p.depth--
+	}
+	if p.depth > p.script.stack {
+		p.script.stack = p.depth
+	}
+	if p.strDepth > p.script.strStack {
+		p.script.strStack = p.strDepth
+	}
+	p.script.code = append(p.script.code, scriptInstruction{op: op, arg: arg, value: value})
+	return len(p.script.code) - 1
+}
+
+// parseExpr parses: or ['?' expr ':' expr]
+func (p *scriptParser) parseExpr() error {
+	p.nesting++
+	defer func() { p.nesting-- }()
+	if p.nesting > maxScriptStack {
+		return p.errorf("script is too complex")
+	}
+	if err := p.parseBinary(0); err != nil {
+		return err
+	}
+	if !p.is("?") {
+		return nil
+	}
+	if p.last {
+		return p.errorf("the condition of [?] should be a number")
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	jumpFalse := p.emit(scriptOpJumpIfFalse, 0, 0)
+	if err := p.parseExpr(); err != nil {
+		return err
+	}
+	text := p.last
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	jumpEnd := p.emit(scriptOpJump, 0, 0)
+	// only one of the branches is on the stack
+	if text {
+		p.strDepth--
+	} else {
+		p.depth--
+	}
+	p.script.code[jumpFalse].arg = len(p.script.code)
+	if err := p.parseExpr(); err != nil {
+		return err
+	}
+	if p.last != text {
+		return p.errorf("both branches of [?] should return the same type")
+	}
+	p.script.code[jumpEnd].arg = len(p.script.code)
+	return nil
+}
+
+var scriptBinaryOperators = []map[string]scriptOp{
+	{"||": scriptOpOr},
+	{"&&": scriptOpAnd},
+	{"==": scriptOpEq, "!=": scriptOpNe},
+	{"<": scriptOpLt, "<=": scriptOpLe, ">": scriptOpGt, ">=": scriptOpGe},
+	{"+": scriptOpAdd, "-": scriptOpSub},
+	{"*": scriptOpMul, "/": scriptOpDiv, "%": scriptOpMod},
+}
+
+// parseBinary parses the left associative binary operators by precedence level
+func (p *scriptParser) parseBinary(level int) error {
+	if level == len(scriptBinaryOperators) {
+		return p.parseUnary()
+	}
+	if err := p.parseBinary(level + 1); err != nil {
+		return err
+	}
+	for p.tok.kind == scriptTokenOperator {
+		op, ok := scriptBinaryOperators[level][p.tok.text]
+		if !ok {
+			break
+		}
+		text := p.tok.text
+		left := p.last
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.parseBinary(level + 1); err != nil {
+			return err
+		}
+		right := p.last
+		switch {
+		case !left && !right:
+			p.emit(op, 0, 0)
+		case op == scriptOpAdd:
+			// the number operand is converted to a string
+			switch {
+			case !left:
+				p.emit(scriptOpToStr, 0, 0)
+				p.emit(scriptOpConcat, 1, 0)
+			case !right:
+				p.emit(scriptOpToStr, 0, 0)
+				p.emit(scriptOpConcat, 0, 0)
+			default:
+				p.emit(scriptOpConcat, 0, 0)
+			}
+			p.last = true
+		case op >= scriptOpEq && op <= scriptOpGe && left && right:
+			p.emit(scriptOpStrCmp, int(op), 0)
+			p.last = false
+		default:
+			return p.errorf("operator [%s] is not supported between %s and %s", text, scriptTypeName(left), scriptTypeName(right))
+		}
+	}
+	return nil
+}
+
+func scriptTypeName(text bool) string {
+	if text {
+		return "string"
+	}
+	return "number"
+}
+
+func (p *scriptParser) parseUnary() error {
+	if !p.is("-") && !p.is("!") && !p.is("+") {
+		return p.parsePrimary()
+	}
+	// the prefix operators nest like the parentheses, e.g. !!!!x
+	p.nesting++
+	defer func() { p.nesting-- }()
+	if p.nesting > maxScriptStack {
+		return p.errorf("script is too complex")
+	}
+	switch {
+	case p.is("-"), p.is("!"):
+		op := scriptOpNeg
+		if p.is("!") {
+			op = scriptOpNot
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+		if p.last {
+			return p.errorf("unary operator is not supported for strings")
+		}
+		p.emit(op, 0, 0)
+		return nil
+	case p.is("+"):
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+		if p.last {
+			return p.errorf("unary operator is not supported for strings")
+		}
+		return nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *scriptParser) parsePrimary() error {
+	tok := p.tok
+	p.last = false
+	switch tok.kind {
+	case scriptTokenString:
+		if !p.text {
+			return p.errorf("strings are not supported")
+		}
+		p.script.strings = append(p.script.strings, tok.text)
+		p.emit(scriptOpStrConst, len(p.script.strings)-1, 0)
+		p.last = true
+		return p.next()
+	case scriptTokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return p.errorf("invalid number [%s]", tok.text)
+		}
+		p.emit(scriptOpConst, 0, v)
+		return p.next()
+	case scriptTokenIdent:
+		if err := p.next(); err != nil {
+			return err
+		}
+		switch tok.text {
+		case "_score":
+			p.emit(scriptOpScore, 0, 0)
+			return nil
+		case "doc":
+			return p.parseDoc()
+		case "params":
+			return p.parseParam()
+		case "Math":
+			if err := p.expect("."); err != nil {
+				return err
+			}
+			name := p.tok
+			if name.kind != scriptTokenIdent {
+				return p.errorf("expected a function name after [Math.]")
+			}
+			if err := p.next(); err != nil {
+				return err
+			}
+			if v, ok := scriptConstants[name.text]; ok && !p.is("(") {
+				p.emit(scriptOpConst, 0, v)
+				return nil
+			}
+			return p.parseCall(name.text)
+		}
+		if p.is("(") {
+			return p.parseCall(tok.text)
+		}
+		// a bare identifier is a field, e.g. views or stats.views
+		name := tok.text
+		for p.is(".") {
+			if err := p.next(); err != nil {
+				return err
+			}
+			if p.tok.kind != scriptTokenIdent {
+				return p.errorf("expected a field name after [%s.]", name)
+			}
+			name += "." + p.tok.text
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+		return p.field(name)
+	case scriptTokenOperator:
+		if tok.text == "(" {
+			if err := p.next(); err != nil {
+				return err
+			}
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+			return p.expect(")")
+		}
+	case scriptTokenEOF:
+		return p.errorf("unexpected end of the script")
+	}
+	return p.errorf("unexpected token [%s]", tok.text)
+}
+
+// parseDoc parses: doc['field'] or doc['field'].value
+func (p *scriptParser) parseDoc() error {
+	if err := p.expect("["); err != nil {
+		return err
+	}
+	if p.tok.kind != scriptTokenString {
+		return p.errorf("expected a field name in doc[]")
+	}
+	name := p.tok.text
+	if err := p.next(); err != nil {
+		return err
+	}
+	if err := p.expect("]"); err != nil {
+		return err
+	}
+	if p.is(".") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.kind != scriptTokenIdent || p.tok.text != "value" {
+			return p.errorf("doc['%s'] only supports [value]", name)
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+	return p.field(name)
+}
+
+// parseParam parses: params.name or params['name']
+func (p *scriptParser) parseParam() error {
+	bracket := p.is("[")
+	if !bracket && !p.is(".") {
+		return p.errorf("expected a param name after [params]")
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	if bracket && p.tok.kind != scriptTokenString || !bracket && p.tok.kind != scriptTokenIdent {
+		return p.errorf("expected a param name after [params]")
+	}
+	name := p.tok.text
+	if err := p.next(); err != nil {
+		return err
+	}
+	if bracket {
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	}
+	v, ok := p.params[name]
+	if !ok && !p.lenient {
+		return p.errorf("param [%s] is not defined", name)
+	}
+	p.emit(scriptOpConst, 0, v)
+	return nil
+}
+
+func (p *scriptParser) parseCall(name string) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	args := 0
+	for !p.is(")") {
+		if args > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+		if p.last {
+			return p.errorf("function [%s] expects numbers but got a string", name)
+		}
+		args++
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	if fn, ok := scriptFunctions1[name]; ok {
+		if args != 1 {
+			return p.errorf("function [%s] expects 1 argument but got %d", name, args)
+		}
+		p.emit(scriptOpFunc1, fn, 0)
+		return nil
+	}
+	if fn, ok := scriptFunctions2[name]; ok {
+		if args != 2 {
+			return p.errorf("function [%s] expects 2 arguments but got %d", name, args)
+		}
+		p.emit(scriptOpFunc2, fn, 0)
+		return nil
+	}
+	return p.errorf("unknown function [%s]", name)
+}
+
+func (p *scriptParser) field(name string) error {
+	var date, keyword bool
+	switch typ := p.fieldType(name); typ {
+	case FieldTypeNumeric:
+	case FieldTypeDate:
+		date = true
+	case FieldTypeKeyword:
+		if !p.text {
+			return p.errorf("field [%s] of type [%s] is not supported, only numeric and date fields can be used", name, typ)
+		}
+		keyword = true
+	case "":
+		return p.errorf("no field found for [%s] in mapping", name)
+	default:
+		if p.text {
+			return p.errorf("field [%s] of type [%s] is not supported, only keyword, numeric and date fields can be used", name, typ)
+		}
+		return p.errorf("field [%s] of type [%s] is not supported, only numeric and date fields can be used", name, typ)
+	}
+	idx := -1
+	for i, f := range p.script.fields {
+		if f.name == name {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		idx = len(p.script.fields)
+		p.script.fields = append(p.script.fields, scriptField{name: name, date: date, keyword: keyword})
+	}
+	if keyword {
+		p.emit(scriptOpStrField, idx, 0)
+		p.last = true
+		return nil
+	}
+	p.emit(scriptOpField, idx, 0)
+	return nil
+}
@@ -0,0 +1,143 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package query
+
+import (
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	segment "github.com/blugelabs/bluge_segment_api"
+)
+
+// ScriptFilterQuery matches the documents whose doc values are accepted by the match function,
+// every document of the reader is visited so it is as slow as the number of documents
+type ScriptFilterQuery struct {
+	description string
+	fields      []string
+	match       func(values DocValues) bool
+	boost       float64
+}
+
+// NewScriptFilterQuery returns a query matching the documents by the doc values of the fields,
+// the description is used as the string of the query
+func NewScriptFilterQuery(description string, fields []string, match func(values DocValues) bool) *ScriptFilterQuery {
+	return &ScriptFilterQuery{description: description, fields: fields, match: match, boost: 1}
+}
+
+func (q *ScriptFilterQuery) SetBoost(boost float64) *ScriptFilterQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *ScriptFilterQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *ScriptFilterQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	allOptions := options
+	allOptions.Explain = false
+	child, err := bluge.NewMatchAllQuery().Searcher(i, allOptions)
+	if err != nil {
+		return nil, err
+	}
+	s := &scriptFilterSearcher{
+		query:   q,
+		child:   child,
+		values:  make(DocValues),
+		explain: options.Explain,
+	}
+	if len(q.fields) > 0 {
+		if s.dvReader, err = i.DocumentValueReader(q.fields); err != nil {
+			_ = child.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (q *ScriptFilterQuery) String() string {
+	return boostString("script("+q.description+")", q.boost)
+}
+
+type scriptFilterSearcher struct {
+	query    *ScriptFilterQuery
+	child    search.Searcher
+	dvReader segment.DocumentValueReader
+	values   DocValues
+	explain  bool
+}
+
+func (s *scriptFilterSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	next, err := s.child.Next(ctx)
+	return s.filter(ctx, next, err)
+}
+
+func (s *scriptFilterSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	next, err := s.child.Advance(ctx, number)
+	return s.filter(ctx, next, err)
+}
+
+// filter skips the documents until one of them matches
+func (s *scriptFilterSearcher) filter(ctx *search.Context, next *search.DocumentMatch, err error) (*search.DocumentMatch, error) {
+	for err == nil && next != nil {
+		var ok bool
+		if ok, err = s.matches(next); err != nil {
+			break
+		}
+		if ok {
+			next.Score = s.query.boost
+			if s.explain {
+				next.Explanation = search.NewExplanation(s.query.boost, "script filter")
+			}
+			return next, nil
+		}
+		ctx.DocumentMatchPool.Put(next)
+		next, err = s.child.Next(ctx)
+	}
+	return nil, err
+}
+
+func (s *scriptFilterSearcher) matches(doc *search.DocumentMatch) (bool, error) {
+	for field := range s.values {
+		s.values[field] = s.values[field][:0]
+	}
+	if s.dvReader != nil {
+		if err := s.dvReader.VisitDocumentValues(doc.Number, func(field string, term []byte) {
+			s.values[field] = append(s.values[field], term)
+		}); err != nil {
+			return false, err
+		}
+	}
+	return s.query.match(s.values), nil
+}
+
+func (s *scriptFilterSearcher) Close() error {
+	return s.child.Close()
+}
+
+func (s *scriptFilterSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *scriptFilterSearcher) Min() int {
+	return s.child.Min()
+}
+
+func (s *scriptFilterSearcher) Size() int {
+	return s.child.Size()
+}
+
+func (s *scriptFilterSearcher) DocumentMatchPoolSize() int {
+	return s.child.DocumentMatchPoolSize()
+}
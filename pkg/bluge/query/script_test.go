@@ -0,0 +1,176 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScript_Eval(t *testing.T) {
+	fieldType := func(field string) string {
+		switch field {
+		case "views", "stats.likes":
+			return FieldTypeNumeric
+		case "published":
+			return FieldTypeDate
+		case "title":
+			return "text"
+		}
+		return ""
+	}
+	published := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := DocValues{
+		"views":     [][]byte{numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(99), 0)},
+		"published": [][]byte{numeric.MustNewPrefixCodedInt64(published.UnixNano(), 0)},
+	}
+	params := map[string]float64{"boost": 2}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    float64
+		wantErr bool
+	}{
+		{name: "score", source: "_score", want: 1.5},
+		{name: "bare field", source: "_score * log(1 + views)", want: 1.5 * math.Log(100)},
+		{name: "doc field", source: "doc['views'].value / 3", want: 33},
+		{name: "doc field without value", source: `doc["views"] + 1`, want: 100},
+		{name: "missing field", source: "stats.likes + 1", want: 1},
+		{name: "date field", source: "doc['published'].value", want: float64(published.UnixMilli())},
+		{name: "params", source: "params.boost * params['boost']", want: 4},
+		{name: "math prefix", source: "Math.max(Math.sqrt(views + 1), Math.PI)", want: 10},
+		{name: "precedence", source: "1 + 2 * 3 - -4 % 3", want: 8},
+		{name: "parentheses", source: "(1 + 2) * 3", want: 9},
+		{name: "ternary", source: "views > 100 ? 10 : views >= 99 && !(views == 0) ? 5 : 1", want: 5},
+		{name: "exponent", source: "1.5e2 + .5", want: 150.5},
+		{name: "empty", source: " ", wantErr: true},
+		{name: "unknown field", source: "likes * 2", wantErr: true},
+		{name: "text field", source: "doc['title'].value", wantErr: true},
+		{name: "unknown param", source: "params.missing", wantErr: true},
+		{name: "unknown function", source: "eval(1)", wantErr: true},
+		{name: "wrong arguments", source: "pow(2)", wantErr: true},
+		{name: "unbalanced", source: "(1 + 2", wantErr: true},
+		{name: "trailing", source: "1 2", wantErr: true},
+		{name: "invalid character", source: "views; os.Exit(1)", wantErr: true},
+		{name: "unterminated string", source: "doc['views", wantErr: true},
+		{name: "nested parentheses", source: strings.Repeat("(", 100000) + "1" + strings.Repeat(")", 100000), wantErr: true},
+		{name: "nested unary operators", source: strings.Repeat("!", 100000) + "views", wantErr: true},
+		{name: "nested signs", source: strings.Repeat("-+", 100000) + "views", wantErr: true},
+		{name: "few unary operators", source: "!!-+-views", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := NewScript(tt.source, params, fieldType)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, script.Eval(1.5, values), 0.0001)
+		})
+	}
+
+	t.Run("score is not negative", func(t *testing.T) {
+		script, err := NewScript("-views", nil, fieldType)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), script.Score(1, values))
+		script, err = NewScript("log(0)", nil, fieldType)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), script.Score(1, values))
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		script, err := NewScript("_score * log(1 + views) + (doc['published'].value > 0 ? params.boost : 1)", params, fieldType)
+		assert.NoError(t, err)
+		allocs := testing.AllocsPerRun(100, func() {
+			script.Score(1.5, values)
+		})
+		assert.Equal(t, float64(0), allocs)
+	})
+}
+
+func TestValidateScript(t *testing.T) {
+	assert.NoError(t, ValidateScript("_score * log(1 + doc['views'].value) * params.boost"))
+	assert.NoError(t, ValidateScript("any.field > params['limit'] ? 1 : 0"))
+	assert.Error(t, ValidateScript(""))
+	assert.Error(t, ValidateScript("pow(2)"))
+	assert.Error(t, ValidateScript("(1 + 2"))
+}
+
+func TestValueScript(t *testing.T) {
+	fieldType := func(field string) string {
+		switch field {
+		case "first", "last":
+			return FieldTypeKeyword
+		case "age":
+			return FieldTypeNumeric
+		case "title":
+			return "text"
+		}
+		return ""
+	}
+	values := DocValues{
+		"first": [][]byte{[]byte("John")},
+		"last":  [][]byte{[]byte("Doe")},
+		"age":   [][]byte{numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(42), 0)},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		text    bool
+		want    string
+		wantErr bool
+	}{
+		{name: "concat", source: "doc['first'].value + ' ' + doc['last'].value", text: true, want: "John Doe"},
+		{name: "number and string", source: "age + ' years'", text: true, want: "42 years"},
+		{name: "string and number", source: "'age ' + (age + 1)", text: true, want: "age 43"},
+		{name: "compare strings", source: "first == 'John' ? 1 : 0", want: "1"},
+		{name: "string ternary", source: "age > 40 ? 'old' : 'young'", text: true, want: "old"},
+		{name: "number", source: "age * 2", want: "84"},
+		{name: "subtract strings", source: "first - last", wantErr: true},
+		{name: "compare string and number", source: "first > 1", wantErr: true},
+		{name: "mixed ternary", source: "age > 40 ? 'old' : 1", wantErr: true},
+		{name: "string function", source: "abs(first)", wantErr: true},
+		{name: "negative string", source: "-first", wantErr: true},
+		{name: "text field", source: "doc['title'].value", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := NewValueScript(tt.source, nil, fieldType)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.text, script.IsText())
+			assert.Equal(t, tt.want, script.Text(0, values))
+		})
+	}
+
+	t.Run("score scripts don't read strings", func(t *testing.T) {
+		_, err := NewScript("doc['first'].value", nil, fieldType)
+		assert.Error(t, err)
+		_, err = NewScript("'a'", nil, fieldType)
+		assert.Error(t, err)
+	})
+}
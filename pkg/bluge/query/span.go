@@ -0,0 +1,346 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+	"github.com/blugelabs/bluge/search/similarity"
+)
+
+// Span is the range of positions [Start, End) of a match in a field, positions start from 0
+type Span struct {
+	Start int
+	End   int
+}
+
+// SpanQuery is a query which matches spans of positions in a field, the field must be indexed with positions
+type SpanQuery interface {
+	bluge.Query
+	Field() string
+	// Terms returns all the terms of the query, a matching document contains all of them
+	Terms() []string
+	// Spans returns the matching spans in the term locations of the field, sorted by start and end
+	Spans(tlm search.TermLocationMap) []Span
+}
+
+// SpanTermQuery matches the positions of a term
+type SpanTermQuery struct {
+	field string
+	term  string
+	boost float64
+}
+
+func NewSpanTermQuery(field, term string) *SpanTermQuery {
+	return &SpanTermQuery{field: field, term: term, boost: 1}
+}
+
+func (q *SpanTermQuery) SetBoost(boost float64) *SpanTermQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *SpanTermQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *SpanTermQuery) Field() string {
+	return q.field
+}
+
+func (q *SpanTermQuery) Term() string {
+	return q.term
+}
+
+func (q *SpanTermQuery) Terms() []string {
+	return []string{q.term}
+}
+
+func (q *SpanTermQuery) Spans(tlm search.TermLocationMap) []Span {
+	locations := tlm[q.term]
+	spans := make([]Span, 0, len(locations))
+	for _, loc := range locations {
+		spans = append(spans, Span{Start: loc.Pos - 1, End: loc.Pos})
+	}
+	sortSpans(spans)
+	return spans
+}
+
+func (q *SpanTermQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	return newSpanSearcher(i, q, q.boost, options)
+}
+
+func (q *SpanTermQuery) String() string {
+	return boostString(fieldString(q.field)+q.term, q.boost)
+}
+
+// SpanNearQuery matches the spans of the clauses which are near each other, the number of
+// positions between the spans is at most slop, the clauses must be in order if inOrder is set
+type SpanNearQuery struct {
+	clauses []SpanQuery
+	slop    int
+	inOrder bool
+	boost   float64
+}
+
+func NewSpanNearQuery(clauses []SpanQuery, slop int, inOrder bool) *SpanNearQuery {
+	return &SpanNearQuery{clauses: clauses, slop: slop, inOrder: inOrder, boost: 1}
+}
+
+func (q *SpanNearQuery) SetBoost(boost float64) *SpanNearQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *SpanNearQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *SpanNearQuery) Field() string {
+	if len(q.clauses) == 0 {
+		return ""
+	}
+	return q.clauses[0].Field()
+}
+
+func (q *SpanNearQuery) Terms() []string {
+	terms := make([]string, 0, len(q.clauses))
+	for _, clause := range q.clauses {
+		terms = append(terms, clause.Terms()...)
+	}
+	return terms
+}
+
+func (q *SpanNearQuery) Spans(tlm search.TermLocationMap) []Span {
+	if len(q.clauses) == 0 {
+		return nil
+	}
+	clauseSpans := make([][]Span, len(q.clauses))
+	for i, clause := range q.clauses {
+		if clauseSpans[i] = clause.Spans(tlm); len(clauseSpans[i]) == 0 {
+			return nil
+		}
+	}
+
+	seen := make(map[Span]struct{})
+	spans := make([]Span, 0)
+	chosen := make([]Span, 0, len(q.clauses))
+	var walk func(i int)
+	walk = func(i int) {
+		if i == len(clauseSpans) {
+			if span, ok := q.near(chosen); ok {
+				if _, ok := seen[span]; !ok {
+					seen[span] = struct{}{}
+					spans = append(spans, span)
+				}
+			}
+			return
+		}
+		for _, span := range clauseSpans[i] {
+			if q.inOrder && i > 0 && span.Start < chosen[i-1].End {
+				continue
+			}
+			chosen = append(chosen, span)
+			walk(i + 1)
+			chosen = chosen[:len(chosen)-1]
+		}
+	}
+	walk(0)
+	sortSpans(spans)
+	return spans
+}
+
+// near checks the chosen spans of the clauses don't overlap and are within the slop
+func (q *SpanNearQuery) near(chosen []Span) (Span, bool) {
+	result := chosen[0]
+	length := 0
+	for i, span := range chosen {
+		for _, other := range chosen[:i] {
+			if span.Start < other.End && other.Start < span.End {
+				return result, false
+			}
+		}
+		if span.Start < result.Start {
+			result.Start = span.Start
+		}
+		if span.End > result.End {
+			result.End = span.End
+		}
+		length += span.End - span.Start
+	}
+	return result, result.End-result.Start-length <= q.slop
+}
+
+func (q *SpanNearQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	return newSpanSearcher(i, q, q.boost, options)
+}
+
+func (q *SpanNearQuery) String() string {
+	clauses := make([]string, 0, len(q.clauses))
+	for _, clause := range q.clauses {
+		clauses = append(clauses, String(clause))
+	}
+	s := "spanNear([" + strings.Join(clauses, ", ") + "], " + strconv.Itoa(q.slop) + ", " + strconv.FormatBool(q.inOrder) + ")"
+	return boostString(s, q.boost)
+}
+
+// SpanFirstQuery matches the spans of the query which end at most at the position end
+type SpanFirstQuery struct {
+	match SpanQuery
+	end   int
+	boost float64
+}
+
+func NewSpanFirstQuery(match SpanQuery, end int) *SpanFirstQuery {
+	return &SpanFirstQuery{match: match, end: end, boost: 1}
+}
+
+func (q *SpanFirstQuery) SetBoost(boost float64) *SpanFirstQuery {
+	q.boost = boost
+	return q
+}
+
+func (q *SpanFirstQuery) Boost() float64 {
+	return q.boost
+}
+
+func (q *SpanFirstQuery) Field() string {
+	return q.match.Field()
+}
+
+func (q *SpanFirstQuery) Terms() []string {
+	return q.match.Terms()
+}
+
+func (q *SpanFirstQuery) Spans(tlm search.TermLocationMap) []Span {
+	spans := make([]Span, 0)
+	for _, span := range q.match.Spans(tlm) {
+		if span.End <= q.end {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (q *SpanFirstQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	return newSpanSearcher(i, q, q.boost, options)
+}
+
+func (q *SpanFirstQuery) String() string {
+	return boostString("spanFirst("+String(q.match)+", "+strconv.Itoa(q.end)+")", q.boost)
+}
+
+func sortSpans(spans []Span) {
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Start == spans[j].Start {
+			return spans[i].End < spans[j].End
+		}
+		return spans[i].Start < spans[j].Start
+	})
+}
+
+// newSpanSearcher finds the documents containing all the terms of the query with a conjunction,
+// then checks the positions of the terms have matching spans
+func newSpanSearcher(i search.Reader, q SpanQuery, boost float64, options search.SearcherOptions) (search.Searcher, error) {
+	options.IncludeTermVectors = true
+	terms := q.Terms()
+	seen := make(map[string]struct{}, len(terms))
+	searchers := make([]search.Searcher, 0, len(terms))
+	for _, term := range terms {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		s, err := searcher.NewTermSearcher(i, term, q.Field(), 1, nil, options)
+		if err != nil {
+			for _, s := range searchers {
+				_ = s.Close()
+			}
+			return nil, err
+		}
+		searchers = append(searchers, s)
+	}
+	if len(searchers) == 0 {
+		return searcher.NewMatchNoneSearcher(i, options)
+	}
+	must, err := searcher.NewConjunctionSearcher(i, searchers, similarity.NewCompositeSumScorerWithBoost(boost), options)
+	if err != nil {
+		for _, s := range searchers {
+			_ = s.Close()
+		}
+		return nil, err
+	}
+	return &spanSearcher{must: must, query: q}, nil
+}
+
+type spanSearcher struct {
+	must  search.Searcher
+	query SpanQuery
+}
+
+func (s *spanSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	dm, err := s.must.Next(ctx)
+	for err == nil && dm != nil {
+		if s.matches(dm) {
+			return dm, nil
+		}
+		ctx.DocumentMatchPool.Put(dm)
+		dm, err = s.must.Next(ctx)
+	}
+	return nil, err
+}
+
+func (s *spanSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	dm, err := s.must.Advance(ctx, number)
+	if err != nil || dm == nil {
+		return nil, err
+	}
+	if s.matches(dm) {
+		return dm, nil
+	}
+	ctx.DocumentMatchPool.Put(dm)
+	return s.Next(ctx)
+}
+
+func (s *spanSearcher) matches(dm *search.DocumentMatch) bool {
+	dm.Complete(nil)
+	return len(s.query.Spans(dm.Locations[s.query.Field()])) > 0
+}
+
+func (s *spanSearcher) Close() error {
+	return s.must.Close()
+}
+
+func (s *spanSearcher) Count() uint64 {
+	return s.must.Count()
+}
+
+func (s *spanSearcher) Min() int {
+	return s.must.Min()
+}
+
+func (s *spanSearcher) Size() int {
+	return s.must.Size()
+}
+
+func (s *spanSearcher) DocumentMatchPoolSize() int {
+	return s.must.DocumentMatchPoolSize()
+}
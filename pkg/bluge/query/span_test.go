@@ -0,0 +1,101 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blugelabs/bluge/search"
+	"github.com/stretchr/testify/assert"
+)
+
+// termLocations returns the term locations of the text, positions start from 1 like the index
+func termLocations(text string) search.TermLocationMap {
+	tlm := make(search.TermLocationMap)
+	for i, term := range strings.Fields(text) {
+		tlm[term] = append(tlm[term], &search.Location{Pos: i + 1})
+	}
+	return tlm
+}
+
+func TestSpanQuery_Spans(t *testing.T) {
+	tlm := termLocations("the quick brown fox jumps over the lazy dog")
+	term := func(term string) SpanQuery {
+		return NewSpanTermQuery("body", term)
+	}
+	tests := []struct {
+		name  string
+		query SpanQuery
+		want  []Span
+	}{
+		{
+			name:  "term",
+			query: term("the"),
+			want:  []Span{{0, 1}, {6, 7}},
+		},
+		{
+			name:  "near exact",
+			query: NewSpanNearQuery([]SpanQuery{term("quick"), term("brown")}, 0, true),
+			want:  []Span{{1, 3}},
+		},
+		{
+			name:  "near in order with slop",
+			query: NewSpanNearQuery([]SpanQuery{term("quick"), term("fox")}, 1, true),
+			want:  []Span{{1, 4}},
+		},
+		{
+			name:  "near slop exceeded",
+			query: NewSpanNearQuery([]SpanQuery{term("quick"), term("jumps")}, 1, true),
+			want:  []Span{},
+		},
+		{
+			name:  "near out of order",
+			query: NewSpanNearQuery([]SpanQuery{term("fox"), term("quick")}, 1, true),
+			want:  []Span{},
+		},
+		{
+			name:  "near unordered",
+			query: NewSpanNearQuery([]SpanQuery{term("fox"), term("quick")}, 1, false),
+			want:  []Span{{1, 4}},
+		},
+		{
+			name:  "nested near",
+			query: NewSpanNearQuery([]SpanQuery{NewSpanNearQuery([]SpanQuery{term("quick"), term("brown")}, 0, true), term("jumps")}, 1, true),
+			want:  []Span{{1, 5}},
+		},
+		{
+			name:  "first",
+			query: NewSpanFirstQuery(term("the"), 3),
+			want:  []Span{{0, 1}},
+		},
+		{
+			name:  "first too far",
+			query: NewSpanFirstQuery(term("fox"), 3),
+			want:  []Span{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.query.Spans(tlm)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+)
+
+// String returns a human-readable lucene like representation of the query, e.g.
+//
+//	+title:zinc +(content:search content:engine) -status:deleted
+func String(q bluge.Query) string {
+	switch q := q.(type) {
+	case fmt.Stringer:
+		return q.String()
+	case *bluge.BooleanQuery:
+		clauses := make([]string, 0, len(q.Musts())+len(q.Shoulds())+len(q.MustNots()))
+		for _, sub := range q.Musts() {
+			clauses = append(clauses, "+"+clauseString(sub))
+		}
+		for _, sub := range q.Shoulds() {
+			clauses = append(clauses, clauseString(sub))
+		}
+		for _, sub := range q.MustNots() {
+			clauses = append(clauses, "-"+clauseString(sub))
+		}
+		s := strings.Join(clauses, " ")
+		if q.MinShould() > 0 && len(q.Shoulds()) > 0 {
+			s = "(" + s + ")~" + strconv.Itoa(q.MinShould())
+		}
+		if len(clauses) == 0 {
+			s = "*:*"
+		}
+		return boostString(s, q.Boost())
+	case *bluge.TermQuery:
+		return boostString(fieldString(q.Field())+q.Term(), q.Boost())
+	case *bluge.MatchQuery:
+		terms := []string{q.Match()}
+		if q.Analyzer() != nil {
+			terms = terms[:0]
+			for _, token := range q.Analyzer().Analyze([]byte(q.Match())) {
+				terms = append(terms, string(token.Term))
+			}
+		}
+		for i, term := range terms {
+			term = fieldString(q.Field()) + term
+			if q.Fuzziness() > 0 {
+				term += "~" + strconv.Itoa(q.Fuzziness())
+			}
+			if q.Operator() == bluge.MatchQueryOperatorAnd {
+				term = "+" + term
+			}
+			terms[i] = term
+		}
+		s := strings.Join(terms, " ")
+		if len(terms) > 1 {
+			s = "(" + s + ")"
+		}
+		return boostString(s, q.Boost())
+	case *bluge.MatchPhraseQuery:
+		s := fieldString(q.Field()) + strconv.Quote(q.Phrase())
+		if q.Slop() > 0 {
+			s += "~" + strconv.Itoa(q.Slop())
+		}
+		return boostString(s, q.Boost())
+	case *bluge.MultiPhraseQuery:
+		terms := make([]string, 0, len(q.Terms()))
+		for _, t := range q.Terms() {
+			if len(t) == 1 {
+				terms = append(terms, t[0])
+			} else {
+				terms = append(terms, "("+strings.Join(t, " ")+")")
+			}
+		}
+		s := fieldString(q.Field()) + `"` + strings.Join(terms, " ") + `"`
+		if q.Slop() > 0 {
+			s += "~" + strconv.Itoa(q.Slop())
+		}
+		return boostString(s, q.Boost())
+	case *bluge.PrefixQuery:
+		return boostString(fieldString(q.Field())+q.Prefix()+"*", q.Boost())
+	case *bluge.WildcardQuery:
+		return boostString(fieldString(q.Field())+q.Wildcard(), q.Boost())
+	case *bluge.RegexpQuery:
+		return boostString(fieldString(q.Field())+"/"+q.Regexp()+"/", q.Boost())
+	case *bluge.FuzzyQuery:
+		return boostString(fieldString(q.Field())+q.Term()+"~"+strconv.Itoa(q.Fuzziness()), q.Boost())
+	case *bluge.NumericRangeQuery:
+		min, minInclusive := q.Min()
+		max, maxInclusive := q.Max()
+		return boostString(fieldString(q.Field())+rangeString(
+			strconv.FormatFloat(min, 'g', -1, 64), minInclusive,
+			strconv.FormatFloat(max, 'g', -1, 64), maxInclusive,
+		), q.Boost())
+	case *bluge.DateRangeQuery:
+		start, startInclusive := q.Start()
+		end, endInclusive := q.End()
+		return boostString(fieldString(q.Field())+rangeString(
+			dateString(start), startInclusive,
+			dateString(end), endInclusive,
+		), q.Boost())
+	case *bluge.TermRangeQuery:
+		min, minInclusive := q.Min()
+		max, maxInclusive := q.Max()
+		if min == "" {
+			min = "*"
+		}
+		if max == "" {
+			max = "*"
+		}
+		return boostString(fieldString(q.Field())+rangeString(min, minInclusive, max, maxInclusive), q.Boost())
+	case *bluge.MatchAllQuery:
+		return boostString("*:*", q.Boost())
+	case *bluge.MatchNoneQuery:
+		return "MatchNoDocsQuery"
+	case *bluge.GeoDistanceQuery:
+		return boostString(fmt.Sprintf("%sdistance(%v, %s)", fieldString(q.Field()), q.Location(), q.Distance()), q.Boost())
+	case *bluge.GeoBoundingBoxQuery:
+		return boostString(fmt.Sprintf("%sbbox(%v, %v)", fieldString(q.Field()), q.TopLeft(), q.BottomRight()), q.Boost())
+	case *bluge.GeoBoundingPolygonQuery:
+		return boostString(fmt.Sprintf("%spolygon(%v)", fieldString(q.Field()), q.Points()), q.Boost())
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%T", q)
+	}
+}
+
+// clauseString wraps the boolean sub query with parentheses
+func clauseString(q bluge.Query) string {
+	s := String(q)
+	if _, ok := q.(*bluge.BooleanQuery); ok && !strings.HasPrefix(s, "(") {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+func fieldString(field string) string {
+	if field == "" {
+		return ""
+	}
+	return field + ":"
+}
+
+func boostString(s string, boost float64) string {
+	if boost == 1 {
+		return s
+	}
+	return s + "^" + strconv.FormatFloat(boost, 'g', -1, 64)
+}
+
+func rangeString(min string, minInclusive bool, max string, maxInclusive bool) string {
+	left, right := "{", "}"
+	if minInclusive {
+		left = "["
+	}
+	if maxInclusive {
+		right = "]"
+	}
+	return left + min + " TO " + max + right
+}
+
+func dateString(t time.Time) string {
+	if t.IsZero() {
+		return "*"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func (q *FunctionScoreQuery) String() string {
+	functions := make([]string, 0, len(q.functions))
+	for _, f := range q.functions {
+		s := "weight(" + strconv.FormatFloat(f.Weight, 'g', -1, 64) + ")"
+		if f.Score != nil {
+			s = fmt.Sprintf("%s * %s", s, scoreFunctionString(f.Score))
+		}
+		if f.Filter != nil {
+			s = "filter(" + String(f.Filter) + ") " + s
+		}
+		functions = append(functions, s)
+	}
+	s := fmt.Sprintf("function score (%s, functions: [%s], score_mode: %s, boost_mode: %s)",
+		String(q.query), strings.Join(functions, ", "), q.scoreMode, q.boostMode)
+	if q.hasMinScore {
+		s += ", min_score: " + strconv.FormatFloat(q.minScore, 'g', -1, 64)
+	}
+	return boostString(s, q.boost)
+}
+
+func (q *FilteredQuery) String() string {
+	return "+" + clauseString(q.query) + " #" + clauseString(q.filter)
+}
+
+func (q *NamedQuery) String() string {
+	return clauseString(q.query)
+}
+
+func (q *MinScoreQuery) String() string {
+	return clauseString(q.query) + " min_score: " + strconv.FormatFloat(q.minScore, 'g', -1, 64)
+}
+
+func scoreFunctionString(f ScoreFunction) string {
+	switch f := f.(type) {
+	case *FieldValueFactorFunction:
+		modifier := f.Modifier
+		if modifier == "" {
+			modifier = "none"
+		}
+		return fmt.Sprintf("field_value_factor(%s(doc['%s'].value * %v))", modifier, f.Field, f.Factor)
+	case *DecayFunction:
+		return fmt.Sprintf("%s(doc['%s'], scale: %v, offset: %v, decay: %v)", f.Curve, f.Field, f.Scale, f.Offset, f.Decay)
+	case *Script:
+		return "script(" + f.Source() + ")"
+	default:
+		return fmt.Sprintf("%T", f)
+	}
+}
@@ -0,0 +1,117 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"testing"
+
+	"github.com/blugelabs/bluge"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name  string
+		query bluge.Query
+		want  string
+	}{
+		{
+			name:  "term",
+			query: bluge.NewTermQuery("zinc").SetField("title").SetBoost(2),
+			want:  "title:zinc^2",
+		},
+		{
+			name: "bool",
+			query: bluge.NewBooleanQuery().
+				AddMust(bluge.NewTermQuery("zinc").SetField("title")).
+				AddShould(bluge.NewPrefixQuery("sea").SetField("content")).
+				AddShould(bluge.NewMatchPhraseQuery("search engine").SetField("content").SetSlop(1)).
+				AddMustNot(bluge.NewBooleanQuery().AddShould(bluge.NewWildcardQuery("d*d").SetField("status"))),
+			want: `+title:zinc content:sea* content:"search engine"~1 -(status:d*d)`,
+		},
+		{
+			name:  "min should",
+			query: bluge.NewBooleanQuery().AddShould(bluge.NewFuzzyQuery("zinx").SetField("title").SetFuzziness(1)).SetMinShould(1),
+			want:  "(title:zinx~1)~1",
+		},
+		{
+			name:  "numeric range",
+			query: bluge.NewNumericRangeInclusiveQuery(1, 10, true, false).SetField("views"),
+			want:  "views:[1 TO 10}",
+		},
+		{
+			name:  "match all",
+			query: bluge.NewMatchAllQuery(),
+			want:  "*:*",
+		},
+		{
+			name:  "filtered",
+			query: NewFilteredQuery(bluge.NewTermQuery("zinc").SetField("title"), bluge.NewTermQuery("1").SetField("_id")),
+			want:  "+title:zinc #_id:1",
+		},
+		{
+			name:  "min score",
+			query: NewMinScoreQuery(bluge.NewTermQuery("zinc").SetField("title"), 1.5),
+			want:  "title:zinc min_score: 1.5",
+		},
+		{
+			name:  "named",
+			query: NewNamedQuery("zinc", bluge.NewTermQuery("zinc").SetField("title")),
+			want:  "title:zinc",
+		},
+		{
+			name: "function score",
+			query: NewFunctionScoreQuery(bluge.NewMatchAllQuery()).
+				AddFunction(&Function{Weight: 2, Score: &FieldValueFactorFunction{Field: "views", Factor: 1, Modifier: "log1p"}}).
+				SetScoreMode(ScoreModeSum),
+			want: "function score (*:*, functions: [weight(2) * field_value_factor(log1p(doc['views'].value * 1))], score_mode: sum, boost_mode: multiply)",
+		},
+		{
+			name: "dis max",
+			query: NewDisMaxQuery(bluge.NewTermQuery("zinc").SetField("title").SetBoost(3), bluge.NewTermQuery("zinc").SetField("body")).
+				SetTieBreaker(0.3),
+			want: "(title:zinc^3 | body:zinc)~0.3",
+		},
+		{
+			name:  "boosting",
+			query: NewBoostingQuery(bluge.NewTermQuery("zinc").SetField("title"), bluge.NewTermQuery("deleted").SetField("status"), 0.2),
+			want:  "boosting(title:zinc, negative: status:deleted, negative_boost: 0.2)",
+		},
+		{
+			name:  "constant score",
+			query: NewConstantScoreQuery(bluge.NewTermQuery("zinc").SetField("title")).SetBoost(2),
+			want:  "ConstantScore(title:zinc)^2",
+		},
+		{
+			name: "span near",
+			query: NewSpanNearQuery([]SpanQuery{
+				NewSpanTermQuery("title", "zinc"),
+				NewSpanFirstQuery(NewSpanTermQuery("title", "search"), 3),
+			}, 1, true),
+			want: "spanNear([title:zinc, spanFirst(title:search, 3)], 1, true)",
+		},
+		{
+			name:  "phrase prefix",
+			query: NewPhrasePrefixQuery("title", []string{"quick", "brown"}, "f").SetSlop(1),
+			want:  `title:"quick brown f*"~1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, String(tt.query))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/searcher"
+)
+
+// TermStats are the statistics of a field and its terms
+type TermStats struct {
+	DocCount   uint64
+	SumDocFreq uint64
+	SumTTF     uint64
+	DocFreq    map[string]uint64
+	TTF        map[string]uint64
+}
+
+// TermStatsQuery collects the statistics of the field and the terms from the reader, it matches no documents.
+// The statistics of each reader searched with the query are added to the stats
+type TermStatsQuery struct {
+	field string
+	terms []string
+	stats *TermStats
+}
+
+func NewTermStatsQuery(field string, terms []string, stats *TermStats) *TermStatsQuery {
+	if stats.DocFreq == nil {
+		stats.DocFreq = make(map[string]uint64, len(terms))
+	}
+	if stats.TTF == nil {
+		stats.TTF = make(map[string]uint64, len(terms))
+	}
+	return &TermStatsQuery{field: field, terms: terms, stats: stats}
+}
+
+func (q *TermStatsQuery) Field() string {
+	return q.field
+}
+
+func (q *TermStatsQuery) Searcher(i search.Reader, options search.SearcherOptions) (search.Searcher, error) {
+	if err := q.collect(i); err != nil {
+		return nil, err
+	}
+	return searcher.NewMatchNoneSearcher(i, options)
+}
+
+func (q *TermStatsQuery) collect(i search.Reader) error {
+	cs, err := i.CollectionStats(q.field)
+	if err != nil {
+		return err
+	}
+	if cs == nil {
+		// the reader has no segments
+		return nil
+	}
+	q.stats.DocCount += cs.DocumentCount()
+	q.stats.SumTTF += cs.SumTotalTermFrequency()
+
+	dict, err := i.DictionaryIterator(q.field, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	entry, err := dict.Next()
+	for err == nil && entry != nil {
+		q.stats.SumDocFreq += entry.Count()
+		entry, err = dict.Next()
+	}
+	dict.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, term := range q.terms {
+		it, err := i.PostingsIterator([]byte(term), q.field, true, false, false)
+		if err != nil {
+			return err
+		}
+		posting, err := it.Next()
+		for err == nil && posting != nil {
+			q.stats.DocFreq[term]++
+			q.stats.TTF[term] += uint64(posting.Frequency())
+			posting, err = it.Next()
+		}
+		it.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *TermStatsQuery) String() string {
+	return "term_stats(" + q.field + ")"
+}
@@ -0,0 +1,82 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"context"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/collector"
+)
+
+// cancellableRequest stops the search when the context is done and keeps the hits and the aggregations
+// collected until then. The collectors of bluge drop them and fail, so the request should be searched
+// with a context never done, the searcher checks the context instead
+type cancellableRequest struct {
+	bluge.SearchRequest
+	ctx context.Context
+}
+
+func newCancellableRequest(ctx context.Context, req bluge.SearchRequest) bluge.SearchRequest {
+	if ctx.Done() == nil {
+		// never cancelled
+		return req
+	}
+	return &cancellableRequest{SearchRequest: req, ctx: ctx}
+}
+
+func (r *cancellableRequest) Searcher(i search.Reader, config bluge.Config) (search.Searcher, error) {
+	s, err := r.SearchRequest.Searcher(i, config)
+	if err != nil {
+		return nil, err
+	}
+	return &cancellableSearcher{Searcher: s, ctx: r.ctx}, nil
+}
+
+// cancellableSearcher ends the matches when the context is done, it is checked every collector.CheckDoneEvery matches
+type cancellableSearcher struct {
+	search.Searcher
+	ctx  context.Context
+	hits int
+}
+
+func (s *cancellableSearcher) Next(ctx *search.Context) (*search.DocumentMatch, error) {
+	if s.done() {
+		return nil, nil
+	}
+	return s.Searcher.Next(ctx)
+}
+
+func (s *cancellableSearcher) Advance(ctx *search.Context, number uint64) (*search.DocumentMatch, error) {
+	if s.done() {
+		return nil, nil
+	}
+	return s.Searcher.Advance(ctx, number)
+}
+
+func (s *cancellableSearcher) done() bool {
+	s.hits++
+	if s.hits%collector.CheckDoneEvery != 1 {
+		return false
+	}
+	return s.ctx.Err() != nil
+}
+
+// searchReader searches the reader until the context is done
+func searchReader(ctx context.Context, r *bluge.Reader, req bluge.SearchRequest) (search.DocumentMatchIterator, error) {
+	return r.Search(context.Background(), newCancellableRequest(ctx, req))
+}
@@ -0,0 +1,94 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"github.com/blugelabs/bluge/search"
+
+	"github.com/zincsearch/zincsearch/pkg/uquery/collapse"
+)
+
+// CollapseIterator returns the top hit of each collapse group, hits should be sorted
+type CollapseIterator struct {
+	groups []*collapseGroup
+	bucket *search.Bucket
+	next   int
+}
+
+type collapseGroup struct {
+	docs  []*search.DocumentMatch
+	total int
+}
+
+// NewCollapseIterator consumes all the hits and groups them by the collapse key, every group keeps at most groupSize hits
+func NewCollapseIterator(dmi search.DocumentMatchIterator, from, size, groupSize int) (*CollapseIterator, error) {
+	if groupSize < 1 {
+		groupSize = 1
+	}
+	groups := make([]*collapseGroup, 0)
+	index := make(map[string]*collapseGroup)
+	next, err := dmi.Next()
+	for err == nil && next != nil {
+		key := collapse.Key(next)
+		group, ok := index[key]
+		if !ok {
+			group = &collapseGroup{}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.total++
+		if len(group.docs) < groupSize {
+			group.docs = append(group.docs, next)
+		}
+		next, err = dmi.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if from > len(groups) {
+		from = len(groups)
+	}
+	end := from + size
+	if end > len(groups) {
+		end = len(groups)
+	}
+	return &CollapseIterator{
+		groups: groups[from:end],
+		bucket: dmi.Aggregations(),
+	}, nil
+}
+
+func (it *CollapseIterator) Next() (*search.DocumentMatch, error) {
+	if it.next >= len(it.groups) {
+		return nil, nil
+	}
+	it.next++
+	return it.groups[it.next-1].docs[0], nil
+}
+
+// Group returns the kept hits and the number of all hits of the group which the last returned hit belongs to
+func (it *CollapseIterator) Group() ([]*search.DocumentMatch, int) {
+	if it.next == 0 {
+		return nil, 0
+	}
+	group := it.groups[it.next-1]
+	return group.docs, group.total
+}
+
+func (it *CollapseIterator) Aggregations() *search.Bucket {
+	return it.bucket
+}
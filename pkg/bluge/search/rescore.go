@@ -0,0 +1,189 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"context"
+	"sort"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/search"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/rescore"
+)
+
+// rescoreSearch fetches at least the window of the rescore and pages the re-scored hits
+func rescoreSearch(
+	ctx context.Context,
+	query *meta.ZincQuery,
+	mappings *meta.Mappings,
+	analyzers map[string]*analysis.Analyzer,
+	readers ...*bluge.Reader,
+) (search.DocumentMatchIterator, error) {
+	rescoreQuery, err := rescore.Request(query.Rescore, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+
+	from, size := query.From, query.Size
+	query.From = 0
+	if query.Size += from; query.Size < query.Rescore.WindowSize {
+		query.Size = query.Rescore.WindowSize
+	}
+	defer func() {
+		query.From, query.Size = from, size
+	}()
+	dmi, err := multiSearch(ctx, query, mappings, analyzers, readers...)
+	if err != nil {
+		return nil, err
+	}
+	return NewRescoreIterator(ctx, dmi, rescoreQuery, query.Rescore, from, size, readers...)
+}
+
+// RescoreIterator returns the hits with the top window size of them re-scored and sorted again
+type RescoreIterator struct {
+	docs     []*search.DocumentMatch
+	bucket   *search.Bucket
+	next     int
+	maxScore float64
+}
+
+// NewRescoreIterator consumes all the hits, re-scores the top window size hits with the rescore query
+// searching the readers, then pages the hits
+func NewRescoreIterator(
+	ctx context.Context,
+	dmi search.DocumentMatchIterator,
+	rescoreQuery bluge.Query,
+	rescoreOptions *meta.Rescore,
+	from, size int,
+	readers ...*bluge.Reader,
+) (*RescoreIterator, error) {
+	docs := make([]*search.DocumentMatch, 0)
+	next, err := dmi.Next()
+	for err == nil && next != nil {
+		docs = append(docs, next)
+		next, err = dmi.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	window := rescoreOptions.WindowSize
+	if window > len(docs) {
+		window = len(docs)
+	}
+	// the hits are returned as collected when the search was cancelled
+	if window > 0 && ctx.Err() == nil {
+		scores, err := rescoreScores(ctx, docs[:window], rescoreQuery, readers...)
+		if err != nil {
+			return nil, err
+		}
+		for i, doc := range docs[:window] {
+			rescoreScore, matched := scores[i]
+			doc.Score = rescore.Score(rescoreOptions, doc.Score, rescoreScore, matched)
+		}
+		sort.SliceStable(docs[:window], func(i, j int) bool {
+			return docs[i].Score > docs[j].Score
+		})
+	}
+
+	var maxScore float64
+	for _, doc := range docs {
+		if doc.Score > maxScore {
+			maxScore = doc.Score
+		}
+	}
+
+	if from > len(docs) {
+		from = len(docs)
+	}
+	end := from + size
+	if end > len(docs) {
+		end = len(docs)
+	}
+	return &RescoreIterator{
+		docs:     docs[from:end],
+		bucket:   dmi.Aggregations(),
+		maxScore: maxScore,
+	}, nil
+}
+
+// rescoreScores returns the scores of the rescore query by the position of the matching documents
+func rescoreScores(ctx context.Context, docs []*search.DocumentMatch, rescoreQuery bluge.Query, readers ...*bluge.Reader) (map[int]float64, error) {
+	ids := bluge.NewBooleanQuery()
+	positions := make(map[string][]int, len(docs))
+	for i, doc := range docs {
+		id, indexName, err := documentKey(doc)
+		if err != nil {
+			return nil, err
+		}
+		ids.AddShould(bluge.NewTermQuery(id).SetField("_id"))
+		positions[indexName+"/"+id] = append(positions[indexName+"/"+id], i)
+	}
+
+	// an id can be in several indexes
+	request := bluge.NewTopNSearch(len(docs)*len(readers), zincquery.NewFilteredQuery(rescoreQuery, ids))
+	dmi, err := bluge.MultiSearch(ctx, request, readers...)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int]float64, len(docs))
+	next, err := dmi.Next()
+	for err == nil && next != nil {
+		var id, indexName string
+		if id, indexName, err = documentKey(next); err != nil {
+			return nil, err
+		}
+		for _, i := range positions[indexName+"/"+id] {
+			scores[i] = next.Score
+		}
+		next, err = dmi.Next()
+	}
+	return scores, err
+}
+
+func documentKey(doc *search.DocumentMatch) (id, indexName string, err error) {
+	err = doc.VisitStoredFields(func(field string, value []byte) bool {
+		switch field {
+		case "_id":
+			id = string(value)
+		case "_index":
+			indexName = string(value)
+		}
+		return true
+	})
+	return id, indexName, err
+}
+
+func (it *RescoreIterator) Next() (*search.DocumentMatch, error) {
+	if it.next >= len(it.docs) {
+		return nil, nil
+	}
+	it.next++
+	return it.docs[it.next-1], nil
+}
+
+// MaxScore returns the max score of the re-scored hits
+func (it *RescoreIterator) MaxScore() float64 {
+	return it.maxScore
+}
+
+func (it *RescoreIterator) Aggregations() *search.Bucket {
+	return it.bucket
+}
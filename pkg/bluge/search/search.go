@@ -19,6 +19,7 @@ import (
 	"container/heap"
 	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
@@ -26,6 +27,7 @@ import (
 	"github.com/blugelabs/bluge/search/aggregations"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/profile"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/uquery"
@@ -37,6 +39,37 @@ func MultiSearch(
 	mappings *meta.Mappings,
 	analyzers map[string]*analysis.Analyzer,
 	readers ...*bluge.Reader,
+) (search.DocumentMatchIterator, error) {
+	if query.Rescore != nil {
+		return rescoreSearch(ctx, query, mappings, analyzers, readers...)
+	}
+	if query.Collapse == nil {
+		return multiSearch(ctx, query, mappings, analyzers, readers...)
+	}
+
+	// collapse works on the top hits, fetch as many hits as allowed then page the groups
+	from, size := query.From, query.Size
+	query.From, query.Size = 0, config.Global.MaxResults
+	defer func() {
+		query.From, query.Size = from, size
+	}()
+	dmi, err := multiSearch(ctx, query, mappings, analyzers, readers...)
+	if err != nil {
+		return nil, err
+	}
+	groupSize := 1
+	if query.Collapse.InnerHits != nil {
+		groupSize = query.Collapse.InnerHits.From + query.Collapse.InnerHits.Size
+	}
+	return NewCollapseIterator(dmi, from, size, groupSize)
+}
+
+func multiSearch(
+	ctx context.Context,
+	query *meta.ZincQuery,
+	mappings *meta.Mappings,
+	analyzers map[string]*analysis.Analyzer,
+	readers ...*bluge.Reader,
 ) (search.DocumentMatchIterator, error) {
 	if len(readers) == 0 {
 		return &DocumentList{
@@ -47,12 +80,18 @@ func MultiSearch(
 			),
 		}, nil
 	}
+	profiler := profile.FromContext(ctx)
 	if len(readers) == 1 {
-		req, err := uquery.ParseQueryDSL(query, mappings, analyzers)
+		shard := profiler.Shard(0)
+		req, err := uquery.ParseBoostedQueryDSL(query, mappings, analyzers, shard, readerBoost(query, 0))
+		if err != nil {
+			return nil, err
+		}
+		dmi, err := searchReader(ctx, readers[0], req)
 		if err != nil {
 			return nil, err
 		}
-		return readers[0].Search(ctx, req)
+		return shard.WrapIterator(dmi), nil
 	}
 
 	bucketAggs := make(map[string]search.Aggregation)
@@ -88,9 +127,10 @@ func MultiSearch(
 		return nil
 	})
 
-	for _, r := range readers {
+	for i, r := range readers {
 		r := r
-		req, err := uquery.ParseQueryDSL(query, mappings, analyzers)
+		shard := profiler.Shard(i)
+		req, err := uquery.ParseBoostedQueryDSL(query, mappings, analyzers, shard, readerBoost(query, i))
 		if err != nil {
 			return nil, err
 		}
@@ -101,7 +141,11 @@ func MultiSearch(
 		}
 		eg.Go(func() error {
 			var n int64
-			dmi, err := r.Search(ctx, req)
+			start := time.Now()
+			defer func() {
+				shard.AddCollect(time.Since(start))
+			}()
+			dmi, err := searchReader(ctx, r, req)
 			if err != nil {
 				return err
 			}
@@ -138,6 +182,14 @@ func MultiSearch(
 	return docList, nil
 }
 
+// readerBoost returns the boost of the scores of the reader
+func readerBoost(query *meta.ZincQuery, i int) float64 {
+	if i < len(query.Boosts) {
+		return query.Boosts[i]
+	}
+	return 1
+}
+
 type Document struct {
 	doc *search.DocumentMatch
 }
@@ -0,0 +1,48 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"context"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/index"
+	"github.com/blugelabs/bluge/search"
+)
+
+// segmentsRequest counts the segments of the snapshot it searches. bluge doesn't expose the segments
+// of a reader, but the snapshot of the reader is the search.Reader given to the searcher of the request
+type segmentsRequest struct {
+	bluge.SearchRequest
+	segments int
+}
+
+func (r *segmentsRequest) Searcher(i search.Reader, config bluge.Config) (search.Searcher, error) {
+	if s, ok := i.(*index.Snapshot); ok {
+		r.segments = len(s.Segments())
+	}
+	return r.SearchRequest.Searcher(i, config)
+}
+
+// Segments returns the number of segments of the reader, it reads the snapshot of the reader
+// so it is safe while the writer merges and introduces segments, unlike the status of the writer
+func Segments(r *bluge.Reader) (int, error) {
+	req := &segmentsRequest{SearchRequest: bluge.NewTopNSearch(0, bluge.NewMatchNoneQuery())}
+	if _, err := r.Search(context.Background(), req); err != nil {
+		return 0, err
+	}
+	return req.segments, nil
+}
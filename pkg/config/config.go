@@ -39,6 +39,8 @@ type config struct {
 	ServerMode                string        `env:"ZINC_SERVER_MODE,default=node"`
 	NodeID                    int           `env:"ZINC_NODE_ID,default=1"`
 	DataPath                  string        `env:"ZINC_DATA_PATH,default=./data"`
+	AnalysisPath              string        `env:"ZINC_ANALYSIS_PATH,default=./analysis"` // the files of the analysis settings like synonyms_path are relative to it
+	SnapshotPathRepo          []string      `env:"ZINC_SNAPSHOT_PATH_REPO"`               // the directories of the fs snapshot repositories, like path.repo of elasticsearch, no fs repository is allowed if it is empty
 	MetadataStorage           string        `env:"ZINC_METADATA_STORAGE,default=bolt"`
 	IceCompressor             string        `env:"ZINC_ICE_COMPRESSOR,default=zstd"`
 	SentryEnable              bool          `env:"ZINC_SENTRY,default=true"`
@@ -49,17 +51,32 @@ type config struct {
 	ProfilerFriendlyProfileID string        `env:"ZINC_PROFILER_FRIENDLY_PROFILE_ID"`
 	TelemetryEnable           bool          `env:"ZINC_TELEMETRY,default=true"`
 	PrometheusEnable          bool          `env:"ZINC_PROMETHEUS_ENABLE,default=false"`
+	PrometheusToken           string        `env:"ZINC_PROMETHEUS_TOKEN"` // require the bearer token to scrape metrics if set
 	EnableTextKeywordMapping  bool          `env:"ZINC_ENABLE_TEXT_KEYWORD_MAPPING,default=false"`
-	BatchSize                 int           `env:"ZINC_BATCH_SIZE,default=1024"`
+	BatchSize                 int           `env:"ZINC_BATCH_SIZE,default=1024"`     // documents of a shard written from WAL to the index at once, larger batches index faster but hold more memory
+	BatchMaxBytes             int           `env:"ZINC_BATCH_MAX_BYTES,default=32m"` // WAL bytes written to the index at once, it caps the memory of the batches of large documents, 0 disables it
 	MaxResults                int           `env:"ZINC_MAX_RESULTS,default=10000"`
 	AggregationTermsSize      int           `env:"ZINC_AGGREGATION_TERMS_SIZE,default=1000"`
+	MsearchMaxConcurrency     int           `env:"ZINC_MSEARCH_MAX_CONCURRENCY,default=5"` // searches of a _msearch running at once
+	PreferenceTTL             time.Duration `env:"ZINC_PREFERENCE_TTL,default=1m"`         // searches with the same preference share an index snapshot for it, 0 disables it
+	TrackTotalHits            int           `env:"ZINC_TRACK_TOTAL_HITS,default=0"`        // default cap of the total hits counted exactly, 0 counts all of them
+	SearchTimeout             time.Duration `env:"ZINC_SEARCH_TIMEOUT,default=0"`          // default timeout of the searches returning the hits collected until then, 0 disables it
 	MaxDocumentSize           int           `env:"ZINC_MAX_DOCUMENT_SIZE,default=1m"`      // Max size for a single document . Default = 1 MB = 1024 * 1024
+	BulkBatchSize             int           `env:"ZINC_BULK_BATCH_SIZE,default=500"`       // documents written together by the bulk handlers
+	BulkMaxPending            int           `env:"ZINC_BULK_MAX_PENDING,default=100000"`   // bulk waits while an index has more WAL entries pending
+	BulkMaxPendingWait        time.Duration `env:"ZINC_BULK_MAX_PENDING_WAIT,default=30s"` // max wait of the bulk backpressure per batch
 	WalSyncInterval           time.Duration `env:"ZINC_WAL_SYNC_INTERVAL,default=1s"`      // sync wal to disk, 1s, 10ms
 	WalRedoLogNoSync          bool          `env:"ZINC_WAL_REDOLOG_NO_SYNC,default=false"` // control sync after every write
+	LifecyclePollInterval     time.Duration `env:"ZINC_ILM_POLL_INTERVAL,default=10m"`     // how often the lifecycle policies are applied to the indexes
+	HTTPCompressMinSize       int           `env:"ZINC_HTTP_COMPRESS_MIN_SIZE,default=1k"` // gzip the responses from this size, 0 disables it
 	ZincSwaggerEnable         bool          `env:"ZINC_SWAGGER_ENABLE,default=true"`
 	Cluster                   cluster
 	Shard                     shard
 	Etcd                      etcd
+	JWT                       jwt
+	Session                   session
+	RateLimit                 rateLimit
+	LDAP                      ldap
 	Plugin                    plugin
 }
 
@@ -70,10 +87,18 @@ type cluster struct {
 type shard struct {
 	// control goroutine number for read
 	GoroutineNum int `env:"ZINC_SHARD_GOROUTINE_NUM,default=3"`
+	// control goroutine number for force merge, keep it small to not starve the queries
+	MergeGoroutineNum int `env:"ZINC_SHARD_MERGE_GOROUTINE_NUM,default=1"`
 	// DefaultNum is the default number of shards.
 	Num int64 `env:"ZINC_SHARD_NUM,default=3"`
 	// MaxSize is the maximum size limit for one shard, or will create a new shard.
 	MaxSize uint64 `env:"ZINC_SHARD_MAX_SIZE,default=1073741824"`
+	// IndexingWorkers is the number of goroutines analyzing the documents of every shard writer,
+	// more workers index faster but take the cpu from the searches
+	IndexingWorkers int `env:"ZINC_SHARD_INDEXING_WORKERS,default=4"`
+	// WriterBufferSize is the buffer of the shard writer merging the segments,
+	// a larger buffer merges with less disk io but more memory for every shard
+	WriterBufferSize int `env:"ZINC_SHARD_WRITER_BUFFER_SIZE,default=1m"`
 }
 
 type etcd struct {
@@ -83,6 +108,66 @@ type etcd struct {
 	Password  string   `env:"ZINC_ETCD_PASSWORD"`
 }
 
+type jwt struct {
+	// Secret verifies the HS256, HS384 and HS512 tokens
+	Secret string `env:"ZINC_JWT_SECRET"`
+	// JWKSURL provides the public keys verifying the RS and ES tokens
+	JWKSURL             string        `env:"ZINC_JWT_JWKS_URL"`
+	JWKSRefreshInterval time.Duration `env:"ZINC_JWT_JWKS_REFRESH_INTERVAL,default=1h"`
+	Issuer              string        `env:"ZINC_JWT_ISSUER"`
+	Audience            string        `env:"ZINC_JWT_AUDIENCE"`
+	UserClaim           string        `env:"ZINC_JWT_USER_CLAIM,default=sub"`
+	RoleClaim           string        `env:"ZINC_JWT_ROLE_CLAIM,default=role"`
+	// RoleMapping maps the claim values to the roles, like group1:admin,group2:reader,
+	// the claim value is the role if it is empty
+	RoleMapping []string `env:"ZINC_JWT_ROLE_MAPPING"`
+	// DefaultRole is the role of the tokens without a mapped role claim, they are rejected if it is empty
+	DefaultRole string `env:"ZINC_JWT_DEFAULT_ROLE"`
+}
+
+type session struct {
+	// AccessTokenTTL is the lifetime of the access tokens issued by the login and the refresh
+	AccessTokenTTL time.Duration `env:"ZINC_SESSION_ACCESS_TOKEN_TTL,default=1h"`
+	// RefreshTokenTTL is the lifetime of the session, its access token can be refreshed until it expires
+	RefreshTokenTTL time.Duration `env:"ZINC_SESSION_REFRESH_TOKEN_TTL,default=168h"`
+}
+
+// rateLimit is the requests per second of every user or api key, 0 disables the limit,
+// the burst is the requests allowed at once and it is the rate if it is 0
+type rateLimit struct {
+	SearchRate  int `env:"ZINC_RATE_LIMIT_SEARCH_RATE,default=0"`
+	SearchBurst int `env:"ZINC_RATE_LIMIT_SEARCH_BURST,default=0"`
+	IndexRate   int `env:"ZINC_RATE_LIMIT_INDEX_RATE,default=0"`
+	IndexBurst  int `env:"ZINC_RATE_LIMIT_INDEX_BURST,default=0"`
+}
+
+type ldap struct {
+	// URL enables the ldap authentication of the users missing in zinc, like ldap://host:389 or ldaps://host:636
+	URL                string `env:"ZINC_LDAP_URL"`
+	StartTLS           bool   `env:"ZINC_LDAP_START_TLS,default=false"`
+	InsecureSkipVerify bool   `env:"ZINC_LDAP_INSECURE_SKIP_VERIFY,default=false"`
+	// BindDN and BindPassword are the service account searching the users, the searches are anonymous if it is empty
+	BindDN       string `env:"ZINC_LDAP_BIND_DN"`
+	BindPassword string `env:"ZINC_LDAP_BIND_PASSWORD"`
+	SearchBase   string `env:"ZINC_LDAP_SEARCH_BASE"`
+	// UserFilter finds the user entry, %s is replaced by the escaped login name
+	UserFilter    string `env:"ZINC_LDAP_USER_FILTER,default=(uid=%s)"`
+	NameAttribute string `env:"ZINC_LDAP_NAME_ATTRIBUTE,default=cn"`
+	// GroupAttribute lists the groups in the user entry, like memberOf of Active Directory
+	GroupAttribute string `env:"ZINC_LDAP_GROUP_ATTRIBUTE,default=memberOf"`
+	// GroupFilter finds the groups of the user under GroupSearchBase, %s is replaced by the escaped user DN
+	GroupFilter     string `env:"ZINC_LDAP_GROUP_FILTER"`
+	GroupSearchBase string `env:"ZINC_LDAP_GROUP_SEARCH_BASE"`
+	// RoleMapping maps the group DN or CN to the roles separated by semicolons, like cn=ops,ou=groups,dc=example,dc=org:admin;dev:reader
+	RoleMapping string `env:"ZINC_LDAP_ROLE_MAPPING"`
+	// DefaultRole is the role of the users without a mapped group, they are rejected if it is empty
+	DefaultRole string        `env:"ZINC_LDAP_DEFAULT_ROLE"`
+	PoolSize    int           `env:"ZINC_LDAP_POOL_SIZE,default=5"`
+	Timeout     time.Duration `env:"ZINC_LDAP_TIMEOUT,default=10s"`
+	// CacheTTL caches the verified credentials to not bind on every request, 0 disables it
+	CacheTTL time.Duration `env:"ZINC_LDAP_CACHE_TTL,default=5m"`
+}
+
 type plugin struct {
 	ES  elasticsearch
 	GSE gse
@@ -16,27 +16,44 @@
 package core
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/metadata"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 var ZINC_INDEX_ALIAS_LIST AliasList
 
+// AliasContextKey is the key of the target of the request naming aliases
+const AliasContextKey = "zinc.alias"
+
 type AliasList struct {
 	lock    sync.RWMutex
 	Aliases map[string][]string
+	// WriteIndexes is the index marked with is_write_index of the alias
+	WriteIndexes map[string]string
+	// Options are the filter and the routing of the indexes of the alias
+	Options map[string]map[string]*meta.AliasOptions
 }
 
 func NewAliasList() *AliasList {
-	return &AliasList{Aliases: map[string][]string{}}
+	return &AliasList{Aliases: map[string][]string{}, WriteIndexes: map[string]string{}, Options: map[string]map[string]*meta.AliasOptions{}}
 }
 
 func (al *AliasList) AddIndexesToAlias(alias string, indexes []string) error {
 	al.lock.Lock()
-	al.Aliases[alias] = append(al.Aliases[alias], indexes...)
+	for _, index := range indexes {
+		if !zutils.SliceExists(al.Aliases[alias], index) {
+			al.Aliases[alias] = append(al.Aliases[alias], index)
+		}
+	}
 
 	err := metadata.Alias.Set(al.Aliases)
 	if err != nil {
@@ -81,6 +98,31 @@ func (al *AliasList) RemoveIndexesFromAlias(alias string, removeIndexes []string
 		return err
 	}
 
+	if options, ok := al.Options[alias]; ok {
+		for index := range removeIndexesMap {
+			delete(options, index)
+		}
+		if len(options) == 0 {
+			delete(al.Options, alias)
+		}
+		err = metadata.Alias.SetOptions(al.Options)
+		if err != nil {
+			log.Err(err).Msg("failed to save alias options in metadata after remove operation")
+			al.lock.Unlock()
+			return err
+		}
+	}
+
+	if writeIndex, ok := al.WriteIndexes[alias]; ok && removeIndexesMap[writeIndex] {
+		delete(al.WriteIndexes, alias)
+		err = metadata.Alias.SetWriteIndexes(al.WriteIndexes)
+		if err != nil {
+			log.Err(err).Msg("failed to save alias write index in metadata after remove operation")
+			al.lock.Unlock()
+			return err
+		}
+	}
+
 	al.lock.Unlock()
 	return nil
 }
@@ -100,6 +142,150 @@ func (al *AliasList) GetIndexesForAlias(aliasName string) ([]string, bool) {
 	return v, ok
 }
 
+// SetWriteIndex marks the index as the write index of the alias,
+// an empty index unmarks the write index of the alias
+func (al *AliasList) SetWriteIndex(alias, index string) error {
+	al.lock.Lock()
+	if al.WriteIndexes == nil {
+		al.WriteIndexes = map[string]string{}
+	}
+	if index == "" {
+		delete(al.WriteIndexes, alias)
+	} else {
+		al.WriteIndexes[alias] = index
+	}
+
+	err := metadata.Alias.SetWriteIndexes(al.WriteIndexes)
+	if err != nil {
+		log.Err(err).Msg("failed to save alias write index in metadata")
+		al.lock.Unlock()
+		return err
+	}
+
+	al.lock.Unlock()
+	return nil
+}
+
+// GetWriteIndex returns the index marked as the write index of the alias
+func (al *AliasList) GetWriteIndex(alias string) (string, bool) {
+	al.lock.RLock()
+	index, ok := al.WriteIndexes[alias]
+	al.lock.RUnlock()
+	return index, ok
+}
+
+// SetOptions sets the filter and the routing of the indexes of the alias,
+// a nil options clears them
+func (al *AliasList) SetOptions(alias string, options map[string]*meta.AliasOptions) error {
+	al.lock.Lock()
+	if al.Options == nil {
+		al.Options = map[string]map[string]*meta.AliasOptions{}
+	}
+	for index, opts := range options {
+		if opts == nil {
+			delete(al.Options[alias], index)
+			continue
+		}
+		if al.Options[alias] == nil {
+			al.Options[alias] = map[string]*meta.AliasOptions{}
+		}
+		al.Options[alias][index] = opts
+	}
+	if len(al.Options[alias]) == 0 {
+		delete(al.Options, alias)
+	}
+
+	err := metadata.Alias.SetOptions(al.Options)
+	if err != nil {
+		log.Err(err).Msg("failed to save alias options in metadata")
+		al.lock.Unlock()
+		return err
+	}
+
+	al.lock.Unlock()
+	return nil
+}
+
+// GetOptions returns the filter and the routing of the index in the alias
+func (al *AliasList) GetOptions(alias, index string) (*meta.AliasOptions, bool) {
+	al.lock.RLock()
+	opts, ok := al.Options[alias][index]
+	al.lock.RUnlock()
+	return opts, ok
+}
+
+// Filter returns the filter of the alias, the filter is applied to the whole search
+// so the indexes of the alias with different filters can't be searched together
+func (al *AliasList) Filter(alias string) (map[string]interface{}, error) {
+	al.lock.RLock()
+	defer al.lock.RUnlock()
+
+	indexes := al.Aliases[alias]
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+	filter := al.Options[alias][indexes[0]].GetFilter()
+	for _, index := range indexes[1:] {
+		if !reflect.DeepEqual(filter, al.Options[alias][index].GetFilter()) {
+			names := append([]string(nil), indexes...)
+			sort.Strings(names)
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException,
+				fmt.Sprintf("the indexes [%s] of alias [%s] have different filters, search them separately", strings.Join(names, ", "), alias))
+		}
+	}
+	return filter, nil
+}
+
+// SearchRouting returns the search routing values of the indexes of the alias separated by commas
+func (al *AliasList) SearchRouting(alias string) string {
+	al.lock.RLock()
+	defer al.lock.RUnlock()
+
+	var routing []string
+	for _, index := range al.Aliases[alias] {
+		if opts, ok := al.Options[alias][index]; ok {
+			for _, v := range SplitRouting(opts.SearchRouting) {
+				if !zutils.SliceExists(routing, v) {
+					routing = append(routing, v)
+				}
+			}
+		}
+	}
+	return strings.Join(routing, ",")
+}
+
+// IndexRouting returns the index routing of the write index of the alias
+func (al *AliasList) IndexRouting(alias string) string {
+	index, err := al.ResolveWriteIndex(alias)
+	if err != nil {
+		return ""
+	}
+	if opts, ok := al.GetOptions(alias, index); ok {
+		return opts.IndexRouting
+	}
+	return ""
+}
+
+// ResolveWriteIndex returns the index which the documents written to name go to.
+// If name is an alias, it is the write index of the alias, or the only index
+// of the alias when no write index is marked. Otherwise it is name itself.
+func (al *AliasList) ResolveWriteIndex(name string) (string, error) {
+	al.lock.RLock()
+	defer al.lock.RUnlock()
+
+	indexes, ok := al.Aliases[name]
+	if !ok || len(indexes) == 0 {
+		return name, nil
+	}
+	if index, ok := al.WriteIndexes[name]; ok {
+		return index, nil
+	}
+	if len(indexes) == 1 {
+		return indexes[0], nil
+	}
+	return "", fmt.Errorf("no write index is defined for alias [%s]. The write index may be explicitly disabled using is_write_index=false or the alias points to multiple indices without one being designated as a write index", name)
+}
+
 func (al *AliasList) GetAliasesForIndex(indexName string) []string {
 	al.lock.RLock()
 	var aliases []string
@@ -113,6 +299,27 @@ func (al *AliasList) GetAliasesForIndex(indexName string) []string {
 	return aliases
 }
 
+// ListMatch returns a copy of the aliases matching any of the names,
+// a name can start or end with `*`, no names match all aliases
+func (al *AliasList) ListMatch(names []string) map[string][]string {
+	al.lock.RLock()
+	aliases := make(map[string][]string, len(al.Aliases))
+	for alias, indexes := range al.Aliases {
+		matched := len(names) == 0
+		for _, name := range names {
+			if isMatchIndex(alias, name) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			aliases[alias] = append([]string(nil), indexes...)
+		}
+	}
+	al.lock.RUnlock()
+	return aliases
+}
+
 type M map[string]interface{}
 
 // GetAliasMap returns an ES compatible map of indexes to their aliases
@@ -147,7 +354,26 @@ outerLoop:
 				indexMap["aliases"] = aliases
 			}
 
-			aliases[alias] = struct{}{}
+			m := M{}
+			if opts, ok := al.Options[alias][index]; ok {
+				if opts.Filter != nil {
+					m["filter"] = opts.Filter
+				}
+				if opts.IndexRouting != "" {
+					m["index_routing"] = opts.IndexRouting
+				}
+				if opts.SearchRouting != "" {
+					m["search_routing"] = opts.SearchRouting
+				}
+			}
+			if al.WriteIndexes[alias] == index {
+				m["is_write_index"] = true
+			}
+			if len(m) > 0 {
+				aliases[alias] = m
+			} else {
+				aliases[alias] = struct{}{}
+			}
 		}
 	}
 
@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
 )
 
 func TestAliasList_AddIndexesToAlias(t *testing.T) {
@@ -252,6 +254,29 @@ func TestAliasList_GetAliasMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "should_get_alias_map_with_write_index",
+			nFn: func(al *AliasList) {
+				al.Aliases["alias_1"] = append(al.Aliases["alias_1"], "index_0", "index_1")
+				al.WriteIndexes["alias_1"] = "index_1"
+			},
+			args: args{
+				targetIndexes: []string{},
+				targetAliases: []string{},
+			},
+			want: M{
+				"index_0": M{
+					"aliases": M{
+						"alias_1": struct{}{},
+					},
+				},
+				"index_1": M{
+					"aliases": M{
+						"alias_1": M{"is_write_index": true},
+					},
+				},
+			},
+		},
 		{
 			name: "should_get_alias_map_with_targets",
 			nFn: func(al *AliasList) {
@@ -302,3 +327,103 @@ func TestAliasList_GetAliasMap(t *testing.T) {
 		})
 	}
 }
+
+func TestAliasList_ListMatch(t *testing.T) {
+	al := NewAliasList()
+	al.Aliases["logs"] = []string{"logs-1", "logs-2"}
+	al.Aliases["logs-current"] = []string{"logs-2"}
+	al.Aliases["users"] = []string{"users-1"}
+
+	require.Len(t, al.ListMatch(nil), 3)
+	require.Equal(t, map[string][]string{"users": {"users-1"}}, al.ListMatch([]string{"users"}))
+	require.Len(t, al.ListMatch([]string{"logs*"}), 2)
+	require.Empty(t, al.ListMatch([]string{"nope"}))
+}
+
+func TestAliasList_ResolveWriteIndex(t *testing.T) {
+	al := NewAliasList()
+	require.NoError(t, al.AddIndexesToAlias("single", []string{"index_0"}))
+	require.NoError(t, al.AddIndexesToAlias("multi", []string{"index_0", "index_1"}))
+
+	index, err := al.ResolveWriteIndex("index_2")
+	require.NoError(t, err)
+	require.Equal(t, "index_2", index)
+
+	index, err = al.ResolveWriteIndex("single")
+	require.NoError(t, err)
+	require.Equal(t, "index_0", index)
+
+	_, err = al.ResolveWriteIndex("multi")
+	require.Error(t, err)
+
+	require.NoError(t, al.SetWriteIndex("multi", "index_1"))
+	index, err = al.ResolveWriteIndex("multi")
+	require.NoError(t, err)
+	require.Equal(t, "index_1", index)
+
+	// removing the write index from the alias unmarks it
+	require.NoError(t, al.RemoveIndexesFromAlias("multi", []string{"index_1"}))
+	_, ok := al.GetWriteIndex("multi")
+	require.False(t, ok)
+	index, err = al.ResolveWriteIndex("multi")
+	require.NoError(t, err)
+	require.Equal(t, "index_0", index)
+
+	require.NoError(t, al.SetWriteIndex("multi", ""))
+	require.NoError(t, al.SetWriteIndex("single", "index_0"))
+	require.NoError(t, al.SetWriteIndex("single", ""))
+	_, ok = al.GetWriteIndex("single")
+	require.False(t, ok)
+}
+
+func TestAliasList_Options(t *testing.T) {
+	al := NewAliasList()
+	require.NoError(t, al.AddIndexesToAlias("alias_1", []string{"index_1", "index_2"}))
+	require.NoError(t, al.SetWriteIndex("alias_1", "index_2"))
+
+	filter := map[string]interface{}{"term": map[string]interface{}{"tenant": "acme"}}
+	t.Run("no options", func(t *testing.T) {
+		got, err := al.Filter("alias_1")
+		require.NoError(t, err)
+		require.Nil(t, got)
+		require.Equal(t, "", al.SearchRouting("alias_1"))
+		require.Equal(t, "", al.IndexRouting("alias_1"))
+	})
+
+	t.Run("different filters", func(t *testing.T) {
+		require.NoError(t, al.SetOptions("alias_1", map[string]*meta.AliasOptions{
+			"index_1": {Filter: filter, SearchRouting: "1,2"},
+		}))
+		_, err := al.Filter("alias_1")
+		require.Error(t, err)
+	})
+
+	t.Run("same filters", func(t *testing.T) {
+		require.NoError(t, al.SetOptions("alias_1", map[string]*meta.AliasOptions{
+			"index_2": {Filter: filter, IndexRouting: "1", SearchRouting: "2,3"},
+		}))
+		got, err := al.Filter("alias_1")
+		require.NoError(t, err)
+		require.Equal(t, filter, got)
+		require.Equal(t, "1,2,3", al.SearchRouting("alias_1"))
+		require.Equal(t, "1", al.IndexRouting("alias_1"))
+	})
+
+	t.Run("get alias map", func(t *testing.T) {
+		m := al.GetAliasMap([]string{"index_2"}, nil)
+		require.Equal(t, M{"alias_1": M{
+			"filter":         filter,
+			"index_routing":  "1",
+			"search_routing": "2,3",
+			"is_write_index": true,
+		}}, m["index_2"].(M)["aliases"])
+	})
+
+	t.Run("remove index", func(t *testing.T) {
+		require.NoError(t, al.RemoveIndexesFromAlias("alias_1", []string{"index_2"}))
+		_, ok := al.GetOptions("alias_1", "index_2")
+		require.False(t, ok)
+		require.NoError(t, al.SetOptions("alias_1", map[string]*meta.AliasOptions{"index_1": nil}))
+		require.Empty(t, al.Options)
+	})
+}
@@ -0,0 +1,157 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/ider"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// ZINC_ASYNC_SEARCHES keeps the searches running in the background and their responses until they expire,
+// a search is cancelled when it expires or it is deleted before it completed
+var ZINC_ASYNC_SEARCHES = &asyncSearches{searches: map[string]*AsyncSearch{}}
+
+type asyncSearches struct {
+	searches map[string]*AsyncSearch
+	lock     sync.RWMutex
+}
+
+// AsyncSearch is a search running in the background, it belongs to the user who submitted it
+type AsyncSearch struct {
+	ID    string
+	Owner string
+
+	shards     int64
+	startTime  time.Time
+	expiration time.Time
+	completion time.Time
+	resp       *meta.SearchResponse
+	err        error
+	done       chan struct{}
+	cancel     context.CancelFunc
+	timer      *time.Timer
+	lock       sync.RWMutex
+}
+
+// Submit runs the search in the background, shards is the number of shards searched for the progress.
+// The search is kept for keepAlive, the expiration can be extended while getting it
+func (t *asyncSearches) Submit(owner string, shards int64, keepAlive time.Duration, search func(ctx context.Context) (*meta.SearchResponse, error)) *AsyncSearch {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &AsyncSearch{
+		ID:         ider.Generate(),
+		Owner:      owner,
+		shards:     shards,
+		startTime:  time.Now(),
+		expiration: time.Now().Add(keepAlive),
+		done:       make(chan struct{}),
+		cancel:     cancel,
+	}
+	t.lock.Lock()
+	t.searches[s.ID] = s
+	s.timer = time.AfterFunc(keepAlive, func() { t.Delete(s.ID) })
+	t.lock.Unlock()
+
+	go func() {
+		resp, err := search(ctx)
+		s.lock.Lock()
+		s.resp, s.err = resp, err
+		s.completion = time.Now()
+		s.lock.Unlock()
+		close(s.done)
+	}()
+	return s
+}
+
+// Get returns the async search, a positive keepAlive extends its expiration from now
+func (t *asyncSearches) Get(id string, keepAlive time.Duration) (*AsyncSearch, bool) {
+	t.lock.RLock()
+	s, ok := t.searches[id]
+	t.lock.RUnlock()
+	if ok && keepAlive > 0 {
+		s.lock.Lock()
+		s.expiration = time.Now().Add(keepAlive)
+		s.lock.Unlock()
+		s.timer.Reset(keepAlive)
+	}
+	return s, ok
+}
+
+// Delete cancels the search if it is running and drops it
+func (t *asyncSearches) Delete(id string) bool {
+	t.lock.Lock()
+	s, ok := t.searches[id]
+	delete(t.searches, id)
+	t.lock.Unlock()
+	if ok {
+		s.timer.Stop()
+		s.cancel()
+	}
+	return ok
+}
+
+// Wait waits at most timeout for the search to complete, it returns whether the search completed
+func (s *AsyncSearch) Wait(timeout time.Duration) bool {
+	if timeout <= 0 {
+		select {
+		case <-s.done:
+			return true
+		default:
+			return false
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Response returns the state of the search, the response has only the progress of the shards while it is running
+func (s *AsyncSearch) Response() *meta.AsyncSearchResponse {
+	running := !s.Wait(0)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	resp := &meta.AsyncSearchResponse{
+		ID:                     s.ID,
+		IsRunning:              running,
+		StartTimeInMillis:      s.startTime.UnixMilli(),
+		ExpirationTimeInMillis: s.expiration.UnixMilli(),
+	}
+	switch {
+	case running:
+		resp.IsPartial = true
+		resp.Response = &meta.SearchResponse{
+			Shards: meta.Shards{Total: s.shards},
+			Hits:   meta.Hits{Hits: []meta.Hit{}},
+		}
+	case s.err != nil:
+		resp.IsPartial = true
+		resp.Error = s.err.Error()
+		resp.CompletionTimeInMillis = s.completion.UnixMilli()
+	default:
+		resp.IsPartial = s.resp.TimedOut
+		resp.Response = s.resp
+		resp.CompletionTimeInMillis = s.completion.UnixMilli()
+	}
+	return resp
+}
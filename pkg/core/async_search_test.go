@@ -0,0 +1,75 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestAsyncSearches(t *testing.T) {
+	t.Run("completed", func(t *testing.T) {
+		s := ZINC_ASYNC_SEARCHES.Submit("user", 3, time.Minute, func(ctx context.Context) (*meta.SearchResponse, error) {
+			return &meta.SearchResponse{Shards: meta.Shards{Total: 3, Successful: 3}}, nil
+		})
+		assert.True(t, s.Wait(time.Second))
+		resp := s.Response()
+		assert.False(t, resp.IsRunning)
+		assert.False(t, resp.IsPartial)
+		assert.Equal(t, int64(3), resp.Response.Shards.Successful)
+		assert.NotZero(t, resp.CompletionTimeInMillis)
+		assert.True(t, ZINC_ASYNC_SEARCHES.Delete(s.ID))
+	})
+
+	t.Run("running", func(t *testing.T) {
+		s := ZINC_ASYNC_SEARCHES.Submit("user", 3, time.Minute, func(ctx context.Context) (*meta.SearchResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.False(t, s.Wait(10*time.Millisecond))
+		resp := s.Response()
+		assert.True(t, resp.IsRunning)
+		assert.True(t, resp.IsPartial)
+		assert.Equal(t, int64(3), resp.Response.Shards.Total)
+
+		expiration := resp.ExpirationTimeInMillis
+		time.Sleep(5 * time.Millisecond)
+		got, ok := ZINC_ASYNC_SEARCHES.Get(s.ID, time.Hour)
+		assert.True(t, ok)
+		assert.Greater(t, got.Response().ExpirationTimeInMillis, expiration)
+
+		// deleting cancels the search
+		assert.True(t, ZINC_ASYNC_SEARCHES.Delete(s.ID))
+		assert.True(t, s.Wait(time.Second))
+		assert.Equal(t, context.Canceled.Error(), s.Response().Error)
+		_, ok = ZINC_ASYNC_SEARCHES.Get(s.ID, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s := ZINC_ASYNC_SEARCHES.Submit("user", 1, 10*time.Millisecond, func(ctx context.Context) (*meta.SearchResponse, error) {
+			return &meta.SearchResponse{}, nil
+		})
+		time.Sleep(50 * time.Millisecond)
+		_, ok := ZINC_ASYNC_SEARCHES.Get(s.ID, 0)
+		assert.False(t, ok)
+	})
+}
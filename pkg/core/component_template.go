@@ -0,0 +1,175 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+)
+
+// ListComponentTemplates returns all component templates sorted by name
+func ListComponentTemplates() ([]*meta.ComponentTemplate, error) {
+	templates, err := metadata.ComponentTemplate.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if templates == nil {
+		templates = make([]*meta.ComponentTemplate, 0)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// NewComponentTemplate create a component template and store in local
+func NewComponentTemplate(name string, template *meta.IndexComponentTemplate) error {
+	if name == "" || template == nil {
+		return nil
+	}
+
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+	tpl := meta.ComponentTemplate{
+		Name:              name,
+		ComponentTemplate: template,
+	}
+	err := metadata.ComponentTemplate.Set(name, tpl)
+	if err != nil {
+		return fmt.Errorf("component template: error updating document: %s", err.Error())
+	}
+
+	return nil
+}
+
+// LoadComponentTemplate load a specific component template from local
+func LoadComponentTemplate(name string) (*meta.IndexComponentTemplate, bool, error) {
+	if name == "" {
+		return nil, false, nil
+	}
+
+	tpl, err := metadata.ComponentTemplate.Get(name)
+	if err != nil {
+		if err == errors.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return tpl.ComponentTemplate, true, nil
+}
+
+// DeleteComponentTemplate delete a component template from local,
+// it fails when the component template is still used by some index templates
+func DeleteComponentTemplate(name string) error {
+	templates, err := ListTemplates("")
+	if err != nil {
+		return err
+	}
+	var used []string
+	for _, tpl := range templates {
+		for _, component := range tpl.IndexTemplate.ComposedOf {
+			if component == name {
+				used = append(used, tpl.Name)
+				break
+			}
+		}
+	}
+	if len(used) > 0 {
+		sort.Strings(used)
+		return fmt.Errorf("component template [%s] cannot be removed as it is still in use by index templates %v", name, used)
+	}
+	return metadata.ComponentTemplate.Delete(name)
+}
+
+// checkComponentTemplates returns an error when the index template uses a missing component template
+func checkComponentTemplates(name string, template *meta.IndexTemplate) error {
+	var missing []string
+	for _, component := range template.ComposedOf {
+		_, exists, err := LoadComponentTemplate(component)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, component)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("index template [%s] specifies component templates %v that do not exist", name, missing)
+	}
+	return nil
+}
+
+// resolveTemplate returns a copy of the index template with the settings and mappings of
+// its component templates merged in order, the settings and mappings of the index template
+// itself are merged last so they take precedence
+func resolveTemplate(template *meta.IndexTemplate) (*meta.IndexTemplate, error) {
+	if len(template.ComposedOf) == 0 {
+		return template, nil
+	}
+
+	resolved := *template
+	resolved.Template = meta.TemplateTemplate{}
+	for _, name := range template.ComposedOf {
+		component, exists, err := LoadComponentTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("component template [%s] does not exist", name)
+		}
+		if err = mergeTemplate(&resolved.Template, &component.Template); err != nil {
+			return nil, err
+		}
+	}
+	if err := mergeTemplate(&resolved.Template, &template.Template); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// mergeTemplate merges the settings and mappings of src into dst, the fields of src win
+func mergeTemplate(dst, src *meta.TemplateTemplate) error {
+	settings, err := meta.MergeSettings(dst.Settings, src.Settings)
+	if err != nil {
+		return err
+	}
+	dst.Settings = settings
+
+	if src.Mappings == nil {
+		return nil
+	}
+	if dst.Mappings == nil {
+		dst.Mappings = meta.NewMappings()
+	}
+	for field, prop := range src.Mappings.ListProperty() {
+		dst.Mappings.SetProperty(field, prop.DeepClone())
+	}
+	for field, runtime := range src.Mappings.ListRuntime() {
+		dst.Mappings.SetRuntime(field, runtime)
+	}
+	for _, tpl := range src.Mappings.ListDynamicTemplates() {
+		dst.Mappings.SetDynamicTemplate(tpl)
+	}
+	if source := src.Mappings.GetSource(); source != nil {
+		dst.Mappings.SetSource(source)
+	}
+	return nil
+}
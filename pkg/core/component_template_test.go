@@ -0,0 +1,120 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestComponentTemplate(t *testing.T) {
+	components := map[string]*meta.IndexComponentTemplate{
+		"TestComponentTemplate-settings": {
+			Template: meta.TemplateTemplate{
+				Settings: &meta.IndexSettings{NumberOfShards: 2, NumberOfReplicas: 1},
+			},
+		},
+		"TestComponentTemplate-mappings": {
+			Template: meta.TemplateTemplate{
+				Mappings: &meta.Mappings{
+					Properties: map[string]meta.Property{
+						"@timestamp": meta.NewProperty("date"),
+						"message":    meta.NewProperty("keyword"),
+					},
+				},
+			},
+		},
+	}
+	template := &meta.IndexTemplate{
+		IndexPatterns: []string{"TestComponentTemplate-log-*"},
+		Priority:      100,
+		ComposedOf:    []string{"TestComponentTemplate-settings", "TestComponentTemplate-mappings"},
+		Template: meta.TemplateTemplate{
+			Settings: &meta.IndexSettings{NumberOfShards: 3},
+			Mappings: &meta.Mappings{
+				Properties: map[string]meta.Property{
+					"message": meta.NewProperty("text"),
+				},
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		for name, tpl := range components {
+			assert.NoError(t, NewComponentTemplate(name, tpl))
+		}
+		assert.NoError(t, NewTemplate("TestComponentTemplate", template))
+	})
+
+	t.Run("list and load", func(t *testing.T) {
+		tpls, err := ListComponentTemplates()
+		assert.NoError(t, err)
+		assert.Len(t, tpls, 2)
+		assert.Equal(t, "TestComponentTemplate-mappings", tpls[0].Name)
+
+		tpl, exists, err := LoadComponentTemplate("TestComponentTemplate-settings")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, int64(2), tpl.Template.Settings.NumberOfShards)
+
+		_, exists, err = LoadComponentTemplate("TestComponentTemplate-none")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("missing component", func(t *testing.T) {
+		err := NewTemplate("TestComponentTemplate-missing", &meta.IndexTemplate{
+			IndexPatterns: []string{"TestComponentTemplate-missing-*"},
+			ComposedOf:    []string{"TestComponentTemplate-none"},
+		})
+		assert.ErrorContains(t, err, "do not exist")
+	})
+
+	t.Run("new index use resolved template", func(t *testing.T) {
+		indexName := "TestComponentTemplate-log-2023.01.01"
+		index, err := NewIndex(indexName, "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), index.GetShardNum())
+		assert.Equal(t, int64(1), index.GetSettings().NumberOfReplicas)
+		prop, ok := index.GetMappings().GetProperty("@timestamp")
+		assert.True(t, ok)
+		assert.Equal(t, "date", prop.Type)
+		prop, ok = index.GetMappings().GetProperty("message")
+		assert.True(t, ok)
+		assert.Equal(t, "text", prop.Type)
+		assert.NoError(t, StoreIndex(index))
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+
+	t.Run("delete component in use", func(t *testing.T) {
+		err := DeleteComponentTemplate("TestComponentTemplate-settings")
+		assert.ErrorContains(t, err, "still in use")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteTemplate("TestComponentTemplate"))
+		for name := range components {
+			assert.NoError(t, DeleteComponentTemplate(name))
+		}
+
+		tpls, err := ListComponentTemplates()
+		assert.NoError(t, err)
+		assert.Len(t, tpls, 0)
+	})
+}
@@ -0,0 +1,302 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+)
+
+var ZINC_DATA_STREAM_LIST DataStreamList
+
+// errDataStreamOpType is the error of the writes of a data stream other than the creations
+var errDataStreamOpType = errors.New(errors.ErrorTypeIllegalArgumentException, "only write ops with an op_type of create are allowed in data streams")
+
+type DataStreamList struct {
+	lock    sync.RWMutex
+	Streams map[string]*meta.DataStream
+}
+
+func (t *DataStreamList) load() error {
+	streams, err := metadata.DataStream.List(0, 0)
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	t.Streams = make(map[string]*meta.DataStream, len(streams))
+	for _, stream := range streams {
+		t.Streams[stream.Name] = stream
+	}
+	t.lock.Unlock()
+	return nil
+}
+
+// Get returns a copy of the data stream
+func (t *DataStreamList) Get(name string) (*meta.DataStream, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	stream, ok := t.Streams[name]
+	if !ok {
+		return nil, false
+	}
+	return copyDataStream(stream), true
+}
+
+// GetIndexes returns the backing indexes of the data stream
+func (t *DataStreamList) GetIndexes(name string) ([]string, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	stream, ok := t.Streams[name]
+	if !ok {
+		return nil, false
+	}
+	return stream.IndexNames(), true
+}
+
+// ListMatch returns the data streams matching any of the names sorted by name, no names match all
+func (t *DataStreamList) ListMatch(names []string) []*meta.DataStream {
+	t.lock.RLock()
+	streams := make([]*meta.DataStream, 0, len(t.Streams))
+	for name, stream := range t.Streams {
+		if len(names) == 0 || matchAny(name, names) {
+			streams = append(streams, copyDataStream(stream))
+		}
+	}
+	t.lock.RUnlock()
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams
+}
+
+func (t *DataStreamList) set(stream *meta.DataStream) error {
+	if err := metadata.DataStream.Set(stream.Name, stream); err != nil {
+		return err
+	}
+	t.Streams[stream.Name] = stream
+	return nil
+}
+
+// removeIndex removes the backing index from the data stream, the write index can't be removed
+func (t *DataStreamList) removeIndex(name, indexName string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	stream, ok := t.Streams[name]
+	if !ok {
+		return nil
+	}
+	if stream.WriteIndex() == indexName {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("index [%s] is the write index for data stream [%s] and cannot be deleted", indexName, name))
+	}
+	stream = copyDataStream(stream)
+	indices := stream.Indices[:0]
+	for _, index := range stream.Indices {
+		if index.IndexName != indexName {
+			indices = append(indices, index)
+		}
+	}
+	stream.Indices = indices
+	return t.set(stream)
+}
+
+func copyDataStream(stream *meta.DataStream) *meta.DataStream {
+	v := *stream
+	v.Indices = append([]meta.DataStreamIndex(nil), stream.Indices...)
+	return &v
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if isMatchIndex(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// DataStreamIndexName returns the name of the backing index of the generation of the data stream
+func DataStreamIndexName(name string, generation int64) string {
+	return fmt.Sprintf(".ds-%s-%06d", name, generation)
+}
+
+// CreateDataStream creates the data stream with its first backing index,
+// the backing indexes use the index template matching the name of the data stream
+func CreateDataStream(name string) (*meta.DataStream, error) {
+	if err := CheckIndexName(name); err != nil {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, err.Error())
+	}
+	ZINC_DATA_STREAM_LIST.lock.Lock()
+	defer ZINC_DATA_STREAM_LIST.lock.Unlock()
+	if _, ok := ZINC_DATA_STREAM_LIST.Streams[name]; ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("data_stream [%s] already exists", name))
+	}
+	if _, ok := GetIndex(name); ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("data stream [%s] conflicts with index [%s]", name, name))
+	}
+	if _, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(name); ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("data stream [%s] conflicts with alias [%s]", name, name))
+	}
+
+	stream := &meta.DataStream{
+		Name:           name,
+		TimestampField: meta.DataStreamTimestampField{Name: meta.TimeFieldName},
+		Status:         "GREEN",
+		CreatedAt:      time.Now(),
+	}
+	if err := newDataStreamIndex(stream, nil); err != nil {
+		return nil, err
+	}
+	if err := ZINC_DATA_STREAM_LIST.set(stream); err != nil {
+		return nil, err
+	}
+	return copyDataStream(stream), nil
+}
+
+// newDataStreamIndex creates the backing index of the next generation of the data stream,
+// the rollover passes the current write index whose settings and mappings are copied
+func newDataStreamIndex(stream *meta.DataStream, old *Index) error {
+	name := DataStreamIndexName(stream.Name, stream.Generation+1)
+	if _, ok := GetIndex(name); ok {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("index [%s] already exists", name))
+	}
+	var index *Index
+	var err error
+	if old != nil {
+		if index, err = NewIndex(name, old.GetStorageType(), old.GetShardNum()); err != nil {
+			return err
+		}
+		_ = index.SetSettings(old.GetSettings())
+		_ = index.SetAnalyzers(old.GetAnalyzers())
+		if mappings := old.GetMappings(); mappings != nil {
+			_ = index.SetMappings(mappings.DeepClone())
+		}
+	} else if index, err = newIndex(name, stream.Name, "", 0); err != nil {
+		return err
+	}
+	index.ref.DataStream = stream.Name
+	if err = StoreIndex(index); err != nil {
+		return err
+	}
+	stream.Generation++
+	stream.Indices = append(stream.Indices, meta.DataStreamIndex{IndexName: name})
+	return nil
+}
+
+// DeleteDataStream deletes the data stream and its backing indexes
+func DeleteDataStream(name string) error {
+	ZINC_DATA_STREAM_LIST.lock.Lock()
+	stream, ok := ZINC_DATA_STREAM_LIST.Streams[name]
+	if !ok {
+		ZINC_DATA_STREAM_LIST.lock.Unlock()
+		return errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", name))
+	}
+	if err := metadata.DataStream.Delete(name); err != nil {
+		ZINC_DATA_STREAM_LIST.lock.Unlock()
+		return err
+	}
+	delete(ZINC_DATA_STREAM_LIST.Streams, name)
+	ZINC_DATA_STREAM_LIST.lock.Unlock()
+
+	for _, index := range stream.IndexNames() {
+		if _, ok := GetIndex(index); !ok {
+			continue
+		}
+		if err := DeleteIndex(index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rolloverDataStream creates the next generation of the data stream when any of the conditions
+// is met by the current write index, the new backing index becomes the write index
+func rolloverDataStream(name string, req *meta.RolloverRequest, dryRun bool) (*meta.RolloverResponse, error) {
+	ZINC_DATA_STREAM_LIST.lock.Lock()
+	defer ZINC_DATA_STREAM_LIST.lock.Unlock()
+	stream, ok := ZINC_DATA_STREAM_LIST.Streams[name]
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", name))
+	}
+	oldName := stream.WriteIndex()
+	old, ok := GetIndex(oldName)
+	if !ok {
+		return nil, fmt.Errorf("index %s does not exists", oldName)
+	}
+
+	conditions, met, err := rolloverConditions(old, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &meta.RolloverResponse{
+		OldIndex:   oldName,
+		NewIndex:   DataStreamIndexName(name, stream.Generation+1),
+		DryRun:     dryRun,
+		Conditions: conditions,
+	}
+	if dryRun || !met {
+		return resp, nil
+	}
+
+	stream = copyDataStream(stream)
+	if err = newDataStreamIndex(stream, old); err != nil {
+		return nil, err
+	}
+	if err = ZINC_DATA_STREAM_LIST.set(stream); err != nil {
+		return nil, err
+	}
+	if err = old.setRolledOver(time.Now()); err != nil {
+		return nil, err
+	}
+	resp.Acknowledged = true
+	resp.RolledOver = true
+	return resp, nil
+}
+
+// ResolveWriteIndex returns the index the writes to the name go to,
+// the write index of a data stream or an alias, or the name itself
+func ResolveWriteIndex(name string) (string, error) {
+	ZINC_DATA_STREAM_LIST.lock.RLock()
+	stream, ok := ZINC_DATA_STREAM_LIST.Streams[name]
+	ZINC_DATA_STREAM_LIST.lock.RUnlock()
+	if ok {
+		return stream.WriteIndex(), nil
+	}
+	return ZINC_INDEX_ALIAS_LIST.ResolveWriteIndex(name)
+}
+
+// GetDataStream returns the data stream of the backing index, empty for the other indexes
+func (index *Index) GetDataStream() string {
+	return index.ref.DataStream
+}
+
+// CheckDataStreamWrite returns an error if the write breaks the append-only data stream of the
+// backing index, only the documents with the timestamp field are created. The doc is nil for deletes.
+func (index *Index) CheckDataStreamWrite(doc map[string]interface{}, create bool) error {
+	if index.GetDataStream() == "" {
+		return nil
+	}
+	if !create {
+		return errDataStreamOpType
+	}
+	if _, ok := doc[meta.TimeFieldName]; !ok {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("data stream timestamp field [%s] is missing", meta.TimeFieldName))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestDataStream(t *testing.T) {
+	name := "test_data_stream"
+	gen1 := DataStreamIndexName(name, 1)
+	gen2 := DataStreamIndexName(name, 2)
+	t.Run("create", func(t *testing.T) {
+		stream, err := CreateDataStream(name)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), stream.Generation)
+		assert.Equal(t, []string{gen1}, stream.IndexNames())
+		assert.Equal(t, "@timestamp", stream.TimestampField.Name)
+
+		index, ok := GetIndex(gen1)
+		assert.True(t, ok)
+		assert.Equal(t, name, index.GetDataStream())
+
+		_, err = CreateDataStream(name)
+		assert.Error(t, err)
+		_, err = CreateDataStream(gen1)
+		assert.Error(t, err)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		writeIndex, err := ResolveWriteIndex(name)
+		assert.NoError(t, err)
+		assert.Equal(t, gen1, writeIndex)
+
+		index, _ := GetIndex(gen1)
+		assert.NoError(t, index.CheckDataStreamWrite(map[string]interface{}{"@timestamp": "2023-01-01T00:00:00Z"}, true))
+		assert.Error(t, index.CheckDataStreamWrite(map[string]interface{}{"name": "doc"}, true))
+		assert.Error(t, index.CheckDataStreamWrite(map[string]interface{}{"@timestamp": "2023-01-01T00:00:00Z"}, false))
+		assert.Error(t, index.CheckDataStreamWrite(nil, false))
+	})
+
+	t.Run("rollover", func(t *testing.T) {
+		_, err := Rollover(name, "new_index", nil, false)
+		assert.Error(t, err)
+
+		resp, err := Rollover(name, "", nil, false)
+		assert.NoError(t, err)
+		assert.True(t, resp.RolledOver)
+		assert.Equal(t, gen1, resp.OldIndex)
+		assert.Equal(t, gen2, resp.NewIndex)
+
+		index, ok := GetIndex(gen2)
+		assert.True(t, ok)
+		assert.Equal(t, name, index.GetDataStream())
+		writeIndex, _ := ResolveWriteIndex(name)
+		assert.Equal(t, gen2, writeIndex)
+
+		// the reads span all generations
+		assert.Equal(t, []string{gen1, gen2}, ResolveIndexNames([]string{name}))
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		resp, err := ResolveIndex([]string{name})
+		assert.NoError(t, err)
+		assert.Len(t, resp.DataStreams, 1)
+		assert.Equal(t, &meta.ResolvedDataStream{
+			Name:           name,
+			BackingIndices: []string{gen1, gen2},
+			TimestampField: "@timestamp",
+		}, resp.DataStreams[0])
+
+		resp, err = ResolveIndex([]string{gen1})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Indices, 1)
+		assert.Equal(t, name, resp.Indices[0].DataStream)
+	})
+
+	t.Run("delete backing index", func(t *testing.T) {
+		assert.Error(t, DeleteIndex(gen2))
+		assert.NoError(t, DeleteIndex(gen1))
+		stream, ok := ZINC_DATA_STREAM_LIST.Get(name)
+		assert.True(t, ok)
+		assert.Equal(t, []string{gen2}, stream.IndexNames())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, DeleteDataStream(name))
+		_, ok := ZINC_DATA_STREAM_LIST.Get(name)
+		assert.False(t, ok)
+		_, ok = GetIndex(gen2)
+		assert.False(t, ok)
+		assert.Error(t, DeleteDataStream(name))
+	})
+}
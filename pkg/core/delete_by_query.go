@@ -0,0 +1,239 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+)
+
+// DeleteByQueryDefaultScrollSize is the default number of documents of one batch
+const DeleteByQueryDefaultScrollSize = 1000
+
+// DeleteByQuery deletes the documents matched a query from the indexes. The matches are
+// read from a snapshot of the index and deleted in batches, so only one batch of ids is
+// kept in memory. A document deleted since the snapshot is a version conflict, which
+// aborts the delete unless conflicts=proceed.
+type DeleteByQuery struct {
+	indexes    []*Index
+	queries    []bluge.Query
+	scrollSize int
+	maxDocs    int
+	proceed    bool
+	refresh    bool
+
+	start   time.Time
+	end     time.Time
+	status  meta.HTTPResponseDeleteByQuery
+	aborted bool
+	lock    sync.Mutex
+}
+
+// NewDeleteByQuery checks the request and parses its query for every index,
+// the query is restricted by the privileges of the user like the searches
+func NewDeleteByQuery(indexes []*Index, req *meta.DeleteByQueryRequest) (*DeleteByQuery, error) {
+	d := &DeleteByQuery{
+		indexes:    indexes,
+		queries:    make([]bluge.Query, 0, len(indexes)),
+		scrollSize: req.ScrollSize,
+		maxDocs:    req.MaxDocs,
+		refresh:    req.Refresh,
+	}
+	switch strings.ToLower(req.Conflicts) {
+	case "", ReindexConflictsAbort:
+	case ReindexConflictsProceed:
+		d.proceed = true
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[delete_by_query] conflicts must be [abort] or [proceed], but got [%s]", req.Conflicts))
+	}
+	if d.scrollSize <= 0 {
+		d.scrollSize = DeleteByQueryDefaultScrollSize
+	}
+	if d.maxDocs < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[delete_by_query] max_docs should be >= 0, but got [%d]", d.maxDocs))
+	}
+
+	for _, index := range indexes {
+		if err := index.checkOpen(); err != nil {
+			return nil, err
+		}
+		mappings := index.GetMappings()
+		query := *req.Query
+		if err := security.Check(&query, security.FieldRules(query.Privileges, index.GetName()), mappings); err != nil {
+			return nil, err
+		}
+		security.Restrict(&query, security.DocumentFilter(query.Privileges, index.GetName()))
+		q, err := uquery.ParseQuery(&query, mappings, index.GetAnalyzers())
+		if err != nil {
+			return nil, err
+		}
+		d.queries = append(d.queries, q)
+	}
+	d.status.Failures = []string{}
+	d.status.RequestsPerSecond = -1
+	return d, nil
+}
+
+// Run deletes the matches of all the indexes, it returns the final status of the delete
+func (d *DeleteByQuery) Run() (*meta.HTTPResponseDeleteByQuery, error) {
+	d.lock.Lock()
+	d.start = time.Now()
+	d.lock.Unlock()
+	for i, index := range d.indexes {
+		if err := d.deleteIndex(index, d.queries[i]); err != nil {
+			return nil, err
+		}
+	}
+	if d.refresh {
+		for _, index := range d.indexes {
+			if err := index.Flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	d.lock.Lock()
+	d.end = time.Now()
+	d.lock.Unlock()
+	return d.Status(), nil
+}
+
+// Status returns the progress of the delete
+func (d *DeleteByQuery) Status() *meta.HTTPResponseDeleteByQuery {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	status := d.status
+	status.Failures = append([]string{}, d.status.Failures...)
+	switch {
+	case !d.end.IsZero():
+		status.Took = d.end.Sub(d.start).Milliseconds()
+	case !d.start.IsZero():
+		status.Took = time.Since(d.start).Milliseconds()
+	}
+	return &status
+}
+
+// deleteIndex reads the ids of the matches from the readers and deletes them batch by batch
+func (d *DeleteByQuery) deleteIndex(index *Index, query bluge.Query) error {
+	readers, err := index.GetReaders(0, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	batch := make([]deleteByQueryDoc, 0, d.scrollSize)
+	for _, r := range readers {
+		dmi, err := r.Search(context.Background(), bluge.NewAllMatches(query))
+		if err != nil {
+			return err
+		}
+		next, err := dmi.Next()
+		for err == nil && next != nil && !d.done() {
+			var doc deleteByQueryDoc
+			err = next.VisitStoredFields(func(field string, value []byte) bool {
+				switch field {
+				case "_id":
+					doc.id = string(value)
+				case "_routing":
+					doc.routing = string(value)
+				}
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			batch = append(batch, doc)
+			d.lock.Lock()
+			d.status.Total++
+			d.lock.Unlock()
+			if len(batch) >= d.scrollSize {
+				d.deleteBatch(index, batch)
+				batch = batch[:0]
+			}
+			next, err = dmi.Next()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	d.deleteBatch(index, batch)
+	return nil
+}
+
+// done returns whether the delete stops, it is aborted or max_docs documents are deleted
+func (d *DeleteByQuery) done() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.aborted || (d.maxDocs > 0 && d.status.Total >= d.maxDocs)
+}
+
+// deleteByQueryDoc is a matched document, it is deleted with its routing
+type deleteByQueryDoc struct {
+	id      string
+	routing string
+}
+
+// deleteBatch deletes the documents like a bulk request, then it waits for the index
+// to catch up when too many WAL entries are pending
+func (d *DeleteByQuery) deleteBatch(index *Index, docs []deleteByQueryDoc) {
+	if len(docs) == 0 {
+		return
+	}
+	for _, doc := range docs {
+		id := doc.id
+		_, err := index.DeleteDocumentIf(id, RoutingCondition(doc.routing))
+		d.lock.Lock()
+		switch {
+		case err == nil:
+			d.status.Deleted++
+		case err == errors.ErrorIDNotFound:
+			d.status.VersionConflicts++
+			if !d.proceed {
+				d.status.Failures = append(d.status.Failures, id)
+				d.aborted = true
+			}
+		default:
+			d.status.Failures = append(d.status.Failures, id)
+		}
+		aborted := d.aborted
+		d.lock.Unlock()
+		if aborted {
+			break
+		}
+	}
+	d.lock.Lock()
+	d.status.Batches++
+	d.lock.Unlock()
+
+	if !index.WaitForWALPending(uint64(config.Global.BulkMaxPending), config.Global.BulkMaxPendingWait) {
+		log.Warn().Str("index", index.GetName()).Msg("delete by query backpressure timeout")
+	}
+}
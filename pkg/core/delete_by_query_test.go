@@ -0,0 +1,138 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestDeleteByQuery(t *testing.T) {
+	indexName := "TestDeleteByQuery.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 30; i++ {
+			doc := map[string]interface{}{"name": "doc", "group": "a"}
+			if i >= 25 {
+				doc["group"] = "b"
+			}
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), doc, false))
+		}
+		assert.NoError(t, index.Flush())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{Query: &meta.ZincQuery{}, Conflicts: "ignore"})
+		assert.Error(t, err)
+		_, err = NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{Query: &meta.ZincQuery{}, MaxDocs: -1})
+		assert.Error(t, err)
+		_, err = NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{
+			Query: &meta.ZincQuery{Query: map[string]interface{}{"unknown": map[string]interface{}{}}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("max_docs", func(t *testing.T) {
+		d, err := NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{
+			Query:      &meta.ZincQuery{Query: map[string]interface{}{"term": map[string]interface{}{"group": "a"}}},
+			ScrollSize: 2,
+			MaxDocs:    5,
+			Refresh:    true,
+		})
+		assert.NoError(t, err)
+		resp, err := d.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 5, resp.Total)
+		assert.Equal(t, 5, resp.Deleted)
+		assert.Equal(t, 3, resp.Batches)
+		assert.Empty(t, resp.Failures)
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		d, err := NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{
+			Query:      &meta.ZincQuery{Query: map[string]interface{}{"term": map[string]interface{}{"group": "a"}}},
+			ScrollSize: 10,
+			Refresh:    true,
+		})
+		assert.NoError(t, err)
+		resp, err := d.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 20, resp.Total)
+		assert.Equal(t, 20, resp.Deleted)
+		assert.Equal(t, 2, resp.Batches)
+		assert.Equal(t, 0, resp.VersionConflicts)
+
+		// refresh makes the deletes visible to the searches at once
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match_all": map[string]interface{}{}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, got.Hits.Total.Value)
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		// the documents deleted since the snapshot are version conflicts
+		d, err := NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{Query: &meta.ZincQuery{}})
+		assert.NoError(t, err)
+		d.deleteBatch(index, []deleteByQueryDoc{{id: "deleted_1"}, {id: "deleted_2"}})
+		resp := d.Status()
+		assert.Equal(t, 1, resp.VersionConflicts)
+		assert.Equal(t, []string{"deleted_1"}, resp.Failures)
+		assert.True(t, d.done())
+
+		d, err = NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{Query: &meta.ZincQuery{}, Conflicts: "proceed"})
+		assert.NoError(t, err)
+		d.deleteBatch(index, []deleteByQueryDoc{{id: "deleted_1"}, {id: "deleted_2"}})
+		resp = d.Status()
+		assert.Equal(t, 2, resp.VersionConflicts)
+		assert.Empty(t, resp.Failures)
+		assert.False(t, d.done())
+	})
+
+	t.Run("task", func(t *testing.T) {
+		d, err := NewDeleteByQuery([]*Index{index}, &meta.DeleteByQueryRequest{
+			Query: &meta.ZincQuery{Query: map[string]interface{}{"term": map[string]interface{}{"group": "b"}}},
+		})
+		assert.NoError(t, err)
+		task := ZINC_TASKS.Submit("", TaskActionDeleteByQuery, func() interface{} {
+			return d.Status()
+		}, func() (interface{}, error) {
+			return d.Run()
+		})
+		got, ok := ZINC_TASKS.Get(task.ID)
+		assert.True(t, ok)
+		assert.True(t, got.Wait(10*time.Second))
+		resp := got.Response()
+		assert.True(t, resp.Completed)
+		assert.Equal(t, TaskActionDeleteByQuery, resp.Task.Action)
+		assert.Equal(t, 5, resp.Response.(*meta.HTTPResponseDeleteByQuery).Deleted)
+		assert.Equal(t, 5, resp.Task.Status.(*meta.HTTPResponseDeleteByQuery).Deleted)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
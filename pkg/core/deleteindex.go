@@ -32,6 +32,13 @@ func DeleteIndex(name string) error {
 		return errors.New("index " + name + " does not exists")
 	}
 
+	// a backing index leaves its data stream, the write index can't be deleted
+	if stream := index.GetDataStream(); stream != "" {
+		if err := ZINC_DATA_STREAM_LIST.removeIndex(stream, name); err != nil {
+			return err
+		}
+	}
+
 	// 2. Close and Delete from cache
 	ZINC_INDEX_LIST.Delete(name)
 
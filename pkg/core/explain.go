@@ -0,0 +1,93 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+)
+
+// Explain computes the score explanation of the document for the query
+func (index *Index) Explain(docID string, query *meta.ZincQuery) (*meta.ExplainResponse, error) {
+	if err := security.Check(query, security.FieldRules(query.Privileges, index.GetName()), index.GetMappings()); err != nil {
+		return nil, err
+	}
+	security.Restrict(query, security.DocumentFilter(query.Privileges, index.GetName()))
+	request, err := uquery.ParseExplainQuery(query, docID, index.GetMappings(), index.GetAnalyzers())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := index.checkOpen(); err != nil {
+		return nil, err
+	}
+	shard := index.GetShardByDocID(docID)
+	if err := shard.OpenWAL(); err != nil {
+		return nil, err
+	}
+	if _, err := shard.FindShardByDocID(docID); err != nil {
+		return nil, err
+	}
+
+	readers, err := shard.GetReaders(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	dmi, err := bluge.MultiSearch(context.Background(), request, readers...)
+	if err != nil {
+		return nil, err
+	}
+	resp := &meta.ExplainResponse{Index: index.GetName(), ID: docID}
+	next, err := dmi.Next()
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		resp.Matched = true
+		resp.Explanation = explanation(next.Explanation, next.Score)
+	}
+	return resp, nil
+}
+
+// explanation converts the bluge explanation tree to the response format
+func explanation(e *search.Explanation, score float64) *meta.Explanation {
+	if e == nil {
+		return &meta.Explanation{Value: score, Description: "score", Details: []*meta.Explanation{}}
+	}
+	resp := &meta.Explanation{
+		Value:       e.Value,
+		Description: e.Message,
+		Details:     make([]*meta.Explanation, 0, len(e.Children)),
+	}
+	for _, child := range e.Children {
+		if child != nil {
+			resp.Details = append(resp.Details, explanation(child, child.Value))
+		}
+	}
+	return resp
+}
@@ -0,0 +1,107 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_Explain(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Explain.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := map[string]map[string]interface{}{
+			"1": {"title": "zinc search engine", "views": float64(10)},
+			"2": {"title": "zinc", "views": float64(20)},
+			"3": {"title": "elastic search", "views": float64(30)},
+		}
+		for id, doc := range docs {
+			err = index.CreateDocument(id, doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("matched", func(t *testing.T) {
+		got, err := index.Explain("1", &meta.ZincQuery{
+			Query: map[string]interface{}{"match": map[string]interface{}{"title": "zinc search"}},
+		})
+		assert.NoError(t, err)
+		assert.True(t, got.Matched)
+		assert.Equal(t, "1", got.ID)
+		assert.NotNil(t, got.Explanation)
+		assert.Greater(t, got.Explanation.Value, 0.0)
+		assert.NotEmpty(t, got.Explanation.Description)
+		assert.NotEmpty(t, got.Explanation.Details)
+
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match": map[string]interface{}{"title": "zinc search"}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		for _, hit := range resp.Hits.Hits {
+			if hit.ID == "1" {
+				assert.InDelta(t, hit.Score, got.Explanation.Value, 0.0001)
+			}
+		}
+	})
+
+	t.Run("not matched", func(t *testing.T) {
+		got, err := index.Explain("3", &meta.ZincQuery{
+			Query: map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}},
+		})
+		assert.NoError(t, err)
+		assert.False(t, got.Matched)
+		assert.Nil(t, got.Explanation)
+	})
+
+	t.Run("function score", func(t *testing.T) {
+		got, err := index.Explain("2", &meta.ZincQuery{
+			Query: map[string]interface{}{"function_score": map[string]interface{}{
+				"field_value_factor": map[string]interface{}{"field": "views"},
+			}},
+		})
+		assert.NoError(t, err)
+		assert.True(t, got.Matched)
+		assert.InDelta(t, 20.0, got.Explanation.Value, 0.0001)
+	})
+
+	t.Run("document not found", func(t *testing.T) {
+		_, err := index.Explain("4", &meta.ZincQuery{
+			Query: map[string]interface{}{"match_all": map[string]interface{}{}},
+		})
+		assert.ErrorIs(t, err, errors.ErrorIDNotFound)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"sort"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+)
+
+// fieldCapsMetadata are the metadata fields of every index: type, searchable, aggregatable
+var fieldCapsMetadata = map[string][2]bool{
+	"_id":     {true, false},
+	"_index":  {true, true},
+	"_source": {false, false},
+}
+
+// FieldCaps returns the capabilities of the fields matching any of the patterns in the indexes, no patterns match all fields.
+// A field mapped to different types in the indexes has an entry per type, the indexes where the field is not searchable
+// or aggregatable are listed when they differ from the other indexes
+func FieldCaps(indexes []*Index, patterns []string) *meta.FieldCapsResponse {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].GetName() < indexes[j].GetName()
+	})
+
+	resp := &meta.FieldCapsResponse{
+		Indices: make([]string, 0, len(indexes)),
+		Fields:  make(map[string]map[string]*meta.FieldCaps),
+	}
+	add := func(indexName, field, typ string, metadata, searchable, aggregatable bool) {
+		matched := false
+		for _, pattern := range patterns {
+			if source.MatchPattern(pattern, field) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return
+		}
+		types, ok := resp.Fields[field]
+		if !ok {
+			types = make(map[string]*meta.FieldCaps)
+			resp.Fields[field] = types
+		}
+		caps, ok := types[typ]
+		if !ok {
+			caps = &meta.FieldCaps{Type: typ, MetadataField: metadata}
+			types[typ] = caps
+		}
+		caps.Indices = append(caps.Indices, indexName)
+		if !searchable {
+			caps.NonSearchableIndices = append(caps.NonSearchableIndices, indexName)
+		}
+		if !aggregatable {
+			caps.NonAggregatableIndices = append(caps.NonAggregatableIndices, indexName)
+		}
+	}
+
+	for _, index := range indexes {
+		name := index.GetName()
+		resp.Indices = append(resp.Indices, name)
+		for field, caps := range fieldCapsMetadata {
+			add(name, field, field, true, caps[0], caps[1])
+		}
+		mappings := index.GetMappings()
+		for field, prop := range mappings.ListProperty() {
+			add(name, field, fieldCapsType(prop.Type), false, prop.Index && prop.Type != "nested", prop.Aggregatable)
+		}
+		for field, runtime := range mappings.ListRuntime() {
+			add(name, field, runtime.Type, false, true, true)
+		}
+	}
+
+	for _, types := range resp.Fields {
+		for _, caps := range types {
+			caps.Searchable = len(caps.NonSearchableIndices) == 0
+			if len(caps.NonSearchableIndices) == len(caps.Indices) {
+				caps.NonSearchableIndices = nil
+			}
+			caps.Aggregatable = len(caps.NonAggregatableIndices) == 0
+			if len(caps.NonAggregatableIndices) == len(caps.Indices) {
+				caps.NonAggregatableIndices = nil
+			}
+		}
+	}
+
+	return resp
+}
+
+// fieldCapsType returns the ES type of the zinc field type
+func fieldCapsType(typ string) string {
+	switch typ {
+	case "numeric":
+		return "double"
+	case "bool":
+		return "boolean"
+	case "time":
+		return "date"
+	default:
+		return typ
+	}
+}
@@ -0,0 +1,100 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestFieldCaps(t *testing.T) {
+	var err error
+	var index1, index2 *Index
+	t.Run("prepare", func(t *testing.T) {
+		index1, err = NewIndex("TestFieldCaps.index_1", "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("name", meta.NewProperty("text"))
+		mappings.SetProperty("status", meta.NewProperty("keyword"))
+		mappings.SetProperty("count", meta.NewProperty("numeric"))
+		mappings.SetRuntime("day", meta.RuntimeField{Type: "keyword"})
+		assert.NoError(t, index1.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index1))
+
+		index2, err = NewIndex("TestFieldCaps.index_2", "disk", 1)
+		assert.NoError(t, err)
+		mappings = meta.NewMappings()
+		mappings.SetProperty("name", meta.NewProperty("text"))
+		status := meta.NewProperty("keyword")
+		status.Index = false
+		mappings.SetProperty("status", status)
+		mappings.SetProperty("count", meta.NewProperty("keyword"))
+		assert.NoError(t, index2.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index2))
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		resp := FieldCaps([]*Index{index2, index1}, nil)
+		assert.Equal(t, []string{"TestFieldCaps.index_1", "TestFieldCaps.index_2"}, resp.Indices)
+		assert.Equal(t, &meta.FieldCaps{
+			Type:          "_id",
+			MetadataField: true,
+			Searchable:    true,
+			Indices:       []string{"TestFieldCaps.index_1", "TestFieldCaps.index_2"},
+		}, resp.Fields["_id"]["_id"])
+		assert.Equal(t, &meta.FieldCaps{
+			Type:       "text",
+			Searchable: true,
+			Indices:    []string{"TestFieldCaps.index_1", "TestFieldCaps.index_2"},
+		}, resp.Fields["name"]["text"])
+		assert.Equal(t, &meta.FieldCaps{
+			Type:                 "keyword",
+			Searchable:           false,
+			Aggregatable:         true,
+			Indices:              []string{"TestFieldCaps.index_1", "TestFieldCaps.index_2"},
+			NonSearchableIndices: []string{"TestFieldCaps.index_2"},
+		}, resp.Fields["status"]["keyword"])
+		assert.Equal(t, &meta.FieldCaps{
+			Type:         "keyword",
+			Searchable:   true,
+			Aggregatable: true,
+			Indices:      []string{"TestFieldCaps.index_1"},
+		}, resp.Fields["day"]["keyword"])
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		resp := FieldCaps([]*Index{index1, index2}, []string{"count"})
+		assert.Len(t, resp.Fields, 1)
+		assert.Len(t, resp.Fields["count"], 2)
+		assert.Equal(t, []string{"TestFieldCaps.index_1"}, resp.Fields["count"]["double"].Indices)
+		assert.Equal(t, []string{"TestFieldCaps.index_2"}, resp.Fields["count"]["keyword"].Indices)
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		resp := FieldCaps([]*Index{index1}, []string{"na*", "_index"})
+		assert.Len(t, resp.Fields, 2)
+		assert.Contains(t, resp.Fields, "name")
+		assert.Contains(t, resp.Fields, "_index")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex("TestFieldCaps.index_1"))
+		assert.NoError(t, DeleteIndex("TestFieldCaps.index_2"))
+	})
+}
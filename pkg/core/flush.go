@@ -0,0 +1,153 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+)
+
+// Refresh policies of the writes
+const (
+	RefreshFalse   = "false"    // the documents are visible after the next scheduled WAL consuming
+	RefreshTrue    = "true"     // the WAL is flushed into the index after the write
+	RefreshWaitFor = "wait_for" // the write waits for the next scheduled WAL consuming
+)
+
+// ParseRefresh returns the refresh policy of the refresh parameter, the parameter without a value is true
+func ParseRefresh(v string, exists bool) (string, error) {
+	if !exists {
+		return RefreshFalse, nil
+	}
+	switch v {
+	case "", RefreshTrue:
+		return RefreshTrue, nil
+	case RefreshFalse, RefreshWaitFor:
+		return v, nil
+	default:
+		return "", errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("unknown value for refresh: [%s]", v))
+	}
+}
+
+// Refresh makes the documents written before it visible to the searches by the refresh policy,
+// true flushes the WAL and wait_for blocks until the WAL consumer wrote them or ctx is done.
+// It returns true when the index is flushed.
+func (index *Index) Refresh(ctx context.Context, policy string) (bool, error) {
+	switch policy {
+	case RefreshTrue:
+		return true, index.Flush()
+	case RefreshWaitFor:
+		// nothing would make the documents visible while the scheduled refresh is disabled
+		if index.GetRefreshInterval() < 0 {
+			return true, index.Flush()
+		}
+		for _, shard := range index.shards {
+			if err := shard.waitForWAL(ctx); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// waitForWAL blocks until the WAL entries written before it are written into the index
+func (s *IndexShard) waitForWAL(ctx context.Context) error {
+	// the WAL is opened by the first write, nothing to wait for before it
+	if atomic.LoadUint64(&s.open) == 0 {
+		return nil
+	}
+	target, done, err := s.walCommitted(0)
+	if err != nil || done {
+		return err
+	}
+	ticker := time.NewTicker(config.Global.WalSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if _, done, err = s.walCommitted(target); err != nil || done {
+			return err
+		}
+	}
+}
+
+// walCommitted returns the last WAL entry and whether the entries up to target are written into the index,
+// target 0 is the last entry. A closed shard has nothing to wait for.
+func (s *IndexShard) walCommitted(target uint64) (uint64, bool, error) {
+	s.consume.Lock()
+	defer s.consume.Unlock()
+	if s.wal == nil {
+		return 0, true, nil
+	}
+	if target == 0 {
+		var err error
+		if target, err = s.wal.LastIndex(); err != nil {
+			return 0, false, err
+		}
+	}
+	_, committed, err := s.readRedoLog(RedoActionWrite)
+	if err != nil && err.Error() != errors.ErrNotFound.Error() {
+		return 0, false, err
+	}
+	return target, committed >= target, nil
+}
+
+// Flush writes all the documents waiting in the WAL into the index.
+// A batch is persisted by the writer before it returns, so the documents
+// written before Flush are durable in the index when it returns.
+func (index *Index) Flush() error {
+	for _, shard := range index.shards {
+		if err := shard.Flush(); err != nil {
+			return err
+		}
+	}
+	return index.UpdateMetadata()
+}
+
+// Flush consumes the WAL of the shard until no entries left
+func (s *IndexShard) Flush() error {
+	// the WAL is opened by the first write, nothing to flush before it
+	if atomic.LoadUint64(&s.open) == 0 {
+		return nil
+	}
+	for s.ConsumeWAL() {
+	}
+
+	s.consume.Lock()
+	defer s.consume.Unlock()
+	if s.wal == nil {
+		return nil
+	}
+	maxID, err := s.wal.LastIndex()
+	if err != nil {
+		return err
+	}
+	_, minID, err := s.readRedoLog(RedoActionWrite)
+	if err != nil && err.Error() != errors.ErrNotFound.Error() {
+		return err
+	}
+	if minID != maxID {
+		return errors.New(errors.ErrorTypeRuntimeException, fmt.Sprintf("[flush] shard [%s] of index [%s] has %d documents not written", s.GetID(), s.GetIndexName(), maxID-minID))
+	}
+	return nil
+}
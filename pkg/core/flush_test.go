@@ -0,0 +1,132 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_Flush(t *testing.T) {
+	indexName := "TestIndex_Flush.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		// flush an index without any writes
+		assert.NoError(t, index.Flush())
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, index.Flush())
+		// documents are searchable without waiting for the WAL consumer
+		got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100})
+		assert.NoError(t, err)
+		assert.Equal(t, 20, got.Hits.Total.Value)
+		assert.Equal(t, uint64(20), index.GetStats().DocNum)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
+func TestIndex_Refresh(t *testing.T) {
+	indexName := "TestIndex_Refresh.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		// refresh an index without any writes
+		_, err = index.Refresh(context.Background(), RefreshWaitFor)
+		assert.NoError(t, err)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		for _, tt := range []struct {
+			value  string
+			exists bool
+			want   string
+		}{
+			{"", false, RefreshFalse},
+			{"", true, RefreshTrue},
+			{"true", true, RefreshTrue},
+			{"false", true, RefreshFalse},
+			{"wait_for", true, RefreshWaitFor},
+		} {
+			got, err := ParseRefresh(tt.value, tt.exists)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		}
+		_, err := ParseRefresh("now", true)
+		assert.Error(t, err)
+	})
+
+	count := func() int {
+		got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100})
+		assert.NoError(t, err)
+		return got.Hits.Total.Value
+	}
+
+	t.Run("true", func(t *testing.T) {
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "doc1"}, false))
+		forced, err := index.Refresh(context.Background(), RefreshTrue)
+		assert.NoError(t, err)
+		assert.True(t, forced)
+		assert.Equal(t, 1, count())
+	})
+
+	t.Run("wait_for", func(t *testing.T) {
+		for i := 2; i <= 10; i++ {
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false))
+		}
+		forced, err := index.Refresh(context.Background(), RefreshWaitFor)
+		assert.NoError(t, err)
+		assert.False(t, forced)
+		assert.Equal(t, 10, count())
+	})
+
+	t.Run("wait_for cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		shard := index.GetShardByDocID("11")
+		// the consumer can't write the document while the WAL is locked
+		shard.consume.Lock()
+		assert.NoError(t, index.CreateDocument("11", map[string]interface{}{"name": "doc11"}, false))
+		shard.consume.Unlock()
+		err := shard.waitForWAL(ctx)
+		if err != nil {
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
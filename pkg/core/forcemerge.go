@@ -0,0 +1,148 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/index/mergeplan"
+
+	"github.com/zincsearch/zincsearch/pkg/bluge/directory"
+	zincsearch "github.com/zincsearch/zincsearch/pkg/bluge/search"
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+)
+
+var (
+	// forceMergeLimiter limits how many second layer shards merging at the same time
+	forceMergeLimiter chan struct{}
+	// forceMergeCheckInterval is the interval to check the merge progress
+	forceMergeCheckInterval = 100 * time.Millisecond
+	// forceMergeStallTimeout gives up waiting when the segments stop decreasing
+	forceMergeStallTimeout = 10 * time.Second
+)
+
+func init() {
+	n := config.Global.Shard.MergeGoroutineNum
+	if n <= 0 {
+		n = 1
+	}
+	forceMergeLimiter = make(chan struct{}, n)
+}
+
+// ForceMerge merges the segments of every second layer shard of the index
+// down to maxNumSegments. The index keeps serving during the merge, only
+// a limited number of shards merge at the same time.
+func (index *Index) ForceMerge(maxNumSegments int) error {
+	if maxNumSegments <= 0 {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[forcemerge] max_num_segments must be a positive number, but got [%d]", maxNumSegments))
+	}
+	if err := index.checkOpen(); err != nil {
+		return err
+	}
+	for _, shard := range index.shards {
+		for i := int64(0); i < shard.GetShardNum(); i++ {
+			if err := shard.forceMerge(i, maxNumSegments); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forceMerge reopens the second layer shard with a merge plan which allows
+// only maxNumSegments segments, waits for the merger to catch up, then
+// closes it to be reopened with the default merge plan by next access.
+func (s *IndexShard) forceMerge(shardID int64, maxNumSegments int) error {
+	forceMergeLimiter <- struct{}{}
+	defer func() { <-forceMergeLimiter }()
+
+	s.lock.RLock()
+	secondShard := s.shards[shardID]
+	s.lock.RUnlock()
+
+	secondShard.lock.Lock()
+	if secondShard.writer != nil {
+		if err := secondShard.writer.Close(); err != nil {
+			secondShard.lock.Unlock()
+			return err
+		}
+		secondShard.writer = nil
+	}
+	w, err := s.openForceMergeWriter(shardID, maxNumSegments)
+	if err != nil {
+		secondShard.lock.Unlock()
+		return err
+	}
+	secondShard.writer = w
+	secondShard.lock.Unlock()
+
+	lastSegments, err := forceMergeSegments(w)
+	lastProgress := time.Now()
+	for err == nil && lastSegments > maxNumSegments && time.Since(lastProgress) < forceMergeStallTimeout {
+		time.Sleep(forceMergeCheckInterval)
+		var n int
+		if n, err = forceMergeSegments(w); err == nil && n < lastSegments {
+			lastSegments = n
+			lastProgress = time.Now()
+		}
+	}
+
+	secondShard.lock.Lock()
+	defer secondShard.lock.Unlock()
+	if secondShard.writer != w {
+		return err
+	}
+	secondShard.writer = nil
+	if cerr := w.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (s *IndexShard) openForceMergeWriter(shardID int64, maxNumSegments int) (*bluge.Writer, error) {
+	name := fmt.Sprintf("%s/%s/%06x", s.GetIndexName(), s.GetID(), shardID)
+	cfg := directory.GetDiskIndexConfig(config.Global.DataPath, name)
+	cfg.MergePlanOptions = forceMergePlanOptions(maxNumSegments)
+	blugeConfig := bluge.DefaultConfigWithIndexConfig(cfg)
+	if analyzers := s.root.GetAnalyzers(); analyzers != nil && analyzers["default"] != nil {
+		blugeConfig.DefaultSearchAnalyzer = analyzers["default"]
+	}
+	return bluge.OpenWriter(blugeConfig)
+}
+
+// forceMergePlanOptions makes every segment eligible to merge,
+// and keeps merging until there are at most maxNumSegments segments
+func forceMergePlanOptions(maxNumSegments int) mergeplan.Options {
+	options := mergeplan.DefaultMergePlanOptions
+	options.MaxSegmentSize = mergeplan.MaxSegmentSizeLimit
+	options.CalcBudget = func(totalSize int64, firstTierSize int64, o *mergeplan.Options) int {
+		return maxNumSegments
+	}
+	return options
+}
+
+// forceMergeSegments counts the segments of a reader snapshot of the writer,
+// the status of the writer is updated by the merger without synchronization
+func forceMergeSegments(w *bluge.Writer) (int, error) {
+	r, err := w.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return zincsearch.Segments(r)
+}
@@ -0,0 +1,72 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_ForceMerge(t *testing.T) {
+	indexName := "TestIndex_ForceMerge.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		// a writer never merges, so every WAL batch leaves a new segment
+		shard := index.GetShardByDocID("0")
+		w, err := shard.openForceMergeWriter(0, 100)
+		assert.NoError(t, err)
+		shard.shards[0].writer = w
+		// every flush consumes the WAL of one document, whatever the WAL sync interval is
+		for i := 0; i < 10; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+			assert.NoError(t, index.Flush())
+		}
+		assert.Eventually(t, func() bool {
+			return index.Stats("segments").Segments.Count > 2
+		}, time.Second*10, time.Millisecond*10)
+	})
+
+	t.Run("force merge", func(t *testing.T) {
+		assert.Error(t, index.ForceMerge(0))
+		assert.NoError(t, index.ForceMerge(2))
+		// the merge writer is closed, reopen it to count the segments
+		_, err := index.GetShardByDocID("0").GetWriter(0)
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return index.Stats("segments").Segments.Count <= 2
+		}, time.Second*10, time.Millisecond*10)
+
+		// the index keeps working after merge
+		assert.Eventually(t, func() bool {
+			got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100})
+			return err == nil && got.Hits.Total.Value == 10
+		}, time.Second*10, time.Millisecond*10)
+		assert.NoError(t, index.CreateDocument("10", map[string]interface{}{"name": "doc10"}, false))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
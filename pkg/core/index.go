@@ -18,11 +18,13 @@ package core
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
 	"github.com/zincsearch/zincsearch/pkg/zutils/hash/rendezvous"
@@ -35,6 +37,9 @@ type Index struct {
 	shards       map[string]*IndexShard
 	shardNum     int64
 	shardHashing *rendezvous.Rendezvous
+	counters     indexCounters
+	closed       atomic.Bool
+	writing      sync.RWMutex // held by the writes, CloseIndex waits for the writes in flight
 	lock         sync.RWMutex
 }
 
@@ -104,7 +109,12 @@ func (index *Index) GetAnalyzers() map[string]*analysis.Analyzer {
 }
 
 func (index *Index) UseTemplate() error {
-	template, err := UseTemplate(index.GetName())
+	return index.useTemplate(index.GetName())
+}
+
+// useTemplate applies the template matching the name, the backing indexes use the template of their data stream
+func (index *Index) useTemplate(name string) error {
+	template, err := UseTemplate(name)
 	if err != nil {
 		return err
 	}
@@ -180,6 +190,22 @@ func (index *Index) SetSettings(settings *meta.IndexSettings) error {
 			}
 		}
 	}
+	if settings.RefreshInterval != "" {
+		index.ref.Settings.RefreshInterval = settings.RefreshInterval
+	}
+	if settings.Indexing != nil {
+		indexing := *settings.Indexing
+		index.ref.Settings.Indexing = &indexing
+	}
+	if settings.Lifecycle != nil {
+		lifecycle := *settings.Lifecycle
+		index.ref.Settings.Lifecycle = &lifecycle
+	}
+	if settings.Search != nil && settings.Search.SlowLog != nil {
+		index.ref.Settings.Search = &meta.IndexSearch{
+			SlowLog: &meta.IndexSearchSlowLog{Threshold: settings.Search.SlowLog.Threshold},
+		}
+	}
 	index.lock.Unlock()
 
 	return nil
@@ -231,6 +257,15 @@ func (index *Index) SetMappings(mappings *meta.Mappings) error {
 	for field, prop := range mappings.ListProperty() {
 		index.ref.Mappings.SetProperty(field, prop)
 	}
+	for field, runtime := range mappings.ListRuntime() {
+		index.ref.Mappings.SetRuntime(field, runtime)
+	}
+	for _, tpl := range mappings.ListDynamicTemplates() {
+		index.ref.Mappings.SetDynamicTemplate(tpl)
+	}
+	if source := mappings.GetSource(); source != nil {
+		index.ref.Mappings.SetSource(source)
+	}
 	index.lock.Unlock()
 
 	return nil
@@ -248,10 +283,45 @@ func (index *Index) GetWALSize() uint64 {
 	return size
 }
 
+// GetWALPending returns the number of WAL entries not written to the index yet
+func (index *Index) GetWALPending() uint64 {
+	var n uint64
+	for _, shard := range index.shards {
+		n += shard.GetWALPending()
+	}
+	return n
+}
+
+// WaitForWALPending blocks while the WAL entries not written to the index exceed the limit,
+// it gives up after the timeout so a stuck consumer doesn't block the writers forever.
+// It doesn't wait when the refresh of the index is disabled, the WAL is consumed by the next
+// explicit refresh only, e.g. the bulk loads with refresh_interval -1.
+func (index *Index) WaitForWALPending(limit uint64, timeout time.Duration) bool {
+	if index.GetRefreshInterval() < 0 {
+		return true
+	}
+	deadline := time.Now().Add(timeout)
+	for index.GetWALPending() > limit {
+		if index.checkOpen() != nil || time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(config.Global.WalSyncInterval)
+	}
+	return true
+}
+
 // GetReaders return all shard readers
 func (index *Index) GetReaders(timeMin, timeMax int64) ([]*bluge.Reader, error) {
+	return index.GetReadersByRouting(timeMin, timeMax, nil)
+}
+
+// GetReadersByRouting return the readers of the shards of the routing values, all shard readers without routing
+func (index *Index) GetReadersByRouting(timeMin, timeMax int64, routing []string) ([]*bluge.Reader, error) {
+	if err := index.checkOpen(); err != nil {
+		return nil, err
+	}
 	readers := make([]*bluge.Reader, 0)
-	for _, shard := range index.shards {
+	for _, shard := range index.GetShardsByRouting(routing) {
 		rs, err := shard.GetReaders(timeMin, timeMax)
 		if err != nil {
 			return nil, err
@@ -348,6 +418,7 @@ func (index *Index) Reopen() error {
 }
 
 func (index *Index) Close() error {
+	ZINC_PREFERENCE_SNAPSHOTS.Invalidate(index.GetName())
 	eg := errgroup.Group{}
 	for _, shard := range index.shards {
 		shard := shard
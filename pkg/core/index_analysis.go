@@ -0,0 +1,119 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// UpdateAnalysis adds the new analyzers, char filters, tokenizers and token filters to the analysis of the index,
+// they apply to the documents indexed afterwards. The existing ones can't be changed, the indexed documents would
+// need reindexing, the same definition again is accepted
+func (index *Index) UpdateAnalysis(analysis *meta.IndexAnalysis) error {
+	if analysis == nil {
+		return nil
+	}
+	if analysis.TokenFilter == nil && analysis.Filter != nil {
+		analysis.TokenFilter = analysis.Filter
+		analysis.Filter = nil
+	}
+
+	existing := new(meta.IndexAnalysis)
+	if settings := index.GetSettings(); settings != nil && settings.Analysis != nil {
+		existing = settings.Analysis
+	}
+	merged := &meta.IndexAnalysis{
+		Analyzer: make(map[string]*meta.Analyzer, len(existing.Analyzer)+len(analysis.Analyzer)),
+	}
+	for name, v := range existing.Analyzer {
+		merged.Analyzer[name] = v
+	}
+	for name, v := range analysis.Analyzer {
+		if old, ok := existing.Analyzer[name]; ok && !sameAnalysis(normalizeAnalyzer(old), normalizeAnalyzer(v)) {
+			return fmt.Errorf("can't update the existing analyzer [%s] of index [%s], it requires reindexing", name, index.GetName())
+		}
+		merged.Analyzer[name] = v
+	}
+	var err error
+	if merged.CharFilter, err = mergeAnalysis(index.GetName(), "char_filter", existing.CharFilter, analysis.CharFilter); err != nil {
+		return err
+	}
+	if merged.Tokenizer, err = mergeAnalysis(index.GetName(), "tokenizer", existing.Tokenizer, analysis.Tokenizer); err != nil {
+		return err
+	}
+	existingFilters := existing.TokenFilter
+	if existingFilters == nil {
+		existingFilters = existing.Filter
+	}
+	if merged.TokenFilter, err = mergeAnalysis(index.GetName(), "token_filter", existingFilters, analysis.TokenFilter); err != nil {
+		return err
+	}
+
+	// the new analyzers can use the existing filters and tokenizers
+	analyzers, err := zincanalysis.RequestAnalyzer(merged)
+	if err != nil {
+		return err
+	}
+	_ = index.SetSettings(&meta.IndexSettings{Analysis: merged})
+	return index.SetAnalyzers(analyzers)
+}
+
+// mergeAnalysis adds the updates to the existing definitions of the kind, the existing ones can't be changed
+func mergeAnalysis(indexName, kind string, existing, updates map[string]interface{}) (map[string]interface{}, error) {
+	if len(existing) == 0 && len(updates) == 0 {
+		return nil, nil
+	}
+	merged := make(map[string]interface{}, len(existing)+len(updates))
+	for name, v := range existing {
+		merged[name] = v
+	}
+	for name, v := range updates {
+		if old, ok := existing[name]; ok && !sameAnalysis(old, v) {
+			return nil, fmt.Errorf("can't update the existing %s [%s] of index [%s], it requires reindexing", kind, name, indexName)
+		}
+		merged[name] = v
+	}
+	return merged, nil
+}
+
+// normalizeAnalyzer returns the analyzer as it's used, filter is an alias of token_filter
+func normalizeAnalyzer(v *meta.Analyzer) meta.Analyzer {
+	a := *v
+	if a.TokenFilter == nil && a.Filter != nil {
+		a.TokenFilter = a.Filter
+		a.Filter = nil
+	}
+	a.Type = strings.ToLower(a.Type)
+	return a
+}
+
+func sameAnalysis(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
@@ -0,0 +1,182 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+)
+
+func TestIndex_UpdateAnalysis(t *testing.T) {
+	indexName := "TestIndex_UpdateAnalysis.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		settings := &meta.IndexSettings{Analysis: &meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"my_analyzer": {Tokenizer: "standard", Filter: []string{"lowercase"}},
+			},
+		}}
+		analyzers, err := zincanalysis.RequestAnalyzer(settings.Analysis)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetSettings(settings))
+		assert.NoError(t, index.SetAnalyzers(analyzers))
+		assert.NoError(t, StoreIndex(index))
+	})
+
+	t.Run("add analyzer and filter", func(t *testing.T) {
+		err := index.UpdateAnalysis(&meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"my_analyzer":   {Tokenizer: "standard", Filter: []string{"lowercase"}},
+				"stop_analyzer": {Tokenizer: "standard", Filter: []string{"lowercase", "my_stop"}},
+			},
+			Filter: map[string]interface{}{
+				"my_stop": map[string]interface{}{"type": "stop", "stopwords": []interface{}{"the"}},
+			},
+		})
+		assert.NoError(t, err)
+		analyzers := index.GetAnalyzers()
+		assert.Contains(t, analyzers, "my_analyzer")
+		assert.Contains(t, analyzers, "stop_analyzer")
+		tokens := analyzers["stop_analyzer"].Analyze([]byte("The Zinc"))
+		assert.Len(t, tokens, 1)
+		assert.Equal(t, "zinc", string(tokens[0].Term))
+		assert.Contains(t, index.GetSettings().Analysis.TokenFilter, "my_stop")
+	})
+
+	t.Run("new analyzer uses the existing filter", func(t *testing.T) {
+		err := index.UpdateAnalysis(&meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"keyword_stop": {Tokenizer: "whitespace", Filter: []string{"my_stop"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, index.GetAnalyzers(), "keyword_stop")
+		assert.Contains(t, index.GetAnalyzers(), "stop_analyzer")
+	})
+
+	t.Run("change existing analyzer", func(t *testing.T) {
+		err := index.UpdateAnalysis(&meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"my_analyzer": {Tokenizer: "whitespace"},
+			},
+		})
+		assert.ErrorContains(t, err, "can't update the existing analyzer [my_analyzer]")
+	})
+
+	t.Run("change existing filter", func(t *testing.T) {
+		err := index.UpdateAnalysis(&meta.IndexAnalysis{
+			TokenFilter: map[string]interface{}{
+				"my_stop": map[string]interface{}{"type": "stop", "stopwords": []interface{}{"a"}},
+			},
+		})
+		assert.ErrorContains(t, err, "can't update the existing token_filter [my_stop]")
+	})
+
+	t.Run("invalid analyzer", func(t *testing.T) {
+		err := index.UpdateAnalysis(&meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"broken": {Tokenizer: "xxx"},
+			},
+		})
+		assert.Error(t, err)
+		assert.NotContains(t, index.GetAnalyzers(), "broken")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
+func TestIndex_SearchAnalyzer(t *testing.T) {
+	indexName := "TestIndex_SearchAnalyzer.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		settings := &meta.IndexSettings{Analysis: &meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{
+				"default":      {Tokenizer: "whitespace"},
+				"autocomplete": {Tokenizer: "standard", Filter: []string{"lowercase", "autocomplete_filter"}},
+			},
+			TokenFilter: map[string]interface{}{
+				"autocomplete_filter": map[string]interface{}{"type": "edge_ngram", "min_gram": 1.0, "max_gram": 10.0},
+			},
+		}}
+		analyzers, err := zincanalysis.RequestAnalyzer(settings.Analysis)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetSettings(settings))
+		assert.NoError(t, index.SetAnalyzers(analyzers))
+
+		mappings := meta.NewMappings()
+		title := meta.NewProperty("text")
+		title.Analyzer = "autocomplete"
+		title.SearchAnalyzer = "standard"
+		mappings.SetProperty("title", title)
+		name := meta.NewProperty("text")
+		name.Analyzer = "autocomplete"
+		mappings.SetProperty("name", name)
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+
+		docs := map[string]map[string]interface{}{
+			"1": {"title": "Quick fox", "name": "Quick fox"},
+			"2": {"title": "Query", "name": "Query"},
+		}
+		for id, doc := range docs {
+			assert.NoError(t, index.CreateDocument(id, doc, false))
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(field, text string) []string {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match": map[string]interface{}{field: text}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	t.Run("search_analyzer", func(t *testing.T) {
+		assert.Equal(t, []string{"1"}, search("title", "Quick"))
+		assert.Equal(t, []string{"1", "2"}, search("title", "qu"))
+	})
+
+	t.Run("field analyzer without search_analyzer", func(t *testing.T) {
+		assert.Equal(t, []string{"1", "2"}, search("name", "Quick"))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
@@ -21,86 +21,136 @@ import (
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
-	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 // CreateDocument inserts or updates a document in the zinc index
 func (index *Index) CreateDocument(docID string, doc map[string]interface{}, update bool) error {
+	_, err := index.CreateDocumentIf(docID, doc, update, nil)
+	return err
+}
+
+// CreateDocumentIf inserts or updates a document in the zinc index if the document matches cond,
+// it returns the sequence number and the version of the write
+func (index *Index) CreateDocumentIf(docID string, doc map[string]interface{}, update bool, cond *WriteCondition) (WriteResult, error) {
 	// metrics
 	IncrMetricStatsByIndex(index.GetName(), "wal_request")
+	index.incrIndexing()
+
+	index.writing.RLock()
+	defer index.writing.RUnlock()
+	if err := index.checkOpen(); err != nil {
+		return WriteResult{}, err
+	}
 
 	// check WAL
-	shard := index.GetShardByDocID(docID)
+	shard := index.GetShardByRouting(docID, cond.routing())
 	if err := shard.OpenWAL(); err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
 	secondShardID := ShardIDNeedLatest
 	if update {
 		secondShardID = ShardIDNeedUpdate
 	}
-	data, err := shard.CheckDocument(docID, doc, update, secondShardID)
+	data, err := shard.checkDocument(docID, doc, update, secondShardID)
 	if err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
-	return shard.wal.Write(data)
+	return shard.writeDocument(docID, data, cond)
 }
 
 // GetDocument get a document in the zinc index
 func (index *Index) GetDocument(docID string) (*meta.Hit, error) {
+	return index.GetDocumentByRouting(docID, "")
+}
+
+// GetDocumentByRouting get a document written with the routing in the zinc index
+func (index *Index) GetDocumentByRouting(docID, routing string, storedFields ...string) (*meta.Hit, error) {
+	if err := index.checkOpen(); err != nil {
+		return nil, err
+	}
+
 	// check WAL
-	shard := index.GetShardByDocID(docID)
+	shard := index.GetShardByRouting(docID, routing)
 	if err := shard.OpenWAL(); err != nil {
 		return nil, err
 	}
 
-	return shard.FindDocumentByDocID(docID)
+	return shard.FindDocumentByDocID(docID, storedFields...)
 }
 
 // UpdateDocument updates a document in the zinc index
 func (index *Index) UpdateDocument(docID string, doc map[string]interface{}, insert bool) error {
+	_, err := index.UpdateDocumentIf(docID, doc, insert, nil)
+	return err
+}
+
+// UpdateDocumentIf updates a document in the zinc index if the document matches cond,
+// it returns the sequence number and the version of the write
+func (index *Index) UpdateDocumentIf(docID string, doc map[string]interface{}, insert bool, cond *WriteCondition) (WriteResult, error) {
 	// metrics
 	IncrMetricStatsByIndex(index.GetName(), "wal_request")
+	index.incrIndexing()
+
+	index.writing.RLock()
+	defer index.writing.RUnlock()
+	if err := index.checkOpen(); err != nil {
+		return WriteResult{}, err
+	}
 
 	// check WAL
-	shard := index.GetShardByDocID(docID)
+	shard := index.GetShardByRouting(docID, cond.routing())
 	if err := shard.OpenWAL(); err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
 	update := true
-	secondShardID, err := shard.FindShardByDocID(docID)
+	secondShardID, err := shard.findShardForWrite(docID)
 	if err != nil {
 		if insert && err == errors.ErrorIDNotFound {
 			update = false
 		} else {
-			return err
+			return WriteResult{}, err
 		}
 	}
 
-	data, err := shard.CheckDocument(docID, doc, update, secondShardID)
+	data, err := shard.checkDocument(docID, doc, update, secondShardID)
 	if err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
-	return shard.wal.Write(data)
+	return shard.writeDocument(docID, data, cond)
 }
 
 // DeleteDocument deletes a document in the zinc index
 func (index *Index) DeleteDocument(docID string) error {
+	_, err := index.DeleteDocumentIf(docID, nil)
+	return err
+}
+
+// DeleteDocumentIf deletes a document in the zinc index if the document matches cond,
+// it returns the sequence number and the version of the write
+func (index *Index) DeleteDocumentIf(docID string, cond *WriteCondition) (WriteResult, error) {
 	// metrics
 	IncrMetricStatsByIndex(index.GetName(), "wal_request")
+	index.incrDeleting()
+
+	index.writing.RLock()
+	defer index.writing.RUnlock()
+	if err := index.checkOpen(); err != nil {
+		return WriteResult{}, err
+	}
 
 	// check WAL
-	shard := index.GetShardByDocID(docID)
+	shard := index.GetShardByRouting(docID, cond.routing())
 	if err := shard.OpenWAL(); err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
-	secondShardID, err := shard.FindShardByDocID(docID)
+	secondShardID, err := shard.findShardForWrite(docID)
 	if err != nil {
-		return err
+		return WriteResult{}, err
 	}
 
 	data := map[string]interface{}{
@@ -108,11 +158,7 @@ func (index *Index) DeleteDocument(docID string) error {
 		meta.ActionFieldName: meta.ActionTypeDelete,
 		meta.ShardFieldName:  secondShardID,
 	}
-	jstr, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	return shard.wal.Write(jstr)
+	return shard.writeDocument(docID, data, cond)
 }
 
 // isDateProperty returns true if the given value matches the default date format.
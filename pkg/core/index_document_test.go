@@ -16,12 +16,15 @@
 package core
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/zincsearch/zincsearch/pkg/bluge/aggregation"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 )
 
@@ -330,6 +333,129 @@ func TestIndex_DeleteDocument(t *testing.T) {
 	})
 }
 
+func TestIndex_SeqNo(t *testing.T) {
+	indexName := "TestIndex_SeqNo.index_1"
+	var index *Index
+	var err error
+	var seqNo int64
+	t.Run("prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+
+		ret, err := index.CreateDocumentIf("1", map[string]interface{}{"name": "Hello"}, false, nil)
+		assert.NoError(t, err)
+		assert.Greater(t, ret.SeqNo, int64(0))
+		assert.Equal(t, int64(1), ret.Version)
+		assert.True(t, ret.Created)
+		seqNo = ret.SeqNo
+	})
+
+	t.Run("update pending in WAL", func(t *testing.T) {
+		_, err := index.UpdateDocumentIf("1", map[string]interface{}{"name": "World"}, false, &WriteCondition{IfSeqNo: seqNo + 1, IfPrimaryTerm: PrimaryTerm})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusBadRequest))
+		_, err = index.UpdateDocumentIf("1", map[string]interface{}{"name": "World"}, false, &WriteCondition{IfSeqNo: seqNo, IfPrimaryTerm: 2})
+		assert.Error(t, err)
+
+		ret, err := index.UpdateDocumentIf("1", map[string]interface{}{"name": "World"}, false, &WriteCondition{IfSeqNo: seqNo, IfPrimaryTerm: PrimaryTerm})
+		assert.NoError(t, err)
+		assert.Greater(t, ret.SeqNo, seqNo)
+		assert.Equal(t, int64(2), ret.Version)
+		assert.False(t, ret.Created)
+		seqNo = ret.SeqNo
+	})
+
+	t.Run("get", func(t *testing.T) {
+		assert.NoError(t, index.Flush())
+		hit, err := index.GetDocument("1")
+		assert.NoError(t, err)
+		assert.Equal(t, seqNo, *hit.SeqNo)
+		assert.Equal(t, PrimaryTerm, *hit.PrimaryTerm)
+		assert.Equal(t, int64(2), *hit.Version)
+		assert.Equal(t, "World", hit.Source.(map[string]interface{})["name"])
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		shard := index.GetShardByDocID("1")
+		shard.write.Lock()
+		defer shard.write.Unlock()
+		assert.Empty(t, shard.versions)
+		assert.NoError(t, shard.loadSeqNo())
+		assert.Equal(t, seqNo, shard.seqNo)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := index.DeleteDocumentIf("1", &WriteCondition{IfSeqNo: seqNo - 1, IfPrimaryTerm: PrimaryTerm})
+		assert.Error(t, err)
+		_, err = index.DeleteDocumentIf("1", &WriteCondition{IfSeqNo: seqNo, IfPrimaryTerm: PrimaryTerm})
+		assert.NoError(t, err)
+		_, err = index.CreateDocumentIf("1", map[string]interface{}{"name": "Again"}, true, &WriteCondition{IfSeqNo: seqNo, IfPrimaryTerm: PrimaryTerm})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
+func TestIndex_ExternalVersion(t *testing.T) {
+	indexName := "TestIndex_ExternalVersion.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+	})
+
+	write := func(version int64, versionType string) (WriteResult, error) {
+		return index.CreateDocumentIf("1", map[string]interface{}{"version": version}, true, &WriteCondition{Version: version, VersionType: versionType})
+	}
+	t.Run("external", func(t *testing.T) {
+		ret, err := write(5, VersionTypeExternal)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), ret.Version)
+		assert.True(t, ret.Created)
+
+		_, err = write(5, VersionTypeExternal)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusBadRequest))
+		_, err = write(3, VersionTypeExternal)
+		assert.Error(t, err)
+
+		assert.NoError(t, index.Flush())
+		ret, err = write(7, VersionTypeExternal)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), ret.Version)
+		assert.False(t, ret.Created)
+	})
+
+	t.Run("external_gte", func(t *testing.T) {
+		_, err := write(6, VersionTypeExternalGTE)
+		assert.Error(t, err)
+		ret, err := write(7, VersionTypeExternalGTE)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), ret.Version)
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		ret, err := index.CreateDocumentIf("1", map[string]interface{}{"version": 8}, true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), ret.Version)
+
+		assert.NoError(t, index.Flush())
+		hit, err := index.GetDocument("1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), *hit.Version)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
 func TestDateLayoutDetection(t *testing.T) {
 	type args struct {
 		layout string
@@ -557,3 +683,322 @@ func TestIndex_CreateUpdateDocumentWithDateField(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestIndex_DynamicTemplates(t *testing.T) {
+	indexName := "TestIndex_DynamicTemplates.index_1"
+	index, err := NewIndex(indexName, "disk", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+
+	mappings := meta.NewMappings()
+	keyword := meta.NewProperty("keyword")
+	mappings.SetDynamicTemplate(&meta.DynamicTemplate{Name: "ids", Match: "*_id", MatchMappingType: "*", Mapping: keyword})
+	mappings.SetDynamicTemplate(&meta.DynamicTemplate{Name: "skip", PathMatch: "raw.*", MatchMappingType: "string", Mapping: meta.NewProperty("text")})
+	mappings.SetDynamicTemplate(&meta.DynamicTemplate{Name: "strings_as_keyword", MatchMappingType: "string", Mapping: keyword})
+	assert.NoError(t, index.SetMappings(mappings))
+
+	err = index.CreateDocument("1", map[string]interface{}{
+		"name":       "zinc",
+		"user_id":    12,
+		"count":      3,
+		"created_at": "2023-01-01T00:00:00",
+		"raw":        map[string]interface{}{"message": "hello world"},
+	}, false)
+	assert.NoError(t, err)
+
+	tests := map[string]string{
+		"name":        "keyword",
+		"user_id":     "keyword",
+		"count":       "numeric",
+		"created_at":  "date",
+		"raw.message": "text",
+	}
+	for field, typ := range tests {
+		prop, ok := index.GetMappings().GetProperty(field)
+		assert.True(t, ok, field)
+		assert.Equal(t, typ, prop.Type, field)
+	}
+
+	assert.NoError(t, DeleteIndex(indexName))
+}
+
+func TestIndex_CopyTo(t *testing.T) {
+	indexName := "TestIndex_CopyTo.index_1"
+	index, err := NewIndex(indexName, "disk", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+
+	mappings := meta.NewMappings()
+	first := meta.NewProperty("text")
+	first.CopyTo = []string{"full_name"}
+	mappings.SetProperty("first", first)
+	last := meta.NewProperty("text")
+	last.CopyTo = []string{"full_name", "tags"}
+	mappings.SetProperty("last", last)
+	fullName := meta.NewProperty("text")
+	fullName.CopyTo = []string{"names"}
+	mappings.SetProperty("full_name", fullName)
+	names := meta.NewProperty("keyword")
+	names.CopyTo = []string{"full_name"} // loop
+	mappings.SetProperty("names", names)
+	mappings.SetProperty("tags", meta.NewProperty("keyword"))
+	assert.NoError(t, index.SetMappings(mappings))
+
+	err = index.CreateDocument("1", map[string]interface{}{"first": "John", "last": "Smith", "tags": "admin"}, false)
+	assert.NoError(t, err)
+	err = index.CreateDocument("2", map[string]interface{}{"first": "Jane", "last": "Doe"}, false)
+	assert.NoError(t, err)
+
+	// wait for WAL write to index
+	time.Sleep(time.Second)
+
+	search := func(query map[string]interface{}) *meta.SearchResponse {
+		got, err := index.Search(&meta.ZincQuery{Query: query, Size: 10})
+		assert.NoError(t, err)
+		return got
+	}
+
+	got := search(map[string]interface{}{"match": map[string]interface{}{"full_name": map[string]interface{}{"query": "john smith", "operator": "and"}}})
+	assert.Len(t, got.Hits.Hits, 1)
+	assert.Equal(t, "1", got.Hits.Hits[0].ID)
+	source := got.Hits.Hits[0].Source.(map[string]interface{})
+	assert.NotContains(t, source, "full_name")
+	assert.NotContains(t, source, "names")
+	assert.Equal(t, "admin", source["tags"])
+
+	// copied to multiple targets and merged with the source value
+	got = search(map[string]interface{}{"term": map[string]interface{}{"tags": "Smith"}})
+	assert.Len(t, got.Hits.Hits, 1)
+	got = search(map[string]interface{}{"term": map[string]interface{}{"tags": "admin"}})
+	assert.Len(t, got.Hits.Hits, 1)
+
+	// chained copies
+	got = search(map[string]interface{}{"term": map[string]interface{}{"names": "Jane"}})
+	assert.Len(t, got.Hits.Hits, 1)
+	assert.Equal(t, "2", got.Hits.Hits[0].ID)
+
+	assert.NoError(t, DeleteIndex(indexName))
+}
+
+func TestIndex_IgnoreAbove(t *testing.T) {
+	indexName := "TestIndex_IgnoreAbove.index_1"
+	index, err := NewIndex(indexName, "disk", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+
+	mappings := meta.NewMappings()
+	tag := meta.NewProperty("keyword")
+	tag.IgnoreAbove = 5
+	mappings.SetProperty("tag", tag)
+	raw := meta.NewProperty("keyword")
+	raw.IgnoreAbove = 10
+	title := meta.NewProperty("text")
+	title.AddField("raw", raw)
+	mappings.SetProperty("title", title)
+	mappings.SetProperty("title.raw", raw)
+	assert.NoError(t, index.SetMappings(mappings))
+
+	err = index.CreateDocument("1", map[string]interface{}{"tag": "short", "title": "zinc"}, false)
+	assert.NoError(t, err)
+	err = index.CreateDocument("2", map[string]interface{}{"tag": "too long", "title": "zinc is a search engine"}, false)
+	assert.NoError(t, err)
+
+	// wait for WAL write to index
+	time.Sleep(time.Second)
+
+	count := func(query map[string]interface{}) int {
+		got, err := index.Search(&meta.ZincQuery{Query: query, Size: 10})
+		assert.NoError(t, err)
+		return len(got.Hits.Hits)
+	}
+	assert.Equal(t, 1, count(map[string]interface{}{"term": map[string]interface{}{"tag": "short"}}))
+	assert.Equal(t, 0, count(map[string]interface{}{"term": map[string]interface{}{"tag": "too long"}}))
+	assert.Equal(t, 1, count(map[string]interface{}{"term": map[string]interface{}{"title.raw": "zinc"}}))
+	assert.Equal(t, 0, count(map[string]interface{}{"term": map[string]interface{}{"title.raw": "zinc is a search engine"}}))
+	// the parent field and the source are not affected
+	assert.Equal(t, 2, count(map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}}))
+	doc, err := index.GetDocument("2")
+	assert.NoError(t, err)
+	assert.Equal(t, "too long", doc.Source.(map[string]interface{})["tag"])
+
+	assert.NoError(t, DeleteIndex(indexName))
+}
+
+func TestIndex_NullValue(t *testing.T) {
+	indexName := "TestIndex_NullValue.index_1"
+	index, err := NewIndex(indexName, "disk", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+
+	mappings := meta.NewMappings()
+	status := meta.NewProperty("keyword")
+	status.NullValue = "NULL"
+	mappings.SetProperty("status", status)
+	count := meta.NewProperty("numeric")
+	count.NullValue = float64(42)
+	mappings.SetProperty("count", count)
+	active := meta.NewProperty("bool")
+	active.NullValue = false
+	mappings.SetProperty("active", active)
+	tags := meta.NewProperty("keyword")
+	tags.NullValue = "none"
+	mappings.SetProperty("tags", tags)
+	assert.NoError(t, index.SetMappings(mappings))
+
+	err = index.CreateDocument("1", map[string]interface{}{"status": nil, "count": nil, "active": nil, "tags": []interface{}{"a", nil}}, false)
+	assert.NoError(t, err)
+	err = index.CreateDocument("2", map[string]interface{}{"status": "ok", "count": 5, "active": true, "tags": "b"}, false)
+	assert.NoError(t, err)
+
+	// wait for WAL write to index
+	time.Sleep(time.Second)
+
+	search := func(query map[string]interface{}) []string {
+		got, err := index.Search(&meta.ZincQuery{Query: query, Size: 10})
+		assert.NoError(t, err)
+		ids := make([]string, 0, len(got.Hits.Hits))
+		for _, hit := range got.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"1"}, search(map[string]interface{}{"term": map[string]interface{}{"status": "NULL"}}))
+	assert.Len(t, search(map[string]interface{}{"exists": map[string]interface{}{"field": "status"}}), 2)
+	assert.Equal(t, []string{"1"}, search(map[string]interface{}{"range": map[string]interface{}{"count": map[string]interface{}{"gte": 42, "lte": 42}}}))
+	assert.Equal(t, []string{"1"}, search(map[string]interface{}{"term": map[string]interface{}{"active": false}}))
+	assert.Equal(t, []string{"1"}, search(map[string]interface{}{"term": map[string]interface{}{"tags": "none"}}))
+
+	// the source keeps the nulls
+	doc, err := index.GetDocument("1")
+	assert.NoError(t, err)
+	source := doc.Source.(map[string]interface{})
+	assert.Nil(t, source["status"])
+	assert.Equal(t, []interface{}{"a", nil}, source["tags"])
+
+	assert.NoError(t, DeleteIndex(indexName))
+}
+
+func TestIndex_SourceDisabled(t *testing.T) {
+	indexName := "TestIndex_SourceDisabled.index_1"
+	index, err := NewIndex(indexName, "disk", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+
+	mappings := meta.NewMappings()
+	mappings.SetSource(&meta.SourceMapping{Enabled: false})
+	name := meta.NewProperty("keyword")
+	name.Store = true
+	mappings.SetProperty("name", name)
+	mappings.SetProperty("tag", meta.NewProperty("keyword"))
+	assert.NoError(t, index.SetMappings(mappings))
+	assert.False(t, index.GetMappings().SourceEnabled())
+
+	assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "user", "tag": "a"}, false))
+	assert.NoError(t, index.Flush())
+
+	// the document is searchable without the source
+	got, err := index.Search(&meta.ZincQuery{
+		Query:        map[string]interface{}{"term": map[string]interface{}{"tag": "a"}},
+		StoredFields: []interface{}{"name"},
+		Size:         10,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, got.Hits.Hits, 1) {
+		assert.Equal(t, "1", got.Hits.Hits[0].ID)
+		assert.Nil(t, got.Hits.Hits[0].Source)
+		assert.Equal(t, map[string]interface{}{"name": []interface{}{"user"}}, got.Hits.Hits[0].Fields)
+	}
+	doc, err := index.GetDocument("1")
+	assert.NoError(t, err)
+	assert.Nil(t, doc.Source)
+
+	// the update needs the source of the existing document
+	_, _, err = index.UpdateDocumentRequest("1", &meta.UpdateRequest{Doc: map[string]interface{}{"tag": "b"}}, nil, 0)
+	var e *errors.Error
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, errors.ErrorTypeSourceMissingException, e.Type)
+	}
+	_, result, err := index.UpdateDocumentRequest("2", &meta.UpdateRequest{Doc: map[string]interface{}{"tag": "b"}, DocAsUpsert: true}, nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, UpdateResultCreated, result)
+
+	_, err = Reindex(&meta.ReindexRequest{Source: meta.ReindexSource{Index: indexName}, Dest: meta.ReindexDest{Index: indexName + "_dest"}})
+	assert.Error(t, err)
+
+	assert.NoError(t, DeleteIndex(indexName))
+}
+
+func TestIndex_Routing(t *testing.T) {
+	indexName := "TestIndex_Routing.index_1"
+	var index *Index
+	routing, other := "user_a", ""
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 4)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		// another routing value hashed to another shard
+		for i := 0; other == ""; i++ {
+			if v := fmt.Sprintf("user_%d", i); index.GetShardByRouting("", v) != index.GetShardByRouting("", routing) {
+				other = v
+			}
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		for _, id := range []string{"1", "2", "3"} {
+			_, err := index.CreateDocumentIf(id, map[string]interface{}{"name": "routed " + id}, true, RoutingCondition(routing))
+			assert.NoError(t, err)
+		}
+		_, err := index.CreateDocumentIf("4", map[string]interface{}{"name": "other"}, true, RoutingCondition(other))
+		assert.NoError(t, err)
+		assert.NoError(t, index.Flush())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		hit, err := index.GetDocumentByRouting("1", routing)
+		assert.NoError(t, err)
+		assert.Equal(t, routing, hit.Routing)
+		assert.Equal(t, "routed 1", hit.Source.(map[string]interface{})["name"])
+		_, err = index.GetDocumentByRouting("1", other)
+		assert.ErrorIs(t, err, errors.ErrorIDNotFound)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		query := func(routing ...string) *meta.SearchResponse {
+			resp, err := index.Search(&meta.ZincQuery{
+				Query:   map[string]interface{}{"match_all": map[string]interface{}{}},
+				Size:    10,
+				Routing: routing,
+			})
+			assert.NoError(t, err)
+			return resp
+		}
+		resp := query(routing)
+		assert.Equal(t, 3, resp.Hits.Total.Value)
+		for _, hit := range resp.Hits.Hits {
+			assert.Equal(t, routing, hit.Routing)
+		}
+		assert.Equal(t, 1, query(other).Hits.Total.Value)
+		assert.Equal(t, 4, query(routing, other).Hits.Total.Value)
+		assert.Equal(t, 4, query().Hits.Total.Value)
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		_, result, err := index.UpdateDocumentRequest("2", &meta.UpdateRequest{Doc: map[string]interface{}{"name": "updated"}}, RoutingCondition(routing), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		_, err = index.DeleteDocumentIf("3", RoutingCondition(routing))
+		assert.NoError(t, err)
+		assert.NoError(t, index.Flush())
+		hit, err := index.GetDocumentByRouting("2", routing)
+		assert.NoError(t, err)
+		assert.Equal(t, "updated", hit.Source.(map[string]interface{})["name"])
+		_, err = index.GetDocumentByRouting("3", routing)
+		assert.Error(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
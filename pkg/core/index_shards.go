@@ -18,6 +18,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,9 +28,11 @@ import (
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/directory"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/fields"
 	"github.com/zincsearch/zincsearch/pkg/uquery/source"
 	"github.com/zincsearch/zincsearch/pkg/wal"
 )
@@ -47,14 +50,21 @@ const (
 // then we will can not found the old document, maybe cause duplicate documents.
 // First layer shard just used for distribute not really store documents.
 type IndexShard struct {
-	open   uint64
-	name   string // shard name: index/shardID
-	root   *Index
-	ref    *meta.IndexShard
-	shards []*IndexSecondShard
-	wal    *wal.Log
-	lock   sync.RWMutex
-	close  chan struct{}
+	open    uint64
+	name    string // shard name: index/shardID
+	root    *Index
+	ref     *meta.IndexShard
+	shards  []*IndexSecondShard
+	wal     *wal.Log
+	lock    sync.RWMutex
+	consume sync.Mutex // serializes consuming the WAL
+	close   chan struct{}
+
+	refreshed int64 // the unix nano time of the last scheduled WAL consuming
+
+	write    sync.Mutex            // serializes assigning sequence numbers to the WAL writes
+	seqNo    int64                 // the last assigned sequence number
+	versions map[string]docVersion // the versions of the documents pending in the WAL
 }
 
 // IndexSecondShard second layer shard by auto increate shards for index.
@@ -78,6 +88,47 @@ func (index *Index) GetShardByDocID(docID string) *IndexShard {
 	return index.shards[shardKey]
 }
 
+// GetShardByRouting return the shard by hash routing, the documents with the same routing are grouped
+// in the same shard. It is the shard of docID when routing is empty.
+func (index *Index) GetShardByRouting(docID, routing string) *IndexShard {
+	if routing == "" {
+		return index.GetShardByDocID(docID)
+	}
+	return index.shards[index.shardHashing.Lookup(routing)]
+}
+
+// SplitRouting returns the routing values of a comma separated routing parameter
+func SplitRouting(routing string) []string {
+	var values []string
+	for _, v := range strings.Split(routing, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// GetShardsByRouting return the shards of the routing values, it returns all shards without routing
+func (index *Index) GetShardsByRouting(routing []string) []*IndexShard {
+	shards := make([]*IndexShard, 0, len(index.shards))
+	if len(routing) == 0 {
+		for _, shard := range index.shards {
+			shards = append(shards, shard)
+		}
+		return shards
+	}
+	seen := make(map[string]struct{}, len(routing))
+	for _, v := range routing {
+		shardKey := index.shardHashing.Lookup(v)
+		if _, ok := seen[shardKey]; ok {
+			continue
+		}
+		seen[shardKey] = struct{}{}
+		shards = append(shards, index.shards[shardKey])
+	}
+	return shards
+}
+
 // CheckShards check all shards status if need create new second layer shard
 func (index *Index) CheckShards() error {
 	for _, shard := range index.shards {
@@ -257,9 +308,17 @@ func (s *IndexShard) openWriter(shardID int64) error {
 	if secondShard.writer != nil {
 		return nil
 	}
-	var err error
 	indexName := fmt.Sprintf("%s/%s/%06x", s.GetIndexName(), s.GetID(), shardID)
-	secondShard.writer, err = OpenIndexWriter(indexName, s.root.GetStorageType(), defaultSearchAnalyzer, 0, 0)
+	opts := s.root.GetIndexingOptions()
+	cfg := directory.GetDiskIndexConfig(config.Global.DataPath, indexName, 0, 0)
+	cfg.NumAnalysisWorkers = opts.Workers
+	cfg.MergeBufferSize = opts.BufferSize
+	blugeConfig := bluge.DefaultConfigWithIndexConfig(cfg)
+	if defaultSearchAnalyzer != nil {
+		blugeConfig.DefaultSearchAnalyzer = defaultSearchAnalyzer
+	}
+	var err error
+	secondShard.writer, err = bluge.OpenWriter(blugeConfig)
 	return err
 }
 
@@ -271,6 +330,8 @@ func (s *IndexShard) Close() error {
 	s.close <- struct{}{}
 	atomic.StoreUint64(&s.open, 0)
 
+	s.consume.Lock()
+	defer s.consume.Unlock()
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	for _, secondShard := range s.shards {
@@ -359,8 +420,8 @@ func (s *IndexShard) FindShardByDocID(docID string) (int64, error) {
 	return shardID, nil
 }
 
-// FindDocumentByDocID finds docID and returns the document
-func (s *IndexShard) FindDocumentByDocID(docID string) (*meta.Hit, error) {
+// FindDocumentByDocID finds docID and returns the document, with the values of the stored fields if requested
+func (s *IndexShard) FindDocumentByDocID(docID string, storedFields ...string) (*meta.Hit, error) {
 	query := bluge.NewBooleanQuery()
 	query.AddMust(bluge.NewTermQuery(docID).SetField("_id"))
 	request := bluge.NewTopNSearch(1, query).WithStandardAggregations()
@@ -401,8 +462,15 @@ func (s *IndexShard) FindDocumentByDocID(docID string) (*meta.Hit, error) {
 			if dmi.Aggregations().Count() > 0 {
 				var id string
 				var indexName string
+				var seqNo int64
+				version := int64(1)
+				var routing string
 				var timestamp time.Time
 				var sourceData map[string]interface{}
+				var stored *fields.Stored
+				if len(storedFields) > 0 {
+					stored = fields.NewStored(storedFields, s.root.GetMappings())
+				}
 				if next, err := dmi.Next(); err == nil {
 					_ = next.VisitStoredFields(func(field string, value []byte) bool {
 						switch field {
@@ -410,22 +478,39 @@ func (s *IndexShard) FindDocumentByDocID(docID string) (*meta.Hit, error) {
 							id = string(value)
 						case "_index":
 							indexName = string(value)
+						case "_seq_no":
+							v, _ := bluge.DecodeNumericFloat64(value)
+							seqNo = int64(v)
+						case "_version":
+							v, _ := bluge.DecodeNumericFloat64(value)
+							version = int64(v)
+						case "_routing":
+							routing = string(value)
 						case "@timestamp":
 							timestamp, _ = bluge.DecodeDateTime(value)
 						case "_source":
 							sourceData = source.Response(&meta.Source{Enable: true}, value)
-						default: // do nothing
+						default:
+							stored.Visit(field, value)
 						}
 						return true
 					})
 				}
+				primaryTerm := PrimaryTerm
 				hit = &meta.Hit{
-					Index:     indexName,
-					Type:      "_doc",
-					ID:        id,
-					Score:     0,
-					Timestamp: timestamp,
-					Source:    sourceData,
+					Index:       indexName,
+					Type:        "_doc",
+					ID:          id,
+					Score:       0,
+					Version:     &version,
+					SeqNo:       &seqNo,
+					PrimaryTerm: &primaryTerm,
+					Routing:     routing,
+					Timestamp:   timestamp,
+					Fields:      stored.Response(),
+				}
+				if sourceData != nil {
+					hit.Source = sourceData
 				}
 				return errors.ErrCancelSignal // check err, if returns err with cancel other all goroutines.
 			}
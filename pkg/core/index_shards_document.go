@@ -17,14 +17,19 @@ package core
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/numeric/geo"
 
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/uquery/suggest"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 	"github.com/zincsearch/zincsearch/pkg/zutils/flatten"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
@@ -43,6 +48,7 @@ func (s *IndexShard) BuildBlugeDocumentFromJSON(docID string, doc map[string]int
 
 	// Create a new bluge document
 	bdoc := bluge.NewDocument(docID)
+	allExcludes := []string{"_id", "_index", "_source", "_seq_no", "_version", "_routing", meta.TimeFieldName}
 	// Iterate through each field and add it to the bluge document
 	for key, value := range doc {
 		if value == nil || key == meta.TimeFieldName || key == meta.SourceFieldName {
@@ -50,8 +56,12 @@ func (s *IndexShard) BuildBlugeDocumentFromJSON(docID string, doc map[string]int
 		}
 
 		prop, ok := mappings.GetProperty(key)
-		if !ok || !prop.Index {
-			continue // not index, skip
+		if !ok || !prop.Index || prop.Type == "nested" {
+			continue // not index, skip; nested objects are indexed as separate documents
+		}
+		if prop.Type == "completion" {
+			// completion terms only serve the completion suggester
+			allExcludes = append(allExcludes, key)
 		}
 
 		switch v := value.(type) {
@@ -76,18 +86,36 @@ func (s *IndexShard) BuildBlugeDocumentFromJSON(docID string, doc map[string]int
 	}
 	bdoc.AddField(bluge.NewDateTimeField(meta.TimeFieldName, timestamp).StoreValue().Sortable().Aggregatable())
 
-	// set source
-	var sourceByteVal []byte
-	if v, ok := doc[meta.SourceFieldName]; ok && v != nil {
-		sourceByteVal, _ = json.Marshal(v)
-	} else {
-		delete(doc, meta.SourceFieldName)
-		sourceByteVal, _ = json.Marshal(doc)
+	// set sequence number and version
+	if value, ok := doc[meta.SeqNoFieldName]; ok {
+		delete(doc, meta.SeqNoFieldName)
+		bdoc.AddField(bluge.NewNumericField("_seq_no", value.(float64)).StoreValue().Sortable())
+	}
+	if value, ok := doc[meta.VersionFieldName]; ok {
+		delete(doc, meta.VersionFieldName)
+		bdoc.AddField(bluge.NewNumericField("_version", value.(float64)).StoreValue())
+	}
+
+	// set routing
+	if value, ok := doc[meta.RoutingFieldName]; ok {
+		delete(doc, meta.RoutingFieldName)
+		bdoc.AddField(bluge.NewKeywordField("_routing", value.(string)).StoreValue())
+	}
+
+	// set source, it isn't stored when disabled in the mappings
+	if mappings.SourceEnabled() {
+		var sourceByteVal []byte
+		if v, ok := doc[meta.SourceFieldName]; ok && v != nil {
+			sourceByteVal, _ = json.Marshal(v)
+		} else {
+			delete(doc, meta.SourceFieldName)
+			sourceByteVal, _ = json.Marshal(doc)
+		}
+		bdoc.AddField(bluge.NewStoredOnlyField("_source", sourceByteVal))
 	}
-	bdoc.AddField(bluge.NewStoredOnlyField("_source", sourceByteVal))
 
 	bdoc.AddField(bluge.NewStoredOnlyField("_index", []byte(s.GetIndexName())))
-	bdoc.AddField(bluge.NewCompositeFieldExcluding("_all", []string{"_id", "_index", "_source", meta.TimeFieldName}))
+	bdoc.AddField(bluge.NewCompositeFieldExcluding("_all", allExcludes))
 
 	// Add time for index
 	bdoc.SetTimestamp(timestamp.UnixNano())
@@ -97,6 +125,74 @@ func (s *IndexShard) BuildBlugeDocumentFromJSON(docID string, doc map[string]int
 	return bdoc, nil
 }
 
+// BuildNestedDocuments returns the hidden documents of the nested objects of the document,
+// they are written and deleted along with the parent document.
+func (s *IndexShard) BuildNestedDocuments(docID string, doc map[string]interface{}, timestamp int64, paths []string) ([]*bluge.Document, error) {
+	mappings := s.root.GetMappings()
+	docs := make([]*bluge.Document, 0)
+	for _, path := range paths {
+		objects, ok := doc[path].([]interface{})
+		if !ok {
+			continue
+		}
+		for offset, object := range objects {
+			object, ok := object.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			bdoc := new(bluge.Document)
+			bdoc.AddField(bluge.NewKeywordField(meta.NestedParentFieldName, docID).StoreValue().Sortable())
+			bdoc.AddField(bluge.NewKeywordField(meta.NestedPathFieldName, path))
+			bdoc.AddField(bluge.NewNumericField(meta.NestedOffsetFieldName, float64(offset)).StoreValue())
+			source := make(map[string]interface{}, len(object))
+			for key, value := range object {
+				if value == nil {
+					continue
+				}
+				source[strings.TrimPrefix(key, path+".")] = value
+				prop, ok := mappings.GetProperty(key)
+				if !ok || !prop.Index || prop.Type == "nested" {
+					continue
+				}
+				switch v := value.(type) {
+				case []interface{}:
+					for _, v := range v {
+						if err := s.buildField(mappings, bdoc, key, v); err != nil {
+							return nil, err
+						}
+					}
+				default:
+					if err := s.buildField(mappings, bdoc, key, v); err != nil {
+						return nil, err
+					}
+				}
+			}
+			source, err := flatten.Unflatten(source)
+			if err != nil {
+				return nil, err
+			}
+			if mappings.SourceEnabled() {
+				sourceByteVal, _ := json.Marshal(source)
+				bdoc.AddField(bluge.NewStoredOnlyField("_source", sourceByteVal))
+			}
+			bdoc.SetTimestamp(timestamp)
+			docs = append(docs, bdoc)
+		}
+	}
+	return docs, nil
+}
+
+// nestedParentID is the term to find the nested documents of the parent document
+type nestedParentID string
+
+func (id nestedParentID) Field() string {
+	return meta.NestedParentFieldName
+}
+
+func (id nestedParentID) Term() []byte {
+	return []byte(id)
+}
+
 func (s *IndexShard) buildField(mappings *meta.Mappings, bdoc *bluge.Document, key string, value interface{}) error {
 	var field *bluge.TermField
 	prop, _ := mappings.GetProperty(key)
@@ -118,6 +214,10 @@ func (s *IndexShard) buildField(mappings *meta.Mappings, bdoc *bluge.Document, k
 		if v == "" {
 			return nil
 		}
+		if prop.IgnoreAbove > 0 && utf8.RuneCountInString(v) > prop.IgnoreAbove {
+			// too long to be indexed, the multi-fields index it in their own way
+			return s.buildMultiFields(mappings, bdoc, key, prop, value)
+		}
 		field = bluge.NewKeywordField(key, v)
 	case "bool":
 		field = bluge.NewKeywordField(key, strconv.FormatBool(value.(bool)))
@@ -127,6 +227,20 @@ func (s *IndexShard) buildField(mappings *meta.Mappings, bdoc *bluge.Document, k
 			return fmt.Errorf("field [%s] value [%v] parse err: %s", key, value, err.Error())
 		}
 		field = bluge.NewDateTimeField(key, v)
+	case "completion":
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field [%s] value [%v] is not a completion", key, value)
+		}
+		input, _ := v["input"].(string)
+		weight, _ := zutils.ToInt(v["weight"])
+		field = bluge.NewKeywordField(key, suggest.CompletionTerm(input, weight))
+	case "geo_point":
+		lon, lat, ok := geo.ExtractGeoPoint(value)
+		if !ok {
+			return fmt.Errorf("field [%s] value [%v] is not a geo point", key, value)
+		}
+		field = bluge.NewGeoPointField(key, lon, lat)
 	}
 	if prop.Store || prop.Highlightable {
 		field.StoreValue()
@@ -142,23 +256,50 @@ func (s *IndexShard) buildField(mappings *meta.Mappings, bdoc *bluge.Document, k
 	}
 	bdoc.AddField(field)
 
+	return s.buildMultiFields(mappings, bdoc, key, prop, value)
+}
+
+// buildMultiFields adds the multi-fields of the field to the bluge document with the value converted to their types
+func (s *IndexShard) buildMultiFields(mappings *meta.Mappings, bdoc *bluge.Document, key string, prop meta.Property, value interface{}) error {
 	for propField := range prop.Fields {
-		err := s.buildField(mappings, bdoc, key+"."+propField, value)
+		sub, ok := mappings.GetProperty(key + "." + propField)
+		if !ok || !sub.Index {
+			continue
+		}
+		v, err := convertField(key+"."+propField, sub, value)
 		if err != nil {
 			return err
 		}
+		if err = s.buildField(mappings, bdoc, key+"."+propField, v); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// CheckDocument checks if the document is valid.
+// CheckDocument checks if the document is valid and returns the WAL entry of the document.
 func (s *IndexShard) CheckDocument(docID string, doc map[string]interface{}, update bool, shard int64) ([]byte, error) {
+	flatDoc, err := s.checkDocument(docID, doc, update, shard)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(flatDoc)
+}
+
+// checkDocument checks if the document is valid and returns the flattened document prepared for the WAL.
+func (s *IndexShard) checkDocument(docID string, doc map[string]interface{}, update bool, shard int64) (map[string]interface{}, error) {
 	// Pick the index mapping from the cache if it already exists
 	mappings := s.root.GetMappings()
 	mappingsNeedsUpdate := false
 
 	flatDoc, _ := flatten.Flatten(doc, "")
+	// completion, geo_point and nested fields accept objects, take them back from the flattened document
+	if err := s.checkObjectFields(mappings, doc, "", flatDoc); err != nil {
+		return nil, err
+	}
+	nullValues(mappings, flatDoc)
+	copyFields(mappings, flatDoc)
 	// Iterate through each field and add it to the bluge document
 	for key, value := range flatDoc {
 		if value == nil {
@@ -173,6 +314,16 @@ func (s *IndexShard) CheckDocument(docID string, doc map[string]interface{}, upd
 		if !ok || !prop.Index {
 			continue // not index, skip
 		}
+		if prop.Type == "nested" {
+			update, err := s.checkNestedObjects(mappings, value.([]interface{}))
+			if err != nil {
+				return nil, err
+			}
+			if update {
+				mappingsNeedsUpdate = true
+			}
+			continue
+		}
 
 		switch v := value.(type) {
 		case []interface{}:
@@ -221,19 +372,114 @@ func (s *IndexShard) CheckDocument(docID string, doc map[string]interface{}, upd
 	flatDoc[meta.TimeFieldName] = timestamp.UnixNano()
 	flatDoc[meta.SourceFieldName] = doc
 
-	return json.Marshal(flatDoc)
+	return flatDoc, nil
+}
+
+// nullValues replaces the explicit null values of the fields with null_value, the source keeps the nulls
+func nullValues(mappings *meta.Mappings, flatDoc map[string]interface{}) {
+	for key, value := range flatDoc {
+		prop, ok := mappings.GetProperty(key)
+		if !ok || prop.NullValue == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			flatDoc[key] = prop.NullValue
+		case []interface{}:
+			// the array is shared with the source, copy it before replacing
+			var values []interface{}
+			for i, item := range v {
+				if item != nil {
+					continue
+				}
+				if values == nil {
+					values = append([]interface{}{}, v...)
+				}
+				values[i] = prop.NullValue
+			}
+			if values != nil {
+				flatDoc[key] = values
+			}
+		}
+	}
+}
+
+// copyFields adds the values of the fields with copy_to to their target fields, the targets are indexed
+// but not in the source. The copies go on through the copy_to of the targets, each field once per source field
+func copyFields(mappings *meta.Mappings, flatDoc map[string]interface{}) {
+	copies := make(map[string][]interface{})
+	for key, value := range flatDoc {
+		if value == nil {
+			continue
+		}
+		prop, ok := mappings.GetProperty(key)
+		if !ok || len(prop.CopyTo) == 0 {
+			continue
+		}
+		copyField(mappings, copies, map[string]bool{key: true}, prop.CopyTo, value)
+	}
+
+	for target, values := range copies {
+		switch v := flatDoc[target].(type) {
+		case nil:
+		case []interface{}:
+			values = append(v, values...)
+		default:
+			values = append([]interface{}{v}, values...)
+		}
+		flatDoc[target] = values
+	}
+}
+
+func copyField(mappings *meta.Mappings, copies map[string][]interface{}, visited map[string]bool, targets []string, value interface{}) {
+	for _, target := range targets {
+		if visited[target] {
+			continue
+		}
+		visited[target] = true
+		if v, ok := value.([]interface{}); ok {
+			copies[target] = append(copies[target], v...)
+		} else {
+			copies[target] = append(copies[target], value)
+		}
+		if prop, ok := mappings.GetProperty(target); ok && len(prop.CopyTo) > 0 {
+			copyField(mappings, copies, visited, prop.CopyTo, value)
+		}
+	}
 }
 
 // checkProperty returns if need update mappings
 func (s *IndexShard) checkProperty(mappings *meta.Mappings, key string, value interface{}) bool {
 	prop, ok := mappings.GetProperty(key)
 	if ok {
-		if config.Global.EnableTextKeywordMapping && prop.Type == "text" {
-			if _, ok := mappings.GetProperty(key + ".keyword"); ok {
-				return false
+		update := false
+		if config.Global.EnableTextKeywordMapping && prop.Type == "text" && len(prop.Fields) == 0 {
+			prop.AddField("keyword", meta.NewProperty("keyword"))
+			mappings.SetProperty(key, prop)
+			update = true
+		}
+		// the multi-fields missing in the mappings are added
+		for field, p := range prop.Fields {
+			if _, ok := mappings.GetProperty(key + "." + field); !ok {
+				mappings.SetProperty(key+"."+field, p)
+				update = true
 			}
-		} else {
-			return false
+		}
+		return update
+	}
+
+	// the dynamic templates take precedence over the default mapping of the new fields
+	if mappingType, layout := dynamicMappingType(value); !ok && mappingType != "" {
+		if tpl, matched := mappings.MatchDynamicTemplate(key, mappingType); matched {
+			newProp := tpl.Mapping.DeepClone()
+			if newProp.Type == "date" && newProp.Format == "" {
+				newProp.Format = layout
+			}
+			mappings.SetProperty(key, newProp)
+			for field, p := range newProp.Fields {
+				mappings.SetProperty(key+"."+field, p)
+			}
+			return true
 		}
 	}
 
@@ -288,45 +534,277 @@ func (s *IndexShard) checkProperty(mappings *meta.Mappings, key string, value in
 	return true
 }
 
+// dynamicMappingType returns the es mapping type of the value matched by the dynamic templates,
+// and the layout of the date strings, the arrays take the type of their first value
+func dynamicMappingType(value interface{}) (string, string) {
+	switch v := value.(type) {
+	case string:
+		if layout, ok := isDateProperty(v); ok {
+			return "date", layout
+		}
+		return "string", ""
+	case int, int64:
+		return "long", ""
+	case float64:
+		if v == math.Trunc(v) {
+			return "long", ""
+		}
+		return "double", ""
+	case bool:
+		return "boolean", ""
+	case []interface{}:
+		if len(v) > 0 {
+			return dynamicMappingType(v[0])
+		}
+	}
+	return "", ""
+}
+
 func (s *IndexShard) checkField(mappings *meta.Mappings, data map[string]interface{}, key string, value interface{}, id int, array bool) error {
+	prop, _ := mappings.GetProperty(key)
+	v, err := convertField(key, prop, value)
+	if err != nil {
+		return err
+	}
+	// the multi-fields index the same value with their own types
+	for field := range prop.Fields {
+		sub, _ := mappings.GetProperty(key + "." + field)
+		if _, err := convertField(key+"."+field, sub, v); err != nil {
+			return err
+		}
+	}
+	if array {
+		sub := data[key].([]interface{})
+		sub[id] = v
+		data[key] = sub
+	} else {
+		data[key] = v
+	}
+
+	return nil
+}
+
+// convertField converts the value to the type of the property of the field
+func convertField(key string, prop meta.Property, value interface{}) (interface{}, error) {
 	var err error
 	var v interface{}
-	prop, _ := mappings.GetProperty(key)
 	switch prop.Type {
 	case "text":
 		v, err = zutils.ToString(value)
 		if err != nil {
-			return fmt.Errorf("field [%s] was set type to [text] but the value [%v] can't convert to string", key, value)
+			return nil, fmt.Errorf("field [%s] was set type to [text] but the value [%v] can't convert to string", key, value)
 		}
 	case "numeric":
 		v, err = zutils.ToFloat64(value)
 		if err != nil {
-			return fmt.Errorf("field [%s] was set type to [numeric] but the value [%v] can't convert to int", key, value)
+			return nil, fmt.Errorf("field [%s] was set type to [numeric] but the value [%v] can't convert to int", key, value)
 		}
 	case "keyword":
 		v, err = zutils.ToString(value)
 		if err != nil {
-			return fmt.Errorf("field [%s] was set type to [keyword] but the value [%v] can't convert to string", key, value)
+			return nil, fmt.Errorf("field [%s] was set type to [keyword] but the value [%v] can't convert to string", key, value)
 		}
 	case "bool":
 		v, err = zutils.ToBool(value)
 		if err != nil {
-			return fmt.Errorf("field [%s] was set type to [bool] but the value [%v] can't convert to boolean", key, value)
+			return nil, fmt.Errorf("field [%s] was set type to [bool] but the value [%v] can't convert to boolean", key, value)
 		}
 	case "date", "time":
 		_, err := zutils.ParseTime(value, prop.Format, prop.TimeZone)
 		if err != nil {
-			return fmt.Errorf("field [%s] value [%v] parse err: %s", key, value, err.Error())
+			return nil, fmt.Errorf("field [%s] value [%v] parse err: %s", key, value, err.Error())
 		}
 		v = value
+	case "completion", "geo_point":
+		v = value
 	}
-	if array {
-		sub := data[key].([]interface{})
-		sub[id] = v
-		data[key] = sub
-	} else {
-		data[key] = v
+	return v, nil
+}
+
+// checkNestedObjects checks the fields of the nested objects, returns if need update mappings
+func (s *IndexShard) checkNestedObjects(mappings *meta.Mappings, objects []interface{}) (bool, error) {
+	mappingsNeedsUpdate := false
+	for _, object := range objects {
+		object := object.(map[string]interface{})
+		for key, value := range object {
+			if value == nil {
+				continue
+			}
+			if update := s.checkProperty(mappings, key, value); update {
+				mappingsNeedsUpdate = true
+			}
+			prop, ok := mappings.GetProperty(key)
+			if !ok || !prop.Index {
+				continue
+			}
+			switch v := value.(type) {
+			case []interface{}:
+				for i, v := range v {
+					if err := s.checkField(mappings, object, key, v, i, true); err != nil {
+						return false, err
+					}
+				}
+			default:
+				if err := s.checkField(mappings, object, key, v, 0, false); err != nil {
+					return false, err
+				}
+			}
+		}
 	}
+	return mappingsNeedsUpdate, nil
+}
 
+// checkObjectFields replaces the flattened values of completion, geo_point and nested fields with the list of parsed values
+func (s *IndexShard) checkObjectFields(mappings *meta.Mappings, doc map[string]interface{}, prefix string, flatDoc map[string]interface{}) error {
+	for k, value := range doc {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if prop, ok := mappings.GetProperty(key); ok && prop.Type == "nested" {
+			if value == nil {
+				continue
+			}
+			objects, err := nestedObjects(key, value)
+			if err != nil {
+				return err
+			}
+			for flatKey := range flatDoc {
+				if strings.HasPrefix(flatKey, key+".") {
+					delete(flatDoc, flatKey)
+				}
+			}
+			flatDoc[key] = objects
+			continue
+		}
+		if prop, ok := mappings.GetProperty(key); ok && (prop.Type == "completion" || prop.Type == "geo_point") {
+			if value == nil {
+				continue
+			}
+			var values []interface{}
+			var err error
+			if prop.Type == "completion" {
+				values, err = completionInputs(key, value, 1)
+			} else {
+				values, err = geoPoints(key, value)
+			}
+			if err != nil {
+				return err
+			}
+			for flatKey := range flatDoc {
+				if strings.HasPrefix(flatKey, key+".") {
+					delete(flatDoc, flatKey)
+				}
+			}
+			flatDoc[key] = values
+			continue
+		}
+		if v, ok := value.(map[string]interface{}); ok {
+			if err := s.checkObjectFields(mappings, v, key, flatDoc); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
+
+// nestedObjects converts a nested value to a list of flattened objects, the keys are the full path of the fields
+//
+//	{"name": "john", "role": "author"}
+//	[{"name": "john", "role": "author"}, {"name": "jane", "role": "editor"}]
+func nestedObjects(key string, value interface{}) ([]interface{}, error) {
+	var values []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		values = []interface{}{v}
+	case []interface{}:
+		values = v
+	default:
+		return nil, fmt.Errorf("field [%s] was set type to [nested] but the value [%v] is not an object", key, value)
+	}
+	objects := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field [%s] was set type to [nested] but the value [%v] is not an object", key, v)
+		}
+		flatObject, _ := flatten.Flatten(object, "")
+		nested := make(map[string]interface{}, len(flatObject))
+		for k, v := range flatObject {
+			nested[key+"."+k] = v
+		}
+		objects = append(objects, nested)
+	}
+	return objects, nil
+}
+
+// completionInputs converts a completion value to a list of {"input": "", "weight": 1}
+//
+//	"input"
+//	["input1", "input2"]
+//	{"input": ["input1", "input2"], "weight": 10}
+//	[{"input": "input1", "weight": 10}, {"input": "input2", "weight": 5}]
+func completionInputs(key string, value interface{}, weight int) ([]interface{}, error) {
+	inputs := make([]interface{}, 0)
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			inputs = append(inputs, map[string]interface{}{"input": v, "weight": float64(weight)})
+		}
+	case []interface{}:
+		for _, v := range v {
+			sub, err := completionInputs(key, v, weight)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, sub...)
+		}
+	case map[string]interface{}:
+		if w, ok := v["weight"]; ok {
+			n, err := zutils.ToInt(w)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("field [%s] weight [%v] must be a non-negative integer", key, w)
+			}
+			weight = n
+		}
+		input, ok := v["input"]
+		if !ok {
+			return nil, fmt.Errorf("field [%s] completion requires [input]", key)
+		}
+		if _, ok := input.(map[string]interface{}); ok {
+			return nil, fmt.Errorf("field [%s] completion input must be a string or an array of strings", key)
+		}
+		return completionInputs(key, input, weight)
+	default:
+		return nil, fmt.Errorf("field [%s] was set type to [completion] but the value [%v] is not a string or an object", key, value)
+	}
+	return inputs, nil
+}
+
+// geoPoints converts a geo_point value to a list of {"lat": 0, "lon": 0}
+//
+//	{"lat": 41.12, "lon": -71.34}
+//	"41.12,-71.34"
+//	[-71.34, 41.12]
+//	[{"lat": 41.12, "lon": -71.34}, "41.12,-71.34"]
+func geoPoints(key string, value interface{}) ([]interface{}, error) {
+	if lon, lat, ok := geo.ExtractGeoPoint(value); ok {
+		return []interface{}{map[string]interface{}{"lat": lat, "lon": lon}}, nil
+	}
+	v, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("field [%s] was set type to [geo_point] but the value [%v] is not a geo point", key, value)
+	}
+	points := make([]interface{}, 0, len(v))
+	for _, v := range v {
+		lon, lat, ok := geo.ExtractGeoPoint(v)
+		if !ok {
+			return nil, fmt.Errorf("field [%s] was set type to [geo_point] but the value [%v] is not a geo point", key, v)
+		}
+		points = append(points, map[string]interface{}{"lat": lat, "lon": lon})
+	}
+	return points, nil
+}
@@ -0,0 +1,373 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+	"github.com/blugelabs/bluge/search/aggregations"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// PrimaryTerm is the primary term of all the documents, zinc has no replicas to elect a new primary
+const PrimaryTerm int64 = 1
+
+// Version types of the document writes
+const (
+	VersionTypeInternal    = "internal"     // the version is incremented on every write
+	VersionTypeExternal    = "external"     // the version is given and must be greater than the stored version
+	VersionTypeExternalGTE = "external_gte" // the version is given and must be greater than or equal to the stored version
+)
+
+// WriteCondition makes a document write conditional on the current version of the document,
+// the write is rejected with a version conflict when the condition doesn't match.
+type WriteCondition struct {
+	IfSeqNo       int64
+	IfPrimaryTerm int64 // 0 without a compare-and-swap on the sequence number
+	Version       int64
+	VersionType   string // internal, external or external_gte
+	Create        bool   // the document must not exist
+	Routing       string // the shard of the document is picked by the routing instead of the id
+}
+
+// RoutingCondition returns the condition writing the document with the routing, it is nil without routing
+func RoutingCondition(routing string) *WriteCondition {
+	if routing == "" {
+		return nil
+	}
+	return &WriteCondition{Routing: routing}
+}
+
+// routing returns the routing of the write, it is empty without condition
+func (cond *WriteCondition) routing() string {
+	if cond == nil {
+		return ""
+	}
+	return cond.Routing
+}
+
+// conditional reports whether the write depends on the current version of the document
+func (cond *WriteCondition) conditional() bool {
+	return cond != nil && (cond.Create || cond.IfPrimaryTerm > 0 || cond.VersionType != "")
+}
+
+// WriteResult is the version assigned to a document write
+type WriteResult struct {
+	SeqNo   int64
+	Version int64
+	Created bool // the document didn't exist before
+}
+
+// docVersion is the version of a document written to the WAL and not consumed yet
+type docVersion struct {
+	seqNo   int64
+	version int64
+	deleted bool
+	walID   uint64 // the WAL entry of the write
+}
+
+// writeDocument assigns the next sequence number and the version to the WAL entry of the document and writes it,
+// when cond is not nil the current version of the document must match it.
+func (s *IndexShard) writeDocument(docID string, data map[string]interface{}, cond *WriteCondition) (WriteResult, error) {
+	s.write.Lock()
+	defer s.write.Unlock()
+
+	if s.versions == nil {
+		if err := s.loadSeqNo(); err != nil {
+			return WriteResult{}, err
+		}
+	}
+
+	// a new document without conditions needs no lookup
+	var current docVersion
+	var found bool
+	if cond.conditional() || data[meta.ActionFieldName] != meta.ActionTypeInsert {
+		var err error
+		if current, found, err = s.currentVersion(docID); err != nil {
+			return WriteResult{}, err
+		}
+	}
+	version := current.version + 1
+	if !found {
+		version = 1
+	}
+	if cond != nil {
+		if err := cond.check(docID, current, found); err != nil {
+			return WriteResult{}, err
+		}
+		if cond.VersionType == VersionTypeExternal || cond.VersionType == VersionTypeExternalGTE {
+			version = cond.Version
+		}
+	}
+
+	seqNo := s.seqNo + 1
+	data[meta.SeqNoFieldName] = seqNo
+	data[meta.VersionFieldName] = version
+	if routing := cond.routing(); routing != "" {
+		data[meta.RoutingFieldName] = routing
+	}
+	entry, err := json.Marshal(data)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	if err = s.wal.Write(entry); err != nil {
+		return WriteResult{}, err
+	}
+	walID, err := s.wal.LastIndex()
+	if err != nil {
+		return WriteResult{}, err
+	}
+	s.seqNo = seqNo
+	s.versions[docID] = docVersion{seqNo: seqNo, version: version, deleted: data[meta.ActionFieldName] == meta.ActionTypeDelete, walID: walID}
+	return WriteResult{SeqNo: seqNo, Version: version, Created: !found}, nil
+}
+
+// check returns a version conflict if the current version of the document doesn't match the condition,
+// the version of a deleted document is still compared by the external version types.
+func (cond *WriteCondition) check(docID string, current docVersion, found bool) error {
+	if cond.Create && found {
+		return errors.New(errors.ErrorTypeVersionConflictException, fmt.Sprintf(
+			"[%s]: version conflict, document already exists (current version [%d])", docID, current.version))
+	}
+	if cond.IfPrimaryTerm > 0 {
+		if !found {
+			return errors.New(errors.ErrorTypeVersionConflictException, fmt.Sprintf(
+				"[%s]: version conflict, required seqNo [%d], primary term [%d] but no document was found",
+				docID, cond.IfSeqNo, cond.IfPrimaryTerm))
+		}
+		if current.seqNo != cond.IfSeqNo || PrimaryTerm != cond.IfPrimaryTerm {
+			return errors.New(errors.ErrorTypeVersionConflictException, fmt.Sprintf(
+				"[%s]: version conflict, required seqNo [%d], primary term [%d]. current document has seqNo [%d] and primary term [%d]",
+				docID, cond.IfSeqNo, cond.IfPrimaryTerm, current.seqNo, PrimaryTerm))
+		}
+	}
+	switch cond.VersionType {
+	case VersionTypeExternal:
+		if current.version > 0 && cond.Version <= current.version {
+			return errors.New(errors.ErrorTypeVersionConflictException, fmt.Sprintf(
+				"[%s]: version conflict, current version [%d] is higher or equal to the one provided [%d]",
+				docID, current.version, cond.Version))
+		}
+	case VersionTypeExternalGTE:
+		if current.version > 0 && cond.Version < current.version {
+			return errors.New(errors.ErrorTypeVersionConflictException, fmt.Sprintf(
+				"[%s]: version conflict, current version [%d] is higher than the one provided [%d]",
+				docID, current.version, cond.Version))
+		}
+	}
+	return nil
+}
+
+// currentVersion returns the latest version of the document, the version of a document deleted
+// in the WAL is returned as not found. The documents written before sequence numbers and versions
+// were tracked have sequence number 0 and version 1.
+func (s *IndexShard) currentVersion(docID string) (docVersion, bool, error) {
+	if v, ok := s.versions[docID]; ok {
+		return v, !v.deleted, nil
+	}
+	v, err := s.findVersionByDocID(docID)
+	if err != nil {
+		if err == errors.ErrorIDNotFound {
+			return docVersion{}, false, nil
+		}
+		return docVersion{}, false, err
+	}
+	return v, true, nil
+}
+
+// currentDocument returns the source and the version of the latest version of the document,
+// the documents pending in the WAL are read from the WAL.
+func (s *IndexShard) currentDocument(docID string) (map[string]interface{}, docVersion, bool, error) {
+	s.write.Lock()
+	defer s.write.Unlock()
+
+	if s.versions == nil {
+		if err := s.loadSeqNo(); err != nil {
+			return nil, docVersion{}, false, err
+		}
+	}
+
+	if v, ok := s.versions[docID]; ok {
+		if v.deleted {
+			return nil, v, false, nil
+		}
+		entry, err := s.wal.Read(v.walID)
+		if err != nil {
+			return nil, v, false, err
+		}
+		doc := make(map[string]interface{})
+		if err = json.Unmarshal(entry, &doc); err != nil {
+			return nil, v, false, err
+		}
+		source, _ := doc[meta.SourceFieldName].(map[string]interface{})
+		return source, v, true, nil
+	}
+
+	hit, err := s.FindDocumentByDocID(docID)
+	if err != nil {
+		if err == errors.ErrorIDNotFound {
+			return nil, docVersion{}, false, nil
+		}
+		return nil, docVersion{}, false, err
+	}
+	source, _ := hit.Source.(map[string]interface{})
+	return source, docVersion{seqNo: *hit.SeqNo, version: *hit.Version}, true, nil
+}
+
+// findVersionByDocID finds docID in the index and returns its version
+func (s *IndexShard) findVersionByDocID(docID string) (docVersion, error) {
+	writers, err := s.GetWriters()
+	if err != nil {
+		return docVersion{}, err
+	}
+	request := bluge.NewTopNSearch(1, bluge.NewTermQuery(docID).SetField("_id"))
+	for id := len(writers) - 1; id >= 0; id-- {
+		r, err := writers[id].Reader()
+		if err != nil {
+			return docVersion{}, err
+		}
+		dmi, err := r.Search(context.Background(), request)
+		if err != nil {
+			_ = r.Close()
+			return docVersion{}, err
+		}
+		next, err := dmi.Next()
+		if err == nil && next != nil {
+			v := docVersion{version: 1}
+			err = next.VisitStoredFields(func(field string, value []byte) bool {
+				switch field {
+				case "_seq_no":
+					n, _ := bluge.DecodeNumericFloat64(value)
+					v.seqNo = int64(n)
+				case "_version":
+					n, _ := bluge.DecodeNumericFloat64(value)
+					v.version = int64(n)
+				}
+				return true
+			})
+			_ = r.Close()
+			return v, err
+		}
+		_ = r.Close()
+		if err != nil {
+			return docVersion{}, err
+		}
+	}
+	return docVersion{}, errors.ErrorIDNotFound
+}
+
+// pendingVersion returns the version of the document if it is still pending in the WAL
+func (s *IndexShard) pendingVersion(docID string) (docVersion, bool) {
+	s.write.Lock()
+	defer s.write.Unlock()
+	v, ok := s.versions[docID]
+	return v, ok
+}
+
+// findShardForWrite is FindShardByDocID taking the documents pending in the WAL into account
+func (s *IndexShard) findShardForWrite(docID string) (int64, error) {
+	v, pending := s.pendingVersion(docID)
+	if pending && v.deleted {
+		return -1, errors.ErrorIDNotFound
+	}
+	shardID, err := s.FindShardByDocID(docID)
+	if err == errors.ErrorIDNotFound && pending {
+		// not in the index yet, replace it wherever it goes
+		return ShardIDNeedUpdate, nil
+	}
+	return shardID, err
+}
+
+// releaseVersion forgets the pending version of the document once it is written to the index,
+// unless the document was written again in the meantime.
+func (s *IndexShard) releaseVersion(docID string, seqNo int64) {
+	s.write.Lock()
+	defer s.write.Unlock()
+	if v, ok := s.versions[docID]; ok && v.seqNo == seqNo {
+		delete(s.versions, docID)
+	}
+}
+
+// loadSeqNo restores the last assigned sequence number from the WAL and the index,
+// and the versions of the documents not consumed from the WAL yet.
+func (s *IndexShard) loadSeqNo() error {
+	// read the WAL first, the entries consumed meanwhile are found in the index then
+	var seqNo int64
+	versions := make(map[string]docVersion)
+	maxID, err := s.wal.LastIndex()
+	if err != nil {
+		return err
+	}
+	_, minID, err := s.readRedoLog(RedoActionWrite)
+	if err != nil && err.Error() != errors.ErrNotFound.Error() {
+		return err
+	}
+	for id := minID + 1; id <= maxID; id++ {
+		entry, err := s.wal.Read(id)
+		if err != nil {
+			return err
+		}
+		doc := make(map[string]interface{})
+		if err = json.Unmarshal(entry, &doc); err != nil {
+			return err
+		}
+		v := docVersion{version: 1, deleted: doc[meta.ActionFieldName] == meta.ActionTypeDelete, walID: id}
+		if n, ok := doc[meta.SeqNoFieldName].(float64); ok {
+			v.seqNo = int64(n)
+		}
+		if n, ok := doc[meta.VersionFieldName].(float64); ok {
+			v.version = int64(n)
+		}
+		if v.seqNo > seqNo {
+			seqNo = v.seqNo
+		}
+		if docID, ok := doc[meta.IDFieldName].(string); ok {
+			versions[docID] = v
+		}
+	}
+
+	writers, err := s.GetWriters()
+	if err != nil {
+		return err
+	}
+	for _, w := range writers {
+		r, err := w.Reader()
+		if err != nil {
+			return err
+		}
+		request := bluge.NewTopNSearch(0, bluge.NewMatchAllQuery())
+		request.AddAggregation("seq_no", aggregations.MaxStartingAt(search.Field("_seq_no"), 0))
+		dmi, err := r.Search(context.Background(), request)
+		if err != nil {
+			_ = r.Close()
+			return err
+		}
+		if v := int64(dmi.Aggregations().Metric("seq_no")); v > seqNo {
+			seqNo = v
+		}
+		_ = r.Close()
+	}
+
+	s.seqNo = seqNo
+	s.versions = versions
+	return nil
+}
@@ -19,12 +19,12 @@ import (
 	"fmt"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/blugelabs/bluge"
 	blugeindex "github.com/blugelabs/bluge/index"
 	"github.com/rs/zerolog/log"
 
-	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/wal"
@@ -138,6 +138,12 @@ func (s *IndexShard) Rollback() error {
 
 // ConsumeWAL consume WAL for index returns if there is any data updated
 func (s *IndexShard) ConsumeWAL() bool {
+	s.consume.Lock()
+	defer s.consume.Unlock()
+	if s.wal == nil {
+		return false // shard closed
+	}
+
 	if err := s.wal.Sync(); err != nil {
 		log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Msg("consume wal.Sync()")
 	}
@@ -166,8 +172,10 @@ func (s *IndexShard) ConsumeWAL() bool {
 		maxID = minID + MaxBatchSize
 	}
 
+	opts := s.root.GetIndexingOptions()
 	batch := blugeindex.NewBatch()
 	docs := make(walMergeDocs)
+	batchBytes := 0
 	minID++
 	for startID = minID; minID <= maxID; minID++ {
 		entry, err = s.wal.Read(minID)
@@ -183,12 +191,13 @@ func (s *IndexShard) ConsumeWAL() bool {
 			return false
 		}
 		docs.AddDocument(doc)
-		if docs.MaxShardLen() >= config.Global.BatchSize {
+		batchBytes += len(entry)
+		if docs.MaxShardLen() >= opts.BatchSize || (opts.BatchBytes > 0 && batchBytes >= opts.BatchBytes) {
 			if err = s.writeRedoLog(RedoActionRead, startID, minID); err != nil {
 				log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Str("stage", "read").Msg("consume wal.redolog.Write()")
 				return false
 			}
-			if err = docs.WriteTo(s, batch, false); err != nil {
+			if err = s.writeBatch(docs, batch); err != nil {
 				log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Msg("consume wal.docs.WriteTo()")
 				return false
 			}
@@ -198,6 +207,7 @@ func (s *IndexShard) ConsumeWAL() bool {
 			}
 			// Reset startID to nextID
 			startID = minID + 1
+			batchBytes = 0
 		}
 	}
 
@@ -209,7 +219,7 @@ func (s *IndexShard) ConsumeWAL() bool {
 			log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Str("stage", "read").Msg("consume wal.redolog.Write()")
 			return false
 		}
-		if err := docs.WriteTo(s, batch, false); err != nil {
+		if err := s.writeBatch(docs, batch); err != nil {
 			log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Msg("consume wal.docs.WriteTo()")
 			return false
 		}
@@ -237,6 +247,16 @@ func (s *IndexShard) ConsumeWAL() bool {
 	return true
 }
 
+// writeBatch writes the documents read from WAL into the index and observes the duration
+func (s *IndexShard) writeBatch(docs walMergeDocs, batch *blugeindex.Batch) error {
+	start := time.Now()
+	if err := docs.WriteTo(s, batch, false); err != nil {
+		return err
+	}
+	ObserveMetricWALFlush(s.GetIndexName(), time.Since(start))
+	return nil
+}
+
 const (
 	RedoActionRead     = uint64(1)
 	RedoActionWrite    = uint64(2)
@@ -278,8 +298,30 @@ func (s *IndexShard) GetWALSize() (uint64, error) {
 	return w.Len()
 }
 
+// GetWALPending returns the number of WAL entries not written to the index yet
+func (s *IndexShard) GetWALPending() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.wal == nil {
+		return 0
+	}
+	maxID, err := s.wal.LastIndex()
+	if err != nil {
+		return 0
+	}
+	_, minID, err := s.readRedoLog(RedoActionWrite)
+	if err != nil && err.Error() != errors.ErrNotFound.Error() {
+		return 0
+	}
+	if maxID < minID {
+		return 0
+	}
+	return maxID - minID
+}
+
 type walDocument struct {
 	docID   string
+	seqNo   int64
 	actions []string
 	data    map[string]interface{}
 }
@@ -320,6 +362,9 @@ func (w *walMergeDocs) AddDocument(data map[string]interface{}) {
 	}
 	doc.actions = append(doc.actions, action)
 	doc.data = data
+	if seqNo, ok := data[meta.SeqNoFieldName].(float64); ok {
+		doc.seqNo = int64(seqNo)
+	}
 }
 
 // WriteTo write documents to index and sync to disk
@@ -338,6 +383,12 @@ func (w *walMergeDocs) WriteTo(shard *IndexShard, batch *blugeindex.Batch, rollb
 		}
 		batch.Reset()
 	}
+	// the documents can be found in the index now
+	for _, docs := range *w {
+		for _, doc := range docs {
+			shard.releaseVersion(doc.docID, doc.seqNo)
+		}
+	}
 	w.Reset()
 	return nil
 }
@@ -369,6 +420,7 @@ func (w *walMergeDocs) WriteToShard(shard *IndexShard, shardID int64, batch *blu
 		otherWriters = otherWriters[:len(ws)-1]
 	}
 	var firstAction, lastAction string
+	nestedPaths := shard.root.GetMappings().NestedPaths()
 	for _, doc := range docs {
 		// str, err := json.Marshal(doc.data)
 		// fmt.Printf("%s, %v, %v\n", str, err, doc.actions)
@@ -377,6 +429,22 @@ func (w *walMergeDocs) WriteToShard(shard *IndexShard, shardID int64, batch *blu
 			return err
 		}
 		firstAction = doc.actions[0]
+		if len(nestedPaths) > 0 {
+			// nested documents are always replaced together with the parent document
+			if firstAction != meta.ActionTypeInsert {
+				batch.Delete(nestedParentID(doc.docID))
+				otherBatch.Delete(nestedParentID(doc.docID))
+			}
+			if doc.actions[len(doc.actions)-1] != meta.ActionTypeDelete {
+				nestedDocs, err := shard.BuildNestedDocuments(doc.docID, doc.data, bdoc.Timestamp(), nestedPaths)
+				if err != nil {
+					return err
+				}
+				for _, nestedDoc := range nestedDocs {
+					batch.Insert(nestedDoc)
+				}
+			}
+		}
 		switch firstAction {
 		case meta.ActionTypeInsert:
 			if len(doc.actions) == 1 {
@@ -469,6 +537,7 @@ func (w *walMergeDocs) WriteToShardRollback(shard *IndexShard, shardID int64, ba
 		switch firstAction {
 		case meta.ActionTypeInsert:
 			batch.Delete(bdoc.ID())
+			batch.Delete(nestedParentID(doc.docID))
 		case meta.ActionTypeUpdate:
 			// skip
 		case meta.ActionTypeDelete:
@@ -87,6 +87,10 @@ func (t *IndexShardWALList) ConsumeWAL() {
 				default:
 					// continue
 				}
+				if !shard.refreshDue(time.Now()) {
+					shard.SyncWAL()
+					return nil
+				}
 				updated := shard.ConsumeWAL()
 				if updated {
 					indexUpdated <- shard.GetIndexName()
@@ -0,0 +1,95 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// CloseIndex closes the index, the data is kept on disk but the writers and
+// readers are released, the index rejects reads and writes until it is opened
+func CloseIndex(name string) error {
+	index, exists := GetIndex(name)
+	if !exists {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "index "+name+" does not exists")
+	}
+
+	// wait for the writes in flight, the writes after it see the index is closed
+	index.writing.Lock()
+	if index.IsClosed() {
+		index.writing.Unlock()
+		return nil
+	}
+	// persist buffered documents before release the writers
+	if err := index.Flush(); err != nil {
+		index.writing.Unlock()
+		return err
+	}
+	index.closed.Store(true)
+	index.writing.Unlock()
+
+	if err := index.Close(); err != nil {
+		return err
+	}
+	return index.setState(meta.IndexStateClose)
+}
+
+// OpenIndex opens a closed index and reloads its writers
+func OpenIndex(name string) error {
+	index, exists := GetIndex(name)
+	if !exists {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "index "+name+" does not exists")
+	}
+	if !index.IsClosed() {
+		return nil
+	}
+
+	index.closed.Store(false)
+	for _, shard := range index.shards {
+		if err := shard.OpenWAL(); err != nil {
+			return err
+		}
+	}
+	return index.setState(meta.IndexStateOpen)
+}
+
+// IsClosed returns if the index is closed
+func (index *Index) IsClosed() bool {
+	return index.closed.Load()
+}
+
+// GetState returns the state of the index, open or close
+func (index *Index) GetState() string {
+	if index.IsClosed() {
+		return meta.IndexStateClose
+	}
+	return meta.IndexStateOpen
+}
+
+func (index *Index) setState(state string) error {
+	index.lock.Lock()
+	index.ref.State = state
+	index.lock.Unlock()
+	return storeIndex(index)
+}
+
+// checkOpen returns an error if the index is closed
+func (index *Index) checkOpen() error {
+	if index.IsClosed() {
+		return errors.New(errors.ErrorTypeIndexClosedException, "index ["+index.GetName()+"] is closed")
+	}
+	return nil
+}
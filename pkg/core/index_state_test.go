@@ -0,0 +1,113 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_OpenClose(t *testing.T) {
+	indexName := "TestIndex_OpenClose.index_1"
+	query := &meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100}
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 10; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, meta.IndexStateOpen, index.GetIndex().State)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		assert.Error(t, CloseIndex("TestIndex_OpenClose.notExist"))
+		assert.NoError(t, CloseIndex(indexName))
+		assert.True(t, index.IsClosed())
+		assert.Equal(t, meta.IndexStateClose, index.GetState())
+		assert.Equal(t, meta.IndexStateClose, index.GetIndex().State)
+		// close twice is ok
+		assert.NoError(t, CloseIndex(indexName))
+
+		// reads and writes are rejected
+		err := index.CreateDocument("10", map[string]interface{}{"name": "doc10"}, false)
+		var e *errors.Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, errors.ErrorTypeIndexClosedException, e.Type)
+		_, err = index.GetDocument("1")
+		assert.Error(t, err)
+		_, err = index.Search(query)
+		assert.Error(t, err)
+		_, err = MultiSearch([]string{indexName}, query)
+		assert.Error(t, err)
+		// wildcard search skips the closed index
+		_, err = MultiSearch([]string{"TestIndex_OpenClose.*"}, query)
+		assert.NoError(t, err)
+
+		// stats are kept in metadata
+		assert.Equal(t, uint64(10), index.Stats("docs").Docs.Count)
+	})
+
+	t.Run("open", func(t *testing.T) {
+		assert.Error(t, OpenIndex("TestIndex_OpenClose.notExist"))
+		assert.NoError(t, OpenIndex(indexName))
+		assert.False(t, index.IsClosed())
+		assert.Equal(t, meta.IndexStateOpen, index.GetIndex().State)
+
+		got, err := index.Search(query)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+		assert.NoError(t, index.CreateDocument("10", map[string]interface{}{"name": "doc10"}, false))
+	})
+
+	t.Run("close with writes in flight", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					err := index.CreateDocument(strconv.Itoa(i*100+j), map[string]interface{}{"name": "doc"}, false)
+					if err == nil {
+						continue
+					}
+					// a write either succeeds or sees the index is closed
+					var e *errors.Error
+					assert.True(t, errors.As(err, &e), err.Error())
+					if e != nil {
+						assert.Equal(t, errors.ErrorTypeIndexClosedException, e.Type)
+					}
+					return
+				}
+			}(i)
+		}
+		assert.NoError(t, CloseIndex(indexName))
+		wg.Wait()
+		assert.NoError(t, OpenIndex(indexName))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
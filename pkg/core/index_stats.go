@@ -0,0 +1,112 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	zincsearch "github.com/zincsearch/zincsearch/pkg/bluge/search"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// IndexStatsMetrics the metrics supported by the `_stats` API
+var IndexStatsMetrics = []string{"docs", "store", "indexing", "search", "segments"}
+
+// indexCounters counts the operations since the index was loaded,
+// they live in memory only and restart from zero after a restart.
+type indexCounters struct {
+	indexTotal        atomic.Uint64
+	deleteTotal       atomic.Uint64
+	queryTotal        atomic.Uint64
+	queryTimeInMillis atomic.Uint64
+}
+
+func (index *Index) incrIndexing() {
+	index.counters.indexTotal.Add(1)
+}
+
+func (index *Index) incrDeleting() {
+	index.counters.deleteTotal.Add(1)
+}
+
+func (index *Index) incrSearch(took time.Duration) {
+	index.counters.queryTotal.Add(1)
+	index.counters.queryTimeInMillis.Add(uint64(took.Milliseconds()))
+	ObserveMetricSearchDuration(index.GetName(), took)
+}
+
+// Stats returns the given metrics of the index, all metrics if metrics is empty
+func (index *Index) Stats(metrics ...string) *meta.IndexStats {
+	if len(metrics) == 0 {
+		metrics = IndexStatsMetrics
+	}
+	stats := meta.NewIndexStats(metrics)
+	if stats.Indexing != nil {
+		stats.Indexing.IndexTotal = index.counters.indexTotal.Load()
+		stats.Indexing.DeleteTotal = index.counters.deleteTotal.Load()
+	}
+	if stats.Search != nil {
+		stats.Search.QueryTotal = index.counters.queryTotal.Load()
+		stats.Search.QueryTimeInMillis = index.counters.queryTimeInMillis.Load()
+	}
+	if stats.Docs == nil && stats.Store == nil && stats.Segments == nil {
+		return stats
+	}
+
+	for _, shard := range index.shards {
+		shard.lock.RLock()
+		secondShards := shard.shards
+		shard.lock.RUnlock()
+		for _, secondShard := range secondShards {
+			secondShard.lock.RLock()
+			w := secondShard.writer
+			secondShard.lock.RUnlock()
+			// closed shards report the stats saved in metadata
+			if w == nil {
+				if stats.Docs != nil {
+					stats.Docs.Count += atomic.LoadUint64(&secondShard.ref.Stats.DocNum)
+				}
+				if stats.Store != nil {
+					stats.Store.SizeInBytes += atomic.LoadUint64(&secondShard.ref.Stats.StorageSize)
+				}
+				continue
+			}
+			if stats.Docs != nil || stats.Segments != nil {
+				// the counts are read from a snapshot, the status of the writer is updated without synchronization
+				if r, err := w.Reader(); err == nil {
+					if stats.Docs != nil {
+						if n, err := r.Count(); err == nil {
+							stats.Docs.Count += n
+						}
+					}
+					if stats.Segments != nil {
+						if n, err := zincsearch.Segments(r); err == nil {
+							stats.Segments.Count += uint64(n)
+						}
+					}
+					_ = r.Close()
+				}
+			}
+			if stats.Store != nil {
+				_, size := w.DirectoryStats()
+				stats.Store.SizeInBytes += size
+			}
+		}
+	}
+
+	return stats
+}
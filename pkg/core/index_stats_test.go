@@ -0,0 +1,61 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_Stats(t *testing.T) {
+	indexName := "TestIndex_Stats.index_1"
+	index, err := NewIndex(indexName, "disk", 2)
+	assert.NoError(t, err)
+	err = StoreIndex(index)
+	assert.NoError(t, err)
+
+	for _, id := range []string{"1", "2", "3"} {
+		err = index.CreateDocument(id, map[string]interface{}{"name": "zinc " + id}, false)
+		assert.NoError(t, err)
+	}
+	// wait for WAL write to index
+	time.Sleep(time.Second)
+	err = index.DeleteDocument("3")
+	assert.NoError(t, err)
+	time.Sleep(time.Second)
+
+	_, err = index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 10})
+	assert.NoError(t, err)
+
+	stats := index.Stats()
+	assert.Equal(t, uint64(2), stats.Docs.Count)
+	assert.Greater(t, stats.Store.SizeInBytes, uint64(0))
+	assert.Equal(t, uint64(3), stats.Indexing.IndexTotal)
+	assert.Equal(t, uint64(1), stats.Indexing.DeleteTotal)
+	assert.Equal(t, uint64(1), stats.Search.QueryTotal)
+	assert.Greater(t, stats.Segments.Count, uint64(0))
+
+	stats = index.Stats("search")
+	assert.Nil(t, stats.Docs)
+	assert.NotNil(t, stats.Search)
+
+	err = DeleteIndex(indexName)
+	assert.NoError(t, err)
+}
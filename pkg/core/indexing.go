@@ -0,0 +1,88 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// IndexingOptions are the indexing settings of the index resolved with the global config
+type IndexingOptions struct {
+	BatchSize  int // documents of a shard written from WAL at once
+	BatchBytes int // WAL bytes written at once, 0 is unlimited
+	Workers    int // analysis goroutines of every shard writer
+	BufferSize int // merge buffer of every shard writer
+}
+
+// parseIndexingOptions returns the options of the indexing settings, the missing settings are the global config
+func parseIndexingOptions(indexing *meta.IndexIndexing) (IndexingOptions, error) {
+	opts := IndexingOptions{
+		BatchSize:  config.Global.BatchSize,
+		BatchBytes: config.Global.BatchMaxBytes,
+		Workers:    config.Global.Shard.IndexingWorkers,
+		BufferSize: config.Global.Shard.WriterBufferSize,
+	}
+	if indexing == nil {
+		return opts, nil
+	}
+	if indexing.BatchSize < 0 {
+		return opts, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("invalid index.indexing.batch_size [%d]", indexing.BatchSize))
+	}
+	if indexing.BatchSize > 0 {
+		opts.BatchSize = indexing.BatchSize
+	}
+	if indexing.Workers < 0 {
+		return opts, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("invalid index.indexing.workers [%d]", indexing.Workers))
+	}
+	if indexing.Workers > 0 {
+		opts.Workers = indexing.Workers
+	}
+	if indexing.BatchBytes != "" {
+		v, err := zutils.ParseByteSize(indexing.BatchBytes)
+		if err != nil {
+			return opts, errors.New(errors.ErrorTypeIllegalArgumentException, "invalid index.indexing.batch_bytes ["+indexing.BatchBytes+"]")
+		}
+		opts.BatchBytes = int(v)
+	}
+	if indexing.BufferSize != "" {
+		v, err := zutils.ParseByteSize(indexing.BufferSize)
+		if err != nil || v == 0 {
+			return opts, errors.New(errors.ErrorTypeIllegalArgumentException, "invalid index.indexing.buffer_size ["+indexing.BufferSize+"]")
+		}
+		opts.BufferSize = int(v)
+	}
+	return opts, nil
+}
+
+// GetIndexingOptions returns the indexing options of the index
+func (index *Index) GetIndexingOptions() IndexingOptions {
+	index.lock.RLock()
+	var indexing *meta.IndexIndexing
+	if index.ref.Settings != nil {
+		indexing = index.ref.Settings.Indexing
+	}
+	index.lock.RUnlock()
+	opts, err := parseIndexingOptions(indexing)
+	if err != nil {
+		opts, _ = parseIndexingOptions(nil)
+	}
+	return opts
+}
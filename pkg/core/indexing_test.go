@@ -0,0 +1,114 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestParseIndexingOptions(t *testing.T) {
+	defaults := IndexingOptions{
+		BatchSize:  config.Global.BatchSize,
+		BatchBytes: config.Global.BatchMaxBytes,
+		Workers:    config.Global.Shard.IndexingWorkers,
+		BufferSize: config.Global.Shard.WriterBufferSize,
+	}
+	tests := []struct {
+		name     string
+		indexing *meta.IndexIndexing
+		want     IndexingOptions
+		wantErr  bool
+	}{
+		{name: "nil", want: defaults},
+		{name: "empty", indexing: &meta.IndexIndexing{}, want: defaults},
+		{
+			name:     "all",
+			indexing: &meta.IndexIndexing{BatchSize: 100, BatchBytes: "1mb", Workers: 8, BufferSize: "4mb"},
+			want:     IndexingOptions{BatchSize: 100, BatchBytes: 1 << 20, Workers: 8, BufferSize: 4 << 20},
+		},
+		{name: "unlimited batch bytes", indexing: &meta.IndexIndexing{BatchBytes: "0"}, want: IndexingOptions{
+			BatchSize:  defaults.BatchSize,
+			BatchBytes: 0,
+			Workers:    defaults.Workers,
+			BufferSize: defaults.BufferSize,
+		}},
+		{name: "negative batch size", indexing: &meta.IndexIndexing{BatchSize: -1}, wantErr: true},
+		{name: "negative workers", indexing: &meta.IndexIndexing{Workers: -1}, wantErr: true},
+		{name: "invalid batch bytes", indexing: &meta.IndexIndexing{BatchBytes: "abc"}, wantErr: true},
+		{name: "zero buffer size", indexing: &meta.IndexIndexing{BufferSize: "0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIndexingOptions(tt.indexing)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndex_Indexing(t *testing.T) {
+	indexName := "TestIndex_Indexing.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{
+			Indexing: &meta.IndexIndexing{BatchSize: 3, BatchBytes: "100b", Workers: 1},
+		}))
+		assert.NoError(t, StoreIndex(index))
+		opts := index.GetIndexingOptions()
+		assert.Equal(t, 3, opts.BatchSize)
+		assert.Equal(t, 100, opts.BatchBytes)
+		assert.Equal(t, 1, opts.Workers)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		flushes := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "wal_flush_duration_seconds"}, []string{"index"})
+		ZINC_WAL_FLUSH_METRICS.MetricCollector = flushes
+		defer func() { ZINC_WAL_FLUSH_METRICS.MetricCollector = nil }()
+
+		for i := 0; i < 10; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, index.Flush())
+		got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+
+		// every batch is written with its own flush
+		pb := &dto.Metric{}
+		assert.NoError(t, flushes.WithLabelValues(indexName).(prometheus.Histogram).Write(pb))
+		assert.GreaterOrEqual(t, pb.GetHistogram().GetSampleCount(), uint64(4))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
@@ -17,6 +17,7 @@ package core
 
 import (
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
@@ -25,6 +26,7 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 var ZINC_INDEX_LIST IndexList
@@ -62,6 +64,18 @@ func init() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error loading alias")
 	}
+	ZINC_INDEX_ALIAS_LIST.WriteIndexes, err = metadata.Alias.GetWriteIndexes()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error loading alias write index")
+	}
+	ZINC_INDEX_ALIAS_LIST.Options, err = metadata.Alias.GetOptions()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error loading alias options")
+	}
+
+	if err = ZINC_DATA_STREAM_LIST.load(); err != nil {
+		log.Fatal().Err(err).Msg("Error loading data streams")
+	}
 }
 
 func (t *IndexList) Add(index *Index) {
@@ -144,6 +158,65 @@ func (t *IndexList) ListMap() map[string]*Index {
 	return indexes
 }
 
+// ListMatch returns the indexes matching any of the names,
+// a name can start or end with `*`, no names match all indexes
+func (t *IndexList) ListMatch(names []string) []*Index {
+	items := t.List()
+	if len(names) == 0 {
+		return items
+	}
+	indexes := make([]*Index, 0, len(items))
+	for _, index := range items {
+		for _, name := range names {
+			if isMatchIndex(index.GetName(), name) {
+				indexes = append(indexes, index)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// ResolveIndexName returns the names of the indexes matching the wildcard name,
+// pointed by the alias or backing the data stream, nil when the name is none of them
+func ResolveIndexName(name string) []string {
+	if strings.Contains(name, "*") {
+		indexes := ZINC_INDEX_LIST.ListMatch([]string{name})
+		names := make([]string, 0, len(indexes))
+		for _, index := range indexes {
+			names = append(names, index.GetName())
+		}
+		return names
+	}
+	if indexes, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(name); ok {
+		return indexes
+	}
+	if indexes, ok := ZINC_DATA_STREAM_LIST.GetIndexes(name); ok {
+		return indexes
+	}
+	return nil
+}
+
+// ResolveIndexNames replaces the aliases and the data streams in the names with their indexes,
+// the duplicates are removed and the wildcards are kept for the callers to match
+func ResolveIndexNames(names []string) []string {
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		indexes, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(name)
+		if !ok {
+			if indexes, ok = ZINC_DATA_STREAM_LIST.GetIndexes(name); !ok {
+				indexes = []string{name}
+			}
+		}
+		for _, index := range indexes {
+			if !zutils.SliceExists(resolved, index) {
+				resolved = append(resolved, index)
+			}
+		}
+	}
+	return resolved
+}
+
 func (t *IndexList) ListStat() []*Index {
 	items := t.List()
 	return items
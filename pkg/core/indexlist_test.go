@@ -41,9 +41,26 @@ func TestIndexList_List(t *testing.T) {
 	got3 := ZINC_INDEX_LIST.ListStat()
 	assert.NotNil(t, got3)
 
+	got4 := ZINC_INDEX_LIST.ListMatch([]string{"TestIndexList_List.*"})
+	assert.Len(t, got4, 1)
+	assert.Len(t, ZINC_INDEX_LIST.ListMatch([]string{"TestIndexList_*.index_1"}), 1)
+	assert.Empty(t, ZINC_INDEX_LIST.ListMatch([]string{"TestIndexList_List.index_2"}))
+
 	err = DeleteIndex(indexName)
 	assert.NoError(t, err)
 
 	err = ZINC_INDEX_LIST.GC()
 	assert.NoError(t, err)
 }
+
+func TestResolveIndexNames(t *testing.T) {
+	alias := "TestResolveIndexNames.alias"
+	assert.NoError(t, ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{"index_1", "index_2"}))
+	defer func() {
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{"index_1", "index_2"}))
+	}()
+
+	assert.Equal(t, []string{"index_1", "index_2", "logs-*"}, ResolveIndexNames([]string{alias, "index_1", "logs-*"}))
+	assert.Equal(t, []string{"index_3"}, ResolveIndexNames([]string{"index_3"}))
+	assert.Empty(t, ResolveIndexNames(nil))
+}
@@ -0,0 +1,127 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// ParseIndicesOptions reads the ignore_unavailable, allow_no_indices and expand_wildcards parameters
+func ParseIndicesOptions(params url.Values) (*meta.IndicesOptions, error) {
+	opts := meta.NewIndicesOptions()
+	var err error
+	if v := params.Get("ignore_unavailable"); v != "" {
+		if opts.IgnoreUnavailable, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[ignore_unavailable] should be a boolean")
+		}
+	}
+	if v := params.Get("allow_no_indices"); v != "" {
+		if opts.AllowNoIndices, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[allow_no_indices] should be a boolean")
+		}
+	}
+	if v := params.Get("expand_wildcards"); v != "" {
+		if err = SetExpandWildcards(opts, v); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// SetExpandWildcards sets the indexes the wildcards match from a comma separated list of
+// open, closed, hidden, all and none. There are no hidden indexes, hidden matches nothing more.
+func SetExpandWildcards(opts *meta.IndicesOptions, value string) error {
+	opts.ExpandOpen = false
+	opts.ExpandClosed = false
+	for _, v := range strings.Split(value, ",") {
+		switch strings.TrimSpace(v) {
+		case "open":
+			opts.ExpandOpen = true
+		case "closed":
+			opts.ExpandClosed = true
+		case "all":
+			opts.ExpandOpen = true
+			opts.ExpandClosed = true
+		case "hidden", "none":
+		default:
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("No enum constant [%s] of [expand_wildcards]", v))
+		}
+	}
+	return nil
+}
+
+// ResolveIndices expands the names to the names of the indexes honoring the options,
+// no names or `_all` match every index. The missing indexes named explicitly are kept
+// for the callers to report unless they are ignored.
+func ResolveIndices(names []string, opts *meta.IndicesOptions) ([]string, error) {
+	if opts == nil {
+		opts = meta.NewIndicesOptions()
+	}
+	if len(names) == 0 || (len(names) == 1 && (names[0] == "" || names[0] == "_all")) {
+		names = []string{"*"}
+	}
+
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		if !strings.Contains(name, "*") {
+			index, ok := GetIndex(name)
+			if opts.IgnoreUnavailable && (!ok || index.IsClosed()) {
+				continue
+			}
+			if !zutils.SliceExists(resolved, name) {
+				resolved = append(resolved, name)
+			}
+			continue
+		}
+		matched := false
+		indexes := ZINC_INDEX_LIST.ListMatch([]string{name})
+		// the wildcards matching a data stream match its backing indexes
+		for _, stream := range ZINC_DATA_STREAM_LIST.ListMatch([]string{name}) {
+			for _, indexName := range stream.IndexNames() {
+				if index, ok := GetIndex(indexName); ok {
+					indexes = append(indexes, index)
+				}
+			}
+		}
+		for _, index := range indexes {
+			expand := opts.ExpandOpen
+			if index.IsClosed() {
+				expand = opts.ExpandClosed
+			}
+			if !expand {
+				continue
+			}
+			matched = true
+			if !zutils.SliceExists(resolved, index.GetName()) {
+				resolved = append(resolved, index.GetName())
+			}
+		}
+		if !matched && !opts.AllowNoIndices {
+			return nil, errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", name))
+		}
+	}
+	if len(resolved) == 0 && !opts.AllowNoIndices {
+		return nil, errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", strings.Join(names, ",")))
+	}
+	return resolved, nil
+}
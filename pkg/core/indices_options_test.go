@@ -0,0 +1,125 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestParseIndicesOptions(t *testing.T) {
+	opts, err := ParseIndicesOptions(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, meta.NewIndicesOptions(), opts)
+
+	opts, err = ParseIndicesOptions(url.Values{
+		"ignore_unavailable": {"true"},
+		"allow_no_indices":   {"false"},
+		"expand_wildcards":   {"closed,hidden"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &meta.IndicesOptions{IgnoreUnavailable: true, ExpandClosed: true}, opts)
+
+	opts, err = ParseIndicesOptions(url.Values{"expand_wildcards": {"all"}})
+	assert.NoError(t, err)
+	assert.True(t, opts.ExpandOpen)
+	assert.True(t, opts.ExpandClosed)
+
+	_, err = ParseIndicesOptions(url.Values{"allow_no_indices": {"x"}})
+	assert.Error(t, err)
+	_, err = ParseIndicesOptions(url.Values{"expand_wildcards": {"open,x"}})
+	assert.Error(t, err)
+}
+
+func TestResolveIndices(t *testing.T) {
+	indexNames := []string{"TestResolveIndices.index_1", "TestResolveIndices.index_2"}
+	t.Run("prepare", func(t *testing.T) {
+		for _, name := range indexNames {
+			index, err := NewIndex(name, "disk", 1)
+			assert.NoError(t, err)
+			assert.NoError(t, StoreIndex(index))
+		}
+		assert.NoError(t, CloseIndex(indexNames[1]))
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		names, err := ResolveIndices([]string{"TestResolveIndices.*"}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, indexNames[:1], names)
+
+		// the names without wildcard are kept for the callers to report
+		names, err = ResolveIndices([]string{indexNames[1], "TestResolveIndices.notExist", indexNames[1]}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{indexNames[1], "TestResolveIndices.notExist"}, names)
+
+		names, err = ResolveIndices([]string{"TestResolveIndices.notExist*"}, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+
+		names, err = ResolveIndices(nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, names, indexNames[0])
+		assert.NotContains(t, names, indexNames[1])
+	})
+
+	t.Run("expand wildcards", func(t *testing.T) {
+		opts := meta.NewIndicesOptions()
+		assert.NoError(t, SetExpandWildcards(opts, "closed"))
+		names, err := ResolveIndices([]string{"TestResolveIndices.*"}, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, indexNames[1:], names)
+
+		assert.NoError(t, SetExpandWildcards(opts, "open,closed"))
+		names, err = ResolveIndices([]string{"TestResolveIndices.*"}, opts)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, indexNames, names)
+
+		assert.NoError(t, SetExpandWildcards(opts, "none"))
+		names, err = ResolveIndices([]string{"TestResolveIndices.*"}, opts)
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+	})
+
+	t.Run("ignore unavailable", func(t *testing.T) {
+		opts := &meta.IndicesOptions{IgnoreUnavailable: true, AllowNoIndices: true, ExpandOpen: true}
+		names, err := ResolveIndices(append([]string{"TestResolveIndices.notExist"}, indexNames...), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, indexNames[:1], names)
+	})
+
+	t.Run("disallow no indices", func(t *testing.T) {
+		opts := &meta.IndicesOptions{ExpandOpen: true}
+		_, err := ResolveIndices([]string{indexNames[0], "TestResolveIndices.notExist*"}, opts)
+		var e *errors.Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, errors.ErrorTypeIndexNotFoundException, e.Type)
+
+		opts.IgnoreUnavailable = true
+		_, err = ResolveIndices([]string{indexNames[1]}, opts)
+		assert.Error(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		for _, name := range indexNames {
+			assert.NoError(t, DeleteIndex(name))
+		}
+	})
+}
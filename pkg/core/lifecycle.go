@@ -0,0 +1,269 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func init() {
+	go runLifecyclePolicies()
+}
+
+// runLifecyclePolicies applies the lifecycle policies to the indexes periodically
+func runLifecyclePolicies() {
+	if config.Global.LifecyclePollInterval <= 0 {
+		return
+	}
+	tick := time.NewTicker(config.Global.LifecyclePollInterval)
+	for range tick.C {
+		ApplyLifecyclePolicies(time.Now())
+	}
+}
+
+// PutLifecyclePolicy validates and stores the lifecycle policy, it replaces the policy with the same name
+// and the indexes using it follow the new policy from the next run
+func PutLifecyclePolicy(name string, req *meta.LifecyclePolicyRequest) error {
+	if name == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[policy] name should be not empty")
+	}
+	if req == nil {
+		return errors.New(errors.ErrorTypeParsingException, "[policy] required property is missing")
+	}
+	if err := validateLifecyclePolicy(&req.Policy); err != nil {
+		return err
+	}
+	policy := meta.LifecyclePolicy{
+		Name:         name,
+		Version:      1,
+		ModifiedDate: time.Now(),
+		Policy:       req.Policy,
+	}
+	if old, ok, err := GetLifecyclePolicy(name); err != nil {
+		return err
+	} else if ok {
+		policy.Version = old.Version + 1
+	}
+	return metadata.LifecyclePolicy.Set(name, policy)
+}
+
+// validateLifecyclePolicy returns an error if the policy has an unsupported phase or action, or a bad value
+func validateLifecyclePolicy(policy *meta.LifecycleSpec) error {
+	for name, phase := range policy.Phases {
+		if phase == nil {
+			return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[policy] phase [%s] should be an object", name))
+		}
+		switch name {
+		case meta.LifecyclePhaseHot:
+			if phase.Actions.Delete != nil {
+				return errors.New(errors.ErrorTypeIllegalArgumentException, "[policy] invalid action [delete] defined in phase [hot]")
+			}
+			if c := phase.Actions.Rollover; c != nil {
+				if c.MaxAge == "" && c.MaxDocs == 0 && c.MaxSize == "" {
+					return errors.New(errors.ErrorTypeIllegalArgumentException, "[rollover] must have at least one condition")
+				}
+				if d, err := zutils.ParseDuration(c.MaxAge); c.MaxAge != "" && (err != nil || d <= 0) {
+					return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] failed to parse max_age [%s]", c.MaxAge))
+				}
+				if n, err := zutils.ParseByteSize(c.MaxSize); c.MaxSize != "" && (err != nil || n == 0) {
+					return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] failed to parse max_size [%s]", c.MaxSize))
+				}
+			}
+		case meta.LifecyclePhaseDelete:
+			if phase.Actions.Rollover != nil {
+				return errors.New(errors.ErrorTypeIllegalArgumentException, "[policy] invalid action [rollover] defined in phase [delete]")
+			}
+		default:
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[policy] unsupported phase [%s], the phases are [hot, delete]", name))
+		}
+		if _, err := parseLifecycleMinAge(phase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLifecycleMinAge returns the min_age of the phase, no min_age is 0
+func parseLifecycleMinAge(phase *meta.LifecyclePhase) (time.Duration, error) {
+	if phase.MinAge == "" {
+		return 0, nil
+	}
+	d, err := zutils.ParseDuration(phase.MinAge)
+	if err != nil || d < 0 {
+		return 0, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[policy] failed to parse min_age [%s]", phase.MinAge))
+	}
+	return d, nil
+}
+
+// GetLifecyclePolicy returns the stored lifecycle policy by name
+func GetLifecyclePolicy(name string) (*meta.LifecyclePolicy, bool, error) {
+	policy, err := metadata.LifecyclePolicy.Get(name)
+	if err != nil {
+		if err == errors.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return policy, true, nil
+}
+
+// ListLifecyclePolicies returns all the stored lifecycle policies
+func ListLifecyclePolicies() ([]*meta.LifecyclePolicy, error) {
+	return metadata.LifecyclePolicy.List(0, 0)
+}
+
+// DeleteLifecyclePolicy deletes the stored lifecycle policy, a policy used by indexes can't be deleted
+func DeleteLifecyclePolicy(name string) (bool, error) {
+	if _, ok, err := GetLifecyclePolicy(name); err != nil || !ok {
+		return false, err
+	}
+	var indexes []string
+	for _, index := range ZINC_INDEX_LIST.List() {
+		if index.GetLifecyclePolicy() == name {
+			indexes = append(indexes, index.GetName())
+		}
+	}
+	if len(indexes) > 0 {
+		sort.Strings(indexes)
+		return false, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("Cannot delete policy [%s]. It is in use by one or more indices: [%s]", name, strings.Join(indexes, ", ")))
+	}
+	return true, metadata.LifecyclePolicy.Delete(name)
+}
+
+// GetLifecyclePolicy returns the name of the lifecycle policy of the index, empty for no policy
+func (index *Index) GetLifecyclePolicy() string {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	if index.ref.Settings == nil || index.ref.Settings.Lifecycle == nil {
+		return ""
+	}
+	return index.ref.Settings.Lifecycle.Name
+}
+
+// ApplyLifecyclePolicies runs the lifecycle policy of every index using one at now,
+// the errors are logged and the index is retried by the next run
+func ApplyLifecyclePolicies(now time.Time) {
+	policies := make(map[string]*meta.LifecyclePolicy)
+	for _, index := range ZINC_INDEX_LIST.List() {
+		name := index.GetLifecyclePolicy()
+		if name == "" {
+			continue
+		}
+		policy, ok := policies[name]
+		if !ok {
+			var err error
+			if policy, ok, err = GetLifecyclePolicy(name); err != nil || !ok {
+				log.Error().Err(err).Str("index", index.GetName()).Str("policy", name).Msg("lifecycle policy does not exist")
+				continue
+			}
+			policies[name] = policy
+		}
+		if err := index.applyLifecyclePolicy(policy, now); err != nil {
+			log.Error().Err(err).Str("index", index.GetName()).Str("policy", name).Msg("failed to apply lifecycle policy")
+		}
+	}
+}
+
+// applyLifecyclePolicy moves the index through the phases of the policy. The hot phase rolls over
+// the index taking the writes of its data stream or rollover alias when any of the conditions is met,
+// the delete phase deletes the index once it rolled over, or the index without a rollover,
+// when it is older than the min_age of the phase.
+func (index *Index) applyLifecyclePolicy(policy *meta.LifecyclePolicy, now time.Time) error {
+	ref := index.GetIndex()
+	rolledOverAt := index.GetRolledOverAt()
+	target := index.lifecycleRolloverTarget()
+
+	if hot := policy.Policy.Phases[meta.LifecyclePhaseHot]; hot != nil && hot.Actions.Rollover != nil && target != "" {
+		minAge, err := parseLifecycleMinAge(hot)
+		if err != nil {
+			return err
+		}
+		if now.Sub(ref.CreatedAt) < minAge {
+			return nil
+		}
+		resp, err := Rollover(target, "", &meta.RolloverRequest{Conditions: *hot.Actions.Rollover}, false)
+		if err != nil {
+			return err
+		}
+		if resp.RolledOver {
+			log.Info().Str("index", index.GetName()).Str("new_index", resp.NewIndex).Str("policy", policy.Name).Msg("lifecycle rolled over")
+		}
+		// the delete phase starts from the next run
+		return nil
+	}
+
+	phase := policy.Policy.Phases[meta.LifecyclePhaseDelete]
+	if phase == nil || phase.Actions.Delete == nil || target != "" {
+		return nil
+	}
+	minAge, err := parseLifecycleMinAge(phase)
+	if err != nil {
+		return err
+	}
+	since := ref.CreatedAt
+	if !rolledOverAt.IsZero() {
+		since = rolledOverAt
+	}
+	if now.Sub(since) < minAge {
+		return nil
+	}
+	for _, alias := range ZINC_INDEX_ALIAS_LIST.GetAliasesForIndex(index.GetName()) {
+		if err = ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{index.GetName()}); err != nil {
+			return err
+		}
+	}
+	if err = DeleteIndex(index.GetName()); err != nil {
+		return err
+	}
+	log.Info().Str("index", index.GetName()).Str("policy", policy.Name).Msg("lifecycle deleted")
+	return nil
+}
+
+// lifecycleRolloverTarget returns the data stream or the rollover alias of the index if the index
+// takes the writes of it, empty for the index already rolled over or without a rollover target
+func (index *Index) lifecycleRolloverTarget() string {
+	name := index.GetName()
+	if stream, ok := ZINC_DATA_STREAM_LIST.Get(index.GetDataStream()); ok {
+		if stream.WriteIndex() == name {
+			return stream.Name
+		}
+		return ""
+	}
+	index.lock.RLock()
+	var alias string
+	if index.ref.Settings != nil && index.ref.Settings.Lifecycle != nil {
+		alias = index.ref.Settings.Lifecycle.RolloverAlias
+	}
+	index.lock.RUnlock()
+	if alias == "" {
+		return ""
+	}
+	if writeIndex, err := ZINC_INDEX_ALIAS_LIST.ResolveWriteIndex(alias); err == nil && writeIndex == name {
+		return alias
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestLifecyclePolicy(t *testing.T) {
+	name := "TestLifecyclePolicy"
+	policy := func(phases map[string]*meta.LifecyclePhase) *meta.LifecyclePolicyRequest {
+		return &meta.LifecyclePolicyRequest{Policy: meta.LifecycleSpec{Phases: phases}}
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		assert.Error(t, PutLifecyclePolicy("", policy(nil)))
+		assert.Error(t, PutLifecyclePolicy(name, policy(map[string]*meta.LifecyclePhase{
+			"warm": {},
+		})))
+		assert.Error(t, PutLifecyclePolicy(name, policy(map[string]*meta.LifecyclePhase{
+			"hot": {Actions: meta.LifecycleActions{Delete: &meta.LifecycleDeleteAction{}}},
+		})))
+		assert.Error(t, PutLifecyclePolicy(name, policy(map[string]*meta.LifecyclePhase{
+			"hot": {Actions: meta.LifecycleActions{Rollover: &meta.RolloverConditions{}}},
+		})))
+		assert.Error(t, PutLifecyclePolicy(name, policy(map[string]*meta.LifecyclePhase{
+			"hot": {Actions: meta.LifecycleActions{Rollover: &meta.RolloverConditions{MaxSize: "x"}}},
+		})))
+		assert.Error(t, PutLifecyclePolicy(name, policy(map[string]*meta.LifecyclePhase{
+			"delete": {MinAge: "x", Actions: meta.LifecycleActions{Delete: &meta.LifecycleDeleteAction{}}},
+		})))
+	})
+
+	t.Run("put", func(t *testing.T) {
+		req := policy(map[string]*meta.LifecyclePhase{
+			"hot":    {Actions: meta.LifecycleActions{Rollover: &meta.RolloverConditions{MaxDocs: 1}}},
+			"delete": {MinAge: "1h", Actions: meta.LifecycleActions{Delete: &meta.LifecycleDeleteAction{}}},
+		})
+		assert.NoError(t, PutLifecyclePolicy(name, req))
+		assert.NoError(t, PutLifecyclePolicy(name, req))
+		p, ok, err := GetLifecyclePolicy(name)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), p.Version)
+		assert.Equal(t, uint64(1), p.Policy.Phases["hot"].Actions.Rollover.MaxDocs)
+	})
+
+	stream := "test_lifecycle_policy"
+	gen1 := DataStreamIndexName(stream, 1)
+	gen2 := DataStreamIndexName(stream, 2)
+	t.Run("rollover", func(t *testing.T) {
+		_, err := CreateDataStream(stream)
+		assert.NoError(t, err)
+		index, _ := GetIndex(gen1)
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{Lifecycle: &meta.IndexLifecycle{Name: name}}))
+		assert.NoError(t, StoreIndex(index))
+
+		// the conditions are not met
+		ApplyLifecyclePolicies(time.Now())
+		_, ok := GetIndex(gen2)
+		assert.False(t, ok)
+
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"@timestamp": "2023-01-01T00:00:00Z"}, false))
+		assert.NoError(t, index.Flush())
+		ApplyLifecyclePolicies(time.Now())
+		newIndex, ok := GetIndex(gen2)
+		assert.True(t, ok)
+		assert.Equal(t, name, newIndex.GetLifecyclePolicy())
+		assert.False(t, index.GetRolledOverAt().IsZero())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// the rolled over index is younger than the min_age
+		ApplyLifecyclePolicies(time.Now())
+		_, ok := GetIndex(gen1)
+		assert.True(t, ok)
+
+		// the write index is kept
+		ApplyLifecyclePolicies(time.Now().Add(time.Hour * 2))
+		_, ok = GetIndex(gen1)
+		assert.False(t, ok)
+		_, ok = GetIndex(gen2)
+		assert.True(t, ok)
+		s, _ := ZINC_DATA_STREAM_LIST.Get(stream)
+		assert.Equal(t, []string{gen2}, s.IndexNames())
+	})
+
+	t.Run("delete without rollover", func(t *testing.T) {
+		index, err := NewIndex("TestLifecyclePolicy.index", "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{Lifecycle: &meta.IndexLifecycle{Name: name}}))
+		assert.NoError(t, StoreIndex(index))
+		ApplyLifecyclePolicies(time.Now())
+		_, ok := GetIndex("TestLifecyclePolicy.index")
+		assert.True(t, ok)
+		ApplyLifecyclePolicies(time.Now().Add(time.Hour * 2))
+		_, ok = GetIndex("TestLifecyclePolicy.index")
+		assert.False(t, ok)
+	})
+
+	t.Run("rollover alias", func(t *testing.T) {
+		alias := "TestLifecyclePolicy.alias"
+		index, err := NewIndex("TestLifecyclePolicy.alias-000001", "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{Lifecycle: &meta.IndexLifecycle{Name: name, RolloverAlias: alias}}))
+		assert.NoError(t, StoreIndex(index))
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{index.GetName()}))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "doc"}, false))
+		assert.NoError(t, index.Flush())
+
+		ApplyLifecyclePolicies(time.Now())
+		writeIndex, err := ZINC_INDEX_ALIAS_LIST.ResolveWriteIndex(alias)
+		assert.NoError(t, err)
+		assert.Equal(t, "TestLifecyclePolicy.alias-000002", writeIndex)
+
+		ApplyLifecyclePolicies(time.Now().Add(time.Hour * 2))
+		_, ok := GetIndex("TestLifecyclePolicy.alias-000001")
+		assert.False(t, ok)
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{"TestLifecyclePolicy.alias-000002"}))
+		assert.NoError(t, DeleteIndex("TestLifecyclePolicy.alias-000002"))
+	})
+
+	t.Run("delete policy", func(t *testing.T) {
+		_, err := DeleteLifecyclePolicy(name)
+		assert.Error(t, err)
+		assert.NoError(t, DeleteDataStream(stream))
+		ok, err := DeleteLifecyclePolicy(name)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = DeleteLifecyclePolicy(name)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
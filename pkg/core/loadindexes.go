@@ -35,25 +35,25 @@ func LoadZincIndexesFromMetadata(version string) error {
 
 	for i := range indexes {
 		readIndex := indexes[i]
-		index := new(Index)
-		index.ref = new(meta.Index)
-		index.ref.Name = readIndex.Name
-		index.ref.StorageType = readIndex.StorageType
-		index.ref.Settings = readIndex.Settings
-		index.ref.Mappings = readIndex.Mappings
-		index.ref.Stats = readIndex.Stats
 
 		// upgrade from old version
 		if readIndex.Version != "" {
 			version = readIndex.Version
 		}
+		upgraded := false
 		if version != meta.Version {
 			log.Info().Msgf("Upgrade index[%s] from version[%s] to version[%s]", readIndex.Name, version, meta.Version)
 			if err := upgrade.Do(version, readIndex); err != nil {
 				return err
 			}
-			// save updated metadata
 			readIndex.Version = meta.Version
+			upgraded = true
+		}
+		if upgrade.UpgradeAnalysis(readIndex) {
+			upgraded = true
+		}
+		if upgraded {
+			// save updated metadata
 			data, err := json.Marshal(readIndex)
 			if err != nil {
 				return err
@@ -63,63 +63,91 @@ func LoadZincIndexesFromMetadata(version string) error {
 			}
 		}
 
-		// init shards
-		index.ref.ShardNum = readIndex.ShardNum
-		index.ref.Shards = make(map[string]*meta.IndexShard, index.shardNum)
-		for id := range readIndex.Shards {
-			index.ref.Shards[id] = &meta.IndexShard{
-				ID:       readIndex.Shards[id].ID,
-				ShardNum: readIndex.Shards[id].ShardNum,
-				Stats:    readIndex.Shards[id].Stats,
-			}
-			index.ref.Shards[id].Shards = make([]*meta.IndexSecondShard, index.ref.Shards[id].ShardNum)
-			for j := range readIndex.Shards[id].Shards {
-				index.ref.Shards[id].Shards[j] = &meta.IndexSecondShard{
-					ID:    readIndex.Shards[id].Shards[j].ID,
-					Stats: readIndex.Shards[id].Shards[j].Stats,
-				}
+		index, err := loadIndex(readIndex)
+		if err != nil {
+			return err
+		}
+
+		// load in memory
+		ZINC_INDEX_LIST.Add(index)
+	}
+
+	return nil
+}
+
+// loadIndex creates the index from the metadata
+func loadIndex(readIndex *meta.Index) (*Index, error) {
+	var err error
+	index := new(Index)
+	index.ref = new(meta.Index)
+	index.ref.Name = readIndex.Name
+	index.ref.StorageType = readIndex.StorageType
+	index.ref.Settings = readIndex.Settings
+	index.ref.Mappings = readIndex.Mappings
+	index.ref.Stats = readIndex.Stats
+	index.ref.CreatedAt = readIndex.CreatedAt
+	index.ref.State = readIndex.State
+	index.ref.DataStream = readIndex.DataStream
+	index.ref.RolledOverAt = readIndex.RolledOverAt
+	index.ref.AnalysisVersion = readIndex.AnalysisVersion
+	if index.ref.State == "" {
+		index.ref.State = meta.IndexStateOpen
+	}
+	index.closed.Store(index.ref.State == meta.IndexStateClose)
+
+	// init shards
+	index.ref.ShardNum = readIndex.ShardNum
+	index.ref.Shards = make(map[string]*meta.IndexShard, index.shardNum)
+	for id := range readIndex.Shards {
+		index.ref.Shards[id] = &meta.IndexShard{
+			ID:       readIndex.Shards[id].ID,
+			ShardNum: readIndex.Shards[id].ShardNum,
+			Stats:    readIndex.Shards[id].Stats,
+		}
+		index.ref.Shards[id].Shards = make([]*meta.IndexSecondShard, index.ref.Shards[id].ShardNum)
+		for j := range readIndex.Shards[id].Shards {
+			index.ref.Shards[id].Shards[j] = &meta.IndexSecondShard{
+				ID:    readIndex.Shards[id].Shards[j].ID,
+				Stats: readIndex.Shards[id].Shards[j].Stats,
 			}
 		}
+	}
 
-		// init shards wrapper
-		totalShardNum := 0
-		index.shardNum = index.ref.ShardNum
-		index.shards = make(map[string]*IndexShard, index.shardNum)
-		for id := range index.ref.Shards {
-			index.shards[id] = &IndexShard{
+	// init shards wrapper
+	totalShardNum := 0
+	index.shardNum = index.ref.ShardNum
+	index.shards = make(map[string]*IndexShard, index.shardNum)
+	for id := range index.ref.Shards {
+		index.shards[id] = &IndexShard{
+			root: index,
+			ref:  index.ref.Shards[id],
+			name: index.ref.Name + "/" + index.ref.Shards[id].ID,
+		}
+		index.shards[id].shards = make([]*IndexSecondShard, index.ref.Shards[id].ShardNum)
+		for j := range index.ref.Shards[id].Shards {
+			index.shards[id].shards[j] = &IndexSecondShard{
 				root: index,
-				ref:  index.ref.Shards[id],
-				name: index.ref.Name + "/" + index.ref.Shards[id].ID,
-			}
-			index.shards[id].shards = make([]*IndexSecondShard, index.ref.Shards[id].ShardNum)
-			for j := range index.ref.Shards[id].Shards {
-				index.shards[id].shards[j] = &IndexSecondShard{
-					root: index,
-					ref:  index.ref.Shards[id].Shards[j],
-				}
-				totalShardNum++
+				ref:  index.ref.Shards[id].Shards[j],
 			}
+			totalShardNum++
 		}
+	}
 
-		// init shards hashing
-		index.shardHashing = rendezvous.New()
-		for id := range index.shards {
-			index.shardHashing.Add(id)
-		}
+	// init shards hashing
+	index.shardHashing = rendezvous.New()
+	for id := range index.shards {
+		index.shardHashing.Add(id)
+	}
 
-		log.Info().Msgf("Loading  index... [%s:%s] shards[%d:%d]", index.ref.Name, index.ref.StorageType, index.ref.ShardNum, totalShardNum)
+	log.Info().Msgf("Loading  index... [%s:%s] shards[%d:%d]", index.ref.Name, index.ref.StorageType, index.ref.ShardNum, totalShardNum)
 
-		// load index analysis
-		if index.ref.Settings != nil && index.ref.Settings.Analysis != nil {
-			index.analyzers, err = zincanalysis.RequestAnalyzer(index.ref.Settings.Analysis)
-			if err != nil {
-				return errors.New(errors.ErrorTypeRuntimeException, "parse stored analysis error").Cause(err)
-			}
+	// load index analysis
+	if index.ref.Settings != nil && index.ref.Settings.Analysis != nil {
+		index.analyzers, err = zincanalysis.RequestAnalyzer(index.ref.Settings.Analysis)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeRuntimeException, "parse stored analysis error").Cause(err)
 		}
-
-		// load in memory
-		ZINC_INDEX_LIST.Add(index)
 	}
 
-	return nil
+	return index, nil
 }
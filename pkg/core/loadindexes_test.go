@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/upgrade"
 )
 
 func TestLoadIndexes(t *testing.T) {
@@ -44,6 +45,26 @@ func TestLoadIndexes(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("create index with the pattern tokenizer of an old version", func(t *testing.T) {
+		index, err := NewIndex("TestLoadIndexes.index_2", "disk", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, upgrade.AnalysisVersion, index.GetIndex().AnalysisVersion)
+		err = index.SetSettings(&meta.IndexSettings{
+			Analysis: &meta.IndexAnalysis{
+				Analyzer: map[string]*meta.Analyzer{
+					"csv": {Tokenizer: "csv"},
+				},
+				Tokenizer: map[string]interface{}{
+					"csv": map[string]interface{}{"type": "pattern", "pattern": "[^,]+"},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		// the pattern matched the tokens before analysis version 1
+		index.ref.AnalysisVersion = 0
+		assert.NoError(t, StoreIndex(index))
+	})
+
 	t.Run("load user index from disk", func(t *testing.T) {
 		ZINC_INDEX_LIST.Close()
 		err := LoadZincIndexesFromMetadata(meta.Version)
@@ -51,8 +72,26 @@ func TestLoadIndexes(t *testing.T) {
 		assert.GreaterOrEqual(t, ZINC_INDEX_LIST.Len(), 0)
 	})
 
+	t.Run("keep the meaning of the stored analysis", func(t *testing.T) {
+		index, ok := GetIndex("TestLoadIndexes.index_2")
+		assert.True(t, ok)
+		assert.Equal(t, upgrade.AnalysisVersion, index.GetIndex().AnalysisVersion)
+		tokenizer := index.GetIndex().Settings.Analysis.Tokenizer["csv"].(map[string]interface{})
+		assert.Equal(t, "regexp", tokenizer["type"])
+		analyzer := index.GetAnalyzers()["csv"]
+		assert.NotNil(t, analyzer)
+		tokens := analyzer.Analyze([]byte("a b,c"))
+		terms := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			terms = append(terms, string(token.Term))
+		}
+		assert.Equal(t, []string{"a b", "c"}, terms)
+	})
+
 	t.Run("cleanup", func(t *testing.T) {
 		err := DeleteIndex("TestLoadIndexes.index_1")
 		assert.NoError(t, err)
+		err = DeleteIndex("TestLoadIndexes.index_2")
+		assert.NoError(t, err)
 	})
 }
@@ -16,12 +16,21 @@
 package core
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	ginprometheus "github.com/zincsearch/go-gin-prometheus"
 )
 
 var ZINC_METRICS *ginprometheus.Metric
 
+// ZINC_SEARCH_METRICS is the histogram of the search durations by index
+var ZINC_SEARCH_METRICS *ginprometheus.Metric
+
+// ZINC_WAL_FLUSH_METRICS is the histogram of the durations writing a WAL batch into the index by index,
+// the queue waiting for it is the index_wal_pending_docs of the IndexCollector
+var ZINC_WAL_FLUSH_METRICS *ginprometheus.Metric
+
 func init() {
 	ZINC_METRICS = &ginprometheus.Metric{
 		ID:          "indexStats",                 // Identifier
@@ -33,6 +42,20 @@ func init() {
 		//	histogram, histogram_vec, summary, summary_vec
 		Args: []string{"index", "field"},
 	}
+	ZINC_SEARCH_METRICS = &ginprometheus.Metric{
+		ID:          "searchDuration",
+		Name:        "search_duration_seconds",
+		Description: "The search durations in seconds",
+		Type:        "histogram_vec",
+		Args:        []string{"index"},
+	}
+	ZINC_WAL_FLUSH_METRICS = &ginprometheus.Metric{
+		ID:          "walFlushDuration",
+		Name:        "wal_flush_duration_seconds",
+		Description: "The durations in seconds writing a WAL batch into the index",
+		Type:        "histogram_vec",
+		Args:        []string{"index"},
+	}
 }
 
 func SetMetricStatsByIndex(index, field string, val float64) {
@@ -48,3 +71,49 @@ func IncrMetricStatsByIndex(index, field string) {
 	}
 	ZINC_METRICS.MetricCollector.(*prometheus.GaugeVec).WithLabelValues(index, field).Inc()
 }
+
+func ObserveMetricSearchDuration(index string, took time.Duration) {
+	if ZINC_SEARCH_METRICS.MetricCollector == nil {
+		return
+	}
+	ZINC_SEARCH_METRICS.MetricCollector.(*prometheus.HistogramVec).WithLabelValues(index).Observe(took.Seconds())
+}
+
+func ObserveMetricWALFlush(index string, took time.Duration) {
+	if ZINC_WAL_FLUSH_METRICS.MetricCollector == nil {
+		return
+	}
+	ZINC_WAL_FLUSH_METRICS.MetricCollector.(*prometheus.HistogramVec).WithLabelValues(index).Observe(took.Seconds())
+}
+
+// IndexCollector collects the stats of all the indexes when scraping,
+// the deleted indexes disappear from the metrics
+type IndexCollector struct {
+	docs       *prometheus.Desc
+	size       *prometheus.Desc
+	walPending *prometheus.Desc
+}
+
+func NewIndexCollector(subsystem string) *IndexCollector {
+	return &IndexCollector{
+		docs:       prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "index_docs"), "The number of documents in the index", []string{"index"}, nil),
+		size:       prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "index_storage_size_bytes"), "The storage size of the index in bytes", []string{"index"}, nil),
+		walPending: prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "index_wal_pending_docs"), "The number of documents queued in WAL and not indexed yet", []string{"index"}, nil),
+	}
+}
+
+func (c *IndexCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.docs
+	ch <- c.size
+	ch <- c.walPending
+}
+
+func (c *IndexCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, index := range ZINC_INDEX_LIST.List() {
+		name := index.GetName()
+		stats := index.Stats("docs", "store")
+		ch <- prometheus.MustNewConstMetric(c.docs, prometheus.GaugeValue, float64(stats.Docs.Count), name)
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Store.SizeInBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.walPending, prometheus.GaugeValue, float64(index.GetWALPending()), name)
+	}
+}
@@ -0,0 +1,66 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexCollector(t *testing.T) {
+	indexName := "TestIndexCollector.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 10; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, index.Flush())
+		assert.Equal(t, uint64(0), index.GetWALPending())
+	})
+
+	t.Run("collect", func(t *testing.T) {
+		c := NewIndexCollector("zinc")
+		ch := make(chan prometheus.Metric, 1024)
+		c.Collect(ch)
+		close(ch)
+
+		got := make(map[string]float64)
+		for m := range ch {
+			pb := &dto.Metric{}
+			assert.NoError(t, m.Write(pb))
+			if pb.GetLabel()[0].GetValue() != indexName {
+				continue
+			}
+			got[m.Desc().String()] = pb.GetGauge().GetValue()
+		}
+		assert.Len(t, got, 3)
+		assert.Equal(t, float64(10), got[c.docs.String()])
+		assert.Greater(t, got[c.size.String()], float64(0))
+		assert.Equal(t, float64(0), got[c.walPending.String()])
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
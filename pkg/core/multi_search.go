@@ -25,27 +25,38 @@ import (
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/rs/zerolog/log"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/profile"
 	zincsearch "github.com/zincsearch/zincsearch/pkg/bluge/search"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/uquery"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+	"github.com/zincsearch/zincsearch/pkg/uquery/suggest"
 	"github.com/zincsearch/zincsearch/pkg/uquery/timerange"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
-func MultiSearch(indexNames []string, query *meta.ZincQuery) (*meta.SearchResponse, error) {
+func MultiSearch(indexNames []string, query *meta.ZincQuery) (resp *meta.SearchResponse, err error) {
 	var mappings *meta.Mappings
 	var analyzers map[string]*analysis.Analyzer
 	var readers []*bluge.Reader
+	var readerIDs []string
+	var readerIndexes []string
+	var releases []func()
 	var shardNum int64
+	var indexes []*Index
 
 	timeMin, timeMax := timerange.Query(query.Query)
 	isMatched := false
 	hasIndex := false
 	for _, index := range ZINC_INDEX_LIST.List() {
+		explicit := false
 		if len(indexNames) > 0 {
 			for _, indexName := range indexNames {
 				isMatched = isMatchIndex(index.GetName(), indexName)
 				if isMatched {
 					hasIndex = true
+					explicit = !strings.Contains(indexName, "*")
 					break
 				}
 			}
@@ -53,12 +64,25 @@ func MultiSearch(indexNames []string, query *meta.ZincQuery) (*meta.SearchRespon
 				continue
 			}
 		}
+		// closed indexes are skipped unless they are named explicitly
+		if index.IsClosed() && !explicit {
+			continue
+		}
 
-		reader, err := index.GetReaders(timeMin, timeMax)
+		reader, release, err := index.searchReaders(timeMin, timeMax, query.Preference, query.Routing)
 		if err != nil {
+			for _, release := range releases {
+				release()
+			}
 			return nil, err
 		}
+		releases = append(releases, release)
 		readers = append(readers, reader...)
+		for i := range reader {
+			readerIDs = append(readerIDs, fmt.Sprintf("[%s][%d]", index.GetName(), i))
+			readerIndexes = append(readerIndexes, index.GetName())
+		}
+		indexes = append(indexes, index)
 		shardNum += index.GetShardNum()
 		if mappings == nil {
 			mappings = index.GetMappings()
@@ -68,35 +92,65 @@ func MultiSearch(indexNames []string, query *meta.ZincQuery) (*meta.SearchRespon
 	}
 
 	if len(readers) == 0 {
+		for _, release := range releases {
+			release()
+		}
 		if !hasIndex {
 			return nil, fmt.Errorf("core.MultiSearchV2: error accessing reader: no index found")
 		}
 		return &meta.SearchResponse{}, nil
 	}
 
+	startTime := time.Now()
 	defer func() {
-		for _, reader := range readers {
-			reader.Close()
+		for _, release := range releases {
+			release()
+		}
+		took := time.Since(startTime)
+		for _, index := range indexes {
+			index.incrSearch(took)
+			index.slowLog(query, took, resp)
 		}
 	}()
 
-	_, err := uquery.ParseQueryDSL(query, mappings, analyzers)
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if err = security.Check(query, security.FieldRules(query.Privileges, index.GetName()), index.GetMappings()); err != nil {
+			return nil, err
+		}
+		names = append(names, index.GetName())
+	}
+	filter, err := security.MultiDocumentFilter(query.Privileges, names)
 	if err != nil {
 		return nil, err
 	}
+	security.Restrict(query, filter)
 
-	ctx := context.Background()
-	var cancel context.CancelFunc
-	if query.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(query.Timeout)*time.Second)
-		defer cancel()
+	parseStart := time.Now()
+	_, err = uquery.ParseQueryDSL(query, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	parseTook := time.Since(parseStart)
+	query.Boosts = readerBoosts(query, readerIndexes)
+
+	ctx, cancel := searchContext(query)
+	defer cancel()
+
+	var profiler *profile.Profiler
+	if query.Profile {
+		profiler = profile.New(readerIDs...)
+		profiler.AddParse(parseTook)
+		ctx = profile.NewContext(ctx, profiler)
 	}
 
 	// dmi, err := bluge.MultiSearch(ctx, searchRequest, readers...)
+	collectStart := time.Now()
 	dmi, err := zincsearch.MultiSearch(ctx, query, mappings, analyzers, readers...)
+	profiler.AddCollect(time.Since(collectStart))
 	if err != nil {
 		log.Printf("core.MultiSearchV2: error executing search: %s", err.Error())
-		if err == context.DeadlineExceeded {
+		if ctx.Err() != nil {
 			return &meta.SearchResponse{
 				TimedOut: true,
 				Error:    err.Error(),
@@ -106,28 +160,80 @@ func MultiSearch(indexNames []string, query *meta.ZincQuery) (*meta.SearchRespon
 		return nil, err
 	}
 
-	return searchV2(shardNum, int64(len(readers)), dmi, query, mappings)
+	resp, err = searchV2(shardNum, int64(len(readers)), dmi, query, mappings, profiler)
+	if err != nil {
+		return nil, err
+	}
+	// the search stopped at the timeout or when the client went away, the hits are partial
+	resp.TimedOut = ctx.Err() != nil
+	// the fetch phase is limited by the size of the hits, it completes the partial hits also
+	fetchCtx := context.Background()
+
+	// nested inner hits
+	if err = nestedInnerHits(fetchCtx, readers, resp, query, mappings, analyzers); err != nil {
+		return nil, err
+	}
+
+	// named queries
+	if err = matchedQueries(fetchCtx, readers, resp, query, mappings, analyzers); err != nil {
+		return nil, err
+	}
+
+	// suggest
+	if query.Suggest != nil {
+		suggestFilter, err := security.DocumentQuery(filter, mappings, analyzers)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Suggest, err = suggest.Response(readers, query.Suggest, suggestFilter, mappings, analyzers); err != nil {
+			return nil, err
+		}
+	}
+
+	// field level security
+	security.Hits(query.Privileges, resp.Hits.Hits)
+	security.Suggest(query.Privileges, resp.Suggest)
+
+	resp.Profile = profiler.Response()
+
+	return resp, nil
+}
+
+// readerBoosts returns the indices_boost of the index of each reader, nil if there is no indices_boost
+func readerBoosts(query *meta.ZincQuery, indexNames []string) []float64 {
+	boosts, _ := uquery.IndicesBoost(query.IndicesBoost)
+	if len(boosts) == 0 {
+		return nil
+	}
+	rv := make([]float64, len(indexNames))
+	for i, name := range indexNames {
+		rv[i] = indexBoost(boosts, name)
+	}
+	return rv
+}
+
+// indexBoost returns the boost of the first indices_boost matching the index or one of its aliases
+func indexBoost(boosts []meta.IndexBoost, name string) float64 {
+	for _, boost := range boosts {
+		if boost.Index != "" && isMatchIndex(name, boost.Index) {
+			return boost.Boost
+		}
+		if indexes, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(boost.Index); ok && zutils.SliceExists(indexes, name) {
+			return boost.Boost
+		}
+	}
+	return 1
 }
 
 // isMatchIndex("abc", "a")  false
 // isMatchIndex("abc", "a*") true
 // isMatchIndex("abc", "*bc") true
+// isMatchIndex("abc", "a*c") true
 // isMatchIndex("abc", "bc") false
 // isMatchIndex("abc", "abc") true
 func isMatchIndex(zincIndexName, indexName string) bool {
 	if indexName == "" {
 		return true
 	}
-
-	// eg.: *-test
-	if strings.HasPrefix(indexName, "*") {
-		return strings.HasSuffix(zincIndexName, indexName[1:])
-	}
-
-	// eg.: test-*
-	if strings.HasSuffix(indexName, "*") {
-		return strings.HasPrefix(zincIndexName, indexName[:len(indexName)-1])
-	}
-
-	return zincIndexName == indexName
+	return source.MatchPattern(indexName, zincIndexName)
 }
@@ -17,6 +17,7 @@ package core
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -130,8 +131,90 @@ func TestIsMatchIndex(t *testing.T) {
 	assert.True(t, ret)
 	ret = isMatchIndex("abc", "*bc") // true
 	assert.True(t, ret)
+	ret = isMatchIndex("abc", "a*c") // true
+	assert.True(t, ret)
+	ret = isMatchIndex("abc", "b*") // false
+	assert.False(t, ret)
 	ret = isMatchIndex("abc", "bc") // false
 	assert.False(t, ret)
 	ret = isMatchIndex("abc", "abc") // true
 	assert.True(t, ret)
 }
+
+func TestMultiSearch_IndicesBoost(t *testing.T) {
+	indexNames := []string{"TestMultiSearch.boost.2023", "TestMultiSearch.boost.2024"}
+	t.Run("prepare", func(t *testing.T) {
+		for _, indexName := range indexNames {
+			index, err := NewIndex(indexName, "disk", 2)
+			assert.NoError(t, err)
+			assert.NoError(t, StoreIndex(index))
+			assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"title": "zinc"}, false))
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(indicesBoost interface{}) ([]string, []float64, error) {
+		got, err := MultiSearch([]string{"TestMultiSearch.boost.*"}, &meta.ZincQuery{
+			Query: map[string]interface{}{
+				"constant_score": map[string]interface{}{"filter": map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}}},
+			},
+			IndicesBoost: indicesBoost,
+			Size:         10,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		indexes := make([]string, 0, len(got.Hits.Hits))
+		scores := make([]float64, 0, len(got.Hits.Hits))
+		for _, hit := range got.Hits.Hits {
+			indexes = append(indexes, hit.Index)
+			scores = append(scores, hit.Score)
+		}
+		return indexes, scores, nil
+	}
+
+	t.Run("array", func(t *testing.T) {
+		indexes, scores, err := search([]interface{}{
+			map[string]interface{}{"TestMultiSearch.boost.2024": 2.0},
+			map[string]interface{}{"TestMultiSearch.boost.*": 0.5},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"TestMultiSearch.boost.2024", "TestMultiSearch.boost.2023"}, indexes)
+		assert.Equal(t, []float64{2, 0.5}, scores)
+	})
+
+	t.Run("object", func(t *testing.T) {
+		indexes, scores, err := search(map[string]interface{}{"TestMultiSearch.boost.2023": 3.0})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"TestMultiSearch.boost.2023", "TestMultiSearch.boost.2024"}, indexes)
+		assert.Equal(t, []float64{3, 1}, scores)
+	})
+
+	t.Run("single index", func(t *testing.T) {
+		index, _ := GetIndex("TestMultiSearch.boost.2024")
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{
+				"constant_score": map[string]interface{}{"filter": map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}}},
+			},
+			IndicesBoost: map[string]interface{}{"TestMultiSearch.boost.2024": 4.0},
+			Size:         10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Equal(t, float64(4), got.Hits.Hits[0].Score)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := search([]interface{}{map[string]interface{}{"TestMultiSearch.boost.2024": "high"}})
+		assert.Error(t, err)
+		_, _, err = search("TestMultiSearch.boost.2024")
+		assert.Error(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		for _, indexName := range indexNames {
+			assert.NoError(t, DeleteIndex(indexName))
+		}
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
@@ -28,6 +29,7 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/ider"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/upgrade"
 	"github.com/zincsearch/zincsearch/pkg/zutils/hash/rendezvous"
 )
 
@@ -48,6 +50,11 @@ func CheckIndexName(name string) error {
 
 // NewIndex creates an instance of a physical zinc index that can be used to store and retrieve data.
 func NewIndex(name, storageType string, shardNum int64) (*Index, error) {
+	return newIndex(name, name, storageType, shardNum)
+}
+
+// newIndex creates the index with the template matching templateName
+func newIndex(name, templateName, storageType string, shardNum int64) (*Index, error) {
 	if err := CheckIndexName(name); err != nil {
 		return nil, err
 	}
@@ -65,9 +72,12 @@ func NewIndex(name, storageType string, shardNum int64) (*Index, error) {
 	index.ref.Name = name
 	index.ref.StorageType = storageType
 	index.ref.Version = meta.Version
+	index.ref.AnalysisVersion = upgrade.AnalysisVersion
+	index.ref.CreatedAt = time.Now()
+	index.ref.State = meta.IndexStateOpen
 
 	// use template
-	if err := index.UseTemplate(); err != nil {
+	if err := index.useTemplate(templateName); err != nil {
 		return nil, err
 	}
 	if index.ref.Settings != nil {
@@ -0,0 +1,133 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/ingest"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+)
+
+func init() {
+	patterns, err := metadata.GrokPattern.List(0, 0)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error loading grok patterns")
+	}
+	// the custom patterns can reference each other, register them until no more succeeds
+	for len(patterns) > 0 {
+		failed := patterns[:0]
+		for _, pattern := range patterns {
+			if err := ingest.RegisterGrokPattern(pattern.Name, pattern.Pattern); err != nil {
+				failed = append(failed, pattern)
+			}
+		}
+		if len(failed) == len(patterns) {
+			for _, pattern := range failed {
+				log.Error().Str("pattern", pattern.Name).Msg("Error loading grok pattern")
+			}
+			break
+		}
+		patterns = failed
+	}
+}
+
+// PutPipeline validates and stores the ingest pipeline, it replaces the pipeline with the same id
+func PutPipeline(id string, req *meta.PipelineRequest) error {
+	if id == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[pipeline] id should be not empty")
+	}
+	if req == nil || req.Processors == nil {
+		return errors.New(errors.ErrorTypeParsingException, "[pipeline] [processors] required property is missing")
+	}
+	if _, err := ingest.New(id, req.Processors); err != nil {
+		return err
+	}
+	return metadata.Pipeline.Set(id, meta.Pipeline{
+		ID:          id,
+		Description: req.Description,
+		Processors:  req.Processors,
+		UpdatedAt:   time.Now(),
+	})
+}
+
+// GetPipeline returns the stored pipeline by id
+func GetPipeline(id string) (*meta.Pipeline, bool, error) {
+	pipeline, err := metadata.Pipeline.Get(id)
+	if err != nil {
+		if err == errors.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return pipeline, true, nil
+}
+
+// ListPipelines returns all the stored pipelines
+func ListPipelines() ([]*meta.Pipeline, error) {
+	return metadata.Pipeline.List(0, 0)
+}
+
+// DeletePipeline deletes the stored pipeline
+func DeletePipeline(id string) (bool, error) {
+	if _, ok, err := GetPipeline(id); err != nil || !ok {
+		return false, err
+	}
+	return true, metadata.Pipeline.Delete(id)
+}
+
+// LoadPipeline compiles the stored pipeline to run the documents through it
+func LoadPipeline(id string) (*ingest.Pipeline, error) {
+	pipeline, ok, err := GetPipeline(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("pipeline with id [%s] does not exist", id))
+	}
+	return ingest.NewFromMeta(pipeline)
+}
+
+// PutGrokPattern registers and stores the custom grok pattern, the bundled patterns can't be replaced
+func PutGrokPattern(name, pattern string) error {
+	if pattern == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[grok] pattern should be not empty")
+	}
+	if err := ingest.RegisterGrokPattern(name, pattern); err != nil {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[grok] invalid pattern").Cause(err)
+	}
+	return metadata.GrokPattern.Set(name, meta.GrokPattern{
+		Name:      name,
+		Pattern:   pattern,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// DeleteGrokPattern deletes the custom grok pattern, the pipelines using it fail to load after deleted
+func DeleteGrokPattern(name string) (bool, error) {
+	if _, err := metadata.GrokPattern.Get(name); err != nil {
+		if err == errors.ErrKeyNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	ingest.UnregisterGrokPattern(name)
+	return true, metadata.GrokPattern.Delete(name)
+}
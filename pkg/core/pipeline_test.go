@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestPipeline(t *testing.T) {
+	id := "TestPipeline.pipeline_1"
+	t.Run("put", func(t *testing.T) {
+		assert.NoError(t, PutPipeline(id, &meta.PipelineRequest{
+			Description: "lowercase the level",
+			Processors:  []map[string]interface{}{{"lowercase": map[string]interface{}{"field": "level"}}},
+		}))
+		assert.Error(t, PutPipeline("", &meta.PipelineRequest{Processors: []map[string]interface{}{}}))
+		assert.Error(t, PutPipeline(id, nil))
+		assert.Error(t, PutPipeline(id, &meta.PipelineRequest{Processors: []map[string]interface{}{{"unknown": map[string]interface{}{}}}}))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		pipeline, ok, err := GetPipeline(id)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "lowercase the level", pipeline.Description)
+
+		_, ok, err = GetPipeline(id + "_not_exists")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("load", func(t *testing.T) {
+		pipeline, err := LoadPipeline(id)
+		assert.NoError(t, err)
+		doc := map[string]interface{}{"level": "WARN"}
+		assert.NoError(t, pipeline.Run(doc))
+		assert.Equal(t, "warn", doc["level"])
+
+		_, err = LoadPipeline(id + "_not_exists")
+		assert.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		ok, err := DeletePipeline(id)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = DeletePipeline(id)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,132 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blugelabs/bluge"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+)
+
+// ZINC_PREFERENCE_SNAPSHOTS pins the readers of the searches with a preference, the searches of an index
+// with the same preference see the same snapshot of the documents until it expires, so the repeated
+// searches return the same hits in the same order while the indexing continues.
+// A snapshot lives ZINC_PREFERENCE_TTL from the first search of the preference,
+// the next search after it takes a new snapshot
+var ZINC_PREFERENCE_SNAPSHOTS = &preferenceSnapshots{snapshots: map[string]*readerSnapshot{}}
+
+type preferenceSnapshots struct {
+	snapshots map[string]*readerSnapshot
+	lock      sync.Mutex
+}
+
+// readerSnapshot is released when it expired and no search uses it
+type readerSnapshot struct {
+	readers []*bluge.Reader
+	refs    int
+}
+
+// Acquire returns the readers of the index for the preference and the routing, release must be called after the search
+func (t *preferenceSnapshots) Acquire(index *Index, preference string, routing []string) ([]*bluge.Reader, func(), error) {
+	key := snapshotKey(index.GetName(), preference)
+	if len(routing) > 0 {
+		key += "\x00" + strings.Join(routing, ",")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s, ok := t.snapshots[key]
+	if !ok {
+		// the whole index is pinned, the time range of the query may change between the searches
+		readers, err := index.GetReadersByRouting(0, 0, routing)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = &readerSnapshot{readers: readers, refs: 1}
+		t.snapshots[key] = s
+		time.AfterFunc(config.Global.PreferenceTTL, func() { t.expire(key, s) })
+	}
+	s.refs++
+	return s.readers, func() { t.release(s) }, nil
+}
+
+// Invalidate drops the snapshots of the index, it is called when the index is closed
+func (t *preferenceSnapshots) Invalidate(indexName string) {
+	prefix := snapshotKey(indexName, "")
+	t.lock.Lock()
+	expired := make([]*readerSnapshot, 0)
+	for key, s := range t.snapshots {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.snapshots, key)
+			expired = append(expired, s)
+		}
+	}
+	t.lock.Unlock()
+	for _, s := range expired {
+		t.release(s)
+	}
+}
+
+func (t *preferenceSnapshots) expire(key string, s *readerSnapshot) {
+	t.lock.Lock()
+	if t.snapshots[key] != s {
+		// invalidated already
+		t.lock.Unlock()
+		return
+	}
+	delete(t.snapshots, key)
+	t.lock.Unlock()
+	t.release(s)
+}
+
+func (t *preferenceSnapshots) release(s *readerSnapshot) {
+	t.lock.Lock()
+	s.refs--
+	unused := s.refs == 0
+	t.lock.Unlock()
+	if unused {
+		closeReaders(s.readers)
+	}
+}
+
+func snapshotKey(indexName, preference string) string {
+	return indexName + "\x00" + preference
+}
+
+// searchReaders returns the readers of a search and the func releasing them, the searches with a routing
+// read the shards of the routing values only and the searches with a preference share a snapshot of the index
+func (index *Index) searchReaders(timeMin, timeMax int64, preference string, routing []string) ([]*bluge.Reader, func(), error) {
+	if preference == "" || config.Global.PreferenceTTL <= 0 {
+		readers, err := index.GetReadersByRouting(timeMin, timeMax, routing)
+		if err != nil {
+			return nil, nil, err
+		}
+		return readers, func() { closeReaders(readers) }, nil
+	}
+	if err := index.checkOpen(); err != nil {
+		return nil, nil, err
+	}
+	return ZINC_PREFERENCE_SNAPSHOTS.Acquire(index, preference, routing)
+}
+
+func closeReaders(readers []*bluge.Reader) {
+	for _, reader := range readers {
+		reader.Close()
+	}
+}
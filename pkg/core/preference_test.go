@@ -0,0 +1,80 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_SearchPreference(t *testing.T) {
+	indexName := "Search.preference.index_1"
+	index, err := NewIndex(indexName, "disk", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, StoreIndex(index))
+	defer func() {
+		assert.NoError(t, DeleteIndex(indexName))
+	}()
+
+	old := config.Global.PreferenceTTL
+	defer func() { config.Global.PreferenceTTL = old }()
+	config.Global.PreferenceTTL = 2 * time.Second
+
+	addDocuments := func(from, to int) {
+		for i := from; i < to; i++ {
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"title": "report"}, false))
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	}
+	search := func(preference string) int {
+		resp, err := index.Search(&meta.ZincQuery{Size: 10, Preference: preference})
+		assert.NoError(t, err)
+		return resp.Hits.Total.Value
+	}
+
+	addDocuments(0, 3)
+	assert.Equal(t, 3, search("session-1"))
+
+	addDocuments(3, 5)
+	assert.Equal(t, 3, search("session-1"), "the preference keeps its snapshot")
+	assert.Equal(t, 5, search("session-2"))
+	assert.Equal(t, 5, search(""))
+
+	// wait for the snapshot to expire
+	time.Sleep(2 * time.Second)
+	assert.Equal(t, 5, search("session-1"))
+
+	t.Run("multi search", func(t *testing.T) {
+		resp, err := MultiSearch([]string{"Search.preference.*"}, &meta.ZincQuery{Size: 10, Preference: "session-3"})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, resp.Hits.Total.Value)
+	})
+
+	t.Run("closed index", func(t *testing.T) {
+		assert.Equal(t, 5, search("session-4"))
+		assert.NoError(t, CloseIndex(indexName))
+		_, err := index.Search(&meta.ZincQuery{Size: 10, Preference: "session-4"})
+		assert.Error(t, err)
+		assert.NoError(t, OpenIndex(indexName))
+	})
+}
@@ -0,0 +1,85 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+)
+
+// ParseRefreshInterval parses the refresh interval setting, returns -1 if the scheduled refresh is disabled.
+// The empty interval is the default, the WAL is consumed on every sync.
+func ParseRefreshInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		return config.Global.WalSyncInterval, nil
+	}
+	if interval == "-1" {
+		return -1, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return 0, errors.New(errors.ErrorTypeIllegalArgumentException, "invalid index.refresh_interval ["+interval+"]")
+	}
+	return d, nil
+}
+
+// GetRefreshInterval returns the refresh interval of the index, -1 if the scheduled refresh is disabled
+func (index *Index) GetRefreshInterval() time.Duration {
+	index.lock.RLock()
+	var interval string
+	if index.ref.Settings != nil {
+		interval = index.ref.Settings.RefreshInterval
+	}
+	index.lock.RUnlock()
+	d, err := ParseRefreshInterval(interval)
+	if err != nil {
+		return config.Global.WalSyncInterval
+	}
+	return d
+}
+
+// refreshDue returns whether the background loop should consume the WAL of the shard at now,
+// and records now as the last refresh when it should.
+func (s *IndexShard) refreshDue(now time.Time) bool {
+	interval := s.root.GetRefreshInterval()
+	if interval < 0 {
+		return false
+	}
+	// the loop ticks every WalSyncInterval, allow half a tick of jitter
+	last := atomic.LoadInt64(&s.refreshed)
+	if now.UnixNano()-last < int64(interval-config.Global.WalSyncInterval/2) {
+		return false
+	}
+	atomic.StoreInt64(&s.refreshed, now.UnixNano())
+	return true
+}
+
+// SyncWAL syncs the WAL to disk without consuming it
+func (s *IndexShard) SyncWAL() {
+	s.consume.Lock()
+	defer s.consume.Unlock()
+	if s.wal == nil {
+		return // shard closed
+	}
+	if err := s.wal.Sync(); err != nil {
+		log.Error().Err(err).Str("index", s.GetIndexName()).Str("shard", s.GetID()).Msg("sync wal.Sync()")
+	}
+}
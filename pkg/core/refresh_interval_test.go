@@ -0,0 +1,113 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestParseRefreshInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{interval: "", want: config.Global.WalSyncInterval},
+		{interval: "-1", want: -1},
+		{interval: "500ms", want: 500 * time.Millisecond},
+		{interval: "30s", want: 30 * time.Second},
+		{interval: "0", wantErr: true},
+		{interval: "1 second", wantErr: true},
+		{interval: "-5s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			got, err := ParseRefreshInterval(tt.interval)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndex_RefreshInterval(t *testing.T) {
+	indexName := "TestIndex_RefreshInterval.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		assert.Equal(t, config.Global.WalSyncInterval, index.GetRefreshInterval())
+	})
+
+	count := func() int {
+		got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 10})
+		assert.NoError(t, err)
+		return got.Hits.Total.Value
+	}
+
+	t.Run("due", func(t *testing.T) {
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{RefreshInterval: "1h"}))
+		shard := index.GetShardByDocID("1")
+		now := time.Now().Add(-2 * time.Hour)
+		assert.True(t, shard.refreshDue(now))
+		assert.False(t, shard.refreshDue(now.Add(time.Minute)))
+		assert.True(t, shard.refreshDue(now.Add(time.Hour)))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{RefreshInterval: "-1"}))
+		assert.Equal(t, time.Duration(-1), index.GetRefreshInterval())
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "doc1"}, false))
+		time.Sleep(config.Global.WalSyncInterval * 5)
+		assert.Equal(t, 0, count())
+		assert.Equal(t, uint64(1), index.GetShardByDocID("1").GetWALPending())
+		// the writers don't wait for a WAL which isn't consumed
+		start := time.Now()
+		assert.True(t, index.WaitForWALPending(0, time.Second*5))
+		assert.Less(t, time.Since(start), time.Second)
+
+		// wait_for can't wait for a disabled refresh
+		forced, err := index.Refresh(context.Background(), RefreshWaitFor)
+		assert.NoError(t, err)
+		assert.True(t, forced)
+		assert.Equal(t, 1, count())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assert.NoError(t, index.SetSettings(&meta.IndexSettings{RefreshInterval: config.Global.WalSyncInterval.String()}))
+		assert.NoError(t, index.CreateDocument("2", map[string]interface{}{"name": "doc2"}, false))
+		forced, err := index.Refresh(context.Background(), RefreshWaitFor)
+		assert.NoError(t, err)
+		assert.False(t, forced)
+		assert.Equal(t, 2, count())
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
@@ -0,0 +1,377 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"golang.org/x/sync/errgroup"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/hash/fnv64"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+const (
+	// ReindexSlicesAuto use the first layer shard number of source index as slices
+	ReindexSlicesAuto = "auto"
+	// ReindexDefaultBatchSize is the default number of documents of one batch
+	ReindexDefaultBatchSize = 1000
+
+	ReindexOpTypeIndex  = "index"
+	ReindexOpTypeCreate = "create"
+
+	ReindexConflictsAbort   = "abort"
+	ReindexConflictsProceed = "proceed"
+)
+
+// Reindexer copies documents from the source index to the dest index.
+// The readers of the source are partitioned into slices, every slice runs concurrently
+// and copies the documents of its readers. With more slices than readers, the slices
+// sharing a reader split its documents by the hash of _id.
+// Only the documents matched source.query are copied when it is given.
+// With dest.op_type=create the documents already exist in dest are version
+// conflicts, which abort the reindex unless conflicts=proceed.
+type Reindexer struct {
+	source    *Index
+	dest      *Index
+	query     interface{}
+	slices    int
+	batchSize int
+	create    bool // op_type=create, skip the documents already exist in dest
+	proceed   bool // conflicts=proceed, keep going on version conflicts
+
+	start    time.Time
+	end      time.Time
+	statuses []meta.ReindexSliceStatus
+	aborted  bool
+	lock     sync.Mutex
+}
+
+// Reindex copies documents from the source index to the dest index and waits for the completion
+func Reindex(req *meta.ReindexRequest) (*meta.ReindexResponse, error) {
+	r, err := NewReindexer(req)
+	if err != nil {
+		return nil, err
+	}
+	return r.Run()
+}
+
+// NewReindexer checks the request, parses the query and creates the dest index if it doesn't exist
+func NewReindexer(req *meta.ReindexRequest) (*Reindexer, error) {
+	if req.Source.Index == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[reindex] source.index is required")
+	}
+	if req.Dest.Index == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[reindex] dest.index is required")
+	}
+	if req.Source.Index == req.Dest.Index {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[reindex] cannot reindex into the index it is reading from")
+	}
+	r := new(Reindexer)
+	switch strings.ToLower(req.Dest.OpType) {
+	case "", ReindexOpTypeIndex:
+	case ReindexOpTypeCreate:
+		r.create = true
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[reindex] dest.op_type must be [index] or [create], but got [%s]", req.Dest.OpType))
+	}
+	switch strings.ToLower(req.Conflicts) {
+	case "", ReindexConflictsAbort:
+	case ReindexConflictsProceed:
+		r.proceed = true
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[reindex] conflicts must be [abort] or [proceed], but got [%s]", req.Conflicts))
+	}
+	source, ok := GetIndex(req.Source.Index)
+	if !ok {
+		return nil, fmt.Errorf("index %s does not exists", req.Source.Index)
+	}
+	if !source.GetMappings().SourceEnabled() {
+		return nil, errors.New(errors.ErrorTypeSourceMissingException, fmt.Sprintf("[reindex] _source is disabled in the mappings of index [%s]", req.Source.Index))
+	}
+	slices, err := ReindexSlices(req.Slices, source.GetShardNum())
+	if err != nil {
+		return nil, err
+	}
+	r.source = source
+	r.slices = slices
+	r.batchSize = req.Source.Size
+	if r.batchSize <= 0 {
+		r.batchSize = ReindexDefaultBatchSize
+	}
+	r.query = req.Source.Query
+	if _, err = r.parseQuery(); err != nil {
+		return nil, err
+	}
+
+	r.dest, _, err = GetOrCreateIndex(req.Dest.Index, source.GetStorageType(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	r.statuses = make([]meta.ReindexSliceStatus, slices)
+	for i := range r.statuses {
+		r.statuses[i].SliceID = i
+		r.statuses[i].Failures = []string{}
+	}
+	return r, nil
+}
+
+// Run copies the documents of all the slices, it returns the final status of the reindex.
+// A reindex aborted by a version conflict returns its status with the failures and a
+// version conflict error.
+func (r *Reindexer) Run() (*meta.ReindexResponse, error) {
+	r.lock.Lock()
+	r.start = time.Now()
+	r.lock.Unlock()
+
+	readers, err := r.source.GetReaders(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	eg := errgroup.Group{}
+	for i := 0; i < r.slices; i++ {
+		slice := i
+		eg.Go(func() error {
+			return r.reindexSlice(readers, slice)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	r.end = time.Now()
+	aborted := r.aborted
+	r.lock.Unlock()
+	if aborted {
+		return r.Status(), errors.New(errors.ErrorTypeVersionConflictException, "[reindex] aborted on version conflicts")
+	}
+	return r.Status(), nil
+}
+
+// Status returns the progress of the reindex, the status of every slice is included with slices
+func (r *Reindexer) Status() *meta.ReindexResponse {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	resp := &meta.ReindexResponse{Failures: []string{}}
+	for _, status := range r.statuses {
+		resp.Total += status.Total
+		resp.Created += status.Created
+		resp.Updated += status.Updated
+		resp.VersionConflicts += status.VersionConflicts
+		resp.Batches += status.Batches
+		resp.Failures = append(resp.Failures, status.Failures...)
+		if r.slices > 1 {
+			status.Failures = append([]string{}, status.Failures...)
+			resp.Slices = append(resp.Slices, status)
+		}
+	}
+	switch {
+	case !r.end.IsZero():
+		resp.Took = r.end.Sub(r.start).Milliseconds()
+	case !r.start.IsZero():
+		resp.Took = time.Since(r.start).Milliseconds()
+	}
+	return resp
+}
+
+// ReindexSlices returns the slices number of the reindex request
+func ReindexSlices(v interface{}, shardNum int64) (int, error) {
+	if v == nil {
+		return 1, nil
+	}
+	if s, ok := v.(string); ok && strings.EqualFold(s, ReindexSlicesAuto) {
+		if shardNum <= 0 {
+			return 1, nil
+		}
+		return int(shardNum), nil
+	}
+	n, err := zutils.ToInt(v)
+	if err != nil || n <= 0 {
+		return 0, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[reindex] slices must be a positive number or [auto], but got [%v]", v))
+	}
+	return n, nil
+}
+
+// reindexSliceOf returns which slice the document belongs to
+func reindexSliceOf(docID string, slices int) int {
+	if slices <= 1 {
+		return 0
+	}
+	return int(fnv64.NewDefaultHasher().Sum64(docID) % uint64(slices))
+}
+
+// parseQuery returns the query of the documents to copy, the nested documents are excluded.
+// Every slice parses its own query as a bluge query can't be searched concurrently.
+func (r *Reindexer) parseQuery() (bluge.Query, error) {
+	var query bluge.Query = bluge.NewMatchAllQuery()
+	if r.query != nil {
+		var err error
+		query, err = uquery.ParseQuery(&meta.ZincQuery{Query: r.query}, r.source.GetMappings(), r.source.GetAnalyzers())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bluge.NewBooleanQuery().AddMust(query).AddMustNot(zincquery.NestedDocumentsQuery()), nil
+}
+
+// reindexSliceReaders returns the readers of the slice and which part of their documents
+// the slice copies. Every reader belongs to one slice when the slices are no more than the
+// readers, otherwise a reader is shared by several slices and split into parts by the hash of _id.
+func reindexSliceReaders(readers []*bluge.Reader, slices, slice int) (rs []*bluge.Reader, parts, part int) {
+	n := len(readers)
+	if n == 0 {
+		return nil, 1, 0
+	}
+	if slices <= n {
+		for i := slice; i < n; i += slices {
+			rs = append(rs, readers[i])
+		}
+		return rs, 1, 0
+	}
+	i := slice % n
+	return []*bluge.Reader{readers[i]}, (slices - i + n - 1) / n, slice / n
+}
+
+func (r *Reindexer) reindexSlice(readers []*bluge.Reader, slice int) error {
+	readers, parts, part := reindexSliceReaders(readers, r.slices, slice)
+	query, err := r.parseQuery()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	batch := 0
+	for _, reader := range readers {
+		dmi, err := reader.Search(ctx, bluge.NewAllMatches(query))
+		if err != nil {
+			return err
+		}
+		next, err := dmi.Next()
+		for err == nil && next != nil && !r.done() {
+			var id, routing string
+			var timestamp time.Time
+			var source []byte
+			skip := false
+			err = next.VisitStoredFields(func(field string, value []byte) bool {
+				switch field {
+				case "_id":
+					// _id is the first stored field, the documents of the other parts are
+					// skipped before their _source is loaded
+					id = string(value)
+					if reindexSliceOf(id, parts) != part {
+						skip = true
+						return false
+					}
+				case "_routing":
+					routing = string(value)
+				case meta.TimeFieldName:
+					timestamp, _ = bluge.DecodeDateTime(value)
+				case "_source":
+					source = value
+				}
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			if !skip {
+				r.reindexDocument(id, routing, timestamp, source, slice)
+				batch++
+				if batch >= r.batchSize {
+					r.lock.Lock()
+					r.statuses[slice].Batches++
+					r.lock.Unlock()
+					batch = 0
+				}
+			}
+			next, err = dmi.Next()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if batch > 0 {
+		r.lock.Lock()
+		r.statuses[slice].Batches++
+		r.lock.Unlock()
+	}
+	return nil
+}
+
+// done returns whether the reindex is aborted by a version conflict
+func (r *Reindexer) done() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.aborted
+}
+
+// reindexDocument writes one document into the dest index, the document keeps its routing. A document
+// which can't be indexed, e.g. the mappings of dest conflict with it, is recorded as a failure and
+// doesn't stop the reindex.
+func (r *Reindexer) reindexDocument(id, routing string, timestamp time.Time, source []byte, slice int) {
+	exists := false
+	_, err := r.dest.GetShardByRouting(id, routing).FindShardByDocID(id)
+	switch {
+	case err == nil:
+		exists = true
+	case err == errors.ErrorIDNotFound:
+		err = nil
+	}
+	if err == nil && !(exists && r.create) {
+		doc := make(map[string]interface{})
+		err = json.Unmarshal(source, &doc)
+		if err == nil {
+			doc[meta.TimeFieldName] = timestamp.UnixNano()
+			_, err = r.dest.CreateDocumentIf(id, doc, exists, RoutingCondition(routing))
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	status := &r.statuses[slice]
+	status.Total++
+	switch {
+	case err != nil:
+		status.Failures = append(status.Failures, id)
+	case exists && r.create:
+		status.VersionConflicts++
+		if !r.proceed {
+			status.Failures = append(status.Failures, id)
+			r.aborted = true
+		}
+	case exists:
+		status.Updated++
+	default:
+		status.Created++
+	}
+}
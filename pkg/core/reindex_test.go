@@ -0,0 +1,259 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestReindexSlices(t *testing.T) {
+	n, err := ReindexSlices(nil, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = ReindexSlices("auto", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = ReindexSlices(float64(4), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	_, err = ReindexSlices("x", 3)
+	assert.Error(t, err)
+	_, err = ReindexSlices(float64(0), 3)
+	assert.Error(t, err)
+}
+
+func TestReindexSliceOf(t *testing.T) {
+	// every document belongs to exactly one slice
+	seen := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		slice := reindexSliceOf(strconv.Itoa(i), 4)
+		assert.GreaterOrEqual(t, slice, 0)
+		assert.Less(t, slice, 4)
+		assert.Equal(t, slice, reindexSliceOf(strconv.Itoa(i), 4))
+		seen[slice]++
+	}
+	assert.Len(t, seen, 4)
+	assert.Equal(t, 0, reindexSliceOf("1", 1))
+}
+
+func TestReindexSliceReaders(t *testing.T) {
+	readers := make([]*bluge.Reader, 3)
+	for i := range readers {
+		readers[i] = new(bluge.Reader)
+	}
+	// every reader belongs to one slice
+	rs, parts, part := reindexSliceReaders(readers, 2, 0)
+	assert.Equal(t, []*bluge.Reader{readers[0], readers[2]}, rs)
+	assert.Equal(t, 1, parts)
+	assert.Equal(t, 0, part)
+	rs, _, _ = reindexSliceReaders(readers, 2, 1)
+	assert.Equal(t, []*bluge.Reader{readers[1]}, rs)
+
+	// the slices sharing a reader split it into parts
+	seen := make(map[*bluge.Reader][]int)
+	for slice := 0; slice < 7; slice++ {
+		rs, parts, part = reindexSliceReaders(readers, 7, slice)
+		assert.Len(t, rs, 1)
+		assert.Less(t, part, parts)
+		seen[rs[0]] = append(seen[rs[0]], part)
+		if rs[0] == readers[0] {
+			assert.Equal(t, 3, parts)
+		} else {
+			assert.Equal(t, 2, parts)
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2}, seen[readers[0]])
+	assert.Equal(t, []int{0, 1}, seen[readers[1]])
+	assert.Equal(t, []int{0, 1}, seen[readers[2]])
+
+	rs, parts, part = reindexSliceReaders(nil, 2, 1)
+	assert.Empty(t, rs)
+	assert.Equal(t, 1, parts)
+	assert.Equal(t, 0, part)
+}
+
+func TestReindex(t *testing.T) {
+	sourceName := "TestReindex.source"
+	destName := "TestReindex.dest"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := NewIndex(sourceName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 20; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		time.Sleep(time.Second * 2)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := Reindex(&meta.ReindexRequest{Dest: meta.ReindexDest{Index: destName}})
+		assert.Error(t, err)
+		_, err = Reindex(&meta.ReindexRequest{Source: meta.ReindexSource{Index: sourceName}})
+		assert.Error(t, err)
+		_, err = Reindex(&meta.ReindexRequest{Source: meta.ReindexSource{Index: sourceName}, Dest: meta.ReindexDest{Index: sourceName}})
+		assert.Error(t, err)
+		_, err = Reindex(&meta.ReindexRequest{Source: meta.ReindexSource{Index: "TestReindex.notExist"}, Dest: meta.ReindexDest{Index: destName}})
+		assert.Error(t, err)
+	})
+
+	t.Run("reindex with slices", func(t *testing.T) {
+		resp, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName, Size: 5},
+			Dest:   meta.ReindexDest{Index: destName},
+			Slices: "auto",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), resp.Total)
+		assert.Equal(t, int64(20), resp.Created)
+		assert.Len(t, resp.Slices, 2)
+		var total int64
+		for _, slice := range resp.Slices {
+			total += slice.Total
+		}
+		assert.Equal(t, resp.Total, total)
+
+		time.Sleep(time.Second * 2)
+		dest, ok := GetIndex(destName)
+		assert.True(t, ok)
+		got, err := dest.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100})
+		assert.NoError(t, err)
+		assert.Equal(t, 20, got.Hits.Total.Value)
+	})
+
+	t.Run("reindex with more slices than readers", func(t *testing.T) {
+		r, err := NewReindexer(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName},
+			Dest:   meta.ReindexDest{Index: destName},
+			Slices: float64(5),
+		})
+		assert.NoError(t, err)
+		status := r.Status()
+		assert.Equal(t, int64(0), status.Total)
+		assert.Len(t, status.Slices, 5)
+
+		resp, err := r.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), resp.Total)
+		assert.Len(t, resp.Slices, 5)
+		var total int64
+		for _, slice := range resp.Slices {
+			total += slice.Total
+		}
+		assert.Equal(t, resp.Total, total)
+		assert.Equal(t, resp, r.Status())
+	})
+
+	t.Run("reindex again updates", func(t *testing.T) {
+		resp, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName},
+			Dest:   meta.ReindexDest{Index: destName},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), resp.Total)
+		assert.Equal(t, int64(0), resp.Created)
+		assert.Equal(t, int64(20), resp.Updated)
+	})
+
+	t.Run("reindex with query", func(t *testing.T) {
+		resp, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName, Query: map[string]interface{}{
+				"ids": map[string]interface{}{"values": []string{"1"}},
+			}},
+			Dest: meta.ReindexDest{Index: destName + "_query"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Total)
+		assert.Equal(t, int64(1), resp.Created)
+
+		_, err = Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName, Query: map[string]interface{}{"xxx": map[string]interface{}{}}},
+			Dest:   meta.ReindexDest{Index: destName + "_query"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("reindex with op_type create", func(t *testing.T) {
+		_, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName},
+			Dest:   meta.ReindexDest{Index: destName, OpType: "xxx"},
+		})
+		assert.Error(t, err)
+		_, err = Reindex(&meta.ReindexRequest{
+			Source:    meta.ReindexSource{Index: sourceName},
+			Dest:      meta.ReindexDest{Index: destName},
+			Conflicts: "xxx",
+		})
+		assert.Error(t, err)
+
+		time.Sleep(time.Second * 2)
+		// abort on the first conflict
+		resp, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName},
+			Dest:   meta.ReindexDest{Index: destName + "_query", OpType: ReindexOpTypeCreate},
+		})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusOK))
+		assert.Equal(t, int64(1), resp.VersionConflicts)
+		assert.Equal(t, []string{"1"}, resp.Failures)
+
+		// the documents created before the abort are conflicts too
+		time.Sleep(time.Second * 2)
+		resp, err = Reindex(&meta.ReindexRequest{
+			Source:    meta.ReindexSource{Index: sourceName},
+			Dest:      meta.ReindexDest{Index: destName + "_query", OpType: ReindexOpTypeCreate},
+			Conflicts: ReindexConflictsProceed,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), resp.Total)
+		assert.GreaterOrEqual(t, resp.VersionConflicts, int64(1))
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, int64(20), resp.Created+resp.VersionConflicts)
+	})
+
+	t.Run("reindex with mapping conflicts", func(t *testing.T) {
+		dest, err := NewIndex(destName+"_mapping", "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("name", meta.NewProperty("numeric"))
+		assert.NoError(t, dest.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(dest))
+
+		resp, err := Reindex(&meta.ReindexRequest{
+			Source: meta.ReindexSource{Index: sourceName},
+			Dest:   meta.ReindexDest{Index: destName + "_mapping"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), resp.Total)
+		assert.Len(t, resp.Failures, 20)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(sourceName))
+		assert.NoError(t, DeleteIndex(destName))
+		assert.NoError(t, DeleteIndex(destName+"_query"))
+		assert.NoError(t, DeleteIndex(destName+"_mapping"))
+	})
+}
@@ -0,0 +1,84 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// ResolveIndex returns the indices, the aliases and the data streams the names expand to,
+// a name can have `*` wildcards, no names or `_all` match everything. A name without
+// wildcard should be an index, an alias or a data stream.
+func ResolveIndex(names []string) (*meta.ResolveIndexResponse, error) {
+	if len(names) == 1 && names[0] == "_all" {
+		names = nil
+	}
+
+	resp := &meta.ResolveIndexResponse{
+		Indices:     make([]*meta.ResolvedIndex, 0),
+		Aliases:     make([]*meta.ResolvedAlias, 0),
+		DataStreams: make([]*meta.ResolvedDataStream, 0),
+	}
+	for _, index := range ZINC_INDEX_LIST.ListMatch(names) {
+		aliases := ZINC_INDEX_ALIAS_LIST.GetAliasesForIndex(index.GetName())
+		sort.Strings(aliases)
+		attribute := "open"
+		if index.IsClosed() {
+			attribute = "closed"
+		}
+		resp.Indices = append(resp.Indices, &meta.ResolvedIndex{
+			Name:       index.GetName(),
+			Aliases:    aliases,
+			Attributes: []string{attribute},
+			DataStream: index.GetDataStream(),
+		})
+	}
+	for alias, indexes := range ZINC_INDEX_ALIAS_LIST.ListMatch(names) {
+		sort.Strings(indexes)
+		resp.Aliases = append(resp.Aliases, &meta.ResolvedAlias{Name: alias, Indices: indexes})
+	}
+	for _, stream := range ZINC_DATA_STREAM_LIST.ListMatch(names) {
+		resp.DataStreams = append(resp.DataStreams, &meta.ResolvedDataStream{
+			Name:           stream.Name,
+			BackingIndices: stream.IndexNames(),
+			TimestampField: stream.TimestampField.Name,
+		})
+	}
+	sort.Slice(resp.Indices, func(i, j int) bool { return resp.Indices[i].Name < resp.Indices[j].Name })
+	sort.Slice(resp.Aliases, func(i, j int) bool { return resp.Aliases[i].Name < resp.Aliases[j].Name })
+
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := GetIndex(name); ok {
+			continue
+		}
+		if _, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(name); ok {
+			continue
+		}
+		if _, ok := ZINC_DATA_STREAM_LIST.Get(name); ok {
+			continue
+		}
+		return nil, errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", name))
+	}
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestResolveIndex(t *testing.T) {
+	t.Run("prepare", func(t *testing.T) {
+		for _, name := range []string{"TestResolveIndex.index_1", "TestResolveIndex.index_2"} {
+			index, err := NewIndex(name, "disk", 1)
+			assert.NoError(t, err)
+			assert.NoError(t, StoreIndex(index))
+		}
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias("TestResolveIndex.alias", []string{"TestResolveIndex.index_2", "TestResolveIndex.index_1"}))
+		assert.NoError(t, CloseIndex("TestResolveIndex.index_2"))
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		resp, err := ResolveIndex([]string{"TestResolveIndex.*"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*meta.ResolvedIndex{
+			{Name: "TestResolveIndex.index_1", Aliases: []string{"TestResolveIndex.alias"}, Attributes: []string{"open"}},
+			{Name: "TestResolveIndex.index_2", Aliases: []string{"TestResolveIndex.alias"}, Attributes: []string{"closed"}},
+		}, resp.Indices)
+		assert.Equal(t, []*meta.ResolvedAlias{
+			{Name: "TestResolveIndex.alias", Indices: []string{"TestResolveIndex.index_1", "TestResolveIndex.index_2"}},
+		}, resp.Aliases)
+		assert.Empty(t, resp.DataStreams)
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		resp, err := ResolveIndex([]string{"TestResolveIndex.alias"})
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Indices)
+		assert.Len(t, resp.Aliases, 1)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := ResolveIndex([]string{"TestResolveIndex.index_1", "TestResolveIndex.index_3"})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, errors.StatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias("TestResolveIndex.alias", []string{"TestResolveIndex.index_1", "TestResolveIndex.index_2"}))
+		assert.NoError(t, DeleteIndex("TestResolveIndex.index_1"))
+		assert.NoError(t, DeleteIndex("TestResolveIndex.index_2"))
+	})
+}
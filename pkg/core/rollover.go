@@ -0,0 +1,189 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+var rolloverIndexNameRe = regexp.MustCompile(`^(.*-)(\d+)$`)
+
+// Rollover creates a new index for the alias and points the writes of the
+// alias to it, when any of the conditions is met by the current write index.
+// No conditions means rollover unconditionally. The new index copies the
+// settings and mappings of the current write index, it is named by
+// increasing the number suffix of the current write index if newName is empty.
+// A data stream rolls over to the backing index of its next generation.
+func Rollover(alias, newName string, req *meta.RolloverRequest, dryRun bool) (*meta.RolloverResponse, error) {
+	if _, ok := ZINC_DATA_STREAM_LIST.Get(alias); ok {
+		if newName != "" {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "new index name may not be specified when rolling over a data stream")
+		}
+		return rolloverDataStream(alias, req, dryRun)
+	}
+	if _, ok := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(alias); !ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] rollover target [%s] does not exist or is not an alias", alias))
+	}
+	oldName, err := ZINC_INDEX_ALIAS_LIST.ResolveWriteIndex(alias)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[rollover] "+err.Error())
+	}
+	old, ok := GetIndex(oldName)
+	if !ok {
+		return nil, fmt.Errorf("index %s does not exists", oldName)
+	}
+	if newName == "" {
+		if newName, err = RolloverIndexName(oldName); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := GetIndex(newName); ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] index [%s] already exists", newName))
+	}
+
+	conditions, met, err := rolloverConditions(old, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &meta.RolloverResponse{
+		OldIndex:   oldName,
+		NewIndex:   newName,
+		DryRun:     dryRun,
+		Conditions: conditions,
+	}
+	if dryRun || !met {
+		return resp, nil
+	}
+
+	index, err := NewIndex(newName, old.GetStorageType(), old.GetShardNum())
+	if err != nil {
+		return nil, err
+	}
+	_ = index.SetSettings(old.GetSettings())
+	_ = index.SetAnalyzers(old.GetAnalyzers())
+	if mappings := old.GetMappings(); mappings != nil {
+		_ = index.SetMappings(mappings.DeepClone())
+	}
+	if err = StoreIndex(index); err != nil {
+		return nil, err
+	}
+
+	// an alias with the write index marked keeps the old index for reads,
+	// otherwise the alias moves to the new index
+	if err = ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{newName}); err != nil {
+		return nil, err
+	}
+	if _, ok := ZINC_INDEX_ALIAS_LIST.GetWriteIndex(alias); ok {
+		err = ZINC_INDEX_ALIAS_LIST.SetWriteIndex(alias, newName)
+	} else {
+		err = ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{oldName})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = old.setRolledOver(time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp.Acknowledged = true
+	resp.RolledOver = true
+	return resp, nil
+}
+
+// RolloverIndexName returns the next index name of the rollover,
+// the name must end with `-` and a number, like logs-000001
+func RolloverIndexName(name string) (string, error) {
+	matches := rolloverIndexNameRe.FindStringSubmatch(name)
+	if matches == nil {
+		return "", errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] index name [%s] does not match pattern '^.*-\\d+$'", name))
+	}
+	n, err := strconv.ParseUint(matches[2], 10, 64)
+	if err != nil {
+		return "", errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] index name [%s] does not match pattern '^.*-\\d+$'", name))
+	}
+	return fmt.Sprintf("%s%06d", matches[1], n+1), nil
+}
+
+// setRolledOver records the time the index stopped taking the writes, the lifecycle policy ages it from then
+func (index *Index) setRolledOver(t time.Time) error {
+	index.lock.Lock()
+	index.ref.RolledOverAt = t
+	index.lock.Unlock()
+	return storeIndex(index)
+}
+
+// GetRolledOverAt returns the time the index rolled over, zero if it didn't
+func (index *Index) GetRolledOverAt() time.Time {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	return index.ref.RolledOverAt
+}
+
+// getCreatedAt returns the creation time of the index, the indexes created before
+// the creation time is recorded use the time of the earliest document
+func (index *Index) getCreatedAt() time.Time {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	createdAt := index.ref.CreatedAt
+	if createdAt.IsZero() {
+		for _, shard := range index.ref.Shards {
+			if t := shard.Stats.DocTimeMin; t > 0 && (createdAt.IsZero() || t < createdAt.UnixNano()) {
+				createdAt = time.Unix(0, t)
+			}
+		}
+	}
+	return createdAt
+}
+
+// rolloverConditions evaluates the conditions against the index,
+// returns the result of every condition and whether any of them is met
+func rolloverConditions(index *Index, req *meta.RolloverRequest) (map[string]bool, bool, error) {
+	conditions := make(map[string]bool)
+	if req == nil {
+		return conditions, true, nil
+	}
+	stats := index.Stats("docs", "store")
+	if c := req.Conditions.MaxAge; c != "" {
+		maxAge, err := zutils.ParseDuration(c)
+		if err != nil || maxAge <= 0 {
+			return nil, false, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] failed to parse max_age [%s]", c))
+		}
+		createdAt := index.getCreatedAt()
+		conditions["[max_age: "+c+"]"] = !createdAt.IsZero() && time.Since(createdAt) >= maxAge
+	}
+	if c := req.Conditions.MaxDocs; c > 0 {
+		conditions["[max_docs: "+strconv.FormatUint(c, 10)+"]"] = stats.Docs.Count >= c
+	}
+	if c := req.Conditions.MaxSize; c != "" {
+		maxSize, err := zutils.ParseByteSize(c)
+		if err != nil || maxSize == 0 {
+			return nil, false, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rollover] failed to parse max_size [%s]", c))
+		}
+		conditions["[max_size: "+c+"]"] = stats.Store.SizeInBytes >= maxSize
+	}
+
+	met := len(conditions) == 0
+	for _, v := range conditions {
+		met = met || v
+	}
+	return conditions, met, nil
+}
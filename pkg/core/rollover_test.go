@@ -0,0 +1,130 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestRolloverIndexName(t *testing.T) {
+	name, err := RolloverIndexName("logs-000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "logs-000002", name)
+	name, err = RolloverIndexName("logs-2023.01.01-9")
+	assert.NoError(t, err)
+	assert.Equal(t, "logs-2023.01.01-000010", name)
+	name, err = RolloverIndexName("logs-999999")
+	assert.NoError(t, err)
+	assert.Equal(t, "logs-1000000", name)
+	_, err = RolloverIndexName("logs")
+	assert.Error(t, err)
+}
+
+func TestRollover(t *testing.T) {
+	alias := "TestRollover.alias"
+	oldName := "TestRollover.index-000001"
+	newName := "TestRollover.index-000002"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := NewIndex(oldName, "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("name", meta.NewProperty("keyword"))
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 5; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc"}, false)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{oldName}))
+		time.Sleep(time.Second * 2)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := Rollover("TestRollover.notExist", "", nil, false)
+		assert.Error(t, err)
+		_, err = Rollover(alias, "", &meta.RolloverRequest{Conditions: meta.RolloverConditions{MaxAge: "x"}}, false)
+		assert.Error(t, err)
+		_, err = Rollover(alias, "", &meta.RolloverRequest{Conditions: meta.RolloverConditions{MaxSize: "x"}}, false)
+		assert.Error(t, err)
+		_, err = Rollover(alias, oldName, nil, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("conditions not met", func(t *testing.T) {
+		resp, err := Rollover(alias, "", &meta.RolloverRequest{Conditions: meta.RolloverConditions{MaxAge: "1d", MaxDocs: 10}}, false)
+		assert.NoError(t, err)
+		assert.False(t, resp.RolledOver)
+		assert.Equal(t, newName, resp.NewIndex)
+		assert.Equal(t, map[string]bool{"[max_age: 1d]": false, "[max_docs: 10]": false}, resp.Conditions)
+		_, ok := GetIndex(newName)
+		assert.False(t, ok)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		resp, err := Rollover(alias, "", &meta.RolloverRequest{Conditions: meta.RolloverConditions{MaxDocs: 5}}, true)
+		assert.NoError(t, err)
+		assert.True(t, resp.DryRun)
+		assert.False(t, resp.RolledOver)
+		assert.Equal(t, map[string]bool{"[max_docs: 5]": true}, resp.Conditions)
+		_, ok := GetIndex(newName)
+		assert.False(t, ok)
+	})
+
+	t.Run("rollover", func(t *testing.T) {
+		resp, err := Rollover(alias, "", &meta.RolloverRequest{Conditions: meta.RolloverConditions{MaxDocs: 5, MaxSize: "1pb"}}, false)
+		assert.NoError(t, err)
+		assert.True(t, resp.RolledOver)
+		assert.Equal(t, oldName, resp.OldIndex)
+		assert.Equal(t, newName, resp.NewIndex)
+
+		index, ok := GetIndex(newName)
+		assert.True(t, ok)
+		prop, ok := index.GetMappings().GetProperty("name")
+		assert.True(t, ok)
+		assert.Equal(t, "keyword", prop.Type)
+
+		// the alias without write index moves to the new index
+		indexes, _ := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(alias)
+		assert.Equal(t, []string{newName}, indexes)
+	})
+
+	t.Run("rollover with write index", func(t *testing.T) {
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{oldName}))
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.SetWriteIndex(alias, newName))
+		resp, err := Rollover(alias, "", nil, false)
+		assert.NoError(t, err)
+		assert.True(t, resp.RolledOver)
+		assert.Equal(t, "TestRollover.index-000003", resp.NewIndex)
+
+		// the old indexes are kept for reads
+		indexes, _ := ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(alias)
+		assert.ElementsMatch(t, []string{oldName, newName, "TestRollover.index-000003"}, indexes)
+		writeIndex, _ := ZINC_INDEX_ALIAS_LIST.GetWriteIndex(alias)
+		assert.Equal(t, "TestRollover.index-000003", writeIndex)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{oldName, newName, "TestRollover.index-000003"}))
+		assert.NoError(t, DeleteIndex(oldName))
+		assert.NoError(t, DeleteIndex(newName))
+		assert.NoError(t, DeleteIndex("TestRollover.index-000003"))
+	})
+}
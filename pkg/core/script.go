@@ -0,0 +1,72 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"fmt"
+	"time"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// PutStoredScript validates and stores the script, it replaces the script with the same id
+func PutStoredScript(id string, script *meta.Script) error {
+	if id == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[script] id should be not empty")
+	}
+	if script == nil || script.Source == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[script] source should be not empty")
+	}
+	if script.Lang != "" && script.Lang != "painless" && script.Lang != "expression" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] unsupported lang [%s]", script.Lang))
+	}
+	for name, param := range script.Params {
+		if _, err := zutils.ToFloat64(param); err != nil {
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] param [%s] should be a number", name))
+		}
+	}
+	if err := zincquery.ValidateScript(script.Source); err != nil {
+		return errors.New(errors.ErrorTypeParsingException, "[script] compile error").Cause(err)
+	}
+	return metadata.StoredScript.Set(id, meta.StoredScript{
+		ID:        id,
+		Script:    *script,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// GetStoredScript returns the stored script by id
+func GetStoredScript(id string) (*meta.StoredScript, bool, error) {
+	script, err := metadata.StoredScript.Get(id)
+	if err != nil {
+		if err == errors.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return script, true, nil
+}
+
+// DeleteStoredScript deletes the stored script, the queries referencing it fail after deleted
+func DeleteStoredScript(id string) (bool, error) {
+	if _, ok, err := GetStoredScript(id); err != nil || !ok {
+		return false, err
+	}
+	return true, metadata.StoredScript.Delete(id)
+}
@@ -0,0 +1,56 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestStoredScript(t *testing.T) {
+	id := "TestStoredScript.script_1"
+	t.Run("put", func(t *testing.T) {
+		assert.NoError(t, PutStoredScript(id, &meta.Script{Source: "_score * params.boost", Params: map[string]interface{}{"boost": 2}}))
+		assert.Error(t, PutStoredScript("", &meta.Script{Source: "1"}))
+		assert.Error(t, PutStoredScript(id, nil))
+		assert.Error(t, PutStoredScript(id, &meta.Script{Source: "1", Lang: "mustache"}))
+		assert.Error(t, PutStoredScript(id, &meta.Script{Source: "1", Params: map[string]interface{}{"boost": "x"}}))
+		assert.Error(t, PutStoredScript(id, &meta.Script{Source: "unknown(1)"}))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		script, ok, err := GetStoredScript(id)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, id, script.ID)
+		assert.Equal(t, "_score * params.boost", script.Script.Source)
+
+		_, ok, err = GetStoredScript(id + "_not_exists")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		ok, err := DeleteStoredScript(id)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = DeleteStoredScript(id)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -17,53 +17,87 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/search"
 	"github.com/blugelabs/bluge/search/highlight"
 	"github.com/rs/zerolog/log"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/profile"
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	zincsearch "github.com/zincsearch/zincsearch/pkg/bluge/search"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/uquery"
+	"github.com/zincsearch/zincsearch/pkg/uquery/collapse"
 	"github.com/zincsearch/zincsearch/pkg/uquery/fields"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+	"github.com/zincsearch/zincsearch/pkg/uquery/sort"
 	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+	"github.com/zincsearch/zincsearch/pkg/uquery/suggest"
 	"github.com/zincsearch/zincsearch/pkg/uquery/timerange"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
-func (index *Index) Search(query *meta.ZincQuery) (*meta.SearchResponse, error) {
+func (index *Index) Search(query *meta.ZincQuery) (resp *meta.SearchResponse, err error) {
+	startTime := time.Now()
+	defer func() {
+		took := time.Since(startTime)
+		index.incrSearch(took)
+		index.slowLog(query, took, resp)
+	}()
+
 	mappings := index.GetMappings()
 	analyzers := index.GetAnalyzers()
-	_, err := uquery.ParseQueryDSL(query, mappings, analyzers)
+	if err = security.Check(query, security.FieldRules(query.Privileges, index.GetName()), mappings); err != nil {
+		return nil, err
+	}
+	filter := security.DocumentFilter(query.Privileges, index.GetName())
+	security.Restrict(query, filter)
+	parseStart := time.Now()
+	_, err = uquery.ParseQueryDSL(query, mappings, analyzers)
 	if err != nil {
 		return nil, err
 	}
+	parseTook := time.Since(parseStart)
 
 	timeMin, timeMax := timerange.Query(query.Query)
-	readers, err := index.GetReaders(timeMin, timeMax)
+	readers, release, err := index.searchReaders(timeMin, timeMax, query.Preference, query.Routing)
 	if err != nil {
 		log.Printf("index.SearchV2: error accessing reader: %s", err.Error())
 		return nil, err
 	}
-	defer func() {
-		for _, reader := range readers {
-			reader.Close()
-		}
-	}()
+	defer release()
 
-	ctx := context.Background()
-	var cancel context.CancelFunc
-	if query.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(query.Timeout)*time.Second)
-		defer cancel()
+	readerIndexes := make([]string, len(readers))
+	for i := range readers {
+		readerIndexes[i] = index.GetName()
+	}
+	query.Boosts = readerBoosts(query, readerIndexes)
+
+	ctx, cancel := searchContext(query)
+	defer cancel()
+
+	var profiler *profile.Profiler
+	if query.Profile {
+		ids := make([]string, len(readers))
+		for i := range readers {
+			ids[i] = fmt.Sprintf("[%s][%d]", index.GetName(), i)
+		}
+		profiler = profile.New(ids...)
+		profiler.AddParse(parseTook)
+		ctx = profile.NewContext(ctx, profiler)
 	}
 
 	// dmi, err := bluge.MultiSearch(ctx, searchRequest, readers...)
+	collectStart := time.Now()
 	dmi, err := zincsearch.MultiSearch(ctx, query, mappings, analyzers, readers...)
+	profiler.AddCollect(time.Since(collectStart))
 	if err != nil {
 		log.Printf("index.SearchV2: error executing search: %s", err.Error())
-		if err == context.DeadlineExceeded {
+		if ctx.Err() != nil {
 			return &meta.SearchResponse{
 				TimedOut: true,
 				Error:    err.Error(),
@@ -73,10 +107,68 @@ func (index *Index) Search(query *meta.ZincQuery) (*meta.SearchResponse, error)
 		return nil, err
 	}
 
-	return searchV2(index.GetAllShardNum(), int64(len(readers)), dmi, query, mappings)
+	resp, err = searchV2(index.GetAllShardNum(), int64(len(readers)), dmi, query, mappings, profiler)
+	if err != nil {
+		return nil, err
+	}
+	// the search stopped at the timeout or when the client went away, the hits are partial
+	resp.TimedOut = ctx.Err() != nil
+	// the fetch phase is limited by the size of the hits, it completes the partial hits also
+	fetchCtx := context.Background()
+
+	// nested inner hits
+	if err = nestedInnerHits(fetchCtx, readers, resp, query, mappings, analyzers); err != nil {
+		return nil, err
+	}
+
+	// named queries
+	if err = matchedQueries(fetchCtx, readers, resp, query, mappings, analyzers); err != nil {
+		return nil, err
+	}
+
+	// suggest
+	if query.Suggest != nil {
+		suggestFilter, err := security.DocumentQuery(filter, mappings, analyzers)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Suggest, err = suggest.Response(readers, query.Suggest, suggestFilter, mappings, analyzers); err != nil {
+			return nil, err
+		}
+	}
+
+	// field level security
+	security.Hits(query.Privileges, resp.Hits.Hits)
+	security.Suggest(query.Privileges, resp.Suggest)
+
+	resp.Profile = profiler.Response()
+
+	return resp, nil
+}
+
+// searchContext returns the context of the query cancelled at its timeout, or the default timeout of the searches.
+// It is set as the context of the query for the aggregations, the returned func cancels it and restores the query
+func searchContext(query *meta.ZincQuery) (context.Context, context.CancelFunc) {
+	parent := query.Context
+	ctx := parent
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var cancel context.CancelFunc
+	// the timeout is validated by the parsing of the query
+	if timeout, _ := uquery.Timeout(query.Timeout); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	query.Context = ctx
+	return ctx, func() {
+		cancel()
+		query.Context = parent
+	}
 }
 
-func searchV2(shardNum, readerNum int64, dmi search.DocumentMatchIterator, query *meta.ZincQuery, mappings *meta.Mappings) (*meta.SearchResponse, error) {
+func searchV2(shardNum, readerNum int64, dmi search.DocumentMatchIterator, query *meta.ZincQuery, mappings *meta.Mappings, profiler *profile.Profiler) (*meta.SearchResponse, error) {
 	resp := &meta.SearchResponse{
 		Hits: meta.Hits{Hits: []meta.Hit{}},
 	}
@@ -92,70 +184,39 @@ func searchV2(shardNum, readerNum int64, dmi search.DocumentMatchIterator, query
 	}
 
 	Hits := make([]meta.Hit, 0)
+	collectStart := time.Now()
 	next, err := dmi.Next()
+	profiler.AddCollect(time.Since(collectStart))
 	for err == nil && next != nil {
-		var id string
-		var indexName string
-		var timestamp time.Time
-		var sourceData map[string]interface{}
-		var fieldsData map[string]interface{}
-		var highlightData map[string]interface{}
-		if query.Highlight != nil {
-			highlightData = make(map[string]interface{})
-		}
-		err = next.VisitStoredFields(func(field string, value []byte) bool {
-			switch field {
-			case "_id":
-				id = string(value)
-			case "_index":
-				indexName = string(value)
-			case "@timestamp":
-				timestamp, _ = bluge.DecodeDateTime(value)
-			case "_source":
-				sourceData = source.Response(query.Source.(*meta.Source), value)
-				if query.Fields != nil {
-					fieldsData = fields.Response(query.Fields.([]*meta.Field), value, mappings)
-				}
-			default:
-				// highlight
-				if query.Highlight != nil && query.Highlight.Fields != nil {
-					if options, ok := query.Highlight.Fields[field]; ok {
-						if v, ok := next.Locations[field]; ok {
-							if len(options.PreTags) > 0 && len(options.PostTags) > 0 {
-								highlighter := highlight.NewHTMLHighlighterTags(options.PreTags[0], options.PostTags[0])
-								highlightData[field] = highlighter.BestFragments(v, value, options.NumberOfFragments)
-							} else {
-								highlightData[field] = highlighter.BestFragments(v, value, options.NumberOfFragments)
-							}
-						}
-					}
-				}
-			}
-
-			return true
-		})
+		var hit meta.Hit
+		fetchStart := time.Now()
+		hit, err = searchHit(next, query, mappings, highlighter)
+		profiler.AddFetch(time.Since(fetchStart))
 		if err != nil {
 			log.Printf("core.SearchV2: error accessing stored fields: %s", err.Error())
 			continue
 		}
 
-		if query.Source.(*meta.Source) == nil || !query.Source.(*meta.Source).Enable || len(query.Source.(*meta.Source).Fields) == 0 {
-			sourceData["@timestamp"] = timestamp
+		// collapse
+		if query.Collapse != nil {
+			prop, _ := mappings.GetProperty(query.Collapse.Field)
+			if hit.Fields == nil {
+				hit.Fields = make(map[string]interface{})
+			}
+			hit.Fields[query.Collapse.Field] = []interface{}{collapse.Value(next, prop.Type)}
+			if it, ok := dmi.(*zincsearch.CollapseIterator); ok && query.Collapse.InnerHits != nil {
+				if hit.InnerHits, err = collapseInnerHits(it, query, mappings, highlighter); err != nil {
+					log.Printf("core.SearchV2: error accessing inner hits: %s", err.Error())
+					continue
+				}
+			}
 		}
 
-		hit := meta.Hit{
-			Index:     indexName,
-			Type:      "_doc",
-			ID:        id,
-			Score:     next.Score,
-			Timestamp: timestamp,
-			Source:    sourceData,
-			Fields:    fieldsData,
-			Highlight: highlightData,
-		}
 		Hits = append(Hits, hit)
 
+		collectStart = time.Now()
 		next, err = dmi.Next()
+		profiler.AddCollect(time.Since(collectStart))
 	}
 	if err != nil {
 		log.Printf("core.SearchV2: error iterating results: %s", err.Error())
@@ -163,15 +224,264 @@ func searchV2(shardNum, readerNum int64, dmi search.DocumentMatchIterator, query
 
 	resp.Took = int(dmi.Aggregations().Duration().Milliseconds())
 	resp.Shards = meta.Shards{Total: shardNum, Successful: readerNum, Skipped: shardNum - readerNum}
+	total := meta.Total{Value: int(dmi.Aggregations().Count()), Relation: "eq"}
+	if limit, _ := uquery.TrackTotalHits(query.TrackTotalHits); limit >= 0 && total.Value > limit {
+		total = meta.Total{Value: limit, Relation: "gte"}
+	}
 	resp.Hits = meta.Hits{
-		Total:    meta.Total{Value: int(dmi.Aggregations().Count())},
+		Total:    total,
 		MaxScore: dmi.Aggregations().Metric("max_score"),
 		Hits:     Hits,
 	}
+	if it, ok := dmi.(*zincsearch.RescoreIterator); ok {
+		resp.Hits.MaxScore = it.MaxScore()
+	}
 
+	aggregationStart := time.Now()
 	if err := uquery.FormatResponse(resp, query, dmi.Aggregations()); err != nil {
 		log.Printf("core.SearchV2: error format response: %s", err.Error())
 	}
+	profiler.AddAggregation(time.Since(aggregationStart))
 
 	return resp, nil
 }
+
+func searchHit(next *search.DocumentMatch, query *meta.ZincQuery, mappings *meta.Mappings, highlighter *highlight.SimpleHighlighter) (meta.Hit, error) {
+	var id string
+	var indexName string
+	var routing string
+	var timestamp time.Time
+	var sourceData map[string]interface{}
+	var fieldsData map[string]interface{}
+	var sourceBytes []byte
+	var highlightData map[string]interface{}
+	if query.Highlight != nil {
+		highlightData = make(map[string]interface{})
+	}
+	var stored *fields.Stored
+	if patterns, ok := query.StoredFields.([]string); ok {
+		stored = fields.NewStored(patterns, mappings)
+	}
+	err := next.VisitStoredFields(func(field string, value []byte) bool {
+		switch field {
+		case "_id":
+			id = string(value)
+		case "_index":
+			indexName = string(value)
+		case "_routing":
+			routing = string(value)
+		case "@timestamp":
+			timestamp, _ = bluge.DecodeDateTime(value)
+		case "_source":
+			sourceData = source.Response(query.Source.(*meta.Source), value)
+			if query.Fields != nil {
+				sourceBytes = value
+			}
+		default:
+			stored.Visit(field, value)
+			// highlight
+			if query.Highlight != nil && query.Highlight.Fields != nil {
+				if options, ok := query.Highlight.Fields[field]; ok {
+					if v, ok := next.Locations[field]; ok {
+						if len(options.PreTags) > 0 && len(options.PostTags) > 0 {
+							highlighter := highlight.NewHTMLHighlighterTags(options.PreTags[0], options.PostTags[0])
+							highlightData[field] = highlighter.BestFragments(v, value, options.NumberOfFragments)
+						} else {
+							highlightData[field] = highlighter.BestFragments(v, value, options.NumberOfFragments)
+						}
+					}
+				}
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return meta.Hit{}, err
+	}
+
+	if query.Fields != nil {
+		fieldsData = fields.Response(query.Fields.([]*meta.Field), sourceBytes, timestamp, mappings)
+		if fieldsData, err = fields.Runtime(fieldsData, query.Fields.([]*meta.Field), next, mappings); err != nil {
+			return meta.Hit{}, err
+		}
+	}
+	for field, values := range stored.Response() {
+		if fieldsData == nil {
+			fieldsData = make(map[string]interface{})
+		}
+		fieldsData[field] = values
+	}
+
+	// the source isn't stored when it is disabled in the mappings
+	if sourceData != nil && (query.Source.(*meta.Source) == nil || !query.Source.(*meta.Source).Enable || len(query.Source.(*meta.Source).Fields) == 0) {
+		if !source.Excluded(query.Source.(*meta.Source), "@timestamp") {
+			sourceData["@timestamp"] = timestamp
+		}
+	}
+
+	var sortData []interface{}
+	if sorts, ok := query.Sort.(search.SortOrder); ok && len(sorts) > 0 {
+		sortData = sort.Response(sorts, next, mappings)
+	}
+
+	hit := meta.Hit{
+		Index:     indexName,
+		Type:      "_doc",
+		ID:        id,
+		Score:     next.Score,
+		Routing:   routing,
+		Timestamp: timestamp,
+		Fields:    fieldsData,
+		Highlight: highlightData,
+		Sort:      sortData,
+	}
+	if sourceData != nil {
+		hit.Source = sourceData
+	}
+	return hit, nil
+}
+
+// collapseInnerHits returns the inner hits of the current collapse group
+func collapseInnerHits(it *zincsearch.CollapseIterator, query *meta.ZincQuery, mappings *meta.Mappings, highlighter *highlight.SimpleHighlighter) (map[string]meta.InnerHit, error) {
+	docs, total := it.Group()
+	innerHits := query.Collapse.InnerHits
+	hits := make([]meta.Hit, 0, innerHits.Size)
+	var maxScore float64
+	for i := innerHits.From; i < len(docs) && i < innerHits.From+innerHits.Size; i++ {
+		hit, err := searchHit(docs[i], query, mappings, highlighter)
+		if err != nil {
+			return nil, err
+		}
+		if hit.Score > maxScore {
+			maxScore = hit.Score
+		}
+		hits = append(hits, hit)
+	}
+	return map[string]meta.InnerHit{
+		innerHits.Name: {
+			Hits: meta.Hits{
+				Total:    meta.Total{Value: total, Relation: "eq"},
+				MaxScore: maxScore,
+				Hits:     hits,
+			},
+		},
+	}, nil
+}
+
+// nestedInnerHits fills the inner hits of the hits with the matching nested objects of the nested queries
+func nestedInnerHits(ctx context.Context, readers []*bluge.Reader, resp *meta.SearchResponse, query *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) error {
+	if len(mappings.NestedPaths()) == 0 || len(resp.Hits.Hits) == 0 {
+		return nil
+	}
+	nestedQueries, err := uquery.ParseNestedInnerHits(query, mappings, analyzers)
+	if err != nil || len(nestedQueries) == 0 {
+		return err
+	}
+	for i := range resp.Hits.Hits {
+		hit := &resp.Hits.Hits[i]
+		for _, nested := range nestedQueries {
+			q := zincquery.NewFilteredQuery(nested.Query.ChildQuery(), bluge.NewTermQuery(hit.ID).SetField(meta.NestedParentFieldName))
+			request := bluge.NewTopNSearch(nested.Size, q).SetFrom(nested.From).WithStandardAggregations()
+			dmi, err := bluge.MultiSearch(ctx, request, readers...)
+			if err != nil {
+				return err
+			}
+			hits := make([]meta.Hit, 0, nested.Size)
+			next, err := dmi.Next()
+			for err == nil && next != nil {
+				nestedHit := meta.Hit{
+					Index:     hit.Index,
+					Type:      "_doc",
+					ID:        hit.ID,
+					Nested:    &meta.NestedIdentity{Field: nested.Query.Path()},
+					Score:     next.Score,
+					Timestamp: hit.Timestamp,
+				}
+				err = next.VisitStoredFields(func(field string, value []byte) bool {
+					switch field {
+					case meta.NestedOffsetFieldName:
+						offset, _ := bluge.DecodeNumericFloat64(value)
+						nestedHit.Nested.Offset = int(offset)
+					case "_source":
+						nestedHit.Source = source.Response(query.Source.(*meta.Source), value)
+					}
+					return true
+				})
+				if err != nil {
+					return err
+				}
+				hits = append(hits, nestedHit)
+				next, err = dmi.Next()
+			}
+			if err != nil {
+				return err
+			}
+			if hit.InnerHits == nil {
+				hit.InnerHits = make(map[string]meta.InnerHit)
+			}
+			hit.InnerHits[nested.Name] = meta.InnerHit{
+				Hits: meta.Hits{
+					Total:    meta.Total{Value: int(dmi.Aggregations().Count()), Relation: "eq"},
+					MaxScore: dmi.Aggregations().Metric("max_score"),
+					Hits:     hits,
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// matchedQueries fills the names of the named queries matching the hits
+func matchedQueries(ctx context.Context, readers []*bluge.Reader, resp *meta.SearchResponse, query *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) error {
+	if len(resp.Hits.Hits) == 0 {
+		return nil
+	}
+	namedQueries, err := uquery.ParseNamedQueries(query, mappings, analyzers)
+	if err != nil || len(namedQueries) == 0 {
+		return err
+	}
+
+	ids := bluge.NewBooleanQuery()
+	hits := make(map[string][]int, len(resp.Hits.Hits))
+	for i, hit := range resp.Hits.Hits {
+		ids.AddShould(bluge.NewTermQuery(hit.ID).SetField("_id"))
+		hits[hit.Index+"/"+hit.ID] = append(hits[hit.Index+"/"+hit.ID], i)
+	}
+	// an id can be in several indexes
+	size := len(resp.Hits.Hits) * len(readers)
+	for _, named := range namedQueries {
+		request := bluge.NewTopNSearch(size, zincquery.NewFilteredQuery(named.Query(), ids))
+		dmi, err := bluge.MultiSearch(ctx, request, readers...)
+		if err != nil {
+			return err
+		}
+		next, err := dmi.Next()
+		for err == nil && next != nil {
+			var id, indexName string
+			err = next.VisitStoredFields(func(field string, value []byte) bool {
+				switch field {
+				case "_id":
+					id = string(value)
+				case "_index":
+					indexName = string(value)
+				}
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			for _, i := range hits[indexName+"/"+id] {
+				hit := &resp.Hits.Hits[i]
+				if !zutils.SliceExists(hit.MatchedQueries, named.Name()) {
+					hit.MatchedQueries = append(hit.MatchedQueries, named.Name())
+				}
+			}
+			next, err = dmi.Next()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
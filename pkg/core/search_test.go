@@ -16,14 +16,24 @@
 package core
 
 import (
+	"context"
+	"math"
 	"math/rand"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/aggregation"
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 func TestIndex_Search(t *testing.T) {
@@ -286,3 +296,2670 @@ func TestIndex_Search(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestIndex_Suggest(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.suggest.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("title_suggest", meta.NewProperty("completion"))
+		for i, title := range []string{"kubernetes cluster", "kubernetes deployment", "kubernetes service", "docker cluster"} {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{
+				"title":         title,
+				"title_suggest": map[string]interface{}{"input": []interface{}{title}, "weight": float64(i)},
+			}, false)
+			assert.NoError(t, err)
+		}
+		err = index.CreateDocument("4", map[string]interface{}{"title_suggest": map[string]interface{}{"input": 1}}, false)
+		assert.Error(t, err)
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("term", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Size: 0,
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: "kubernets clustr", Term: &meta.TermSuggester{Field: "title"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Suggest["s"], 2)
+		assert.Equal(t, "kubernets", got.Suggest["s"][0].Text)
+		assert.Equal(t, 10, got.Suggest["s"][1].Offset)
+		assert.NotEmpty(t, got.Suggest["s"][0].Options)
+		assert.Equal(t, "kubernetes", got.Suggest["s"][0].Options[0].Text)
+		assert.Equal(t, uint64(3), got.Suggest["s"][0].Options[0].Freq)
+		assert.Equal(t, "cluster", got.Suggest["s"][1].Options[0].Text)
+	})
+
+	t.Run("term suggest_mode missing", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: "kubernetes", Term: &meta.TermSuggester{Field: "title"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Suggest["s"], 1)
+		assert.Empty(t, got.Suggest["s"][0].Options)
+	})
+
+	t.Run("phrase", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: "kubernets cluster", Phrase: &meta.PhraseSuggester{
+					Field:     "title",
+					Highlight: &meta.PhraseSuggesterHighlight{PreTag: "<em>", PostTag: "</em>"},
+				}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Suggest["s"], 1)
+		assert.NotEmpty(t, got.Suggest["s"][0].Options)
+		assert.Equal(t, "kubernetes cluster", got.Suggest["s"][0].Options[0].Text)
+		assert.Equal(t, "<em>kubernetes</em> cluster", got.Suggest["s"][0].Options[0].Highlighted)
+	})
+
+	t.Run("phrase many tokens", func(t *testing.T) {
+		start := time.Now()
+		got, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: strings.Repeat("kubernets clustr dcoker ", 100), Phrase: &meta.PhraseSuggester{Field: "title", MaxErrors: 0.5}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 10*time.Second)
+		assert.Len(t, got.Suggest["s"], 1)
+		assert.Len(t, got.Suggest["s"][0].Options, 5)
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"c": {Prefix: "Ku", Completion: &meta.CompletionSuggester{Field: "title_suggest", Size: 2}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Suggest["c"], 1)
+		assert.Len(t, got.Suggest["c"][0].Options, 2)
+		assert.Equal(t, "kubernetes service", got.Suggest["c"][0].Options[0].Text)
+		assert.Equal(t, "2", got.Suggest["c"][0].Options[0].ID)
+		assert.Equal(t, float64(2), got.Suggest["c"][0].Options[0].DocScore)
+		assert.Equal(t, "kubernetes deployment", got.Suggest["c"][0].Options[1].Text)
+	})
+
+	t.Run("completion fuzzy", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"c": {Prefix: "dcoker", Completion: &meta.CompletionSuggester{Field: "title_suggest", Fuzzy: &meta.CompletionFuzzy{Fuzziness: 2}}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Suggest["c"][0].Options, 1)
+		assert.Equal(t, "docker cluster", got.Suggest["c"][0].Options[0].Text)
+	})
+
+	t.Run("completion not in _all", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"prefix": map[string]interface{}{"_all": "docker cluster\x00"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.Hits.Total.Value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: "kubernets"},
+			},
+		})
+		assert.Error(t, err)
+		_, err = index.Search(&meta.ZincQuery{
+			Suggest: map[string]*meta.Suggest{
+				"s": {Text: "kubernets", Term: &meta.TermSuggester{Field: "title", MaxEdits: 3}},
+			},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Collapse(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.collapse.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("order_id", meta.NewProperty("keyword"))
+		for i := 0; i < 9; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{
+				"order_id": "order" + strconv.Itoa(i%3),
+				"version":  float64(i),
+			}, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("collapse", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Sort:     []interface{}{"-version"},
+			Collapse: &meta.Collapse{Field: "order_id"},
+			Size:     10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 9, got.Hits.Total.Value)
+		assert.Len(t, got.Hits.Hits, 3)
+		assert.Equal(t, "8", got.Hits.Hits[0].ID)
+		assert.Equal(t, []interface{}{"order2"}, got.Hits.Hits[0].Fields["order_id"])
+		assert.Equal(t, "7", got.Hits.Hits[1].ID)
+		assert.Equal(t, "6", got.Hits.Hits[2].ID)
+	})
+
+	t.Run("collapse with from", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Sort:     []interface{}{"-version"},
+			Collapse: &meta.Collapse{Field: "order_id"},
+			From:     1,
+			Size:     1,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Equal(t, "7", got.Hits.Hits[0].ID)
+	})
+
+	t.Run("collapse with inner_hits", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Sort:     []interface{}{"-version"},
+			Collapse: &meta.Collapse{Field: "order_id", InnerHits: &meta.CollapseInnerHits{Name: "recent", Size: 2}},
+			Size:     1,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		inner := got.Hits.Hits[0].InnerHits["recent"]
+		assert.Equal(t, 3, inner.Hits.Total.Value)
+		assert.Len(t, inner.Hits.Hits, 2)
+		assert.Equal(t, "8", inner.Hits.Hits[0].ID)
+		assert.Equal(t, "5", inner.Hits.Hits[1].ID)
+	})
+
+	t.Run("collapse on text field", func(t *testing.T) {
+		index.GetMappings().SetProperty("title", meta.NewProperty("text"))
+		_, err := index.Search(&meta.ZincQuery{Collapse: &meta.Collapse{Field: "title"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_FieldSecurity(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.field_security.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		err = index.CreateDocument("1", map[string]interface{}{
+			"name":   "alice",
+			"email":  "alice@example.com",
+			"user":   map[string]interface{}{"ssn": "123-45-6789", "city": "paris"},
+			"salary": 98765,
+		}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, PutStoredScript("Search.field_security.salary", &meta.Script{Source: "salary * 2"}))
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	except := []*meta.RoleIndices{{
+		Names:         []string{"Search.field_security.*"},
+		FieldSecurity: &meta.FieldSecurity{Grant: []string{"*"}, Except: []string{"email", "user.ssn", "salary"}},
+	}}
+	search := func(privileges []*meta.RoleIndices, query string) (*meta.SearchResponse, error) {
+		q := &meta.ZincQuery{Size: 10}
+		assert.NoError(t, json.Unmarshal([]byte(query), q))
+		q.Privileges = privileges
+		return index.Search(q)
+	}
+
+	t.Run("strip source", func(t *testing.T) {
+		got, err := search(except, `{"query":{"match":{"name":"alice"}}}`)
+		require.NoError(t, err)
+		require.Len(t, got.Hits.Hits, 1)
+		require.IsType(t, map[string]interface{}{}, got.Hits.Hits[0].Source)
+		source := got.Hits.Hits[0].Source.(map[string]interface{})
+		assert.Equal(t, "alice", source["name"])
+		assert.NotContains(t, source, "email")
+		assert.Equal(t, map[string]interface{}{"city": "paris"}, source["user"])
+	})
+
+	t.Run("grant", func(t *testing.T) {
+		got, err := search([]*meta.RoleIndices{{
+			Names:         []string{indexName},
+			FieldSecurity: &meta.FieldSecurity{Grant: []string{"na*"}},
+		}}, `{"query":{"match_all":{}}, "fields":["*"]}`)
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Equal(t, map[string]interface{}{"name": "alice"}, got.Hits.Hits[0].Source)
+		for field := range got.Hits.Hits[0].Fields {
+			assert.Equal(t, "name", field)
+		}
+	})
+
+	t.Run("other index", func(t *testing.T) {
+		got, err := search([]*meta.RoleIndices{{
+			Names:         []string{"other"},
+			FieldSecurity: &meta.FieldSecurity{Grant: []string{"name"}},
+		}}, `{"query":{"match":{"email":"alice@example.com"}}}`)
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Contains(t, got.Hits.Hits[0].Source, "email")
+	})
+
+	for name, query := range map[string]string{
+		"term":                 `{"query":{"bool":{"must":[{"term":{"email":"alice@example.com"}}]}}}`,
+		"sub field":            `{"query":{"match":{"user.ssn":"123"}}}`,
+		"multi_match wildcard": `{"query":{"multi_match":{"query":"alice","fields":["*"]}}}`,
+		"multi_match _all":     `{"query":{"multi_match":{"query":"alice"}}}`,
+		"script field":         `{"query":{"script_score":{"query":{"match_all":{}},"script":{"source":"salary"}}}}`,
+		"script expression":    `{"query":{"script_score":{"query":{"match_all":{}},"script":"salary + 0"}}}`,
+		"script doc value":     `{"query":{"script_score":{"query":{"match_all":{}},"script":"doc['salary'].value"}}}`,
+		"script query":         `{"query":{"script":{"script":{"source":"salary > 1000"}}}}`,
+		"stored script":        `{"query":{"script_score":{"query":{"match_all":{}},"script":{"id":"Search.field_security.salary"}}}}`,
+		"query_string":         `{"query":{"query_string":{"query":"name:alice OR email:alice","default_field":"name"}}}`,
+		"query_string _all":    `{"query":{"query_string":{"query":"alice"}}}`,
+		"exists":               `{"query":{"exists":{"field":"email"}}}`,
+		"aggregation":          `{"aggs":{"emails":{"terms":{"field":"email"}}}}`,
+		"sort":                 `{"sort":["-email"]}`,
+	} {
+		t.Run("block "+name, func(t *testing.T) {
+			_, err := search(except, query)
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusForbidden, errors.StatusCode(err, http.StatusBadRequest))
+		})
+	}
+
+	t.Run("allow script", func(t *testing.T) {
+		_, err := search(except, `{"query":{"script_score":{"query":{"match_all":{}},"script":"_score * 2"}}}`)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		_, err = DeleteStoredScript("Search.field_security.salary")
+		assert.NoError(t, err)
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_DocumentSecurity(t *testing.T) {
+	var err error
+	var index, other *Index
+	indexName := "Search.document_security.index_1"
+	otherName := "Search.document_security.index_2"
+	t.Run("Prepare", func(t *testing.T) {
+		mappings := meta.NewMappings()
+		mappings.SetProperty("tenant", meta.NewProperty("keyword"))
+		mappings.SetProperty("suggest", meta.NewProperty("completion"))
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+		other, err = NewIndex(otherName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, other.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(other))
+
+		for i, tenant := range []string{"acme", "acme", "globex"} {
+			doc := map[string]interface{}{
+				"tenant":  tenant,
+				"title":   "report",
+				"suggest": map[string]interface{}{"input": []interface{}{tenant + " report"}},
+			}
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), doc, false))
+			assert.NoError(t, other.CreateDocument(strconv.Itoa(i), doc, false))
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	acme := []*meta.RoleIndices{{
+		Names: []string{"Search.document_security.*"},
+		Query: map[string]interface{}{"match": map[string]interface{}{"tenant": "acme"}},
+	}}
+	search := func(privileges []*meta.RoleIndices, query string) (*meta.SearchResponse, error) {
+		q := &meta.ZincQuery{Size: 10}
+		assert.NoError(t, json.Unmarshal([]byte(query), q))
+		q.Privileges = privileges
+		return index.Search(q)
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		got, err := search(acme, `{"query":{"match_all":{}}}`)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Hits.Total.Value)
+		for _, hit := range got.Hits.Hits {
+			require.IsType(t, map[string]interface{}{}, hit.Source)
+			assert.Equal(t, "acme", hit.Source.(map[string]interface{})["tenant"])
+		}
+	})
+
+	t.Run("can't bypass", func(t *testing.T) {
+		got, err := search(acme, `{"query":{"bool":{"should":[{"match_all":{}},{"match":{"tenant":"globex"}}]}}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Hits.Total.Value)
+		got, err = search(acme, `{"query":{"match":{"tenant":"globex"}}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.Hits.Total.Value)
+	})
+
+	t.Run("aggregation", func(t *testing.T) {
+		got, err := search(acme, `{"size":0,"aggs":{"tenants":{"terms":{"field":"tenant"}}}}`)
+		require.NoError(t, err)
+		data, _ := json.Marshal(got.Aggregations)
+		assert.Contains(t, string(data), "acme")
+		assert.NotContains(t, string(data), "globex")
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		privileges := []*meta.RoleIndices{{
+			Names:         acme[0].Names,
+			Query:         acme[0].Query,
+			FieldSecurity: &meta.FieldSecurity{Grant: []string{"*"}, Except: []string{"tenant"}},
+		}}
+		got, err := search(privileges, `{"size":0,"suggest":{"c":{"prefix":"globex","completion":{"field":"suggest"}}}}`)
+		require.NoError(t, err)
+		require.Len(t, got.Suggest["c"], 1)
+		assert.Empty(t, got.Suggest["c"][0].Options)
+
+		got, err = search(privileges, `{"size":0,"suggest":{"c":{"prefix":"acme","completion":{"field":"suggest"}}}}`)
+		require.NoError(t, err)
+		require.Len(t, got.Suggest["c"], 1)
+		require.NotEmpty(t, got.Suggest["c"][0].Options)
+		for _, option := range got.Suggest["c"][0].Options {
+			require.IsType(t, map[string]interface{}{}, option.Source)
+			source := option.Source.(map[string]interface{})
+			assert.NotContains(t, source, "tenant")
+			assert.Equal(t, "report", source["title"])
+		}
+	})
+
+	t.Run("term and phrase", func(t *testing.T) {
+		got, err := search(acme, `{"size":0,"suggest":{"t":{"text":"globax","term":{"field":"tenant"}},"p":{"text":"globax","phrase":{"field":"tenant"}}}}`)
+		require.NoError(t, err)
+		require.Len(t, got.Suggest["t"], 1)
+		assert.Empty(t, got.Suggest["t"][0].Options)
+		require.Len(t, got.Suggest["p"], 1)
+		assert.Empty(t, got.Suggest["p"][0].Options)
+
+		got, err = search(acme, `{"size":0,"suggest":{"t":{"text":"acmi","term":{"field":"tenant"}}}}`)
+		require.NoError(t, err)
+		require.Len(t, got.Suggest["t"], 1)
+		require.Len(t, got.Suggest["t"][0].Options, 1)
+		assert.Equal(t, "acme", got.Suggest["t"][0].Options[0].Text)
+		assert.Equal(t, uint64(2), got.Suggest["t"][0].Options[0].Freq)
+	})
+
+	t.Run("json string query", func(t *testing.T) {
+		got, err := search([]*meta.RoleIndices{{
+			Names: []string{indexName},
+			Query: `{"match":{"tenant":"globex"}}`,
+		}}, `{}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Hits.Total.Value)
+	})
+
+	t.Run("multiple indexes", func(t *testing.T) {
+		got, err := MultiSearch([]string{"Search.document_security.*"}, &meta.ZincQuery{Size: 10, Privileges: acme})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, got.Hits.Total.Value)
+
+		_, err = MultiSearch([]string{"Search.document_security.*"}, &meta.ZincQuery{Size: 10, Privileges: []*meta.RoleIndices{
+			{Names: []string{indexName}, Query: map[string]interface{}{"match": map[string]interface{}{"tenant": "acme"}}},
+		}})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, errors.StatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+		assert.NoError(t, DeleteIndex(otherName))
+	})
+}
+
+func TestIndex_FunctionScore(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.function_score.index_1"
+	now := time.Now()
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("published", meta.NewProperty("date"))
+		index.GetMappings().SetProperty("location", meta.NewProperty("geo_point"))
+		docs := []map[string]interface{}{
+			{"title": "zinc search", "views": float64(10), "published": now.Add(-30 * 24 * time.Hour).Format(time.RFC3339), "location": "52.37,4.89"},
+			{"title": "zinc search", "views": float64(300), "published": now.Add(-10 * 24 * time.Hour).Format(time.RFC3339), "location": "48.85,2.35"},
+			{"title": "zinc search", "views": float64(20), "published": now.Add(-time.Hour).Format(time.RFC3339), "location": map[string]interface{}{"lat": 40.71, "lon": -74.0}},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(functionScore map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"function_score": functionScore},
+			Size:  10,
+		})
+	}
+	ids := func(resp *meta.SearchResponse) []string {
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("field_value_factor", func(t *testing.T) {
+		got, err := search(map[string]interface{}{
+			"query":              map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}},
+			"field_value_factor": map[string]interface{}{"field": "views", "modifier": "log1p"},
+			"boost_mode":         "replace",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "0"}, ids(got))
+		assert.InDelta(t, math.Log10(301), got.Hits.Hits[0].Score, 0.0001)
+	})
+
+	t.Run("date decay", func(t *testing.T) {
+		got, err := search(map[string]interface{}{
+			"functions": []interface{}{
+				map[string]interface{}{"gauss": map[string]interface{}{"published": map[string]interface{}{"origin": "now", "scale": "10d", "decay": 0.5}}},
+			},
+			"boost_mode": "replace",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "1", "0"}, ids(got))
+		assert.InDelta(t, 0.5, got.Hits.Hits[1].Score, 0.01)
+	})
+
+	t.Run("geo decay", func(t *testing.T) {
+		got, err := search(map[string]interface{}{
+			"exp":        map[string]interface{}{"location": map[string]interface{}{"origin": map[string]interface{}{"lat": 48.86, "lon": 2.34}, "scale": "500km", "offset": "10km"}},
+			"boost_mode": "replace",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "0", "2"}, ids(got))
+		assert.InDelta(t, 1.0, got.Hits.Hits[0].Score, 0.0001)
+	})
+
+	t.Run("numeric decay with weight and filter", func(t *testing.T) {
+		got, err := search(map[string]interface{}{
+			"functions": []interface{}{
+				map[string]interface{}{"linear": map[string]interface{}{"views": map[string]interface{}{"origin": 0, "scale": 100}}},
+				map[string]interface{}{"filter": map[string]interface{}{"ids": map[string]interface{}{"values": []interface{}{"0"}}}, "weight": 10},
+			},
+			"score_mode": "sum",
+			"boost_mode": "replace",
+			"min_score":  0.6,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0", "2"}, ids(got))
+		assert.InDelta(t, 10.95, got.Hits.Hits[0].Score, 0.0001)
+		assert.InDelta(t, 0.9, got.Hits.Hits[1].Score, 0.0001)
+	})
+
+	t.Run("script_score function", func(t *testing.T) {
+		got, err := search(map[string]interface{}{
+			"script_score": map[string]interface{}{"script": map[string]interface{}{"source": "params.factor * log(1 + views)", "params": map[string]interface{}{"factor": 2}}},
+			"boost_mode":   "replace",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "0"}, ids(got))
+		assert.InDelta(t, 2*math.Log(301), got.Hits.Hits[0].Score, 0.0001)
+	})
+
+	t.Run("script_score query", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"script_score": map[string]interface{}{
+				"query":     map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}},
+				"script":    "doc['views'].value < 100 ? _score * views : 0",
+				"min_score": 0.1,
+			}},
+			Size: 10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "0"}, ids(got))
+	})
+
+	t.Run("script_score with compile error", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"script_score": map[string]interface{}{
+				"query":  map[string]interface{}{"match_all": map[string]interface{}{}},
+				"script": "_score * (views",
+			}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid function", func(t *testing.T) {
+		_, err := search(map[string]interface{}{
+			"gauss": map[string]interface{}{"title": map[string]interface{}{"origin": "zinc", "scale": 1}},
+		})
+		assert.Error(t, err)
+		_, err = search(map[string]interface{}{"score_mode": "median"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_QueryString(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.query_string.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("status", meta.NewProperty("keyword"))
+		index.GetMappings().SetProperty("level", meta.NewProperty("numeric"))
+		docs := []map[string]interface{}{
+			{"status": "error", "level": float64(5), "message": "disk is full on server alpha"},
+			{"status": "error", "level": float64(2), "message": "connection refused by server beta"},
+			{"status": "ok", "level": float64(4), "message": "full backup finished"},
+			{"status": "warn", "level": float64(3), "message": "server alpha is slow"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(queryString map[string]interface{}) ([]string, error) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"query_string": queryString},
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"field and range", map[string]interface{}{"query": "status:error AND level:>3"}, []string{"0"}},
+		{"inclusive range", map[string]interface{}{"query": "level:[3 TO 4]"}, []string{"2", "3"}},
+		{"open range", map[string]interface{}{"query": "level:{3 TO *]"}, []string{"0", "2"}},
+		{"grouping", map[string]interface{}{"query": "status:(ok OR warn) AND NOT message:slow"}, []string{"2"}},
+		{"phrase", map[string]interface{}{"query": `message:"server alpha"`}, []string{"0", "3"}},
+		{"wildcard", map[string]interface{}{"query": "message:ful*"}, []string{"0", "2"}},
+		{"modifiers", map[string]interface{}{"query": "+message:server -status:warn"}, []string{"0", "1"}},
+		{"default field", map[string]interface{}{"query": "alpha", "default_field": "message"}, []string{"0", "3"}},
+		{"default operator", map[string]interface{}{"query": "server alpha", "default_field": "message", "default_operator": "AND"}, []string{"0", "3"}},
+		{"fields", map[string]interface{}{"query": "ok", "fields": []interface{}{"status", "message^2"}}, []string{"2"}},
+		{"exists", map[string]interface{}{"query": "_exists_:status AND level:<=2"}, []string{"1"}},
+		{"match all", map[string]interface{}{"query": "*:*"}, []string{"0", "1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		for query, pos := range map[string]string{
+			"status:(error":    "position 7",
+			`message:"server`:  "position 8",
+			"level:[1 TO 5":    "position 6",
+			"status:error AND": "position 16",
+			"level:>abc":       "position 6",
+			"AND status:error": "position 0",
+		} {
+			_, err := search(map[string]interface{}{"query": query})
+			assert.Error(t, err, query)
+			if err != nil {
+				assert.Contains(t, err.Error(), pos, query)
+			}
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_SimpleQueryString(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.simple_query_string.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("tag", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"title": "fried eggs with bread", "tag": "breakfast"},
+			{"title": "eggplant parmesan", "tag": "dinner"},
+			{"title": "potato frittata", "tag": "breakfast"},
+			{"title": "eggs fried in butter", "tag": "lunch"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(simpleQueryString map[string]interface{}) ([]string, error) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"simple_query_string": simpleQueryString},
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"or", map[string]interface{}{"query": "eggplant | potato", "fields": []interface{}{"title"}}, []string{"1", "2"}},
+		{"and", map[string]interface{}{"query": "eggs + butter", "fields": []interface{}{"title"}}, []string{"3"}},
+		{"not", map[string]interface{}{"query": "eggs -bread", "fields": []interface{}{"title"}, "default_operator": "and"}, []string{"3"}},
+		{"phrase", map[string]interface{}{"query": `"fried eggs"`, "fields": []interface{}{"title"}}, []string{"0"}},
+		{"phrase slop", map[string]interface{}{"query": `"fried eggs"~2`, "fields": []interface{}{"title"}}, []string{"0", "3"}},
+		{"prefix", map[string]interface{}{"query": "egg*", "fields": []interface{}{"title"}}, []string{"0", "1", "3"}},
+		{"fuzzy", map[string]interface{}{"query": "potatoe~1", "fields": []interface{}{"title"}}, []string{"2"}},
+		{"precedence", map[string]interface{}{"query": "breakfast + (eggs | frittata)", "fields": []interface{}{"tag", "title"}}, []string{"0", "2"}},
+		{"fields with boost", map[string]interface{}{"query": "breakfast", "fields": []interface{}{"tag^2", "title"}}, []string{"0", "2"}},
+		{"default field", map[string]interface{}{"query": "parmesan"}, []string{"1"}},
+		{"flags disable operators", map[string]interface{}{"query": "eggplant | potato", "fields": []interface{}{"title"}, "flags": "AND|WHITESPACE", "default_operator": "and"}, []string{}},
+		{"malformed", map[string]interface{}{"query": `(potato | "fried )) +`, "fields": []interface{}{"title"}}, []string{"0", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("unknown flag", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"query": "eggs", "flags": "AND|UNKNOWN"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_MultiMatch(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.multi_match.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"first_name": "will", "last_name": "smith", "title": "actor", "body": "will smith and will smith"},
+			{"first_name": "smith", "last_name": "jones", "title": "smith", "body": "blacksmith"},
+			{"first_name": "john", "last_name": "will", "title": "quick brown fox", "body": "the quick brown fox"},
+			{"first_name": "jane", "last_name": "doe", "title": "fox", "body": "a brown quick fox"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(multiMatch map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"multi_match": multiMatch},
+			Sort:  []interface{}{"-_score", "_id"},
+			Size:  10,
+		})
+	}
+	ids := func(resp *meta.SearchResponse) []string {
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("best_fields with field boost", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"query": "smith", "fields": []interface{}{"title^3", "body"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "0"}, ids(got))
+	})
+
+	t.Run("best_fields tie_breaker", func(t *testing.T) {
+		best, err := search(map[string]interface{}{"query": "smith", "fields": []interface{}{"first_name", "last_name"}})
+		assert.NoError(t, err)
+		tie, err := search(map[string]interface{}{"query": "smith", "fields": []interface{}{"first_name", "last_name"}, "tie_breaker": 1})
+		assert.NoError(t, err)
+		most, err := search(map[string]interface{}{"query": "smith", "fields": []interface{}{"first_name", "last_name"}, "type": "most_fields"})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"0", "1"}, ids(best))
+		assert.Equal(t, ids(most), ids(tie))
+		assert.InDelta(t, most.Hits.Hits[0].Score, tie.Hits.Hits[0].Score, 0.0001)
+	})
+
+	t.Run("operator and minimum_should_match", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"query": "quick brown fox", "fields": []interface{}{"title"}, "operator": "and"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(got))
+		got, err = search(map[string]interface{}{"query": "quick brown dog", "fields": []interface{}{"title", "body"}, "minimum_should_match": "100%"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, ids(got))
+	})
+
+	t.Run("cross_fields", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"query": "will smith", "fields": []interface{}{"first_name", "last_name"}, "type": "cross_fields", "operator": "and"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0"}, ids(got))
+		got, err = search(map[string]interface{}{"query": "john will", "fields": []interface{}{"first_name", "last_name"}, "type": "cross_fields", "operator": "and"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(got))
+	})
+
+	t.Run("phrase", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"query": "quick brown", "fields": []interface{}{"title", "body"}, "type": "phrase"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(got))
+		got, err = search(map[string]interface{}{"query": "quick brown", "fields": []interface{}{"body"}, "type": "phrase", "slop": 2})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"2", "3"}, ids(got))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"query": "smith", "type": "unknown"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Nested(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.nested.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("title", meta.NewProperty("text"))
+		mappings.SetProperty("authors", meta.NewProperty("nested"))
+		mappings.SetProperty("authors.name", meta.NewProperty("text"))
+		mappings.SetProperty("authors.role", meta.NewProperty("keyword"))
+		err = index.SetMappings(mappings)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"title": "go book", "authors": []interface{}{
+				map[string]interface{}{"name": "john smith", "role": "author"},
+				map[string]interface{}{"name": "jane doe", "role": "editor"},
+			}},
+			{"title": "rust book", "authors": []interface{}{
+				map[string]interface{}{"name": "jane doe", "role": "author"},
+			}},
+			{"title": "zig book", "authors": map[string]interface{}{"name": "john smith", "role": "editor"}},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: query,
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+	}
+	nested := func(name, role string) map[string]interface{} {
+		return map[string]interface{}{"nested": map[string]interface{}{
+			"path": "authors",
+			"query": map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{
+				map[string]interface{}{"match": map[string]interface{}{"authors.name": name}},
+				map[string]interface{}{"term": map[string]interface{}{"authors.role": role}},
+			}}},
+		}}
+	}
+	ids := func(resp *meta.SearchResponse) []string {
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("match all skips nested documents", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"match_all": map[string]interface{}{}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0", "1", "2"}, ids(got))
+		assert.Equal(t, 3, got.Hits.Total.Value)
+	})
+
+	t.Run("single nested object matches all clauses", func(t *testing.T) {
+		got, err := search(nested("jane", "author"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, ids(got))
+		got, err = search(nested("john", "editor"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(got))
+		got, err = search(nested("john", "author"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0"}, ids(got))
+	})
+
+	t.Run("inner_hits", func(t *testing.T) {
+		query := nested("jane", "editor")
+		query["nested"].(map[string]interface{})["inner_hits"] = map[string]interface{}{}
+		got, err := search(query)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0"}, ids(got))
+		innerHits, ok := got.Hits.Hits[0].InnerHits["authors"]
+		assert.True(t, ok)
+		assert.Equal(t, 1, innerHits.Hits.Total.Value)
+		assert.Len(t, innerHits.Hits.Hits, 1)
+		assert.Equal(t, &meta.NestedIdentity{Field: "authors", Offset: 1}, innerHits.Hits.Hits[0].Nested)
+		source, _ := innerHits.Hits.Hits[0].Source.(map[string]interface{})
+		assert.Equal(t, "jane doe", source["name"])
+		assert.Equal(t, "editor", source["role"])
+	})
+
+	t.Run("update and delete replace nested documents", func(t *testing.T) {
+		err := index.UpdateDocument("1", map[string]interface{}{"title": "rust book", "authors": []interface{}{
+			map[string]interface{}{"name": "jane doe", "role": "editor"},
+		}}, false)
+		assert.NoError(t, err)
+		time.Sleep(time.Second)
+		got, err := search(nested("jane", "author"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, ids(got))
+		got, err = search(nested("jane", "editor"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0", "1"}, ids(got))
+
+		err = index.DeleteDocument("0")
+		assert.NoError(t, err)
+		time.Sleep(time.Second)
+		got, err = search(nested("jane", "editor"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, ids(got))
+	})
+
+	t.Run("unmapped path", func(t *testing.T) {
+		query := map[string]interface{}{"nested": map[string]interface{}{
+			"path":  "title",
+			"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+		}}
+		_, err := search(query)
+		assert.Error(t, err)
+		query["nested"].(map[string]interface{})["ignore_unmapped"] = true
+		got, err := search(query)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, ids(got))
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_RangeDateMath(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.range.index_1"
+	now := time.Now().UTC()
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		prop := meta.NewProperty("date")
+		prop.Format = time.RFC3339
+		mappings.SetProperty("created", prop)
+		mappings.SetProperty("count", meta.NewProperty("numeric"))
+		err = index.SetMappings(mappings)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"created": now.Add(-50 * time.Hour).Format(time.RFC3339), "count": 1},
+			{"created": now.Add(-3 * time.Hour).Format(time.RFC3339), "count": 2},
+			{"created": now.Add(-10 * time.Minute).Format(time.RFC3339), "count": 3},
+			{"created": now.Add(48 * time.Hour).Format(time.RFC3339), "count": 4},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(field string, rangeQuery map[string]interface{}) ([]string, error) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"range": map[string]interface{}{field: rangeQuery}},
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"now offset", "created", map[string]interface{}{"gte": "now-1h", "lte": "now"}, []string{"2"}},
+		{"days", "created", map[string]interface{}{"gte": "now-1d/d"}, []string{"1", "2"}},
+		{"future", "created", map[string]interface{}{"gt": "now/d", "lte": "now+3d"}, []string{"3"}},
+		{"rounding with time_zone", "created", map[string]interface{}{"gte": "now-49h/h", "lt": "now+1d", "time_zone": "+08:00"}, []string{"1", "2"}},
+		{"date anchor", "created", map[string]interface{}{"gte": now.Add(-4*time.Hour).Format(time.RFC3339) + "||+2h"}, []string{"2"}},
+		{"plain date", "created", map[string]interface{}{"lt": now.Add(-time.Hour).Format(time.RFC3339)}, []string{"0", "1"}},
+		{"numeric", "count", map[string]interface{}{"gt": 1, "lte": 3}, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.field, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("invalid date math", func(t *testing.T) {
+		_, err := search("created", map[string]interface{}{"gte": "now-1x"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_DateFormats(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.date_formats.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		prop := meta.NewProperty("date")
+		prop.Format = "2006-01-02||epoch_millis"
+		mappings.SetProperty("created", prop)
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"created": "2022-10-01"}, false))
+		assert.NoError(t, index.CreateDocument("2", map[string]interface{}{"created": "1664755200000"}, false))
+		assert.NoError(t, index.CreateDocument("3", map[string]interface{}{"created": "2022-10-05"}, false))
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		err := index.CreateDocument("4", map[string]interface{}{"created": "10/01/2022"}, false)
+		assert.ErrorContains(t, err, "doesn't match any of the formats")
+	})
+
+	t.Run("range", func(t *testing.T) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"range": map[string]interface{}{"created": map[string]interface{}{
+				"gte": "2022-10-02",
+				"lte": "1664841600000",
+			}}},
+			Fields: []interface{}{"created"},
+			Size:   10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Hits.Hits, 1)
+		assert.Equal(t, "2", resp.Hits.Hits[0].ID)
+		// the value is returned in the first format of the mapping
+		assert.Equal(t, []interface{}{"2022-10-03"}, resp.Hits.Hits[0].Fields["created"])
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Boosting(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.boosting.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("category", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"title": "apple pie", "category": "food"},
+			{"title": "apple iphone", "category": "phone"},
+			{"title": "apple tart", "category": "food"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: query,
+			Sort:  []interface{}{"-_score", "_id"},
+			Size:  10,
+		})
+	}
+	boosting := map[string]interface{}{
+		"positive":       map[string]interface{}{"match": map[string]interface{}{"title": "apple"}},
+		"negative":       map[string]interface{}{"term": map[string]interface{}{"category": "phone"}},
+		"negative_boost": 0.2,
+	}
+
+	t.Run("demote negative matches", func(t *testing.T) {
+		plain, err := search(map[string]interface{}{"bool": map[string]interface{}{
+			"must":   []interface{}{boosting["positive"]},
+			"filter": []interface{}{map[string]interface{}{"term": map[string]interface{}{"_id": "1"}}},
+		}})
+		assert.NoError(t, err)
+		assert.Len(t, plain.Hits.Hits, 1)
+		got, err := search(map[string]interface{}{"boosting": boosting})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 3)
+		assert.Equal(t, "1", got.Hits.Hits[2].ID)
+		assert.InDelta(t, plain.Hits.Hits[0].Score*0.2, got.Hits.Hits[2].Score, 0.0001)
+	})
+
+	t.Run("inside bool", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"bool": map[string]interface{}{
+			"must":   []interface{}{map[string]interface{}{"boosting": boosting}},
+			"filter": []interface{}{map[string]interface{}{"terms": map[string]interface{}{"_id": []interface{}{"1", "2"}}}},
+		}})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 2)
+		assert.Equal(t, "2", got.Hits.Hits[0].ID)
+		assert.Equal(t, "1", got.Hits.Hits[1].ID)
+	})
+
+	t.Run("missing positive", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"boosting": map[string]interface{}{
+			"negative":       map[string]interface{}{"match_all": map[string]interface{}{}},
+			"negative_boost": 0.2,
+		}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_DisMax(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.dis_max.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"title": "brown fox", "body": "brown fox"},
+			{"title": "brown fox", "body": "lazy dog"},
+			{"title": "lazy dog", "body": "sleeping cat"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"dis_max": query},
+			Sort:  []interface{}{"-_score", "_id"},
+			Size:  10,
+		})
+	}
+	scores := func(resp *meta.SearchResponse) map[string]float64 {
+		scores := make(map[string]float64, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			scores[hit.ID] = hit.Score
+		}
+		return scores
+	}
+	queries := []interface{}{
+		map[string]interface{}{"match": map[string]interface{}{"title": "fox"}},
+		map[string]interface{}{"match": map[string]interface{}{"body": "fox"}},
+		map[string]interface{}{"match": map[string]interface{}{"body": "cat"}},
+	}
+
+	t.Run("max of sub queries", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"queries": queries})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 3)
+		title, err := search(map[string]interface{}{"queries": queries[:1]})
+		assert.NoError(t, err)
+		body, err := search(map[string]interface{}{"queries": queries[1:2]})
+		assert.NoError(t, err)
+		want := scores(title)["0"]
+		if s := scores(body)["0"]; s > want {
+			want = s
+		}
+		assert.InDelta(t, want, scores(got)["0"], 0.0001)
+	})
+
+	t.Run("tie_breaker", func(t *testing.T) {
+		plain, err := search(map[string]interface{}{"queries": queries})
+		assert.NoError(t, err)
+		tie, err := search(map[string]interface{}{"queries": queries, "tie_breaker": 0.3})
+		assert.NoError(t, err)
+		body, err := search(map[string]interface{}{"queries": queries[1:2]})
+		assert.NoError(t, err)
+		title, err := search(map[string]interface{}{"queries": queries[:1]})
+		assert.NoError(t, err)
+		min := scores(body)["0"]
+		if s := scores(title)["0"]; s < min {
+			min = s
+		}
+		assert.InDelta(t, scores(plain)["0"]+0.3*min, scores(tie)["0"], 0.0001)
+		assert.InDelta(t, scores(plain)["1"], scores(tie)["1"], 0.0001)
+	})
+
+	t.Run("missing queries", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"tie_breaker": 0.3})
+		assert.Error(t, err)
+		_, err = search(map[string]interface{}{"queries": []interface{}{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_ConstantScore(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.constant_score.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("status", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"title": "zinc search zinc", "status": "published"},
+			{"title": "zinc", "status": "draft"},
+			{"title": "search engine", "status": "published"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) (*meta.SearchResponse, error) {
+		return index.Search(&meta.ZincQuery{
+			Query: query,
+			Sort:  []interface{}{"-_score", "_id"},
+			Size:  10,
+		})
+	}
+
+	t.Run("every match gets the boost", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"constant_score": map[string]interface{}{
+			"filter": map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}},
+			"boost":  2.0,
+		}})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 2)
+		for _, hit := range got.Hits.Hits {
+			assert.Equal(t, 2.0, hit.Score)
+		}
+	})
+
+	t.Run("default boost", func(t *testing.T) {
+		got, err := search(map[string]interface{}{"constant_score": map[string]interface{}{
+			"filter": map[string]interface{}{"term": map[string]interface{}{"status": "published"}},
+		}})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 2)
+		for _, hit := range got.Hits.Hits {
+			assert.Equal(t, 1.0, hit.Score)
+		}
+	})
+
+	t.Run("combined with scored clauses", func(t *testing.T) {
+		scored, err := search(map[string]interface{}{"match": map[string]interface{}{"title": "search"}})
+		assert.NoError(t, err)
+		got, err := search(map[string]interface{}{"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"match": map[string]interface{}{"title": "search"}},
+				map[string]interface{}{"constant_score": map[string]interface{}{
+					"filter": map[string]interface{}{"term": map[string]interface{}{"status": "published"}},
+					"boost":  10,
+				}},
+			},
+		}})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 2)
+		want := make(map[string]float64)
+		for _, hit := range scored.Hits.Hits {
+			want[hit.ID] = hit.Score + 10
+		}
+		for _, hit := range got.Hits.Hits {
+			assert.InDelta(t, want[hit.ID], hit.Score, 0.0001)
+		}
+	})
+
+	t.Run("missing filter", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"constant_score": map[string]interface{}{"boost": 2}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Span(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.span.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("status", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"claim": "a rotating shaft coupled to a motor", "status": "granted"},
+			{"claim": "a motor coupled to a rotating shaft", "status": "granted"},
+			{"claim": "the shaft is made of steel and rotating freely", "status": "pending"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) ([]string, error) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: query,
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+	spanTerm := func(term string) map[string]interface{} {
+		return map[string]interface{}{"span_term": map[string]interface{}{"claim": term}}
+	}
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"span_term", spanTerm("steel"), []string{"2"}},
+		{"span_near in order", map[string]interface{}{"span_near": map[string]interface{}{
+			"clauses": []interface{}{spanTerm("rotating"), spanTerm("shaft")}, "slop": 0, "in_order": true,
+		}}, []string{"0", "1"}},
+		{"span_near slop", map[string]interface{}{"span_near": map[string]interface{}{
+			"clauses": []interface{}{spanTerm("shaft"), spanTerm("motor")}, "slop": 4, "in_order": true,
+		}}, []string{"0"}},
+		{"span_near unordered", map[string]interface{}{"span_near": map[string]interface{}{
+			"clauses": []interface{}{spanTerm("shaft"), spanTerm("motor")}, "slop": 4, "in_order": false,
+		}}, []string{"0", "1"}},
+		{"span_first", map[string]interface{}{"span_first": map[string]interface{}{
+			"match": spanTerm("motor"), "end": 2,
+		}}, []string{"1"}},
+		{"inside bool", map[string]interface{}{"bool": map[string]interface{}{
+			"must": []interface{}{map[string]interface{}{"span_near": map[string]interface{}{
+				"clauses": []interface{}{spanTerm("rotating"), spanTerm("shaft")}, "slop": 5, "in_order": false,
+			}}},
+			"filter": []interface{}{map[string]interface{}{"term": map[string]interface{}{"status": "pending"}}},
+		}}, []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("field without positions", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"span_term": map[string]interface{}{"status": "granted"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "position")
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_MatchPhrasePrefix(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.match_phrase_prefix.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"title": "quick brown fox"},
+			{"title": "brown quick fox"},
+			{"title": "quick little brown fawn"},
+			{"title": "quick brown fig"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(query map[string]interface{}) ([]string, error) {
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match_phrase_prefix": map[string]interface{}{"title": query}},
+			Sort:  []interface{}{"_id"},
+			Size:  10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"phrase with prefix", map[string]interface{}{"query": "quick brown f"}, []string{"0", "3"}},
+		{"prefix only", map[string]interface{}{"query": "fa"}, []string{"2"}},
+		{"order is kept", map[string]interface{}{"query": "brown quick f"}, []string{"1"}},
+		{"slop", map[string]interface{}{"query": "quick brown f", "slop": 1}, []string{"0", "2", "3"}},
+		{"max_expansions", map[string]interface{}{"query": "quick brown f", "slop": 1, "max_expansions": 1}, []string{"2"}},
+		{"no expansion", map[string]interface{}{"query": "quick brown z"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("invalid max_expansions", func(t *testing.T) {
+		_, err := search(map[string]interface{}{"query": "quick brown f", "max_expansions": 0})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_BoolMinimumShouldMatch(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.bool_minimum_should_match.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"tags": "red"},
+			{"tags": "red green"},
+			{"tags": "red green blue"},
+			{"tags": "red green blue black"},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(tags []string, minimumShouldMatch interface{}) ([]string, error) {
+		shoulds := make([]interface{}, 0, len(tags))
+		for _, tag := range tags {
+			shoulds = append(shoulds, map[string]interface{}{"term": map[string]interface{}{"tags": tag}})
+		}
+		resp, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"bool": map[string]interface{}{
+				"should":               shoulds,
+				"minimum_should_match": minimumShouldMatch,
+			}},
+			Sort: []interface{}{"_id"},
+			Size: 10,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids, nil
+	}
+
+	all := []string{"red", "green", "blue", "black"}
+	tests := []struct {
+		name               string
+		tags               []string
+		minimumShouldMatch interface{}
+		want               []string
+	}{
+		{"integer", all, 2, []string{"1", "2", "3"}},
+		{"negative integer", all, -1, []string{"2", "3"}},
+		{"percentage", all, "50%", []string{"1", "2", "3"}},
+		{"negative percentage", all, "-25%", []string{"2", "3"}},
+		{"combination below condition", all[:2], "2<75%", []string{"1", "2", "3"}},
+		{"combination above condition", all, "2<75%", []string{"2", "3"}},
+		{"multiple combinations", all, "1<-1 3<100%", []string{"3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := search(tt.tags, tt.minimumShouldMatch)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, v := range []interface{}{"abc", "2<", "75.5%", "2<50% x", true} {
+			_, err := search(all, v)
+			assert.Error(t, err, v)
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Profile(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.profile.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, index.Flush())
+	})
+
+	t.Run("without profile", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 10})
+		assert.NoError(t, err)
+		assert.Nil(t, got.Profile)
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{
+				"bool": map[string]interface{}{
+					"must": []interface{}{map[string]interface{}{"wildcard": map[string]interface{}{"name": "doc*"}}},
+				},
+			},
+			Aggregations: map[string]meta.Aggregations{"names": {Terms: &meta.AggregationsTerms{Field: "name"}}},
+			Size:         5,
+			Profile:      true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+		assert.NotNil(t, got.Profile)
+		assert.Greater(t, got.Profile.Phases.ParseTimeInNanos, int64(0))
+		assert.Greater(t, got.Profile.Phases.CollectTimeInNanos, int64(0))
+		assert.Greater(t, got.Profile.Phases.FetchTimeInNanos, int64(0))
+		assert.Greater(t, got.Profile.Phases.AggregationTimeInNanos, int64(0))
+		assert.Len(t, got.Profile.Shards, len(index.shards))
+		for i, shard := range got.Profile.Shards {
+			assert.Equal(t, "[Search.profile.index_1]["+strconv.Itoa(i)+"]", shard.ID)
+			query := shard.Searches[0].Query[0]
+			assert.Equal(t, "BooleanQuery", query.Type)
+			assert.Len(t, query.Children, 1)
+		}
+	})
+
+	t.Run("multi search profile", func(t *testing.T) {
+		got, err := MultiSearch([]string{indexName}, &meta.ZincQuery{Size: 5, Profile: true})
+		assert.NoError(t, err)
+		assert.NotNil(t, got.Profile)
+		assert.Len(t, got.Profile.Shards, len(index.shards))
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_SourceFiltering(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.source.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		err = index.CreateDocument("1", map[string]interface{}{
+			"a": map[string]interface{}{
+				"name":   "zinc",
+				"secret": "xxx",
+				"nested": map[string]interface{}{"name": "inner", "secret": "yyy"},
+			},
+			"b": "bar",
+			"c": []interface{}{
+				map[string]interface{}{"name": "c1", "value": 1.0},
+				map[string]interface{}{"name": "c2", "value": 2.0},
+			},
+		}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, index.Flush())
+	})
+
+	tests := []struct {
+		name   string
+		source interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "disabled",
+			source: false,
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "string",
+			source: "b",
+			want:   map[string]interface{}{"b": "bar"},
+		},
+		{
+			name:   "array",
+			source: []interface{}{"a.name", "c.name"},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"name": "zinc"},
+				"c": []interface{}{map[string]interface{}{"name": "c1"}, map[string]interface{}{"name": "c2"}},
+			},
+		},
+		{
+			name:   "includes and excludes",
+			source: map[string]interface{}{"includes": []interface{}{"a.*", "b"}, "excludes": []interface{}{"a.secret", "*.nested.secret"}},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"name": "zinc", "nested": map[string]interface{}{"name": "inner"}},
+				"b": "bar",
+			},
+		},
+		{
+			name:   "excludes only",
+			source: map[string]interface{}{"excludes": []interface{}{"a", "c.value", "@timestamp"}},
+			want: map[string]interface{}{
+				"b": "bar",
+				"c": []interface{}{map[string]interface{}{"name": "c1"}, map[string]interface{}{"name": "c2"}},
+			},
+		},
+		{
+			name:   "wildcard",
+			source: []interface{}{"*.name"},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"name": "zinc", "nested": map[string]interface{}{"name": "inner"}},
+				"c": []interface{}{map[string]interface{}{"name": "c1"}, map[string]interface{}{"name": "c2"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := index.Search(&meta.ZincQuery{Size: 10, Source: tt.source})
+			assert.NoError(t, err)
+			assert.Len(t, got.Hits.Hits, 1)
+			source := got.Hits.Hits[0].Source.(map[string]interface{})
+			delete(source, "@timestamp")
+			assert.Equal(t, tt.want, source)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{Size: 10, Source: map[string]interface{}{"fields": "a"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Fields(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.fields.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		prop := meta.NewProperty("date")
+		prop.Format = "2006-01-02 15:04:05"
+		index.GetMappings().SetProperty("created", prop)
+		err = index.CreateDocument("1", map[string]interface{}{
+			meta.TimeFieldName: "2022-10-01T10:00:00Z",
+			"created":          "2022-10-02 08:30:00",
+			"price":            9.5,
+			"tags":             []interface{}{"a", "b"},
+			"user":             map[string]interface{}{"name": "zinc", "age": 3.0},
+		}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, index.Flush())
+	})
+
+	tests := []struct {
+		name  string
+		query *meta.ZincQuery
+		want  map[string]interface{}
+	}{
+		{
+			name:  "fields",
+			query: &meta.ZincQuery{Fields: []interface{}{"price", "tags", "user.name"}},
+			want: map[string]interface{}{
+				"price":     []interface{}{9.5},
+				"tags":      []interface{}{"a", "b"},
+				"user.name": []interface{}{"zinc"},
+			},
+		},
+		{
+			name:  "wildcard",
+			query: &meta.ZincQuery{Fields: []interface{}{"user.*"}},
+			want: map[string]interface{}{
+				"user.name": []interface{}{"zinc"},
+				"user.age":  []interface{}{3.0},
+			},
+		},
+		{
+			name: "date format",
+			query: &meta.ZincQuery{Fields: []interface{}{
+				map[string]interface{}{"field": meta.TimeFieldName, "format": "epoch_millis"},
+				map[string]interface{}{"field": "created", "format": "2006/01/02"},
+			}},
+			want: map[string]interface{}{
+				meta.TimeFieldName: []interface{}{int64(1664618400000)},
+				"created":          []interface{}{"2022/10/02"},
+			},
+		},
+		{
+			name: "docvalue_fields",
+			query: &meta.ZincQuery{
+				Fields:         []interface{}{"price"},
+				DocValueFields: []interface{}{map[string]interface{}{"field": "created", "format": "epoch_second"}},
+			},
+			want: map[string]interface{}{
+				"price":   []interface{}{9.5},
+				"created": []interface{}{int64(1664699400)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Size = 10
+			got, err := index.Search(tt.query)
+			assert.NoError(t, err)
+			assert.Len(t, got.Hits.Hits, 1)
+			assert.Equal(t, tt.want, got.Hits.Hits[0].Fields)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{Size: 10, Fields: "price"})
+		assert.Error(t, err)
+		_, err = index.Search(&meta.ZincQuery{Size: 10, DocValueFields: "price"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_RuntimeFields(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.runtime.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		mappings := meta.NewMappings()
+		mappings.SetProperty("first", meta.NewProperty("keyword"))
+		mappings.SetProperty("last", meta.NewProperty("keyword"))
+		mappings.SetProperty("age", meta.NewProperty("numeric"))
+		mappings.SetRuntime("full_name", meta.RuntimeField{
+			Type:   "keyword",
+			Script: &meta.Script{Source: "doc['first'].value + ' ' + doc['last'].value"},
+		})
+		mappings.SetRuntime("age_months", meta.RuntimeField{
+			Type:   "long",
+			Script: &meta.Script{Source: "doc['age'].value * params.months", Params: map[string]interface{}{"months": 12}},
+		})
+		mappings.SetRuntime("adult", meta.RuntimeField{Type: "boolean", Script: &meta.Script{Source: "age >= 18"}})
+		assert.NoError(t, index.SetMappings(mappings))
+
+		docs := []map[string]interface{}{
+			{"first": "John", "last": "Doe", "age": 30.0},
+			{"first": "Jane", "last": "Doe", "age": 10.0},
+			{"first": "Zinc", "age": 2.0},
+		}
+		for i, doc := range docs {
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i+1), doc, false))
+		}
+		assert.NoError(t, index.Flush())
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:  map[string]interface{}{"term": map[string]interface{}{"_id": "1"}},
+			Fields: []interface{}{"full_name", "age_*", "adult"},
+			Size:   10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Equal(t, map[string]interface{}{
+			"full_name":  []interface{}{"John Doe"},
+			"age_months": []interface{}{int64(360)},
+			"adult":      []interface{}{true},
+		}, got.Hits.Hits[0].Fields)
+	})
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{"term", map[string]interface{}{"term": map[string]interface{}{"full_name": "Jane Doe"}}, []string{"2"}},
+		{"terms", map[string]interface{}{"terms": map[string]interface{}{"full_name": []interface{}{"Jane Doe", "John Doe"}}}, []string{"1", "2"}},
+		{"range", map[string]interface{}{"range": map[string]interface{}{"age_months": map[string]interface{}{"gte": 24, "lt": 360}}}, []string{"2", "3"}},
+		{"boolean", map[string]interface{}{"term": map[string]interface{}{"adult": false}}, []string{"2", "3"}},
+		{"exists", map[string]interface{}{"exists": map[string]interface{}{"field": "full_name"}}, []string{"1", "2"}},
+		{"bool", map[string]interface{}{"bool": map[string]interface{}{
+			"must":   []interface{}{map[string]interface{}{"term": map[string]interface{}{"last": "Doe"}}},
+			"filter": []interface{}{map[string]interface{}{"range": map[string]interface{}{"age_months": map[string]interface{}{"gt": 200}}}},
+		}}, []string{"1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := index.Search(&meta.ZincQuery{Query: tt.query, Size: 10})
+			assert.NoError(t, err)
+			ids := make([]string, 0, len(got.Hits.Hits))
+			for _, hit := range got.Hits.Hits {
+				ids = append(ids, hit.ID)
+			}
+			sort.Strings(ids)
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	t.Run("aggregations", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Aggregations: map[string]meta.Aggregations{
+				"names":  {Terms: &meta.AggregationsTerms{Field: "full_name"}},
+				"months": {Max: &meta.AggregationMetric{Field: "age_months"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Aggregations["names"].Buckets, 2)
+		assert.Equal(t, 360.0, got.Aggregations["months"].Value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		index.GetMappings().SetRuntime("invalid", meta.RuntimeField{Type: "long", Script: &meta.Script{Source: "doc['first'].value"}})
+		_, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"term": map[string]interface{}{"invalid": 1}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_SearchAfter(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.search_after.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		for i := 0; i < 7; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{
+				"priority": float64(i % 3),
+			}, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		var ids []string
+		var after []interface{}
+		for page := 0; page < 4; page++ {
+			got, err := index.Search(&meta.ZincQuery{
+				Sort:        []interface{}{"-priority", "_id"},
+				SearchAfter: after,
+				Size:        2,
+			})
+			assert.NoError(t, err)
+			if len(got.Hits.Hits) == 0 {
+				break
+			}
+			for _, hit := range got.Hits.Hits {
+				ids = append(ids, hit.ID)
+				assert.Len(t, hit.Sort, 2)
+			}
+			after = got.Hits.Hits[len(got.Hits.Hits)-1].Sort
+		}
+		assert.Equal(t, []string{"2", "5", "1", "4", "0", "3", "6"}, ids)
+	})
+
+	t.Run("sort values", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Sort: []interface{}{"-priority", "_id"},
+			Size: 1,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 1)
+		assert.Equal(t, []interface{}{float64(2), "2"}, got.Hits.Hits[0].Sort)
+	})
+
+	t.Run("without tiebreaker", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Sort:        []interface{}{"-priority"},
+			SearchAfter: []interface{}{float64(1)},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("without sort", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{SearchAfter: []interface{}{"1"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("with from", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Sort:        []interface{}{"-priority", "_id"},
+			SearchAfter: []interface{}{float64(1), "4"},
+			From:        1,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("values mismatch", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Sort:        []interface{}{"-priority", "_id"},
+			SearchAfter: []interface{}{"1", "4"},
+		})
+		assert.Error(t, err)
+		_, err = index.Search(&meta.ZincQuery{
+			Sort:        []interface{}{"-priority", "_id"},
+			SearchAfter: []interface{}{float64(1)},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_TrackTotalHits(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.track_total_hits.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"title": "report"}, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	tests := []struct {
+		name           string
+		trackTotalHits interface{}
+		want           meta.Total
+	}{
+		{name: "default", want: meta.Total{Value: 5, Relation: "eq"}},
+		{name: "true", trackTotalHits: true, want: meta.Total{Value: 5, Relation: "eq"}},
+		{name: "false", trackTotalHits: false, want: meta.Total{Value: 0, Relation: "gte"}},
+		{name: "capped", trackTotalHits: float64(3), want: meta.Total{Value: 3, Relation: "gte"}},
+		{name: "under the cap", trackTotalHits: float64(10), want: meta.Total{Value: 5, Relation: "eq"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := index.Search(&meta.ZincQuery{TrackTotalHits: tt.trackTotalHits, Size: 10})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Hits.Total)
+			assert.Len(t, got.Hits.Hits, 5)
+		})
+	}
+
+	t.Run("configured cap", func(t *testing.T) {
+		old := config.Global.TrackTotalHits
+		defer func() { config.Global.TrackTotalHits = old }()
+		config.Global.TrackTotalHits = 2
+		got, err := index.Search(&meta.ZincQuery{Size: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, meta.Total{Value: 2, Relation: "gte"}, got.Hits.Total)
+		got, err = index.Search(&meta.ZincQuery{TrackTotalHits: true, Size: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, meta.Total{Value: 5, Relation: "eq"}, got.Hits.Total)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{TrackTotalHits: "all"})
+		assert.Error(t, err)
+		_, err = index.Search(&meta.ZincQuery{TrackTotalHits: float64(-1)})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_MinScore(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.min_score.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("tags", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"tags": []interface{}{"a"}, "views": float64(10)},
+			{"tags": []interface{}{"b"}, "views": float64(20)},
+			{"tags": []interface{}{"a", "b"}, "views": float64(30)},
+			{"tags": []interface{}{"c"}, "views": float64(40)},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	// a scores 1, b scores 2 and the scores of a document sum up
+	query := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"constant_score": map[string]interface{}{"filter": map[string]interface{}{"term": map[string]interface{}{"tags": "a"}}, "boost": 1.0}},
+				map[string]interface{}{"constant_score": map[string]interface{}{"filter": map[string]interface{}{"term": map[string]interface{}{"tags": "b"}}, "boost": 2.0}},
+			},
+		},
+	}
+
+	t.Run("min_score", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:    query,
+			MinScore: 1.5,
+			Size:     10,
+			Aggregations: map[string]meta.Aggregations{
+				"max_views": {Max: &meta.AggregationMetric{Field: "views"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Hits.Total.Value)
+		assert.Len(t, got.Hits.Hits, 2)
+		assert.Equal(t, "2", got.Hits.Hits[0].ID)
+		assert.Equal(t, "1", got.Hits.Hits[1].ID)
+		assert.Equal(t, float64(30), got.Aggregations["max_views"].Value)
+	})
+
+	t.Run("min_score with sort", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:    query,
+			MinScore: 1,
+			Sort:     []interface{}{"views"},
+			Size:     10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Hits.Total.Value)
+		ids := make([]string, 0, len(got.Hits.Hits))
+		for _, hit := range got.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		assert.Equal(t, []string{"0", "1", "2"}, ids)
+	})
+
+	t.Run("min_score above every score", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{Query: query, MinScore: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.Hits.Total.Value)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_NamedQueries(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.named_queries.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		index.GetMappings().SetProperty("status", meta.NewProperty("keyword"))
+		docs := []map[string]interface{}{
+			{"title": "zinc search", "status": "published", "views": float64(10)},
+			{"title": "zinc engine", "status": "draft", "views": float64(200)},
+			{"title": "lucene search", "status": "published", "views": float64(300)},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("bool clauses", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{
+				"bool": map[string]interface{}{
+					"should": []interface{}{
+						map[string]interface{}{"match": map[string]interface{}{"title": map[string]interface{}{"query": "zinc", "_name": "title_zinc"}}},
+						map[string]interface{}{"term": map[string]interface{}{"status": map[string]interface{}{"value": "published", "_name": "is_published"}}},
+						map[string]interface{}{"range": map[string]interface{}{"views": map[string]interface{}{"gte": 100, "_name": "popular"}}},
+					},
+				},
+			},
+			Sort: []interface{}{"_id"},
+			Size: 10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 3)
+		assert.Equal(t, []string{"title_zinc", "is_published"}, got.Hits.Hits[0].MatchedQueries)
+		assert.Equal(t, []string{"title_zinc", "popular"}, got.Hits.Hits[1].MatchedQueries)
+		assert.Equal(t, []string{"is_published", "popular"}, got.Hits.Hits[2].MatchedQueries)
+	})
+
+	t.Run("compound query", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{
+				"bool": map[string]interface{}{
+					"_name":  "zinc_docs",
+					"must":   []interface{}{map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}}},
+					"filter": []interface{}{map[string]interface{}{"range": map[string]interface{}{"views": map[string]interface{}{"gte": 0, "_name": "has_views"}}}},
+				},
+			},
+			Sort: []interface{}{"_id"},
+			Size: 10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 2)
+		for _, hit := range got.Hits.Hits {
+			assert.Equal(t, []string{"zinc_docs", "has_views"}, hit.MatchedQueries)
+		}
+	})
+
+	t.Run("without names", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{Size: 10})
+		assert.NoError(t, err)
+		for _, hit := range got.Hits.Hits {
+			assert.Nil(t, hit.MatchedQueries)
+		}
+	})
+
+	t.Run("malformed name", func(t *testing.T) {
+		_, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match_all": map[string]interface{}{"_name": 1.0}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_Rescore(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.rescore.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		for i, title := range []string{"zinc engine", "zinc search", "zinc engine", "zinc search"} {
+			err := index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"title": title, "rank": float64(4 - i)}, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	search := func(rescore *meta.Rescore, from, size int) ([]string, []float64, error) {
+		got, err := index.Search(&meta.ZincQuery{
+			// the documents score their rank
+			Query: map[string]interface{}{
+				"function_score": map[string]interface{}{
+					"query":              map[string]interface{}{"match": map[string]interface{}{"title": "zinc"}},
+					"field_value_factor": map[string]interface{}{"field": "rank"},
+					"boost_mode":         "replace",
+				},
+			},
+			Rescore: rescore,
+			From:    from,
+			Size:    size,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		ids := make([]string, 0, len(got.Hits.Hits))
+		scores := make([]float64, 0, len(got.Hits.Hits))
+		for _, hit := range got.Hits.Hits {
+			ids = append(ids, hit.ID)
+			scores = append(scores, hit.Score)
+		}
+		return ids, scores, nil
+	}
+	rescoreQuery := map[string]interface{}{
+		"constant_score": map[string]interface{}{"filter": map[string]interface{}{"match": map[string]interface{}{"title": "search"}}, "boost": 10.0},
+	}
+	weight := func(v float64) *float64 { return &v }
+
+	t.Run("rescore", func(t *testing.T) {
+		ids, scores, err := search(&meta.Rescore{Query: &meta.RescoreQuery{RescoreQuery: rescoreQuery}}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "3", "0", "2"}, ids)
+		assert.Equal(t, []float64{13, 11, 4, 2}, scores)
+	})
+
+	t.Run("window size", func(t *testing.T) {
+		ids, _, err := search(&meta.Rescore{WindowSize: 2, Query: &meta.RescoreQuery{RescoreQuery: rescoreQuery}}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "0", "2", "3"}, ids)
+	})
+
+	t.Run("weights and score mode", func(t *testing.T) {
+		_, scores, err := search(&meta.Rescore{Query: &meta.RescoreQuery{
+			RescoreQuery:       rescoreQuery,
+			QueryWeight:        weight(0.5),
+			RescoreQueryWeight: weight(3),
+			ScoreMode:          "multiply",
+		}}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{45, 15, 2, 1}, scores)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		ids, _, err := search(&meta.Rescore{Query: &meta.RescoreQuery{RescoreQuery: rescoreQuery}}, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3", "0"}, ids)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := search(&meta.Rescore{Query: &meta.RescoreQuery{}}, 0, 10)
+		assert.Error(t, err)
+		_, _, err = search(&meta.Rescore{Query: &meta.RescoreQuery{RescoreQuery: rescoreQuery, ScoreMode: "sum"}}, 0, 10)
+		assert.Error(t, err)
+		_, err = index.Search(&meta.ZincQuery{
+			Sort:    []interface{}{"title"},
+			Rescore: &meta.Rescore{Query: &meta.RescoreQuery{RescoreQuery: rescoreQuery}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		err = DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_MultiFields(t *testing.T) {
+	var err error
+	var index *Index
+	indexName := "Search.multi_fields.index_1"
+	t.Run("Prepare", func(t *testing.T) {
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = StoreIndex(index)
+		assert.NoError(t, err)
+
+		mappings := meta.NewMappings()
+		title := meta.NewProperty("text")
+		title.AddField("raw", meta.NewProperty("keyword"))
+		mappings.SetProperty("title", title)
+		mappings.SetProperty("title.raw", meta.NewProperty("keyword"))
+		code := meta.NewProperty("numeric")
+		code.AddField("text", meta.NewProperty("keyword"))
+		mappings.SetProperty("code", code)
+		mappings.SetProperty("code.text", meta.NewProperty("keyword"))
+		assert.NoError(t, index.SetMappings(mappings))
+
+		docs := []map[string]interface{}{
+			{"title": "Quick Brown Fox", "code": 1},
+			{"title": "Lazy Brown Dog", "code": 2},
+			{"title": "Quick Brown Fox", "code": 3},
+		}
+		for i, doc := range docs {
+			err := index.CreateDocument(strconv.Itoa(i), doc, false)
+			assert.NoError(t, err)
+		}
+
+		err = index.CreateDocument("bad", map[string]interface{}{"code": "x"}, false)
+		assert.Error(t, err)
+
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("full text and exact", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"match": map[string]interface{}{"title": "brown"}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Hits.Total.Value)
+
+		got, err = index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"term": map[string]interface{}{"title.raw": "Quick Brown Fox"}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Hits.Total.Value)
+
+		got, err = index.Search(&meta.ZincQuery{
+			Query: map[string]interface{}{"term": map[string]interface{}{"code.text": "2"}},
+			Size:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Hits.Total.Value)
+	})
+
+	t.Run("sort, aggregations and fields", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:  map[string]interface{}{"match_all": map[string]interface{}{}},
+			Sort:   []interface{}{"title.raw", "-code"},
+			Fields: []interface{}{"title.raw"},
+			Size:   10,
+			Aggregations: map[string]meta.Aggregations{
+				"titles": {Terms: &meta.AggregationsTerms{Field: "title.raw"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Hits.Hits, 3)
+		assert.Equal(t, "1", got.Hits.Hits[0].ID)
+		assert.Equal(t, "2", got.Hits.Hits[1].ID)
+		assert.Equal(t, []interface{}{"Lazy Brown Dog"}, got.Hits.Hits[0].Fields["title.raw"])
+		buckets := got.Aggregations["titles"].Buckets.([]map[string]interface{})
+		assert.Len(t, buckets, 2)
+		assert.Equal(t, "Quick Brown Fox", buckets[0]["key"])
+		assert.Equal(t, uint64(2), buckets[0]["doc_count"])
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIndex_SearchCancelled(t *testing.T) {
+	indexName := "TestIndex_SearchCancelled.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		index.GetMappings().SetProperty("name", meta.NewProperty("keyword"))
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc"}, false))
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("not cancelled", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:   map[string]interface{}{"match_all": map[string]interface{}{}},
+			Size:    10,
+			Context: context.Background(),
+		})
+		assert.NoError(t, err)
+		assert.False(t, got.TimedOut)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		query := &meta.ZincQuery{
+			Query: map[string]interface{}{"match_all": map[string]interface{}{}},
+			Size:  10,
+			Aggregations: map[string]meta.Aggregations{
+				"names": {Terms: &meta.AggregationsTerms{Field: "name"}},
+			},
+			Context: ctx,
+		}
+		got, err := index.Search(query)
+		require.NoError(t, err)
+		assert.True(t, got.TimedOut)
+		assert.Equal(t, 0, got.Hits.Total.Value)
+
+		got, err = MultiSearch([]string{indexName}, query)
+		require.NoError(t, err)
+		assert.True(t, got.TimedOut)
+		assert.Equal(t, 0, got.Hits.Total.Value)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
+func TestIndex_SearchTimeout(t *testing.T) {
+	indexName := "TestIndex_SearchTimeout.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc", "count": i}, false))
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("time value", func(t *testing.T) {
+		got, err := index.Search(&meta.ZincQuery{
+			Query:   map[string]interface{}{"match_all": map[string]interface{}{}},
+			Size:    10,
+			Timeout: "2s",
+		})
+		assert.NoError(t, err)
+		assert.False(t, got.TimedOut)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+	})
+
+	t.Run("timed out in aggregation", func(t *testing.T) {
+		// the empty buckets of the histogram are created until the timeout
+		got, err := index.Search(&meta.ZincQuery{
+			Query:   map[string]interface{}{"match_all": map[string]interface{}{}},
+			Size:    10,
+			Timeout: "50ms",
+			Aggregations: map[string]meta.Aggregations{
+				"counts": {Histogram: &meta.AggregationHistogram{
+					Field:          "count",
+					Interval:       1e-6,
+					ExtendedBounds: &aggregation.HistogramBound{Min: 0, Max: 1e9},
+				}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.True(t, got.TimedOut)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, timeout := range []interface{}{"2", "abc", true} {
+			_, err := index.Search(&meta.ZincQuery{
+				Query:   map[string]interface{}{"match_all": map[string]interface{}{}},
+				Timeout: timeout,
+			})
+			assert.Error(t, err, timeout)
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
+
+func TestIndex_StoredFields(t *testing.T) {
+	indexName := "TestIndex_StoredFields.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		name := meta.NewProperty("text")
+		name.Store = true
+		mappings.SetProperty("name", name)
+		count := meta.NewProperty("numeric")
+		count.Store = true
+		mappings.SetProperty("count", count)
+		mappings.SetProperty("tag", meta.NewProperty("keyword"))
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "user", "count": 5, "tag": "a"}, false))
+		assert.NoError(t, index.Flush())
+	})
+
+	search := func(query *meta.ZincQuery) meta.Hit {
+		query.Query = map[string]interface{}{"match_all": map[string]interface{}{}}
+		query.Size = 10
+		resp, err := index.Search(query)
+		assert.NoError(t, err)
+		if !assert.Len(t, resp.Hits.Hits, 1) {
+			return meta.Hit{}
+		}
+		return resp.Hits.Hits[0]
+	}
+
+	t.Run("stored fields", func(t *testing.T) {
+		hit := search(&meta.ZincQuery{StoredFields: []interface{}{"name", "count", "tag"}})
+		assert.Equal(t, map[string]interface{}{"name": []interface{}{"user"}, "count": []interface{}{float64(5)}}, hit.Fields)
+		assert.NotContains(t, hit.Source, "name")
+	})
+
+	t.Run("wildcard with source", func(t *testing.T) {
+		hit := search(&meta.ZincQuery{StoredFields: "n*", Source: true})
+		assert.Equal(t, map[string]interface{}{"name": []interface{}{"user"}}, hit.Fields)
+		assert.Equal(t, "a", hit.Source.(map[string]interface{})["tag"])
+	})
+
+	t.Run("none", func(t *testing.T) {
+		hit := search(&meta.ZincQuery{StoredFields: "_none_"})
+		assert.Nil(t, hit.Fields)
+		assert.NotContains(t, hit.Source, "name")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		hit, err := index.GetDocumentByRouting("1", "", "count")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"count": []interface{}{float64(5)}}, hit.Fields)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
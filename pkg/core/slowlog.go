@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// ParseSlowLogThreshold parses the slowlog threshold setting, returns -1 if the slowlog is disabled
+func ParseSlowLogThreshold(threshold string) (time.Duration, error) {
+	if threshold == "" || threshold == "-1" {
+		return -1, nil
+	}
+	d, err := time.ParseDuration(threshold)
+	if err != nil || d < 0 {
+		return 0, errors.New(errors.ErrorTypeIllegalArgumentException, "invalid index.search.slowlog.threshold ["+threshold+"]")
+	}
+	return d, nil
+}
+
+// ValidateSettings checks the values of the index settings
+func ValidateSettings(settings *meta.IndexSettings) error {
+	if settings == nil {
+		return nil
+	}
+	if settings.Search != nil && settings.Search.SlowLog != nil {
+		if _, err := ParseSlowLogThreshold(settings.Search.SlowLog.Threshold); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseRefreshInterval(settings.RefreshInterval); err != nil {
+		return err
+	}
+	if _, err := parseIndexingOptions(settings.Indexing); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSlowLogThreshold returns the slowlog threshold of the index, -1 if the slowlog is disabled
+func (index *Index) GetSlowLogThreshold() time.Duration {
+	index.lock.RLock()
+	var threshold string
+	if index.ref.Settings != nil && index.ref.Settings.Search != nil && index.ref.Settings.Search.SlowLog != nil {
+		threshold = index.ref.Settings.Search.SlowLog.Threshold
+	}
+	index.lock.RUnlock()
+	d, err := ParseSlowLogThreshold(threshold)
+	if err != nil {
+		return -1
+	}
+	return d
+}
+
+// slowLog logs the search if it took longer than the slowlog threshold of the index
+func (index *Index) slowLog(query *meta.ZincQuery, took time.Duration, resp *meta.SearchResponse) {
+	threshold := index.GetSlowLogThreshold()
+	if threshold < 0 || took < threshold {
+		return
+	}
+
+	var hits, total int
+	if resp != nil {
+		hits = len(resp.Hits.Hits)
+		// the collector visits every matched document, it is the number of documents scanned
+		total = resp.Hits.Total.Value
+	}
+	log.Warn().
+		Str("index", index.GetName()).
+		Dur("took", took).
+		Dur("threshold", threshold).
+		Int("hits", hits).
+		Int("docs_scanned", total).
+		Int("from", query.From).
+		Int("size", query.Size).
+		Interface("query", query.Query).
+		Interface("aggs", query.Aggregations).
+		Msg("slowlog")
+}
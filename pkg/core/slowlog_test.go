@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestParseSlowLogThreshold(t *testing.T) {
+	tests := []struct {
+		threshold string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{threshold: "", want: -1},
+		{threshold: "-1", want: -1},
+		{threshold: "0", want: 0},
+		{threshold: "500ms", want: 500 * time.Millisecond},
+		{threshold: "2s", want: 2 * time.Second},
+		{threshold: "abc", wantErr: true},
+		{threshold: "-5s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.threshold, func(t *testing.T) {
+			got, err := ParseSlowLogThreshold(tt.threshold)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndex_SlowLog(t *testing.T) {
+	indexName := "TestIndex_SlowLog.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "slow"}, false))
+		assert.NoError(t, index.Flush())
+		assert.Equal(t, time.Duration(-1), index.GetSlowLogThreshold())
+	})
+
+	t.Run("slowlog", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		logger := log.Logger
+		log.Logger = zerolog.New(buf)
+		defer func() {
+			log.Logger = logger
+		}()
+
+		query := &meta.ZincQuery{Query: map[string]interface{}{"wildcard": map[string]interface{}{"name": "sl*"}}, Size: 10}
+		_, err := index.Search(query)
+		assert.NoError(t, err)
+		assert.Empty(t, buf.String())
+
+		err = index.SetSettings(&meta.IndexSettings{Search: &meta.IndexSearch{SlowLog: &meta.IndexSearchSlowLog{Threshold: "0ms"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), index.GetSlowLogThreshold())
+		_, err = index.Search(query)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), `"index":"TestIndex_SlowLog.index_1"`)
+		assert.Contains(t, buf.String(), `"hits":1`)
+		assert.Contains(t, buf.String(), `"query":{"wildcard":{"name":"sl*"}}`)
+		assert.Contains(t, buf.String(), `"message":"slowlog"`)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
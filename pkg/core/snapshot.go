@@ -0,0 +1,506 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/snapshot"
+	"github.com/zincsearch/zincsearch/pkg/upgrade"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// the layout of a snapshot repository:
+//
+//	snapshots/{snapshot}.json  manifest of the snapshot
+//	blobs/{sha256}             content of the segment and bluge snapshot files
+//
+// the files are addressed by content, so the segments already uploaded by
+// previous snapshots are skipped, that makes the snapshots incremental.
+const (
+	snapshotKeyPrefix = "snapshots/"
+	snapshotBlobKey   = "blobs/"
+)
+
+type snapshotManifest struct {
+	Snapshot *meta.Snapshot            `json:"snapshot"`
+	Indices  map[string]*snapshotIndex `json:"indices"`
+}
+
+type snapshotIndex struct {
+	Index *meta.Index                `json:"index"`
+	Files map[string][]*snapshotFile `json:"files"` // second shard path {shardID}/{secondShardID} => files
+}
+
+type snapshotFile struct {
+	Name string `json:"name"`
+	Blob string `json:"blob"`
+	Size int64  `json:"size"`
+}
+
+// CreateSnapshot takes a snapshot of the indexes into the repository
+func CreateSnapshot(repoName, name string, req *meta.SnapshotRequest) (*meta.Snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	repo, err := openSnapshotRepository(repoName)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := repo.Exists(snapshotManifestKey(name)); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] snapshot with the same name already exists", repoName, name))
+	}
+
+	names, err := snapshotIndexNames(req.Indices)
+	if err != nil {
+		return nil, err
+	}
+	for _, indexName := range names {
+		if strings.Contains(indexName, "*") {
+			continue
+		}
+		if _, ok := GetIndex(indexName); !ok {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "index "+indexName+" does not exists")
+		}
+	}
+	var indexes []*Index
+	for _, index := range ZINC_INDEX_LIST.ListMatch(names) {
+		if index.IsClosed() {
+			if zutils.SliceExists(names, index.GetName()) {
+				return nil, index.checkOpen()
+			}
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].GetName() < indexes[j].GetName()
+	})
+
+	info := &meta.Snapshot{
+		Snapshot:   name,
+		Repository: repoName,
+		Indices:    make([]string, 0, len(indexes)),
+		StartTime:  time.Now(),
+	}
+	manifest := &snapshotManifest{Snapshot: info, Indices: make(map[string]*snapshotIndex, len(indexes))}
+	for _, index := range indexes {
+		snap, err := index.snapshot(repo, &info.Stats)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeRuntimeException, fmt.Sprintf("[%s:%s] snapshot index [%s] failed", repoName, name, index.GetName())).Cause(err)
+		}
+		manifest.Indices[index.GetName()] = snap
+		info.Indices = append(info.Indices, index.GetName())
+	}
+	info.State = meta.SnapshotStateSuccess
+	info.EndTime = time.Now()
+	info.DurationInMillis = info.EndTime.Sub(info.StartTime).Milliseconds()
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.Put(snapshotManifestKey(name), strings.NewReader(string(data)), int64(len(data))); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// snapshot uploads the files of all the second shards to the repository
+func (index *Index) snapshot(repo snapshot.Repository, stats *meta.SnapshotStats) (*snapshotIndex, error) {
+	// persist the documents in WAL
+	if err := index.Flush(); err != nil {
+		return nil, err
+	}
+
+	data, err := index.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	snap := &snapshotIndex{Index: new(meta.Index), Files: make(map[string][]*snapshotFile)}
+	if err = json.Unmarshal(data, snap.Index); err != nil {
+		return nil, err
+	}
+
+	for _, shard := range index.shards {
+		for i := int64(0); i < shard.GetShardNum(); i++ {
+			files, err := snapshotSecondShard(repo, shard, i, stats)
+			if err != nil {
+				return nil, err
+			}
+			snap.Files[snapshotShardPath(shard.GetID(), i)] = files
+		}
+	}
+	return snap, nil
+}
+
+// snapshotSecondShard backups a point in time view of the second shard
+// to a temporary directory, then uploads the files not in the repository
+func snapshotSecondShard(repo snapshot.Repository, shard *IndexShard, shardID int64, stats *meta.SnapshotStats) ([]*snapshotFile, error) {
+	w, err := shard.GetWriter(shardID)
+	if err != nil {
+		return nil, err
+	}
+	r, err := w.Reader()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "zincsearch-snapshot-")
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	err = r.Backup(dir, nil)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*snapshotFile, 0, len(entries))
+	for _, entry := range entries {
+		file, uploaded, err := snapshotUploadFile(repo, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		stats.Total.FileCount++
+		stats.Total.SizeInBytes += file.Size
+		if uploaded {
+			stats.Incremental.FileCount++
+			stats.Incremental.SizeInBytes += file.Size
+		}
+	}
+	return files, nil
+}
+
+// snapshotUploadFile uploads the file if its content is not in the repository
+func snapshotUploadFile(repo snapshot.Repository, path string) (*snapshotFile, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, false, err
+	}
+	file := &snapshotFile{Name: filepath.Base(path), Blob: hex.EncodeToString(h.Sum(nil)), Size: size}
+	ok, err := repo.Exists(snapshotBlobKey + file.Blob)
+	if err != nil || ok {
+		return file, false, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	if err = repo.Put(snapshotBlobKey+file.Blob, f, size); err != nil {
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+// ListSnapshots returns the snapshots in the repository sorted by start time
+func ListSnapshots(repoName string) ([]*meta.Snapshot, error) {
+	repo, err := openSnapshotRepository(repoName)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := repo.List(snapshotKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*meta.Snapshot, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, snapshotKeyPrefix), ".json")
+		manifest, err := loadSnapshotManifest(repo, repoName, name)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, manifest.Snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].StartTime.Before(snapshots[j].StartTime)
+	})
+	return snapshots, nil
+}
+
+// GetSnapshot returns the snapshot in the repository
+func GetSnapshot(repoName, name string) (*meta.Snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	repo, err := openSnapshotRepository(repoName)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadSnapshotManifest(repo, repoName, name)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Snapshot, nil
+}
+
+// RestoreSnapshot restores the indexes in the snapshot, the restored
+// indexes must not exist, use rename_pattern to restore them with new names
+func RestoreSnapshot(repoName, name string, req *meta.RestoreSnapshotRequest) (*meta.RestoreSnapshotInfo, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	repo, err := openSnapshotRepository(repoName)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadSnapshotManifest(repo, repoName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := snapshotIndexNames(req.Indices)
+	if err != nil {
+		return nil, err
+	}
+	var rename *regexp.Regexp
+	if req.RenamePattern != "" {
+		if rename, err = regexp.Compile(req.RenamePattern); err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] invalid rename_pattern").Cause(err)
+		}
+	}
+
+	// check all the indexes before restore any of them
+	targets := make(map[string]string)
+	for _, indexName := range manifest.Snapshot.Indices {
+		if len(names) > 0 && !snapshotIndexMatch(indexName, names) {
+			continue
+		}
+		newName := indexName
+		if rename != nil {
+			newName = rename.ReplaceAllString(indexName, req.RenameReplacement)
+		}
+		if err := CheckIndexName(newName); err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] cannot restore index [%s]", repoName, name, indexName)).Cause(err)
+		}
+		if err := checkSnapshotIndex(manifest.Indices[indexName]); err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] cannot restore index [%s]", repoName, name, indexName)).Cause(err)
+		}
+		if _, ok := GetIndex(newName); ok {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] cannot restore index [%s] because an index with same name already exists", repoName, name, newName))
+		}
+		for _, v := range targets {
+			if v == newName {
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] indices are renamed to the same name [%s]", repoName, name, newName))
+			}
+		}
+		targets[indexName] = newName
+	}
+	for _, indexName := range names {
+		if !strings.Contains(indexName, "*") && targets[indexName] == "" {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] index [%s] does not exist in the snapshot", repoName, name, indexName))
+		}
+	}
+
+	info := &meta.RestoreSnapshotInfo{Snapshot: name, Indices: make([]string, 0, len(targets))}
+	for _, indexName := range manifest.Snapshot.Indices {
+		newName, ok := targets[indexName]
+		if !ok {
+			continue
+		}
+		snap := manifest.Indices[indexName]
+		if err := restoreSnapshotIndex(repo, snap, newName); err != nil {
+			return nil, errors.New(errors.ErrorTypeRuntimeException, fmt.Sprintf("[%s:%s] restore index [%s] failed", repoName, name, indexName)).Cause(err)
+		}
+		info.Indices = append(info.Indices, newName)
+		info.Shards.Total += int64(len(snap.Files))
+		info.Shards.Successful += int64(len(snap.Files))
+	}
+	return info, nil
+}
+
+// restoreSnapshotIndex downloads the files to the data directory of the new index then loads it
+func restoreSnapshotIndex(repo snapshot.Repository, snap *snapshotIndex, name string) error {
+	root := filepath.Join(config.Global.DataPath, name)
+	for shardPath, files := range snap.Files {
+		dir := filepath.Join(root, filepath.FromSlash(shardPath))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			os.RemoveAll(root)
+			return err
+		}
+		for _, file := range files {
+			if err := restoreSnapshotFile(repo, file, filepath.Join(dir, file.Name)); err != nil {
+				os.RemoveAll(root)
+				return err
+			}
+		}
+	}
+
+	ref := snap.Index
+	ref.Name = name
+	ref.State = meta.IndexStateOpen
+	upgrade.UpgradeAnalysis(ref)
+	index, err := loadIndex(ref)
+	if err != nil {
+		os.RemoveAll(root)
+		return err
+	}
+	if err = storeIndex(index); err != nil {
+		os.RemoveAll(root)
+		return err
+	}
+	ZINC_INDEX_LIST.Add(index)
+	return nil
+}
+
+func restoreSnapshotFile(repo snapshot.Repository, file *snapshotFile, path string) error {
+	r, err := repo.Get(snapshotBlobKey + file.Blob)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadSnapshotManifest(repo snapshot.Repository, repoName, name string) (*snapshotManifest, error) {
+	r, err := repo.Get(snapshotManifestKey(name))
+	if err != nil {
+		if err == errors.ErrNotFound {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s:%s] snapshot does not exist", repoName, name))
+		}
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(snapshotManifest)
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// snapshotIndexNames parses the index names given as a comma separated string or an array
+func snapshotIndexNames(v interface{}) ([]string, error) {
+	var names []string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		names = strings.Split(v, ",")
+	case []interface{}:
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] indices should be a string or an array of strings")
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] indices should be a string or an array of strings")
+	}
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && name != "_all" {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func snapshotIndexMatch(indexName string, names []string) bool {
+	for _, name := range names {
+		if isMatchIndex(indexName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSnapshotName(name string) error {
+	if err := CheckIndexName(name); err != nil {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] invalid snapshot name").Cause(err)
+	}
+	return nil
+}
+
+// checkSnapshotIndex rejects the manifest of the index with the files out of the directory of the index,
+// the manifest is read from the repository so its paths can't be trusted
+func checkSnapshotIndex(snap *snapshotIndex) error {
+	if snap == nil || snap.Index == nil {
+		return fmt.Errorf("index is missing in the snapshot")
+	}
+	for shardPath, files := range snap.Files {
+		for _, part := range strings.Split(shardPath, "/") {
+			if err := checkSnapshotPathPart(part); err != nil {
+				return fmt.Errorf("invalid shard path [%s]: %w", shardPath, err)
+			}
+		}
+		for _, file := range files {
+			if err := checkSnapshotPathPart(file.Name); err != nil {
+				return fmt.Errorf("invalid file name [%s]: %w", file.Name, err)
+			}
+			if err := checkSnapshotPathPart(file.Blob); err != nil {
+				return fmt.Errorf("invalid blob [%s]: %w", file.Blob, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSnapshotPathPart accepts a single relative component of a path
+func checkSnapshotPathPart(part string) error {
+	if part == "" || part == "." || part == ".." {
+		return fmt.Errorf("empty or relative path component")
+	}
+	if strings.ContainsAny(part, `/\`) || filepath.IsAbs(part) || filepath.VolumeName(part) != "" {
+		return fmt.Errorf("path separator in path component")
+	}
+	return nil
+}
+
+func snapshotManifestKey(name string) string {
+	return snapshotKeyPrefix + name + ".json"
+}
+
+func snapshotShardPath(shardID string, secondShardID int64) string {
+	return fmt.Sprintf("%s/%06x", shardID, secondShardID)
+}
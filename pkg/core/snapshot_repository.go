@@ -0,0 +1,87 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/snapshot"
+)
+
+// PutSnapshotRepository registers or updates a snapshot repository,
+// the repository is verified before registered
+func PutSnapshotRepository(repo *meta.SnapshotRepository) error {
+	if err := CheckIndexName(repo.Name); err != nil {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] invalid repository name").Cause(err)
+	}
+	r, err := snapshot.New(repo)
+	if err != nil {
+		return err
+	}
+	if _, err = r.List(snapshotKeyPrefix); err != nil {
+		return errors.New(errors.ErrorTypeRuntimeException, fmt.Sprintf("[snapshot] repository [%s] verification failed", repo.Name)).Cause(err)
+	}
+	return metadata.SnapshotRepository.Set(repo.Name, *repo)
+}
+
+// GetSnapshotRepository returns the snapshot repository by name
+func GetSnapshotRepository(name string) (*meta.SnapshotRepository, bool, error) {
+	repo, err := metadata.SnapshotRepository.Get(name)
+	if err != nil {
+		if err == errors.ErrKeyNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return repo, true, nil
+}
+
+// ListSnapshotRepositories returns all the snapshot repositories sorted by name
+func ListSnapshotRepositories() ([]*meta.SnapshotRepository, error) {
+	repos, err := metadata.SnapshotRepository.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Name < repos[j].Name
+	})
+	return repos, nil
+}
+
+// DeleteSnapshotRepository unregisters the snapshot repository,
+// the snapshots in the repository are kept
+func DeleteSnapshotRepository(name string) error {
+	if _, ok, err := GetSnapshotRepository(name); err != nil {
+		return err
+	} else if !ok {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] repository [%s] does not exist", name))
+	}
+	return metadata.SnapshotRepository.Delete(name)
+}
+
+func openSnapshotRepository(name string) (snapshot.Repository, error) {
+	repo, ok, err := GetSnapshotRepository(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] repository [%s] does not exist", name))
+	}
+	return snapshot.New(repo)
+}
@@ -0,0 +1,192 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestSnapshot(t *testing.T) {
+	repoName := "TestSnapshot.repo"
+	indexName := "TestSnapshot.index_1"
+	query := &meta.ZincQuery{Query: map[string]interface{}{"match_all": map[string]interface{}{}}, Size: 100}
+	pathRepo := t.TempDir()
+	location := filepath.Join(pathRepo, "repo")
+	defer func(paths []string) { config.Global.SnapshotPathRepo = paths }(config.Global.SnapshotPathRepo)
+	t.Run("prepare", func(t *testing.T) {
+		index, err := NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+		for i := 0; i < 10; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "doc" + strconv.Itoa(i)}, false)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("repository", func(t *testing.T) {
+		err := PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: "hdfs"})
+		assert.Error(t, err)
+		// the location must be in the path repo
+		config.Global.SnapshotPathRepo = nil
+		err = PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: location}})
+		assert.Error(t, err)
+		config.Global.SnapshotPathRepo = []string{pathRepo}
+		err = PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: t.TempDir()}})
+		assert.Error(t, err)
+		err = PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: "../repo"}})
+		assert.Error(t, err)
+		err = PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: location}})
+		assert.NoError(t, err)
+		repo, ok, err := GetSnapshotRepository(repoName)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, meta.SnapshotRepositoryTypeFS, repo.Type)
+		repos, err := ListSnapshotRepositories()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, repos)
+	})
+
+	t.Run("create snapshot", func(t *testing.T) {
+		_, err := CreateSnapshot("TestSnapshot.notExist", "s1", &meta.SnapshotRequest{})
+		assert.Error(t, err)
+		_, err = CreateSnapshot(repoName, "s1", &meta.SnapshotRequest{Indices: "TestSnapshot.notExist"})
+		assert.Error(t, err)
+
+		s1, err := CreateSnapshot(repoName, "s1", &meta.SnapshotRequest{Indices: []interface{}{indexName}})
+		assert.NoError(t, err)
+		assert.Equal(t, meta.SnapshotStateSuccess, s1.State)
+		assert.Equal(t, []string{indexName}, s1.Indices)
+		assert.Greater(t, s1.Stats.Total.FileCount, 0)
+		assert.Equal(t, s1.Stats.Total, s1.Stats.Incremental)
+
+		_, err = CreateSnapshot(repoName, "s1", &meta.SnapshotRequest{Indices: indexName})
+		assert.Error(t, err, "snapshot exists")
+
+		// the unchanged segments are skipped
+		s2, err := CreateSnapshot(repoName, "s2", &meta.SnapshotRequest{Indices: "TestSnapshot.*"})
+		assert.NoError(t, err)
+		assert.Equal(t, s1.Stats.Total.FileCount, s2.Stats.Total.FileCount)
+		assert.Less(t, s2.Stats.Incremental.FileCount, s2.Stats.Total.FileCount)
+	})
+
+	t.Run("list snapshots", func(t *testing.T) {
+		snapshots, err := ListSnapshots(repoName)
+		assert.NoError(t, err)
+		assert.Len(t, snapshots, 2)
+		assert.Equal(t, "s1", snapshots[0].Snapshot)
+		assert.Equal(t, "s2", snapshots[1].Snapshot)
+
+		snapshot, err := GetSnapshot(repoName, "s2")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{indexName}, snapshot.Indices)
+		_, err = GetSnapshot(repoName, "s3")
+		assert.Error(t, err)
+		_, err = GetSnapshot(repoName, "../snapshots/s2")
+		assert.Error(t, err)
+	})
+
+	t.Run("restore snapshot", func(t *testing.T) {
+		// the index exists
+		_, err := RestoreSnapshot(repoName, "s1", &meta.RestoreSnapshotRequest{})
+		assert.Error(t, err)
+		_, err = RestoreSnapshot(repoName, "s3", &meta.RestoreSnapshotRequest{})
+		assert.Error(t, err)
+		_, err = RestoreSnapshot(repoName, "s1", &meta.RestoreSnapshotRequest{Indices: "TestSnapshot.notExist", RenamePattern: "(.+)", RenameReplacement: "restored_$1"})
+		assert.Error(t, err)
+
+		info, err := RestoreSnapshot(repoName, "s1", &meta.RestoreSnapshotRequest{
+			Indices:           indexName,
+			RenamePattern:     "TestSnapshot.(.+)",
+			RenameReplacement: "TestSnapshot.restored_$1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"TestSnapshot.restored_index_1"}, info.Indices)
+		assert.Equal(t, int64(2), info.Shards.Successful)
+
+		index, ok := GetIndex("TestSnapshot.restored_index_1")
+		assert.True(t, ok)
+		got, err := index.Search(query)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.Hits.Total.Value)
+		doc, err := index.GetDocument("1")
+		assert.NoError(t, err)
+		assert.Equal(t, "doc1", doc.Source.(map[string]interface{})["name"])
+		// the restored index is writable
+		assert.NoError(t, index.CreateDocument("10", map[string]interface{}{"name": "doc10"}, false))
+	})
+
+	t.Run("restore tampered snapshot", func(t *testing.T) {
+		_, err := RestoreSnapshot(repoName, "../snapshots/s1", &meta.RestoreSnapshotRequest{})
+		assert.Error(t, err)
+
+		data, err := os.ReadFile(filepath.Join(location, "snapshots", "s1.json"))
+		assert.NoError(t, err)
+		for name, tamper := range map[string]func(snap *snapshotIndex){
+			"shard_path": func(snap *snapshotIndex) {
+				for shardPath, files := range snap.Files {
+					snap.Files["../../"+shardPath] = files
+				}
+			},
+			"absolute_shard_path": func(snap *snapshotIndex) {
+				for shardPath, files := range snap.Files {
+					snap.Files[filepath.Join(pathRepo, shardPath)] = files
+				}
+			},
+			"file_name": func(snap *snapshotIndex) {
+				for _, files := range snap.Files {
+					files[0].Name = "../../escaped"
+				}
+			},
+			"blob": func(snap *snapshotIndex) {
+				for _, files := range snap.Files {
+					files[0].Blob = "../snapshots/s1.json"
+				}
+			},
+		} {
+			manifest := new(snapshotManifest)
+			assert.NoError(t, json.Unmarshal(data, manifest))
+			tamper(manifest.Indices[indexName])
+			tampered, err := json.Marshal(manifest)
+			assert.NoError(t, err)
+			assert.NoError(t, os.WriteFile(filepath.Join(location, "snapshots", name+".json"), tampered, 0o644))
+
+			_, err = RestoreSnapshot(repoName, name, &meta.RestoreSnapshotRequest{
+				RenamePattern:     "TestSnapshot.(.+)",
+				RenameReplacement: "TestSnapshot.tampered_$1",
+			})
+			assert.Error(t, err, name)
+			_, ok := GetIndex("TestSnapshot.tampered_index_1")
+			assert.False(t, ok, name)
+		}
+		_, err = os.Stat(filepath.Join(config.Global.DataPath, "escaped"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteSnapshotRepository(repoName))
+		assert.Error(t, DeleteSnapshotRepository(repoName))
+		assert.NoError(t, DeleteIndex(indexName))
+		assert.NoError(t, DeleteIndex("TestSnapshot.restored_index_1"))
+	})
+}
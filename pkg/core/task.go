@@ -0,0 +1,155 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/ider"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+const (
+	TaskActionDeleteByQuery = "indices:data/write/delete/byquery"
+	TaskActionReindex       = "indices:data/write/reindex"
+
+	// TaskResultRetention is how long a completed task is kept for its result
+	TaskResultRetention = 24 * time.Hour
+)
+
+// ZINC_TASKS keeps the requests running in the background, e.g. a delete by query
+// which doesn't wait for its completion, and their results after they completed
+var ZINC_TASKS = &tasks{tasks: map[string]*Task{}}
+
+type tasks struct {
+	tasks map[string]*Task
+	lock  sync.RWMutex
+}
+
+// Task is a request running in the background, it belongs to the user who submitted it.
+// The ID is node:id like the tasks of ES
+type Task struct {
+	ID     string
+	Owner  string
+	Action string
+
+	node      string
+	id        string
+	startTime time.Time
+	endTime   time.Time
+	status    func() interface{}
+	resp      interface{}
+	err       error
+	done      chan struct{}
+	lock      sync.RWMutex
+}
+
+// Submit runs the task in the background, status returns the progress of the task while it is running
+func (t *tasks) Submit(owner, action string, status func() interface{}, run func() (interface{}, error)) *Task {
+	s := &Task{
+		Owner:     owner,
+		Action:    action,
+		node:      strconv.Itoa(config.Global.NodeID),
+		id:        ider.Generate(),
+		startTime: time.Now(),
+		status:    status,
+		done:      make(chan struct{}),
+	}
+	s.ID = s.node + ":" + s.id
+	t.lock.Lock()
+	t.tasks[s.ID] = s
+	t.lock.Unlock()
+
+	go func() {
+		resp, err := run()
+		s.lock.Lock()
+		s.resp, s.err = resp, err
+		s.endTime = time.Now()
+		s.lock.Unlock()
+		close(s.done)
+		time.AfterFunc(TaskResultRetention, func() { t.delete(s.ID) })
+	}()
+	return s
+}
+
+// Get returns the task of the id
+func (t *tasks) Get(id string) (*Task, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	s, ok := t.tasks[id]
+	return s, ok
+}
+
+func (t *tasks) delete(id string) {
+	t.lock.Lock()
+	delete(t.tasks, id)
+	t.lock.Unlock()
+}
+
+// Wait waits at most timeout for the task to complete, it returns whether the task completed
+func (s *Task) Wait(timeout time.Duration) bool {
+	if timeout <= 0 {
+		select {
+		case <-s.done:
+			return true
+		default:
+			return false
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Response returns the state of the task, it has the result of the task when it completed
+func (s *Task) Response() *meta.TaskResponse {
+	completed := s.Wait(0)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	end := time.Now()
+	if completed {
+		end = s.endTime
+	}
+	resp := &meta.TaskResponse{
+		Completed: completed,
+		Task: meta.TaskInfo{
+			Node:               s.node,
+			ID:                 s.id,
+			Action:             s.Action,
+			StartTimeInMillis:  s.startTime.UnixMilli(),
+			RunningTimeInNanos: end.Sub(s.startTime).Nanoseconds(),
+		},
+	}
+	if s.status != nil {
+		resp.Task.Status = s.status()
+	}
+	switch {
+	case !completed:
+	case s.err != nil:
+		resp.Error = s.err.Error()
+	default:
+		resp.Response = s.resp
+	}
+	return resp
+}
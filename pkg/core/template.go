@@ -78,6 +78,10 @@ func NewTemplate(name string, template *meta.IndexTemplate) error {
 		}
 	}
 
+	if err := checkComponentTemplates(name, template); err != nil {
+		return err
+	}
+
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
 	tpl := meta.Template{
@@ -144,7 +148,7 @@ func UseTemplate(indexName string) (*meta.IndexTemplate, error) {
 			pattern := strings.TrimRight(strings.ReplaceAll(pattern, "*", ".*"), "$") + "$"
 			re := regexp.MustCompile(pattern)
 			if re.MatchString(indexName) {
-				return tpl.IndexTemplate, nil
+				return resolveTemplate(tpl.IndexTemplate)
 			}
 		}
 	}
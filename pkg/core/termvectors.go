@@ -0,0 +1,252 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/analyzer"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+	"github.com/zincsearch/zincsearch/pkg/zutils/flatten"
+)
+
+// termVectorsPositionGap is the position gap between the values of an array, like the position_increment_gap of es
+const termVectorsPositionGap = 100
+
+// TermVectors returns the terms of the document fields with their frequency, positions and offsets.
+// The terms are computed by analyzing the source of the document with the analyzer of the field
+func (index *Index) TermVectors(docID string, req *meta.TermVectorsRequest) (*meta.TermVectorsResponse, error) {
+	startTime := time.Now()
+	resp := &meta.TermVectorsResponse{Index: index.GetName(), ID: docID}
+	hit, err := index.GetDocument(docID)
+	if err != nil {
+		if errors.Is(err, errors.ErrorIDNotFound) {
+			resp.Took = time.Since(startTime).Milliseconds()
+			return resp, nil
+		}
+		return nil, err
+	}
+	if visible, err := index.termVectorsVisible(docID, req.Privileges); err != nil || !visible {
+		resp.Took = time.Since(startTime).Milliseconds()
+		return resp, err
+	}
+	resp.Found = true
+
+	doc, _ := hit.Source.(map[string]interface{})
+	values, err := flatten.Flatten(doc, "")
+	if err != nil {
+		return nil, err
+	}
+	mappings := index.GetMappings()
+	analyzers := index.GetAnalyzers()
+	fields := termVectorsFields(mappings, values, req.Fields, security.FieldRules(req.Privileges, index.GetName()))
+
+	resp.TermVectors = make(map[string]*meta.TermVector, len(fields))
+	for _, field := range fields {
+		tv := termVector(mappings, analyzers, field, values[termVectorsSourceField(mappings, field)], req)
+		if len(tv.Terms) > 0 {
+			resp.TermVectors[field] = tv
+		}
+	}
+
+	if optionValue(req.TermStatistics, false) || optionValue(req.FieldStatistics, true) {
+		if err = index.termVectorsStatistics(resp.TermVectors, req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Took = time.Since(startTime).Milliseconds()
+	return resp, nil
+}
+
+// termVectorsVisible returns whether the document matches the document level security of the privileges
+func (index *Index) termVectorsVisible(docID string, privileges []*meta.RoleIndices) (bool, error) {
+	if security.DocumentFilter(privileges, index.GetName()) == nil {
+		return true, nil
+	}
+	resp, err := index.Search(&meta.ZincQuery{
+		Query:      map[string]interface{}{"ids": map[string]interface{}{"values": []interface{}{docID}}},
+		Size:       1,
+		Privileges: privileges,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Hits.Hits) > 0, nil
+}
+
+// termVectorsFields returns the visible text and keyword fields of the document matching the patterns, all of them without patterns
+func termVectorsFields(mappings *meta.Mappings, values map[string]interface{}, patterns []string, rules *security.Fields) []string {
+	fields := make([]string, 0)
+	for field, prop := range mappings.ListProperty() {
+		if prop.Type != "text" && prop.Type != "keyword" || !prop.Index || !rules.Allowed(field) {
+			continue
+		}
+		if _, ok := values[termVectorsSourceField(mappings, field)]; !ok {
+			continue
+		}
+		if len(patterns) == 0 {
+			fields = append(fields, field)
+			continue
+		}
+		for _, pattern := range patterns {
+			if source.MatchPattern(pattern, field) {
+				fields = append(fields, field)
+				break
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// termVectorsSourceField returns the field of the value in the source, it is the parent field for the multi-fields
+func termVectorsSourceField(mappings *meta.Mappings, field string) string {
+	if i := strings.LastIndex(field, "."); i > 0 {
+		if parent, ok := mappings.GetProperty(field[:i]); ok {
+			if _, ok := parent.Fields[field[i+1:]]; ok {
+				return field[:i]
+			}
+		}
+	}
+	return field
+}
+
+// termVector analyzes the values of the field and collects the terms
+func termVector(mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer, field string, value interface{}, req *meta.TermVectorsRequest) *meta.TermVector {
+	prop, _ := mappings.GetProperty(field)
+	var zer *analysis.Analyzer
+	if prop.Type == "text" {
+		zer, _ = zincanalysis.QueryAnalyzerForField(analyzers, mappings, field)
+		if zer == nil {
+			zer = analyzer.NewStandardAnalyzer()
+		}
+	} else {
+		zer = analyzer.NewKeywordAnalyzer()
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	positions := optionValue(req.Positions, true)
+	offsets := optionValue(req.Offsets, true)
+	tv := &meta.TermVector{Terms: make(map[string]*meta.TermVectorTerm)}
+	position, offset := -1, 0
+	for i, v := range values {
+		var text string
+		switch v := v.(type) {
+		case string:
+			text = v
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			continue
+		}
+		if i > 0 {
+			position += termVectorsPositionGap
+		}
+		for _, token := range zer.Analyze([]byte(text)) {
+			position += token.PositionIncr
+			term := string(token.Term)
+			t, ok := tv.Terms[term]
+			if !ok {
+				t = new(meta.TermVectorTerm)
+				tv.Terms[term] = t
+			}
+			t.TermFreq++
+			if positions || offsets {
+				var tok meta.TermVectorToken
+				if positions {
+					tok.Position = intPtr(position)
+				}
+				if offsets {
+					tok.StartOffset = intPtr(offset + token.Start)
+					tok.EndOffset = intPtr(offset + token.End)
+				}
+				t.Tokens = append(t.Tokens, tok)
+			}
+		}
+		// the offsets of the values are separated by one like es
+		offset += len(text) + 1
+	}
+	return tv
+}
+
+// termVectorsStatistics sets the field and term statistics of the term vectors from all the shards of the index
+func (index *Index) termVectorsStatistics(termVectors map[string]*meta.TermVector, req *meta.TermVectorsRequest) error {
+	readers, err := index.GetReaders(0, 0)
+	if err != nil {
+		return err
+	}
+	defer closeReaders(readers)
+
+	for field, tv := range termVectors {
+		terms := make([]string, 0, len(tv.Terms))
+		for term := range tv.Terms {
+			terms = append(terms, term)
+		}
+		stats := new(zincquery.TermStats)
+		query := zincquery.NewTermStatsQuery(field, terms, stats)
+		for _, reader := range readers {
+			dmi, err := reader.Search(context.Background(), bluge.NewTopNSearch(0, query))
+			if err != nil {
+				return err
+			}
+			if _, err = dmi.Next(); err != nil {
+				return err
+			}
+		}
+
+		if optionValue(req.FieldStatistics, true) {
+			tv.FieldStatistics = &meta.TermVectorFieldStatistics{
+				SumDocFreq: stats.SumDocFreq,
+				DocCount:   stats.DocCount,
+				SumTTF:     stats.SumTTF,
+			}
+		}
+		if optionValue(req.TermStatistics, false) {
+			for term, t := range tv.Terms {
+				docFreq, ttf := stats.DocFreq[term], stats.TTF[term]
+				t.DocFreq, t.TTF = &docFreq, &ttf
+			}
+		}
+	}
+	return nil
+}
+
+func optionValue(v *bool, defaultValue bool) bool {
+	if v == nil {
+		return defaultValue
+	}
+	return *v
+}
+
+func intPtr(v int) *int {
+	return &v
+}
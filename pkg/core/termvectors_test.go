@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_TermVectors(t *testing.T) {
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex("TestIndex_TermVectors.index_1", "disk", 2)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("title", meta.NewProperty("text"))
+		mappings.SetProperty("tags", meta.NewProperty("keyword"))
+		mappings.SetProperty("count", meta.NewProperty("numeric"))
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"title": "Zinc search zinc", "tags": []interface{}{"a", "b"}, "count": 1}, false))
+		assert.NoError(t, index.CreateDocument("2", map[string]interface{}{"title": "zinc", "tags": "a"}, false))
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("terms", func(t *testing.T) {
+		resp, err := index.TermVectors("1", &meta.TermVectorsRequest{})
+		assert.NoError(t, err)
+		assert.True(t, resp.Found)
+		assert.NotContains(t, resp.TermVectors, "count")
+		title := resp.TermVectors["title"]
+		assert.Len(t, title.Terms, 2)
+		assert.Equal(t, 2, title.Terms["zinc"].TermFreq)
+		assert.Nil(t, title.Terms["zinc"].DocFreq)
+		tokens := title.Terms["zinc"].Tokens
+		assert.Equal(t, 0, *tokens[0].Position)
+		assert.Equal(t, 0, *tokens[0].StartOffset)
+		assert.Equal(t, 4, *tokens[0].EndOffset)
+		assert.Equal(t, 2, *tokens[1].Position)
+		assert.Equal(t, 12, *tokens[1].StartOffset)
+		assert.Equal(t, &meta.TermVectorFieldStatistics{SumDocFreq: 3, DocCount: 2, SumTTF: 4}, title.FieldStatistics)
+
+		tags := resp.TermVectors["tags"]
+		assert.Equal(t, 101, *tags.Terms["b"].Tokens[0].Position)
+		assert.Equal(t, 2, *tags.Terms["b"].Tokens[0].StartOffset)
+	})
+
+	t.Run("term statistics", func(t *testing.T) {
+		disabled, enabled := false, true
+		resp, err := index.TermVectors("1", &meta.TermVectorsRequest{
+			Fields:          []string{"ti*"},
+			Offsets:         &disabled,
+			Positions:       &disabled,
+			TermStatistics:  &enabled,
+			FieldStatistics: &disabled,
+		})
+		assert.NoError(t, err)
+		assert.NotContains(t, resp.TermVectors, "tags")
+		title := resp.TermVectors["title"]
+		assert.Nil(t, title.FieldStatistics)
+		assert.Nil(t, title.Terms["zinc"].Tokens)
+		assert.Equal(t, uint64(2), *title.Terms["zinc"].DocFreq)
+		assert.Equal(t, uint64(3), *title.Terms["zinc"].TTF)
+		assert.Equal(t, uint64(1), *title.Terms["search"].DocFreq)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp, err := index.TermVectors("3", &meta.TermVectorsRequest{})
+		assert.NoError(t, err)
+		assert.False(t, resp.Found)
+		assert.Nil(t, resp.TermVectors)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex("TestIndex_TermVectors.index_1"))
+	})
+}
@@ -0,0 +1,242 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/ingest"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/query"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// Results of the update API
+const (
+	UpdateResultCreated = "created"
+	UpdateResultUpdated = "updated"
+	UpdateResultDeleted = "deleted"
+	UpdateResultNoop    = "noop"
+)
+
+// UpdateDocumentRequest merges the partial document or runs the script on the current document,
+// the upsert document is indexed when the document doesn't exist. The read and the write are a
+// compare-and-swap, the update is retried retries times when another write comes in between.
+func (index *Index) UpdateDocumentRequest(docID string, req *meta.UpdateRequest, cond *WriteCondition, retries int) (WriteResult, string, error) {
+	if req.Doc == nil && req.Script == nil {
+		return WriteResult{}, "", errors.New(errors.ErrorTypeIllegalArgumentException, "[update] requires one of [doc] or [script]")
+	}
+	if req.Doc != nil && req.Script != nil {
+		return WriteResult{}, "", errors.New(errors.ErrorTypeIllegalArgumentException, "[update] can't provide both [script] and [doc]")
+	}
+	if req.DocAsUpsert && req.Upsert != nil {
+		return WriteResult{}, "", errors.New(errors.ErrorTypeIllegalArgumentException, "[update] can't provide both [upsert] and [doc_as_upsert]")
+	}
+	var err error
+	if req.Doc, err = normalizeSource(req.Doc); err != nil {
+		return WriteResult{}, "", err
+	}
+	if req.Upsert, err = normalizeSource(req.Upsert); err != nil {
+		return WriteResult{}, "", err
+	}
+	var script *ingest.UpdateScript
+	if req.Script != nil {
+		source, params, err := query.ScriptSource(req.Script)
+		if err != nil {
+			return WriteResult{}, "", err
+		}
+		if script, err = ingest.NewUpdateScript(source, params); err != nil {
+			return WriteResult{}, "", err
+		}
+	}
+
+	if err := index.checkOpen(); err != nil {
+		return WriteResult{}, "", err
+	}
+	shard := index.GetShardByRouting(docID, cond.routing())
+	if err := shard.OpenWAL(); err != nil {
+		return WriteResult{}, "", err
+	}
+
+	for retry := 0; ; retry++ {
+		ret, result, err := index.updateDocument(shard, docID, req, script, cond)
+		var e *errors.Error
+		if retry < retries && errors.As(err, &e) && e.Type == errors.ErrorTypeVersionConflictException && (cond == nil || cond.IfPrimaryTerm == 0) {
+			continue
+		}
+		return ret, result, err
+	}
+}
+
+// ReplaceDocument replaces the document like UpdateDocumentIf, the write is skipped and the result is
+// noop when detectNoop is true and the document equals the current one, so the version doesn't change.
+func (index *Index) ReplaceDocument(docID string, doc map[string]interface{}, insert bool, cond *WriteCondition, detectNoop bool) (WriteResult, string, error) {
+	if !detectNoop {
+		ret, err := index.UpdateDocumentIf(docID, doc, insert, cond)
+		return ret, UpdateResultUpdated, err
+	}
+
+	if err := index.checkOpen(); err != nil {
+		return WriteResult{}, "", err
+	}
+	shard := index.GetShardByRouting(docID, cond.routing())
+	if err := shard.OpenWAL(); err != nil {
+		return WriteResult{}, "", err
+	}
+	source, current, found, err := shard.currentDocument(docID)
+	if err != nil {
+		return WriteResult{}, "", err
+	}
+	if found {
+		if cond != nil {
+			if err = cond.check(docID, current, found); err != nil {
+				return WriteResult{}, "", err
+			}
+		}
+		if equal, err := sourceEqual(source, doc); err != nil {
+			return WriteResult{}, "", err
+		} else if equal {
+			return WriteResult{SeqNo: current.seqNo, Version: current.version}, UpdateResultNoop, nil
+		}
+	}
+	ret, err := index.UpdateDocumentIf(docID, doc, insert, cond)
+	if ret.Created {
+		return ret, UpdateResultCreated, err
+	}
+	return ret, UpdateResultUpdated, err
+}
+
+// updateDocument reads the current document and writes the update if the document didn't change meanwhile
+func (index *Index) updateDocument(shard *IndexShard, docID string, req *meta.UpdateRequest, script *ingest.UpdateScript, cond *WriteCondition) (WriteResult, string, error) {
+	source, current, found, err := shard.currentDocument(docID)
+	if err != nil {
+		return WriteResult{}, "", err
+	}
+
+	if !found {
+		doc := req.Upsert
+		if req.DocAsUpsert {
+			doc = req.Doc
+		}
+		if doc == nil {
+			return WriteResult{}, "", errors.New(errors.ErrorTypeDocumentMissingException, fmt.Sprintf("[_doc][%s]: document missing", docID))
+		}
+		// the upsert doesn't skip the condition, e.g. if_seq_no is a version conflict without the document
+		if cond != nil {
+			if err = cond.check(docID, current, found); err != nil {
+				return WriteResult{}, "", err
+			}
+		}
+		if script != nil && req.ScriptedUpsert {
+			ctx := &ingest.UpdateContext{Op: ingest.UpdateOpCreate, Source: doc}
+			if err = script.Run(ctx); err != nil {
+				return WriteResult{}, "", err
+			}
+			if ctx.Op == ingest.UpdateOpNoop || ctx.Op == ingest.UpdateOpDelete {
+				return WriteResult{}, UpdateResultNoop, nil
+			}
+		}
+		ret, err := index.CreateDocumentIf(docID, doc, true, &WriteCondition{Create: true, Routing: cond.routing()})
+		return ret, UpdateResultCreated, err
+	}
+
+	if cond != nil {
+		if err = cond.check(docID, current, found); err != nil {
+			return WriteResult{}, "", err
+		}
+	}
+	// the partial document and the script need the current source
+	if !index.GetMappings().SourceEnabled() {
+		return WriteResult{}, "", errors.New(errors.ErrorTypeSourceMissingException, fmt.Sprintf("[_doc][%s]: document source missing", docID))
+	}
+	noop := WriteResult{SeqNo: current.seqNo, Version: current.version}
+	swap := &WriteCondition{IfSeqNo: current.seqNo, IfPrimaryTerm: PrimaryTerm, Routing: cond.routing()}
+	if source == nil {
+		source = make(map[string]interface{})
+	}
+	if req.Doc != nil {
+		changed := mergeSource(source, req.Doc)
+		if !changed && (req.DetectNoop == nil || *req.DetectNoop) {
+			return noop, UpdateResultNoop, nil
+		}
+	} else {
+		ctx := &ingest.UpdateContext{Op: ingest.UpdateOpIndex, Source: source}
+		if err = script.Run(ctx); err != nil {
+			return WriteResult{}, "", err
+		}
+		switch ctx.Op {
+		case ingest.UpdateOpNoop:
+			return noop, UpdateResultNoop, nil
+		case ingest.UpdateOpDelete:
+			ret, err := index.DeleteDocumentIf(docID, swap)
+			return ret, UpdateResultDeleted, err
+		}
+	}
+	ret, err := index.CreateDocumentIf(docID, source, true, swap)
+	return ret, UpdateResultUpdated, err
+}
+
+// mergeSource merges the partial document into the source, the objects are merged recursively
+// and the other values are replaced. It returns true if the source changed.
+func mergeSource(source, doc map[string]interface{}) bool {
+	changed := false
+	for k, v := range doc {
+		if sub, ok := v.(map[string]interface{}); ok {
+			if dst, ok := source[k].(map[string]interface{}); ok {
+				if mergeSource(dst, sub) {
+					changed = true
+				}
+				continue
+			}
+		}
+		if old, ok := source[k]; !ok || !reflect.DeepEqual(old, v) {
+			source[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// normalizeSource returns the document as it is decoded from its JSON source, so the numbers
+// of the documents built in code compare equal with the stored ones
+func normalizeSource(doc map[string]interface{}) (map[string]interface{}, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ret map[string]interface{}
+	if err = json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// sourceEqual reports whether the document is deeply equal to the stored source
+func sourceEqual(source, doc map[string]interface{}) (bool, error) {
+	doc, err := normalizeSource(doc)
+	if err != nil {
+		return false, err
+	}
+	if source == nil {
+		source = make(map[string]interface{})
+	}
+	return reflect.DeepEqual(source, doc), nil
+}
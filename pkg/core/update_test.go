@@ -0,0 +1,195 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestIndex_UpdateDocumentRequest(t *testing.T) {
+	indexName := "TestIndex_UpdateDocumentRequest.index_1"
+	var index *Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, StoreIndex(index))
+	})
+
+	source := func(docID string) map[string]interface{} {
+		assert.NoError(t, index.Flush())
+		hit, err := index.GetDocument(docID)
+		assert.NoError(t, err)
+		return hit.Source.(map[string]interface{})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := index.UpdateDocumentRequest("1", &meta.UpdateRequest{}, nil, 0)
+		assert.Error(t, err)
+		_, _, err = index.UpdateDocumentRequest("1", &meta.UpdateRequest{Doc: map[string]interface{}{}, Script: "ctx.op = 'noop'"}, nil, 0)
+		assert.Error(t, err)
+		_, _, err = index.UpdateDocumentRequest("1", &meta.UpdateRequest{Script: "ctx._source.a ="}, nil, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("document missing", func(t *testing.T) {
+		_, _, err := index.UpdateDocumentRequest("1", &meta.UpdateRequest{Doc: map[string]interface{}{"name": "Hello"}}, nil, 0)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, errors.StatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("doc as upsert", func(t *testing.T) {
+		ret, result, err := index.UpdateDocumentRequest("1", &meta.UpdateRequest{
+			Doc:         map[string]interface{}{"name": "Hello", "user": map[string]interface{}{"id": "a"}},
+			DocAsUpsert: true,
+		}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultCreated, result)
+		assert.Equal(t, int64(1), ret.Version)
+	})
+
+	t.Run("merge doc", func(t *testing.T) {
+		ret, result, err := index.UpdateDocumentRequest("1", &meta.UpdateRequest{
+			Doc: map[string]interface{}{"user": map[string]interface{}{"name": "b"}},
+		}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		assert.Equal(t, int64(2), ret.Version)
+		assert.Equal(t, map[string]interface{}{"id": "a", "name": "b"}, source("1")["user"])
+	})
+
+	t.Run("noop", func(t *testing.T) {
+		req := &meta.UpdateRequest{Doc: map[string]interface{}{"name": "Hello"}}
+		ret, result, err := index.UpdateDocumentRequest("1", req, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultNoop, result)
+		assert.Equal(t, int64(2), ret.Version)
+
+		detectNoop := false
+		req.DetectNoop = &detectNoop
+		ret, result, err = index.UpdateDocumentRequest("1", req, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		assert.Equal(t, int64(3), ret.Version)
+	})
+
+	t.Run("noop numbers", func(t *testing.T) {
+		_, result, err := index.UpdateDocumentRequest("4", &meta.UpdateRequest{
+			Doc:         map[string]interface{}{"count": 1, "tags": []string{"a"}},
+			DocAsUpsert: true,
+		}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultCreated, result)
+		_, result, err = index.UpdateDocumentRequest("4", &meta.UpdateRequest{
+			Doc: map[string]interface{}{"count": int64(1), "tags": []interface{}{"a"}},
+		}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultNoop, result)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		doc := map[string]interface{}{"name": "Hello", "count": 1}
+		ret, result, err := index.ReplaceDocument("5", doc, true, nil, true)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultCreated, result)
+		assert.Equal(t, int64(1), ret.Version)
+
+		ret, result, err = index.ReplaceDocument("5", map[string]interface{}{"name": "Hello", "count": 1}, false, nil, true)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultNoop, result)
+		assert.Equal(t, int64(1), ret.Version)
+
+		_, _, err = index.ReplaceDocument("5", map[string]interface{}{"name": "Hello", "count": 1}, false, &WriteCondition{IfSeqNo: ret.SeqNo + 100, IfPrimaryTerm: PrimaryTerm}, true)
+		assert.Error(t, err)
+
+		ret, result, err = index.ReplaceDocument("5", map[string]interface{}{"name": "Hello", "count": 1}, false, nil, false)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		assert.Equal(t, int64(2), ret.Version)
+
+		ret, result, err = index.ReplaceDocument("5", map[string]interface{}{"name": "Hello"}, false, nil, true)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		assert.Equal(t, int64(3), ret.Version)
+		assert.Equal(t, map[string]interface{}{"name": "Hello"}, source("5"))
+	})
+
+	t.Run("script", func(t *testing.T) {
+		req := &meta.UpdateRequest{
+			Script: map[string]interface{}{"source": "ctx._source.counter += params.count", "params": map[string]interface{}{"count": 2}},
+			Upsert: map[string]interface{}{"counter": 1},
+		}
+		_, result, err := index.UpdateDocumentRequest("2", req, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultCreated, result)
+		assert.Equal(t, 1.0, source("2")["counter"])
+
+		_, result, err = index.UpdateDocumentRequest("2", req, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultUpdated, result)
+		assert.Equal(t, 3.0, source("2")["counter"])
+
+		_, result, err = index.UpdateDocumentRequest("2", &meta.UpdateRequest{Script: "ctx.op = 'noop'"}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultNoop, result)
+	})
+
+	t.Run("scripted upsert", func(t *testing.T) {
+		_, result, err := index.UpdateDocumentRequest("3", &meta.UpdateRequest{
+			Script:         "ctx._source.counter += 5",
+			ScriptedUpsert: true,
+			Upsert:         map[string]interface{}{},
+		}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultCreated, result)
+		assert.Equal(t, 5.0, source("3")["counter"])
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		_, _, err := index.UpdateDocumentRequest("3", &meta.UpdateRequest{Script: "ctx._source.counter++"}, &WriteCondition{IfSeqNo: 1000, IfPrimaryTerm: PrimaryTerm}, 3)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusBadRequest))
+
+		// the upsert of a missing document is a conflict too
+		_, _, err = index.UpdateDocumentRequest("6", &meta.UpdateRequest{
+			Doc:         map[string]interface{}{"name": "Hello"},
+			DocAsUpsert: true,
+		}, &WriteCondition{IfSeqNo: 1, IfPrimaryTerm: PrimaryTerm}, 0)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, errors.StatusCode(err, http.StatusBadRequest))
+		_, err = index.GetDocument("6")
+		assert.Error(t, err)
+	})
+
+	t.Run("script delete", func(t *testing.T) {
+		_, result, err := index.UpdateDocumentRequest("3", &meta.UpdateRequest{Script: "ctx.op = 'delete'"}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, UpdateResultDeleted, result)
+		assert.NoError(t, index.Flush())
+		_, err = index.GetDocument("3")
+		assert.Error(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, DeleteIndex(indexName))
+	})
+}
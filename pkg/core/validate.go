@@ -0,0 +1,74 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery"
+)
+
+// ValidateQuery parses the query against the mappings of the indexes without executing it
+func ValidateQuery(indexNames []string, query *meta.ZincQuery, explain bool) (*meta.ValidateResponse, error) {
+	resp := &meta.ValidateResponse{Valid: true}
+	hasIndex := false
+	for _, index := range ZINC_INDEX_LIST.List() {
+		if len(indexNames) > 0 {
+			isMatched := false
+			for _, indexName := range indexNames {
+				if isMatched = isMatchIndex(index.GetName(), indexName); isMatched {
+					break
+				}
+			}
+			if !isMatched {
+				continue
+			}
+		}
+		hasIndex = true
+		resp.Shards.Total += index.GetShardNum()
+
+		explanation := meta.ValidateExplanation{Index: index.GetName(), Valid: true}
+		mappings, analyzers := index.GetMappings(), index.GetAnalyzers()
+		q := *query // parse mutates the query
+		if _, err := uquery.ParseQueryDSL(&q, mappings, analyzers); err != nil {
+			explanation.Valid = false
+			explanation.Error = err.Error()
+		} else if explain {
+			subq, _ := uquery.ParseQuery(query, mappings, analyzers)
+			explanation.Explanation = zincquery.String(subq)
+		}
+
+		if explanation.Valid {
+			resp.Shards.Successful += index.GetShardNum()
+		} else {
+			resp.Valid = false
+			resp.Shards.Failed += index.GetShardNum()
+			if resp.Error == "" {
+				resp.Error = explanation.Error
+			}
+		}
+		if explain {
+			resp.Explanations = append(resp.Explanations, explanation)
+		}
+	}
+
+	if !hasIndex {
+		return nil, fmt.Errorf("core.ValidateQuery: no index found")
+	}
+	return resp, nil
+}
@@ -28,6 +28,12 @@ const (
 	ErrorTypeRuntimeException         = "runtime_exception"
 	ErrorTypeNotImplemented           = "not_implemented"
 	ErrorTypeInvalidArgument          = "invalid_argument"
+	ErrorTypeIndexClosedException     = "index_closed_exception"
+	ErrorTypeSecurityException        = "security_exception"
+	ErrorTypeVersionConflictException = "version_conflict_engine_exception"
+	ErrorTypeDocumentMissingException = "document_missing_exception"
+	ErrorTypeSourceMissingException   = "document_source_missing_exception"
+	ErrorTypeIndexNotFoundException   = "index_not_found_exception"
 )
 
 var ErrorIDNotFound = errors.New("id not found")
@@ -25,7 +25,7 @@ func HandleError(c *gin.Context, err error) {
 	if err != nil {
 		switch v := err.(type) {
 		case *Error:
-			c.JSON(http.StatusBadRequest, gin.H{"error": v})
+			c.JSON(StatusCode(v, http.StatusBadRequest), gin.H{"error": v})
 		default:
 			c.JSON(http.StatusBadRequest, gin.H{"error": v.Error()})
 		}
@@ -33,3 +33,19 @@ func HandleError(c *gin.Context, err error) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
+
+// StatusCode returns the http status code for the error,
+// the code is returned if the error has no special status
+func StatusCode(err error, code int) int {
+	var e *Error
+	if As(err, &e) && (e.Type == ErrorTypeIndexClosedException || e.Type == ErrorTypeSecurityException) {
+		return http.StatusForbidden
+	}
+	if As(err, &e) && e.Type == ErrorTypeVersionConflictException {
+		return http.StatusConflict
+	}
+	if As(err, &e) && (e.Type == ErrorTypeDocumentMissingException || e.Type == ErrorTypeIndexNotFoundException) {
+		return http.StatusNotFound
+	}
+	return code
+}
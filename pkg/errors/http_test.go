@@ -59,6 +59,14 @@ func TestHandleError(t *testing.T) {
 				result: `{"error":{"type":"runtime_exception","reason":"error message","cause":"reason"}}`,
 			},
 		},
+		{
+			name: "index closed",
+			args: args{
+				err:    New(ErrorTypeIndexClosedException, "index [foo] is closed"),
+				code:   http.StatusForbidden,
+				result: `{"error":{"type":"index_closed_exception","reason":"index [foo] is closed"}}`,
+			},
+		},
 		{
 			name: "nil",
 			args: args{
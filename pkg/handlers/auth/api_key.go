@@ -0,0 +1,136 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id CreateAPIKey
+// @Summary Create api key
+// @security BasicAuth
+// @Tags    User
+// @Accept  json
+// @Produce json
+// @Param   key body meta.APIKeyRequest true "Api key data"
+// @Success 200 {object} meta.APIKeyResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 403 {object} meta.HTTPResponseError
+// @Router /api/_security/api_key [post]
+func CreateAPIKey(c *gin.Context) {
+	var req meta.APIKeyRequest
+	if err := zutils.GinBindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	owner, _ := auth.GetContextUser(c)
+	if owner == nil {
+		owner = &meta.User{}
+	}
+	if req.Role == "" {
+		req.Role = owner.Role
+	}
+	// the users can't create keys with more permissions than they have
+	if owner.Role != "admin" && req.Role != owner.Role {
+		c.JSON(http.StatusForbidden, meta.HTTPResponseError{Error: "api key role should be the role of the user"})
+		return
+	}
+
+	var expiration time.Duration
+	if req.Expiration != "" {
+		var err error
+		if expiration, err = zutils.ParseDuration(req.Expiration); err != nil || expiration <= 0 {
+			c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "invalid api key expiration [" + req.Expiration + "]"})
+			return
+		}
+	}
+
+	key, secret, err := auth.CreateAPIKey(req.Name, req.Role, owner.ID, expiration)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		APIKey:     secret,
+		Encoded:    auth.EncodeAPIKey(key.ID, secret),
+		Expiration: key.Expiration,
+	})
+}
+
+// @Id ListAPIKeys
+// @Summary List api keys, the users other than admin only see their own keys
+// @security BasicAuth
+// @Tags    User
+// @Produce json
+// @Success 200 {object} []meta.APIKey
+// @Failure 500 {object} meta.HTTPResponseError
+// @Router /api/_security/api_key [get]
+func ListAPIKeys(c *gin.Context) {
+	keys, err := auth.GetAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	user, _ := auth.GetContextUser(c)
+	if user != nil && user.Role != "admin" {
+		owned := make([]*meta.APIKey, 0, len(keys))
+		for _, key := range keys {
+			if key.Owner == user.ID {
+				owned = append(owned, key)
+			}
+		}
+		keys = owned
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Id DeleteAPIKey
+// @Summary Revoke api key
+// @security BasicAuth
+// @Tags    User
+// @Produce json
+// @Param   id  path  string  true  "Api key id"
+// @Success 200 {object} meta.HTTPResponseID
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /api/_security/api_key/{id} [delete]
+func DeleteAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	key, ok, err := auth.GetAPIKey(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	user, _ := auth.GetContextUser(c)
+	if !ok || (user != nil && user.Role != "admin" && key.Owner != user.ID) {
+		c.JSON(http.StatusNotFound, meta.HTTPResponseError{Error: "api key " + id + " does not exists"})
+		return
+	}
+	if err := auth.DeleteAPIKey(id); err != nil {
+		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta.HTTPResponseID{Message: "deleted", ID: id})
+}
@@ -17,6 +17,8 @@ package auth
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -43,20 +45,91 @@ func Login(c *gin.Context) {
 	}
 
 	loggedInUser, validationResult := auth.VerifyCredentials(loginInput.ID, loginInput.Password)
-	var resUser LoginUser
+	res := LoginResponse{Validated: validationResult}
 	if validationResult {
-		resUser = LoginUser{
+		res.User = LoginUser{
 			ID:   loggedInUser.ID,
 			Name: loggedInUser.Name,
 			Role: loggedInUser.Role,
 		}
+		session, accessToken, refreshToken, err := auth.CreateSession(loggedInUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+		res.AccessToken = accessToken
+		res.RefreshToken = refreshToken
+		res.TokenType = "Bearer"
+		res.ExpiresIn = expiresIn(session.AccessExpiresAt)
 	}
-	c.JSON(http.StatusOK, LoginResponse{
-		Validated: validationResult,
-		User:      resUser,
+	c.JSON(http.StatusOK, res)
+}
+
+// @Id RefreshToken
+// @Summary Refresh the access token
+// @Tags    User
+// @Accept  json
+// @Produce json
+// @Param   refresh body RefreshRequest true "Refresh token"
+// @Success 200 {object} RefreshResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 401 {object} meta.HTTPResponseError
+// @Router /api/refresh [post]
+func Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := zutils.GinBindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	session, accessToken, err := auth.RefreshSession(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, RefreshResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn(session.AccessExpiresAt),
 	})
 }
 
+// Logout revokes the session of the refresh token in the body or of the bearer access token
+//
+// @Id Logout
+// @Summary Logout
+// @Tags    User
+// @Accept  json
+// @Produce json
+// @Param   refresh body RefreshRequest false "Refresh token"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 401 {object} meta.HTTPResponseError
+// @Router /api/logout [post]
+func Logout(c *gin.Context) {
+	var req RefreshRequest
+	if c.Request.ContentLength > 0 {
+		if err := zutils.GinBindJSON(c, &req); err != nil {
+			c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+	}
+	token := req.RefreshToken
+	if token == "" {
+		if scheme, credentials, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(credentials)
+		}
+	}
+	if err := auth.RevokeSession(token); err != nil {
+		c.JSON(http.StatusUnauthorized, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta.HTTPResponse{Message: "logged out"})
+}
+
+// expiresIn returns the seconds until the access token expires
+func expiresIn(expiresAt time.Time) int64 {
+	return int64(time.Until(expiresAt) / time.Second)
+}
+
 type LoginUser struct {
 	ID   string `json:"_id"`
 	Name string `json:"name"`
@@ -71,4 +144,19 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Validated bool      `json:"validated"`
 	User      LoginUser `json:"user"`
+	// AccessToken authenticates the requests with the Authorization: Bearer header until it expires
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
 }
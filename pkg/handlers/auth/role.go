@@ -20,6 +20,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
@@ -47,8 +48,13 @@ func CreateUpdateRole(c *gin.Context) {
 		return
 	}
 
-	newRole, err := auth.CreateRole(role.ID, role.Name, role.Permission)
+	newRole, err := auth.SaveRole(&role)
 	if err != nil {
+		var e *errors.Error
+		if errors.As(err, &e) && e.Type == errors.ErrorTypeInvalidArgument {
+			c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: e.Reason})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
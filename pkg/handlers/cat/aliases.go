@@ -0,0 +1,68 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+)
+
+// @Id CatAliases
+// @Summary List aliases in a compact table for elasticsearch
+// @security BasicAuth
+// @Tags    Cat
+// @Produce plain
+// @Param   alias  path   string  false  "Alias"
+// @Param   v      query  bool    false  "Show the header line"
+// @Param   h      query  string  false  "Columns to show, comma separated"
+// @Param   s      query  string  false  "Columns to sort by, comma separated, like alias:desc"
+// @Param   format query  string  false  "Output format, text or json"
+// @Success 200 {string} string
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_cat/aliases/{alias} [get]
+func Aliases(c *gin.Context) {
+	var names []string
+	if target := c.Param("target_alias"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+
+	t := newTable(
+		column{name: "alias", isDefault: true},
+		column{name: "index", isDefault: true},
+		column{name: "filter", isDefault: true},
+		column{name: "routing.index", isDefault: true},
+		column{name: "routing.search", isDefault: true},
+		column{name: "is_write_index", isDefault: true},
+	)
+	for alias, indexes := range core.ZINC_INDEX_ALIAS_LIST.ListMatch(names) {
+		writeIndex, _ := core.ZINC_INDEX_ALIAS_LIST.GetWriteIndex(alias)
+		for _, index := range indexes {
+			isWriteIndex := "-"
+			if index == writeIndex {
+				isWriteIndex = "true"
+			}
+			t.addRow(alias, index, "-", "-", "-", isWriteIndex)
+		}
+	}
+	if c.Query("s") == "" {
+		_ = t.sort("alias,index")
+	}
+
+	render(c, t)
+}
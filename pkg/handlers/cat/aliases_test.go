@@ -0,0 +1,73 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestAliases(t *testing.T) {
+	t.Run("prepare", func(t *testing.T) {
+		err := core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias("TestCatAliases.alias_1", []string{"TestCatAliases.index_2", "TestCatAliases.index_1"})
+		assert.NoError(t, err)
+		err = core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias("TestCatAliases.alias_2", []string{"TestCatAliases.index_1"})
+		assert.NoError(t, err)
+	})
+
+	request := func(params, query map[string]string) (int, string) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, params)
+		utils.SetGinRequestURL(c, "/es/_cat/aliases", query)
+		Aliases(c)
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("text", func(t *testing.T) {
+		code, body := request(map[string]string{"target_alias": "TestCatAliases.*"}, map[string]string{"v": ""})
+		assert.Equal(t, http.StatusOK, code)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Len(t, lines, 4)
+		assert.Equal(t, []string{"alias", "index", "filter", "routing.index", "routing.search", "is_write_index"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"TestCatAliases.alias_1", "TestCatAliases.index_1"}, strings.Fields(lines[1])[:2])
+	})
+
+	t.Run("json sorted", func(t *testing.T) {
+		code, body := request(map[string]string{"target_alias": "TestCatAliases.alias_1"}, map[string]string{"format": "json", "h": "alias,index", "s": "index:desc"})
+		assert.Equal(t, http.StatusOK, code)
+		items := make([]map[string]string, 0)
+		err := json.Unmarshal([]byte(body), &items)
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]string{
+			{"alias": "TestCatAliases.alias_1", "index": "TestCatAliases.index_2"},
+			{"alias": "TestCatAliases.alias_1", "index": "TestCatAliases.index_1"},
+		}, items)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias("TestCatAliases.alias_1", []string{"TestCatAliases.index_1", "TestCatAliases.index_2"})
+		assert.NoError(t, err)
+		err = core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias("TestCatAliases.alias_2", []string{"TestCatAliases.index_1"})
+		assert.NoError(t, err)
+	})
+}
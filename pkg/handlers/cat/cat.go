@@ -0,0 +1,348 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package cat implements the compact, human readable `_cat` APIs.
+package cat
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// column of a cat table, only default columns are shown without `?h=`
+type column struct {
+	name      string
+	isDefault bool
+}
+
+// byteSize a cell rendered as a human readable size, or in the `?bytes=` unit
+type byteSize uint64
+
+type table struct {
+	columns []column
+	rows    [][]interface{}
+}
+
+func newTable(columns ...column) *table {
+	return &table{columns: columns}
+}
+
+// addRow adds a row, cells follow the order of the columns
+func (t *table) addRow(cells ...interface{}) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *table) columnIndex(name string) int {
+	for i, col := range t.columns {
+		if col.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// targetNames returns the index names of the `target` param, no names for all indexes.
+// It renders a not found error and returns false when an index without wildcard is missing.
+func targetNames(c *gin.Context) ([]string, bool) {
+	target := c.Param("target")
+	if target == "" || target == "_all" {
+		return nil, true
+	}
+	names := strings.Split(target, ",")
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return nil, false
+		}
+	}
+	return names, true
+}
+
+// resolveTargetNames returns the names of the indexes the `target` param expands to honoring the
+// `ignore_unavailable`, `allow_no_indices` and `expand_wildcards` params, it renders the errors
+// and returns false like targetNames.
+func resolveTargetNames(c *gin.Context) ([]string, bool) {
+	opts, err := core.ParseIndicesOptions(c.Request.URL.Query())
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return nil, false
+	}
+	var names []string
+	if opts.IgnoreUnavailable {
+		if target := c.Param("target"); target != "" && target != "_all" {
+			names = strings.Split(target, ",")
+		}
+	} else {
+		var ok bool
+		if names, ok = targetNames(c); !ok {
+			return nil, false
+		}
+	}
+	if names, err = core.ResolveIndices(names, opts); err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return nil, false
+	}
+	return names, true
+}
+
+// render writes the table honoring the `v`, `h`, `s`, `bytes` and `format` parameters
+func render(c *gin.Context, t *table) {
+	selected, err := t.selectColumns(c.Query("h"))
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := t.sort(c.Query("s")); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	bytesUnit := strings.ToLower(c.Query("bytes"))
+	if _, ok := byteUnits[bytesUnit]; bytesUnit != "" && !ok {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: fmt.Sprintf("failed to parse [bytes] with value [%s]", bytesUnit)})
+		return
+	}
+
+	rows := make([][]string, 0, len(t.rows))
+	for _, row := range t.rows {
+		cells := make([]string, len(selected))
+		for i, col := range selected {
+			cells[i] = formatCell(row[col], bytesUnit)
+		}
+		rows = append(rows, cells)
+	}
+
+	switch strings.ToLower(c.Query("format")) {
+	case "json":
+		items := make([]map[string]string, 0, len(rows))
+		for _, row := range rows {
+			item := make(map[string]string, len(selected))
+			for i, col := range selected {
+				item[t.columns[col].name] = row[i]
+			}
+			items = append(items, item)
+		}
+		zutils.GinRenderJSON(c, http.StatusOK, items)
+	case "", "text", "txt":
+		if verbose(c) {
+			header := make([]string, len(selected))
+			for i, col := range selected {
+				header[i] = t.columns[col].name
+			}
+			rows = append([][]string{header}, rows...)
+		}
+		c.String(http.StatusOK, formatText(rows, t.numericColumns(selected)))
+	default:
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "unsupported format [" + c.Query("format") + "]"})
+	}
+}
+
+// selectColumns returns the indexes of the columns to show
+func (t *table) selectColumns(h string) ([]int, error) {
+	selected := make([]int, 0, len(t.columns))
+	if h == "" {
+		for i, col := range t.columns {
+			if col.isDefault {
+				selected = append(selected, i)
+			}
+		}
+		return selected, nil
+	}
+	for _, name := range strings.Split(h, ",") {
+		name = strings.TrimSpace(name)
+		if name == "*" {
+			for i := range t.columns {
+				selected = append(selected, i)
+			}
+			continue
+		}
+		i := t.columnIndex(name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column [%s]", name)
+		}
+		selected = append(selected, i)
+	}
+	return selected, nil
+}
+
+// sort sorts rows by `col[:asc|desc]` entries, separated by comma
+func (t *table) sort(s string) error {
+	if s == "" {
+		return nil
+	}
+	type sortBy struct {
+		column int
+		desc   bool
+	}
+	sorts := make([]sortBy, 0)
+	for _, item := range strings.Split(s, ",") {
+		name, order, _ := strings.Cut(strings.TrimSpace(item), ":")
+		i := t.columnIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unable to sort by unknown sort key [%s]", name)
+		}
+		switch strings.ToLower(order) {
+		case "", "asc":
+			sorts = append(sorts, sortBy{column: i})
+		case "desc":
+			sorts = append(sorts, sortBy{column: i, desc: true})
+		default:
+			return fmt.Errorf("unsupported sort order [%s]", order)
+		}
+	}
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		for _, s := range sorts {
+			cmp := compareCell(t.rows[i][s.column], t.rows[j][s.column])
+			if cmp == 0 {
+				continue
+			}
+			if s.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return nil
+}
+
+func (t *table) numericColumns(selected []int) []bool {
+	numeric := make([]bool, len(selected))
+	if len(t.rows) == 0 {
+		return numeric
+	}
+	for i, col := range selected {
+		switch t.rows[0][col].(type) {
+		case string:
+		default:
+			numeric[i] = true
+		}
+	}
+	return numeric
+}
+
+func compareCell(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case int64:
+		return compareNumber(float64(a), float64(b.(int64)))
+	case uint64:
+		return compareNumber(float64(a), float64(b.(uint64)))
+	case float64:
+		return compareNumber(a, b.(float64))
+	case byteSize:
+		return compareNumber(float64(a), float64(b.(byteSize)))
+	}
+	return 0
+}
+
+func compareNumber(a, b float64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+var byteUnits = map[string]uint64{
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+	"pb": 1 << 50,
+}
+
+func formatCell(v interface{}, bytesUnit string) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case byteSize:
+		return formatBytes(uint64(v), bytesUnit)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatBytes prints the size in the given unit, or in the largest unit
+// keeping the value above one, like 230b, 4.5kb or 12mb
+func formatBytes(size uint64, unit string) string {
+	if unit != "" {
+		return strconv.FormatUint(size/byteUnits[unit], 10)
+	}
+	for _, unit := range []string{"pb", "tb", "gb", "mb", "kb"} {
+		if size >= byteUnits[unit] {
+			value := strconv.FormatFloat(float64(size)/float64(byteUnits[unit]), 'f', 1, 64)
+			return strings.TrimSuffix(value, ".0") + unit
+		}
+	}
+	return strconv.FormatUint(size, 10) + "b"
+}
+
+// formatText aligns the cells in columns, numeric columns are right aligned
+func formatText(rows [][]string, numeric []bool) string {
+	widths := make([]int, len(numeric))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-len(cell))
+			if numeric[i] {
+				sb.WriteString(pad + cell)
+			} else if i < len(row)-1 {
+				sb.WriteString(cell + pad)
+			} else {
+				sb.WriteString(cell)
+			}
+			if i < len(row)-1 {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// verbose reports the `?v` parameter, which adds the header line
+func verbose(c *gin.Context) bool {
+	v, ok := c.GetQuery("v")
+	return ok && (v == "" || strings.EqualFold(v, "true"))
+}
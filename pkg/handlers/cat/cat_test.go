@@ -0,0 +1,61 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size uint64
+		unit string
+		want string
+	}{
+		{size: 230, want: "230b"},
+		{size: 4608, want: "4.5kb"},
+		{size: 12 << 20, want: "12mb"},
+		{size: 3 << 30, unit: "mb", want: "3072"},
+		{size: 1000, unit: "b", want: "1000"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatBytes(tt.size, tt.unit))
+	}
+}
+
+func TestTable_Sort(t *testing.T) {
+	tb := newTable(column{name: "index", isDefault: true}, column{name: "docs.count", isDefault: true})
+	tb.addRow("b", uint64(10))
+	tb.addRow("a", uint64(10))
+	tb.addRow("c", uint64(2))
+
+	assert.NoError(t, tb.sort("docs.count:desc,index"))
+	assert.Equal(t, [][]interface{}{{"a", uint64(10)}, {"b", uint64(10)}, {"c", uint64(2)}}, tb.rows)
+
+	assert.Error(t, tb.sort("health"))
+	assert.Error(t, tb.sort("index:up"))
+}
+
+func TestFormatText(t *testing.T) {
+	got := formatText([][]string{
+		{"index", "docs.count"},
+		{"logs", "7"},
+		{"products", "1200"},
+	}, []bool{false, true})
+	assert.Equal(t, "index    docs.count\nlogs              7\nproducts       1200\n", got)
+}
@@ -0,0 +1,77 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id CatCount
+// @Summary Count documents in a compact table for elasticsearch
+// @security BasicAuth
+// @Tags    Cat
+// @Produce plain
+// @Param   index  path   string  false  "Index"
+// @Param   v      query  bool    false  "Show the header line"
+// @Param   h      query  string  false  "Columns to show, comma separated"
+// @Param   format query  string  false  "Output format, text or json"
+// @Param   ignore_unavailable  query  bool    false  "Skip the missing and closed indexes named in the target"
+// @Param   allow_no_indices    query  bool    false  "A wildcard matching no index is not an error, default true"
+// @Param   expand_wildcards    query  string  false  "Comma separated states of the indexes the wildcards match: open, closed, hidden, all or none, default open"
+// @Success 200 {string} string
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_cat/count/{index} [get]
+func Count(c *gin.Context) {
+	names, ok := resolveTargetNames(c)
+	if !ok {
+		return
+	}
+
+	// count with a search, so the hidden nested documents are not counted
+	var count int64
+	if len(names) > 0 {
+		resp, err := core.MultiSearch(names, &meta.ZincQuery{
+			Query:          map[string]interface{}{"match_all": map[string]interface{}{}},
+			TrackTotalHits: true,
+			Privileges:     auth.GetContextPrivileges(c),
+		})
+		if err != nil {
+			zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+		count = int64(resp.Hits.Total.Value)
+	}
+
+	t := newTable(
+		column{name: "epoch", isDefault: true},
+		column{name: "timestamp", isDefault: true},
+		column{name: "count", isDefault: true},
+	)
+	now := time.Now()
+	t.addRow(now.Unix(), now.Format("15:04:05"), count)
+
+	render(c, t)
+}
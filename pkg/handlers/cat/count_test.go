@@ -0,0 +1,87 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestCount(t *testing.T) {
+	indexName := "TestCatCount.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+		for _, id := range []string{"1", "2", "3"} {
+			err = index.CreateDocument(id, map[string]interface{}{"name": "zinc"}, false)
+			assert.NoError(t, err)
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	request := func(params, query map[string]string) (int, string) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, params)
+		utils.SetGinRequestURL(c, "/es/_cat/count", query)
+		Count(c)
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("text", func(t *testing.T) {
+		code, body := request(map[string]string{"target": indexName}, map[string]string{"v": ""})
+		assert.Equal(t, http.StatusOK, code)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Len(t, lines, 2)
+		assert.Equal(t, []string{"epoch", "timestamp", "count"}, strings.Fields(lines[0]))
+		assert.Equal(t, "3", strings.Fields(lines[1])[2])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		code, body := request(map[string]string{"target": "TestCatCount.*"}, map[string]string{"format": "json", "h": "count"})
+		assert.Equal(t, http.StatusOK, code)
+		items := make([]map[string]string, 0)
+		err := json.Unmarshal([]byte(body), &items)
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]string{{"count": "3"}}, items)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		code, body := request(map[string]string{"target": "TestCatCount.nope*"}, map[string]string{"h": "count"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "0\n", body)
+	})
+
+	t.Run("not exists index", func(t *testing.T) {
+		code, _ := request(map[string]string{"target": "TestCatCount.index_2"}, nil)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
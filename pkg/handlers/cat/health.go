@@ -0,0 +1,80 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta/elastic"
+)
+
+// @Id CatHealth
+// @Summary Show the cluster health in a compact table for elasticsearch
+// @security BasicAuth
+// @Tags    Cat
+// @Produce plain
+// @Param   v      query  bool    false  "Show the header line"
+// @Param   h      query  string  false  "Columns to show, comma separated"
+// @Param   format query  string  false  "Output format, text or json"
+// @Success 200 {string} string
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_cat/health [get]
+func Health(c *gin.Context) {
+	t := newTable(
+		column{name: "epoch", isDefault: true},
+		column{name: "timestamp", isDefault: true},
+		column{name: "cluster", isDefault: true},
+		column{name: "status", isDefault: true},
+		column{name: "node.total", isDefault: true},
+		column{name: "node.data", isDefault: true},
+		column{name: "shards", isDefault: true},
+		column{name: "pri", isDefault: true},
+		column{name: "relo", isDefault: true},
+		column{name: "init", isDefault: true},
+		column{name: "unassign", isDefault: true},
+		column{name: "pending_tasks", isDefault: true},
+		column{name: "max_task_wait_time", isDefault: true},
+		column{name: "active_shards_percent", isDefault: true},
+	)
+
+	// zinc is a single node without replicas, all shards are always active
+	var shards int64
+	for _, index := range core.ZINC_INDEX_LIST.List() {
+		shards += index.GetShardNum()
+	}
+	now := time.Now()
+	t.addRow(
+		now.Unix(),
+		now.Format("15:04:05"),
+		elastic.NewESInfo(c).ClusterName,
+		"green",
+		int64(1),
+		int64(1),
+		shards,
+		shards,
+		int64(0),
+		int64(0),
+		int64(0),
+		int64(0),
+		"-",
+		"100.0%",
+	)
+
+	render(c, t)
+}
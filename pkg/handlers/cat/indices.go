@@ -0,0 +1,74 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+)
+
+// @Id CatIndices
+// @Summary List indices in a compact table for elasticsearch
+// @security BasicAuth
+// @Tags    Cat
+// @Produce plain
+// @Param   index  path   string  false  "Index"
+// @Param   v      query  bool    false  "Show the header line"
+// @Param   h      query  string  false  "Columns to show, comma separated"
+// @Param   s      query  string  false  "Columns to sort by, comma separated, like index:desc"
+// @Param   format query  string  false  "Output format, text or json"
+// @Param   bytes  query  string  false  "Unit of byte values, b, kb, mb, gb, tb or pb"
+// @Success 200 {string} string
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_cat/indices/{index} [get]
+func Indices(c *gin.Context) {
+	names, ok := targetNames(c)
+	if !ok {
+		return
+	}
+
+	t := newTable(
+		column{name: "health", isDefault: true},
+		column{name: "status", isDefault: true},
+		column{name: "index", isDefault: true},
+		column{name: "pri"},
+		column{name: "rep"},
+		column{name: "docs.count", isDefault: true},
+		column{name: "docs.deleted", isDefault: true},
+		column{name: "store.size", isDefault: true},
+		column{name: "pri.store.size"},
+	)
+	for _, index := range core.ZINC_INDEX_LIST.ListMatch(names) {
+		stats := index.Stats("docs", "store")
+		t.addRow(
+			"green",
+			index.GetState(),
+			index.GetName(),
+			index.GetShardNum(),
+			int64(0),
+			stats.Docs.Count,
+			stats.Docs.Deleted,
+			byteSize(stats.Store.SizeInBytes),
+			byteSize(stats.Store.SizeInBytes),
+		)
+	}
+	if c.Query("s") == "" {
+		_ = t.sort("index")
+	}
+
+	render(c, t)
+}
@@ -0,0 +1,102 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestIndices(t *testing.T) {
+	t.Run("prepare", func(t *testing.T) {
+		for i, name := range []string{"TestCatIndices.index_1", "TestCatIndices.index_2"} {
+			index, err := core.NewIndex(name, "disk", 2)
+			assert.NoError(t, err)
+			err = core.StoreIndex(index)
+			assert.NoError(t, err)
+			for j := 0; j <= i; j++ {
+				err = index.CreateDocument(strings.Repeat("x", j+1), map[string]interface{}{"name": "zinc"}, false)
+				assert.NoError(t, err)
+			}
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	request := func(params, query map[string]string) (int, string) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, params)
+		utils.SetGinRequestURL(c, "/es/_cat/indices", query)
+		Indices(c)
+		return w.Code, w.Body.String()
+	}
+	target := map[string]string{"target": "TestCatIndices.*"}
+
+	t.Run("default columns", func(t *testing.T) {
+		code, body := request(target, nil)
+		assert.Equal(t, http.StatusOK, code)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Len(t, lines, 2)
+		assert.Equal(t, []string{"green", "open", "TestCatIndices.index_1", "1", "0"}, strings.Fields(lines[0])[:5])
+		assert.Len(t, strings.Fields(lines[0]), 6)
+	})
+
+	t.Run("header, columns and sort", func(t *testing.T) {
+		code, body := request(target, map[string]string{"v": "", "h": "index,docs.count", "s": "docs.count:desc"})
+		assert.Equal(t, http.StatusOK, code)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Len(t, lines, 3)
+		assert.Equal(t, []string{"index", "docs.count"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"TestCatIndices.index_2", "2"}, strings.Fields(lines[1]))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		code, body := request(target, map[string]string{"format": "json", "h": "index,pri,store.size", "bytes": "b"})
+		assert.Equal(t, http.StatusOK, code)
+		items := make([]map[string]string, 0)
+		err := json.Unmarshal([]byte(body), &items)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, "TestCatIndices.index_1", items[0]["index"])
+		assert.Equal(t, "2", items[0]["pri"])
+		assert.NotContains(t, items[0]["store.size"], "b")
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		code, _ := request(target, map[string]string{"h": "index,nope"})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("not exists index", func(t *testing.T) {
+		code, _ := request(map[string]string{"target": "TestCatIndices.index_3"}, nil)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		for _, name := range []string{"TestCatIndices.index_1", "TestCatIndices.index_2"} {
+			err := core.DeleteIndex(name)
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta/elastic"
+)
+
+// @Id CatNodes
+// @Summary Show the nodes in a compact table for elasticsearch
+// @security BasicAuth
+// @Tags    Cat
+// @Produce plain
+// @Param   v      query  bool    false  "Show the header line"
+// @Param   h      query  string  false  "Columns to show, comma separated"
+// @Param   format query  string  false  "Output format, text or json"
+// @Param   bytes  query  string  false  "Unit of byte values, b, kb, mb, gb, tb or pb"
+// @Success 200 {string} string
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_cat/nodes [get]
+func Nodes(c *gin.Context) {
+	t := newTable(
+		column{name: "id"},
+		column{name: "ip", isDefault: true},
+		column{name: "heap.current"},
+		column{name: "heap.percent", isDefault: true},
+		column{name: "heap.max"},
+		column{name: "ram.current"},
+		column{name: "ram.percent", isDefault: true},
+		column{name: "ram.max"},
+		column{name: "cpu", isDefault: true},
+		column{name: "load_1m", isDefault: true},
+		column{name: "load_5m", isDefault: true},
+		column{name: "load_15m", isDefault: true},
+		column{name: "node.role", isDefault: true},
+		column{name: "master", isDefault: true},
+		column{name: "name", isDefault: true},
+	)
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	var ramUsed, ramTotal uint64
+	if m, err := mem.VirtualMemory(); err == nil {
+		ramUsed, ramTotal = m.Used, m.Total
+	}
+	// go has no max heap size, use the memory limit when it was set
+	heapMax := ramTotal
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		heapMax = uint64(limit)
+	}
+	var cpuPercent int64
+	if p, err := cpu.Percent(0, false); err == nil && len(p) > 0 {
+		cpuPercent = int64(p[0])
+	}
+	avg := new(load.AvgStat)
+	if l, err := load.Avg(); err == nil {
+		avg = l
+	}
+
+	host, _, _ := strings.Cut(c.Request.Host, ":")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	t.addRow(
+		strconv.Itoa(config.Global.NodeID),
+		host,
+		byteSize(ms.HeapAlloc),
+		percent(ms.HeapAlloc, heapMax),
+		byteSize(heapMax),
+		byteSize(ramUsed),
+		percent(ramUsed, ramTotal),
+		byteSize(ramTotal),
+		cpuPercent,
+		avg.Load1,
+		avg.Load5,
+		avg.Load15,
+		"dim",
+		"*",
+		elastic.NewESInfo(c).Name,
+	)
+
+	render(c, t)
+}
+
+func percent(used, total uint64) int64 {
+	if total == 0 {
+		return 0
+	}
+	return int64(used * 100 / total)
+}
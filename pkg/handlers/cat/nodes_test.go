@@ -0,0 +1,53 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package cat
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestNodes(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestURL(c, "/es/_cat/nodes", map[string]string{"v": ""})
+		Nodes(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.Len(t, lines, 2)
+		assert.Equal(t, []string{"ip", "heap.percent", "ram.percent", "cpu", "load_1m", "load_5m", "load_15m", "node.role", "master", "name"}, strings.Fields(lines[0]))
+		assert.Len(t, strings.Fields(lines[1]), 10)
+	})
+
+	t.Run("json with columns", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestURL(c, "/es/_cat/nodes", map[string]string{"format": "json", "h": "name,master,heap.max", "bytes": "b"})
+		Nodes(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		items := make([]map[string]string, 0)
+		err := json.Unmarshal(w.Body.Bytes(), &items)
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "*", items[0]["master"])
+		assert.NotEmpty(t, items[0]["heap.max"])
+	})
+}
@@ -17,18 +17,22 @@ package document
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/ider"
+	"github.com/zincsearch/zincsearch/pkg/ingest"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
@@ -42,8 +46,12 @@ import (
 // @Tags    Document
 // @Accept  plain
 // @Produce json
-// @Param   query  body  string  true  "Query"
-// @Success 200 {object} meta.HTTPResponseRecordCount
+// @Param   query       body   string  true   "Query"
+// @Param   pipeline    query  string  false  "Ingest pipeline"
+// @Param   max_errors  query  int     false  "Abort the bulk once this number of items failed"
+// @Param   refresh     query  string  false  "true flushes the written indexes after the bulk, wait_for waits for the next WAL consuming"
+// @Success 200 {object} BulkRecordCountResponse
+// @Failure 400 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
 // @Router /api/_bulk [post]
 func Bulk(c *gin.Context) {
@@ -51,16 +59,59 @@ func Bulk(c *gin.Context) {
 
 	defer c.Request.Body.Close()
 
-	ret, err := BulkWorker(target, c.Request.Body)
+	maxErrors, err := bulkMaxErrors(c)
 	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	// only the failed items are returned, the successful ones are counted
+	failed := []map[string]BulkResponseItem{}
+	ret, err := bulkStream(target, c.Query("pipeline"), maxErrors, c.Request.Body, func(items []map[string]BulkResponseItem) error {
+		for _, item := range items {
+			for _, v := range item {
+				if v.Error != nil {
+					failed = append(failed, item)
+				}
+			}
+		}
+		return nil
+	}, indexAuthorizer(c))
+	if refreshErr := ret.refresh(c.Request.Context(), refresh); err == nil {
+		err = refreshErr
+	}
+	if err != nil && !errors.Is(err, errBulkMaxErrors) {
 		zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
 
-	zutils.GinRenderJSON(c, http.StatusOK, meta.HTTPResponseRecordCount{Message: "bulk data inserted", RecordCount: ret.Count})
+	resp := BulkRecordCountResponse{Message: "bulk data inserted", RecordCount: ret.Count, Errors: ret.Errors, Items: failed}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
 }
 
-// ESBulk accept multiple documents, first line index metadata, second line document
+// bulkMaxErrors returns the max_errors of the request, it is 0 when the bulk is never aborted
+func bulkMaxErrors(c *gin.Context) (int, error) {
+	v := c.Query("max_errors")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := zutils.ToInt(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("[max_errors] should be a positive number, got [%s]", v)
+	}
+	return n, nil
+}
+
+// ESBulk accept multiple documents, first line index metadata, second line document,
+// the items are streamed to the client as the batches are written
 //
 // @Id ESBulk
 // @Summary ES bulk documents
@@ -68,8 +119,12 @@ func Bulk(c *gin.Context) {
 // @Tags    Document
 // @Accept  plain
 // @Produce json
-// @Param   query  body  string  true  "Query"
+// @Param   query       body   string  true   "Query"
+// @Param   pipeline    query  string  false  "Ingest pipeline"
+// @Param   max_errors  query  int     false  "Abort the bulk once this number of items failed"
+// @Param   refresh     query  string  false  "true flushes the written indexes after the bulk, wait_for waits for the next WAL consuming"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
 // @Router /es/_bulk [post]
 func ESBulk(c *gin.Context) {
@@ -77,155 +132,443 @@ func ESBulk(c *gin.Context) {
 
 	defer c.Request.Body.Close()
 
-	ret, err := BulkWorker(target, c.Request.Body)
+	maxErrors, err := bulkMaxErrors(c)
 	if err != nil {
-		ret.Error = err.Error()
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
 	}
 
 	startTime := time.Now()
+	if _, ok := c.GetQuery("pretty"); ok {
+		items := []map[string]BulkResponseItem{}
+		ret, err := bulkStream(target, c.Query("pipeline"), maxErrors, c.Request.Body, func(batch []map[string]BulkResponseItem) error {
+			items = append(items, batch...)
+			return nil
+		}, indexAuthorizer(c))
+		ret.Items = items
+		if refreshErr := ret.refresh(c.Request.Context(), refresh); err == nil {
+			err = refreshErr
+		}
+		if err != nil {
+			ret.Error = err.Error()
+		}
+		ret.Took = int(time.Since(startTime) / time.Millisecond)
+		zutils.GinRenderJSON(c, http.StatusOK, ret)
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.WriteString(`{"items":[`)
+	written := 0
+	ret, err := bulkStream(target, c.Query("pipeline"), maxErrors, c.Request.Body, func(items []map[string]BulkResponseItem) error {
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if written > 0 {
+				_, _ = w.WriteString(",")
+			}
+			if _, err = w.Write(data); err != nil {
+				return err
+			}
+			written++
+		}
+		w.Flush()
+		return nil
+	}, indexAuthorizer(c))
+	if refreshErr := ret.refresh(c.Request.Context(), refresh); err == nil {
+		err = refreshErr
+	}
+	if err != nil {
+		ret.Error = err.Error()
+	}
 	ret.Took = int(time.Since(startTime) / time.Millisecond)
-	// update seqNo
-	atomic.AddInt64(&globalSeqNo, int64(ret.Count))
 
-	zutils.GinRenderJSON(c, http.StatusOK, ret)
+	data, _ := json.Marshal(bulkSummary{Took: ret.Took, Errors: ret.Errors, Error: ret.Error})
+	_, _ = w.WriteString("]," + string(data[1:]))
 }
 
 func BulkWorker(target string, body io.Reader) (*BulkResponse, error) {
-	bulkRes := &BulkResponse{Items: []map[string]BulkResponseItem{}}
+	return BulkWorkerWithPipeline(target, "", body)
+}
 
-	// Prepare to read the entire raw text of the body
-	scanner := bufio.NewScanner(body)
+// BulkWorkerWithPipeline runs the documents through the ingest pipeline before indexing,
+// the pipeline of the action metadata overrides the default pipeline
+func BulkWorkerWithPipeline(target, defaultPipeline string, body io.Reader) (*BulkResponse, error) {
+	items := []map[string]BulkResponseItem{}
+	bulkRes, err := BulkStream(target, defaultPipeline, body, func(batch []map[string]BulkResponseItem) error {
+		items = append(items, batch...)
+		return nil
+	})
+	bulkRes.Items = items
+	return bulkRes, err
+}
+
+// BulkStream parses the body line by line and writes the documents in batches,
+// the items of every written batch are passed to onItems instead of kept in the response,
+// it waits for the indexes to catch up when too many WAL entries are pending so the memory stays bounded
+func BulkStream(target, defaultPipeline string, body io.Reader, onItems func(items []map[string]BulkResponseItem) error) (*BulkResponse, error) {
+	return bulkStream(target, defaultPipeline, 0, body, onItems, nil)
+}
+
+// bulkStream is BulkStream with the authorization of the write indexes, the actions on an
+// unauthorized index fail with 403 while the other actions are written. The bulk is aborted
+// with errBulkMaxErrors once maxErrors items failed, it is never aborted when maxErrors is 0.
+func bulkStream(target, defaultPipeline string, maxErrors int, body io.Reader, onItems func(items []map[string]BulkResponseItem) error, authorize func(index string) error) (*BulkResponse, error) {
+	w := &bulkWorker{
+		target:          target,
+		defaultPipeline: defaultPipeline,
+		maxErrors:       maxErrors,
+		pipelines:       make(map[string]*ingest.Pipeline),
+		onItems:         onItems,
+		authorize:       authorize,
+		res:             &BulkResponse{indexes: make(map[string]*core.Index)},
+	}
+	return w.res, w.run(body)
+}
 
-	// Set 1 MB max per line. docs at - https://pkg.go.dev/bufio#pkg-constants
+// indexAuthorizer checks the user of the request has its permission on the write index of an action
+func indexAuthorizer(c *gin.Context) func(index string) error {
+	return func(index string) error {
+		_, err := auth.AuthorizeContextIndexNames(c, []string{index}, core.ResolveIndexName)
+		return err
+	}
+}
+
+// bulkScannerBufferSize is the initial line buffer, it grows up to the max document size
+const bulkScannerBufferSize = 64 * 1024
+
+var errBulkFormat = errors.New("bulk index data format error")
+
+var errBulkMaxErrors = errors.New("bulk aborted, too many failed items")
+
+// bulkAction is a parsed action waiting to be written with its batch
+type bulkAction struct {
+	operation string
+	index     string
+	id        string
+	routing   string
+	pipeline  string
+	doc       map[string]interface{}
+	update    bool
+	cond      *core.WriteCondition // if_seq_no, if_primary_term, version and version_type of the metadata
+	invalid   error                // the metadata can't be parsed, the action fails with it
+	failed    *BulkResponseItem
+}
+
+type bulkWorker struct {
+	target          string
+	defaultPipeline string
+	pipelines       map[string]*ingest.Pipeline
+	onItems         func(items []map[string]BulkResponseItem) error
+	authorize       func(index string) error
+	maxErrors       int
+	failures        int
+	res             *BulkResponse
+	batch           []bulkAction
+}
+
+func (w *bulkWorker) run(body io.Reader) error {
+	scanner := bufio.NewScanner(body)
 	// This is the max size of a line in a file that we will process
 	maxCapacityPerLine := config.Global.MaxDocumentSize
-	buf := make([]byte, maxCapacityPerLine)
-	scanner.Buffer(buf, maxCapacityPerLine)
-
-	nextLineIsData := false
-	lastLineMetaData := make(map[string]interface{})
+	bufferSize := bulkScannerBufferSize
+	if bufferSize > maxCapacityPerLine {
+		bufferSize = maxCapacityPerLine
+	}
+	scanner.Buffer(make([]byte, 0, bufferSize), maxCapacityPerLine)
 
-	var doc map[string]interface{}
-	var err error
+	// the metadata line of the action waiting for its data line
+	var pending *bulkAction
 	for scanner.Scan() { // Read each line
-		for k := range doc {
-			delete(doc, k)
-		}
-		if err = json.Unmarshal(scanner.Bytes(), &doc); err != nil {
-			log.Error().Msgf("bulk.json.Unmarshal: %s, err %s", scanner.Text(), err.Error())
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
 			continue
 		}
+		var doc map[string]interface{}
+		err := json.Unmarshal(line, &doc)
 
 		// This will process the data line in the request. Each data line is preceded by a metadata line.
 		// Docs at https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
-		if nextLineIsData {
-			bulkRes.Count++
-			nextLineIsData = false
-			update := false
-
-			docID := ""
-			if val, ok := lastLineMetaData["_id"]; ok && val != nil {
-				docID = val.(string)
-			}
-			if docID == "" {
-				docID = ider.Generate()
+		if pending != nil {
+			action := *pending
+			pending = nil
+			if err != nil {
+				err = w.addFailed(action, err)
 			} else {
-				update = true
+				err = w.addDocument(action, doc)
 			}
-			suppliedIndexName := lastLineMetaData["_index"]
-			if suppliedIndexName == nil && len(target) > 0 {
-				suppliedIndexName = target
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			log.Error().Msgf("bulk.json.Unmarshal: %s, err %s", scanner.Text(), err.Error())
+			continue
+		}
+
+		// This branch will process the metadata line in the request.
+		for k, v := range doc {
+			vm, ok := v.(map[string]interface{})
+			if !ok {
+				return errBulkFormat
 			}
-			suppliedOperation := lastLineMetaData["operation"]
-			if suppliedOperation == nil && len(target) > 0 {
-				suppliedOperation = "create"
+			action := bulkAction{operation: k}
+			action.index, _ = vm["_index"].(string)
+			action.id, _ = vm["_id"].(string)
+			action.pipeline, _ = vm["pipeline"].(string)
+			if action.routing, _ = vm["routing"].(string); action.routing == "" {
+				action.routing, _ = vm["_routing"].(string)
 			}
-			indexName := suppliedIndexName.(string)
-			operation := suppliedOperation.(string)
-			switch operation {
-			case "index":
-				bulkRes.Items = append(bulkRes.Items, map[string]BulkResponseItem{
-					"index": NewBulkResponseItem(bulkRes.Count, indexName, docID, "created", nil),
-				})
-			case "create":
-				bulkRes.Items = append(bulkRes.Items, map[string]BulkResponseItem{
-					"index": NewBulkResponseItem(bulkRes.Count, indexName, docID, "created", nil),
-				})
-			case "update":
-				bulkRes.Items = append(bulkRes.Items, map[string]BulkResponseItem{
-					"index": NewBulkResponseItem(bulkRes.Count, indexName, docID, "updated", nil),
-				})
-			default:
+			action.cond, action.invalid = parseWriteCondition(metadataString(vm, "if_seq_no"), metadataString(vm, "if_primary_term"),
+				metadataString(vm, "version"), metadataString(vm, "version_type"), "")
+			switch k {
+			case "index", "create", "update":
+				pending = &action
+			case "delete":
+				if err := w.addDelete(action); err != nil {
+					return err
+				}
 			}
+		}
+	}
 
-			newIndex, _, err := core.GetOrCreateIndex(indexName, "", 0)
-			if err != nil {
-				return bulkRes, err
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("bulk line exceeds the max document size [%d]", maxCapacityPerLine)
+		}
+		return err
+	}
+
+	return w.flush()
+}
+
+// metadataString returns the value of the field of the action metadata as a string, the numbers are formatted
+func metadataString(vm map[string]interface{}, field string) string {
+	v, ok := vm[field]
+	if !ok || v == nil {
+		return ""
+	}
+	s, _ := zutils.ToString(v)
+	return s
+}
+
+// resolveIndex sets the write index of the action, the index in metadata overtakes the index in the query path.
+// The action written through an alias without routing uses the index routing of the alias.
+// The bulk can't go on without an index, the other errors fail the action only.
+func (w *bulkWorker) resolveIndex(action *bulkAction) error {
+	name := action.index
+	if name == "" {
+		name = w.target
+	}
+	if name == "" {
+		return errBulkFormat
+	}
+	action.index = name
+	index, err := core.ResolveWriteIndex(name)
+	if err != nil {
+		return illegalArgumentError(err)
+	}
+	if action.routing == "" {
+		action.routing = core.ZINC_INDEX_ALIAS_LIST.IndexRouting(name)
+	}
+	action.index = index
+	return nil
+}
+
+func (w *bulkWorker) addDocument(action bulkAction, doc map[string]interface{}) error {
+	w.res.Count++
+	action.doc = doc
+	if action.id == "" {
+		action.id = ider.Generate()
+	} else {
+		action.update = true
+	}
+	err := w.resolveIndex(&action)
+	if err == errBulkFormat {
+		return err
+	}
+	if w.fail(&action, err) || w.deny(&action) || w.fail(&action, action.invalid) {
+		return w.add(action)
+	}
+
+	pipelineID := w.defaultPipeline
+	if action.pipeline != "" {
+		pipelineID = action.pipeline
+	}
+	if pipelineID != "" && pipelineID != "_none" {
+		pipeline, ok := w.pipelines[pipelineID]
+		if !ok {
+			if pipeline, err = core.LoadPipeline(pipelineID); err != nil {
+				w.fail(&action, illegalArgumentError(err))
+				return w.add(action)
 			}
+			w.pipelines[pipelineID] = pipeline
+		}
+		if err = pipeline.Run(doc); err != nil {
+			w.fail(&action, illegalArgumentError(err))
+		}
+	}
+	return w.add(action)
+}
 
-			err = newIndex.CreateDocument(docID, doc, update)
-			if err != nil {
-				return bulkRes, err
+// addFailed adds the action whose data line failed to parse
+func (w *bulkWorker) addFailed(action bulkAction, err error) error {
+	w.res.Count++
+	if action.index == "" {
+		action.index = w.target
+	}
+	w.fail(&action, err)
+	return w.add(action)
+}
+
+func (w *bulkWorker) addDelete(action bulkAction) error {
+	if action.id == "" {
+		return errBulkFormat
+	}
+	w.res.Count++
+	err := w.resolveIndex(&action)
+	if err == errBulkFormat {
+		return err
+	}
+	if !w.fail(&action, err) && !w.deny(&action) {
+		w.fail(&action, action.invalid)
+	}
+	return w.add(action)
+}
+
+// deny fails the action when the user is not authorized on its index
+func (w *bulkWorker) deny(action *bulkAction) bool {
+	if w.authorize == nil {
+		return false
+	}
+	return w.fail(action, w.authorize(action.index))
+}
+
+// fail fails the action with the error, it returns false when the error is nil
+func (w *bulkWorker) fail(action *bulkAction, err error) bool {
+	if err == nil {
+		return false
+	}
+	item := NewBulkResponseItem(action.index, action.id, "", core.WriteResult{}, err)
+	action.failed = &item
+	return true
+}
+
+// add adds the action to the batch, with max_errors the batch is written as soon as
+// a failed action comes so the bulk is aborted before reading the rest of the body
+func (w *bulkWorker) add(action bulkAction) error {
+	w.batch = append(w.batch, action)
+	if len(w.batch) >= config.Global.BulkBatchSize || (action.failed != nil && w.maxErrors > 0) {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the batch and emits its items, then it applies the backpressure of the written indexes
+func (w *bulkWorker) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	items := make([]map[string]BulkResponseItem, 0, len(w.batch))
+	indexes := make(map[string]*core.Index)
+	var err error
+	for _, action := range w.batch {
+		var item BulkResponseItem
+		if item, err = w.write(indexes, action); err != nil {
+			break
+		}
+		items = append(items, map[string]BulkResponseItem{action.operation: item})
+		if item.Error != nil {
+			w.res.Errors = true
+			w.failures++
+			if w.maxErrors > 0 && w.failures >= w.maxErrors {
+				err = fmt.Errorf("%w: [%d] reached max_errors [%d]", errBulkMaxErrors, w.failures, w.maxErrors)
+				break
 			}
+		}
+	}
+	w.batch = w.batch[:0]
+	if w.onItems != nil {
+		if emitErr := w.onItems(items); emitErr != nil && err == nil {
+			err = emitErr
+		}
+	}
+	if err != nil {
+		return err
+	}
 
-		} else { // This branch will process the metadata line in the request. Each metadata line is preceded by a data line.
+	for _, index := range indexes {
+		if !index.WaitForWALPending(uint64(config.Global.BulkMaxPending), config.Global.BulkMaxPendingWait) {
+			log.Warn().Str("index", index.GetName()).Msg("bulk backpressure timeout")
+		}
+	}
+	return nil
+}
 
-			for k, v := range doc {
-				vm, ok := v.(map[string]interface{})
-				if !ok {
-					return nil, errors.New("bulk index data format error")
-				}
-				for k := range lastLineMetaData {
-					delete(lastLineMetaData, k)
-				}
-				if k == "index" || k == "create" || k == "update" {
-					nextLineIsData = true
-					lastLineMetaData["operation"] = k
-
-					if vm["_index"] != "" { // if index is specified in metadata then it overtakes the index in the query path
-						lastLineMetaData["_index"] = vm["_index"]
-					} else {
-						lastLineMetaData["_index"] = target
-					}
-					if lastLineMetaData["_index"] == "" {
-						return nil, errors.New("bulk index data format error")
-					}
-					lastLineMetaData["_id"] = vm["_id"]
-				} else if k == "delete" {
-					nextLineIsData = false
-					docID := vm["_id"].(string)
-					indexName := target
-					if vm["_index"] != "" { // if index is specified in metadata then it overtakes the index in the query path
-						indexName = vm["_index"].(string)
-					}
-					if indexName == "" {
-						return nil, errors.New("bulk index data format error")
-					}
-
-					newIndex, _, err := core.GetOrCreateIndex(indexName, "", 0)
-					if err != nil {
-						return bulkRes, err
-					}
-
-					// delete
-					err = newIndex.DeleteDocument(docID)
-					bulkRes.Count++
-					bulkRes.Items = append(bulkRes.Items, map[string]BulkResponseItem{
-						"delete": NewBulkResponseItem(bulkRes.Count, indexName, docID, "deleted", err),
-					})
-				} else {
-					lastLineMetaData["_index"] = target
-					lastLineMetaData["operation"] = "index"
-				}
+// write writes the action and returns its item, the item has the error of the failed action.
+// The error is returned when the index can't be opened and the bulk can't go on.
+func (w *bulkWorker) write(indexes map[string]*core.Index, action bulkAction) (BulkResponseItem, error) {
+	if action.failed != nil {
+		return *action.failed, nil
+	}
+	index, ok := indexes[action.index]
+	if !ok {
+		var err error
+		if index, _, err = core.GetOrCreateIndex(action.index, "", 0); err != nil {
+			return BulkResponseItem{}, err
+		}
+		indexes[action.index] = index
+		w.res.indexes[action.index] = index
+	}
+
+	cond := core.RoutingCondition(action.routing)
+	if action.cond != nil {
+		cond = action.cond
+		cond.Routing = action.routing
+	}
+	// a data stream only appends documents, the document with an id is created if it doesn't exist
+	if index.GetDataStream() != "" {
+		create := action.operation == "create" || (action.operation == "index" && !action.update)
+		if err := index.CheckDataStreamWrite(action.doc, create); err != nil {
+			return NewBulkResponseItem(action.index, action.id, "", core.WriteResult{}, err), nil
+		}
+		if action.update {
+			if cond == nil {
+				cond = &core.WriteCondition{Routing: action.routing}
 			}
+			cond.Create = true
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return bulkRes, err
+	if action.operation == "delete" {
+		ret, err := index.DeleteDocumentIf(action.id, cond)
+		if isDocumentNotFound(err) {
+			item := NewBulkResponseItem(action.index, action.id, "not_found", ret, nil)
+			item.Status = http.StatusNotFound
+			return item, nil
+		}
+		return NewBulkResponseItem(action.index, action.id, "deleted", ret, err), nil
 	}
 
-	return bulkRes, nil
+	ret, err := index.CreateDocumentIf(action.id, action.doc, action.update, cond)
+	result := "updated"
+	switch {
+	case err != nil:
+		result = ""
+	case ret.Created:
+		result = "created"
+	}
+	return NewBulkResponseItem(action.index, action.id, result, ret, err), nil
 }
 
 // DoesExistInThisRequest takes a slice and looks for an element in it. If found it will
@@ -239,12 +582,14 @@ func DoesExistInThisRequest(slice []string, val string) int {
 	return -1
 }
 
-func NewBulkResponseItem(seqNo int64, index, id, result string, err error) BulkResponseItem {
-	return BulkResponseItem{
+// NewBulkResponseItem returns the item of a bulk action with the sequence number and the version
+// of its write, the item is failed when err is not nil
+func NewBulkResponseItem(index, id, result string, ret core.WriteResult, err error) BulkResponseItem {
+	item := BulkResponseItem{
 		Index:   index,
 		Type:    "_doc",
 		ID:      id,
-		Version: 1,
+		Version: ret.Version,
 		Result:  result,
 		Shards: BulkResponseItemShard{
 			Total:      1,
@@ -252,33 +597,64 @@ func NewBulkResponseItem(seqNo int64, index, id, result string, err error) BulkR
 			Failed:     0,
 		},
 		Status:      200,
-		SeqNo:       globalSeqNo + seqNo,
-		PrimaryTerm: 1,
-		Error:       err,
+		SeqNo:       ret.SeqNo,
+		PrimaryTerm: core.PrimaryTerm,
+	}
+	if err != nil {
+		item.Error = NewBulkResponseItemError(err)
+		item.Status = bulkItemStatus(err)
+		item.Shards.Successful, item.Shards.Failed = 0, 1
 	}
+	return item
 }
 
-var globalSeqNo int64
-
 type BulkResponse struct {
 	Took   int                           `json:"took"`
 	Errors bool                          `json:"errors"`
 	Error  string                        `json:"error,omitempty"`
 	Items  []map[string]BulkResponseItem `json:"items"`
 	Count  int64                         `json:"-"`
+	// indexes are the written indexes
+	indexes map[string]*core.Index
+}
+
+// refresh refreshes the written indexes by the refresh policy
+func (r *BulkResponse) refresh(ctx context.Context, policy string) error {
+	for _, index := range r.indexes {
+		if _, err := index.Refresh(ctx, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkRecordCountResponse is the response of the zinc bulk API, it has the failed items only
+type BulkRecordCountResponse struct {
+	Message     string                        `json:"message"`
+	RecordCount int64                         `json:"record_count"`
+	Errors      bool                          `json:"errors"`
+	Error       string                        `json:"error,omitempty"`
+	Items       []map[string]BulkResponseItem `json:"items,omitempty"`
+}
+
+// bulkSummary is the end of the streamed bulk response following the items
+type bulkSummary struct {
+	Took   int    `json:"took"`
+	Errors bool   `json:"errors"`
+	Error  string `json:"error,omitempty"`
 }
 
 type BulkResponseItem struct {
-	Index       string                `json:"_index"`
-	Type        string                `json:"_type"`
-	ID          string                `json:"_id"`
-	Version     int64                 `json:"_version"`
-	Result      string                `json:"result"`
-	Status      int                   `json:"status"`
-	Shards      BulkResponseItemShard `json:"_shards"`
-	SeqNo       int64                 `json:"_seq_no"`
-	PrimaryTerm int                   `json:"_primary_term"`
-	Error       error                 `json:"error,omitempty"`
+	Index       string                 `json:"_index"`
+	Type        string                 `json:"_type"`
+	ID          string                 `json:"_id"`
+	Version     int64                  `json:"_version"`
+	Result      string                 `json:"result"`
+	Status      int                    `json:"status"`
+	Shards      BulkResponseItemShard  `json:"_shards"`
+	SeqNo       int64                  `json:"_seq_no"`
+	PrimaryTerm int64                  `json:"_primary_term"`
+	Error       *BulkResponseItemError `json:"error,omitempty"`
 }
 
 type BulkResponseItemShard struct {
@@ -0,0 +1,82 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package document
+
+import (
+	"net/http"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+)
+
+// ErrorTypeMapperParsingException is the error type of the documents which failed to parse
+const ErrorTypeMapperParsingException = "mapper_parsing_exception"
+
+// BulkResponseItemError is the error of a failed bulk item
+type BulkResponseItemError struct {
+	Type     string                 `json:"type"`
+	Reason   string                 `json:"reason"`
+	CausedBy *BulkResponseItemError `json:"caused_by,omitempty"`
+}
+
+// NewBulkResponseItemError converts the error to the bulk item error, the zinc errors keep their type
+// and cause, the other errors are the failures to parse the document with the error as the cause
+func NewBulkResponseItemError(err error) *BulkResponseItemError {
+	if err == nil {
+		return nil
+	}
+	var e *errors.Error
+	if errors.As(err, &e) {
+		return newBulkResponseItemError(e)
+	}
+	return &BulkResponseItemError{
+		Type:     ErrorTypeMapperParsingException,
+		Reason:   "failed to parse",
+		CausedBy: &BulkResponseItemError{Type: errors.ErrorTypeIllegalArgumentException, Reason: err.Error()},
+	}
+}
+
+func newBulkResponseItemError(e *errors.Error) *BulkResponseItemError {
+	ret := &BulkResponseItemError{Type: e.Type, Reason: e.Reason}
+	if e.CausedBy != nil {
+		var cause *errors.Error
+		if errors.As(e.CausedBy, &cause) {
+			ret.CausedBy = newBulkResponseItemError(cause)
+		} else {
+			ret.CausedBy = &BulkResponseItemError{Type: errors.ErrorTypeRuntimeException, Reason: e.CausedBy.Error()}
+		}
+	}
+	return ret
+}
+
+// bulkItemStatus returns the http status of the failed bulk item
+func bulkItemStatus(err error) int {
+	return errors.StatusCode(err, http.StatusBadRequest)
+}
+
+// illegalArgumentError returns the error of the action as an illegal argument unless it has a type,
+// e.g. the errors of the ingest pipeline and of resolving the write index
+func illegalArgumentError(err error) error {
+	var e *errors.Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return errors.New(errors.ErrorTypeIllegalArgumentException, err.Error())
+}
+
+// isDocumentNotFound reports whether the document of the action doesn't exist
+func isDocumentNotFound(err error) bool {
+	return errors.Is(err, errors.ErrorIDNotFound)
+}
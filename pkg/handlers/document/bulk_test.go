@@ -17,10 +17,16 @@ package document
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 	"github.com/zincsearch/zincsearch/test/utils"
 )
 
@@ -123,3 +129,230 @@ func TestESBulk(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkWorker_WriteAlias(t *testing.T) {
+	alias := "TestBulkWorker_WriteAlias.alias"
+	index1 := "TestBulkWorker_WriteAlias.index_1"
+	index2 := "TestBulkWorker_WriteAlias.index_2"
+	assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{index1, index2}))
+	defer func() {
+		assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{index1, index2}))
+	}()
+
+	data := `{ "index" : { "_index" : "TestBulkWorker_WriteAlias.alias" } }
+	{"name": "user"}`
+	// the action fails without a write index, the others are written
+	resp, err := BulkWorker("", strings.NewReader(data+`
+	{ "index" : { "_index" : "TestBulkWorker_WriteAlias.index_2" } }
+	{"name": "user"}`))
+	assert.NoError(t, err)
+	assert.True(t, resp.Errors)
+	assert.Len(t, resp.Items, 2)
+	item := resp.Items[0]["index"]
+	assert.Equal(t, alias, item.Index)
+	assert.Equal(t, http.StatusBadRequest, item.Status)
+	assert.Equal(t, errors.ErrorTypeIllegalArgumentException, item.Error.Type)
+	assert.Contains(t, item.Error.Reason, "no write index")
+	assert.Nil(t, resp.Items[1]["index"].Error)
+
+	assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.SetWriteIndex(alias, index2))
+	resp, err = BulkWorker("", strings.NewReader(data))
+	assert.NoError(t, err)
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, index2, resp.Items[0]["index"].Index)
+	assert.NoError(t, core.DeleteIndex(index2))
+}
+
+func TestBulkStream(t *testing.T) {
+	indexName := "TestBulkStream.index_1"
+	batchSize, maxDocumentSize := config.Global.BulkBatchSize, config.Global.MaxDocumentSize
+	config.Global.BulkBatchSize = 2
+	defer func() {
+		config.Global.BulkBatchSize, config.Global.MaxDocumentSize = batchSize, maxDocumentSize
+	}()
+
+	t.Run("batches", func(t *testing.T) {
+		data := `{"index":{"_id":"1"}}
+		{"name":"a"}
+
+		{"create":{}}
+		{"name":"b"}
+		{"update":{"_id":"1"}}
+		{"name":"c"}
+		{"delete":{"_id":"1"}}
+		{"index":{"_id":"2"}}
+		{"name":"d"}`
+		batches := 0
+		ret, err := BulkStream(indexName, "", strings.NewReader(data), func(items []map[string]BulkResponseItem) error {
+			batches++
+			assert.LessOrEqual(t, len(items), 2)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), ret.Count)
+		assert.Equal(t, 3, batches)
+		assert.Nil(t, ret.Items)
+	})
+
+	t.Run("streamed response", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"index":{"_id":"3"}}
+		{"name":"e"}
+		{"index":{"_id":"4"}}
+		{"name":"f"}
+		{"delete":{"_id":"3"}}`)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		ESBulk(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := struct {
+			Errors bool                                `json:"errors"`
+			Items  []map[string]map[string]interface{} `json:"items"`
+		}{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.False(t, resp.Errors)
+		assert.Len(t, resp.Items, 3)
+		assert.Equal(t, "4", resp.Items[1]["index"]["_id"])
+		assert.Equal(t, "deleted", resp.Items[2]["delete"]["result"])
+	})
+
+	t.Run("item errors", func(t *testing.T) {
+		data := `{"index":{"_id":"5"}}
+		{"count":1}
+		{"index":{"_id":"6"}}
+		{"count":"abc"}
+		{"index":{"_id":"7"}}
+		{"count":
+		{"delete":{"_id":"8"}}
+		{"create":{"_id":"9"}}
+		{"count":2}`
+		ret, err := BulkWorker(indexName, strings.NewReader(data))
+		assert.NoError(t, err)
+		assert.True(t, ret.Errors)
+		assert.Len(t, ret.Items, 5)
+		assert.Nil(t, ret.Items[0]["index"].Error)
+
+		item := ret.Items[1]["index"]
+		assert.Equal(t, http.StatusBadRequest, item.Status)
+		assert.Equal(t, 1, item.Shards.Failed)
+		assert.Equal(t, ErrorTypeMapperParsingException, item.Error.Type)
+		assert.Contains(t, item.Error.CausedBy.Reason, "[count]")
+
+		item = ret.Items[2]["index"]
+		assert.Equal(t, "7", item.ID)
+		assert.Equal(t, http.StatusBadRequest, item.Status)
+		assert.NotNil(t, item.Error)
+
+		item = ret.Items[3]["delete"]
+		assert.Equal(t, "not_found", item.Result)
+		assert.Equal(t, http.StatusNotFound, item.Status)
+		assert.Nil(t, item.Error)
+		assert.Equal(t, "created", ret.Items[4]["create"].Result)
+	})
+
+	t.Run("max errors", func(t *testing.T) {
+		data := `{"index":{"_id":"10"}}
+		{"count":"a"}
+		{"index":{"_id":"11"}}
+		{"count":"b"}
+		{"index":{"_id":"12"}}
+		{"count":3}`
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, data)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		utils.SetGinRequestURL(c, "/es/_bulk", map[string]string{"max_errors": "2"})
+		ESBulk(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := struct {
+			Errors bool                                `json:"errors"`
+			Error  string                              `json:"error"`
+			Items  []map[string]map[string]interface{} `json:"items"`
+		}{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Errors)
+		assert.Contains(t, resp.Error, "max_errors [2]")
+		assert.Len(t, resp.Items, 2)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestData(c, data)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		utils.SetGinRequestURL(c, "/api/_bulk", map[string]string{"max_errors": "1"})
+		Bulk(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		bulkResp := BulkRecordCountResponse{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bulkResp))
+		assert.True(t, bulkResp.Errors)
+		assert.NotEmpty(t, bulkResp.Error)
+		assert.Len(t, bulkResp.Items, 1)
+		assert.Equal(t, "10", bulkResp.Items[0]["index"].ID)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestData(c, data)
+		utils.SetGinRequestURL(c, "/api/_bulk", map[string]string{"max_errors": "-1"})
+		Bulk(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown pipeline", func(t *testing.T) {
+		ret, err := BulkWorker(indexName, strings.NewReader(`{"index":{"_id":"13","pipeline":"TestBulkStream.unknown"}}
+		{"name":"a"}
+		{"index":{"_id":"14"}}
+		{"name":"b"}`))
+		assert.NoError(t, err)
+		assert.True(t, ret.Errors)
+		assert.Len(t, ret.Items, 2)
+		item := ret.Items[0]["index"]
+		assert.Equal(t, http.StatusBadRequest, item.Status)
+		assert.Equal(t, errors.ErrorTypeIllegalArgumentException, item.Error.Type)
+		assert.Contains(t, item.Error.Reason, "[TestBulkStream.unknown] does not exist")
+		assert.Equal(t, "created", ret.Items[1]["index"].Result)
+	})
+
+	t.Run("seq_no and version", func(t *testing.T) {
+		ret, err := BulkWorker(indexName, strings.NewReader(`{"index":{"_id":"20"}}
+		{"name":"a"}
+		{"index":{"_id":"21","version":5,"version_type":"external"}}
+		{"name":"b"}
+		{"index":{"_id":"22","version":"x","version_type":"external"}}
+		{"name":"c"}`))
+		assert.NoError(t, err)
+		assert.Len(t, ret.Items, 3)
+		item := ret.Items[0]["index"]
+		assert.Equal(t, "created", item.Result)
+		assert.Equal(t, int64(1), item.Version)
+		assert.Equal(t, core.PrimaryTerm, item.PrimaryTerm)
+		assert.Equal(t, int64(5), ret.Items[1]["index"].Version)
+		assert.Equal(t, http.StatusBadRequest, ret.Items[2]["index"].Status)
+
+		// the seq_no of the item is the one of the document
+		data := `{"index":{"_id":"20","if_seq_no":` + strconv.FormatInt(item.SeqNo, 10) + `,"if_primary_term":1}}
+		{"name":"d"}`
+		ret, err = BulkWorker(indexName, strings.NewReader(data))
+		assert.NoError(t, err)
+		updated := ret.Items[0]["index"]
+		assert.Nil(t, updated.Error)
+		assert.Equal(t, "updated", updated.Result)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Greater(t, updated.SeqNo, item.SeqNo)
+
+		// the stale seq_no is a version conflict
+		ret, err = BulkWorker(indexName, strings.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, ret.Items[0]["index"].Status)
+
+		index, ok := core.GetIndex(indexName)
+		assert.True(t, ok)
+		_, err = index.CreateDocumentIf("20", map[string]interface{}{"name": "e"}, true, &core.WriteCondition{IfSeqNo: updated.SeqNo, IfPrimaryTerm: updated.PrimaryTerm})
+		assert.NoError(t, err)
+	})
+
+	t.Run("line too long", func(t *testing.T) {
+		config.Global.MaxDocumentSize = 32
+		_, err := BulkStream(indexName, "", strings.NewReader(`{"index":{}}
+		{"name":"a very long value exceeding the max document size"}`), nil)
+		assert.ErrorContains(t, err, "exceeds the max document size [32]")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
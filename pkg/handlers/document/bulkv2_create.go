@@ -20,6 +20,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/ider"
 	"github.com/zincsearch/zincsearch/pkg/meta"
@@ -35,7 +36,8 @@ import (
 // @Tags    Document
 // @Accept  json
 // @Produce json
-// @Param   query  body  meta.JSONIngest  true  "Query"
+// @Param   query    body   meta.JSONIngest  true   "Query"
+// @Param   refresh  query  string           false  "true flushes the index after the bulk, wait_for waits for the next WAL consuming"
 // @Success 200 {object} meta.HTTPResponseRecordCount
 // @Failure 400 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
@@ -49,13 +51,27 @@ func Bulkv2(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
 	if target == "" {
 		target = body.Index
 	}
+	if _, err := auth.AuthorizeContextIndexNames(c, []string{target}, core.ResolveIndexName); err != nil {
+		c.JSON(http.StatusForbidden, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
 	defer c.Request.Body.Close()
 	count, err := Bulkv2Worker(target, body)
+	if err == nil && refresh != core.RefreshFalse {
+		if index, ok := core.GetIndex(target); ok {
+			_, err = index.Refresh(c.Request.Context(), refresh)
+		}
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
@@ -16,11 +16,15 @@
 package document
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/ider"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
@@ -34,15 +38,33 @@ import (
 // @Produce json
 // @Param   index     path  string  true  "Index"
 // @Param   document  body  map[string]interface{}  true  "Document"
-// @Success 200 {object} meta.HTTPResponseID
+// @Param   pipeline  query string  false  "Ingest pipeline"
+// @Param   if_seq_no        query int     false  "Only write if the document has this sequence number"
+// @Param   if_primary_term  query int     false  "Only write if the document has this primary term"
+// @Param   version          query int     false  "Version of the document for the external version types"
+// @Param   version_type     query string  false  "Version type: internal, external or external_gte"
+// @Param   routing          query string  false  "Routing of the document, it picks the shard instead of the id"
+// @Param   refresh          query string  false  "true flushes the index after the write, wait_for waits for the next WAL consuming"
+// @Success 200 {object} meta.HTTPResponseESID
 // @Failure 400 {object} meta.HTTPResponseError
+// @Failure 409 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
 // @Router /api/{index}/_doc [post]
 func CreateUpdate(c *gin.Context) {
 	indexName := c.Param("target")
 	docID := c.Param("id") // ID for the document to be updated provided in URL path
 
-	var err error
+	cond, err := writeCondition(c)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
 	var doc map[string]interface{}
 	if err = zutils.GinBindJSON(c, &doc); err != nil {
 		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
@@ -60,41 +82,124 @@ func CreateUpdate(c *gin.Context) {
 		update = true
 	}
 
+	// Run the document through the ingest pipeline before indexing
+	if id := c.Query("pipeline"); id != "" {
+		pipeline, err := core.LoadPipeline(id)
+		if err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+		if err = pipeline.Run(doc); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	}
+
 	// If the index does not exist, then create it
 	index, _, err := core.GetOrCreateIndex(indexName, "", 0)
 	if err != nil {
 		zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
+	// a data stream only appends documents, the document with an id is created if it doesn't exist
+	if index.GetDataStream() != "" {
+		create := !update || c.Query("op_type") == "create" || strings.Contains(c.FullPath(), "/_create/")
+		if err = index.CheckDataStreamWrite(doc, create); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+		if update {
+			if cond == nil {
+				cond = core.RoutingCondition(c.Query("routing"))
+			}
+			if cond == nil {
+				cond = new(core.WriteCondition)
+			}
+			cond.Create = true
+		}
+	}
 
-	err = index.CreateDocument(docID, doc, update)
+	ret, err := index.CreateDocumentIf(docID, doc, update, cond)
+	if err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusInternalServerError), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	forced, err := index.Refresh(c.Request.Context(), refresh)
 	if err != nil {
 		zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
+	result := "updated"
+	if ret.Created {
+		result = "created"
+	}
 	zutils.GinRenderJSON(c, http.StatusOK, meta.HTTPResponseESID{
-		Message:     "ok",
-		ID:          docID,
-		ESID:        docID,
-		Index:       indexName,
-		Version:     1,
-		SeqNo:       0,
-		PrimaryTerm: 0,
-		Result:      "created",
+		Message:       "ok",
+		ID:            docID,
+		ESID:          docID,
+		Index:         indexName,
+		Version:       ret.Version,
+		SeqNo:         ret.SeqNo,
+		PrimaryTerm:   core.PrimaryTerm,
+		Routing:       c.Query("routing"),
+		Result:        result,
+		ForcedRefresh: forced,
 	})
 }
 
-// @Id IndexDocumentWithID
-// @Summary Create or update document with id
-// @security BasicAuth
-// @Tags    Document
-// @Accept  json
-// @Produce json
-// @Param   index     path  string  true  "Index"
-// @Param   id        path  string  true  "ID"
-// @Param   document  body  map[string]interface{}  true  "Document"
-// @Success 200 {object} meta.HTTPResponseID
-// @Failure 400 {object} meta.HTTPResponseError
-// @Failure 500 {object} meta.HTTPResponseError
-// @Router /api/{index}/_doc/{id} [put]
-func CreateWithIDForSDK() {}
+// refreshPolicy returns the refresh policy of the refresh parameter, it is false without the parameter
+func refreshPolicy(c *gin.Context) (string, error) {
+	v, ok := c.GetQuery("refresh")
+	return core.ParseRefresh(v, ok)
+}
+
+// writeCondition returns the condition of the if_seq_no, if_primary_term, version, version_type
+// and routing parameters, it is nil without the parameters
+func writeCondition(c *gin.Context) (*core.WriteCondition, error) {
+	return parseWriteCondition(c.Query("if_seq_no"), c.Query("if_primary_term"), c.Query("version"), c.Query("version_type"), c.Query("routing"))
+}
+
+// parseWriteCondition returns the condition of the values of if_seq_no, if_primary_term, version,
+// version_type and routing, it is nil without the values
+func parseWriteCondition(seqNo, primaryTerm, version, versionType, routing string) (*core.WriteCondition, error) {
+	versionType = strings.ToLower(versionType)
+	if seqNo == "" && primaryTerm == "" && version == "" && versionType == "" {
+		return core.RoutingCondition(routing), nil
+	}
+
+	cond := &core.WriteCondition{Routing: routing}
+	var err error
+	if seqNo != "" || primaryTerm != "" {
+		if seqNo == "" || primaryTerm == "" {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[if_seq_no] and [if_primary_term] must be set together")
+		}
+		if cond.IfSeqNo, err = strconv.ParseInt(seqNo, 10, 64); err != nil || cond.IfSeqNo < 0 {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[if_seq_no] must be a non-negative number, got [%s]", seqNo))
+		}
+		if cond.IfPrimaryTerm, err = strconv.ParseInt(primaryTerm, 10, 64); err != nil || cond.IfPrimaryTerm <= 0 {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[if_primary_term] must be a positive number, got [%s]", primaryTerm))
+		}
+	}
+
+	switch versionType {
+	case "", core.VersionTypeInternal:
+		if version != "" {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "internal versioning can not be used for optimistic concurrency control, please use [if_seq_no] and [if_primary_term] instead")
+		}
+		return cond, nil
+	case core.VersionTypeExternal, core.VersionTypeExternalGTE:
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[version_type] unknown version type [%s]", versionType))
+	}
+	if cond.IfPrimaryTerm > 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("compare and write operations can not be used with version type [%s]", versionType))
+	}
+	if version == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[version] is required for version type [%s]", versionType))
+	}
+	if cond.Version, err = strconv.ParseInt(version, 10, 64); err != nil || cond.Version < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[version] must be a non-negative number, got [%s]", version))
+	}
+	cond.VersionType = versionType
+	return cond, nil
+}
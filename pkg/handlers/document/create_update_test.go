@@ -17,11 +17,14 @@ package document
 
 import (
 	"net/http"
+	"strconv"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 	"github.com/zincsearch/zincsearch/test/utils"
 )
 
@@ -140,3 +143,164 @@ func TestCreateUpdate(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestCreateUpdateSeqNo(t *testing.T) {
+	indexName := "TestDocumentCreateUpdateSeqNo.index_1"
+	write := func(handler gin.HandlerFunc, data map[string]interface{}, query map[string]string) (int, map[string]interface{}) {
+		c, w := utils.NewGinContext()
+		if data != nil {
+			utils.SetGinRequestData(c, data)
+		}
+		utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", query)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+		handler(c)
+		resp := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	var seqNo string
+	t.Run("create", func(t *testing.T) {
+		code, resp := write(CreateUpdate, map[string]interface{}{"name": "user"}, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(1), resp["_primary_term"])
+		seqNo = strconv.Itoa(int(resp["_seq_no"].(float64)))
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		code, _ := write(CreateUpdate, map[string]interface{}{"name": "user"}, map[string]string{"if_seq_no": seqNo})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = write(Update, map[string]interface{}{"name": "user"}, map[string]string{"if_seq_no": "x", "if_primary_term": "1"})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		code, resp := write(CreateUpdate, map[string]interface{}{"name": "user"}, map[string]string{"if_seq_no": seqNo + "0", "if_primary_term": "1"})
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Contains(t, resp["error"], "version_conflict_engine_exception")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		code, resp := write(Update, map[string]interface{}{"name": "admin"}, map[string]string{"if_seq_no": seqNo, "if_primary_term": "1"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "updated", resp["result"])
+		code, _ = write(Delete, nil, map[string]string{"if_seq_no": seqNo, "if_primary_term": "1"})
+		assert.Equal(t, http.StatusConflict, code)
+		code, _ = write(Delete, nil, map[string]string{"if_seq_no": strconv.Itoa(int(resp["_seq_no"].(float64))), "if_primary_term": "1"})
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreateUpdateVersion(t *testing.T) {
+	indexName := "TestDocumentCreateUpdateVersion.index_1"
+	tests := []struct {
+		name   string
+		query  map[string]string
+		code   int
+		result string
+	}{
+		{"external", map[string]string{"version": "5", "version_type": "external"}, http.StatusOK, `"_version":5`},
+		{"external lower", map[string]string{"version": "5", "version_type": "external"}, http.StatusConflict, "version_conflict_engine_exception"},
+		{"external_gte", map[string]string{"version": "5", "version_type": "external_gte"}, http.StatusOK, `"_version":5`},
+		{"internal", nil, http.StatusOK, `"_version":6`},
+		{"version without type", map[string]string{"version": "7"}, http.StatusBadRequest, "internal versioning"},
+		{"missing version", map[string]string{"version_type": "external"}, http.StatusBadRequest, "[version] is required"},
+		{"unknown type", map[string]string{"version": "7", "version_type": "force"}, http.StatusBadRequest, "unknown version type"},
+		{"with if_seq_no", map[string]string{"version": "7", "version_type": "external", "if_seq_no": "1", "if_primary_term": "1"}, http.StatusBadRequest, "compare and write"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, map[string]interface{}{"name": "user"})
+			utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", tt.query)
+			utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+			CreateUpdate(c)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.result)
+		})
+	}
+
+	t.Run("update API", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{"name": "user"})
+		utils.SetGinRequestURL(c, "/api/"+indexName+"/_update/1", map[string]string{"version": "9", "version_type": "external"})
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+		Update(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not supported by the update API")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreateUpdateRouting(t *testing.T) {
+	indexName := "TestDocumentCreateUpdateRouting.index_1"
+	t.Run("create", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{"name": "user"})
+		utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", map[string]string{"routing": "user_a"})
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+		CreateUpdate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"_routing":"user_a"`)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		index, _ := core.GetIndex(indexName)
+		assert.NoError(t, index.Flush())
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", map[string]string{"routing": "user_a"})
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+		Get(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"_routing":"user_a"`)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreateUpdateRefresh(t *testing.T) {
+	indexName := "TestDocumentCreateUpdateRefresh.index_1"
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		query   map[string]string
+		code    int
+		result  string
+	}{
+		{"default", CreateUpdate, nil, http.StatusOK, `"result":"created"`},
+		{"true", CreateUpdate, map[string]string{"refresh": "true"}, http.StatusOK, `"forced_refresh":true`},
+		{"empty", CreateUpdate, map[string]string{"refresh": ""}, http.StatusOK, `"forced_refresh":true`},
+		{"wait_for", CreateUpdate, map[string]string{"refresh": "wait_for"}, http.StatusOK, `"result":"updated"`},
+		{"invalid", CreateUpdate, map[string]string{"refresh": "now"}, http.StatusBadRequest, "unknown value for refresh"},
+		{"update", Update, map[string]string{"refresh": "true"}, http.StatusOK, `"forced_refresh":true`},
+		{"delete", Delete, map[string]string{"refresh": "wait_for"}, http.StatusOK, `"message":"deleted"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, map[string]interface{}{"name": tt.name})
+			utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", tt.query)
+			utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+			tt.handler(c)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
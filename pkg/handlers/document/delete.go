@@ -21,6 +21,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 )
 
@@ -31,8 +32,15 @@ import (
 // @Produce json
 // @Param   index  path  string  true  "Index"
 // @Param   id     path  string  true  "ID"
+// @Param   if_seq_no        query int     false  "Only delete if the document has this sequence number"
+// @Param   if_primary_term  query int     false  "Only delete if the document has this primary term"
+// @Param   version          query int     false  "Version of the document for the external version types"
+// @Param   version_type     query string  false  "Version type: internal, external or external_gte"
+// @Param   routing          query string  false  "Routing of the document"
+// @Param   refresh          query string  false  "true flushes the index after the write, wait_for waits for the next WAL consuming"
 // @Success 200 {object} meta.HTTPResponseDocument
 // @Failure 400 {object} meta.HTTPResponseError
+// @Failure 409 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
 // @Router /api/{index}/_doc/{id} [delete]
 func Delete(c *gin.Context) {
@@ -42,17 +50,46 @@ func Delete(c *gin.Context) {
 		return
 	}
 
+	cond, err := writeCondition(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
 	indexName := c.Param("target")
 	index, exists := core.GetIndex(indexName)
 	if !exists {
 		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "index does not exists"})
 		return
 	}
+	if err = index.CheckDataStreamWrite(nil, false); err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
-	err := index.DeleteDocument(docID)
+	ret, err := index.DeleteDocumentIf(docID, cond)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		c.JSON(errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	forced, err := index.Refresh(c.Request.Context(), refresh)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, meta.HTTPResponseDocument{Message: "deleted", Index: indexName, ID: docID})
+	c.JSON(http.StatusOK, meta.HTTPResponseDocument{
+		Message:       "deleted",
+		Index:         indexName,
+		ID:            docID,
+		Version:       ret.Version,
+		SeqNo:         ret.SeqNo,
+		PrimaryTerm:   core.PrimaryTerm,
+		Routing:       c.Query("routing"),
+		ForcedRefresh: forced,
+	})
 }
@@ -17,11 +17,18 @@ package document
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/fields"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
@@ -33,6 +40,11 @@ import (
 // @Produce json
 // @Param   index  path  string  true  "Index"
 // @Param   id     path  string  true  "ID"
+// @Param   routing  query  string  false  "Routing of the document"
+// @Param   _source           query  string  false  "false omits the source, or the fields of the source to return"
+// @Param   _source_includes  query  string  false  "Comma separated fields of the source to return, supports wildcards"
+// @Param   _source_excludes  query  string  false  "Comma separated fields to remove from the source, supports wildcards"
+// @Param   stored_fields     query  string  false  "Comma separated stored fields to return, the source isn't returned with them unless requested"
 // @Success 200 {object} meta.Hit
 // @Failure 400 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
@@ -53,10 +65,83 @@ func Get(c *gin.Context) {
 		return
 	}
 
-	source, err := index.GetDocument(docID)
+	storedFields, err := fields.StoredRequest(c.Query("stored_fields"))
 	if err != nil {
 		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
-	zutils.GinRenderJSON(c, http.StatusOK, source)
+	routing := c.Query("routing")
+	doc, err := index.GetDocumentByRouting(docID, routing, storedFields...)
+	if err == nil {
+		err = checkDocumentVisible(index, docID, routing, auth.GetContextPrivileges(c))
+	}
+	if err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	security.Hit(doc, security.FieldRules(auth.GetContextPrivileges(c), index.GetName()))
+	if data, ok := doc.Source.(map[string]interface{}); ok {
+		if data = source.Filter(sourceParams(c), data); data != nil {
+			doc.Source = data
+		} else {
+			doc.Source = nil
+		}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, doc)
+}
+
+// sourceParams returns the source fields of the _source, _source_includes and _source_excludes parameters,
+// _source is true, false or the fields to return and _source_includes overtakes its fields.
+// The source isn't returned with the stored_fields unless it is requested.
+func sourceParams(c *gin.Context) *meta.Source {
+	_, storedFields := c.GetQuery("stored_fields")
+	ret := &meta.Source{Enable: !storedFields}
+	if v, ok := c.GetQuery("_source"); ok && v != "" {
+		if enable, err := strconv.ParseBool(v); err == nil {
+			ret.Enable = enable
+		} else {
+			ret.Enable = true
+			ret.Fields = splitFields(v)
+		}
+	}
+	if v := c.Query("_source_includes"); v != "" {
+		ret.Enable = true
+		ret.Fields = splitFields(v)
+	}
+	if v := c.Query("_source_excludes"); v != "" {
+		ret.Enable = ret.Enable || storedFields
+		ret.Excludes = splitFields(v)
+	}
+	return ret
+}
+
+// splitFields splits the comma separated fields
+func splitFields(v string) []string {
+	ret := make([]string, 0)
+	for _, field := range strings.Split(v, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			ret = append(ret, field)
+		}
+	}
+	return ret
+}
+
+// checkDocumentVisible returns not found if the document doesn't match the document level security of the user
+func checkDocumentVisible(index *core.Index, docID, routing string, privileges []*meta.RoleIndices) error {
+	if security.DocumentFilter(privileges, index.GetName()) == nil {
+		return nil
+	}
+	resp, err := index.Search(&meta.ZincQuery{
+		Query:      map[string]interface{}{"ids": map[string]interface{}{"values": []interface{}{docID}}},
+		Size:       1,
+		Privileges: privileges,
+		Routing:    core.SplitRouting(routing),
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return errors.ErrorIDNotFound
+	}
+	return nil
 }
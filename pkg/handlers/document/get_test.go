@@ -23,6 +23,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 	"github.com/zincsearch/zincsearch/test/utils"
 )
 
@@ -119,3 +121,103 @@ func TestGet(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestGetSource(t *testing.T) {
+	indexName := "TestDocumentGetSource.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{
+			"name":    "user",
+			"role":    "create",
+			"address": map[string]interface{}{"city": "paris", "zip": "75001"},
+		})
+		utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", map[string]string{"refresh": "true"})
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+		CreateUpdate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	tests := []struct {
+		name  string
+		query map[string]string
+		want  map[string]interface{}
+	}{
+		{"all", nil, map[string]interface{}{"name": "user", "role": "create", "address": map[string]interface{}{"city": "paris", "zip": "75001"}}},
+		{"disabled", map[string]string{"_source": "false"}, nil},
+		{"fields", map[string]string{"_source": "name,address.city"}, map[string]interface{}{"name": "user", "address": map[string]interface{}{"city": "paris"}}},
+		{"includes", map[string]string{"_source_includes": "address.*"}, map[string]interface{}{"address": map[string]interface{}{"city": "paris", "zip": "75001"}}},
+		{"excludes", map[string]string{"_source_excludes": "r*,address.zip"}, map[string]interface{}{"name": "user", "address": map[string]interface{}{"city": "paris"}}},
+		{"includes and excludes", map[string]string{"_source_includes": "address", "_source_excludes": "*.zip"}, map[string]interface{}{"address": map[string]interface{}{"city": "paris"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", tt.query)
+			utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+			Get(c)
+			assert.Equal(t, http.StatusOK, w.Code)
+			resp := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "1", resp["_id"])
+			if tt.want == nil {
+				assert.NotContains(t, resp, "_source")
+				return
+			}
+			assert.Equal(t, tt.want, resp["_source"])
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetStoredFields(t *testing.T) {
+	indexName := "TestDocumentGetStoredFields.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		name := meta.NewProperty("keyword")
+		name.Store = true
+		mappings.SetProperty("name", name)
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, core.StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "user", "role": "create"}, false))
+		assert.NoError(t, index.Flush())
+	})
+
+	tests := []struct {
+		name   string
+		query  map[string]string
+		fields interface{}
+		source bool
+	}{
+		{"stored fields", map[string]string{"stored_fields": "name,role"}, map[string]interface{}{"name": []interface{}{"user"}}, false},
+		{"with source", map[string]string{"stored_fields": "name", "_source": "true"}, map[string]interface{}{"name": []interface{}{"user"}}, true},
+		{"none", map[string]string{"stored_fields": "_none_"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestURL(c, "/api/"+indexName+"/_doc/1", tt.query)
+			utils.SetGinRequestParams(c, map[string]string{"target": indexName, "id": "1"})
+			Get(c)
+			assert.Equal(t, http.StatusOK, w.Code)
+			resp := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.fields, resp["fields"])
+			if tt.source {
+				assert.Contains(t, resp, "_source")
+			} else {
+				assert.NotContains(t, resp, "_source")
+			}
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,219 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package document
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/ingest"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id PutPipeline
+// @Summary Create or update an ingest pipeline
+// @security BasicAuth
+// @Tags    Document
+// @Accept  json
+// @Produce json
+// @Param   id    path  string                true  "Pipeline ID"
+// @Param   data  body  meta.PipelineRequest  true  "Pipeline"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_ingest/pipeline/{id} [put]
+func PutPipeline(c *gin.Context) {
+	req := new(meta.PipelineRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := core.PutPipeline(c.Param("id"), req); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id GetPipeline
+// @Summary Get the ingest pipelines
+// @security BasicAuth
+// @Tags    Document
+// @Produce json
+// @Param   id  path  string  false  "Pipeline ID"
+// @Success 200 {object} map[string]meta.PipelineRequest
+// @Failure 404 {object} map[string]interface{}
+// @Router /es/_ingest/pipeline/{id} [get]
+func GetPipeline(c *gin.Context) {
+	id := c.Param("id")
+	resp := make(map[string]meta.PipelineRequest)
+	if id == "" {
+		pipelines, err := core.ListPipelines()
+		if err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+		for _, pipeline := range pipelines {
+			resp[pipeline.ID] = meta.PipelineRequest{Description: pipeline.Description, Processors: pipeline.Processors}
+		}
+		zutils.GinRenderJSON(c, http.StatusOK, resp)
+		return
+	}
+	pipeline, ok, err := core.GetPipeline(id)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, resp)
+		return
+	}
+	resp[id] = meta.PipelineRequest{Description: pipeline.Description, Processors: pipeline.Processors}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// @Id DeletePipeline
+// @Summary Delete an ingest pipeline
+// @security BasicAuth
+// @Tags    Document
+// @Produce json
+// @Param   id  path  string  true  "Pipeline ID"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_ingest/pipeline/{id} [delete]
+func DeletePipeline(c *gin.Context) {
+	id := c.Param("id")
+	ok, err := core.DeletePipeline(id)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "pipeline " + id + " does not exists"})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// SimulatePipeline runs the sample documents through the stored pipeline or the pipeline of the request,
+// the documents are not indexed
+//
+// @Id SimulatePipeline
+// @Summary Simulate an ingest pipeline
+// @security BasicAuth
+// @Tags    Document
+// @Accept  json
+// @Produce json
+// @Param   id    path  string                        false  "Pipeline ID"
+// @Param   data  body  meta.PipelineSimulateRequest  true   "Simulate"
+// @Success 200 {object} meta.PipelineSimulateResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_ingest/pipeline/{id}/_simulate [post]
+func SimulatePipeline(c *gin.Context) {
+	req := new(meta.PipelineSimulateRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	var pipeline *ingest.Pipeline
+	var err error
+	if id := c.Param("id"); id != "" {
+		pipeline, err = core.LoadPipeline(id)
+	} else if req.Pipeline != nil {
+		pipeline, err = ingest.New("_simulate_pipeline", req.Pipeline.Processors)
+	} else {
+		err = errors.New(errors.ErrorTypeParsingException, "[simulate] [pipeline] required property is missing")
+	}
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	resp := meta.PipelineSimulateResponse{Docs: make([]meta.PipelineSimulateResult, 0, len(req.Docs))}
+	for i := range req.Docs {
+		doc := req.Docs[i]
+		if doc.Source == nil {
+			doc.Source = make(map[string]interface{})
+		}
+		if err := pipeline.Run(doc.Source); err != nil {
+			resp.Docs = append(resp.Docs, meta.PipelineSimulateResult{Error: err})
+			continue
+		}
+		resp.Docs = append(resp.Docs, meta.PipelineSimulateResult{Doc: &doc})
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// @Id GetGrokPatterns
+// @Summary Get the bundled and the custom grok patterns
+// @security BasicAuth
+// @Tags    Document
+// @Produce json
+// @Success 200 {object} meta.GrokPatternsResponse
+// @Router /es/_ingest/processor/grok [get]
+func GetGrokPatterns(c *gin.Context) {
+	zutils.GinRenderJSON(c, http.StatusOK, meta.GrokPatternsResponse{Patterns: ingest.GrokPatterns()})
+}
+
+// @Id PutGrokPattern
+// @Summary Create or update a custom grok pattern
+// @security BasicAuth
+// @Tags    Document
+// @Accept  json
+// @Produce json
+// @Param   name  path  string                   true  "Pattern name"
+// @Param   data  body  meta.GrokPatternRequest  true  "Pattern"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_ingest/processor/grok/{name} [put]
+func PutGrokPattern(c *gin.Context) {
+	req := new(meta.GrokPatternRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := core.PutGrokPattern(c.Param("name"), req.Pattern); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id DeleteGrokPattern
+// @Summary Delete a custom grok pattern
+// @security BasicAuth
+// @Tags    Document
+// @Produce json
+// @Param   name  path  string  true  "Pattern name"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_ingest/processor/grok/{name} [delete]
+func DeleteGrokPattern(c *gin.Context) {
+	name := c.Param("name")
+	ok, err := core.DeleteGrokPattern(name)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "grok pattern " + name + " does not exists"})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
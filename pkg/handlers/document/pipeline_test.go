@@ -0,0 +1,283 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package document
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestPipeline(t *testing.T) {
+	indexName := "TestPipeline.index_1"
+	type args struct {
+		code   int
+		params map[string]string
+		query  map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+	}{
+		{
+			name:    "put pipeline",
+			handler: PutPipeline,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_pipeline"},
+				data:   `{"description":"parse","processors":[{"grok":{"field":"message","patterns":["%{WORD:level} %{GREEDYDATA:text}"]}},{"lowercase":{"field":"level"}},{"remove":{"field":"message"}}]}`,
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "put invalid pipeline",
+			handler: PutPipeline,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"id": "my_invalid"},
+				data:   `{"processors":[{"set":{"value":1}}]}`,
+				result: "[set] [field] required property is missing",
+			},
+		},
+		{
+			name:    "get pipeline",
+			handler: GetPipeline,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_pipeline"},
+				result: `{"my_pipeline":{"description":"parse","processors":[`,
+			},
+		},
+		{
+			name:    "get not exists pipeline",
+			handler: GetPipeline,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"id": "my_invalid"},
+				result: `{}`,
+			},
+		},
+		{
+			name:    "simulate stored pipeline",
+			handler: SimulatePipeline,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_pipeline"},
+				data:   `{"docs":[{"_source":{"message":"ERROR disk full"}},{"_source":{"message":""}}]}`,
+				result: `{"docs":[{"doc":{"_source":{"level":"error","text":"disk full"}}},{"error":{"type":"illegal_argument_exception"`,
+			},
+		},
+		{
+			name:    "simulate inline pipeline",
+			handler: SimulatePipeline,
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"pipeline":{"processors":[{"set":{"field":"a","value":"{{b}}"}}]},"docs":[{"_index":"x","_id":"1","_source":{"b":"c"}}]}`,
+				result: `{"docs":[{"doc":{"_index":"x","_id":"1","_source":{"a":"c","b":"c"}}}]}`,
+			},
+		},
+		{
+			name:    "simulate without pipeline",
+			handler: SimulatePipeline,
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"docs":[]}`,
+				result: "[pipeline] required property is missing",
+			},
+		},
+		{
+			name:    "create document with pipeline",
+			handler: CreateUpdate,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName, "id": "1"},
+				query:  map[string]string{"pipeline": "my_pipeline"},
+				data:   `{"message":"WARN low memory"}`,
+				result: `"_id":"1"`,
+			},
+		},
+		{
+			name:    "create document with not exists pipeline",
+			handler: CreateUpdate,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": indexName, "id": "2"},
+				query:  map[string]string{"pipeline": "my_invalid"},
+				data:   `{"message":"WARN low memory"}`,
+				result: "pipeline with id [my_invalid] does not exist",
+			},
+		},
+		{
+			name:    "bulk with pipeline",
+			handler: ESBulk,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"pipeline": "my_pipeline"},
+				data: `{"index":{"_id":"3"}}
+				{"message":"INFO started"}
+				{"index":{"_id":"4"}}
+				{"message":""}
+				{"index":{"_id":"5","pipeline":"_none"}}
+				{"message":"INFO raw"}`,
+				result: `"errors":true`,
+			},
+		},
+		{
+			name:    "delete pipeline",
+			handler: DeletePipeline,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_pipeline"},
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "delete not exists pipeline",
+			handler: DeletePipeline,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"id": "my_pipeline"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestURL(c, "/", tt.args.query)
+			utils.SetGinRequestData(c, tt.args.data)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("check documents", func(t *testing.T) {
+		index, exists := core.GetIndex(indexName)
+		assert.True(t, exists)
+		assert.NoError(t, index.Flush())
+		for id, want := range map[string]map[string]interface{}{
+			"1": {"level": "warn", "text": "low memory"},
+			"3": {"level": "info", "text": "started"},
+			"5": {"message": "INFO raw"},
+		} {
+			doc, err := index.GetDocument(id)
+			assert.NoError(t, err)
+			source := doc.Source.(map[string]interface{})
+			for k, v := range want {
+				assert.Equal(t, v, source[k])
+			}
+			assert.Equal(t, len(want), len(source))
+		}
+		_, err := index.GetDocument("4")
+		assert.Error(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
+
+func TestGrokPattern(t *testing.T) {
+	type args struct {
+		code   int
+		params map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+	}{
+		{
+			name:    "put pattern",
+			handler: PutGrokPattern,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"name": "TestGrokPattern_STATUS"},
+				data:   `{"pattern":"(?:UP|DOWN)"}`,
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "put bundled pattern",
+			handler: PutGrokPattern,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"name": "WORD"},
+				data:   `{"pattern":"\\w+"}`,
+				result: "bundled pattern",
+			},
+		},
+		{
+			name:    "get patterns",
+			handler: GetGrokPatterns,
+			args: args{
+				code:   http.StatusOK,
+				result: `"TestGrokPattern_STATUS":"(?:UP|DOWN)"`,
+			},
+		},
+		{
+			name:    "simulate with pattern",
+			handler: SimulatePipeline,
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"pipeline":{"processors":[{"grok":{"field":"m","patterns":["%{TestGrokPattern_STATUS:status}"],"tag_on_failure":true}}]},"docs":[{"_source":{"m":"UP"}},{"_source":{"m":"?"}}]}`,
+				result: `{"docs":[{"doc":{"_source":{"m":"UP","status":"UP"}}},{"doc":{"_source":{"m":"?","tags":["_grokparsefailure"]}}}]}`,
+			},
+		},
+		{
+			name:    "delete pattern",
+			handler: DeleteGrokPattern,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"name": "TestGrokPattern_STATUS"},
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "delete not exists pattern",
+			handler: DeleteGrokPattern,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"name": "TestGrokPattern_STATUS"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestData(c, tt.args.data)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+}
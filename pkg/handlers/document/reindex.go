@@ -0,0 +1,99 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package document
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// Reindex copies documents from one index to another
+//
+// @Id Reindex
+// @Summary Reindex documents from source index to dest index
+// @security BasicAuth
+// @Tags    Document
+// @Accept  json
+// @Produce json
+// @Param   slices    query  string  false  "Number of slices or auto"
+// @Param   conflicts query  string  false  "What to do on version conflicts: abort or proceed"
+// @Param   wait_for_completion  query  bool  false  "Wait for the reindex to complete, default is true"
+// @Param   request   body   meta.ReindexRequest  true  "Reindex request"
+// @Success 200 {object} meta.ReindexResponse
+// @Failure 409 {object} meta.ReindexResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_reindex [post]
+func Reindex(c *gin.Context) {
+	req := new(meta.ReindexRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if slices := c.Query("slices"); slices != "" {
+		req.Slices = slices
+	}
+	if conflicts := c.Query("conflicts"); conflicts != "" {
+		req.Conflicts = conflicts
+	}
+	wait := true
+	if v, ok := c.GetQuery("wait_for_completion"); ok && v != "" {
+		var err error
+		if wait, err = strconv.ParseBool(v); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "failed to parse [wait_for_completion] with value [" + v + "]"})
+			return
+		}
+	}
+
+	r, err := core.NewReindexer(req)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	if !wait {
+		owner := ""
+		if user, ok := auth.GetContextUser(c); ok {
+			owner = user.ID
+		}
+		task := core.ZINC_TASKS.Submit(owner, core.TaskActionReindex, func() interface{} {
+			return r.Status()
+		}, func() (interface{}, error) {
+			resp, err := r.Run()
+			if resp != nil {
+				// an aborted reindex keeps its failures in the response of the task
+				return resp, nil
+			}
+			return nil, err
+		})
+		zutils.GinRenderJSON(c, http.StatusOK, meta.TaskSubmitResponse{Task: task.ID})
+		return
+	}
+
+	resp, err := r.Run()
+	if resp == nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusOK), resp)
+}
@@ -0,0 +1,204 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package document
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestReindex(t *testing.T) {
+	type args struct {
+		code   int
+		query  map[string]string
+		data   interface{}
+		wait   bool // wait for the documents reindexed before written into dest
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "normal",
+			args: args{
+				code: http.StatusOK,
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: `"created":10`,
+			},
+		},
+		{
+			name: "slices",
+			args: args{
+				code:  http.StatusOK,
+				query: map[string]string{"slices": "2"},
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: `"slice_id":1`,
+			},
+		},
+		{
+			name: "invalid slices",
+			args: args{
+				code:  http.StatusBadRequest,
+				query: map[string]string{"slices": "x"},
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: `slices`,
+			},
+		},
+		{
+			name: "with query",
+			args: args{
+				code: http.StatusOK,
+				data: map[string]interface{}{
+					"source": map[string]interface{}{
+						"index": "TestDocumentReindex.index_1",
+						"query": map[string]interface{}{"ids": map[string]interface{}{"values": []string{"1", "2"}}},
+					},
+					"dest": map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: `"total":2`,
+			},
+		},
+		{
+			name: "op_type create with conflicts proceed",
+			args: args{
+				code:  http.StatusOK,
+				query: map[string]string{"conflicts": "proceed"},
+				wait:  true,
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2", "op_type": "create"},
+				},
+				result: `"version_conflicts":10`,
+			},
+		},
+		{
+			name: "op_type create with conflicts abort",
+			args: args{
+				code: http.StatusConflict,
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2", "op_type": "create"},
+				},
+				result: `"version_conflicts":1,"batches":1,"failures":["`,
+			},
+		},
+		{
+			name: "invalid conflicts",
+			args: args{
+				code:  http.StatusBadRequest,
+				query: map[string]string{"conflicts": "x"},
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_1"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: `conflicts`,
+			},
+		},
+		{
+			name: "not exists index",
+			args: args{
+				code: http.StatusBadRequest,
+				data: map[string]interface{}{
+					"source": map[string]interface{}{"index": "TestDocumentReindex.index_3"},
+					"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+				},
+				result: "does not exists",
+			},
+		},
+		{
+			name: "error request",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   "xxx",
+				result: `"error":`,
+			},
+		},
+	}
+
+	indexName := "TestDocumentReindex.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+		for i := 0; i < 10; i++ {
+			err = index.CreateDocument(strconv.Itoa(i), map[string]interface{}{"name": "user"}, false)
+			assert.NoError(t, err)
+		}
+
+		// wait for WAL write to index
+		time.Sleep(time.Second * 2)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.wait {
+				time.Sleep(time.Second * 2)
+			}
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.args.data)
+			if tt.args.query != nil {
+				utils.SetGinRequestURL(c, "/es/_reindex", tt.args.query)
+			}
+			Reindex(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("wait_for_completion false", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{
+			"source": map[string]interface{}{"index": indexName},
+			"dest":   map[string]interface{}{"index": "TestDocumentReindex.index_2"},
+		})
+		utils.SetGinRequestURL(c, "/es/_reindex", map[string]string{"wait_for_completion": "false"})
+		Reindex(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := new(meta.TaskSubmitResponse)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		task, ok := core.ZINC_TASKS.Get(resp.Task)
+		assert.True(t, ok)
+		assert.Equal(t, core.TaskActionReindex, task.Action)
+		assert.True(t, task.Wait(time.Second*10))
+		result := task.Response()
+		assert.Empty(t, result.Error)
+		assert.Equal(t, int64(10), result.Response.(*meta.ReindexResponse).Total)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+		assert.NoError(t, core.DeleteIndex("TestDocumentReindex.index_2"))
+	})
+}
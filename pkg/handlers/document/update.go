@@ -16,13 +16,16 @@
 package document
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 // @Id UpdateDocument
@@ -33,9 +36,16 @@ import (
 // @Produce json
 // @Param   index  path  string  true  "Index"
 // @Param   id     path  string  true  "ID"
-// @Param   document  body  map[string]interface{}  true  "Document"
-// @Success 200 {object} meta.HTTPResponseID
+// @Param   document  body  map[string]interface{}  true  "Document, or an update request with doc or script and upsert"
+// @Param   if_seq_no          query int     false  "Only update if the document has this sequence number"
+// @Param   if_primary_term    query int     false  "Only update if the document has this primary term"
+// @Param   retry_on_conflict  query int     false  "Times to retry the update when the document changes meanwhile"
+// @Param   routing            query string  false  "Routing of the document"
+// @Param   refresh            query string  false  "true flushes the index after the write, wait_for waits for the next WAL consuming"
+// @Param   detect_noop        query bool    false  "Skip the write when the document doesn't change, default is true"
+// @Success 200 {object} meta.HTTPResponseESID
 // @Failure 400 {object} meta.HTTPResponseError
+// @Failure 409 {object} meta.HTTPResponseError
 // @Failure 500 {object} meta.HTTPResponseError
 // @Router /api/{index}/_update/{id} [post]
 func Update(c *gin.Context) {
@@ -44,7 +54,20 @@ func Update(c *gin.Context) {
 	insert := c.Query("insert") // true or false
 	insertBool, _ := zutils.ToBool(insert)
 
-	var err error
+	cond, err := writeCondition(c)
+	if err == nil && cond != nil && cond.VersionType != "" {
+		err = errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("version type [%s] is not supported by the update API", cond.VersionType))
+	}
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	refresh, err := refreshPolicy(c)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
 	var doc map[string]interface{}
 	if err = zutils.GinBindJSON(c, &doc); err != nil {
 		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
@@ -60,17 +83,75 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	// the body is an update request with a partial document or a script, otherwise it replaces the document
+	var req *meta.UpdateRequest
+	_, hasDoc := doc["doc"]
+	_, hasScript := doc["script"]
+	if hasDoc || hasScript {
+		req = new(meta.UpdateRequest)
+		data, _ := json.Marshal(doc)
+		if err = json.Unmarshal(data, req); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+	}
+	detectNoop := true
+	if v := c.Query("detect_noop"); v != "" {
+		detectNoop, _ = zutils.ToBool(v)
+	}
+	retries := 0
+	if v := c.Query("retry_on_conflict"); v != "" {
+		if retries, err = zutils.ToInt(v); err != nil || retries < 0 {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "[retry_on_conflict] should be a non-negative number"})
+			return
+		}
+	}
+
 	// If the index does not exist, then create it
 	index, _, err := core.GetOrCreateIndex(indexName, "", 0)
 	if err != nil {
 		zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
+	if err = index.CheckDataStreamWrite(doc, false); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
-	err = index.UpdateDocument(docID, doc, insertBool)
+	var ret core.WriteResult
+	var result string
+	if req != nil {
+		ret, result, err = index.UpdateDocumentRequest(docID, req, cond, retries)
+	} else {
+		ret, result, err = index.ReplaceDocument(docID, doc, insertBool, cond, detectNoop)
+	}
 	if err != nil {
-		zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+		code := http.StatusInternalServerError
+		var e *errors.Error
+		if errors.As(err, &e) && (e.Type == errors.ErrorTypeParsingException || e.Type == errors.ErrorTypeIllegalArgumentException || e.Type == errors.ErrorTypeSourceMissingException) {
+			code = http.StatusBadRequest
+		}
+		zutils.GinRenderJSON(c, errors.StatusCode(err, code), meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
-	zutils.GinRenderJSON(c, http.StatusOK, meta.HTTPResponseID{Message: "ok", ID: docID})
+	// a noop wrote nothing to refresh
+	forced := false
+	if result != core.UpdateResultNoop {
+		if forced, err = index.Refresh(c.Request.Context(), refresh); err != nil {
+			zutils.GinRenderJSON(c, http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.HTTPResponseESID{
+		Message:       "ok",
+		ID:            docID,
+		ESID:          docID,
+		Index:         indexName,
+		Version:       ret.Version,
+		SeqNo:         ret.SeqNo,
+		PrimaryTerm:   core.PrimaryTerm,
+		Routing:       c.Query("routing"),
+		Result:        result,
+		ForcedRefresh: forced,
+	})
 }
@@ -54,6 +54,94 @@ func TestUpdate(t *testing.T) {
 				result: `"id":"1"`,
 			},
 		},
+		{
+			name: "replace noop",
+			args: args{
+				code: http.StatusOK,
+				data: map[string]interface{}{
+					"_id":  "1",
+					"name": "userUpdate",
+					"role": "create",
+				},
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "1",
+				},
+				result: `"result":"noop"`,
+			},
+		},
+		{
+			name: "partial doc",
+			args: args{
+				code:    http.StatusOK,
+				rawData: `{"doc":{"role":"update"}}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "1",
+				},
+				result: `"result":"updated"`,
+			},
+		},
+		{
+			name: "noop",
+			args: args{
+				code:    http.StatusOK,
+				rawData: `{"doc":{"role":"update"}}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "1",
+				},
+				result: `"result":"noop"`,
+			},
+		},
+		{
+			name: "missing doc",
+			args: args{
+				code:    http.StatusNotFound,
+				rawData: `{"doc":{"role":"update"}}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "2",
+				},
+				result: `document missing`,
+			},
+		},
+		{
+			name: "doc as upsert",
+			args: args{
+				code:    http.StatusOK,
+				rawData: `{"doc":{"role":"update"},"doc_as_upsert":true}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "2",
+				},
+				result: `"result":"created"`,
+			},
+		},
+		{
+			name: "script with upsert",
+			args: args{
+				code:    http.StatusOK,
+				rawData: `{"script":{"source":"ctx._source.count += params.n","params":{"n":1}},"upsert":{"count":1}}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "3",
+				},
+				result: `"result":"created"`,
+			},
+		},
+		{
+			name: "err script",
+			args: args{
+				code:    http.StatusBadRequest,
+				rawData: `{"script":"ctx._source.count +="}`,
+				params: map[string]string{
+					"target": "TestDocumentUpdate.index_1",
+					"id":     "3",
+				},
+				result: `"error":`,
+			},
+		},
 		{
 			name: "err json",
 			args: args{
@@ -16,6 +16,7 @@
 package index
 
 import (
+	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
@@ -38,10 +40,31 @@ type Action struct {
 }
 
 type base struct {
-	Index   string   `json:"index"`
-	Alias   string   `json:"alias"`
-	Indices []string `json:"indices"`
-	Aliases []string `json:"aliases"`
+	Index         string                 `json:"index"`
+	Alias         string                 `json:"alias"`
+	Indices       []string               `json:"indices"`
+	Aliases       []string               `json:"aliases"`
+	IsWriteIndex  *bool                  `json:"is_write_index"`
+	Filter        map[string]interface{} `json:"filter"`
+	Routing       string                 `json:"routing"`
+	IndexRouting  string                 `json:"index_routing"`
+	SearchRouting string                 `json:"search_routing"`
+}
+
+// options returns the filter and the routing of the add action, routing is the default of
+// index_routing and search_routing, nil means the alias has neither
+func (b *base) options() *meta.AliasOptions {
+	opts := &meta.AliasOptions{Filter: b.Filter, IndexRouting: b.IndexRouting, SearchRouting: b.SearchRouting}
+	if opts.IndexRouting == "" {
+		opts.IndexRouting = b.Routing
+	}
+	if opts.SearchRouting == "" {
+		opts.SearchRouting = b.Routing
+	}
+	if opts.Filter == nil && opts.IndexRouting == "" && opts.SearchRouting == "" {
+		return nil
+	}
+	return opts
 }
 
 // @Id AddOrRemoveESAlias
@@ -62,19 +85,45 @@ func AddOrRemoveESAlias(c *gin.Context) {
 
 	addMap := map[string][]string{}
 	removeMap := map[string][]string{}
+	// the indexes set or unset as the write index of the alias
+	writeMap := map[string][]string{}
+	unwriteMap := map[string][]string{}
+	// the filter and the routing of the added indexes, adding an index again replaces them
+	optionsMap := map[string]map[string]*meta.AliasOptions{}
 
 	indexList := core.ZINC_INDEX_LIST.List()
 
 	for _, action := range alias.Actions {
 		if action.Add != nil {
+			var wm map[string][]string
+			if action.Add.IsWriteIndex != nil {
+				wm = unwriteMap
+				if *action.Add.IsWriteIndex {
+					wm = writeMap
+				}
+			}
+			added := map[string][]string{}
 			if action.Add.Index != "" {
-				matchAndAddToMap(indexList, action.Add.Index, addMap, action.Add)
-				continue
+				matchAndAddToMap(indexList, action.Add.Index, added, action.Add)
+			} else {
+				// index is empty, try the indices field
+				for _, indexName := range action.Add.Indices {
+					matchAndAddToMap(indexList, indexName, added, action.Add)
+				}
 			}
 
-			// index is empty, try the indices field
-			for _, indexName := range action.Add.Indices {
-				matchAndAddToMap(indexList, indexName, addMap, action.Add)
+			opts := action.Add.options()
+			for alias, indexes := range added {
+				addMap[alias] = append(addMap[alias], indexes...)
+				if wm != nil {
+					wm[alias] = append(wm[alias], indexes...)
+				}
+				if optionsMap[alias] == nil {
+					optionsMap[alias] = map[string]*meta.AliasOptions{}
+				}
+				for _, index := range indexes {
+					optionsMap[alias][index] = opts
+				}
 			}
 
 			continue // this was an add action, don't bother checking action.Remove
@@ -93,14 +142,68 @@ func AddOrRemoveESAlias(c *gin.Context) {
 		}
 	}
 
+	// an alias can have only one write index
+	for alias, names := range writeMap {
+		var indexes []string
+		for _, name := range names {
+			if !zutils.SliceExists(indexes, name) {
+				indexes = append(indexes, name)
+			}
+		}
+		if current, ok := core.ZINC_INDEX_ALIAS_LIST.GetWriteIndex(alias); ok && !zutils.SliceExists(indexes, current) &&
+			!zutils.SliceExists(unwriteMap[alias], current) && !zutils.SliceExists(removeMap[alias], current) {
+			indexes = append([]string{current}, indexes...)
+		}
+		if len(indexes) > 1 {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{
+				Error: fmt.Sprintf("alias [%s] has more than one write index [%s]", alias, strings.Join(indexes, ",")),
+			})
+			return
+		}
+		writeMap[alias] = indexes
+	}
+
+	// the filter should be a valid query of the index
+	for alias, options := range optionsMap {
+		for name, opts := range options {
+			if opts == nil || opts.Filter == nil {
+				continue
+			}
+			index, ok := core.GetIndex(name)
+			if !ok {
+				continue
+			}
+			if _, err := uquery.ParseQuery(&meta.ZincQuery{Query: opts.Filter}, index.GetMappings(), index.GetAnalyzers()); err != nil {
+				zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{
+					Error: fmt.Sprintf("invalid filter of alias [%s]: %s", alias, err.Error()),
+				})
+				return
+			}
+		}
+	}
+
 	for alias, indexes := range addMap {
 		_ = core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, indexes)
 	}
 
+	for alias, options := range optionsMap {
+		_ = core.ZINC_INDEX_ALIAS_LIST.SetOptions(alias, options)
+	}
+
 	for alias, indexes := range removeMap {
 		_ = core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, indexes)
 	}
 
+	for alias, indexes := range unwriteMap {
+		if current, ok := core.ZINC_INDEX_ALIAS_LIST.GetWriteIndex(alias); ok && zutils.SliceExists(indexes, current) {
+			_ = core.ZINC_INDEX_ALIAS_LIST.SetWriteIndex(alias, "")
+		}
+	}
+
+	for alias, indexes := range writeMap {
+		_ = core.ZINC_INDEX_ALIAS_LIST.SetWriteIndex(alias, indexes[0])
+	}
+
 	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
 }
 
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/metadata"
 	"github.com/zincsearch/zincsearch/test/utils"
 )
@@ -194,6 +195,119 @@ func TestAddOrRemoveESAlias(t *testing.T) {
 	}
 }
 
+func TestAddOrRemoveESAlias_WriteIndex(t *testing.T) {
+	_, closeFn1 := newIndex(t, "TestAddOrRemoveESAlias_WriteIndex.index_1")
+	defer closeFn1()
+	_, closeFn2 := newIndex(t, "TestAddOrRemoveESAlias_WriteIndex.index_2")
+	defer closeFn2()
+
+	tests := []struct {
+		name       string
+		data       string
+		wantCode   int
+		result     string
+		writeIndex string
+	}{
+		{
+			name:     "should_reject_more_than_one_write_index",
+			data:     `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.*","alias": "write_alias","is_write_index": true}}]}`,
+			wantCode: http.StatusBadRequest,
+			result:   "more than one write index",
+		},
+		{
+			name:       "should_set_write_index",
+			data:       `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.index_1","alias": "write_alias","is_write_index": true}},{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.index_2","alias": "write_alias"}}]}`,
+			wantCode:   http.StatusOK,
+			result:     `{"acknowledged":true}`,
+			writeIndex: "TestAddOrRemoveESAlias_WriteIndex.index_1",
+		},
+		{
+			name:       "should_reject_another_write_index",
+			data:       `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.index_2","alias": "write_alias","is_write_index": true}}]}`,
+			wantCode:   http.StatusBadRequest,
+			result:     "more than one write index",
+			writeIndex: "TestAddOrRemoveESAlias_WriteIndex.index_1",
+		},
+		{
+			name:       "should_swap_write_index",
+			data:       `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.index_1","alias": "write_alias","is_write_index": false}},{"add": {"index": "TestAddOrRemoveESAlias_WriteIndex.index_2","alias": "write_alias","is_write_index": true}}]}`,
+			wantCode:   http.StatusOK,
+			result:     `{"acknowledged":true}`,
+			writeIndex: "TestAddOrRemoveESAlias_WriteIndex.index_2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.data)
+			AddOrRemoveESAlias(c)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			require.Contains(t, w.Body.String(), tt.result)
+
+			writeIndex, _ := core.ZINC_INDEX_ALIAS_LIST.GetWriteIndex("write_alias")
+			require.Equal(t, tt.writeIndex, writeIndex)
+		})
+	}
+
+	indexes, ok := core.ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias("write_alias")
+	require.True(t, ok)
+	require.Len(t, indexes, 2)
+}
+
+func TestAddOrRemoveESAlias_Options(t *testing.T) {
+	indexName := "TestAddOrRemoveESAlias_Options.index_1"
+	_, closeFn := newIndex(t, indexName)
+	defer closeFn()
+
+	tests := []struct {
+		name     string
+		data     string
+		wantCode int
+		result   string
+		options  *meta.AliasOptions
+	}{
+		{
+			name:     "should_reject_invalid_filter",
+			data:     `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_Options.index_1","alias": "options_alias","filter": {"unknown": {}}}}]}`,
+			wantCode: http.StatusBadRequest,
+			result:   "invalid filter of alias [options_alias]",
+		},
+		{
+			name:     "should_add_filter_and_routing",
+			data:     `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_Options.index_1","alias": "options_alias","filter": {"term": {"tenant": "acme"}},"routing": "1","search_routing": "1,2"}}]}`,
+			wantCode: http.StatusOK,
+			result:   `{"acknowledged":true}`,
+			options: &meta.AliasOptions{
+				Filter:        map[string]interface{}{"term": map[string]interface{}{"tenant": "acme"}},
+				IndexRouting:  "1",
+				SearchRouting: "1,2",
+			},
+		},
+		{
+			name:     "should_replace_options",
+			data:     `{"actions": [{"add": {"index": "TestAddOrRemoveESAlias_Options.index_1","alias": "options_alias"}}]}`,
+			wantCode: http.StatusOK,
+			result:   `{"acknowledged":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.data)
+			AddOrRemoveESAlias(c)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			require.Contains(t, w.Body.String(), tt.result)
+
+			options, _ := core.ZINC_INDEX_ALIAS_LIST.GetOptions("options_alias", indexName)
+			require.Equal(t, tt.options, options)
+		})
+	}
+}
+
 func TestGetESAliases(t *testing.T) {
 	indexName := "TestAddOrRemoveESAlias.index_1"
 	type args struct {
@@ -282,6 +396,8 @@ func newIndex(t *testing.T, indexName string) (*core.Index, func()) {
 
 	return index, func() {
 		require.NoError(t, metadata.Alias.Set(map[string][]string{}))
+		require.NoError(t, metadata.Alias.SetWriteIndexes(map[string]string{}))
+		require.NoError(t, metadata.Alias.SetOptions(map[string]map[string]*meta.AliasOptions{}))
 		core.ZINC_INDEX_ALIAS_LIST = *core.NewAliasList()
 		require.NoError(t, core.DeleteIndex(indexName))
 	}
@@ -18,6 +18,8 @@ package index
 import (
 	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/gin-gonic/gin"
@@ -27,6 +29,7 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 // @Id Analyze
@@ -48,6 +51,8 @@ func Analyze(c *gin.Context) {
 
 	var err error
 	var ana *analysis.Analyzer
+	var components *analysisComponents
+	analyzerName, _ := query.Analyzer.(string)
 	indexName := c.Param("target")
 	if indexName != "" {
 		// use index analyzer
@@ -56,42 +61,56 @@ func Analyze(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "index " + indexName + " does not exists"})
 			return
 		}
-		if query.Filed != "" && query.Analyzer == "" {
+		if query.Filed != "" && query.Analyzer == nil {
 			mappings := index.GetMappings()
 			if mappings != nil && mappings.Len() > 0 {
 				if prop, ok := mappings.GetProperty(query.Filed); ok {
-					if query.Analyzer == "" && prop.SearchAnalyzer != "" {
-						query.Analyzer = prop.SearchAnalyzer
+					if analyzerName == "" && prop.SearchAnalyzer != "" {
+						analyzerName = prop.SearchAnalyzer
 					}
-					if query.Analyzer == "" && prop.Analyzer != "" {
-						query.Analyzer = prop.Analyzer
+					if analyzerName == "" && prop.Analyzer != "" {
+						analyzerName = prop.Analyzer
 					}
 				}
 			}
 		}
-		ana, _ = zincanalysis.QueryAnalyzer(index.GetAnalyzers(), query.Analyzer)
+		ana, _ = zincanalysis.QueryAnalyzer(index.GetAnalyzers(), analyzerName)
 		if ana == nil {
-			if query.Analyzer == "" {
+			if analyzerName == "" {
 				ana = new(analysis.Analyzer)
 			} else {
-				c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "analyzer " + query.Analyzer + " does not exists"})
+				c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "analyzer " + analyzerName + " does not exists"})
 				return
 			}
 		}
+		// the inline analysis can use the tokenizers and filters defined in the index
+		if components, err = newAnalysisComponents(index.GetSettings()); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
 	} else {
 		// none index specified
-		ana, _ = zincanalysis.QueryAnalyzer(nil, query.Analyzer)
+		ana, _ = zincanalysis.QueryAnalyzer(nil, analyzerName)
 		if ana == nil {
-			if query.Analyzer == "" {
+			if analyzerName == "" {
 				ana = new(analysis.Analyzer)
 			} else {
-				c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "analyzer " + query.Analyzer + " does not exists"})
+				c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "analyzer " + analyzerName + " does not exists"})
 				return
 			}
 		}
+		components = new(analysisComponents)
+	}
+
+	// inline analyzer definition
+	if def, ok := query.Analyzer.(map[string]interface{}); ok {
+		if ana, err = inlineAnalyzer(def, &query); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
 	}
 
-	charFilters, err := parseCharFilter(query.CharFilter)
+	charFilters, err := parseCharFilter(query.CharFilter, components.charFilters)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
@@ -101,28 +120,29 @@ func Analyze(c *gin.Context) {
 		query.TokenFilter = query.Filter
 		query.Filter = nil
 	}
-	tokenFilters, err := parseTokenFilter(query.TokenFilter)
+	tokenFilters, err := parseTokenFilter(query.TokenFilter, components.tokenFilters)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
 	}
 
-	tokenizers, err := parseTokenizer(query.Tokenizer)
+	tokenizers, err := parseTokenizer(query.Tokenizer, components.tokenizers)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
 	}
 
-	if len(charFilters) > 0 {
-		ana.CharFilters = append(ana.CharFilters, charFilters...)
-	}
-
-	if len(tokenFilters) > 0 {
-		ana.TokenFilters = append(ana.TokenFilters, tokenFilters...)
-	}
-
-	if len(tokenizers) > 0 {
-		ana.Tokenizer = tokenizers[0]
+	// the analyzers are shared, so a throwaway analyzer is built for the inline components
+	inline := len(charFilters) > 0 || len(tokenFilters) > 0 || len(tokenizers) > 0
+	if inline {
+		ana = &analysis.Analyzer{
+			CharFilters:  append(append([]analysis.CharFilter{}, ana.CharFilters...), charFilters...),
+			Tokenizer:    ana.Tokenizer,
+			TokenFilters: append(append([]analysis.TokenFilter{}, ana.TokenFilters...), tokenFilters...),
+		}
+		if len(tokenizers) > 0 {
+			ana.Tokenizer = tokenizers[0]
+		}
 	}
 
 	if ana.Tokenizer == nil {
@@ -130,12 +150,16 @@ func Analyze(c *gin.Context) {
 		return
 	}
 
-	tokens := ana.Analyze([]byte(query.Text))
 	ret := AnalyzeResponse{}
-	ret.Tokens = make([]AnalyzeResponseToken, 0, len(tokens))
-	for _, token := range tokens {
-		ret.Tokens = append(ret.Tokens, formatToken(token))
+	if query.Explain {
+		custom := analyzerName == "" || inline
+		ret.Detail = explainAnalyzer(ana, []byte(query.Text), custom)
+		ret.Tokens = ret.Detail.lastTokens()
+		c.JSON(http.StatusOK, ret)
+		return
 	}
+
+	ret.Tokens = formatTokens(ana.Analyze([]byte(query.Text)), false)
 	c.JSON(http.StatusOK, ret)
 }
 
@@ -152,7 +176,7 @@ func Analyze(c *gin.Context) {
 // @Router /api/{index}/_analyze [post]
 func AnalyzeIndexForSDK() {}
 
-func parseTokenizer(data interface{}) ([]analysis.Tokenizer, error) {
+func parseTokenizer(data interface{}, named map[string]analysis.Tokenizer) ([]analysis.Tokenizer, error) {
 	if data == nil {
 		return nil, nil
 	}
@@ -160,17 +184,29 @@ func parseTokenizer(data interface{}) ([]analysis.Tokenizer, error) {
 	tokenizers := make([]analysis.Tokenizer, 0)
 	switch v := data.(type) {
 	case string:
+		if zer, ok := named[v]; ok {
+			tokenizers = append(tokenizers, zer)
+			break
+		}
 		zer, err := zincanalysis.RequestTokenizerSingle(v, nil)
 		if err != nil {
 			return nil, err
 		}
 		tokenizers = append(tokenizers, zer)
 	case []interface{}:
-		zers, err := zincanalysis.RequestTokenizerSlice(v)
-		if err != nil {
-			return nil, err
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				if zer, ok := named[name]; ok {
+					tokenizers = append(tokenizers, zer)
+					continue
+				}
+			}
+			zers, err := zincanalysis.RequestTokenizerSlice([]interface{}{item})
+			if err != nil {
+				return nil, err
+			}
+			tokenizers = append(tokenizers, zers...)
 		}
-		tokenizers = append(tokenizers, zers...)
 	case map[string]interface{}:
 		typ, err := zutils.GetStringFromMap(v, "type")
 		if typ != "" && err == nil {
@@ -195,7 +231,7 @@ func parseTokenizer(data interface{}) ([]analysis.Tokenizer, error) {
 	return tokenizers, nil
 }
 
-func parseTokenFilter(data interface{}) ([]analysis.TokenFilter, error) {
+func parseTokenFilter(data interface{}, named map[string]analysis.TokenFilter) ([]analysis.TokenFilter, error) {
 	if data == nil {
 		return nil, nil
 	}
@@ -203,17 +239,29 @@ func parseTokenFilter(data interface{}) ([]analysis.TokenFilter, error) {
 	tokens := make([]analysis.TokenFilter, 0)
 	switch v := data.(type) {
 	case string:
+		if filter, ok := named[v]; ok {
+			tokens = append(tokens, filter)
+			break
+		}
 		filter, err := zincanalysis.RequestTokenFilterSingle(v, nil)
 		if err != nil {
 			return nil, err
 		}
 		tokens = append(tokens, filter)
 	case []interface{}:
-		filters, err := zincanalysis.RequestTokenFilterSlice(v)
-		if err != nil {
-			return nil, err
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				if filter, ok := named[name]; ok {
+					tokens = append(tokens, filter)
+					continue
+				}
+			}
+			filters, err := zincanalysis.RequestTokenFilterSlice([]interface{}{item})
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filters...)
 		}
-		tokens = append(tokens, filters...)
 	case map[string]interface{}:
 		typ, err := zutils.GetStringFromMap(v, "type")
 		if typ != "" && err == nil {
@@ -238,7 +286,7 @@ func parseTokenFilter(data interface{}) ([]analysis.TokenFilter, error) {
 	return tokens, nil
 }
 
-func parseCharFilter(data interface{}) ([]analysis.CharFilter, error) {
+func parseCharFilter(data interface{}, named map[string]analysis.CharFilter) ([]analysis.CharFilter, error) {
 	if data == nil {
 		return nil, nil
 	}
@@ -246,17 +294,29 @@ func parseCharFilter(data interface{}) ([]analysis.CharFilter, error) {
 	chars := make([]analysis.CharFilter, 0)
 	switch v := data.(type) {
 	case string:
+		if filter, ok := named[v]; ok {
+			chars = append(chars, filter)
+			break
+		}
 		filter, err := zincanalysis.RequestCharFilterSingle(v, nil)
 		if err != nil {
 			return nil, err
 		}
 		chars = append(chars, filter)
 	case []interface{}:
-		filters, err := zincanalysis.RequestCharFilterSlice(v)
-		if err != nil {
-			return nil, err
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				if filter, ok := named[name]; ok {
+					chars = append(chars, filter)
+					continue
+				}
+			}
+			filters, err := zincanalysis.RequestCharFilterSlice([]interface{}{item})
+			if err != nil {
+				return nil, err
+			}
+			chars = append(chars, filters...)
 		}
-		chars = append(chars, filters...)
 	case map[string]interface{}:
 		typ, err := zutils.GetStringFromMap(v, "type")
 		if typ != "" && err == nil {
@@ -281,12 +341,134 @@ func parseCharFilter(data interface{}) ([]analysis.CharFilter, error) {
 	return chars, nil
 }
 
-func formatToken(token *analysis.Token) AnalyzeResponseToken {
+// analysisComponents are the tokenizers and filters defined in the index settings
+type analysisComponents struct {
+	charFilters  map[string]analysis.CharFilter
+	tokenizers   map[string]analysis.Tokenizer
+	tokenFilters map[string]analysis.TokenFilter
+}
+
+func newAnalysisComponents(settings *meta.IndexSettings) (*analysisComponents, error) {
+	components := new(analysisComponents)
+	if settings == nil || settings.Analysis == nil {
+		return components, nil
+	}
+
+	var err error
+	data := settings.Analysis
+	if components.charFilters, err = zincanalysis.RequestCharFilter(data.CharFilter); err != nil {
+		return nil, err
+	}
+	if components.tokenizers, err = zincanalysis.RequestTokenizer(data.Tokenizer); err != nil {
+		return nil, err
+	}
+	tokenFilters := data.TokenFilter
+	if tokenFilters == nil {
+		tokenFilters = data.Filter
+	}
+	if components.tokenFilters, err = zincanalysis.RequestTokenFilter(tokenFilters); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// inlineAnalyzer builds the analyzer defined in the request, the components of a custom analyzer
+// are moved to the request, so they are parsed like the inline tokenizer and filters
+func inlineAnalyzer(def map[string]interface{}, query *AnalyzeRequest) (*analysis.Analyzer, error) {
+	typ, _ := zutils.GetStringFromMap(def, "type")
+	if typ != "" && !strings.EqualFold(typ, "custom") {
+		data, err := json.Marshal(def)
+		if err != nil {
+			return nil, err
+		}
+		v := new(meta.Analyzer)
+		if err = json.Unmarshal(data, v); err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[analyzer] parse error: %s", err.Error()))
+		}
+		analyzers, err := zincanalysis.RequestAnalyzer(&meta.IndexAnalysis{
+			Analyzer: map[string]*meta.Analyzer{"inline": v},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return analyzers["inline"], nil
+	}
+
+	if query.Tokenizer == nil {
+		query.Tokenizer = def["tokenizer"]
+	}
+	if query.CharFilter == nil {
+		query.CharFilter = def["char_filter"]
+	}
+	if query.TokenFilter == nil && query.Filter == nil {
+		query.TokenFilter = def["token_filter"]
+		if query.TokenFilter == nil {
+			query.TokenFilter = def["filter"]
+		}
+	}
+	return new(analysis.Analyzer), nil
+}
+
+// explainAnalyzer runs the analyzer step by step and records the text after each char filter
+// and the tokens after the tokenizer and each token filter
+func explainAnalyzer(ana *analysis.Analyzer, input []byte, custom bool) *AnalyzeResponseDetail {
+	detail := &AnalyzeResponseDetail{CustomAnalyzer: custom}
+	for _, filter := range ana.CharFilters {
+		input = filter.Filter(input)
+		detail.CharFilters = append(detail.CharFilters, AnalyzeResponseCharFilter{
+			Name:         componentName(filter),
+			FilteredText: []string{string(input)},
+		})
+	}
+
+	// the filters change the tokens in place, so the tokens are formatted after each step
+	tokens := ana.Tokenizer.Tokenize(input)
+	detail.Tokenizer = &AnalyzeResponseTokens{
+		Name:   componentName(ana.Tokenizer),
+		Tokens: formatTokens(tokens, true),
+	}
+	for _, filter := range ana.TokenFilters {
+		tokens = filter.Filter(tokens)
+		detail.TokenFilters = append(detail.TokenFilters, AnalyzeResponseTokens{
+			Name:   componentName(filter),
+			Tokens: formatTokens(tokens, true),
+		})
+	}
+
+	return detail
+}
+
+// componentName returns the type name of the analysis component, like LowerCaseFilter
+func componentName(component interface{}) string {
+	typ := reflect.TypeOf(component)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Name()
+}
+
+// formatTokens returns the tokens with their positions, the explain output has the position length also
+func formatTokens(tokens analysis.TokenStream, explain bool) []AnalyzeResponseToken {
+	ret := make([]AnalyzeResponseToken, 0, len(tokens))
+	position := -1
+	for _, token := range tokens {
+		position += token.PositionIncr
+		t := formatToken(token, position)
+		if explain {
+			t.PositionLength = 1
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+// formatToken returns the token with its position in the text, starting from 0
+func formatToken(token *analysis.Token, position int) AnalyzeResponseToken {
 	return AnalyzeResponseToken{
 		Token:       string(token.Term),
 		StartOffset: token.Start,
 		EndOffset:   token.End,
-		Position:    token.PositionIncr,
+		Position:    position,
 		Type:        formatTokenType(token.Type),
 		Keyword:     token.KeyWord,
 	}
@@ -316,17 +498,45 @@ func formatTokenType(typ analysis.TokenType) string {
 }
 
 type AnalyzeRequest struct {
-	Analyzer    string      `json:"analyzer"`
+	Analyzer    interface{} `json:"analyzer"` // the analyzer name or an inline analyzer definition
 	Filed       string      `json:"field"`
 	Text        string      `json:"text"`
 	Tokenizer   interface{} `json:"tokenizer"`
 	CharFilter  interface{} `json:"char_filter"`
 	TokenFilter interface{} `json:"token_filter"`
 	Filter      interface{} `json:"filter"` // compatibility with es, alias for TokenFilter
+	Explain     bool        `json:"explain"`
 }
 
 type AnalyzeResponse struct {
 	Tokens []AnalyzeResponseToken `json:"tokens"`
+	Detail *AnalyzeResponseDetail `json:"detail,omitempty"`
+}
+
+// AnalyzeResponseDetail is the output of each analysis step, returned with explain
+type AnalyzeResponseDetail struct {
+	CustomAnalyzer bool                        `json:"custom_analyzer"`
+	CharFilters    []AnalyzeResponseCharFilter `json:"charfilters,omitempty"`
+	Tokenizer      *AnalyzeResponseTokens      `json:"tokenizer,omitempty"`
+	TokenFilters   []AnalyzeResponseTokens     `json:"tokenfilters,omitempty"`
+}
+
+// lastTokens returns the tokens of the last step
+func (d *AnalyzeResponseDetail) lastTokens() []AnalyzeResponseToken {
+	if len(d.TokenFilters) > 0 {
+		return d.TokenFilters[len(d.TokenFilters)-1].Tokens
+	}
+	return d.Tokenizer.Tokens
+}
+
+type AnalyzeResponseCharFilter struct {
+	Name         string   `json:"name"`
+	FilteredText []string `json:"filtered_text"`
+}
+
+type AnalyzeResponseTokens struct {
+	Name   string                 `json:"name"`
+	Tokens []AnalyzeResponseToken `json:"tokens"`
 }
 
 type AnalyzeResponseToken struct {
@@ -336,4 +546,6 @@ type AnalyzeResponseToken struct {
 	Position    int    `json:"position"`
 	Type        string `json:"type"`
 	Keyword     bool   `json:"keyword"`
+	// PositionLength is only set in the explain output
+	PositionLength int `json:"positionLength,omitempty"`
 }
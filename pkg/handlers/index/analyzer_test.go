@@ -200,6 +200,249 @@ func TestAnalyze(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "custom synonym filter",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"syn":{"type":"synonym","synonyms":["k8s => kubernetes","quick, fast"]}},"text":"quick k8s"}`,
+				params: map[string]string{"target": ""},
+				result: "[quick fast kubernetes]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom stop filter with language",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"fr":{"type":"stop","stopwords":"_french_"}},"text":"le chat et la souris"}`,
+				params: map[string]string{"target": ""},
+				result: "[chat souris]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom stop filter with ignore_case",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"en":{"type":"stop","stopwords":["_english_","Quick"],"ignore_case":true}},"text":"The quick Fox"}`,
+				params: map[string]string{"target": ""},
+				result: "[Fox]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "inline custom analyzer",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"analyzer":{"type":"custom","tokenizer":"whitespace","char_filter":["html_strip"],"filter":["lowercase",{"type":"stop","stopwords":["the"]}]},"text":"<p>The Quick</p>"}`,
+				params: map[string]string{"target": ""},
+				result: "[quick]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "inline build-in analyzer",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"analyzer":{"type":"standard","stopwords":["is"]},"text":"this is a test"}`,
+				params: map[string]string{"target": ""},
+				result: "[this a test]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "inline analyzer with not exists tokenizer",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"analyzer":{"tokenizer":"not_exists"},"text":"this is a test"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "with index defined filters",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"my_tokenizer","filter":["lowercase","my_stop"],"text":"The-Quick-Fox"}`,
+				params: map[string]string{"target": indexName},
+				result: "[quick fox]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "cjk analyzer",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"analyzer":"cjk","text":"我爱北京天安门"}`,
+				params: map[string]string{"target": ""},
+				result: "[我爱 爱北 北京 京天 天安 安门]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "icu analyzer",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"analyzer":"icu_analyzer","text":"Ünïcode Ｔｅｘｔ"}`,
+				params: map[string]string{"target": ""},
+				result: "[unicode text]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom icu tokenizer and filters",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"icu_tokenizer","char_filter":{"type":"icu_normalizer","name":"nfkc"},"filter":["icu_folding"],"text":"Café ÉTÉ ｆｕｌｌ"}`,
+				params: map[string]string{"target": ""},
+				result: "[cafe ete full]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom icu_normalizer filter with error name",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":"icu_tokenizer","filter":{"type":"icu_normalizer","name":"nfx"},"text":"abc"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom cjk_bigram filter with unigrams",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"type":"cjk_bigram","output_unigrams":true},"text":"北京"}`,
+				params: map[string]string{"target": ""},
+				result: "[北 北京 京]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom pattern tokenizer",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":{"type":"pattern","pattern":"[^a-z0-9]+","flags":"CASE_INSENSITIVE"},"text":"Foo,bar--Baz 42"}`,
+				params: map[string]string{"target": ""},
+				result: "[Foo bar Baz 42]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom pattern tokenizer with group",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":{"type":"pattern","pattern":"'(.*?)'","group":1},"text":"'value', 'value2'"}`,
+				params: map[string]string{"target": ""},
+				result: "[value value2]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom pattern tokenizer with error pattern",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":{"type":"pattern","pattern":"a("},"text":"abc"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom pattern_replace filters",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"whitespace","char_filter":{"type":"pattern_replace","pattern":"X","flags":"CASE_INSENSITIVE","replacement":"-"},"filter":{"type":"pattern_replace","pattern":"(\\d)","replacement":"<$1>","all":false},"text":"a1b2 cx3"}`,
+				params: map[string]string{"target": ""},
+				result: "[a<1>b2 c-<3>]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom edge_ngram filter",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":["lowercase",{"type":"edge_ngram","min_gram":2,"max_gram":3,"preserve_original":true}],"text":"Quick a"}`,
+				params: map[string]string{"target": ""},
+				result: "[qu qui quick a]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom ngram filter",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"ng":{"type":"ngram","min_gram":2,"max_gram":3}},"text":"fox"}`,
+				params: map[string]string{"target": ""},
+				result: "[fo fox ox]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom edge_ngram filter with error min_gram",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":"standard","filter":{"ng":{"type":"edge_ngram","min_gram":-1}},"text":"fox"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom stemmer filter",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":["lowercase",{"type":"stemmer","language":"english"}],"text":"Running jumps"}`,
+				params: map[string]string{"target": ""},
+				result: "[run jump]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom snowball filter with language",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":{"fr":{"type":"snowball","language":"French"}},"text":"chevaux continuellement"}`,
+				params: map[string]string{"target": ""},
+				result: "[cheval continuel]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom stemmer filter with keyword_marker and stemmer_override",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"tokenizer":"standard","filter":[{"type":"keyword_marker","keywords":["running"]},{"type":"stemmer_override","rules":["mice => mouse"]},{"type":"stemmer","language":"light_french"},{"type":"snowball"}],"text":"running mice jumps"}`,
+				params: map[string]string{"target": ""},
+				result: "[running mouse jump]",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom stemmer filter with not supported language",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":"standard","filter":{"xx":{"type":"stemmer","language":"klingon"}},"text":"running"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom stop filter with error stopwords",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":"standard","filter":{"en":{"type":"stop","stopwords":1}},"text":"The quick Fox"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom synonym filter without synonyms",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"tokenizer":"standard","filter":{"syn":{"type":"synonym"}},"text":"quick k8s"}`,
+				params: map[string]string{"target": ""},
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty analyzer with custom tokenizer",
 			args: args{
@@ -247,6 +490,15 @@ func TestAnalyze(t *testing.T) {
 		mapping.Properties["name2"] = prop2
 		err = index.SetMappings(mapping)
 		assert.NoError(t, err)
+		err = index.SetSettings(&meta.IndexSettings{Analysis: &meta.IndexAnalysis{
+			Tokenizer: map[string]interface{}{
+				"my_tokenizer": map[string]interface{}{"type": "char_group", "tokenize_on_chars": []interface{}{"-"}},
+			},
+			TokenFilter: map[string]interface{}{
+				"my_stop": map[string]interface{}{"type": "stop", "stopwords": []interface{}{"the"}},
+			},
+		}})
+		assert.NoError(t, err)
 
 		err = core.StoreIndex(index)
 		assert.NoError(t, err)
@@ -275,6 +527,56 @@ func TestAnalyze(t *testing.T) {
 	})
 }
 
+func TestAnalyzePosition(t *testing.T) {
+	c, w := utils.NewGinContext()
+	utils.SetGinRequestData(c, `{"tokenizer":"standard","filter":["stop",{"type":"edge_ngram","min_gram":1,"max_gram":2}],"text":"quick and fox"}`)
+	Analyze(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ret := new(AnalyzeResponse)
+	err := json.Unmarshal(w.Body.Bytes(), ret)
+	assert.NoError(t, err)
+	positions := make([]int, 0, len(ret.Tokens))
+	for _, token := range ret.Tokens {
+		positions = append(positions, token.Position)
+	}
+	assert.Equal(t, []int{0, 0, 2, 2}, positions)
+	assert.Equal(t, 10, ret.Tokens[2].StartOffset)
+	assert.Equal(t, 13, ret.Tokens[2].EndOffset)
+}
+
+func TestAnalyzeExplain(t *testing.T) {
+	c, w := utils.NewGinContext()
+	utils.SetGinRequestData(c, `{"tokenizer":"standard","char_filter":"html_strip","filter":["lowercase","stop"],"text":"<b>The</b> Quick fox","explain":true}`)
+	Analyze(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ret := new(AnalyzeResponse)
+	err := json.Unmarshal(w.Body.Bytes(), ret)
+	assert.NoError(t, err)
+	assert.NotNil(t, ret.Detail)
+	assert.True(t, ret.Detail.CustomAnalyzer)
+
+	assert.Len(t, ret.Detail.CharFilters, 1)
+	assert.Equal(t, []string{" The  Quick fox"}, ret.Detail.CharFilters[0].FilteredText)
+
+	terms := func(tokens []AnalyzeResponseToken) []string {
+		strs := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			strs = append(strs, token.Token)
+		}
+		return strs
+	}
+	assert.Equal(t, "UnicodeTokenizer", ret.Detail.Tokenizer.Name)
+	assert.Equal(t, []string{"The", "Quick", "fox"}, terms(ret.Detail.Tokenizer.Tokens))
+	assert.Equal(t, 1, ret.Detail.Tokenizer.Tokens[0].PositionLength)
+	assert.Len(t, ret.Detail.TokenFilters, 2)
+	assert.Equal(t, []string{"the", "quick", "fox"}, terms(ret.Detail.TokenFilters[0].Tokens))
+	assert.Equal(t, []string{"quick", "fox"}, terms(ret.Detail.TokenFilters[1].Tokens))
+	assert.Equal(t, 1, ret.Detail.TokenFilters[1].Tokens[0].Position)
+	assert.Equal(t, terms(ret.Detail.TokenFilters[1].Tokens), terms(ret.Tokens))
+}
+
 func getTokenStrings(data []byte) (string, error) {
 	var ret map[string]interface{}
 	err := json.Unmarshal(data, &ret)
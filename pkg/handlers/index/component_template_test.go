@@ -0,0 +1,165 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestComponentTemplate(t *testing.T) {
+	t.Run("create component template", func(t *testing.T) {
+		type args struct {
+			code    int
+			data    map[string]interface{}
+			rawData string
+			target  string
+			result  string
+		}
+		tests := []struct {
+			name string
+			args args
+		}{
+			{
+				name: "normal",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"template": map[string]interface{}{
+							"settings": map[string]interface{}{"number_of_shards": 2},
+							"mappings": map[string]interface{}{
+								"properties": map[string]interface{}{
+									"@timestamp": map[string]interface{}{"type": "date"},
+								},
+							},
+						},
+						"version": 1,
+						"_meta":   map[string]interface{}{"description": "timestamp"},
+					},
+					target: "TestComponentTemplate.component_1",
+					result: `{"message":"ok"`,
+				},
+			},
+			{
+				name: "empty",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{}`,
+					target:  "",
+					result:  `should be not empty`,
+				},
+			},
+			{
+				name: "without template",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"version":1}`,
+					target:  "TestComponentTemplate.component_2",
+					result:  `template should be defined`,
+				},
+			},
+			{
+				name: "unknown option",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"template":{},"index_patterns":["log-*"]}`,
+					target:  "TestComponentTemplate.component_2",
+					result:  `unknown option [index_patterns]`,
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c, w := utils.NewGinContext()
+				if tt.args.data != nil {
+					utils.SetGinRequestData(c, tt.args.data)
+				}
+				if tt.args.rawData != "" {
+					utils.SetGinRequestData(c, tt.args.rawData)
+				}
+				utils.SetGinRequestParams(c, map[string]string{"target": tt.args.target})
+				CreateComponentTemplate(c)
+				assert.Equal(t, tt.args.code, w.Code)
+				assert.Contains(t, w.Body.String(), tt.args.result)
+			})
+		}
+	})
+
+	t.Run("use component template", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{
+			"index_patterns": []string{"TestComponentTemplate-*"},
+			"composed_of":    []string{"TestComponentTemplate.component_1"},
+			"template":       map[string]interface{}{},
+		})
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.template_1"})
+		CreateTemplate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{
+			"index_patterns": []string{"TestComponentTemplate-missing-*"},
+			"composed_of":    []string{"TestComponentTemplate.component_N"},
+			"template":       map[string]interface{}{},
+		})
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.template_2"})
+		CreateTemplate(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `do not exist`)
+	})
+
+	t.Run("get component template", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.component_1"})
+		GetComponentTemplate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"number_of_shards":2`)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.component_N"})
+		GetComponentTemplate(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("list component template", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		ListComponentTemplate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"TestComponentTemplate.component_1"`)
+	})
+
+	t.Run("delete component template", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.component_1"})
+		DeleteComponentTemplate(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `still in use`)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.template_1"})
+		DeleteTemplate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestComponentTemplate.component_1"})
+		DeleteComponentTemplate(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -112,6 +112,9 @@ func CreateIndexWorker(newIndex *meta.IndexSimple, indexName string) error {
 	if newIndex.Settings == nil {
 		newIndex.Settings = new(meta.IndexSettings)
 	}
+	if err := core.ValidateSettings(newIndex.Settings); err != nil {
+		return errors.New(err.Error())
+	}
 	analyzers, err := zincanalysis.RequestAnalyzer(newIndex.Settings.Analysis)
 	if err != nil {
 		return errors.New(err.Error())
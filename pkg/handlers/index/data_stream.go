@@ -0,0 +1,91 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id CreateDataStream
+// @Summary Create data stream
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  true  "Data stream"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_data_stream/{name} [put]
+func PutDataStream(c *gin.Context) {
+	if _, err := core.CreateDataStream(c.Param("target")); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id GetDataStream
+// @Summary Get data streams with their backing indexes
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  false  "Data streams, supports wildcards and comma separated list"
+// @Success 200 {object} meta.DataStreamsResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_data_stream/{name} [get]
+func GetDataStream(c *gin.Context) {
+	var names []string
+	if target := c.Param("target"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.ZINC_DATA_STREAM_LIST.Get(name); !ok {
+			errors.HandleError(c, errors.New(errors.ErrorTypeIndexNotFoundException, fmt.Sprintf("no such index [%s]", name)))
+			return
+		}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.DataStreamsResponse{DataStreams: core.ZINC_DATA_STREAM_LIST.ListMatch(names)})
+}
+
+// @Id DeleteDataStream
+// @Summary Delete data streams with their backing indexes
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  true  "Data streams, supports comma separated list"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_data_stream/{name} [delete]
+func DeleteDataStream(c *gin.Context) {
+	for _, name := range strings.Split(c.Param("target"), ",") {
+		if err := core.DeleteDataStream(name); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
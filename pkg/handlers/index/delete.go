@@ -17,7 +17,6 @@ package index
 
 import (
 	"net/http"
-	"regexp"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -46,11 +45,9 @@ func Delete(c *gin.Context) {
 		return
 	}
 
-	indexList := core.ZINC_INDEX_LIST.List()
-
 	for _, indexName := range strings.Split(indexNames, ",") {
 		if strings.Contains(indexName, "*") { // check for wildcard
-			err := deleteIndexWithWildcard(indexName, indexList)
+			err := deleteIndexWithWildcard(indexName)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
 				return
@@ -68,26 +65,10 @@ func Delete(c *gin.Context) {
 	})
 }
 
-func deleteIndexWithWildcard(indexName string, indexList []*core.Index) error {
-	parts := strings.Split(indexName, "*")
-	pattern := ""
-	for i, part := range parts {
-		pattern += part
-		if i < len(parts)-1 {
-			pattern += "[[:ascii:]]+"
-		}
-	}
-
-	p, err := regexp.Compile(pattern)
-	if err != nil {
-		return err
-	}
-
-	for _, i := range indexList {
-		if p.MatchString(i.GetName()) {
-			if err := core.DeleteIndex(i.GetName()); err != nil {
-				return err
-			}
+func deleteIndexWithWildcard(indexName string) error {
+	for _, index := range core.ZINC_INDEX_LIST.ListMatch([]string{indexName}) {
+		if err := core.DeleteIndex(index.GetName()); err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,61 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id FieldCaps
+// @Summary Get the capabilities of the fields for compatible ES
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index  path  string  false  "Index"
+// @Param   fields query string  false  "Comma separated fields, support wildcard"
+// @Success 200 {object} meta.FieldCapsResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_field_caps [get]
+func FieldCaps(c *gin.Context) {
+	var names []string
+	if target := c.Param("target"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+	indexes := core.ZINC_INDEX_LIST.ListMatch(names)
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return
+		}
+	}
+
+	var fields []string
+	if v := c.Query("fields"); v != "" {
+		fields = strings.Split(v, ",")
+	}
+
+	zutils.GinRenderJSON(c, http.StatusOK, core.FieldCaps(indexes, fields))
+}
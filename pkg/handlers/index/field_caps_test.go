@@ -0,0 +1,98 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestFieldCaps(t *testing.T) {
+	indexName := "TestFieldCaps.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		mappings := meta.NewMappings()
+		mappings.SetProperty("name", meta.NewProperty("text"))
+		mappings.SetProperty("status", meta.NewProperty("keyword"))
+		assert.NoError(t, index.SetMappings(mappings))
+		assert.NoError(t, core.StoreIndex(index))
+	})
+
+	type args struct {
+		code   int
+		params map[string]string
+		query  map[string]string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		check func(t *testing.T, resp *meta.FieldCapsResponse)
+	}{
+		{
+			name: "fields",
+			args: args{code: http.StatusOK, params: map[string]string{"target": indexName}, query: map[string]string{"fields": "name,stat*"}},
+			check: func(t *testing.T, resp *meta.FieldCapsResponse) {
+				assert.Equal(t, []string{indexName}, resp.Indices)
+				assert.Len(t, resp.Fields, 2)
+				assert.True(t, resp.Fields["name"]["text"].Searchable)
+				assert.False(t, resp.Fields["name"]["text"].Aggregatable)
+				assert.True(t, resp.Fields["status"]["keyword"].Aggregatable)
+			},
+		},
+		{
+			name: "wildcard",
+			args: args{code: http.StatusOK, params: map[string]string{"target": "TestFieldCaps.*"}, query: map[string]string{"fields": "*"}},
+			check: func(t *testing.T, resp *meta.FieldCapsResponse) {
+				assert.Equal(t, []string{indexName}, resp.Indices)
+				assert.Contains(t, resp.Fields, "_id")
+				assert.Contains(t, resp.Fields, "name")
+			},
+		},
+		{
+			name: "not exists index",
+			args: args{code: http.StatusNotFound, params: map[string]string{"target": "TestFieldCaps.index_2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestURL(c, "/es/"+tt.args.params["target"]+"/_field_caps", tt.args.query)
+			FieldCaps(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			if tt.check == nil {
+				return
+			}
+			resp := new(meta.FieldCapsResponse)
+			err := json.Unmarshal(w.Body.Bytes(), resp)
+			assert.NoError(t, err)
+			tt.check(t, resp)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id Flush
+// @Summary Flush buffered documents of indexes to disk
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index  path  string  false  "Index"
+// @Success 200 {object} meta.FlushResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_flush [post]
+func Flush(c *gin.Context) {
+	indexes, ok := targetIndexes(c)
+	if !ok {
+		return
+	}
+
+	resp := &meta.FlushResponse{Indices: make(map[string]meta.FlushIndexResult, len(indexes))}
+	for _, index := range indexes {
+		shardNum := index.GetAllShardNum()
+		resp.Shards.Total += shardNum
+		if err := index.Flush(); err != nil {
+			resp.Shards.Failed += shardNum
+			resp.Indices[index.GetName()] = meta.FlushIndexResult{Error: err.Error()}
+			continue
+		}
+		resp.Shards.Successful += shardNum
+		resp.Indices[index.GetName()] = meta.FlushIndexResult{Flushed: true}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
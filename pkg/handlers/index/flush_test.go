@@ -0,0 +1,91 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestFlush(t *testing.T) {
+	indexName := "TestFlush.index_1"
+	type args struct {
+		code   int
+		params map[string]string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "flush",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				result: `{"_shards":{"total":2,"successful":2,"failed":0},"indices":{"TestFlush.index_1":{"flushed":true}}}`,
+			},
+		},
+		{
+			name: "flush with wildcard",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": "TestFlush.*"},
+				result: `"TestFlush.index_1":{"flushed":true}`,
+			},
+		},
+		{
+			name: "flush all",
+			args: args{
+				code:   http.StatusOK,
+				result: `"TestFlush.index_1":{"flushed":true}`,
+			},
+		},
+		{
+			name: "not exists index",
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"target": "TestFlush.notExist"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "doc1"}, false))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			Flush(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
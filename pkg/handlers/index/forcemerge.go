@@ -0,0 +1,89 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id ForceMerge
+// @Summary Merge the segments of indexes
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index               path   string  false  "Index"
+// @Param   max_num_segments    query  int     false  "The number of segments to merge to, default is 1"
+// @Param   wait_for_completion query  bool    false  "Wait for the merge to complete, default is true"
+// @Success 200 {object} meta.ForceMergeResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_forcemerge [post]
+func ForceMerge(c *gin.Context) {
+	maxNumSegments := 1
+	if v := c.Query("max_num_segments"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "failed to parse [max_num_segments] with value [" + v + "]"})
+			return
+		}
+		maxNumSegments = n
+	}
+	wait := true
+	if v := c.Query("wait_for_completion"); v != "" {
+		var err error
+		if wait, err = strconv.ParseBool(v); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "failed to parse [wait_for_completion] with value [" + v + "]"})
+			return
+		}
+	}
+
+	indexes, ok := targetIndexes(c)
+	if !ok {
+		return
+	}
+
+	resp := new(meta.ForceMergeResponse)
+	for _, index := range indexes {
+		resp.Shards.Total += index.GetAllShardNum()
+	}
+	if !wait {
+		go func() {
+			for _, index := range indexes {
+				if err := index.ForceMerge(maxNumSegments); err != nil {
+					log.Error().Err(err).Str("index", index.GetName()).Msg("force merge failed")
+				}
+			}
+		}()
+		zutils.GinRenderJSON(c, http.StatusOK, resp)
+		return
+	}
+
+	for _, index := range indexes {
+		if err := index.ForceMerge(maxNumSegments); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	}
+	resp.Shards.Successful = resp.Shards.Total
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
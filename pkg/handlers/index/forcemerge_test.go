@@ -0,0 +1,115 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestForceMerge(t *testing.T) {
+	indexName := "TestForceMerge.index_1"
+	type args struct {
+		code   int
+		params map[string]string
+		query  map[string]string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "without waiting",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"wait_for_completion": "false"},
+				result: `"successful":0`,
+			},
+		},
+		{
+			name: "force merge",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"max_num_segments": "1"},
+				result: `{"_shards":{"total":2,"successful":2,"failed":0}}`,
+			},
+		},
+		{
+			name: "force merge with wildcard",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": "TestForceMerge.*"},
+				result: `"successful":2`,
+			},
+		},
+		{
+			name: "invalid max_num_segments",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"max_num_segments": "0"},
+				result: "max_num_segments",
+			},
+		},
+		{
+			name: "invalid wait_for_completion",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"wait_for_completion": "x"},
+				result: "wait_for_completion",
+			},
+		},
+		{
+			name: "not exists index",
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"target": "TestForceMerge.notExist"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			if tt.args.query != nil {
+				utils.SetGinRequestURL(c, "/es/"+indexName+"/_forcemerge", tt.args.query)
+			}
+			ForceMerge(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
@@ -0,0 +1,111 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id PutLifecyclePolicy
+// @Summary Create or update a lifecycle policy
+// @security BasicAuth
+// @Tags    Index
+// @Accept  json
+// @Produce json
+// @Param   name  path  string                       true  "Policy"
+// @Param   data  body  meta.LifecyclePolicyRequest  true  "Policy"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_ilm/policy/{name} [put]
+func PutLifecyclePolicy(c *gin.Context) {
+	req := new(meta.LifecyclePolicyRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := core.PutLifecyclePolicy(c.Param("name"), req); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id GetLifecyclePolicy
+// @Summary Get the lifecycle policies
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  false  "Policy"
+// @Success 200 {object} map[string]meta.LifecyclePolicyResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_ilm/policy/{name} [get]
+func GetLifecyclePolicy(c *gin.Context) {
+	name := c.Param("name")
+	resp := make(map[string]meta.LifecyclePolicyResponse)
+	if name == "" {
+		policies, err := core.ListLifecyclePolicies()
+		if err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+		for _, policy := range policies {
+			resp[policy.Name] = meta.LifecyclePolicyResponse{Version: policy.Version, ModifiedDate: policy.ModifiedDate, Policy: policy.Policy}
+		}
+		zutils.GinRenderJSON(c, http.StatusOK, resp)
+		return
+	}
+	policy, ok, err := core.GetLifecyclePolicy(name)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "lifecycle policy " + name + " does not exists"})
+		return
+	}
+	resp[name] = meta.LifecyclePolicyResponse{Version: policy.Version, ModifiedDate: policy.ModifiedDate, Policy: policy.Policy}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// @Id DeleteLifecyclePolicy
+// @Summary Delete a lifecycle policy not used by any index
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  true  "Policy"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_ilm/policy/{name} [delete]
+func DeleteLifecyclePolicy(c *gin.Context) {
+	name := c.Param("name")
+	ok, err := core.DeleteLifecyclePolicy(name)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "lifecycle policy " + name + " does not exists"})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
@@ -97,15 +97,26 @@ func SetMapping(c *gin.Context) {
 				}
 			}
 		}
-		// add mappings
+		// the stored documents can't be changed
+		if source := mappings.GetSource(); source != nil && source.Enabled != indexMappings.SourceEnabled() {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "index [" + indexName + "] cannot update parameter [enabled] of [_source]"})
+			return
+		}
+		// add mappings, the runtime fields and dynamic templates can be replaced
 		for field, prop := range mappings.ListProperty() {
 			indexMappings.SetProperty(field, prop)
 		}
+		for field, runtime := range mappings.ListRuntime() {
+			indexMappings.SetRuntime(field, runtime)
+		}
+		for _, tpl := range mappings.ListDynamicTemplates() {
+			indexMappings.SetDynamicTemplate(tpl)
+		}
 		mappings = indexMappings
 	}
 
 	// update mappings
-	if mappings != nil && mappings.Len() > 0 {
+	if mappings != nil && (mappings.Len() > 0 || len(mappings.ListRuntime()) > 0 || len(mappings.ListDynamicTemplates()) > 0 || mappings.GetSource() != nil) {
 		for k, v := range mappings.Properties {
 			if v.Fields == nil {
 				continue
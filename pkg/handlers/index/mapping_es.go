@@ -9,6 +9,7 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/meta/elastic"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
@@ -55,11 +56,63 @@ func GetESMapping(c *gin.Context) {
 	zutils.GinRenderJSON(c, http.StatusOK, gin.H{index.GetName(): gin.H{"mappings": es}})
 }
 
+// @Id ESGetFieldMapping
+// @Summary Get the mappings of the fields for compatible ES
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index path  string  true  "Index"
+// @Param   field path  string  true  "Comma separated fields, support wildcard"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_mapping/field/{field} [get]
+func GetESFieldMapping(c *gin.Context) {
+	var names []string
+	if target := c.Param("target"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+	indexes := core.ZINC_INDEX_LIST.ListMatch(names)
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return
+		}
+	}
+	fields := strings.Split(c.Param("field"), ",")
+
+	resp := make(map[string]interface{}, len(indexes))
+	for _, index := range indexes {
+		mappings := make(map[string]interface{})
+		for field, prop := range index.GetMappings().ListProperty() {
+			for _, pattern := range fields {
+				if !source.MatchPattern(pattern, field) {
+					continue
+				}
+				// the mapping is keyed by the last part of the dotted path
+				name := field[strings.LastIndex(field, ".")+1:]
+				mappings[field] = gin.H{
+					"full_name": field,
+					"mapping":   gin.H{name: convertToESProperty(prop)},
+				}
+				break
+			}
+		}
+		resp[index.GetName()] = gin.H{"mappings": mappings}
+	}
+
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
 // convertToESMapping converts the given Zinc mappings to the ElasticSearch representation.
 // TODO: In the future, the result can be stored, if a performance gain can be achieved (with a TTL).
 func convertToESMapping(mappings *meta.Mappings) *elastic.Mappings {
 	orig := mappings.DeepClone()
 	m := elastic.NewMappings()
+	m.Runtime = orig.Runtime
+	m.DynamicTemplates = orig.DynamicTemplates
 
 	// we first have to remove the automatically added property field mappings
 	for k, v := range orig.Properties {
@@ -139,6 +192,11 @@ func convertToESProperty(p meta.Property) elastic.Property {
 	prop.Analyzer = p.Analyzer
 	prop.SearchAnalyzer = p.SearchAnalyzer
 	prop.Format = p.Format
+	prop.CopyTo = p.CopyTo
+	prop.NullValue = p.NullValue
+	if p.IgnoreAbove > 0 {
+		prop.IgnoreAbove = uint(p.IgnoreAbove)
+	}
 
 	if p.Fields != nil {
 		for k, v := range p.Fields {
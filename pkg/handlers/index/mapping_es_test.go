@@ -157,6 +157,74 @@ func TestESMapping_GetConverted(t *testing.T) {
 		}
 	})
 
+	t.Run("get field mapping", func(t *testing.T) {
+		type args struct {
+			code   int
+			target string
+			field  string
+			result string
+		}
+		tests := []struct {
+			name string
+			args args
+		}{
+			{
+				name: "field",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestEsMapping.index_1",
+					field:  "City",
+					result: `{"TestEsMapping.index_1":{"mappings":{"City":{"full_name":"City","mapping":{"City":{"type":"keyword"}}}}}}`,
+				},
+			},
+			{
+				name: "dotted path",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestEsMapping.index_1",
+					field:  "obj.sub_field",
+					result: `{"TestEsMapping.index_1":{"mappings":{"obj.sub_field":{"full_name":"obj.sub_field","mapping":{"sub_field":{"type":"text","fields":{"keyword":{"type":"keyword"}}}}}}}}`,
+				},
+			},
+			{
+				name: "wildcard",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestEsMapping.*",
+					field:  "Ci*,Gender",
+					result: `"Gender":{"full_name":"Gender","mapping":{"Gender":{"type":"bool"}}}`,
+				},
+			},
+			{
+				name: "not exists field",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestEsMapping.index_1",
+					field:  "xxx",
+					result: `{"TestEsMapping.index_1":{"mappings":{}}}`,
+				},
+			},
+			{
+				name: "not exists index",
+				args: args{
+					code:   http.StatusNotFound,
+					target: "TestEsMapping.index_2",
+					field:  "City",
+					result: `{"error":"index TestEsMapping.index_2 does not exists"}`,
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c, w := utils.NewGinContext()
+				utils.SetGinRequestParams(c, map[string]string{"target": tt.args.target, "field": tt.args.field})
+				GetESFieldMapping(c)
+				assert.Equal(t, tt.args.code, w.Code)
+				assert.Contains(t, w.Body.String(), tt.args.result)
+			})
+		}
+	})
+
 	t.Run("delete index", func(t *testing.T) {
 		for i := 0; i < 10; i++ {
 			_ = core.DeleteIndex(fmt.Sprintf("TestEsMapping.index_%d", i))
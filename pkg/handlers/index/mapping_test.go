@@ -115,6 +115,263 @@ func TestMapping(t *testing.T) {
 				},
 				wantErr: true,
 			},
+			{
+				name: "runtime",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"runtime": map[string]interface{}{
+							"full_name": map[string]interface{}{
+								"type":   "keyword",
+								"script": "doc['first'].value + ' ' + doc['last'].value",
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "runtime with invalid script",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"runtime": map[string]interface{}{
+							"full_name": map[string]interface{}{
+								"type":   "keyword",
+								"script": map[string]interface{}{"source": "doc['first'].value +"},
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] runtime [full_name] compile error, cause: script [doc['first'].value +] compile error at position 20: unexpected end of the script"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "runtime with invalid type",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"runtime": map[string]interface{}{
+							"full_name": map[string]interface{}{"type": "text", "script": "1"},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] runtime [full_name] doesn't support type [text]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "multi fields",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Event": map[string]interface{}{
+								"type":   "keyword",
+								"fields": map[string]interface{}{"text": map[string]interface{}{"type": "text"}},
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "copy_to",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"First": map[string]interface{}{"type": "text", "copy_to": "FullName"},
+							"Last":  map[string]interface{}{"type": "text", "copy_to": []interface{}{"FullName", "Names"}},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "copy_to with invalid type",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Middle": map[string]interface{}{"type": "text", "copy_to": 1},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] properties [Middle] copy_to should be a string or an array of string"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "ignore_above",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Code": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "ignore_above with negative value",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Serial": map[string]interface{}{"type": "keyword", "ignore_above": -1},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] properties [Serial] ignore_above should be a non negative integer"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "null_value",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Status": map[string]interface{}{"type": "keyword", "null_value": "NULL"},
+							"Score":  map[string]interface{}{"type": "integer", "null_value": 0},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "null_value with invalid value",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Rank": map[string]interface{}{"type": "integer", "null_value": "none"},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] properties [Rank] null_value [none] is not a valid [numeric] value"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "multi fields with nested",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"Sport": map[string]interface{}{
+								"type":   "keyword",
+								"fields": map[string]interface{}{"list": map[string]interface{}{"type": "nested"}},
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] properties [Sport.list] doesn't support type [nested] in fields"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "dynamic templates",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"dynamic_templates": []interface{}{
+							map[string]interface{}{
+								"strings_as_keyword": map[string]interface{}{
+									"match_mapping_type": "string",
+									"mapping":            map[string]interface{}{"type": "keyword"},
+								},
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "dynamic templates with invalid match_mapping_type",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"dynamic_templates": []interface{}{
+							map[string]interface{}{
+								"strings": map[string]interface{}{
+									"match_mapping_type": "text",
+									"mapping":            map[string]interface{}{"type": "keyword"},
+								},
+							},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] dynamic_templates [strings] doesn't support match_mapping_type [text]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "dynamic templates without mapping",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"dynamic_templates": []interface{}{
+							map[string]interface{}{"strings": map[string]interface{}{"match": "*"}},
+						},
+					},
+					target: "TestMapping.index_1",
+					result: `{"error":"type: parsing_exception, reason: [mappings] dynamic_templates [strings] mapping should be an object"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "source disabled",
+				args: args{
+					code: http.StatusOK,
+					data: map[string]interface{}{
+						"_source":    map[string]interface{}{"enabled": false},
+						"properties": map[string]interface{}{"Name": map[string]interface{}{"type": "keyword", "store": true}},
+					},
+					target: "TestMapping.index_4",
+					result: `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "source enabled on existing index",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"_source": map[string]interface{}{"enabled": true},
+					},
+					target: "TestMapping.index_4",
+					result: `{"error":"index [TestMapping.index_4] cannot update parameter [enabled] of [_source]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "source with invalid option",
+				args: args{
+					code: http.StatusBadRequest,
+					data: map[string]interface{}{
+						"_source": map[string]interface{}{"includes": []interface{}{"a"}},
+					},
+					target: "TestMapping.index_4",
+					result: `{"error":"type: parsing_exception, reason: [mappings] _source doesn't support option [includes]"}`,
+				},
+				wantErr: true,
+			},
 			{
 				name: "empty_body",
 				args: args{
@@ -183,6 +440,69 @@ func TestMapping(t *testing.T) {
 				},
 				wantErr: false,
 			},
+			{
+				name: "runtime",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"runtime":{"full_name":{"type":"keyword","script":{"source":"doc['first'].value + ' ' + doc['last'].value"}}}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "copy_to",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"copy_to":["FullName","Names"]`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "null_value",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"null_value":"NULL"`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "ignore_above",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"ignore_above":256`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "multi fields",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"Event.text":{"type":"text"`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "dynamic templates",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_1",
+					result: `"dynamic_templates":[{"strings_as_keyword":{"match_mapping_type":"string","mapping":{"type":"keyword"`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "source disabled",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestMapping.index_4",
+					result: `"_source":{"enabled":false}`,
+				},
+				wantErr: false,
+			},
 			{
 				name: "empty",
 				args: args{
@@ -0,0 +1,99 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id CloseIndex
+// @Summary Close indexes, the data is kept but reads and writes are rejected
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index  path  string  true  "Index"
+// @Success 200 {object} meta.CloseIndexResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_close [post]
+func Close(c *gin.Context) {
+	indexes, ok := targetIndexes(c)
+	if !ok {
+		return
+	}
+
+	resp := &meta.CloseIndexResponse{Acknowledged: true, ShardsAcknowledged: true, Indices: make(map[string]meta.CloseIndexResult, len(indexes))}
+	for _, index := range indexes {
+		if err := core.CloseIndex(index.GetName()); err != nil {
+			resp.Acknowledged = false
+			resp.ShardsAcknowledged = false
+			resp.Indices[index.GetName()] = meta.CloseIndexResult{Error: err.Error()}
+			continue
+		}
+		resp.Indices[index.GetName()] = meta.CloseIndexResult{Closed: true}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// @Id OpenIndex
+// @Summary Open closed indexes
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index  path  string  true  "Index"
+// @Success 200 {object} meta.OpenIndexResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_open [post]
+func Open(c *gin.Context) {
+	indexes, ok := targetIndexes(c)
+	if !ok {
+		return
+	}
+
+	for _, index := range indexes {
+		if err := core.OpenIndex(index.GetName()); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.OpenIndexResponse{Acknowledged: true, ShardsAcknowledged: true})
+}
+
+// targetIndexes returns the indexes matched by the target param,
+// renders 404 if an index named explicitly does not exist
+func targetIndexes(c *gin.Context) ([]*core.Index, bool) {
+	var names []string
+	if target := c.Param("target"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+	for _, name := range names {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return nil, false
+		}
+	}
+	return core.ZINC_INDEX_LIST.ListMatch(names), true
+}
@@ -0,0 +1,126 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestOpenClose(t *testing.T) {
+	indexName := "TestOpenClose.index_1"
+	type args struct {
+		code   int
+		params map[string]string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+		state   string
+	}{
+		{
+			name:    "close",
+			handler: Close,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				result: `{"acknowledged":true,"shards_acknowledged":true,"indices":{"TestOpenClose.index_1":{"closed":true}}}`,
+			},
+			state: meta.IndexStateClose,
+		},
+		{
+			name:    "open",
+			handler: Open,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				result: `{"acknowledged":true,"shards_acknowledged":true}`,
+			},
+			state: meta.IndexStateOpen,
+		},
+		{
+			name:    "close with wildcard",
+			handler: Close,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": "TestOpenClose.*"},
+				result: `"TestOpenClose.index_1":{"closed":true}`,
+			},
+			state: meta.IndexStateClose,
+		},
+		{
+			name:    "open with wildcard",
+			handler: Open,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": "TestOpenClose.*"},
+				result: `"acknowledged":true`,
+			},
+			state: meta.IndexStateOpen,
+		},
+		{
+			name:    "close not exists index",
+			handler: Close,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"target": "TestOpenClose.notExist"},
+				result: "does not exists",
+			},
+			state: meta.IndexStateOpen,
+		},
+		{
+			name:    "open not exists index",
+			handler: Open,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"target": "TestOpenClose.notExist"},
+				result: "does not exists",
+			},
+			state: meta.IndexStateOpen,
+		},
+	}
+
+	var index *core.Index
+	t.Run("prepare", func(t *testing.T) {
+		var err error
+		index, err = core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+			assert.Equal(t, tt.state, index.GetState())
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
@@ -0,0 +1,51 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id ResolveIndex
+// @Summary Resolve the names to the indices, aliases and data streams for compatible ES
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   name  path  string  true  "Comma separated names, support wildcard"
+// @Success 200 {object} meta.ResolveIndexResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_resolve/index/{name} [get]
+func ResolveIndex(c *gin.Context) {
+	var names []string
+	if target := c.Param("target"); target != "" {
+		names = strings.Split(target, ",")
+	}
+
+	resp, err := core.ResolveIndex(names)
+	if err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
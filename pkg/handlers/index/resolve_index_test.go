@@ -0,0 +1,45 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestResolveIndex(t *testing.T) {
+	_, closeFn := newIndex(t, "TestResolveIndex.index_1")
+	defer closeFn()
+
+	t.Run("resolve", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestResolveIndex.*"})
+		ResolveIndex(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"indices":[{"name":"TestResolveIndex.index_1","attributes":["open"]}],"aliases":[],"data_streams":[]}`, w.Body.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"target": "TestResolveIndex.index_2"})
+		ResolveIndex(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,73 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// @Id Rollover
+// @Summary Rollover the write index of an alias or a data stream to a new index
+// @security BasicAuth
+// @Tags    Index
+// @Accept  json
+// @Produce json
+// @Param   alias     path   string  true   "Alias"
+// @Param   new_index path   string  false  "New index name"
+// @Param   dry_run   query  bool    false  "Only check the conditions"
+// @Param   data      body   meta.RolloverRequest  false  "Rollover conditions"
+// @Success 200 {object} meta.RolloverResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/{alias}/_rollover/{new_index} [post]
+func Rollover(c *gin.Context) {
+	req := new(meta.RolloverRequest)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err = json.Unmarshal(body, req); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+	}
+
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "failed to parse [dry_run] with value [" + v + "]"})
+			return
+		}
+	}
+
+	resp, err := core.Rollover(c.Param("target"), c.Param("new_index"), req, dryRun)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
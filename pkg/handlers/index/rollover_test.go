@@ -0,0 +1,113 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package index
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestRollover(t *testing.T) {
+	alias := "TestRollover.alias"
+	oldName := "TestRollover.index-000001"
+	type args struct {
+		code   int
+		params map[string]string
+		query  map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "not alias",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": oldName},
+				result: "is not an alias",
+			},
+		},
+		{
+			name: "error request",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": alias},
+				data:   "xxx",
+				result: `"error":`,
+			},
+		},
+		{
+			name: "invalid dry_run",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": alias},
+				query:  map[string]string{"dry_run": "x"},
+				result: "dry_run",
+			},
+		},
+		{
+			name: "dry run",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": alias},
+				query:  map[string]string{"dry_run": "true"},
+				data:   `{"conditions":{"max_docs":1}}`,
+				result: `"new_index":"TestRollover.index-000002","rolled_over":false,"dry_run":true`,
+			},
+		},
+		{
+			name: "rollover with new index name",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": alias, "new_index": "TestRollover.custom"},
+				result: `"new_index":"TestRollover.custom","rolled_over":true`,
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(oldName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+		assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, []string{oldName}))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.args.data)
+			utils.SetGinRequestParams(c, tt.args.params)
+			if tt.args.query != nil {
+				utils.SetGinRequestURL(c, "/es/"+alias+"/_rollover", tt.args.query)
+			}
+			Rollover(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{oldName, "TestRollover.custom"}))
+		assert.NoError(t, core.DeleteIndex(oldName))
+		assert.NoError(t, core.DeleteIndex("TestRollover.custom"))
+	})
+}
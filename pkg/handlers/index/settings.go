@@ -83,6 +83,16 @@ func SetSettings(c *gin.Context) {
 		return
 	}
 
+	if err := core.ValidateSettings(settings); err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	if index, exists := core.GetIndex(indexName); exists {
+		setExistingSettings(c, index, settings)
+		return
+	}
+
 	analyzers, err := zincanalysis.RequestAnalyzer(settings.Analysis)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
@@ -99,22 +109,7 @@ func SetSettings(c *gin.Context) {
 		return
 	}
 	if exists {
-		// it can only change settings.NumberOfReplicas when index exists
-		if settings.NumberOfReplicas > 0 {
-			indexSettings := index.GetSettings()
-			atomic.StoreInt64(&indexSettings.NumberOfReplicas, settings.NumberOfReplicas)
-		}
-		if settings.Analysis != nil && len(settings.Analysis.Analyzer) > 0 {
-			c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "can't update analyzer for existing index"})
-			return
-		}
-		// store index
-		if err := core.StoreIndex(index); err != nil {
-			c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusOK, meta.HTTPResponse{Message: "ok"})
+		setExistingSettings(c, index, settings)
 		return
 	}
 
@@ -132,3 +127,32 @@ func SetSettings(c *gin.Context) {
 
 	c.JSON(http.StatusOK, meta.HTTPResponse{Message: "ok"})
 }
+
+// setExistingSettings updates the dynamic settings of the existing index, the analysis can only be added to
+func setExistingSettings(c *gin.Context, index *core.Index, settings *meta.IndexSettings) {
+	// it can only change settings.NumberOfReplicas when index exists
+	if settings.NumberOfReplicas > 0 {
+		indexSettings := index.GetSettings()
+		atomic.StoreInt64(&indexSettings.NumberOfReplicas, settings.NumberOfReplicas)
+	}
+	if err := index.UpdateAnalysis(settings.Analysis); err != nil {
+		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	// search, refresh, indexing and lifecycle settings are dynamic
+	if settings.Search != nil || settings.RefreshInterval != "" || settings.Indexing != nil || settings.Lifecycle != nil {
+		_ = index.SetSettings(&meta.IndexSettings{
+			Search:          settings.Search,
+			RefreshInterval: settings.RefreshInterval,
+			Indexing:        settings.Indexing,
+			Lifecycle:       settings.Lifecycle,
+		})
+	}
+	// store index
+	if err := core.StoreIndex(index); err != nil {
+		c.JSON(http.StatusInternalServerError, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, meta.HTTPResponse{Message: "ok"})
+}
@@ -63,6 +63,96 @@ func TestSettings(t *testing.T) {
 				},
 				wantErr: false,
 			},
+			{
+				name: "slowlog",
+				args: args{
+					code:    http.StatusOK,
+					rawData: `{"index.search.slowlog.threshold":"500ms"}`,
+					target:  "TestSettings.index_1",
+					result:  `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "invalid slowlog",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"index":{"search":{"slowlog":{"threshold":"abc"}}}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"error":"type: illegal_argument_exception, reason: invalid index.search.slowlog.threshold [abc]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "refresh interval",
+				args: args{
+					code:    http.StatusOK,
+					rawData: `{"index.refresh_interval":"-1"}`,
+					target:  "TestSettings.index_1",
+					result:  `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "invalid refresh interval",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"index":{"refresh_interval":"1 second"}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"error":"type: illegal_argument_exception, reason: invalid index.refresh_interval [1 second]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "indexing",
+				args: args{
+					code:    http.StatusOK,
+					rawData: `{"index.indexing.batch_size":2000,"index.indexing.batch_bytes":"64mb"}`,
+					target:  "TestSettings.index_1",
+					result:  `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "invalid indexing",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"index":{"indexing":{"buffer_size":"big"}}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"error":"type: illegal_argument_exception, reason: invalid index.indexing.buffer_size [big]"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "add analyzer",
+				args: args{
+					code:    http.StatusOK,
+					rawData: `{"analysis":{"analyzer":{"my_analyzer":{"tokenizer":"standard","filter":["lowercase"]}}}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"message":"ok"}`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "change analyzer",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"analysis":{"analyzer":{"my_analyzer":{"tokenizer":"whitespace"}}}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"error":"can't update the existing analyzer [my_analyzer] of index [TestSettings.index_1], it requires reindexing"}`,
+				},
+				wantErr: true,
+			},
+			{
+				name: "add analyzer with invalid pattern",
+				args: args{
+					code:    http.StatusBadRequest,
+					rawData: `{"analysis":{"tokenizer":{"my_pattern":{"type":"pattern","pattern":"a("}},"analyzer":{"my_pattern_analyzer":{"tokenizer":"my_pattern"}}}}`,
+					target:  "TestSettings.index_1",
+					result:  `{"error":"type: parsing_exception, reason: [tokenizer] pattern option [pattern] compile error: error parsing regexp: missing closing ): ` + "`a(`" + `"}`,
+				},
+				wantErr: true,
+			},
 			{
 				name: "with not exists index",
 				args: args{
@@ -149,7 +239,16 @@ func TestSettings(t *testing.T) {
 				args: args{
 					code:   http.StatusOK,
 					target: "TestSettings.index_1",
-					result: `{"settings":`,
+					result: `"refresh_interval":"-1"`,
+				},
+				wantErr: false,
+			},
+			{
+				name: "analysis",
+				args: args{
+					code:   http.StatusOK,
+					target: "TestSettings.index_1",
+					result: `"my_analyzer":{"tokenizer":"standard"`,
 				},
 				wantErr: false,
 			},
@@ -0,0 +1,116 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id IndexStats
+// @Summary Get index stats for elasticsearch
+// @security BasicAuth
+// @Tags    Index
+// @Produce json
+// @Param   index  path  string  false  "Index"
+// @Param   metric path  string  false  "Metric, one or more of docs,store,indexing,search,segments"
+// @Param   ignore_unavailable  query  bool    false  "Skip the missing and closed indexes named in the target"
+// @Param   allow_no_indices    query  bool    false  "A wildcard matching no index is not an error, default true"
+// @Param   expand_wildcards    query  string  false  "Comma separated states of the indexes the wildcards match: open, closed, hidden, all or none, default open"
+// @Success 200 {object} meta.IndexStatsResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/{index}/_stats/{metric} [get]
+func Stats(c *gin.Context) {
+	metrics, err := statsMetrics(c.Param("metric"))
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	opts, err := core.ParseIndicesOptions(c.Request.URL.Query())
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	var names []string
+	if target := c.Param("target"); target != "" && target != "_all" {
+		names = strings.Split(target, ",")
+	}
+	for _, name := range names {
+		if strings.Contains(name, "*") || opts.IgnoreUnavailable {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return
+		}
+	}
+	if names, err = core.ResolveIndices(names, opts); err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	indexes := make([]*core.Index, 0, len(names))
+	for _, name := range names {
+		if index, ok := core.GetIndex(name); ok {
+			indexes = append(indexes, index)
+		}
+	}
+
+	resp := &meta.IndexStatsResponse{
+		All: meta.IndexStatsGroup{
+			Primaries: meta.NewIndexStats(metrics),
+			Total:     meta.NewIndexStats(metrics),
+		},
+		Indices: make(map[string]*meta.IndexStatsGroup, len(indexes)),
+	}
+	for _, index := range indexes {
+		stats := index.Stats(metrics...)
+		resp.Shards.Total += index.GetAllShardNum()
+		resp.All.Primaries.Add(stats)
+		resp.All.Total.Add(stats)
+		resp.Indices[index.GetName()] = &meta.IndexStatsGroup{
+			Primaries: stats,
+			Total:     stats,
+		}
+	}
+	resp.Shards.Successful = resp.Shards.Total
+
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// statsMetrics parses the comma separated metrics, empty or `_all` means all metrics
+func statsMetrics(metric string) ([]string, error) {
+	if metric == "" || metric == "_all" {
+		return core.IndexStatsMetrics, nil
+	}
+	metrics := strings.Split(metric, ",")
+	for _, m := range metrics {
+		if !zutils.SliceExists(core.IndexStatsMetrics, m) {
+			return nil, fmt.Errorf("request [_stats] contains unrecognized metric: [%s]", m)
+		}
+	}
+	return metrics, nil
+}
@@ -0,0 +1,126 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package index
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestStats(t *testing.T) {
+	indexName := "TestStats.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NotNil(t, index)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+
+		err = index.CreateDocument("1", map[string]interface{}{"name": "zinc"}, false)
+		assert.NoError(t, err)
+		err = index.CreateDocument("2", map[string]interface{}{"name": "search"}, false)
+		assert.NoError(t, err)
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	type args struct {
+		code   int
+		params map[string]string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		check func(t *testing.T, resp *meta.IndexStatsResponse)
+	}{
+		{
+			name: "all metrics",
+			args: args{code: http.StatusOK, params: map[string]string{"target": indexName}},
+			check: func(t *testing.T, resp *meta.IndexStatsResponse) {
+				assert.Len(t, resp.Indices, 1)
+				stats := resp.Indices[indexName].Primaries
+				assert.Equal(t, uint64(2), stats.Docs.Count)
+				assert.Equal(t, uint64(2), stats.Indexing.IndexTotal)
+				assert.Greater(t, stats.Store.SizeInBytes, uint64(0))
+				assert.NotNil(t, stats.Search)
+				assert.NotNil(t, stats.Segments)
+				assert.Equal(t, uint64(2), resp.All.Total.Docs.Count)
+			},
+		},
+		{
+			name: "metric filter",
+			args: args{code: http.StatusOK, params: map[string]string{"target": indexName, "metric": "docs,search"}},
+			check: func(t *testing.T, resp *meta.IndexStatsResponse) {
+				stats := resp.Indices[indexName].Primaries
+				assert.NotNil(t, stats.Docs)
+				assert.NotNil(t, stats.Search)
+				assert.Nil(t, stats.Store)
+				assert.Nil(t, stats.Indexing)
+				assert.Nil(t, stats.Segments)
+			},
+		},
+		{
+			name: "wildcard",
+			args: args{code: http.StatusOK, params: map[string]string{"target": "TestStats.*"}},
+			check: func(t *testing.T, resp *meta.IndexStatsResponse) {
+				assert.Contains(t, resp.Indices, indexName)
+			},
+		},
+		{
+			name: "all indices",
+			args: args{code: http.StatusOK, params: map[string]string{}},
+			check: func(t *testing.T, resp *meta.IndexStatsResponse) {
+				assert.Contains(t, resp.Indices, indexName)
+			},
+		},
+		{
+			name: "unknown metric",
+			args: args{code: http.StatusBadRequest, params: map[string]string{"target": indexName, "metric": "docs,fielddata"}},
+		},
+		{
+			name: "not exists index",
+			args: args{code: http.StatusNotFound, params: map[string]string{"target": "TestStats.index_2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			Stats(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			if tt.check == nil {
+				return
+			}
+			resp := new(meta.IndexStatsResponse)
+			err := json.Unmarshal(w.Body.Bytes(), resp)
+			assert.NoError(t, err)
+			tt.check(t, resp)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,220 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+const (
+	// asyncSearchKeepAlive is how long the async searches are kept by default
+	asyncSearchKeepAlive = 5 * 24 * time.Hour
+	// asyncSearchWaitTimeout is how long the submit waits for the search to complete by default
+	asyncSearchWaitTimeout = time.Second
+)
+
+// SubmitAsyncSearch runs the search in the background, the response is returned directly
+// if the search completes within wait_for_completion_timeout
+//
+// @Id SubmitAsyncSearch
+// @Summary Submit an async search for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   index                        path   string  true   "Index"
+// @Param   query                        body   meta.ZincQueryForSDK true  "Query"
+// @Param   wait_for_completion_timeout  query  string  false  "Time to wait for the search to complete, default 1s"
+// @Param   keep_on_completion           query  bool    false  "Keep the search if it completes within the wait, default false"
+// @Param   keep_alive                   query  string  false  "Time the search is kept, default 5d"
+// @Param   ignore_unavailable           query  bool    false  "Skip the missing and closed indexes named in the target"
+// @Param   allow_no_indices             query  bool    false  "A wildcard matching no index is not an error, default true"
+// @Param   expand_wildcards             query  string  false  "Comma separated states of the indexes the wildcards match: open, closed, hidden, all or none, default open"
+// @Success 200 {object} meta.AsyncSearchResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/{index}/_async_search [post]
+func SubmitAsyncSearch(c *gin.Context) {
+	var indexNames []string
+	if target := c.Param("target"); target != "" {
+		indexNames = strings.Split(target, ",")
+	}
+
+	wait, err := asyncSearchDuration(c, "wait_for_completion_timeout", asyncSearchWaitTimeout)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	keepAlive, err := asyncSearchDuration(c, "keep_alive", asyncSearchKeepAlive)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	keepOnCompletion := false
+	if v := c.Query("keep_on_completion"); v != "" {
+		if keepOnCompletion, err = strconv.ParseBool(v); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "failed to parse [keep_on_completion] with value [" + v + "]"})
+			return
+		}
+	}
+
+	query := &meta.ZincQuery{Size: 10}
+	if err := zutils.GinBindJSON(c, query); err != nil {
+		log.Printf("handlers.search.SubmitAsyncSearch: %s", err.Error())
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	query.Privileges = auth.GetContextPrivileges(c)
+	query.Preference = c.Query("preference")
+	query.Routing = core.SplitRouting(c.Query("routing"))
+	if err := restrictAliases(contextAliases(c), query); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	opts, err := core.ParseIndicesOptions(c.Request.URL.Query())
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	resolved, err := core.ResolveIndices(indexNames, opts)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	var shards int64
+	for _, name := range resolved {
+		index, ok := core.GetIndex(name)
+		if !ok && len(resolved) == 1 {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return
+		}
+		if ok {
+			shards += index.GetAllShardNum()
+		}
+	}
+
+	s := core.ZINC_ASYNC_SEARCHES.Submit(requestOwner(c), shards, keepAlive, func(ctx context.Context) (*meta.SearchResponse, error) {
+		query.Context = ctx
+		return searchIndex(indexNames, opts, query)
+	})
+	completed := s.Wait(wait)
+	resp := s.Response()
+	if completed && !keepOnCompletion {
+		core.ZINC_ASYNC_SEARCHES.Delete(s.ID)
+		resp.ID = ""
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// GetAsyncSearch returns the progress or the response of an async search
+//
+// @Id GetAsyncSearch
+// @Summary Get an async search for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Produce json
+// @Param   id                           path   string  true   "ID"
+// @Param   wait_for_completion_timeout  query  string  false  "Time to wait for the search to complete"
+// @Param   keep_alive                   query  string  false  "Extends the expiration of the search"
+// @Success 200 {object} meta.AsyncSearchResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_async_search/{id} [get]
+func GetAsyncSearch(c *gin.Context) {
+	wait, err := asyncSearchDuration(c, "wait_for_completion_timeout", 0)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	keepAlive, err := asyncSearchDuration(c, "keep_alive", 0)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	s, ok := getAsyncSearch(c, keepAlive)
+	if !ok {
+		return
+	}
+	s.Wait(wait)
+	zutils.GinRenderJSON(c, http.StatusOK, s.Response())
+}
+
+// DeleteAsyncSearch cancels the async search if it is running and deletes it
+//
+// @Id DeleteAsyncSearch
+// @Summary Delete an async search for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Produce json
+// @Param   id  path  string  true  "ID"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_async_search/{id} [delete]
+func DeleteAsyncSearch(c *gin.Context) {
+	s, ok := getAsyncSearch(c, 0)
+	if !ok {
+		return
+	}
+	core.ZINC_ASYNC_SEARCHES.Delete(s.ID)
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// getAsyncSearch returns the async search of the id, only its owner can access it
+func getAsyncSearch(c *gin.Context, keepAlive time.Duration) (*core.AsyncSearch, bool) {
+	id := c.Param("id")
+	s, ok := core.ZINC_ASYNC_SEARCHES.Get(id, 0)
+	if !ok || s.Owner != requestOwner(c) {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "async search " + id + " does not exists"})
+		return nil, false
+	}
+	if keepAlive > 0 {
+		s, ok = core.ZINC_ASYNC_SEARCHES.Get(id, keepAlive)
+	}
+	return s, ok
+}
+
+// requestOwner returns the user of the request, the async searches and the tasks belong to it
+func requestOwner(c *gin.Context) string {
+	if user, ok := auth.GetContextUser(c); ok {
+		return user.ID
+	}
+	return ""
+}
+
+func asyncSearchDuration(c *gin.Context, name string, defaultValue time.Duration) (time.Duration, error) {
+	v := c.Query(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := zutils.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, errors.New(errors.ErrorTypeInvalidArgument, "failed to parse ["+name+"] with value ["+v+"]")
+	}
+	return d, nil
+}
@@ -0,0 +1,116 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestAsyncSearch(t *testing.T) {
+	indexName := "TestAsyncSearch.index_1"
+	var id string
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+		err = index.CreateDocument("1", map[string]interface{}{"title": "zinc search"}, false)
+		assert.NoError(t, err)
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	t.Run("submit completed", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"query":{"match":{"title":"zinc"}}}`)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		utils.SetGinRequestURL(c, "/es/"+indexName+"/_async_search", map[string]string{"wait_for_completion_timeout": "10s"})
+		SubmitAsyncSearch(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := new(meta.AsyncSearchResponse)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		assert.Empty(t, resp.ID)
+		assert.False(t, resp.IsRunning)
+		assert.False(t, resp.IsPartial)
+		assert.Equal(t, 1, resp.Response.Hits.Total.Value)
+	})
+
+	t.Run("submit kept", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"query":{"match_all":{}}}`)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		utils.SetGinRequestURL(c, "/es/"+indexName+"/_async_search", map[string]string{"wait_for_completion_timeout": "10s", "keep_on_completion": "true"})
+		SubmitAsyncSearch(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := new(meta.AsyncSearchResponse)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		assert.NotEmpty(t, resp.ID)
+		id = resp.ID
+	})
+
+	t.Run("get", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"id": id})
+		utils.SetGinRequestURL(c, "/es/_async_search/"+id, map[string]string{"keep_alive": "1m"})
+		GetAsyncSearch(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"is_running":false`)
+		assert.Contains(t, w.Body.String(), `"_id":"1"`)
+	})
+
+	t.Run("invalid parameter", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"id": id})
+		utils.SetGinRequestURL(c, "/es/_async_search/"+id, map[string]string{"keep_alive": "soon"})
+		GetAsyncSearch(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"id": id})
+		DeleteAsyncSearch(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		c, w = utils.NewGinContext()
+		utils.SetGinRequestParams(c, map[string]string{"id": id})
+		GetAsyncSearch(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("index not found", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"query":{"match_all":{}}}`)
+		utils.SetGinRequestParams(c, map[string]string{"target": "NotExist" + indexName})
+		SubmitAsyncSearch(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -2,11 +2,13 @@ package search
 
 import (
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
@@ -21,52 +23,108 @@ import (
 // @Tags    Search
 // @Accept  json
 // @Produce json
-// @Param   index  path  string  true  "Index"
+// @Param   index                path   string  true   "Index"
+// @Param   conflicts            query  string  false  "What to do on version conflicts: abort or proceed"
+// @Param   scroll_size          query  int     false  "Number of documents of one batch, default is 1000"
+// @Param   max_docs             query  int     false  "Max number of documents to delete"
+// @Param   refresh              query  bool    false  "Refresh the index after the deletes"
+// @Param   wait_for_completion  query  bool    false  "Wait for the delete to complete, default is true"
 // @Param   query  body  meta.ZincQueryForSDK true  "Query"
 // @Success 200 {object} meta.HTTPResponseDeleteByQuery
 // @Failure 400 {object} meta.HTTPResponseError
 // @Router /es/{index}/_delete_by_query [post]
 func DeleteByQuery(c *gin.Context) {
-	start := time.Now()
-	query := &meta.ZincQuery{Size: 10}
-	if err := zutils.GinBindJSON(c, query); err != nil {
-		log.Printf("handlers.search.searchDSL: %s", err.Error())
+	req := &meta.DeleteByQueryRequest{Conflicts: c.Query("conflicts")}
+	var err error
+	if req.ScrollSize, err = queryInt(c, "scroll_size"); err != nil {
 		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
-
-	indexName := c.Param("target")
-	resp, err := searchIndex([]string{indexName}, query)
+	if req.MaxDocs, err = queryInt(c, "max_docs"); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if req.Refresh, err = queryBool(c, "refresh", false); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	wait, err := queryBool(c, "wait_for_completion", true)
 	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	req.Query = new(meta.ZincQuery)
+	if err := zutils.GinBindJSON(c, req.Query); err != nil {
+		log.Printf("handlers.search.DeleteByQuery: %s", err.Error())
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	req.Query.Privileges = auth.GetContextPrivileges(c)
+	if err := restrictAliases(contextAliases(c), req.Query); err != nil {
 		errors.HandleError(c, err)
 		return
 	}
 
-	failures := []string{}
-	for _, hit := range resp.Hits.Hits {
-		index, _ := core.GetIndex(hit.Index)
-		err := index.DeleteDocument(hit.ID)
-		if err != nil {
-			failures = append(failures, hit.ID)
+	indexNames := strings.Split(c.Param("target"), ",")
+	for _, name := range indexNames {
+		if strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := core.GetIndex(name); !ok {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "index " + name + " does not exists"})
+			return
 		}
 	}
+	d, err := core.NewDeleteByQuery(core.ZINC_INDEX_LIST.ListMatch(indexNames), req)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
 
-	totalDeletes := resp.Hits.Total.Value - len(failures)
-	zutils.GinRenderJSON(c, http.StatusOK, meta.HTTPResponseDeleteByQuery{
-		Took:             time.Since(start).Milliseconds(),
-		TimedOut:         false,
-		Total:            totalDeletes,
-		Deleted:          totalDeletes,
-		Batches:          0,
-		VersionConflicts: 0,
-		Noops:            0,
-		Failures:         failures,
-		Retries: meta.HttpRetriesResponse{
-			Bulk:   0,
-			Search: 0,
-		},
-		ThrottledMillis:      0,
-		RequestsPerSecond:    -1,
-		ThrottledUntilMillis: 0,
-	})
+	if !wait {
+		task := core.ZINC_TASKS.Submit(requestOwner(c), core.TaskActionDeleteByQuery, func() interface{} {
+			return d.Status()
+		}, func() (interface{}, error) {
+			return d.Run()
+		})
+		zutils.GinRenderJSON(c, http.StatusOK, meta.TaskSubmitResponse{Task: task.ID})
+		return
+	}
+
+	resp, err := d.Run()
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// queryInt returns the int value of the query parameter, 0 without it
+func queryInt(c *gin.Context, name string) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.New(errors.ErrorTypeIllegalArgumentException, "failed to parse ["+name+"] with value ["+v+"]")
+	}
+	return n, nil
+}
+
+// queryBool returns the bool value of the query parameter, the parameter without a value is true like ?refresh
+func queryBool(c *gin.Context, name string, defaultValue bool) (bool, error) {
+	v, ok := c.GetQuery(name)
+	if !ok {
+		return defaultValue, nil
+	}
+	if v == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New(errors.ErrorTypeIllegalArgumentException, "failed to parse ["+name+"] with value ["+v+"]")
+	}
+	return b, nil
 }
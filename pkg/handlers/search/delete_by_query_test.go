@@ -1,6 +1,7 @@
 package search
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -63,7 +64,7 @@ func TestDeleteByQuery(t *testing.T) {
 					outcome:    true,
 					statusCode: 200,
 					body: body{
-						contains: `"time_out":false,"total":1,"deleted":1,"batches":0,"version_conflicts":0,"noops":0,"failures":[],"retries":{"bulk":0,"search":0},"throttled_millis":0,"requests_per_second":-1,"throttled_until_millis":0}`,
+						contains: `"time_out":false,"total":1,"deleted":1,"batches":1,"version_conflicts":0,"noops":0,"failures":[],"retries":{"bulk":0,"search":0},"throttled_millis":0,"requests_per_second":-1,"throttled_until_millis":0}`,
 					},
 				},
 			},
@@ -136,6 +137,50 @@ func TestDeleteByQuery(t *testing.T) {
 	}
 }
 
+func TestDeleteByQueryTask(t *testing.T) {
+	indexName := "TestDeleteByQueryTask.index"
+	index, err := core.NewIndex(indexName, "disk", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, core.StoreIndex(index))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, index.CreateDocument(ider.Generate(), map[string]interface{}{"name": "zinc"}, false))
+	}
+	assert.NoError(t, index.Flush())
+
+	c, w := utils.NewGinContext()
+	utils.SetGinRequestData(c, `{"query":{"match":{"name":"zinc"}}}`)
+	utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+	utils.SetGinRequestURL(c, "/es/"+indexName+"/_delete_by_query", map[string]string{"wait_for_completion": "false", "refresh": "true"})
+	DeleteByQuery(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	submit := new(meta.TaskSubmitResponse)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), submit))
+	assert.NotEmpty(t, submit.Task)
+
+	c, w = utils.NewGinContext()
+	utils.SetGinRequestParams(c, map[string]string{"id": submit.Task})
+	utils.SetGinRequestURL(c, "/es/_tasks/"+submit.Task, map[string]string{"wait_for_completion": "true", "timeout": "10s"})
+	GetTask(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"completed":true`)
+	assert.Contains(t, w.Body.String(), `"deleted":3`)
+	assertZeruResultQuery(t, index, `{"query":{"match":{"name":"zinc"}}}`)
+
+	c, w = utils.NewGinContext()
+	utils.SetGinRequestParams(c, map[string]string{"id": "1:unknown"})
+	GetTask(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	c, w = utils.NewGinContext()
+	utils.SetGinRequestData(c, `{}`)
+	utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+	utils.SetGinRequestURL(c, "/es/"+indexName+"/_delete_by_query", map[string]string{"conflicts": "ignore"})
+	DeleteByQuery(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.NoError(t, core.DeleteIndex(indexName))
+}
+
 func assertHTTPResponse(t *testing.T, w *httptest.ResponseRecorder, statusCode int, body body) {
 	assert.Equal(t, w.Code, statusCode)
 	if body.is != "" {
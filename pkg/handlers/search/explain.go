@@ -0,0 +1,78 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// Explain computes the score explanation of a document for the query
+//
+// @Id Explain
+// @Summary Explain the score of a document for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   index  path  string  true  "Index"
+// @Param   id     path  string  true  "ID"
+// @Param   query  body  meta.ZincQueryForSDK true  "Query"
+// @Success 200 {object} meta.ExplainResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.ExplainResponse
+// @Router /es/{index}/_explain/{id} [post]
+func Explain(c *gin.Context) {
+	indexName := c.Param("target")
+	docID := c.Param("id")
+	if docID == "" {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "id is empty"})
+		return
+	}
+
+	query := new(meta.ZincQuery)
+	if err := zutils.GinBindJSON(c, query); err != nil {
+		log.Printf("handlers.search.Explain: %s", err.Error())
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	query.Privileges = auth.GetContextPrivileges(c)
+
+	index, exists := core.GetIndex(indexName)
+	if !exists {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "index " + indexName + " does not exists"})
+		return
+	}
+
+	resp, err := index.Explain(docID, query)
+	if err != nil {
+		if errors.Is(err, errors.ErrorIDNotFound) {
+			zutils.GinRenderJSON(c, http.StatusNotFound, meta.ExplainResponse{Index: indexName, ID: docID})
+			return
+		}
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
@@ -0,0 +1,115 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestExplain(t *testing.T) {
+	indexName := "TestExplain.index_1"
+	type args struct {
+		code   int
+		data   string
+		params map[string]string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "matched",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"match":{"title":"zinc"}}}`,
+				params: map[string]string{"target": indexName, "id": "1"},
+				result: `"matched":true`,
+			},
+		},
+		{
+			name: "not matched",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"match":{"title":"elastic"}}}`,
+				params: map[string]string{"target": indexName, "id": "1"},
+				result: `"matched":false`,
+			},
+		},
+		{
+			name: "document not found",
+			args: args{
+				code:   http.StatusNotFound,
+				data:   `{"query":{"match_all":{}}}`,
+				params: map[string]string{"target": indexName, "id": "2"},
+				result: `"matched":false`,
+			},
+		},
+		{
+			name: "index not found",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"match_all":{}}}`,
+				params: map[string]string{"target": "NotExist" + indexName, "id": "1"},
+				result: "does not exists",
+			},
+		},
+		{
+			name: "query error",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"script_score":{"query":{"match_all":{}},"script":"_score *"}}}`,
+				params: map[string]string{"target": indexName, "id": "1"},
+				result: "compile error",
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NotNil(t, index)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+		err = index.CreateDocument("1", map[string]interface{}{"title": "zinc search"}, false)
+		assert.NoError(t, err)
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.args.data)
+			utils.SetGinRequestParams(c, tt.args.params)
+			Explain(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package search
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id PutScript
+// @Summary Create or update a stored script
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   id    path  string                    true  "Script ID"
+// @Param   data  body  meta.StoredScriptRequest  true  "Script"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_scripts/{id} [put]
+func PutScript(c *gin.Context) {
+	req := new(meta.StoredScriptRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := core.PutStoredScript(c.Param("id"), req.Script); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id GetScript
+// @Summary Get a stored script
+// @security BasicAuth
+// @Tags    Search
+// @Produce json
+// @Param   id  path  string  true  "Script ID"
+// @Success 200 {object} meta.StoredScriptResponse
+// @Failure 404 {object} meta.StoredScriptResponse
+// @Router /es/_scripts/{id} [get]
+func GetScript(c *gin.Context) {
+	id := c.Param("id")
+	script, ok, err := core.GetStoredScript(id)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.StoredScriptResponse{ID: id})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.StoredScriptResponse{ID: id, Found: true, Script: &script.Script})
+}
+
+// @Id DeleteScript
+// @Summary Delete a stored script
+// @security BasicAuth
+// @Tags    Search
+// @Produce json
+// @Param   id  path  string  true  "Script ID"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_scripts/{id} [delete]
+func DeleteScript(c *gin.Context) {
+	id := c.Param("id")
+	ok, err := core.DeleteStoredScript(id)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "script " + id + " does not exists"})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
@@ -0,0 +1,173 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package search
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestStoredScript(t *testing.T) {
+	indexName := "TestStoredScript.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+		index.GetMappings().SetProperty("views", meta.NewProperty("numeric"))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"views": 10.0}, false))
+		assert.NoError(t, index.Flush())
+	})
+
+	type args struct {
+		code   int
+		params map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+	}{
+		{
+			name:    "put script",
+			handler: PutScript,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_score"},
+				data:   `{"script":{"lang":"painless","source":"doc['views'].value * params.factor","params":{"factor":1}}}`,
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "put invalid script",
+			handler: PutScript,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"id": "my_invalid"},
+				data:   `{"script":{"source":"doc['views'].value *"}}`,
+				result: "compile error",
+			},
+		},
+		{
+			name:    "put script with invalid lang",
+			handler: PutScript,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"id": "my_invalid"},
+				data:   `{"script":{"lang":"groovy","source":"1"}}`,
+				result: "unsupported lang [groovy]",
+			},
+		},
+		{
+			name:    "put script without source",
+			handler: PutScript,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"id": "my_invalid"},
+				data:   `{}`,
+				result: "source should be not empty",
+			},
+		},
+		{
+			name:    "get script",
+			handler: GetScript,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_score"},
+				result: `{"_id":"my_score","found":true,"script":{"source":"doc['views'].value * params.factor","lang":"painless","params":{"factor":1}}}`,
+			},
+		},
+		{
+			name:    "get not exists script",
+			handler: GetScript,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"id": "my_invalid"},
+				result: `{"_id":"my_invalid","found":false}`,
+			},
+		},
+		{
+			name:    "search with stored script",
+			handler: SearchDSL,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				data:   `{"query":{"script_score":{"query":{"match_all":{}},"script":{"id":"my_score","params":{"factor":2}}}}}`,
+				result: `"_score":20`,
+			},
+		},
+		{
+			name:    "search with stored script default params",
+			handler: SearchDSL,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				data:   `{"query":{"script_score":{"query":{"match_all":{}},"script":{"id":"my_score"}}}}`,
+				result: `"_score":10`,
+			},
+		},
+		{
+			name:    "search with not exists script",
+			handler: SearchDSL,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": indexName},
+				data:   `{"query":{"script_score":{"query":{"match_all":{}},"script":{"id":"my_invalid"}}}}`,
+				result: "unable to find script [my_invalid]",
+			},
+		},
+		{
+			name:    "delete script",
+			handler: DeleteScript,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"id": "my_score"},
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "delete not exists script",
+			handler: DeleteScript,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"id": "my_score"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestData(c, tt.args.data)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteIndex(indexName))
+	})
+}
@@ -20,7 +20,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	v1 "github.com/zincsearch/zincsearch/pkg/meta/v1"
 	"github.com/zincsearch/zincsearch/pkg/uquery"
@@ -62,9 +64,12 @@ func SearchV1(c *gin.Context) {
 		return
 	}
 
+	newQuery.Privileges = auth.GetContextPrivileges(c)
+	newQuery.Context = c.Request.Context()
+
 	resp, err := index.Search(newQuery)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		c.JSON(errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
 
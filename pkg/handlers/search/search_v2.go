@@ -17,17 +17,23 @@ package search
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/core"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/security"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
@@ -40,8 +46,14 @@ import (
 // @Tags    Search
 // @Accept  json
 // @Produce json
-// @Param   index  path  string  true  "Index"
-// @Param   query  body  meta.ZincQueryForSDK true  "Query"
+// @Param   index       path   string  true   "Index"
+// @Param   query       body   meta.ZincQueryForSDK true  "Query"
+// @Param   preference  query  string  false  "Pins the searches with the same preference to a snapshot of the index for ZINC_PREFERENCE_TTL"
+// @Param   routing     query  string  false  "Comma separated routing values, only the shards of the routing values are searched"
+// @Param   stored_fields  query  string  false  "Comma separated stored fields to return, overridden by the stored_fields of the body"
+// @Param   ignore_unavailable  query  bool    false  "Skip the missing and closed indexes named in the target"
+// @Param   allow_no_indices    query  bool    false  "A wildcard matching no index is not an error, default true"
+// @Param   expand_wildcards    query  string  false  "Comma separated states of the indexes the wildcards match: open, closed, hidden, all or none, default open"
 // @Success 200 {object} meta.SearchResponse
 // @Failure 400 {object} meta.HTTPResponseError
 // @Router /es/{index}/_search [post]
@@ -54,8 +66,25 @@ func SearchDSL(c *gin.Context) {
 		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
 		return
 	}
+	query.Privileges = auth.GetContextPrivileges(c)
+	query.Preference = c.Query("preference")
+	query.Routing = core.SplitRouting(c.Query("routing"))
+	if v, ok := c.GetQuery("stored_fields"); ok && query.StoredFields == nil {
+		query.StoredFields = v
+	}
+	// the search is cancelled when the client goes away
+	query.Context = c.Request.Context()
+	if err := restrictAliases(contextAliases(c), query); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	opts, err := core.ParseIndicesOptions(c.Request.URL.Query())
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
 
-	resp, err := searchIndex(strings.Split(indexName, ","), query)
+	resp, err := searchIndex(strings.Split(indexName, ","), opts, query)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
@@ -79,7 +108,9 @@ func SearchDSL(c *gin.Context) {
 	zutils.GinRenderJSON(c, http.StatusOK, resp)
 }
 
-// MultipleSearch like bulk searches
+// MultipleSearch like bulk searches, every header line is followed by its query line.
+// The searches run concurrently and the responses keep the order of the requests,
+// a malformed line fails only its own search
 //
 // @Id MSearch
 // @Summary Search V2 MultipleSearch for compatible ES
@@ -87,89 +118,251 @@ func SearchDSL(c *gin.Context) {
 // @Tags    Search
 // @Accept  plain
 // @Produce json
-// @Param   query  body  string  true  "Query"
+// @Param   query                    body   string  true   "Query"
+// @Param   max_concurrent_searches  query  int     false  "Searches running at once"
+// @Param   ignore_unavailable       query  bool    false  "Skip the missing and closed indexes named in the targets, overridden by the headers"
+// @Param   allow_no_indices         query  bool    false  "A wildcard matching no index is not an error, default true, overridden by the headers"
+// @Param   expand_wildcards         query  string  false  "Comma separated states of the indexes the wildcards match, default open, overridden by the headers"
 // @Success 200 {object} meta.SearchResponse
 // @Failure 400 {object} meta.HTTPResponseError
 // @Router /es/_msearch [post]
 func MultipleSearch(c *gin.Context) {
 	indexName := c.Param("target")
 	defaultIndexNames := make([]string, 0)
-	if indexName != "" {
+	if aliases := contextAliases(c); aliases != nil {
+		// the aliases are resolved along with the aliases of the header lines for their filters
+		defaultIndexNames = aliases
+	} else if indexName != "" {
 		defaultIndexNames = strings.Split(indexName, ",")
 	}
 
-	responses := make([]interface{}, 0)
+	concurrency := config.Global.MsearchMaxConcurrency
+	if v := c.Query("max_concurrent_searches"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "max_concurrent_searches should be a positive integer"})
+			return
+		}
+		concurrency = n
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	opts, err := core.ParseIndicesOptions(c.Request.URL.Query())
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
-	// Prepare to read the entire raw text of the body
-	scanner := bufio.NewScanner(c.Request.Body)
 	defer c.Request.Body.Close()
+	requests, err := parseMultipleSearch(c.Request.Body, defaultIndexNames, opts)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
 
-	maxCapacityPerLine := config.Global.MaxDocumentSize
-	buf := make([]byte, maxCapacityPerLine)
-	scanner.Buffer(buf, maxCapacityPerLine)
-
-	indexNames := make([]string, 0)
-	nextLineIsData := false
-
-	var doc map[string]interface{}
-	var err error
-	for scanner.Scan() { // Read each line
-		if nextLineIsData {
-			nextLineIsData = false
-			query := &meta.ZincQuery{Size: 10}
-			if err = json.Unmarshal(scanner.Bytes(), &query); err != nil {
-				log.Error().Msgf("handlers.search.MultipleSearch.json.Unmarshal: %s, err %s", scanner.Text(), err.Error())
-				responses = append(responses, &meta.SearchResponse{Error: err.Error()})
-				continue
-			}
-			// search query
-			resp, err := searchIndex(indexNames, query)
+	privileges := auth.GetContextPrivileges(c)
+	responses := make([]*meta.SearchResponse, len(requests))
+	eg := errgroup.Group{}
+	eg.SetLimit(concurrency)
+	for i, req := range requests {
+		i, req := i, req
+		if req.err == nil {
+			// the filters of the aliases are looked up before the authorization resolves the aliases
+			req.err = restrictAliases(req.indexNames, req.query)
+		}
+		if req.err == nil {
+			req.indexNames, req.err = auth.AuthorizeContextIndexNames(c, req.indexNames, core.ResolveIndexName)
+		}
+		if req.err != nil {
+			responses[i] = multipleSearchError(req.err)
+			continue
+		}
+		req.query.Privileges = privileges
+		req.query.Preference = req.preference
+		req.query.Routing = core.SplitRouting(req.routing)
+		req.query.Context = c.Request.Context()
+		eg.Go(func() error {
+			resp, err := searchIndex(core.ResolveIndexNames(req.indexNames), req.options, req.query)
 			if err != nil {
 				log.Error().Msgf("handlers.search.MultipleSearch.searchIndex: err %s", err.Error())
-				responses = append(responses, &meta.SearchResponse{Error: err.Error()})
-			} else {
-				responses = append(responses, resp)
+				responses[i] = multipleSearchError(err)
+				return nil
+			}
+			resp.Status = http.StatusOK
+			responses[i] = resp
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"responses": responses})
+}
+
+// multipleSearchRequest is a search of _msearch, err is set when its lines are malformed
+type multipleSearchRequest struct {
+	indexNames []string
+	options    *meta.IndicesOptions
+	preference string
+	routing    string
+	query      *meta.ZincQuery
+	err        error
+}
+
+// multipleSearchHeader is the header line of a search
+type multipleSearchHeader struct {
+	Index             interface{} `json:"index"`
+	Preference        string      `json:"preference"`
+	Routing           string      `json:"routing"`
+	IgnoreUnavailable *bool       `json:"ignore_unavailable"`
+	AllowNoIndices    *bool       `json:"allow_no_indices"`
+	ExpandWildcards   string      `json:"expand_wildcards"`
+}
+
+// parseMultipleSearch reads the header and query line pairs, a blank header line is an empty header.
+// The options of the request are the defaults of the options of the headers.
+func parseMultipleSearch(body io.Reader, defaultIndexNames []string, defaultOptions *meta.IndicesOptions) ([]*multipleSearchRequest, error) {
+	scanner := bufio.NewScanner(body)
+	maxCapacityPerLine := config.Global.MaxDocumentSize
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCapacityPerLine)
+
+	requests := make([]*multipleSearchRequest, 0)
+	var req *multipleSearchRequest
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if req == nil {
+			req = &multipleSearchRequest{indexNames: defaultIndexNames, options: defaultOptions}
+			if len(line) == 0 {
+				continue
 			}
-		} else {
-			nextLineIsData = true
-			indexNames = indexNames[:0]
-			if err = json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			header := new(multipleSearchHeader)
+			if err := json.Unmarshal(line, header); err != nil {
 				log.Error().Msgf("handlers.search.MultipleSearch.json.Unmarshal: %s, err %s", scanner.Text(), err.Error())
+				req.err = errors.New(errors.ErrorTypeParsingException, "malformed header line: "+err.Error())
+				continue
+			}
+			req.preference = header.Preference
+			req.routing = header.Routing
+			if req.options, req.err = multipleSearchOptions(header, defaultOptions); req.err != nil {
 				continue
 			}
-			if v, ok := doc["index"]; ok {
-				switch v := v.(type) {
-				case string:
-					indexNames = append(indexNames, v)
-				case []interface{}:
-					for _, v := range v {
-						indexNames = append(indexNames, v.(string))
-					}
+			if header.Index != nil {
+				if req.indexNames, req.err = multipleSearchIndexNames(header.Index); req.err != nil {
+					continue
 				}
-			} else {
-				indexNames = append(indexNames, defaultIndexNames...)
 			}
+			continue
 		}
+
+		if req.err == nil {
+			req.query = &meta.ZincQuery{Size: 10}
+			if err := json.Unmarshal(line, req.query); err != nil {
+				log.Error().Msgf("handlers.search.MultipleSearch.json.Unmarshal: %s, err %s", scanner.Text(), err.Error())
+				req.err = errors.New(errors.ErrorTypeParsingException, "malformed query line: "+err.Error())
+			}
+		}
+		requests = append(requests, req)
+		req = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
+	if req != nil {
+		req.err = errors.New(errors.ErrorTypeParsingException, "header line without query line")
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
 
-	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"responses": responses})
+// multipleSearchOptions returns the options of the header, the options it doesn't set are the defaults
+func multipleSearchOptions(header *multipleSearchHeader, defaultOptions *meta.IndicesOptions) (*meta.IndicesOptions, error) {
+	if header.IgnoreUnavailable == nil && header.AllowNoIndices == nil && header.ExpandWildcards == "" {
+		return defaultOptions, nil
+	}
+	opts := *defaultOptions
+	if header.IgnoreUnavailable != nil {
+		opts.IgnoreUnavailable = *header.IgnoreUnavailable
+	}
+	if header.AllowNoIndices != nil {
+		opts.AllowNoIndices = *header.AllowNoIndices
+	}
+	if header.ExpandWildcards != "" {
+		if err := core.SetExpandWildcards(&opts, header.ExpandWildcards); err != nil {
+			return nil, err
+		}
+	}
+	return &opts, nil
+}
+
+// multipleSearchIndexNames returns the index names of the header, a string can list the names separated by commas
+func multipleSearchIndexNames(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return []string{}, nil
+		}
+		return strings.Split(v, ","), nil
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, "header index should be a string or an array of strings")
+			}
+			names = append(names, s)
+		}
+		return names, nil
+	}
+	return nil, errors.New(errors.ErrorTypeParsingException, "header index should be a string or an array of strings")
 }
 
-func searchIndex(indexNames []string, query *meta.ZincQuery) (*meta.SearchResponse, error) {
-	indexName := ""
-	if len(indexNames) > 0 {
-		indexName = indexNames[0]
-	}
-	var err error
-	var resp *meta.SearchResponse
-	if indexName == "" || strings.HasSuffix(indexName, "*") || strings.HasPrefix(indexName, "*") || len(indexNames) > 1 {
-		resp, err = core.MultiSearch(indexNames, query)
-	} else {
-		index, exists := core.GetIndex(indexName)
-		if !exists {
-			return nil, fmt.Errorf("index %s does not exists", indexName)
+// restrictAliases restricts the query to the filters of the aliases in the names,
+// a filtered alias can't be searched along with other indexes
+func restrictAliases(names []string, query *meta.ZincQuery) error {
+	for _, name := range names {
+		filter, err := core.ZINC_INDEX_ALIAS_LIST.Filter(name)
+		if err != nil {
+			return err
 		}
-		resp, err = index.Search(query)
+		if filter == nil {
+			continue
+		}
+		if len(names) > 1 {
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("the filtered alias [%s] can't be searched along with other indexes", name))
+		}
+		security.Restrict(query, filter)
+	}
+	return nil
+}
+
+// contextAliases returns the names of the target searched by the request when it has aliases
+func contextAliases(c *gin.Context) []string {
+	if target := c.GetString(core.AliasContextKey); target != "" {
+		return strings.Split(target, ",")
+	}
+	return nil
+}
+
+func multipleSearchError(err error) *meta.SearchResponse {
+	return &meta.SearchResponse{Error: err.Error(), Status: errors.StatusCode(err, http.StatusBadRequest)}
+}
+
+// searchIndex searches the indexes the names expand to, the search is empty when they expand to no index
+func searchIndex(indexNames []string, opts *meta.IndicesOptions, query *meta.ZincQuery) (*meta.SearchResponse, error) {
+	indexNames, err := core.ResolveIndices(indexNames, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(indexNames) == 0 {
+		return &meta.SearchResponse{Hits: meta.Hits{Hits: []meta.Hit{}}}, nil
+	}
+	if len(indexNames) > 1 {
+		return core.MultiSearch(indexNames, query)
+	}
+	index, exists := core.GetIndex(indexNames[0])
+	if !exists {
+		return nil, fmt.Errorf("index %s does not exists", indexNames[0])
 	}
-	return resp, err
+	return index.Search(query)
 }
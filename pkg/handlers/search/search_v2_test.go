@@ -22,6 +22,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 	"github.com/zincsearch/zincsearch/test/utils"
 )
 
@@ -72,6 +74,51 @@ func TestSearchDSL(t *testing.T) {
 				result: "invalid character",
 			},
 		},
+		{
+			name: "boosting without positive",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"boosting":{"negative":{"match_all":{}},"negative_boost":0.2}},"size":10}`,
+				params: map[string]string{"target": indexName},
+				result: "requires 'positive' query",
+			},
+		},
+		{
+			name: "bool with malformed minimum_should_match",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"bool":{"should":[{"match":{"_all":"zinc"}}],"minimum_should_match":"2<"}},"size":10}`,
+				params: map[string]string{"target": indexName},
+				result: "MinimumShouldMatch",
+			},
+		},
+		{
+			name: "search_after",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"match_all":{}},"sort":["_id"],"search_after":["1"],"size":10}`,
+				params: map[string]string{"target": indexName},
+				result: "successful",
+			},
+		},
+		{
+			name: "search_after with from",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"match_all":{}},"sort":["_id"],"search_after":["1"],"from":10}`,
+				params: map[string]string{"target": indexName},
+				result: "[from] parameter must be set to 0",
+			},
+		},
+		{
+			name: "malformed track_total_hits",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"match_all":{}},"track_total_hits":"all"}`,
+				params: map[string]string{"target": indexName},
+				result: "[track_total_hits] value should be boolean or integer",
+			},
+		},
 	}
 
 	t.Run("prepare", func(t *testing.T) {
@@ -139,6 +186,37 @@ func TestMultipleSearch(t *testing.T) {
 				result: "does not exists",
 			},
 		},
+		{
+			name: "status",
+			args: args{
+				code: http.StatusOK,
+				data: `{"index":"` + indexName + `"}
+{"query":{"match_all":{}},"size":10}`,
+				result: `"status":200`,
+			},
+		},
+		{
+			name: "malformed header",
+			args: args{
+				code: http.StatusOK,
+				data: `{"index":
+{"query":{"match_all":{}},"size":10}
+{"index":"` + indexName + `"}
+{"query":{"match_all":{}},"size":10}`,
+				result: `malformed header line`,
+			},
+		},
+		{
+			name: "malformed query",
+			args: args{
+				code: http.StatusOK,
+				data: `{"index":"` + indexName + `"}
+{"query":
+{"index":"` + indexName + `"}
+{"query":{"match_all":{}},"size":10}`,
+				result: `malformed query line`,
+			},
+		},
 	}
 
 	t.Run("prepare", func(t *testing.T) {
@@ -160,6 +238,36 @@ func TestMultipleSearch(t *testing.T) {
 		})
 	}
 
+	t.Run("invalid concurrency", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"index":"`+indexName+`"}
+{"query":{"match_all":{}},"size":10}`)
+		utils.SetGinRequestURL(c, "/es/_msearch", map[string]string{"max_concurrent_searches": "0"})
+		MultipleSearch(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("responses keep the order", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"index":"`+indexName+`"}
+{"query":{"match_all":{}},"size":10}
+{"index":"TestMultipleSearch.notExists"}
+{"query":{"match_all":{}},"size":10}
+{"index":"`+indexName+`"}
+{"query":`)
+		MultipleSearch(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		resp := struct {
+			Responses []*meta.SearchResponse `json:"responses"`
+		}{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Responses, 3)
+		assert.Equal(t, http.StatusOK, resp.Responses[0].Status)
+		assert.Contains(t, resp.Responses[1].Error, "does not exists")
+		assert.Equal(t, http.StatusBadRequest, resp.Responses[1].Status)
+		assert.Contains(t, resp.Responses[2].Error, "malformed query line")
+	})
+
 	t.Run("cleanup", func(t *testing.T) {
 		err := core.DeleteIndex(indexName)
 		assert.NoError(t, err)
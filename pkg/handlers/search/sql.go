@@ -0,0 +1,129 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/sql"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id SQL
+// @Summary Search with SQL for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   format query  string          false  "Output format, json or csv"
+// @Param   query  body   meta.SQLRequest true   "Query"
+// @Success 200 {object} meta.SQLResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_sql [post]
+func SQL(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "invalid format [" + format + "], expected json or csv"})
+		return
+	}
+
+	req := new(meta.SQLRequest)
+	if err := zutils.GinBindJSON(c, req); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	q, err := sql.Parse(req.Query)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	names := []string{q.Index}
+	if indexes, ok := core.ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(q.Index); ok {
+		names = indexes
+	}
+	indexes := core.ZINC_INDEX_LIST.ListMatch(names)
+	if len(indexes) == 0 {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "index " + q.Index + " does not exists"})
+		return
+	}
+	mappings := indexes[0].GetMappings()
+
+	query, err := q.Request(mappings)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	query.Privileges = auth.GetContextPrivileges(c)
+	query.Context = c.Request.Context()
+	resp, err := core.MultiSearch(names, query)
+	if err != nil {
+		zutils.GinRenderJSON(c, errors.StatusCode(err, http.StatusBadRequest), meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	result, err := q.Response(resp, mappings)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	if format == "json" {
+		zutils.GinRenderJSON(c, http.StatusOK, result)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	header := make([]string, len(result.Columns))
+	for i, col := range result.Columns {
+		header[i] = col.Name
+	}
+	_ = w.Write(header)
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Rows {
+		for i, v := range row {
+			record[i] = csvValue(v)
+		}
+		if err := w.Write(record); err != nil {
+			return
+		}
+	}
+	w.Flush()
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
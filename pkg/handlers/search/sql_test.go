@@ -0,0 +1,152 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestSQL(t *testing.T) {
+	indexName := "TestSQL.index_1"
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+
+		docs := []map[string]interface{}{
+			{"host": "web-1", "level": "error", "bytes": 100, "message": "disk full"},
+			{"host": "web-1", "level": "info", "bytes": 200, "message": "request served"},
+			{"host": "web-2", "level": "error", "bytes": 300, "message": "disk failure"},
+			{"host": "web-2", "level": "error", "bytes": 400, "message": "timeout"},
+			{"host": "db-1", "level": "warn", "bytes": 500, "message": "slow query"},
+		}
+		for i, doc := range docs {
+			err = index.CreateDocument(strconv.Itoa(i+1), doc, false)
+			assert.NoError(t, err)
+		}
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	request := func(query string, params map[string]string) (int, string) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, map[string]interface{}{"query": query})
+		utils.SetGinRequestURL(c, "/es/_sql", params)
+		SQL(c)
+		return w.Code, w.Body.String()
+	}
+	rows := func(t *testing.T, query string) [][]interface{} {
+		code, body := request(query, nil)
+		assert.Equal(t, http.StatusOK, code, body)
+		resp := new(meta.SQLResponse)
+		err := json.Unmarshal([]byte(body), resp)
+		assert.NoError(t, err)
+		return resp.Rows
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  [][]interface{}
+	}{
+		{
+			name:  "group by with count",
+			query: `SELECT host, count(*) FROM "TestSQL.index_1" WHERE level='error' GROUP BY host LIMIT 10`,
+			want:  [][]interface{}{{"web-1", 1.0}, {"web-2", 2.0}},
+		},
+		{
+			name:  "order by aggregate",
+			query: `SELECT host, sum(bytes) AS total FROM "TestSQL.index_1" GROUP BY host ORDER BY total DESC LIMIT 2`,
+			want:  [][]interface{}{{"web-2", 700.0}, {"db-1", 500.0}},
+		},
+		{
+			name:  "metrics without group",
+			query: `SELECT COUNT(*), MIN(bytes), MAX(bytes), AVG(bytes), COUNT(DISTINCT host) FROM "TestSQL.index_1"`,
+			want:  [][]interface{}{{5.0, 100.0, 500.0, 300.0, 3.0}},
+		},
+		{
+			name:  "range and in",
+			query: `SELECT host, bytes FROM "TestSQL.index_1" WHERE bytes >= 200 AND host IN ('web-1', 'db-1') ORDER BY bytes`,
+			want:  [][]interface{}{{"web-1", 200.0}, {"db-1", 500.0}},
+		},
+		{
+			name:  "like and not",
+			query: `SELECT host FROM "TestSQL.index_1" WHERE host LIKE 'web-%' AND NOT level = 'error' ORDER BY host`,
+			want:  [][]interface{}{{"web-1"}},
+		},
+		{
+			name:  "or and between",
+			query: `SELECT bytes FROM "TestSQL.index_1" WHERE bytes BETWEEN 150 AND 350 OR level <> 'error' ORDER BY bytes DESC LIMIT 3`,
+			want:  [][]interface{}{{500.0}, {300.0}, {200.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rows(t, tt.query))
+		})
+	}
+
+	t.Run("columns", func(t *testing.T) {
+		code, body := request(`SELECT host AS h, count(*) AS n FROM "TestSQL.index_1" GROUP BY host`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		resp := new(meta.SQLResponse)
+		err := json.Unmarshal([]byte(body), resp)
+		assert.NoError(t, err)
+		assert.Equal(t, []meta.SQLColumn{{Name: "h", Type: "text"}, {Name: "n", Type: "long"}}, resp.Columns)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		code, body := request(`SELECT host, count(*) AS n FROM "TestSQL.index_1" GROUP BY host`, map[string]string{"format": "csv"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "host,n\ndb-1,1\nweb-1,2\nweb-2,2\n", body)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, query := range []string{
+			`SELECT FROM "TestSQL.index_1"`,
+			`SELECT host "TestSQL.index_1"`,
+			`SELECT host FROM "TestSQL.index_1" WHERE`,
+			`SELECT host FROM "TestSQL.index_1" WHERE host = 'a`,
+			`SELECT nope FROM "TestSQL.index_1"`,
+			`SELECT host, level, count(*) FROM "TestSQL.index_1" GROUP BY host`,
+			`SELECT count(host) FROM "TestSQL.index_1"`,
+			`SELECT host FROM "TestSQL.index_1" LIMIT x`,
+		} {
+			code, body := request(query, nil)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+			assert.True(t, strings.Contains(body, "error"), query)
+		}
+		code, _ := request(`SELECT host FROM "TestSQL.index_2"`, nil)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// taskWaitTimeout is how long GetTask waits for the task to complete by default
+const taskWaitTimeout = 30 * time.Second
+
+// GetTask returns the progress or the result of a task
+//
+// @Id GetTask
+// @Summary Get a task for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Produce json
+// @Param   id                   path   string  true   "Task ID"
+// @Param   wait_for_completion  query  bool    false  "Wait for the task to complete, default is false"
+// @Param   timeout              query  string  false  "Time to wait for the task to complete, default 30s"
+// @Success 200 {object} meta.TaskResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_tasks/{id} [get]
+func GetTask(c *gin.Context) {
+	wait, err := queryBool(c, "wait_for_completion", false)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	timeout, err := asyncSearchDuration(c, "timeout", taskWaitTimeout)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	task, ok := core.ZINC_TASKS.Get(id)
+	if !ok || task.Owner != requestOwner(c) {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "task " + id + " does not exists"})
+		return
+	}
+	if wait {
+		task.Wait(timeout)
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, task.Response())
+}
@@ -0,0 +1,207 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// TermVectors returns the terms of the document fields with their statistics
+//
+// @Id TermVectors
+// @Summary Get the term vectors of a document for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   index             path   string  true   "Index"
+// @Param   id                path   string  true   "ID"
+// @Param   fields            query  string  false  "Comma separated fields, supports wildcards"
+// @Param   offsets           query  bool    false  "Return the offsets of the terms, default true"
+// @Param   positions         query  bool    false  "Return the positions of the terms, default true"
+// @Param   term_statistics   query  bool    false  "Return the term statistics, default false"
+// @Param   field_statistics  query  bool    false  "Return the field statistics, default true"
+// @Param   data  body  meta.TermVectorsRequest  false  "Options"
+// @Success 200 {object} meta.TermVectorsResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.TermVectorsResponse
+// @Router /es/{index}/_termvectors/{id} [post]
+func TermVectors(c *gin.Context) {
+	indexName := c.Param("target")
+	docID := c.Param("id")
+	if docID == "" {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "id is empty"})
+		return
+	}
+
+	req := new(meta.TermVectorsRequest)
+	err := bindOptionalJSON(c, req)
+	if err == nil {
+		// the query parameters override the body
+		err = termVectorsQueryParams(c, req)
+	}
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	req.Privileges = auth.GetContextPrivileges(c)
+
+	index, exists := core.GetIndex(indexName)
+	if !exists {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: "index " + indexName + " does not exists"})
+		return
+	}
+
+	resp, err := index.TermVectors(docID, req)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !resp.Found {
+		zutils.GinRenderJSON(c, http.StatusNotFound, resp)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// MultiTermVectors returns the term vectors of several documents
+//
+// @Id MultiTermVectors
+// @Summary Get the term vectors of several documents for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   data  body  meta.MultiTermVectorsRequest  true  "Documents"
+// @Success 200 {object} meta.MultiTermVectorsResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_mtermvectors [post]
+func MultiTermVectors(c *gin.Context) {
+	defaultIndexName := c.Param("target")
+
+	defaults := new(meta.TermVectorsRequest)
+	body := new(meta.MultiTermVectorsRequest)
+	if err := bindOptionalJSON(c, body); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if err := termVectorsQueryParams(c, defaults); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	if body.Parameters != nil {
+		body.Parameters.Merge(defaults)
+		defaults = body.Parameters
+	}
+	if ids := c.Query("ids"); ids != "" && len(body.IDs) == 0 {
+		body.IDs = strings.Split(ids, ",")
+	}
+
+	docs := body.Docs
+	for _, id := range body.IDs {
+		docs = append(docs, &meta.MultiTermVectorsDoc{ID: id})
+	}
+
+	privileges := auth.GetContextPrivileges(c)
+	resp := &meta.MultiTermVectorsResponse{Docs: make([]*meta.TermVectorsResponse, 0, len(docs))}
+	for _, doc := range docs {
+		indexName := doc.Index
+		if indexName == "" {
+			indexName = defaultIndexName
+		}
+		req := doc.TermVectorsRequest
+		req.Merge(defaults)
+		req.Privileges = privileges
+		docResp, err := multiTermVectorsDoc(c, indexName, doc.ID, &req)
+		if err != nil {
+			docResp = &meta.TermVectorsResponse{Index: indexName, ID: doc.ID, Error: err.Error()}
+		}
+		resp.Docs = append(resp.Docs, docResp)
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// multiTermVectorsDoc checks the user can read the index and returns the term vectors of the document
+func multiTermVectorsDoc(c *gin.Context, indexName, docID string, req *meta.TermVectorsRequest) (*meta.TermVectorsResponse, error) {
+	if indexName == "" {
+		return nil, errors.New(errors.ErrorTypeInvalidArgument, "index is missing")
+	}
+	if docID == "" {
+		return nil, errors.New(errors.ErrorTypeInvalidArgument, "id is empty")
+	}
+	names, err := auth.AuthorizeContextIndexNames(c, []string{indexName}, core.ResolveIndexName)
+	if err != nil {
+		return nil, err
+	}
+	if names = core.ResolveIndexNames(names); len(names) != 1 {
+		return nil, errors.New(errors.ErrorTypeInvalidArgument, "index "+indexName+" should resolve to one index")
+	}
+	index, exists := core.GetIndex(names[0])
+	if !exists {
+		return nil, errors.New(errors.ErrorTypeInvalidArgument, "index "+indexName+" does not exists")
+	}
+	return index.TermVectors(docID, req)
+}
+
+// bindOptionalJSON binds the body of the request if it isn't empty
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	defer c.Request.Body.Close()
+	if len(bytes.TrimSpace(body)) > 0 {
+		return json.Unmarshal(body, obj)
+	}
+	return nil
+}
+
+// termVectorsQueryParams sets the options of the query parameters in the request
+func termVectorsQueryParams(c *gin.Context, req *meta.TermVectorsRequest) error {
+	if v := c.Query("fields"); v != "" {
+		req.Fields = strings.Split(v, ",")
+	}
+	for name, option := range map[string]**bool{
+		"offsets":          &req.Offsets,
+		"positions":        &req.Positions,
+		"term_statistics":  &req.TermStatistics,
+		"field_statistics": &req.FieldStatistics,
+	} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New(errors.ErrorTypeInvalidArgument, "failed to parse ["+name+"] with value ["+v+"]")
+		}
+		*option = &b
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestTermVectors(t *testing.T) {
+	indexName := "TestTermVectors.index_1"
+	type args struct {
+		code   int
+		data   string
+		params map[string]string
+		query  map[string]string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "terms",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName, "id": "1"},
+				result: `"zinc":{"term_freq":1,"tokens":[{"position":0,"start_offset":0,"end_offset":4}]}`,
+			},
+		},
+		{
+			name: "query parameters",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"positions":false}`,
+				params: map[string]string{"target": indexName, "id": "1"},
+				query:  map[string]string{"fields": "title", "offsets": "false", "term_statistics": "true"},
+				result: `"zinc":{"doc_freq":1,"ttf":1,"term_freq":1}`,
+			},
+		},
+		{
+			name: "invalid parameter",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": indexName, "id": "1"},
+				query:  map[string]string{"offsets": "maybe"},
+				result: "failed to parse [offsets]",
+			},
+		},
+		{
+			name: "document not found",
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"target": indexName, "id": "2"},
+				result: `"found":false`,
+			},
+		},
+		{
+			name: "index not found",
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"target": "NotExist" + indexName, "id": "1"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NotNil(t, index)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+		err = index.CreateDocument("1", map[string]interface{}{"title": "zinc search"}, false)
+		assert.NoError(t, err)
+		// wait for WAL write to index
+		time.Sleep(time.Second)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.args.data)
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestURL(c, "/es/"+indexName+"/_termvectors/1", tt.args.query)
+			TermVectors(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("multi term vectors", func(t *testing.T) {
+		c, w := utils.NewGinContext()
+		utils.SetGinRequestData(c, `{"docs":[{"_index":"`+indexName+`","_id":"1","fields":["title"]},{"_id":"2"},{"_index":"NotExist`+indexName+`","_id":"1"}],"parameters":{"offsets":false}}`)
+		utils.SetGinRequestParams(c, map[string]string{"target": indexName})
+		MultiTermVectors(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `"zinc":{"term_freq":1,"tokens":[{"position":0}]}`)
+		assert.Contains(t, body, `"_id":"2","found":false`)
+		assert.Contains(t, body, `does not exists`)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
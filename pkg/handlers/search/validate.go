@@ -0,0 +1,80 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// ValidateQuery validates the query DSL without executing it
+//
+// @Id ValidateQuery
+// @Summary Validate the query DSL for compatible ES
+// @security BasicAuth
+// @Tags    Search
+// @Accept  json
+// @Produce json
+// @Param   index    path   string  true  "Index"
+// @Param   explain  query  bool    false "Include a human-readable rewrite of the query"
+// @Param   query    body   meta.ZincQueryForSDK true  "Query"
+// @Success 200 {object} meta.ValidateResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/{index}/_validate/query [post]
+func ValidateQuery(c *gin.Context) {
+	indexName := c.Param("target")
+	indexNames := make([]string, 0)
+	if indexName != "" {
+		indexNames = strings.Split(indexName, ",")
+	}
+	explain := false
+	if v, ok := c.GetQuery("explain"); ok {
+		explain, _ = zutils.ToBool(v)
+		explain = explain || v == ""
+	}
+
+	// an empty body validates match_all
+	query := new(meta.ZincQuery)
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err = json.Unmarshal(body, query); err != nil {
+				zutils.GinRenderJSON(c, http.StatusOK, meta.ValidateResponse{Error: err.Error()})
+				return
+			}
+		}
+	}
+
+	resp, err := core.ValidateQuery(indexNames, query, explain)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
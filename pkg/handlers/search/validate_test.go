@@ -0,0 +1,124 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package search
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestValidateQuery(t *testing.T) {
+	indexName := "TestValidateQuery.index_1"
+	type args struct {
+		code   int
+		data   string
+		params map[string]string
+		query  map[string]string
+		result string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "valid",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"match":{"title":"zinc"}}}`,
+				params: map[string]string{"target": indexName},
+				result: `"valid":true`,
+			},
+		},
+		{
+			name: "empty body",
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"target": indexName},
+				result: `"valid":true`,
+			},
+		},
+		{
+			name: "explain",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"bool":{"must":[{"term":{"status":"active"}}],"must_not":[{"range":{"views":{"gte":10}}}]}}}`,
+				params: map[string]string{"target": indexName},
+				query:  map[string]string{"explain": "true"},
+				result: `"explanation":"+status:active -views:[10 TO`,
+			},
+		},
+		{
+			name: "unknown query",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"unknown":{"title":"zinc"}}}`,
+				params: map[string]string{"target": indexName},
+				result: `"valid":false,"error":"type: parsing_exception`,
+			},
+		},
+		{
+			name: "invalid json",
+			args: args{
+				code:   http.StatusOK,
+				data:   `{"query":{"match_all":{x}}}`,
+				params: map[string]string{"target": indexName},
+				result: `"valid":false`,
+			},
+		},
+		{
+			name: "index not found",
+			args: args{
+				code:   http.StatusBadRequest,
+				data:   `{"query":{"match_all":{}}}`,
+				params: map[string]string{"target": "NotExist" + indexName},
+				result: "no index found",
+			},
+		},
+	}
+
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NotNil(t, index)
+		err = core.StoreIndex(index)
+		assert.NoError(t, err)
+		index.GetMappings().SetProperty("status", meta.NewProperty("keyword"))
+		index.GetMappings().SetProperty("views", meta.NewProperty("numeric"))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestData(c, tt.args.data)
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestURL(c, "/es/"+indexName+"/_validate/query", tt.args.query)
+			ValidateQuery(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		err := core.DeleteIndex(indexName)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// @Id PutSnapshotRepository
+// @Summary Register a snapshot repository
+// @security BasicAuth
+// @Tags    Snapshot
+// @Accept  json
+// @Produce json
+// @Param   repository path  string                   true  "Repository"
+// @Param   data       body  meta.SnapshotRepository  true  "Repository type and settings"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository} [put]
+func PutRepository(c *gin.Context) {
+	repo := new(meta.SnapshotRepository)
+	if err := zutils.GinBindJSON(c, repo); err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	repo.Name = c.Param("repo")
+	if err := core.PutSnapshotRepository(repo); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// @Id GetSnapshotRepository
+// @Summary Get snapshot repositories
+// @security BasicAuth
+// @Tags    Snapshot
+// @Produce json
+// @Param   repository path  string  false  "Repository"
+// @Success 200 {object} map[string]meta.SnapshotRepository
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository} [get]
+func GetRepository(c *gin.Context) {
+	var repos []*meta.SnapshotRepository
+	if name := c.Param("repo"); name != "" && name != "_all" && name != "*" {
+		repo, ok := getRepository(c, name)
+		if !ok {
+			return
+		}
+		repos = append(repos, repo)
+	} else {
+		var err error
+		if repos, err = core.ListSnapshotRepositories(); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	}
+
+	resp := make(map[string]*meta.SnapshotRepository, len(repos))
+	for _, repo := range repos {
+		// never render the credentials
+		repo.Settings.SecretKey = ""
+		resp[repo.Name] = repo
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, resp)
+}
+
+// @Id DeleteSnapshotRepository
+// @Summary Unregister a snapshot repository, the snapshots are kept
+// @security BasicAuth
+// @Tags    Snapshot
+// @Produce json
+// @Param   repository path  string  true  "Repository"
+// @Success 200 {object} meta.HTTPResponse
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository} [delete]
+func DeleteRepository(c *gin.Context) {
+	name := c.Param("repo")
+	if _, ok := getRepository(c, name); !ok {
+		return
+	}
+	if err := core.DeleteSnapshotRepository(name); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, gin.H{"acknowledged": true})
+}
+
+// getRepository returns the repository, renders 404 if it does not exist
+func getRepository(c *gin.Context, name string) (*meta.SnapshotRepository, bool) {
+	repo, ok, err := core.GetSnapshotRepository(name)
+	if err != nil {
+		errors.HandleError(c, err)
+		return nil, false
+	}
+	if !ok {
+		zutils.GinRenderJSON(c, http.StatusNotFound, meta.HTTPResponseError{Error: "repository " + name + " does not exists"})
+		return nil, false
+	}
+	return repo, true
+}
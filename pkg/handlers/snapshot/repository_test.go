@@ -0,0 +1,145 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestRepository(t *testing.T) {
+	repoName := "TestRepository.repo"
+	location := t.TempDir()
+	defer func(paths []string) { config.Global.SnapshotPathRepo = paths }(config.Global.SnapshotPathRepo)
+	config.Global.SnapshotPathRepo = []string{location}
+	type args struct {
+		code   int
+		params map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+	}{
+		{
+			name:    "put repository",
+			handler: PutRepository,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName},
+				data:   `{"type":"fs","settings":{"location":"` + location + `"}}`,
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "put repository with the secret",
+			handler: PutRepository,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName + "_s3"},
+				data:   `{"type":"fs","settings":{"location":"` + location + `","secret_key":"secret"}}`,
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "put repository with invalid type",
+			handler: PutRepository,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName},
+				data:   `{"type":"hdfs"}`,
+				result: "repository type [hdfs] does not exist",
+			},
+		},
+		{
+			name:    "put repository with invalid body",
+			handler: PutRepository,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName},
+				data:   `{"type":`,
+				result: "error",
+			},
+		},
+		{
+			name:    "get repository",
+			handler: GetRepository,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName},
+				result: `{"TestRepository.repo":{"name":"TestRepository.repo","type":"fs","settings":{"location":"` + location + `"}}}`,
+			},
+		},
+		{
+			name:    "get all repositories",
+			handler: GetRepository,
+			args: args{
+				code:   http.StatusOK,
+				result: `"TestRepository.repo_s3":{"name":"TestRepository.repo_s3","type":"fs","settings":{"location":"` + location + `"}}`,
+			},
+		},
+		{
+			name:    "get not exists repository",
+			handler: GetRepository,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"repo": "TestRepository.notExist"},
+				result: "does not exists",
+			},
+		},
+		{
+			name:    "delete repository",
+			handler: DeleteRepository,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName + "_s3"},
+				result: `{"acknowledged":true}`,
+			},
+		},
+		{
+			name:    "delete not exists repository",
+			handler: DeleteRepository,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"repo": repoName + "_s3"},
+				result: "does not exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestData(c, tt.args.data)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteSnapshotRepository(repoName))
+	})
+}
@@ -0,0 +1,142 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// @Id CreateSnapshot
+// @Summary Take a snapshot of indexes into the repository
+// @security BasicAuth
+// @Tags    Snapshot
+// @Accept  json
+// @Produce json
+// @Param   repository path  string                true   "Repository"
+// @Param   snapshot   path  string                true   "Snapshot"
+// @Param   data       body  meta.SnapshotRequest  false  "Indexes to snapshot"
+// @Success 200 {object} meta.SnapshotResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository}/{snapshot} [put]
+func Create(c *gin.Context) {
+	req := new(meta.SnapshotRequest)
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+	repoName := c.Param("repo")
+	if _, ok := getRepository(c, repoName); !ok {
+		return
+	}
+
+	snapshot, err := core.CreateSnapshot(repoName, c.Param("snapshot"), req)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.SnapshotResponse{Snapshot: snapshot})
+}
+
+// @Id GetSnapshot
+// @Summary Get snapshots in the repository, use _all to list all of them
+// @security BasicAuth
+// @Tags    Snapshot
+// @Produce json
+// @Param   repository path  string  true  "Repository"
+// @Param   snapshot   path  string  true  "Snapshot"
+// @Success 200 {object} meta.SnapshotListResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository}/{snapshot} [get]
+func Get(c *gin.Context) {
+	repoName := c.Param("repo")
+	if _, ok := getRepository(c, repoName); !ok {
+		return
+	}
+
+	var snapshots []*meta.Snapshot
+	if name := c.Param("snapshot"); name == "_all" || name == "*" {
+		var err error
+		if snapshots, err = core.ListSnapshots(repoName); err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+	} else {
+		snapshot, err := core.GetSnapshot(repoName, name)
+		if err != nil {
+			errors.HandleError(c, err)
+			return
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.SnapshotListResponse{Snapshots: snapshots})
+}
+
+// @Id RestoreSnapshot
+// @Summary Restore indexes from the snapshot, the indexes must not exist
+// @security BasicAuth
+// @Tags    Snapshot
+// @Accept  json
+// @Produce json
+// @Param   repository path  string                       true   "Repository"
+// @Param   snapshot   path  string                       true   "Snapshot"
+// @Param   data       body  meta.RestoreSnapshotRequest  false  "Indexes to restore and the rename rule"
+// @Success 200 {object} meta.RestoreSnapshotResponse
+// @Failure 400 {object} meta.HTTPResponseError
+// @Failure 404 {object} meta.HTTPResponseError
+// @Router /es/_snapshot/{repository}/{snapshot}/_restore [post]
+func Restore(c *gin.Context) {
+	req := new(meta.RestoreSnapshotRequest)
+	if !bindOptionalJSON(c, req) {
+		return
+	}
+	repoName := c.Param("repo")
+	if _, ok := getRepository(c, repoName); !ok {
+		return
+	}
+
+	info, err := core.RestoreSnapshot(repoName, c.Param("snapshot"), req)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	zutils.GinRenderJSON(c, http.StatusOK, meta.RestoreSnapshotResponse{Snapshot: *info})
+}
+
+// bindOptionalJSON binds the request body if any, renders 400 if it is invalid
+func bindOptionalJSON(c *gin.Context, obj interface{}) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return false
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err = json.Unmarshal(body, obj); err != nil {
+			zutils.GinRenderJSON(c, http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/test/utils"
+)
+
+func TestSnapshot(t *testing.T) {
+	repoName := "TestSnapshot.repo"
+	indexName := "TestSnapshot.index_1"
+	type args struct {
+		code   int
+		params map[string]string
+		data   string
+		result string
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		args    args
+	}{
+		{
+			name:    "create snapshot",
+			handler: Create,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName, "snapshot": "s1"},
+				data:   `{"indices":"` + indexName + `"}`,
+				result: `"indices":["TestSnapshot.index_1"],"state":"SUCCESS"`,
+			},
+		},
+		{
+			name:    "create snapshot without body",
+			handler: Create,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName, "snapshot": "s2"},
+				result: `"snapshot":"s2"`,
+			},
+		},
+		{
+			name:    "create snapshot exists",
+			handler: Create,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName, "snapshot": "s1"},
+				result: "already exists",
+			},
+		},
+		{
+			name:    "create snapshot with invalid body",
+			handler: Create,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName, "snapshot": "s3"},
+				data:   `{"indices":`,
+				result: "error",
+			},
+		},
+		{
+			name:    "create snapshot in not exists repository",
+			handler: Create,
+			args: args{
+				code:   http.StatusNotFound,
+				params: map[string]string{"repo": "TestSnapshot.notExist", "snapshot": "s3"},
+				result: "does not exists",
+			},
+		},
+		{
+			name:    "get snapshot",
+			handler: Get,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName, "snapshot": "s1"},
+				result: `{"snapshots":[{"snapshot":"s1","repository":"TestSnapshot.repo","indices":["TestSnapshot.index_1"]`,
+			},
+		},
+		{
+			name:    "list snapshots",
+			handler: Get,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName, "snapshot": "_all"},
+				result: `"snapshot":"s2"`,
+			},
+		},
+		{
+			name:    "get not exists snapshot",
+			handler: Get,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName, "snapshot": "s3"},
+				result: "snapshot does not exist",
+			},
+		},
+		{
+			name:    "restore snapshot to existing index",
+			handler: Restore,
+			args: args{
+				code:   http.StatusBadRequest,
+				params: map[string]string{"repo": repoName, "snapshot": "s1"},
+				result: "already exists",
+			},
+		},
+		{
+			name:    "restore snapshot",
+			handler: Restore,
+			args: args{
+				code:   http.StatusOK,
+				params: map[string]string{"repo": repoName, "snapshot": "s1"},
+				data:   `{"indices":["` + indexName + `"],"rename_pattern":"index_(.+)","rename_replacement":"restored_$1"}`,
+				result: `{"snapshot":{"snapshot":"s1","indices":["TestSnapshot.restored_1"],"shards":{"total":2,"successful":2,"failed":0}}}`,
+			},
+		},
+	}
+
+	defer func(paths []string) { config.Global.SnapshotPathRepo = paths }(config.Global.SnapshotPathRepo)
+	t.Run("prepare", func(t *testing.T) {
+		index, err := core.NewIndex(indexName, "disk", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, core.StoreIndex(index))
+		assert.NoError(t, index.CreateDocument("1", map[string]interface{}{"name": "doc1"}, false))
+		location := t.TempDir()
+		config.Global.SnapshotPathRepo = []string{location}
+		err = core.PutSnapshotRepository(&meta.SnapshotRepository{Name: repoName, Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: location}})
+		assert.NoError(t, err)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := utils.NewGinContext()
+			utils.SetGinRequestParams(c, tt.args.params)
+			utils.SetGinRequestData(c, tt.args.data)
+			tt.handler(c)
+			assert.Equal(t, tt.args.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.args.result)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert.NoError(t, core.DeleteSnapshotRepository(repoName))
+		for _, index := range core.ZINC_INDEX_LIST.ListMatch([]string{"TestSnapshot.*"}) {
+			assert.NoError(t, core.DeleteIndex(index.GetName()))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getField returns the value of the dotted path, the keys containing dots are matched first
+func getField(doc map[string]interface{}, path string) (interface{}, bool) {
+	if v, ok := doc[path]; ok {
+		return v, true
+	}
+	parts := strings.SplitN(path, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	sub, ok := doc[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return getField(sub, parts[1])
+}
+
+// setField sets the value of the dotted path, the missing objects are created
+func setField(doc map[string]interface{}, path string, value interface{}) error {
+	if _, ok := doc[path]; ok || !strings.Contains(path, ".") {
+		doc[path] = value
+		return nil
+	}
+	parts := strings.SplitN(path, ".", 2)
+	switch sub := doc[parts[0]].(type) {
+	case map[string]interface{}:
+		return setField(sub, parts[1], value)
+	case nil:
+		m := make(map[string]interface{})
+		doc[parts[0]] = m
+		return setField(m, parts[1], value)
+	default:
+		return fmt.Errorf("cannot set [%s] because [%s] is not an object", path, parts[0])
+	}
+}
+
+// removeField removes the dotted path, it returns false if the path doesn't exist
+func removeField(doc map[string]interface{}, path string) bool {
+	if _, ok := doc[path]; ok {
+		delete(doc, path)
+		return true
+	}
+	parts := strings.SplitN(path, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	sub, ok := doc[parts[0]].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return removeField(sub, parts[1])
+}
+
+// renderTemplate replaces the {{field}} and {{{field}}} placeholders with the values of the document,
+// the missing fields are replaced with empty strings
+func renderTemplate(s string, doc map[string]interface{}) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			break
+		}
+		open, close := "{{", "}}"
+		if strings.HasPrefix(s[start:], "{{{") {
+			open, close = "{{{", "}}}"
+		}
+		end := strings.Index(s[start+len(open):], close)
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:start])
+		name := strings.TrimSpace(s[start+len(open) : start+len(open)+end])
+		if v, ok := getField(doc, name); ok && v != nil {
+			b.WriteString(fmt.Sprint(v))
+		}
+		s = s[start+len(open)+end+len(close):]
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,290 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// GrokParseFailureTag is the tag added to the documents not matched by the grok expressions
+const GrokParseFailureTag = "_grokparsefailure"
+
+var grokPatternName = regexp.MustCompile(`^\w+$`)
+
+// customGrokPatterns are the patterns registered at runtime, they are shared by all the pipelines
+var customGrokPatterns = struct {
+	patterns map[string]string
+	lock     sync.RWMutex
+}{patterns: make(map[string]string)}
+
+// RegisterGrokPattern adds or replaces a custom pattern, the bundled patterns can't be replaced
+func RegisterGrokPattern(name, pattern string) error {
+	if !grokPatternName.MatchString(name) {
+		return fmt.Errorf("invalid grok pattern name [%s]", name)
+	}
+	if IsBuiltinGrokPattern(name) {
+		return fmt.Errorf("grok pattern [%s] is a bundled pattern", name)
+	}
+	if _, err := compileGrok(pattern, map[string]string{name: pattern}); err != nil {
+		return err
+	}
+	customGrokPatterns.lock.Lock()
+	customGrokPatterns.patterns[name] = pattern
+	customGrokPatterns.lock.Unlock()
+	return nil
+}
+
+// UnregisterGrokPattern removes the custom pattern
+func UnregisterGrokPattern(name string) {
+	customGrokPatterns.lock.Lock()
+	delete(customGrokPatterns.patterns, name)
+	customGrokPatterns.lock.Unlock()
+}
+
+// IsBuiltinGrokPattern returns true if the pattern is in the bundled pattern library
+func IsBuiltinGrokPattern(name string) bool {
+	_, ok := grokPatterns[name]
+	return ok
+}
+
+// GrokPatterns returns the bundled and the custom patterns
+func GrokPatterns() map[string]string {
+	customGrokPatterns.lock.RLock()
+	defer customGrokPatterns.lock.RUnlock()
+	patterns := make(map[string]string, len(grokPatterns)+len(customGrokPatterns.patterns))
+	for k, v := range grokPatterns {
+		patterns[k] = v
+	}
+	for k, v := range customGrokPatterns.patterns {
+		patterns[k] = v
+	}
+	return patterns
+}
+
+func lookupGrokPattern(name string) (string, bool) {
+	if pattern, ok := grokPatterns[name]; ok {
+		return pattern, true
+	}
+	customGrokPatterns.lock.RLock()
+	pattern, ok := customGrokPatterns.patterns[name]
+	customGrokPatterns.lock.RUnlock()
+	return pattern, ok
+}
+
+// grokTypes are the types a semantic can be converted to
+var grokTypes = map[string]bool{"": true, "string": true, "int": true, "long": true, "float": true, "double": true, "boolean": true}
+
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@\[\]-]+))?(?::(\w+))?\}`)
+
+// grokCapture is a semantic of the grok expression
+type grokCapture struct {
+	group string
+	field string
+	typ   string
+}
+
+// grokExpression is a compiled grok expression
+type grokExpression struct {
+	regexp   *regexp.Regexp
+	captures []grokCapture
+}
+
+// compileGrok expands the named patterns of the grok expression into a regular expression,
+// the semantics become numbered groups because field names are not valid group names
+func compileGrok(expr string, definitions map[string]string) (*grokExpression, error) {
+	g := &grokExpression{}
+	source, err := g.expand(expr, definitions, 0)
+	if err != nil {
+		return nil, err
+	}
+	if g.regexp, err = regexp.Compile(source); err != nil {
+		return nil, fmt.Errorf("invalid grok expression [%s]: %s", expr, err.Error())
+	}
+	return g, nil
+}
+
+func (g *grokExpression) expand(expr string, definitions map[string]string, depth int) (string, error) {
+	if depth > 32 {
+		return "", fmt.Errorf("grok pattern [%s] is recursive", expr)
+	}
+	var err error
+	source := grokReference.ReplaceAllStringFunc(expr, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := grokReference.FindStringSubmatch(ref)
+		pattern, ok := definitions[m[1]]
+		if !ok {
+			pattern, ok = lookupGrokPattern(m[1])
+		}
+		if !ok {
+			err = fmt.Errorf("unable to find pattern [%s] in grok's pattern dictionary", m[1])
+			return ""
+		}
+		var sub string
+		if sub, err = g.expand(pattern, definitions, depth+1); err != nil {
+			return ""
+		}
+		if m[2] == "" {
+			return "(?:" + sub + ")"
+		}
+		if !grokTypes[m[3]] {
+			err = fmt.Errorf("unsupported type [%s] of semantic [%s]", m[3], m[2])
+			return ""
+		}
+		group := "g" + strconv.Itoa(len(g.captures))
+		g.captures = append(g.captures, grokCapture{group: group, field: m[2], typ: m[3]})
+		return "(?P<" + group + ">" + sub + ")"
+	})
+	return source, err
+}
+
+// match returns the captured values of the string, the unmatched optional semantics are skipped
+func (g *grokExpression) match(s string) (map[string]interface{}, bool, error) {
+	m := g.regexp.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil, false, nil
+	}
+	values := make(map[string]interface{}, len(g.captures))
+	for _, c := range g.captures {
+		i := g.regexp.SubexpIndex(c.group)
+		if m[2*i] < 0 {
+			continue
+		}
+		v, err := convertValue(s[m[2*i]:m[2*i+1]], c.typ)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to convert [%s] to [%s]", c.field, c.typ)
+		}
+		values[c.field] = v
+	}
+	return values, true, nil
+}
+
+// grokProcessor extracts the fields from the string of the field by the first matched grok expression,
+// the unmatched documents fail unless tag_on_failure is set, then the failure tags are added to the tags field
+type grokProcessor struct {
+	field         string
+	expressions   []*grokExpression
+	ignoreMissing bool
+	failureTags   []string
+}
+
+func newGrokProcessor(o *options) (Processor, error) {
+	p := new(grokProcessor)
+	var err error
+	if p.field, err = o.string("field", true); err != nil {
+		return nil, err
+	}
+	patterns, err := o.strings("patterns", true)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := o.object("pattern_definitions")
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]string, len(defs))
+	for k, v := range defs {
+		s, ok := v.(string)
+		if !ok {
+			return nil, o.errorf("[pattern_definitions] pattern [%s] isn't a string", k)
+		}
+		definitions[k] = s
+	}
+	for _, pattern := range patterns {
+		expr, err := compileGrok(pattern, definitions)
+		if err != nil {
+			return nil, o.errorf("%s", err.Error())
+		}
+		p.expressions = append(p.expressions, expr)
+	}
+	if p.ignoreMissing, err = o.bool("ignore_missing", false); err != nil {
+		return nil, err
+	}
+	if v, ok := o.get("tag_on_failure"); ok {
+		if b, ok := v.(bool); ok {
+			if b {
+				p.failureTags = []string{GrokParseFailureTag}
+			}
+		} else if p.failureTags, err = o.strings("tag_on_failure", false); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *grokProcessor) Process(doc map[string]interface{}) error {
+	v, ok := getField(doc, p.field)
+	if !ok || v == nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] doesn't exist", p.field)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("field [%s] of type [%T] cannot be cast to a string", p.field, v)
+	}
+	for _, expr := range p.expressions {
+		values, ok, err := expr.match(s)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for field, value := range values {
+			if err := setField(doc, field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(p.failureTags) > 0 {
+		return addTags(doc, p.failureTags)
+	}
+	return fmt.Errorf("provided grok expressions do not match field value: [%s]", s)
+}
+
+// addTags appends the missing tags to the tags field
+func addTags(doc map[string]interface{}, tags []string) error {
+	var values []interface{}
+	switch v := doc["tags"].(type) {
+	case nil:
+	case []interface{}:
+		values = v
+	case string:
+		values = []interface{}{v}
+	default:
+		return fmt.Errorf("field [tags] of type [%T] cannot be cast to a list", v)
+	}
+	for _, tag := range tags {
+		exists := false
+		for _, v := range values {
+			if v == tag {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			values = append(values, tag)
+		}
+	}
+	doc["tags"] = values
+	return nil
+}
@@ -0,0 +1,166 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrokProcessor(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		doc     map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "match",
+			options: map[string]interface{}{
+				"field":    "message",
+				"patterns": []interface{}{`%{IP:client.ip} %{WORD:method} %{NOTSPACE:path} %{NUMBER:bytes:int} %{NUMBER:duration:float}`},
+			},
+			doc: map[string]interface{}{"message": "55.3.244.1 GET /index.html 15824 0.043"},
+			want: map[string]interface{}{
+				"message": "55.3.244.1 GET /index.html 15824 0.043",
+				"client":  map[string]interface{}{"ip": "55.3.244.1"},
+				"method":  "GET", "path": "/index.html", "bytes": int64(15824), "duration": 0.043,
+			},
+		},
+		{
+			name: "first matched pattern with definitions",
+			options: map[string]interface{}{
+				"field":               "message",
+				"patterns":            []interface{}{`^%{NUMBER:code:int}$`, `^%{FAVORITE_DOG:pet}$`},
+				"pattern_definitions": map[string]interface{}{"FAVORITE_DOG": "beagle|poodle"},
+			},
+			doc:  map[string]interface{}{"message": "beagle"},
+			want: map[string]interface{}{"message": "beagle", "pet": "beagle"},
+		},
+		{
+			name:    "no match",
+			options: map[string]interface{}{"field": "message", "patterns": "^%{INT:n}$"},
+			doc:     map[string]interface{}{"message": "abc"},
+			wantErr: "provided grok expressions do not match field value: [abc]",
+		},
+		{
+			name: "combined apache log",
+			options: map[string]interface{}{
+				"field":    "message",
+				"patterns": "%{COMBINEDAPACHELOG}",
+			},
+			doc: map[string]interface{}{"message": `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`},
+			want: map[string]interface{}{
+				"message":     `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+				"clientip":    "127.0.0.1",
+				"ident":       "-",
+				"auth":        "frank",
+				"timestamp":   "10/Oct/2000:13:55:36 -0700",
+				"verb":        "GET",
+				"request":     "/apache_pb.gif",
+				"httpversion": "1.0",
+				"response":    "200",
+				"bytes":       "2326",
+				"referrer":    `"http://www.example.com/start.html"`,
+				"agent":       `"Mozilla/4.08"`,
+			},
+		},
+		{
+			name: "syslog line",
+			options: map[string]interface{}{
+				"field":    "message",
+				"patterns": "%{SYSLOGLINE}",
+			},
+			doc: map[string]interface{}{"message": "Mar  7 00:12:01 web-1 sshd[4242]: Accepted publickey for root"},
+			want: map[string]interface{}{
+				"message":   "Accepted publickey for root",
+				"timestamp": "Mar  7 00:12:01",
+				"logsource": "web-1",
+				"program":   "sshd",
+				"pid":       "4242",
+			},
+		},
+		{
+			name:    "tag on failure",
+			options: map[string]interface{}{"field": "message", "patterns": "^%{INT:n}$", "tag_on_failure": true},
+			doc:     map[string]interface{}{"message": "abc", "tags": "raw"},
+			want:    map[string]interface{}{"message": "abc", "tags": []interface{}{"raw", "_grokparsefailure"}},
+		},
+		{
+			name:    "custom tags on failure",
+			options: map[string]interface{}{"field": "message", "patterns": "^%{INT:n}$", "tag_on_failure": []interface{}{"not_number"}},
+			doc:     map[string]interface{}{"message": "abc"},
+			want:    map[string]interface{}{"message": "abc", "tags": []interface{}{"not_number"}},
+		},
+		{
+			name:    "ignore missing",
+			options: map[string]interface{}{"field": "message", "patterns": "%{INT:n}", "ignore_missing": true},
+			doc:     map[string]interface{}{},
+			want:    map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newGrokProcessor(&options{typ: "grok", data: tt.options})
+			assert.NoError(t, err)
+			err = p.Process(tt.doc)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.doc)
+		})
+	}
+}
+
+func TestCompileGrok(t *testing.T) {
+	_, err := compileGrok("%{UNKNOWN:a}", nil)
+	assert.ErrorContains(t, err, "unable to find pattern [UNKNOWN]")
+	_, err = compileGrok("%{A}", map[string]string{"A": "%{A}"})
+	assert.ErrorContains(t, err, "recursive")
+	_, err = compileGrok("%{INT:a:decimal}", nil)
+	assert.ErrorContains(t, err, "unsupported type [decimal]")
+}
+
+func TestRegisterGrokPattern(t *testing.T) {
+	assert.NoError(t, RegisterGrokPattern("TestRegisterGrokPattern_LEVEL", "(?:LOW|HIGH)"))
+	assert.NoError(t, RegisterGrokPattern("TestRegisterGrokPattern_ALERT", "%{TestRegisterGrokPattern_LEVEL:level} %{GREEDYDATA:text}"))
+	defer UnregisterGrokPattern("TestRegisterGrokPattern_LEVEL")
+	defer UnregisterGrokPattern("TestRegisterGrokPattern_ALERT")
+	assert.Equal(t, "(?:LOW|HIGH)", GrokPatterns()["TestRegisterGrokPattern_LEVEL"])
+
+	expr, err := compileGrok("%{TestRegisterGrokPattern_ALERT}", nil)
+	assert.NoError(t, err)
+	values, ok, err := expr.match("HIGH disk usage")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"level": "HIGH", "text": "disk usage"}, values)
+
+	assert.ErrorContains(t, RegisterGrokPattern("WORD", `\w+`), "bundled pattern")
+	assert.ErrorContains(t, RegisterGrokPattern("BAD NAME", `\w+`), "invalid grok pattern name")
+	assert.ErrorContains(t, RegisterGrokPattern("TestRegisterGrokPattern_BAD", "%{UNKNOWN}"), "unable to find pattern")
+	assert.ErrorContains(t, RegisterGrokPattern("TestRegisterGrokPattern_SELF", "%{TestRegisterGrokPattern_SELF}"), "recursive")
+}
+
+func TestGrokPatterns_Compile(t *testing.T) {
+	for name := range grokPatterns {
+		_, err := compileGrok("%{"+name+"}", nil)
+		assert.NoError(t, err, name)
+	}
+}
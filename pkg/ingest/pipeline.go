@@ -0,0 +1,232 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// Processor transforms the document in place
+type Processor interface {
+	Process(doc map[string]interface{}) error
+}
+
+// Pipeline runs the processors on the documents in order before they are indexed
+type Pipeline struct {
+	id         string
+	processors []Processor
+}
+
+// New compiles the processors of the pipeline definition
+func New(id string, processors []map[string]interface{}) (*Pipeline, error) {
+	p := &Pipeline{id: id, processors: make([]Processor, 0, len(processors))}
+	for i, v := range processors {
+		if len(v) != 1 {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[pipeline] processor [%d] should have exactly one type", i))
+		}
+		for typ, options := range v {
+			options, ok := options.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[pipeline] processor [%s] should be an object", typ))
+			}
+			processor, err := newProcessor(strings.ToLower(typ), options)
+			if err != nil {
+				return nil, err
+			}
+			p.processors = append(p.processors, processor)
+		}
+	}
+	return p, nil
+}
+
+// NewFromMeta compiles the stored pipeline
+func NewFromMeta(pipeline *meta.Pipeline) (*Pipeline, error) {
+	return New(pipeline.ID, pipeline.Processors)
+}
+
+func (p *Pipeline) ID() string {
+	return p.id
+}
+
+// Run runs the processors on the document, it stops at the first failed processor
+func (p *Pipeline) Run(doc map[string]interface{}) error {
+	for _, processor := range p.processors {
+		if err := processor.Process(doc); err != nil {
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[pipeline] [%s] failed", p.id)).Cause(err)
+		}
+	}
+	return nil
+}
+
+type processorFactory func(options *options) (Processor, error)
+
+var processorFactories = map[string]processorFactory{
+	"set":       newSetProcessor,
+	"rename":    newRenameProcessor,
+	"remove":    newRemoveProcessor,
+	"lowercase": newLowercaseProcessor,
+	"uppercase": newUppercaseProcessor,
+	"date":      newDateProcessor,
+	"gsub":      newGsubProcessor,
+	"split":     newSplitProcessor,
+	"grok":      newGrokProcessor,
+}
+
+func newProcessor(typ string, data map[string]interface{}) (Processor, error) {
+	factory, ok := processorFactories[typ]
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[pipeline] unknown processor type [%s]", typ))
+	}
+	opts := &options{typ: typ, data: data}
+	ignoreFailure, err := opts.bool("ignore_failure", false)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := opts.string("tag", false)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = opts.string("description", false)
+	processor, err := factory(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.unknown(); err != nil {
+		return nil, err
+	}
+	return &commonProcessor{typ: typ, tag: tag, ignoreFailure: ignoreFailure, processor: processor}, nil
+}
+
+// commonProcessor handles the options shared by all the processors
+type commonProcessor struct {
+	typ           string
+	tag           string
+	ignoreFailure bool
+	processor     Processor
+}
+
+func (p *commonProcessor) Process(doc map[string]interface{}) error {
+	err := p.processor.Process(doc)
+	if err == nil || p.ignoreFailure {
+		return nil
+	}
+	if p.tag != "" {
+		return fmt.Errorf("processor [%s] with tag [%s]: %s", p.typ, p.tag, err.Error())
+	}
+	return fmt.Errorf("processor [%s]: %s", p.typ, err.Error())
+}
+
+// options reads the options of a processor and remembers the read keys
+type options struct {
+	typ  string
+	data map[string]interface{}
+	read map[string]bool
+}
+
+func (o *options) get(key string) (interface{}, bool) {
+	if o.read == nil {
+		o.read = make(map[string]bool)
+	}
+	o.read[key] = true
+	v, ok := o.data[key]
+	return v, ok && v != nil
+}
+
+func (o *options) errorf(format string, args ...interface{}) error {
+	return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] ", o.typ)+fmt.Sprintf(format, args...))
+}
+
+func (o *options) string(key string, required bool) (string, error) {
+	v, ok := o.get(key)
+	if !ok {
+		if required {
+			return "", o.errorf("[%s] required property is missing", key)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", o.errorf("[%s] property isn't a string", key)
+	}
+	return s, nil
+}
+
+func (o *options) bool(key string, defaultValue bool) (bool, error) {
+	v, ok := o.get(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, o.errorf("[%s] property isn't a boolean", key)
+	}
+	return b, nil
+}
+
+// strings reads a string or a list of strings
+func (o *options) strings(key string, required bool) ([]string, error) {
+	v, ok := o.get(key)
+	if !ok {
+		if required {
+			return nil, o.errorf("[%s] required property is missing", key)
+		}
+		return nil, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, vv := range v {
+			s, ok := vv.(string)
+			if !ok {
+				return nil, o.errorf("[%s] property should be a list of strings", key)
+			}
+			values = append(values, s)
+		}
+		if required && len(values) == 0 {
+			return nil, o.errorf("[%s] property should not be empty", key)
+		}
+		return values, nil
+	default:
+		return nil, o.errorf("[%s] property should be a string or a list of strings", key)
+	}
+}
+
+func (o *options) object(key string) (map[string]interface{}, error) {
+	v, ok := o.get(key)
+	if !ok {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, o.errorf("[%s] property should be an object", key)
+	}
+	return m, nil
+}
+
+// unknown returns an error if one of the options is not read by the processor
+func (o *options) unknown() error {
+	for k := range o.data {
+		if !o.read[k] {
+			return o.errorf("unknown property [%s]", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors []map[string]interface{}
+		doc        map[string]interface{}
+		want       map[string]interface{}
+		wantErr    string
+	}{
+		{
+			name: "set",
+			processors: []map[string]interface{}{
+				{"set": map[string]interface{}{"field": "env", "value": "prod"}},
+				{"set": map[string]interface{}{"field": "host.label", "value": "{{host.name}}-{{env}}"}},
+				{"set": map[string]interface{}{"field": "env", "value": "dev", "override": false}},
+			},
+			doc:  map[string]interface{}{"host": map[string]interface{}{"name": "web"}},
+			want: map[string]interface{}{"env": "prod", "host": map[string]interface{}{"name": "web", "label": "web-prod"}},
+		},
+		{
+			name: "rename and remove",
+			processors: []map[string]interface{}{
+				{"rename": map[string]interface{}{"field": "msg", "target_field": "message"}},
+				{"remove": map[string]interface{}{"field": []interface{}{"tmp", "missing"}, "ignore_missing": true}},
+			},
+			doc:  map[string]interface{}{"msg": "hello", "tmp": 1.0},
+			want: map[string]interface{}{"message": "hello"},
+		},
+		{
+			name: "rename to existing field",
+			processors: []map[string]interface{}{
+				{"rename": map[string]interface{}{"field": "a", "target_field": "b"}},
+			},
+			doc:     map[string]interface{}{"a": 1.0, "b": 2.0},
+			wantErr: "field [b] already exists",
+		},
+		{
+			name: "lowercase and uppercase",
+			processors: []map[string]interface{}{
+				{"lowercase": map[string]interface{}{"field": "tags"}},
+				{"uppercase": map[string]interface{}{"field": "level", "target_field": "level_upper"}},
+			},
+			doc:  map[string]interface{}{"tags": []interface{}{"A", "b"}, "level": "warn"},
+			want: map[string]interface{}{"tags": []interface{}{"a", "b"}, "level": "warn", "level_upper": "WARN"},
+		},
+		{
+			name: "date",
+			processors: []map[string]interface{}{
+				{"date": map[string]interface{}{"field": "ts", "formats": []interface{}{"02/Jan/2006:15:04:05 -0700", "UNIX"}}},
+				{"date": map[string]interface{}{"field": "epoch", "target_field": "epoch_date", "formats": "UNIX_MS"}},
+			},
+			doc: map[string]interface{}{"ts": "10/Oct/2000:13:55:36 -0700", "epoch": 1000.0},
+			want: map[string]interface{}{
+				"ts": "10/Oct/2000:13:55:36 -0700", "@timestamp": "2000-10-10T20:55:36Z",
+				"epoch": 1000.0, "epoch_date": "1970-01-01T00:00:01Z",
+			},
+		},
+		{
+			name: "date without matched format",
+			processors: []map[string]interface{}{
+				{"date": map[string]interface{}{"field": "ts", "formats": "ISO8601"}},
+			},
+			doc:     map[string]interface{}{"ts": "yesterday"},
+			wantErr: "unable to parse date [yesterday]",
+		},
+		{
+			name: "gsub and split",
+			processors: []map[string]interface{}{
+				{"gsub": map[string]interface{}{"field": "path", "pattern": `\\`, "replacement": "/"}},
+				{"split": map[string]interface{}{"field": "path", "separator": "/", "target_field": "parts"}},
+			},
+			doc:  map[string]interface{}{"path": `a\b\c\`},
+			want: map[string]interface{}{"path": "a/b/c/", "parts": []interface{}{"a", "b", "c"}},
+		},
+		{
+			name: "missing field",
+			processors: []map[string]interface{}{
+				{"lowercase": map[string]interface{}{"field": "missing", "tag": "lower"}},
+			},
+			doc:     map[string]interface{}{},
+			wantErr: "processor [lowercase] with tag [lower]: field [missing] doesn't exist",
+		},
+		{
+			name: "ignore failure",
+			processors: []map[string]interface{}{
+				{"lowercase": map[string]interface{}{"field": "missing", "ignore_failure": true}},
+				{"set": map[string]interface{}{"field": "ok", "value": true}},
+			},
+			doc:  map[string]interface{}{},
+			want: map[string]interface{}{"ok": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New("test", tt.processors)
+			assert.NoError(t, err)
+			err = p.Run(tt.doc)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.doc)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors []map[string]interface{}
+		wantErr    string
+	}{
+		{
+			name:       "unknown processor",
+			processors: []map[string]interface{}{{"foo": map[string]interface{}{}}},
+			wantErr:    "unknown processor type [foo]",
+		},
+		{
+			name:       "missing required property",
+			processors: []map[string]interface{}{{"rename": map[string]interface{}{"field": "a"}}},
+			wantErr:    "[rename] [target_field] required property is missing",
+		},
+		{
+			name:       "unknown property",
+			processors: []map[string]interface{}{{"set": map[string]interface{}{"field": "a", "value": 1, "foo": 1}}},
+			wantErr:    "[set] unknown property [foo]",
+		},
+		{
+			name:       "invalid pattern",
+			processors: []map[string]interface{}{{"gsub": map[string]interface{}{"field": "a", "pattern": "(", "replacement": ""}}},
+			wantErr:    "[gsub] invalid [pattern]",
+		},
+		{
+			name: "multiple types",
+			processors: []map[string]interface{}{{
+				"set":    map[string]interface{}{"field": "a", "value": 1},
+				"remove": map[string]interface{}{"field": "a"},
+			}},
+			wantErr: "should have exactly one type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("test", tt.processors)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,404 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// fieldOptions are the options of the processors transforming the value of a field
+type fieldOptions struct {
+	field         string
+	targetField   string
+	ignoreMissing bool
+}
+
+func readFieldOptions(o *options) (fieldOptions, error) {
+	var f fieldOptions
+	var err error
+	if f.field, err = o.string("field", true); err != nil {
+		return f, err
+	}
+	if f.targetField, err = o.string("target_field", false); err != nil {
+		return f, err
+	}
+	if f.targetField == "" {
+		f.targetField = f.field
+	}
+	if f.ignoreMissing, err = o.bool("ignore_missing", false); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// transform replaces the value of the field with the transformed value
+func (f fieldOptions) transform(doc map[string]interface{}, fn func(v interface{}) (interface{}, error)) error {
+	v, ok := getField(doc, f.field)
+	if !ok || v == nil {
+		if f.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] doesn't exist", f.field)
+	}
+	v, err := fn(v)
+	if err != nil {
+		return err
+	}
+	return setField(doc, f.targetField, v)
+}
+
+// mapStrings applies the function on a string or on every string of a list
+func mapStrings(field string, v interface{}, fn func(s string) (interface{}, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, vv := range v {
+			s, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("field [%s] of type [%T] cannot be cast to a string", field, vv)
+			}
+			r, err := fn(s)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, r)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("field [%s] of type [%T] cannot be cast to a string", field, v)
+	}
+}
+
+// setProcessor sets the value of the field, the string values can use {{field}} templates
+type setProcessor struct {
+	field            string
+	value            interface{}
+	copyFrom         string
+	override         bool
+	ignoreEmptyValue bool
+}
+
+func newSetProcessor(o *options) (Processor, error) {
+	p := new(setProcessor)
+	var err error
+	if p.field, err = o.string("field", true); err != nil {
+		return nil, err
+	}
+	if p.copyFrom, err = o.string("copy_from", false); err != nil {
+		return nil, err
+	}
+	var ok bool
+	p.value, ok = o.get("value")
+	if ok == (p.copyFrom != "") {
+		return nil, o.errorf("exactly one of [value] or [copy_from] is required")
+	}
+	if p.override, err = o.bool("override", true); err != nil {
+		return nil, err
+	}
+	if p.ignoreEmptyValue, err = o.bool("ignore_empty_value", false); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *setProcessor) Process(doc map[string]interface{}) error {
+	if !p.override {
+		if v, ok := getField(doc, p.field); ok && v != nil {
+			return nil
+		}
+	}
+	value := p.value
+	if p.copyFrom != "" {
+		value, _ = getField(doc, p.copyFrom)
+	} else if s, ok := value.(string); ok {
+		value = renderTemplate(s, doc)
+	}
+	if p.ignoreEmptyValue && (value == nil || value == "") {
+		return nil
+	}
+	return setField(doc, renderTemplate(p.field, doc), value)
+}
+
+// renameProcessor moves the value of the field to the target field
+type renameProcessor struct {
+	fieldOptions
+}
+
+func newRenameProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	if f.targetField == f.field {
+		return nil, o.errorf("[target_field] required property is missing")
+	}
+	return &renameProcessor{fieldOptions: f}, nil
+}
+
+func (p *renameProcessor) Process(doc map[string]interface{}) error {
+	v, ok := getField(doc, p.field)
+	if !ok {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] doesn't exist", p.field)
+	}
+	if _, ok := getField(doc, p.targetField); ok {
+		return fmt.Errorf("field [%s] already exists", p.targetField)
+	}
+	removeField(doc, p.field)
+	return setField(doc, p.targetField, v)
+}
+
+// removeProcessor removes the fields
+type removeProcessor struct {
+	fields        []string
+	ignoreMissing bool
+}
+
+func newRemoveProcessor(o *options) (Processor, error) {
+	p := new(removeProcessor)
+	var err error
+	if p.fields, err = o.strings("field", true); err != nil {
+		return nil, err
+	}
+	if p.ignoreMissing, err = o.bool("ignore_missing", false); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *removeProcessor) Process(doc map[string]interface{}) error {
+	for _, field := range p.fields {
+		if !removeField(doc, field) && !p.ignoreMissing {
+			return fmt.Errorf("field [%s] doesn't exist", field)
+		}
+	}
+	return nil
+}
+
+// caseProcessor converts the strings of the field to lower or upper case
+type caseProcessor struct {
+	fieldOptions
+	convert func(string) string
+}
+
+func newLowercaseProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	return &caseProcessor{fieldOptions: f, convert: strings.ToLower}, nil
+}
+
+func newUppercaseProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	return &caseProcessor{fieldOptions: f, convert: strings.ToUpper}, nil
+}
+
+func (p *caseProcessor) Process(doc map[string]interface{}) error {
+	return p.transform(doc, func(v interface{}) (interface{}, error) {
+		return mapStrings(p.field, v, func(s string) (interface{}, error) {
+			return p.convert(s), nil
+		})
+	})
+}
+
+// dateProcessor parses the date of the field by the formats and sets the target field,
+// the formats are ISO8601, UNIX, UNIX_MS or Go layouts, the target field is @timestamp by default
+type dateProcessor struct {
+	fieldOptions
+	formats      []string
+	location     *time.Location
+	outputFormat string
+}
+
+func newDateProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	if target, _ := o.string("target_field", false); target == "" {
+		f.targetField = meta.TimeFieldName
+	}
+	p := &dateProcessor{fieldOptions: f, location: time.UTC}
+	if p.formats, err = o.strings("formats", true); err != nil {
+		return nil, err
+	}
+	timezone, err := o.string("timezone", false)
+	if err != nil {
+		return nil, err
+	}
+	if timezone != "" {
+		if p.location, err = zutils.ParseTimeZone(timezone); err != nil {
+			return nil, o.errorf("invalid timezone [%s]", timezone)
+		}
+	}
+	if p.outputFormat, err = o.string("output_format", false); err != nil {
+		return nil, err
+	}
+	if p.outputFormat == "" {
+		p.outputFormat = time.RFC3339Nano
+	}
+	return p, nil
+}
+
+func (p *dateProcessor) Process(doc map[string]interface{}) error {
+	return p.transform(doc, func(v interface{}) (interface{}, error) {
+		for _, format := range p.formats {
+			if t, ok := p.parse(v, format); ok {
+				return t.In(p.location).Format(p.outputFormat), nil
+			}
+		}
+		return nil, fmt.Errorf("unable to parse date [%v] of field [%s]", v, p.field)
+	})
+}
+
+func (p *dateProcessor) parse(v interface{}, format string) (time.Time, bool) {
+	switch format {
+	case "UNIX", "epoch_second", "UNIX_MS", "epoch_millis":
+		if _, ok := v.(bool); ok {
+			return time.Time{}, false
+		}
+		n, err := zutils.ToFloat64(v)
+		if err != nil {
+			return time.Time{}, false
+		}
+		if format == "UNIX" || format == "epoch_second" {
+			return time.UnixMilli(int64(n * 1000)), true
+		}
+		return time.UnixMilli(int64(n)), true
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	if format == "ISO8601" {
+		format = time.RFC3339Nano
+	}
+	t, err := time.ParseInLocation(format, s, p.location)
+	return t, err == nil
+}
+
+// gsubProcessor replaces the matches of the regular expression in the strings of the field
+type gsubProcessor struct {
+	fieldOptions
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func newGsubProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	p := &gsubProcessor{fieldOptions: f}
+	pattern, err := o.string("pattern", true)
+	if err != nil {
+		return nil, err
+	}
+	if p.pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, o.errorf("invalid [pattern]: %s", err.Error())
+	}
+	if _, ok := o.data["replacement"]; !ok {
+		return nil, o.errorf("[replacement] required property is missing")
+	}
+	if p.replacement, err = o.string("replacement", false); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *gsubProcessor) Process(doc map[string]interface{}) error {
+	return p.transform(doc, func(v interface{}) (interface{}, error) {
+		return mapStrings(p.field, v, func(s string) (interface{}, error) {
+			return p.pattern.ReplaceAllString(s, p.replacement), nil
+		})
+	})
+}
+
+// splitProcessor splits the string of the field into a list by the separator regular expression
+type splitProcessor struct {
+	fieldOptions
+	separator        *regexp.Regexp
+	preserveTrailing bool
+}
+
+func newSplitProcessor(o *options) (Processor, error) {
+	f, err := readFieldOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	p := &splitProcessor{fieldOptions: f}
+	separator, err := o.string("separator", true)
+	if err != nil {
+		return nil, err
+	}
+	if p.separator, err = regexp.Compile(separator); err != nil {
+		return nil, o.errorf("invalid [separator]: %s", err.Error())
+	}
+	if p.preserveTrailing, err = o.bool("preserve_trailing", false); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *splitProcessor) Process(doc map[string]interface{}) error {
+	return p.transform(doc, func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field [%s] of type [%T] cannot be cast to a string", p.field, v)
+		}
+		parts := p.separator.Split(s, -1)
+		if !p.preserveTrailing {
+			for len(parts) > 0 && parts[len(parts)-1] == "" {
+				parts = parts[:len(parts)-1]
+			}
+		}
+		values := make([]interface{}, len(parts))
+		for i, part := range parts {
+			values[i] = part
+		}
+		return values, nil
+	})
+}
+
+// convertValue converts the string to the type of a grok capture
+func convertValue(s, typ string) (interface{}, error) {
+	switch typ {
+	case "", "string":
+		return s, nil
+	case "int", "long":
+		return strconv.ParseInt(s, 10, 64)
+	case "float", "double":
+		return strconv.ParseFloat(s, 64)
+	case "boolean":
+		return strconv.ParseBool(s)
+	default:
+		return nil, fmt.Errorf("unsupported type [%s]", typ)
+	}
+}
@@ -0,0 +1,216 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blugelabs/bluge/numeric"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+)
+
+// Update script operations, the script sets ctx.op to skip or delete the document
+const (
+	UpdateOpIndex  = "index"
+	UpdateOpCreate = "create"
+	UpdateOpNoop   = "noop"
+	UpdateOpDelete = "delete"
+)
+
+const updateSourcePrefix = "ctx._source."
+
+var (
+	updateAssignRegexp = regexp.MustCompile(`^ctx\._source(?:\.([\w.]+)|\[\s*['"]([^'"]+)['"]\s*\])\s*([-+*/%]?=|\+\+|--)\s*(.*)$`)
+	updateRemoveRegexp = regexp.MustCompile(`^ctx\._source\.remove\(\s*['"]([^'"]+)['"]\s*\)$`)
+	updateOpRegexp     = regexp.MustCompile(`^ctx\.op\s*=\s*['"](\w+)['"]$`)
+)
+
+// UpdateContext is the ctx of an update script, the script changes the source in place
+type UpdateContext struct {
+	Op     string
+	Source map[string]interface{}
+}
+
+// UpdateScript is a script of the update API, it is a list of statements separated by ; or new lines:
+//
+//	ctx._source.counter += params.count
+//	ctx._source.name = ctx._source.first + ' ' + ctx._source.last
+//	ctx._source.remove('tmp')
+//	ctx.op = 'noop'
+//
+// The values are the expressions of the value scripts, they read the fields of the source
+// as ctx._source.field, the missing fields are 0.
+type UpdateScript struct {
+	source     string
+	params     map[string]float64
+	statements []updateStatement
+}
+
+type updateStatement struct {
+	op    string // set, remove or op
+	field string
+	value string
+}
+
+// NewUpdateScript parses the statements of the update script and checks the syntax of the expressions
+func NewUpdateScript(source string, params map[string]float64) (*UpdateScript, error) {
+	s := &UpdateScript{source: source, params: params}
+	for _, line := range splitStatements(source) {
+		if m := updateOpRegexp.FindStringSubmatch(line); m != nil {
+			switch m[1] {
+			case UpdateOpIndex, UpdateOpCreate, UpdateOpNoop, UpdateOpDelete, "none":
+			default:
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] unsupported ctx.op [%s]", m[1]))
+			}
+			s.statements = append(s.statements, updateStatement{op: "op", value: m[1]})
+			continue
+		}
+		if m := updateRemoveRegexp.FindStringSubmatch(line); m != nil {
+			s.statements = append(s.statements, updateStatement{op: "remove", field: m[1]})
+			continue
+		}
+		m := updateAssignRegexp.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(m[4], "=") {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[script] unsupported statement [%s]", line))
+		}
+		field := m[1]
+		if field == "" {
+			field = m[2]
+		}
+		target := updateSourcePrefix + field
+		if m[2] != "" {
+			target = "doc['" + field + "'].value"
+		}
+		value := strings.TrimSpace(m[4])
+		switch m[3] {
+		case "=":
+		case "++", "--":
+			if value != "" {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[script] unsupported statement [%s]", line))
+			}
+			value = target + " " + m[3][:1] + " 1"
+		default:
+			value = target + " " + m[3][:1] + " (" + value + ")"
+		}
+		if value == "" {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[script] statement [%s] has no value", line))
+		}
+		if err := zincquery.ValidateScript(value); err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[script] compile error").Cause(err)
+		}
+		s.statements = append(s.statements, updateStatement{op: "set", field: field, value: value})
+	}
+	if len(s.statements) == 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[script] source should be not empty")
+	}
+	return s, nil
+}
+
+// Run runs the statements on the source of the context in order
+func (s *UpdateScript) Run(ctx *UpdateContext) error {
+	for _, st := range s.statements {
+		switch st.op {
+		case "op":
+			ctx.Op = st.value
+			if ctx.Op == "none" {
+				ctx.Op = UpdateOpNoop
+			}
+		case "remove":
+			removeField(ctx.Source, st.field)
+		case "set":
+			value, err := s.eval(st.value, ctx.Source)
+			if err != nil {
+				return err
+			}
+			if err = setField(ctx.Source, st.field, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// eval compiles the expression against the current values of the source and evaluates it,
+// the numbers and the booleans are numeric fields and the strings are keyword fields
+func (s *UpdateScript) eval(expr string, source map[string]interface{}) (interface{}, error) {
+	values := make(zincquery.DocValues)
+	script, err := zincquery.NewValueScript(expr, s.params, func(field string) string {
+		v, _ := getField(source, sourceField(field))
+		if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
+			v = arr[0]
+		}
+		switch v := v.(type) {
+		case string:
+			values[field] = [][]byte{[]byte(v)}
+			return zincquery.FieldTypeKeyword
+		case float64:
+			values[field] = [][]byte{numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(v), 0)}
+		case bool:
+			n := 0.0
+			if v {
+				n = 1
+			}
+			values[field] = [][]byte{numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(n), 0)}
+		}
+		return zincquery.FieldTypeNumeric
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[script] compile error").Cause(err)
+	}
+	if script.IsText() {
+		return script.Text(0, values), nil
+	}
+	return script.Eval(0, values), nil
+}
+
+// sourceField returns the path in the source of a field of the expression
+func sourceField(field string) string {
+	return strings.TrimPrefix(field, updateSourcePrefix)
+}
+
+// splitStatements splits the script by ; and new lines outside of the string literals
+func splitStatements(source string) []string {
+	var statements []string
+	var quote byte
+	start := 0
+	for i := 0; i <= len(source); i++ {
+		if i < len(source) {
+			c := source[i]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+				continue
+			}
+			if c == '\'' || c == '"' {
+				quote = c
+				continue
+			}
+			if c != ';' && c != '\n' {
+				continue
+			}
+		}
+		if st := strings.TrimSpace(source[start:i]); st != "" {
+			statements = append(statements, st)
+		}
+		start = i + 1
+	}
+	return statements
+}
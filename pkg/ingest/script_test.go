@@ -0,0 +1,92 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		params  map[string]float64
+		doc     map[string]interface{}
+		want    map[string]interface{}
+		op      string
+		wantErr bool
+	}{
+		{
+			name:   "increment",
+			source: "ctx._source.counter += params.count",
+			params: map[string]float64{"count": 4},
+			doc:    map[string]interface{}{"counter": 1.0},
+			want:   map[string]interface{}{"counter": 5.0},
+		},
+		{
+			name:   "missing field",
+			source: "ctx._source.stats.views++",
+			doc:    map[string]interface{}{},
+			want:   map[string]interface{}{"stats": map[string]interface{}{"views": 1.0}},
+		},
+		{
+			name:   "strings and statements",
+			source: "ctx._source.name = ctx._source.first + ' ' + ctx._source.last; ctx._source.remove('first')\nctx._source['last'] = 'x;y'",
+			doc:    map[string]interface{}{"first": "John", "last": "Doe"},
+			want:   map[string]interface{}{"name": "John Doe", "last": "x;y"},
+		},
+		{
+			name:   "op",
+			source: "ctx.op = 'noop'",
+			doc:    map[string]interface{}{"a": 1.0},
+			want:   map[string]interface{}{"a": 1.0},
+			op:     UpdateOpNoop,
+		},
+		{
+			name:    "unsupported statement",
+			source:  "if (ctx._source.a > 1) { ctx._source.a = 1 }",
+			wantErr: true,
+		},
+		{
+			name:    "unknown op",
+			source:  "ctx.op = 'drop'",
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			source:  "ctx._source.a = (1 +",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := NewUpdateScript(tt.source, tt.params)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			ctx := &UpdateContext{Op: UpdateOpIndex, Source: tt.doc}
+			assert.NoError(t, script.Run(ctx))
+			assert.Equal(t, tt.want, ctx.Source)
+			if tt.op != "" {
+				assert.Equal(t, tt.op, ctx.Op)
+			}
+		})
+	}
+}
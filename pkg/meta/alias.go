@@ -0,0 +1,32 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// AliasOptions are the filter and the routing of an index in an alias
+type AliasOptions struct {
+	// Filter is the query restricting the documents searched through the alias
+	Filter        map[string]interface{} `json:"filter,omitempty"`
+	IndexRouting  string                 `json:"index_routing,omitempty"`
+	SearchRouting string                 `json:"search_routing,omitempty"`
+}
+
+// GetFilter returns the filter, it is nil for the nil options
+func (t *AliasOptions) GetFilter() map[string]interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Filter
+}
@@ -25,7 +25,9 @@ type Analyzer struct {
 	Type      string   `json:"type,omitempty"`
 	Pattern   string   `json:"pattern,omitempty"`   // for type=pattern
 	Lowercase bool     `json:"lowercase,omitempty"` // for type=pattern
-	Stopwords []string `json:"stopwords,omitempty"` // for type=pattern,standard,stop
+	Stopwords []string `json:"stopwords,omitempty"` // for type=pattern,standard,stop,cjk
+	Method    string   `json:"method,omitempty"`    // for type=icu_analyzer
+	Mode      string   `json:"mode,omitempty"`      // for type=icu_analyzer
 }
 
 type Tokenizer struct {
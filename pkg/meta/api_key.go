@@ -0,0 +1,43 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+import "time"
+
+// APIKey is a long-lived credential of a service, only the hash of the secret is stored
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Owner      string     `json:"owner"`
+	Hash       string     `json:"hash,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name       string `json:"name"`
+	Role       string `json:"role,omitempty"`
+	Expiration string `json:"expiration,omitempty"` // duration like 30d or 12h, never expires if empty
+}
+
+// APIKeyResponse is returned once when the key is created, the secret can't be retrieved later
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	APIKey     string     `json:"api_key"`
+	Encoded    string     `json:"encoded"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+}
@@ -0,0 +1,29 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// AsyncSearchResponse is the state of a search running in the background,
+// the response is partial while the search is running
+type AsyncSearchResponse struct {
+	ID                     string          `json:"id,omitempty"`
+	IsPartial              bool            `json:"is_partial"`
+	IsRunning              bool            `json:"is_running"`
+	StartTimeInMillis      int64           `json:"start_time_in_millis"`
+	ExpirationTimeInMillis int64           `json:"expiration_time_in_millis"`
+	CompletionTimeInMillis int64           `json:"completion_time_in_millis,omitempty"`
+	Response               *SearchResponse `json:"response,omitempty"`
+	Error                  string          `json:"error,omitempty"`
+}
@@ -0,0 +1,59 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+import "time"
+
+// DataStream is an append-only stream of time series documents stored in generations of
+// backing indexes, the documents are written to the last backing index and the reads span all of them
+type DataStream struct {
+	Name           string                   `json:"name"`
+	TimestampField DataStreamTimestampField `json:"timestamp_field"`
+	Indices        []DataStreamIndex        `json:"indices"`
+	Generation     int64                    `json:"generation"`
+	Status         string                   `json:"status"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+type DataStreamTimestampField struct {
+	Name string `json:"name"`
+}
+
+// DataStreamIndex is a backing index of the data stream
+type DataStreamIndex struct {
+	IndexName string `json:"index_name"`
+}
+
+type DataStreamsResponse struct {
+	DataStreams []*DataStream `json:"data_streams"`
+}
+
+// IndexNames returns the names of the backing indexes, the oldest first
+func (t *DataStream) IndexNames() []string {
+	names := make([]string, 0, len(t.Indices))
+	for _, index := range t.Indices {
+		names = append(names, index.IndexName)
+	}
+	return names
+}
+
+// WriteIndex returns the name of the backing index the documents are written to
+func (t *DataStream) WriteIndex() string {
+	if len(t.Indices) == 0 {
+		return ""
+	}
+	return t.Indices[len(t.Indices)-1].IndexName
+}
@@ -0,0 +1,25 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// DeleteByQueryRequest deletes the documents matched the query in batches
+type DeleteByQueryRequest struct {
+	Query      *ZincQuery
+	Conflicts  string // abort or proceed, default is abort
+	ScrollSize int    // number of documents of one batch
+	MaxDocs    int    // max number of documents to delete, all the matches without it
+	Refresh    bool   // flush the indexes after the deletes, so the searches see them
+}
@@ -0,0 +1,113 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// DynamicTemplate maps the new fields of the documents matching its rules,
+// it is written in es style {"strings_as_keyword":{"match_mapping_type":"string","mapping":{"type":"keyword"}}}
+type DynamicTemplate struct {
+	Name             string   `json:"-"`
+	Match            string   `json:"match,omitempty"`   // pattern of the field name
+	Unmatch          string   `json:"unmatch,omitempty"` // pattern of the field name
+	MatchPattern     string   `json:"match_pattern,omitempty"`
+	PathMatch        string   `json:"path_match,omitempty"`         // pattern of the full dotted path of the field
+	PathUnmatch      string   `json:"path_unmatch,omitempty"`       // pattern of the full dotted path of the field
+	MatchMappingType string   `json:"match_mapping_type,omitempty"` // string, long, double, boolean, date or *
+	Mapping          Property `json:"mapping"`
+}
+
+const (
+	DynamicTemplateMatchSimple = "simple" // * wildcards, the default
+	DynamicTemplateMatchRegex  = "regex"
+)
+
+func (t DynamicTemplate) MarshalJSON() ([]byte, error) {
+	type dynamicTemplate DynamicTemplate
+	return json.Marshal(map[string]dynamicTemplate{t.Name: dynamicTemplate(t)})
+}
+
+func (t *DynamicTemplate) UnmarshalJSON(data []byte) error {
+	type dynamicTemplate DynamicTemplate
+	var v map[string]*dynamicTemplate
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	for name, tpl := range v {
+		*t = DynamicTemplate(*tpl)
+		t.Name = name
+	}
+	return nil
+}
+
+// Matches reports if the template applies to the field at the dotted path,
+// the mapping type is the type detected from the value of the field
+func (t *DynamicTemplate) Matches(path, mappingType string) bool {
+	if t.MatchMappingType != "" && t.MatchMappingType != "*" && t.MatchMappingType != mappingType {
+		return false
+	}
+	name := path
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		name = path[i+1:]
+	}
+	if t.Match != "" && !t.match(t.Match, name) {
+		return false
+	}
+	if t.Unmatch != "" && t.match(t.Unmatch, name) {
+		return false
+	}
+	if t.PathMatch != "" && !t.match(t.PathMatch, path) {
+		return false
+	}
+	if t.PathUnmatch != "" && t.match(t.PathUnmatch, path) {
+		return false
+	}
+	return true
+}
+
+func (t *DynamicTemplate) match(pattern, s string) bool {
+	if t.MatchPattern == DynamicTemplateMatchRegex {
+		re, err := regexp.Compile(pattern)
+		return err == nil && re.MatchString(s)
+	}
+	return simpleMatch(pattern, s)
+}
+
+// simpleMatch matches the string with the pattern which can contain * wildcards anywhere
+func simpleMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"sync"
 
+	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
@@ -28,6 +29,10 @@ type Mappings struct {
 
 	// Properties holds the index properties.
 	Properties map[string]Property `json:"properties,omitempty"`
+	// Runtime holds the runtime fields computed at query time.
+	Runtime map[string]meta.RuntimeField `json:"runtime,omitempty"`
+	// DynamicTemplates holds the templates mapping the new fields.
+	DynamicTemplates []*meta.DynamicTemplate `json:"dynamic_templates,omitempty"`
 }
 
 // NewMappings returns a initialized Mappings object.
@@ -92,6 +97,25 @@ func (t *Mappings) MarshalJSON() ([]byte, error) {
 	}
 
 	b.Write(p)
+
+	if len(t.Runtime) > 0 {
+		b.WriteString(`,"runtime":`)
+		r, err := json.Marshal(t.Runtime)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(r)
+	}
+
+	if len(t.DynamicTemplates) > 0 {
+		b.WriteString(`,"dynamic_templates":`)
+		d, err := json.Marshal(t.DynamicTemplates)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(d)
+	}
+
 	b.WriteByte('}')
 
 	return b.Bytes(), nil
@@ -109,12 +133,15 @@ type Property struct {
 	// or the same string value analyzed by different analyzers.
 	Fields map[string]Property `json:"fields,omitempty"`
 	// IgnoreAbove prevents indexing of strings longer than the configured value.
-	// TODO: Should this be supported by Zinc?
 	IgnoreAbove    uint   `json:"ignore_above,omitempty"`
 	Analyzer       string `json:"analyzer,omitempty"`
 	SearchAnalyzer string `json:"search_analyzer,omitempty"`
 	// Format holds the property format.
 	Format string `json:"format,omitempty"`
+	// CopyTo holds the fields the value is copied to.
+	CopyTo []string `json:"copy_to,omitempty"`
+	// NullValue holds the value indexed in place of null.
+	NullValue interface{} `json:"null_value,omitempty"`
 }
 
 // NewProperty returns a new Property object.
@@ -0,0 +1,34 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// FieldCapsResponse is the response of the `_field_caps` API, the fields are keyed by the name and then the type
+type FieldCapsResponse struct {
+	Indices []string                         `json:"indices"`
+	Fields  map[string]map[string]*FieldCaps `json:"fields"`
+}
+
+// FieldCaps are the capabilities of a field of one type, a field mapped to
+// different types in the indices has one entry per type
+type FieldCaps struct {
+	Type                   string   `json:"type"`
+	MetadataField          bool     `json:"metadata_field"`
+	Searchable             bool     `json:"searchable"`
+	Aggregatable           bool     `json:"aggregatable"`
+	Indices                []string `json:"indices"`
+	NonSearchableIndices   []string `json:"non_searchable_indices,omitempty"`
+	NonAggregatableIndices []string `json:"non_aggregatable_indices,omitempty"`
+}
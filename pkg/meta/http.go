@@ -25,9 +25,14 @@ type HTTPResponseID struct {
 }
 
 type HTTPResponseDocument struct {
-	Message string `json:"message"`
-	Index   string `json:"index"`
-	ID      string `json:"id,omitempty"`
+	Message       string `json:"message"`
+	Index         string `json:"index"`
+	ID            string `json:"id,omitempty"`
+	Version       int64  `json:"_version,omitempty"`
+	SeqNo         int64  `json:"_seq_no,omitempty"`
+	PrimaryTerm   int64  `json:"_primary_term,omitempty"`
+	Routing       string `json:"_routing,omitempty"`
+	ForcedRefresh bool   `json:"forced_refresh,omitempty"`
 }
 
 type HTTPResponseIndex struct {
@@ -51,14 +56,16 @@ type HTTPResponseError struct {
 }
 
 type HTTPResponseESID struct {
-	Message     string `json:"message"`
-	ID          string `json:"id"`
-	ESID        string `json:"_id"`
-	Index       string `json:"_index"`
-	Version     int    `json:"_version"`
-	SeqNo       int    `json:"_seq_no"`
-	PrimaryTerm int    `json:"_primary_term"`
-	Result      string `json:"result"` // created, updated, deleted
+	Message       string `json:"message"`
+	ID            string `json:"id"`
+	ESID          string `json:"_id"`
+	Index         string `json:"_index"`
+	Version       int64  `json:"_version"`
+	SeqNo         int64  `json:"_seq_no"`
+	PrimaryTerm   int64  `json:"_primary_term"`
+	Routing       string `json:"_routing,omitempty"`
+	Result        string `json:"result"` // created, updated, deleted
+	ForcedRefresh bool   `json:"forced_refresh,omitempty"`
 }
 
 type HttpRetriesResponse struct {
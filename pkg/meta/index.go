@@ -15,17 +15,35 @@
 
 package meta
 
+import (
+	"strings"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
 type Index struct {
-	ShardNum    int64                  `json:"shard_num"`
-	Name        string                 `json:"name"`
-	StorageType string                 `json:"storage_type"`
-	Settings    *IndexSettings         `json:"settings,omitempty"`
-	Mappings    *Mappings              `json:"mappings,omitempty"`
-	Shards      map[string]*IndexShard `json:"shards"`
-	Stats       IndexStat              `json:"stats"`
-	Version     string                 `json:"version"`
+	ShardNum     int64                  `json:"shard_num"`
+	Name         string                 `json:"name"`
+	StorageType  string                 `json:"storage_type"`
+	Settings     *IndexSettings         `json:"settings,omitempty"`
+	Mappings     *Mappings              `json:"mappings,omitempty"`
+	Shards       map[string]*IndexShard `json:"shards"`
+	Stats        IndexStat              `json:"stats"`
+	Version      string                 `json:"version"`
+	CreatedAt    time.Time              `json:"created_at"`
+	State        string                 `json:"state"`
+	DataStream   string                 `json:"data_stream,omitempty"` // the data stream of the backing index
+	RolledOverAt time.Time              `json:"rolled_over_at"`        // the time the index stopped taking the writes of its alias or data stream
+	// AnalysisVersion is the meaning of the analysis settings the index is created with, see upgrade.AnalysisVersion
+	AnalysisVersion int `json:"analysis_version,omitempty"`
 }
 
+const (
+	IndexStateOpen  = "open"
+	IndexStateClose = "close"
+)
+
 type IndexShard struct {
 	ShardNum int64               `json:"shard_num"`
 	ID       string              `json:"id"`
@@ -56,9 +74,136 @@ type IndexSimple struct {
 }
 
 type IndexSettings struct {
-	NumberOfShards   int64          `json:"number_of_shards,omitempty"`
-	NumberOfReplicas int64          `json:"number_of_replicas,omitempty"`
-	Analysis         *IndexAnalysis `json:"analysis,omitempty"`
+	NumberOfShards   int64           `json:"number_of_shards,omitempty"`
+	NumberOfReplicas int64           `json:"number_of_replicas,omitempty"`
+	Analysis         *IndexAnalysis  `json:"analysis,omitempty"`
+	Search           *IndexSearch    `json:"search,omitempty"`
+	RefreshInterval  string          `json:"refresh_interval,omitempty"` // e.g. 1s, -1 to disable
+	Indexing         *IndexIndexing  `json:"indexing,omitempty"`
+	Lifecycle        *IndexLifecycle `json:"lifecycle,omitempty"`
+}
+
+// IndexLifecycle attaches the lifecycle policy to the index, e.g. {"index.lifecycle.name": "logs"}.
+// The rollover of the policy rolls over the data stream of the index, or the rollover alias.
+type IndexLifecycle struct {
+	Name          string `json:"name,omitempty"`
+	RolloverAlias string `json:"rollover_alias,omitempty"`
+}
+
+// IndexIndexing tunes the writes of the index, the zero values use the global config.
+// The batch settings apply to the next batch, the writer settings apply when the shard writers are opened again.
+type IndexIndexing struct {
+	BatchSize  int    `json:"batch_size,omitempty"`  // documents of a shard written from WAL at once, ZINC_BATCH_SIZE
+	BatchBytes string `json:"batch_bytes,omitempty"` // e.g. 32mb, WAL bytes written at once, ZINC_BATCH_MAX_BYTES
+	Workers    int    `json:"workers,omitempty"`     // analysis goroutines of every shard writer, ZINC_SHARD_INDEXING_WORKERS
+	BufferSize string `json:"buffer_size,omitempty"` // e.g. 1mb, the merge buffer of every shard writer, ZINC_SHARD_WRITER_BUFFER_SIZE
+}
+
+// UnmarshalJSON accepts the settings in es style, with the optional index prefix
+// and dotted keys, e.g. {"index.search.slowlog.threshold": "500ms"}
+func (s *IndexSettings) UnmarshalJSON(data []byte) error {
+	type indexSettings IndexSettings
+	if err := json.Unmarshal(data, (*indexSettings)(s)); err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	normalized := true
+	for k := range raw {
+		if k == "index" || strings.Contains(k, ".") {
+			normalized = false
+			break
+		}
+	}
+	if normalized {
+		return nil
+	}
+
+	settings := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		mergeSettings(settings, strings.Split(k, "."), v)
+	}
+	if v, ok := settings["index"].(map[string]interface{}); ok {
+		delete(settings, "index")
+		for k, v := range v {
+			mergeSettings(settings, strings.Split(k, "."), v)
+		}
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, (*indexSettings)(s))
+}
+
+// MergeSettings returns the settings overridden by the other settings,
+// the nested objects like the analysis are merged key by key
+func MergeSettings(settings, other *IndexSettings) (*IndexSettings, error) {
+	if other == nil {
+		return settings, nil
+	}
+	if settings == nil {
+		return other, nil
+	}
+
+	var dst, src map[string]interface{}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &dst); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(other); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &src); err != nil {
+		return nil, err
+	}
+	for k, v := range src {
+		mergeSettings(dst, []string{k}, v)
+	}
+	if data, err = json.Marshal(dst); err != nil {
+		return nil, err
+	}
+	merged := new(IndexSettings)
+	if err = json.Unmarshal(data, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// mergeSettings sets the value at the path of keys, merging the nested objects
+func mergeSettings(settings map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := settings[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			settings[key] = next
+		}
+		settings = next
+	}
+	key := keys[len(keys)-1]
+	if v, ok := value.(map[string]interface{}); ok {
+		if exist, ok := settings[key].(map[string]interface{}); ok {
+			for k, v := range v {
+				mergeSettings(exist, []string{k}, v)
+			}
+			return
+		}
+	}
+	settings[key] = value
+}
+
+type IndexSearch struct {
+	SlowLog *IndexSearchSlowLog `json:"slowlog,omitempty"`
+}
+
+type IndexSearchSlowLog struct {
+	Threshold string `json:"threshold,omitempty"` // e.g. 500ms, -1 to disable
 }
 
 type IndexAnalysis struct {
@@ -0,0 +1,34 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+// CloseIndexResponse is the response of the `_close` API
+type CloseIndexResponse struct {
+	Acknowledged       bool                        `json:"acknowledged"`
+	ShardsAcknowledged bool                        `json:"shards_acknowledged"`
+	Indices            map[string]CloseIndexResult `json:"indices"`
+}
+
+// CloseIndexResult is the close result of one index
+type CloseIndexResult struct {
+	Closed bool   `json:"closed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OpenIndexResponse is the response of the `_open` API
+type OpenIndexResponse struct {
+	Acknowledged       bool `json:"acknowledged"`
+	ShardsAcknowledged bool `json:"shards_acknowledged"`
+}
@@ -0,0 +1,110 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// IndexStatsResponse is the response of the `_stats` API
+type IndexStatsResponse struct {
+	Shards  StatsShards                 `json:"_shards"`
+	All     IndexStatsGroup             `json:"_all"`
+	Indices map[string]*IndexStatsGroup `json:"indices"`
+}
+
+type StatsShards struct {
+	Total      int64 `json:"total"`
+	Successful int64 `json:"successful"`
+	Failed     int64 `json:"failed"`
+}
+
+// IndexStatsGroup zinc has no replicas, so primaries and total are the same
+type IndexStatsGroup struct {
+	UUID      string      `json:"uuid,omitempty"`
+	Primaries *IndexStats `json:"primaries"`
+	Total     *IndexStats `json:"total"`
+}
+
+// IndexStats the metrics of an index, a nil metric was filtered out
+type IndexStats struct {
+	Docs     *IndexStatsDocs     `json:"docs,omitempty"`
+	Store    *IndexStatsStore    `json:"store,omitempty"`
+	Indexing *IndexStatsIndexing `json:"indexing,omitempty"`
+	Search   *IndexStatsSearch   `json:"search,omitempty"`
+	Segments *IndexStatsSegments `json:"segments,omitempty"`
+}
+
+type IndexStatsDocs struct {
+	Count   uint64 `json:"count"`
+	Deleted uint64 `json:"deleted"`
+}
+
+type IndexStatsStore struct {
+	SizeInBytes uint64 `json:"size_in_bytes"`
+}
+
+type IndexStatsIndexing struct {
+	IndexTotal  uint64 `json:"index_total"`
+	DeleteTotal uint64 `json:"delete_total"`
+}
+
+type IndexStatsSearch struct {
+	QueryTotal        uint64 `json:"query_total"`
+	QueryTimeInMillis uint64 `json:"query_time_in_millis"`
+}
+
+type IndexStatsSegments struct {
+	Count uint64 `json:"count"`
+}
+
+// NewIndexStats returns empty stats holding the given metrics
+func NewIndexStats(metrics []string) *IndexStats {
+	stats := new(IndexStats)
+	for _, metric := range metrics {
+		switch metric {
+		case "docs":
+			stats.Docs = new(IndexStatsDocs)
+		case "store":
+			stats.Store = new(IndexStatsStore)
+		case "indexing":
+			stats.Indexing = new(IndexStatsIndexing)
+		case "search":
+			stats.Search = new(IndexStatsSearch)
+		case "segments":
+			stats.Segments = new(IndexStatsSegments)
+		}
+	}
+	return stats
+}
+
+// Add merges other into s, metrics missing in s are ignored
+func (s *IndexStats) Add(other *IndexStats) {
+	if s.Docs != nil && other.Docs != nil {
+		s.Docs.Count += other.Docs.Count
+		s.Docs.Deleted += other.Docs.Deleted
+	}
+	if s.Store != nil && other.Store != nil {
+		s.Store.SizeInBytes += other.Store.SizeInBytes
+	}
+	if s.Indexing != nil && other.Indexing != nil {
+		s.Indexing.IndexTotal += other.Indexing.IndexTotal
+		s.Indexing.DeleteTotal += other.Indexing.DeleteTotal
+	}
+	if s.Search != nil && other.Search != nil {
+		s.Search.QueryTotal += other.Search.QueryTotal
+		s.Search.QueryTimeInMillis += other.Search.QueryTimeInMillis
+	}
+	if s.Segments != nil && other.Segments != nil {
+		s.Segments.Count += other.Segments.Count
+	}
+}
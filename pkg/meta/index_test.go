@@ -0,0 +1,88 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestIndexSettings_UnmarshalJSON(t *testing.T) {
+	slowlog := &IndexSearch{SlowLog: &IndexSearchSlowLog{Threshold: "500ms"}}
+	tests := []struct {
+		name    string
+		data    string
+		want    *IndexSettings
+		wantErr bool
+	}{
+		{
+			name: "nested",
+			data: `{"number_of_shards":3,"search":{"slowlog":{"threshold":"500ms"}}}`,
+			want: &IndexSettings{NumberOfShards: 3, Search: slowlog},
+		},
+		{
+			name: "index prefix",
+			data: `{"index":{"number_of_shards":3,"search":{"slowlog":{"threshold":"500ms"}}}}`,
+			want: &IndexSettings{NumberOfShards: 3, Search: slowlog},
+		},
+		{
+			name: "dotted keys",
+			data: `{"index.number_of_shards":3,"index.search.slowlog.threshold":"500ms"}`,
+			want: &IndexSettings{NumberOfShards: 3, Search: slowlog},
+		},
+		{
+			name: "mixed",
+			data: `{"index":{"search.slowlog":{"threshold":"500ms"}},"number_of_replicas":1}`,
+			want: &IndexSettings{NumberOfReplicas: 1, Search: slowlog},
+		},
+		{
+			name: "analysis",
+			data: `{"analysis":{"analyzer":{"my.analyzer":{"type":"standard"}}}}`,
+			want: &IndexSettings{Analysis: &IndexAnalysis{Analyzer: map[string]*Analyzer{"my.analyzer": {Type: "standard"}}}},
+		},
+		{
+			name:    "error",
+			data:    `{"number_of_shards":"x"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(IndexSettings)
+			err := json.Unmarshal([]byte(tt.data), got)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMergeSettings(t *testing.T) {
+	settings := &IndexSettings{
+		NumberOfShards: 3,
+		Analysis:       &IndexAnalysis{Analyzer: map[string]*Analyzer{"a": {Type: "standard"}}},
+	}
+	other := &IndexSettings{
+		NumberOfReplicas: 1,
+		Analysis:         &IndexAnalysis{Analyzer: map[string]*Analyzer{"b": {Type: "keyword"}}},
+	}
+
+	got, err := MergeSettings(settings, other)
+	assert.NoError(t, err)
+	assert.Equal(t, &IndexSettings{
+		NumberOfShards:   3,
+		NumberOfReplicas: 1,
+		Analysis:         &IndexAnalysis{Analyzer: map[string]*Analyzer{"a": {Type: "standard"}, "b": {Type: "keyword"}}},
+	}, got)
+
+	got, err = MergeSettings(nil, other)
+	assert.NoError(t, err)
+	assert.Equal(t, other, got)
+	got, err = MergeSettings(settings, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, settings, got)
+}
@@ -0,0 +1,30 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// IndicesOptions controls how the index names of a request expand to indexes
+type IndicesOptions struct {
+	IgnoreUnavailable bool // the missing and closed indexes named explicitly are skipped
+	AllowNoIndices    bool // a wildcard or _all matching no index is not an error
+	ExpandOpen        bool // the wildcards match the open indexes
+	ExpandClosed      bool // the wildcards match the closed indexes
+}
+
+// NewIndicesOptions returns the default options, the wildcards match the open indexes
+// and matching no index is not an error
+func NewIndicesOptions() *IndicesOptions {
+	return &IndicesOptions{AllowNoIndices: true, ExpandOpen: true}
+}
@@ -0,0 +1,61 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+import "time"
+
+// Lifecycle phases and actions
+const (
+	LifecyclePhaseHot    = "hot"
+	LifecyclePhaseDelete = "delete"
+)
+
+// LifecyclePolicy rolls over and deletes the indexes using it as they age
+type LifecyclePolicy struct {
+	Name         string        `json:"name"`
+	Version      int64         `json:"version"`
+	ModifiedDate time.Time     `json:"modified_date"`
+	Policy       LifecycleSpec `json:"policy"`
+}
+
+type LifecyclePolicyRequest struct {
+	Policy LifecycleSpec `json:"policy"`
+}
+
+type LifecycleSpec struct {
+	Phases map[string]*LifecyclePhase `json:"phases"` // hot and delete
+}
+
+// LifecyclePhase applies its actions to the index from the min_age, the age of the index
+// is from the rollover of it if it rolled over, or from the creation of it.
+type LifecyclePhase struct {
+	MinAge  string           `json:"min_age,omitempty"` // like 30d, 12h
+	Actions LifecycleActions `json:"actions"`
+}
+
+type LifecycleActions struct {
+	Rollover *RolloverConditions    `json:"rollover,omitempty"` // the hot phase only
+	Delete   *LifecycleDeleteAction `json:"delete,omitempty"`   // the delete phase only
+}
+
+type LifecycleDeleteAction struct{}
+
+// LifecyclePolicyResponse is the policy returned by name
+type LifecyclePolicyResponse struct {
+	Version      int64         `json:"version"`
+	ModifiedDate time.Time     `json:"modified_date"`
+	Policy       LifecycleSpec `json:"policy"`
+}
@@ -17,18 +17,34 @@ package meta
 
 import (
 	"bytes"
+	"sort"
 	"sync"
 
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 type Mappings struct {
-	Properties map[string]Property `json:"properties,omitempty"`
-	lock       sync.RWMutex
+	Properties       map[string]Property     `json:"properties,omitempty"`
+	Runtime          map[string]RuntimeField `json:"runtime,omitempty"`
+	DynamicTemplates []*DynamicTemplate      `json:"dynamic_templates,omitempty"`
+	Source           *SourceMapping          `json:"_source,omitempty"`
+	lock             sync.RWMutex
+}
+
+// SourceMapping controls storing the original documents, without them the hits have no _source
+// and the documents can't be updated partially
+type SourceMapping struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RuntimeField is a field computed by the script from the doc values of other fields at query time
+type RuntimeField struct {
+	Type   string  `json:"type"` // keyword, long, double, date, boolean
+	Script *Script `json:"script"`
 }
 
 type Property struct {
-	Type           string `json:"type"` // text, keyword, date, numeric, boolean, geo_point
+	Type           string `json:"type"` // text, keyword, date, numeric, boolean, completion, geo_point, nested
 	Analyzer       string `json:"analyzer,omitempty"`
 	SearchAnalyzer string `json:"search_analyzer,omitempty"`
 	Format         string `json:"format,omitempty"`    // date format yyyy-MM-dd HH:mm:ss || yyyy-MM-dd || epoch_millis
@@ -38,12 +54,17 @@ type Property struct {
 	Sortable       bool   `json:"sortable"`
 	Aggregatable   bool   `json:"aggregatable"`
 	Highlightable  bool   `json:"highlightable"`
+	// IgnoreAbove skips indexing the keyword values longer than it, they are still in the source
+	IgnoreAbove int `json:"ignore_above,omitempty"`
+	// CopyTo are the fields the value is copied to at index time, the copies are not in the source
+	CopyTo []string `json:"copy_to,omitempty"`
+	// NullValue is indexed in place of an explicit null value, the source keeps the null
+	NullValue interface{} `json:"null_value,omitempty"`
 	// Fields allow the same string value to be indexed in multiple ways for different purposes,
 	// such as one field for search and a multi-field for sorting and aggregations,
 	// or the same string value analyzed by different analyzers.
 	// If the Fields property is defined within a sub-field, it will be ignored.
-	//
-	// Currently, only "text" fields support the Fields parameter.
+	// The multi-fields are also in the mappings by the dotted path, e.g. title.raw
 	Fields map[string]Property `json:"fields,omitempty"`
 }
 
@@ -67,7 +88,7 @@ func NewProperty(typ string) Property {
 		Highlightable:  false,
 		Fields:         make(map[string]Property),
 	}
-	if typ == "text" {
+	if typ == "text" || typ == "completion" || typ == "nested" {
 		p.Sortable = false
 		p.Aggregatable = false
 	}
@@ -97,6 +118,11 @@ func (p *Property) DeepClone() Property {
 	prop.Sortable = p.Sortable
 	prop.Aggregatable = p.Aggregatable
 	prop.Highlightable = p.Highlightable
+	prop.IgnoreAbove = p.IgnoreAbove
+	prop.NullValue = p.NullValue
+	if p.CopyTo != nil {
+		prop.CopyTo = append([]string{}, p.CopyTo...)
+	}
 
 	if p.Fields != nil {
 		for k, v := range p.Fields {
@@ -129,6 +155,81 @@ func (t *Mappings) GetProperty(field string) (Property, bool) {
 	return prop, ok
 }
 
+// SetRuntime adds or replaces the runtime field
+func (t *Mappings) SetRuntime(field string, runtime RuntimeField) {
+	t.lock.Lock()
+	if t.Runtime == nil {
+		t.Runtime = make(map[string]RuntimeField)
+	}
+	t.Runtime[field] = runtime
+	t.lock.Unlock()
+}
+
+func (t *Mappings) GetRuntime(field string) (RuntimeField, bool) {
+	t.lock.RLock()
+	runtime, ok := t.Runtime[field]
+	t.lock.RUnlock()
+	return runtime, ok
+}
+
+func (t *Mappings) ListRuntime() map[string]RuntimeField {
+	m := make(map[string]RuntimeField)
+	t.lock.RLock()
+	for k, v := range t.Runtime {
+		m[k] = v
+	}
+	t.lock.RUnlock()
+	return m
+}
+
+// ListDynamicTemplates returns the dynamic templates in the order they are evaluated
+func (t *Mappings) ListDynamicTemplates() []*DynamicTemplate {
+	t.lock.RLock()
+	templates := make([]*DynamicTemplate, len(t.DynamicTemplates))
+	copy(templates, t.DynamicTemplates)
+	t.lock.RUnlock()
+	return templates
+}
+
+// SetDynamicTemplate replaces the dynamic template with the same name or appends it
+func (t *Mappings) SetDynamicTemplate(template *DynamicTemplate) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i, tpl := range t.DynamicTemplates {
+		if tpl.Name == template.Name {
+			t.DynamicTemplates[i] = template
+			return
+		}
+	}
+	t.DynamicTemplates = append(t.DynamicTemplates, template)
+}
+
+// MatchDynamicTemplate returns the first dynamic template matching the new field
+func (t *Mappings) MatchDynamicTemplate(path, mappingType string) (*DynamicTemplate, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	for _, tpl := range t.DynamicTemplates {
+		if tpl.Matches(path, mappingType) {
+			return tpl, true
+		}
+	}
+	return nil, false
+}
+
+// NestedPaths returns the sorted paths of the nested fields
+func (t *Mappings) NestedPaths() []string {
+	paths := make([]string, 0)
+	t.lock.RLock()
+	for k, v := range t.Properties {
+		if v.Type == "nested" {
+			paths = append(paths, k)
+		}
+	}
+	t.lock.RUnlock()
+	sort.Strings(paths)
+	return paths
+}
+
 func (t *Mappings) ListProperty() map[string]Property {
 	m := make(map[string]Property)
 	t.lock.RLock()
@@ -139,6 +240,24 @@ func (t *Mappings) ListProperty() map[string]Property {
 	return m
 }
 
+func (t *Mappings) SetSource(source *SourceMapping) {
+	t.lock.Lock()
+	t.Source = source
+	t.lock.Unlock()
+}
+
+func (t *Mappings) GetSource() *SourceMapping {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.Source
+}
+
+// SourceEnabled returns whether the original documents are stored, they are by default
+func (t *Mappings) SourceEnabled() bool {
+	source := t.GetSource()
+	return source == nil || source.Enabled
+}
+
 // DeepClone returns a full copy of the mapping.
 func (t *Mappings) DeepClone() *Mappings {
 	m := NewMappings()
@@ -149,6 +268,20 @@ func (t *Mappings) DeepClone() *Mappings {
 	for k, v := range t.Properties {
 		m.Properties[k] = v.DeepClone()
 	}
+	for k, v := range t.Runtime {
+		if m.Runtime == nil {
+			m.Runtime = make(map[string]RuntimeField)
+		}
+		m.Runtime[k] = v
+	}
+	for _, tpl := range t.DynamicTemplates {
+		tpl := *tpl
+		tpl.Mapping = tpl.Mapping.DeepClone()
+		m.DynamicTemplates = append(m.DynamicTemplates, &tpl)
+	}
+	if t.Source != nil {
+		m.Source = &SourceMapping{Enabled: t.Source.Enabled}
+	}
 
 	return m
 }
@@ -163,6 +296,30 @@ func (t *Mappings) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 	b.Write(p)
+	if len(t.Runtime) > 0 {
+		b.WriteString(`,"runtime":`)
+		r, err := json.Marshal(t.Runtime)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(r)
+	}
+	if len(t.DynamicTemplates) > 0 {
+		b.WriteString(`,"dynamic_templates":`)
+		d, err := json.Marshal(t.DynamicTemplates)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(d)
+	}
+	if t.Source != nil {
+		b.WriteString(`,"_source":`)
+		s, err := json.Marshal(t.Source)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(s)
+	}
 	b.WriteByte('}')
 	return b.Bytes(), nil
 }
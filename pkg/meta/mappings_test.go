@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 func TestProperty_DeepClone(t *testing.T) {
@@ -22,3 +24,49 @@ func TestProperty_DeepClone(t *testing.T) {
 	clone := prop.DeepClone()
 	assert.Equal(t, prop, clone)
 }
+
+func TestDynamicTemplate_Matches(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    DynamicTemplate
+		path        string
+		mappingType string
+		want        bool
+	}{
+		{"mapping type", DynamicTemplate{MatchMappingType: "string"}, "a", "string", true},
+		{"other mapping type", DynamicTemplate{MatchMappingType: "string"}, "a", "long", false},
+		{"any mapping type", DynamicTemplate{MatchMappingType: "*"}, "a", "date", true},
+		{"match suffix", DynamicTemplate{Match: "*_id"}, "user.org_id", "string", true},
+		{"match name only", DynamicTemplate{Match: "user*"}, "user.name", "string", false},
+		{"match middle", DynamicTemplate{Match: "a*b*c"}, "axxbyyc", "string", true},
+		{"unmatch", DynamicTemplate{Match: "*_id", Unmatch: "skip_*"}, "skip_id", "string", false},
+		{"path match", DynamicTemplate{PathMatch: "user.*"}, "user.name", "string", true},
+		{"path unmatch", DynamicTemplate{PathMatch: "user.*", PathUnmatch: "*.age"}, "user.age", "long", false},
+		{"regex", DynamicTemplate{Match: `^\d+$`, MatchPattern: DynamicTemplateMatchRegex}, "x.123", "string", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.template.Matches(tt.path, tt.mappingType))
+		})
+	}
+}
+
+func TestMappings_DynamicTemplatesJSON(t *testing.T) {
+	data := `{"properties":{},"dynamic_templates":[{"strings_as_keyword":{"match_mapping_type":"string","mapping":{"type":"keyword","index":true,"store":false,"sortable":true,"aggregatable":true,"highlightable":false}}}]}`
+	mappings := NewMappings()
+	assert.NoError(t, json.Unmarshal([]byte(data), mappings))
+	assert.Len(t, mappings.ListDynamicTemplates(), 1)
+	tpl, ok := mappings.MatchDynamicTemplate("name", "string")
+	assert.True(t, ok)
+	assert.Equal(t, "strings_as_keyword", tpl.Name)
+	assert.Equal(t, "keyword", tpl.Mapping.Type)
+
+	got, err := json.Marshal(mappings)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(got))
+
+	mappings.SetDynamicTemplate(&DynamicTemplate{Name: "strings_as_keyword", MatchMappingType: "long"})
+	assert.Len(t, mappings.ListDynamicTemplates(), 1)
+	_, ok = mappings.MatchDynamicTemplate("name", "string")
+	assert.False(t, ok)
+}
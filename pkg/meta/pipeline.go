@@ -0,0 +1,65 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+import "time"
+
+// Pipeline is a named list of ingest processors run on the documents before they are indexed
+type Pipeline struct {
+	ID          string                   `json:"id"`
+	Description string                   `json:"description,omitempty"`
+	Processors  []map[string]interface{} `json:"processors"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+type PipelineRequest struct {
+	Description string                   `json:"description,omitempty"`
+	Processors  []map[string]interface{} `json:"processors"`
+}
+
+type PipelineSimulateRequest struct {
+	Pipeline *PipelineRequest      `json:"pipeline,omitempty"`
+	Docs     []PipelineSimulateDoc `json:"docs"`
+}
+
+type PipelineSimulateDoc struct {
+	Index  string                 `json:"_index,omitempty"`
+	ID     string                 `json:"_id,omitempty"`
+	Source map[string]interface{} `json:"_source"`
+}
+
+type PipelineSimulateResponse struct {
+	Docs []PipelineSimulateResult `json:"docs"`
+}
+
+type PipelineSimulateResult struct {
+	Doc   *PipelineSimulateDoc `json:"doc,omitempty"`
+	Error error                `json:"error,omitempty"`
+}
+
+// GrokPattern is a custom grok pattern shared by all the pipelines
+type GrokPattern struct {
+	Name      string    `json:"name"`
+	Pattern   string    `json:"pattern"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type GrokPatternRequest struct {
+	Pattern string `json:"pattern"`
+}
+
+type GrokPatternsResponse struct {
+	Patterns map[string]string `json:"patterns"`
+}
@@ -0,0 +1,53 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+// SearchProfile is the timing breakdown of a search, compatible with the ES profile API
+type SearchProfile struct {
+	Phases ProfilePhases  `json:"phases"`
+	Shards []ProfileShard `json:"shards"`
+}
+
+type ProfilePhases struct {
+	ParseTimeInNanos       int64 `json:"parse_time_in_nanos"`
+	CollectTimeInNanos     int64 `json:"collect_time_in_nanos"`
+	FetchTimeInNanos       int64 `json:"fetch_time_in_nanos"`
+	AggregationTimeInNanos int64 `json:"aggregation_time_in_nanos"`
+}
+
+type ProfileShard struct {
+	ID       string          `json:"id"`
+	Searches []ProfileSearch `json:"searches"`
+}
+
+type ProfileSearch struct {
+	Query       []ProfileQuery     `json:"query"`
+	RewriteTime int64              `json:"rewrite_time"`
+	Collector   []ProfileCollector `json:"collector"`
+}
+
+type ProfileQuery struct {
+	Type        string           `json:"type"`
+	Description string           `json:"description"`
+	TimeInNanos int64            `json:"time_in_nanos"`
+	Breakdown   map[string]int64 `json:"breakdown"`
+	Children    []ProfileQuery   `json:"children,omitempty"`
+}
+
+type ProfileCollector struct {
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+	TimeInNanos int64  `json:"time_in_nanos"`
+}
@@ -15,21 +15,50 @@
 
 package meta
 
-import "github.com/zincsearch/zincsearch/pkg/bluge/aggregation"
+import (
+	"context"
+
+	"github.com/zincsearch/zincsearch/pkg/bluge/aggregation"
+)
 
 // ZincQuery is the query object for the zinc index. compatible ES Query DSL
 type ZincQuery struct {
 	Query          interface{}             `json:"query"`
 	Aggregations   map[string]Aggregations `json:"aggs"`
 	Highlight      *Highlight              `json:"highlight"`
-	Fields         interface{}             `json:"fields"`  // ["field1", "field2.*", {"field": "fieldName", "format": "epoch_millis"}]
-	Source         interface{}             `json:"_source"` // true, false, ["field1", "field2.*"]
-	Sort           interface{}             `json:"sort"`    // "_score", ["+Year","-Year", {"Year": "desc"}, "Date": {"order": "asc"", "format": "yyyy-MM-dd"}}"}]
+	Fields         interface{}             `json:"fields"`          // ["field1", "field2.*", {"field": "fieldName", "format": "epoch_millis"}]
+	DocValueFields interface{}             `json:"docvalue_fields"` // same as fields, merged into fields
+	StoredFields   interface{}             `json:"stored_fields"`   // "_none_", "field1,field2", ["field1", "field2.*"], the fields with store in the mappings
+	Source         interface{}             `json:"_source"`         // true, false, ["field1", "field2.*"]
+	Sort           interface{}             `json:"sort"`            // "_score", ["+Year","-Year", {"Year": "desc"}, "Date": {"order": "asc"", "format": "yyyy-MM-dd"}}"}]
 	Explain        bool                    `json:"explain"`
 	From           int                     `json:"from"`
 	Size           int                     `json:"size"`
-	Timeout        int                     `json:"timeout"`
-	TrackTotalHits bool                    `json:"track_total_hits"`
+	MinScore       float64                 `json:"min_score"`        // hits scoring less are neither returned nor counted
+	Timeout        interface{}             `json:"timeout"`          // "2s", "500ms", or seconds as a number
+	TrackTotalHits interface{}             `json:"track_total_hits"` // true, false, 10000
+	Suggest        map[string]*Suggest     `json:"suggest"`
+	Collapse       *Collapse               `json:"collapse"`
+	Rescore        *Rescore                `json:"rescore"`
+	Profile        bool                    `json:"profile"`
+	SearchAfter    []interface{}           `json:"search_after"`  // sort values of the last hit of the previous page
+	IndicesBoost   interface{}             `json:"indices_boost"` // [{"index": 2.0}] or {"index": 2.0}
+	// Privileges are the index privileges of the user, they are set by the handlers and nil for the admins
+	Privileges []*RoleIndices `json:"-"`
+	// Preference pins the searches with the same value to a snapshot of the index, it is set by the handlers
+	Preference string `json:"-"`
+	// Routing limits the search to the shards of the routing values, it is set by the handlers
+	Routing []string `json:"-"`
+	// Boosts multiply the scores of the hits of each reader, they are set from the indices_boost by the searches
+	Boosts []float64 `json:"-"`
+	// Context cancels the search when it is done, nil is the background context
+	Context context.Context `json:"-"`
+}
+
+// IndexBoost multiplies the scores of the hits of the indexes matching the name
+type IndexBoost struct {
+	Index string
+	Boost float64
 }
 
 type ZincQueryForSDK struct {
@@ -44,11 +73,17 @@ type ZincQueryForSDK struct {
 	Size           int                     `json:"size"`
 	Timeout        int                     `json:"timeout"`
 	TrackTotalHits bool                    `json:"track_total_hits"`
+	Suggest        map[string]*Suggest     `json:"suggest"`
+	Collapse       *Collapse               `json:"collapse"`
 }
 
 type Query struct {
 	Bool              *BoolQuery                         `json:"bool,omitempty"`                // .
-	Boosting          *BoostingQuery                     `json:"boosting,omitempty"`            // TODO: not implemented
+	Boosting          *BoostingQuery                     `json:"boosting,omitempty"`            // .
+	ConstantScore     *ConstantScoreQuery                `json:"constant_score,omitempty"`      // .
+	DisMax            *DisMaxQuery                       `json:"dis_max,omitempty"`             // .
+	FunctionScore     *FunctionScoreQuery                `json:"function_score,omitempty"`      // .
+	ScriptScore       *ScriptScoreQuery                  `json:"script_score,omitempty"`        // .
 	Match             map[string]*MatchQuery             `json:"match,omitempty"`               // simple, MatchQuery
 	MatchBoolPrefix   map[string]*MatchBoolPrefixQuery   `json:"match_bool_prefix,omitempty"`   // simple, MatchBoolPrefixQuery
 	MatchPhrase       map[string]*MatchPhraseQuery       `json:"match_phrase,omitempty"`        // simple, MatchPhraseQuery
@@ -56,6 +91,7 @@ type Query struct {
 	MultiMatch        *MultiMatchQuery                   `json:"multi_match,omitempty"`         // .
 	MatchAll          *MatchAllQuery                     `json:"match_all,omitempty"`           // just set or null
 	MatchNone         *MatchNoneQuery                    `json:"match_none,omitempty"`          // just set or null
+	Nested            *NestedQuery                       `json:"nested,omitempty"`              // .
 	CombinedFields    *CombinedFieldsQuery               `json:"combined_fields,omitempty"`     // TODO: not implemented
 	QueryString       *QueryStringQuery                  `json:"query_string,omitempty"`        // .
 	SimpleQueryString *SimpleQueryStringQuery            `json:"simple_query_string,omitempty"` // .
@@ -69,6 +105,9 @@ type Query struct {
 	Term              map[string]*TermQuery              `json:"term,omitempty"`                // simple, TermQuery
 	Terms             map[string]*TermsQuery             `json:"terms,omitempty"`               // .
 	TermsSet          map[string]*TermsSetQuery          `json:"terms_set,omitempty"`           // TODO: not implemented
+	SpanTerm          map[string]interface{}             `json:"span_term,omitempty"`           // simple, SpanTermQuery
+	SpanNear          *SpanNearQuery                     `json:"span_near,omitempty"`           // .
+	SpanFirst         *SpanFirstQuery                    `json:"span_first,omitempty"`          // .
 	GeoBoundingBox    interface{}                        `json:"geo_bounding_box,omitempty"`    // TODO: not implemented
 	GeoDistance       interface{}                        `json:"geo_distance,omitempty"`        // TODO: not implemented
 	GeoPolygon        interface{}                        `json:"geo_polygon,omitempty"`         // TODO: not implemented
@@ -117,6 +156,87 @@ type BoostingQuery struct {
 	Positive      interface{} `json:"positive,omitempty"` // singe or multiple queries
 	Negative      interface{} `json:"negative,omitempty"` // singe or multiple queries
 	NegativeBoost float64     `json:"negative_boost,omitempty"`
+	Boost         float64     `json:"boost,omitempty"`
+}
+
+type ConstantScoreQuery struct {
+	Filter interface{} `json:"filter"`
+	Boost  float64     `json:"boost,omitempty"` // default 1
+}
+
+type DisMaxQuery struct {
+	Queries    []interface{} `json:"queries"`
+	TieBreaker float64       `json:"tie_breaker,omitempty"` // default 0
+	Boost      float64       `json:"boost,omitempty"`
+}
+
+// NestedQuery
+// {"nested":{"path":"authors","query":{"match":{"authors.name":"john"}},"score_mode":"avg","inner_hits":{}}}
+type NestedQuery struct {
+	Path           string           `json:"path"`
+	Query          interface{}      `json:"query"`
+	ScoreMode      string           `json:"score_mode,omitempty"` // avg(default), max, min, sum, none
+	IgnoreUnmapped bool             `json:"ignore_unmapped,omitempty"`
+	InnerHits      *NestedInnerHits `json:"inner_hits,omitempty"`
+	Boost          float64          `json:"boost,omitempty"`
+}
+
+type NestedInnerHits struct {
+	Name string `json:"name,omitempty"` // default is the path
+	From int    `json:"from,omitempty"`
+	Size int    `json:"size,omitempty"` // default 3
+}
+
+type FunctionScoreQuery struct {
+	Query          interface{}      `json:"query,omitempty"`
+	Functions      []*ScoreFunction `json:"functions,omitempty"`
+	*ScoreFunction                  // single function shorthand
+	ScoreMode      string           `json:"score_mode,omitempty"` // multiply, sum, avg, first, max, min
+	BoostMode      string           `json:"boost_mode,omitempty"` // multiply, replace, sum, avg, max, min
+	MaxBoost       float64          `json:"max_boost,omitempty"`
+	MinScore       float64          `json:"min_score,omitempty"`
+	Boost          float64          `json:"boost,omitempty"`
+}
+
+type ScoreFunction struct {
+	Filter           interface{}               `json:"filter,omitempty"`
+	Weight           float64                   `json:"weight,omitempty"`
+	FieldValueFactor *FieldValueFactorFunction `json:"field_value_factor,omitempty"`
+	Gauss            map[string]interface{}    `json:"gauss,omitempty"`  // field: DecayFunction, multi_value_mode
+	Exp              map[string]interface{}    `json:"exp,omitempty"`    // field: DecayFunction, multi_value_mode
+	Linear           map[string]interface{}    `json:"linear,omitempty"` // field: DecayFunction, multi_value_mode
+	ScriptScore      *ScriptScoreFunction      `json:"script_score,omitempty"`
+}
+
+type FieldValueFactorFunction struct {
+	Field    string      `json:"field,omitempty"`
+	Factor   float64     `json:"factor,omitempty"`
+	Modifier string      `json:"modifier,omitempty"` // none, log, log1p, log2p, ln, ln1p, ln2p, square, sqrt, reciprocal
+	Missing  interface{} `json:"missing,omitempty"`
+}
+
+type DecayFunction struct {
+	Origin interface{} `json:"origin,omitempty"` // number, date or geo_point
+	Scale  interface{} `json:"scale,omitempty"`  // number, 10d, 2km
+	Offset interface{} `json:"offset,omitempty"` // number, 10d, 2km
+	Decay  float64     `json:"decay,omitempty"`
+}
+
+type ScriptScoreFunction struct {
+	Script interface{} `json:"script,omitempty"` // source, Script
+}
+
+type ScriptScoreQuery struct {
+	Query    interface{} `json:"query,omitempty"`
+	Script   interface{} `json:"script,omitempty"` // source, Script
+	MinScore float64     `json:"min_score,omitempty"`
+	Boost    float64     `json:"boost,omitempty"`
+}
+
+type Script struct {
+	Source string                 `json:"source,omitempty"` // e.g. _score * log(1 + doc['views'].value)
+	Lang   string                 `json:"lang,omitempty"`   // painless, expression
+	Params map[string]interface{} `json:"params,omitempty"` // numeric params
 }
 
 type MatchAllQuery struct{}
@@ -145,9 +265,11 @@ type MatchPhraseQuery struct {
 }
 
 type MatchPhrasePrefixQuery struct {
-	Query    string  `json:"query,omitempty"`
-	Analyzer string  `json:"analyzer,omitempty"`
-	Boost    float64 `json:"boost,omitempty"`
+	Query         string  `json:"query,omitempty"`
+	Analyzer      string  `json:"analyzer,omitempty"`
+	Slop          int     `json:"slop,omitempty"`
+	MaxExpansions int     `json:"max_expansions,omitempty"` // default 50
+	Boost         float64 `json:"boost,omitempty"`
 }
 
 type MultiMatchQuery struct {
@@ -158,6 +280,12 @@ type MultiMatchQuery struct {
 	Type               string      `json:"type,omitempty"`     // best_fields(default), most_fields, cross_fields, phrase, phrase_prefix, bool_prefix
 	Operator           string      `json:"operator,omitempty"` // or(default), and
 	MinimumShouldMatch interface{} `json:"minimum_should_match,omitempty"`
+	TieBreaker         float64     `json:"tie_breaker,omitempty"`
+	Fuzziness          interface{} `json:"fuzziness,omitempty"`
+	PrefixLength       float64     `json:"prefix_length,omitempty"`
+	Slop               int         `json:"slop,omitempty"`
+	MaxExpansions      int         `json:"max_expansions,omitempty"`
+	Lenient            bool        `json:"lenient,omitempty"`
 }
 
 type CombinedFieldsQuery struct {
@@ -174,16 +302,21 @@ type QueryStringQuery struct {
 	Fields          []string `json:"fields,omitempty"`
 	DefaultField    string   `json:"default_field,omitempty"`
 	DefaultOperator string   `json:"default_operator,omitempty"` // or(default), and
+	Lenient         bool     `json:"lenient,omitempty"`
+	PhraseSlop      int      `json:"phrase_slop,omitempty"`
 	Boost           float64  `json:"boost,omitempty"`
 }
 
 type SimpleQueryStringQuery struct {
-	Query           string   `json:"query,omitempty"`
-	Analyzer        string   `json:"analyzer,omitempty"`
-	Fields          []string `json:"fields,omitempty"`
-	DefaultOperator string   `json:"default_operator,omitempty"` // or(default), and
-	AllFields       bool     `json:"all_fields,omitempty"`
-	Boost           float64  `json:"boost,omitempty"`
+	Query           string      `json:"query,omitempty"`
+	Analyzer        string      `json:"analyzer,omitempty"`
+	Fields          []string    `json:"fields,omitempty"`
+	DefaultOperator string      `json:"default_operator,omitempty"` // or(default), and
+	Flags           interface{} `json:"flags,omitempty"`            // ALL(default), NONE, AND, OR, NOT, PREFIX, PHRASE, PRECEDENCE, ESCAPE, WHITESPACE, FUZZY, NEAR, SLOP
+	AnalyzeWildcard bool        `json:"analyze_wildcard,omitempty"`
+	Lenient         bool        `json:"lenient,omitempty"`
+	AllFields       bool        `json:"all_fields,omitempty"`
+	Boost           float64     `json:"boost,omitempty"`
 }
 
 // ExistsQuery
@@ -276,6 +409,27 @@ type TermsQuery map[string]interface{}
 // TermsSetQuery ...
 type TermsSetQuery struct{}
 
+// SpanTermQuery {"span_term":{"title":{"value":"zinc"}}}
+type SpanTermQuery struct {
+	Value string  `json:"value"`
+	Boost float64 `json:"boost,omitempty"`
+}
+
+// SpanNearQuery {"span_near":{"clauses":[{"span_term":{"title":"zinc"}},{"span_term":{"title":"search"}}],"slop":1,"in_order":true}}
+type SpanNearQuery struct {
+	Clauses []interface{} `json:"clauses"`
+	Slop    int           `json:"slop,omitempty"`
+	InOrder bool          `json:"in_order"` // default true
+	Boost   float64       `json:"boost,omitempty"`
+}
+
+// SpanFirstQuery {"span_first":{"match":{"span_term":{"title":"zinc"}},"end":3}}
+type SpanFirstQuery struct {
+	Match interface{} `json:"match"`
+	End   int         `json:"end"`
+	Boost float64     `json:"boost,omitempty"`
+}
+
 type Aggregations struct {
 	Avg               *AggregationMetric            `json:"avg"`
 	WeightedAvg       *AggregationMetric            `json:"weighted_avg"`
@@ -385,12 +539,83 @@ type Highlight struct {
 	Fields            map[string]*Highlight `json:"fields"`
 }
 
+type Collapse struct {
+	Field     string             `json:"field"`
+	InnerHits *CollapseInnerHits `json:"inner_hits,omitempty"`
+}
+
+type CollapseInnerHits struct {
+	Name string `json:"name"`
+	From int    `json:"from"`
+	Size int    `json:"size"` // default 3
+}
+
+// Rescore re-scores the top hits with a more expensive query
+type Rescore struct {
+	WindowSize int           `json:"window_size"` // default 10
+	Query      *RescoreQuery `json:"query"`
+}
+
+type RescoreQuery struct {
+	RescoreQuery       interface{} `json:"rescore_query"`
+	QueryWeight        *float64    `json:"query_weight"`         // default 1
+	RescoreQueryWeight *float64    `json:"rescore_query_weight"` // default 1
+	ScoreMode          string      `json:"score_mode"`           // total, multiply, avg, max, min, default total
+}
+
+type Suggest struct {
+	Text       string               `json:"text"`
+	Prefix     string               `json:"prefix"`
+	Term       *TermSuggester       `json:"term,omitempty"`
+	Phrase     *PhraseSuggester     `json:"phrase,omitempty"`
+	Completion *CompletionSuggester `json:"completion,omitempty"`
+}
+
+type TermSuggester struct {
+	Field         string `json:"field"`
+	Analyzer      string `json:"analyzer"`
+	Size          int    `json:"size"`            // max options per token, default 5
+	Sort          string `json:"sort"`            // score, frequency, default score
+	SuggestMode   string `json:"suggest_mode"`    // missing, popular, always, default missing
+	MaxEdits      int    `json:"max_edits"`       // 1 or 2, default 2
+	PrefixLength  int    `json:"prefix_length"`   // default 1
+	MinWordLength int    `json:"min_word_length"` // default 4
+}
+
+type PhraseSuggester struct {
+	Field        string                    `json:"field"`
+	Analyzer     string                    `json:"analyzer"`
+	Size         int                       `json:"size"`       // max options, default 5
+	MaxErrors    float64                   `json:"max_errors"` // max corrected terms, less than 1 means a percentage of terms, default 1
+	MaxEdits     int                       `json:"max_edits"`  // 1 or 2, default 2
+	PrefixLength int                       `json:"prefix_length"`
+	Highlight    *PhraseSuggesterHighlight `json:"highlight,omitempty"`
+}
+
+type PhraseSuggesterHighlight struct {
+	PreTag  string `json:"pre_tag"`
+	PostTag string `json:"post_tag"`
+}
+
+type CompletionSuggester struct {
+	Field          string           `json:"field"`
+	Size           int              `json:"size"` // max options, default 5
+	SkipDuplicates bool             `json:"skip_duplicates"`
+	Fuzzy          *CompletionFuzzy `json:"fuzzy,omitempty"`
+}
+
+type CompletionFuzzy struct {
+	Fuzziness    int `json:"fuzziness"`     // 1 or 2, default 1
+	PrefixLength int `json:"prefix_length"` // default 1
+}
+
 type Field struct {
 	Field  string `json:"field"`
 	Format string `json:"format"`
 }
 
 type Source struct {
-	Enable bool     // enable _source returns, default is true
-	Fields []string // what fields can returns
+	Enable   bool     // enable _source returns, default is true
+	Fields   []string // what fields can returns, alias includes
+	Excludes []string // what fields can't returns, excludes take precedence over includes
 }
@@ -24,7 +24,10 @@ type SearchResponse struct {
 	Shards       Shards                         `json:"_shards"`
 	Hits         Hits                           `json:"hits"`
 	Aggregations map[string]AggregationResponse `json:"aggregations,omitempty"`
+	Suggest      map[string][]SuggestResponse   `json:"suggest,omitempty"`
+	Profile      *SearchProfile                 `json:"profile,omitempty"`
 	Error        string                         `json:"error,omitempty"`
+	Status       int                            `json:"status,omitempty"` // status of a search of _msearch
 }
 
 type Shards struct {
@@ -41,18 +44,38 @@ type Hits struct {
 }
 
 type Hit struct {
-	Index     string                 `json:"_index"`
-	Type      string                 `json:"_type"`
-	ID        string                 `json:"_id"`
-	Score     float64                `json:"_score"`
-	Timestamp time.Time              `json:"@timestamp"`
-	Source    interface{}            `json:"_source,omitempty"`
-	Fields    map[string]interface{} `json:"fields,omitempty"`
-	Highlight map[string]interface{} `json:"highlight,omitempty"`
+	Index       string                 `json:"_index"`
+	Type        string                 `json:"_type"`
+	ID          string                 `json:"_id"`
+	Nested      *NestedIdentity        `json:"_nested,omitempty"`
+	Score       float64                `json:"_score"`
+	Version     *int64                 `json:"_version,omitempty"`
+	SeqNo       *int64                 `json:"_seq_no,omitempty"`
+	PrimaryTerm *int64                 `json:"_primary_term,omitempty"`
+	Routing     string                 `json:"_routing,omitempty"`
+	Timestamp   time.Time              `json:"@timestamp"`
+	Source      interface{}            `json:"_source,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	Highlight   map[string]interface{} `json:"highlight,omitempty"`
+	InnerHits   map[string]InnerHit    `json:"inner_hits,omitempty"`
+	Sort        []interface{}          `json:"sort,omitempty"`
+	// MatchedQueries are the names of the named queries matching the hit
+	MatchedQueries []string `json:"matched_queries,omitempty"`
+}
+
+// NestedIdentity is the position of a nested object in the parent document
+type NestedIdentity struct {
+	Field  string `json:"field"`
+	Offset int    `json:"offset"`
+}
+
+type InnerHit struct {
+	Hits Hits `json:"hits"`
 }
 
 type Total struct {
-	Value int `json:"value"` // Count of documents returned
+	Value    int    `json:"value"`              // Count of documents returned
+	Relation string `json:"relation,omitempty"` // eq or gte when the count is capped by track_total_hits
 }
 
 type AggregationResponse struct {
@@ -60,3 +83,21 @@ type AggregationResponse struct {
 	Buckets  interface{} `json:"buckets,omitempty"`  // slice or map
 	Interval string      `json:"interval,omitempty"` // support for auto_date_histogram_aggregation
 }
+
+type SuggestResponse struct {
+	Text    string          `json:"text"`
+	Offset  int             `json:"offset"`
+	Length  int             `json:"length"`
+	Options []SuggestOption `json:"options"`
+}
+
+type SuggestOption struct {
+	Text        string      `json:"text"`
+	Highlighted string      `json:"highlighted,omitempty"`
+	Score       float64     `json:"score"`
+	Freq        uint64      `json:"freq,omitempty"`
+	Index       string      `json:"_index,omitempty"`  // completion only
+	ID          string      `json:"_id,omitempty"`     // completion only
+	DocScore    float64     `json:"_score,omitempty"`  // completion only, the weight of the suggestion
+	Source      interface{} `json:"_source,omitempty"` // completion only
+}
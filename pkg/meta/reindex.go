@@ -0,0 +1,56 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+type ReindexRequest struct {
+	Source    ReindexSource `json:"source"`
+	Dest      ReindexDest   `json:"dest"`
+	Slices    interface{}   `json:"slices,omitempty"`    // number of slices or "auto"
+	Conflicts string        `json:"conflicts,omitempty"` // abort or proceed, default is abort
+}
+
+type ReindexSource struct {
+	Index string      `json:"index"`
+	Size  int         `json:"size,omitempty"`  // batch size
+	Query interface{} `json:"query,omitempty"` // only copy the documents matched the query
+}
+
+type ReindexDest struct {
+	Index  string `json:"index"`
+	OpType string `json:"op_type,omitempty"` // index or create, default is index
+}
+
+type ReindexResponse struct {
+	Took             int64                `json:"took"`
+	TimedOut         bool                 `json:"timed_out"`
+	Total            int64                `json:"total"`
+	Created          int64                `json:"created"`
+	Updated          int64                `json:"updated"`
+	VersionConflicts int64                `json:"version_conflicts"`
+	Batches          int64                `json:"batches"`
+	Failures         []string             `json:"failures"`
+	Slices           []ReindexSliceStatus `json:"slices,omitempty"`
+}
+
+type ReindexSliceStatus struct {
+	SliceID          int      `json:"slice_id"`
+	Total            int64    `json:"total"`
+	Created          int64    `json:"created"`
+	Updated          int64    `json:"updated"`
+	VersionConflicts int64    `json:"version_conflicts"`
+	Batches          int64    `json:"batches"`
+	Failures         []string `json:"failures"`
+}
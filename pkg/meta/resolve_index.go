@@ -0,0 +1,45 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// ResolveIndexResponse is the response of the `_resolve/index` API, the names are classified
+// into the concrete indices, the aliases and the data streams they expand to
+type ResolveIndexResponse struct {
+	Indices     []*ResolvedIndex      `json:"indices"`
+	Aliases     []*ResolvedAlias      `json:"aliases"`
+	DataStreams []*ResolvedDataStream `json:"data_streams"`
+}
+
+// ResolvedIndex is a concrete index, the attributes are the state of the index
+type ResolvedIndex struct {
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Attributes []string `json:"attributes"`
+	DataStream string   `json:"data_stream,omitempty"` // the data stream of a backing index
+}
+
+// ResolvedAlias is an alias and the indices it points to
+type ResolvedAlias struct {
+	Name    string   `json:"name"`
+	Indices []string `json:"indices"`
+}
+
+// ResolvedDataStream is a data stream and its backing indices
+type ResolvedDataStream struct {
+	Name           string   `json:"name"`
+	BackingIndices []string `json:"backing_indices"`
+	TimestampField string   `json:"timestamp_field"`
+}
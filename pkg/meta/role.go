@@ -18,10 +18,44 @@ package meta
 import "time"
 
 type Role struct {
-	ID         string    `json:"_id"`
-	Name       string    `json:"name"`
-	Role       string    `json:"role"`
-	Permission []string  `json:"permission"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string   `json:"_id"`
+	Name       string   `json:"name"`
+	Role       string   `json:"role"`
+	Permission []string `json:"permission"`
+	// Indices limits the access to the documents of the matching indexes
+	Indices []*RoleIndices `json:"indices,omitempty"`
+	// RateLimit overrides the global rate limits for the users of the role
+	RateLimit *RoleRateLimit `json:"rate_limit,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// RoleIndices are the privileges of a role on the indexes matching the names, the names support wildcards
+type RoleIndices struct {
+	Names []string `json:"names"`
+	// Privileges are the permissions granted only on the matching indexes, like search.* or document.Get
+	Privileges    []string       `json:"privileges,omitempty"`
+	FieldSecurity *FieldSecurity `json:"field_security,omitempty"`
+	// Query limits the visible documents to the documents matching the query, like {"term": {"tenant": "acme"}}
+	Query interface{} `json:"query,omitempty"`
+}
+
+// FieldSecurity lists the fields visible to the role, the fields support wildcards
+// and include their sub fields, the except fields are removed from the granted fields
+type FieldSecurity struct {
+	Grant  []string `json:"grant"`
+	Except []string `json:"except,omitempty"`
+}
+
+// RoleRateLimit are the limits of the search and the indexing requests, a missing limit falls back to the global one
+type RoleRateLimit struct {
+	Search *RateLimit `json:"search,omitempty"`
+	Index  *RateLimit `json:"index,omitempty"`
+}
+
+// RateLimit is a token bucket refilled by rate requests per second up to burst requests,
+// a rate of 0 means no limit and the burst is the rate if it is 0
+type RateLimit struct {
+	Rate  int `json:"rate"`
+	Burst int `json:"burst,omitempty"`
 }
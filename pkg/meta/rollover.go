@@ -0,0 +1,34 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+type RolloverRequest struct {
+	Conditions RolloverConditions `json:"conditions"`
+}
+
+type RolloverConditions struct {
+	MaxAge  string `json:"max_age,omitempty"`  // like 7d, 12h
+	MaxDocs uint64 `json:"max_docs,omitempty"` // number of documents
+	MaxSize string `json:"max_size,omitempty"` // like 50gb, 100mb
+}
+
+type RolloverResponse struct {
+	Acknowledged bool            `json:"acknowledged"`
+	OldIndex     string          `json:"old_index"`
+	NewIndex     string          `json:"new_index"`
+	RolledOver   bool            `json:"rolled_over"`
+	DryRun       bool            `json:"dry_run"`
+	Conditions   map[string]bool `json:"conditions"`
+}
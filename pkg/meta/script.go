@@ -0,0 +1,34 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+import "time"
+
+// StoredScript is a named script which can be referenced by id from the queries
+type StoredScript struct {
+	ID        string    `json:"id"`
+	Script    Script    `json:"script"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StoredScriptRequest struct {
+	Script *Script `json:"script"`
+}
+
+type StoredScriptResponse struct {
+	ID     string  `json:"_id"`
+	Found  bool    `json:"found"`
+	Script *Script `json:"script,omitempty"`
+}
@@ -0,0 +1,89 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package meta
+
+import "time"
+
+const (
+	SnapshotRepositoryTypeFS = "fs"
+	SnapshotRepositoryTypeS3 = "s3"
+
+	SnapshotStateSuccess = "SUCCESS"
+)
+
+type SnapshotRepository struct {
+	Name     string                     `json:"name"`
+	Type     string                     `json:"type"` // fs or s3
+	Settings SnapshotRepositorySettings `json:"settings"`
+}
+
+type SnapshotRepositorySettings struct {
+	Location  string `json:"location,omitempty"`   // fs: the directory of the repository
+	Bucket    string `json:"bucket,omitempty"`     // s3: the bucket of the repository
+	Region    string `json:"region,omitempty"`     // s3: default is us-east-1
+	Endpoint  string `json:"endpoint,omitempty"`   // s3: default is https://s3.{region}.amazonaws.com
+	BasePath  string `json:"base_path,omitempty"`  // s3: the key prefix of the repository in the bucket
+	AccessKey string `json:"access_key,omitempty"` // s3: default from env AWS_ACCESS_KEY_ID
+	SecretKey string `json:"secret_key,omitempty"` // s3: default from env AWS_SECRET_ACCESS_KEY
+}
+
+type SnapshotRequest struct {
+	Indices interface{} `json:"indices,omitempty"` // index names as string or array, default is all the open indexes
+}
+
+type Snapshot struct {
+	Snapshot         string        `json:"snapshot"`
+	Repository       string        `json:"repository"`
+	Indices          []string      `json:"indices"`
+	State            string        `json:"state"`
+	StartTime        time.Time     `json:"start_time"`
+	EndTime          time.Time     `json:"end_time"`
+	DurationInMillis int64         `json:"duration_in_millis"`
+	Stats            SnapshotStats `json:"stats"`
+}
+
+type SnapshotStats struct {
+	Incremental SnapshotFileStats `json:"incremental"` // the files uploaded by this snapshot
+	Total       SnapshotFileStats `json:"total"`
+}
+
+type SnapshotFileStats struct {
+	FileCount   int   `json:"file_count"`
+	SizeInBytes int64 `json:"size_in_bytes"`
+}
+
+type SnapshotResponse struct {
+	Snapshot *Snapshot `json:"snapshot"`
+}
+
+type SnapshotListResponse struct {
+	Snapshots []*Snapshot `json:"snapshots"`
+}
+
+type RestoreSnapshotRequest struct {
+	Indices           interface{} `json:"indices,omitempty"` // index names as string or array, default is all the indexes in the snapshot
+	RenamePattern     string      `json:"rename_pattern,omitempty"`
+	RenameReplacement string      `json:"rename_replacement,omitempty"`
+}
+
+type RestoreSnapshotResponse struct {
+	Snapshot RestoreSnapshotInfo `json:"snapshot"`
+}
+
+type RestoreSnapshotInfo struct {
+	Snapshot string      `json:"snapshot"`
+	Indices  []string    `json:"indices"`
+	Shards   StatsShards `json:"shards"`
+}
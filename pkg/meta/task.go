@@ -0,0 +1,37 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// TaskSubmitResponse is returned instead of the response when a request runs as a task
+type TaskSubmitResponse struct {
+	Task string `json:"task"`
+}
+
+type TaskResponse struct {
+	Completed bool        `json:"completed"`
+	Task      TaskInfo    `json:"task"`
+	Response  interface{} `json:"response,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type TaskInfo struct {
+	Node               string      `json:"node"`
+	ID                 string      `json:"id"`
+	Action             string      `json:"action"`
+	StartTimeInMillis  int64       `json:"start_time_in_millis"`
+	RunningTimeInNanos int64       `json:"running_time_in_nanos"`
+	Status             interface{} `json:"status,omitempty"`
+}
@@ -24,7 +24,8 @@ type Template struct {
 
 type IndexTemplate struct {
 	IndexPatterns []string         `json:"index_patterns"`
-	Priority      int              `json:"priority"` // highest priority is chosen
+	Priority      int              `json:"priority"`              // highest priority is chosen
+	ComposedOf    []string         `json:"composed_of,omitempty"` // component templates merged in order before the template
 	Template      TemplateTemplate `json:"template"`
 	CreatedAt     time.Time        `json:"created_at"`
 	UpdatedAt     time.Time        `json:"updated_at"`
@@ -34,3 +35,15 @@ type TemplateTemplate struct {
 	Settings *IndexSettings `json:"settings,omitempty"`
 	Mappings *Mappings      `json:"mappings,omitempty"`
 }
+
+type ComponentTemplate struct {
+	Name              string                  `json:"name"`
+	ComponentTemplate *IndexComponentTemplate `json:"component_template"`
+}
+
+// IndexComponentTemplate is a reusable block of settings and mappings for the index templates
+type IndexComponentTemplate struct {
+	Template  TemplateTemplate `json:"template"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
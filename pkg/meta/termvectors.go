@@ -0,0 +1,100 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// TermVectorsRequest are the options of the term vectors of a document,
+// the nil options use their defaults
+type TermVectorsRequest struct {
+	Fields          []string `json:"fields,omitempty"`
+	Offsets         *bool    `json:"offsets,omitempty"`          // default true
+	Positions       *bool    `json:"positions,omitempty"`        // default true
+	TermStatistics  *bool    `json:"term_statistics,omitempty"`  // default false
+	FieldStatistics *bool    `json:"field_statistics,omitempty"` // default true
+
+	Privileges []*RoleIndices `json:"-"`
+}
+
+// Merge sets the options missing in the request from the defaults
+func (r *TermVectorsRequest) Merge(defaults *TermVectorsRequest) {
+	if defaults == nil {
+		return
+	}
+	if len(r.Fields) == 0 {
+		r.Fields = defaults.Fields
+	}
+	if r.Offsets == nil {
+		r.Offsets = defaults.Offsets
+	}
+	if r.Positions == nil {
+		r.Positions = defaults.Positions
+	}
+	if r.TermStatistics == nil {
+		r.TermStatistics = defaults.TermStatistics
+	}
+	if r.FieldStatistics == nil {
+		r.FieldStatistics = defaults.FieldStatistics
+	}
+}
+
+// MultiTermVectorsRequest gets the term vectors of the docs, or the ids with the same parameters
+type MultiTermVectorsRequest struct {
+	Docs       []*MultiTermVectorsDoc `json:"docs,omitempty"`
+	IDs        []string               `json:"ids,omitempty"`
+	Parameters *TermVectorsRequest    `json:"parameters,omitempty"`
+}
+
+type MultiTermVectorsDoc struct {
+	Index string `json:"_index,omitempty"`
+	ID    string `json:"_id"`
+	TermVectorsRequest
+}
+
+type TermVectorsResponse struct {
+	Index       string                 `json:"_index"`
+	ID          string                 `json:"_id"`
+	Found       bool                   `json:"found"`
+	Took        int64                  `json:"took"`
+	TermVectors map[string]*TermVector `json:"term_vectors,omitempty"`
+	Error       string                 `json:"error,omitempty"` // for _mtermvectors only
+}
+
+type MultiTermVectorsResponse struct {
+	Docs []*TermVectorsResponse `json:"docs"`
+}
+
+type TermVector struct {
+	FieldStatistics *TermVectorFieldStatistics `json:"field_statistics,omitempty"`
+	Terms           map[string]*TermVectorTerm `json:"terms"`
+}
+
+type TermVectorFieldStatistics struct {
+	SumDocFreq uint64 `json:"sum_doc_freq"`
+	DocCount   uint64 `json:"doc_count"`
+	SumTTF     uint64 `json:"sum_ttf"`
+}
+
+type TermVectorTerm struct {
+	DocFreq  *uint64           `json:"doc_freq,omitempty"`
+	TTF      *uint64           `json:"ttf,omitempty"`
+	TermFreq int               `json:"term_freq"`
+	Tokens   []TermVectorToken `json:"tokens,omitempty"`
+}
+
+type TermVectorToken struct {
+	Position    *int `json:"position,omitempty"`
+	StartOffset *int `json:"start_offset,omitempty"`
+	EndOffset   *int `json:"end_offset,omitempty"`
+}
@@ -17,11 +17,22 @@ package meta
 
 // Default field name
 const (
-	TimeFieldName   = "@timestamp"
-	IDFieldName     = "@_id"
-	ActionFieldName = "@_action"
-	ShardFieldName  = "@_shard"
-	SourceFieldName = "@_source"
+	TimeFieldName    = "@timestamp"
+	IDFieldName      = "@_id"
+	ActionFieldName  = "@_action"
+	ShardFieldName   = "@_shard"
+	SourceFieldName  = "@_source"
+	SeqNoFieldName   = "@_seq_no"
+	VersionFieldName = "@_version"
+	RoutingFieldName = "@_routing"
+)
+
+// Nested document field names, the objects of nested fields are indexed as hidden documents
+// next to the parent document
+const (
+	NestedPathFieldName   = "_nested_path"
+	NestedParentFieldName = "_nested_parent"
+	NestedOffsetFieldName = "_nested_offset"
 )
 
 const (
@@ -0,0 +1,27 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// UpdateRequest updates a document with a partial document or a script,
+// the upsert document is indexed when the document doesn't exist
+type UpdateRequest struct {
+	Doc            map[string]interface{} `json:"doc,omitempty"`
+	DocAsUpsert    bool                   `json:"doc_as_upsert,omitempty"`   // index doc when the document doesn't exist
+	Script         interface{}            `json:"script,omitempty"`          // source or {"source": "", "params": {}} or {"id": ""}
+	ScriptedUpsert bool                   `json:"scripted_upsert,omitempty"` // run the script on upsert when the document doesn't exist
+	Upsert         map[string]interface{} `json:"upsert,omitempty"`
+	DetectNoop     *bool                  `json:"detect_noop,omitempty"` // skip the doc merges changing nothing, default is true
+}
@@ -32,6 +32,7 @@ type ZincQuery struct {
 	Query        QueryParams                  `json:"query"`
 	Aggregations map[string]AggregationParams `json:"aggs"`
 	SortFields   []string                     `json:"sort_fields"`
+	SearchAfter  []interface{}                `json:"search_after"`
 	Source       interface{}                  `json:"_source"`
 }
 
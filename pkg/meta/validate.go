@@ -0,0 +1,31 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package meta
+
+// ValidateResponse is the result of validating a query without executing it
+type ValidateResponse struct {
+	Valid        bool                  `json:"valid"`
+	Error        string                `json:"error,omitempty"`
+	Shards       Shards                `json:"_shards"`
+	Explanations []ValidateExplanation `json:"explanations,omitempty"`
+}
+
+type ValidateExplanation struct {
+	Index       string `json:"index"`
+	Valid       bool   `json:"valid"`
+	Explanation string `json:"explanation,omitempty"` // human-readable rewrite of the query
+	Error       string `json:"error,omitempty"`
+}
@@ -15,7 +15,10 @@
 
 package metadata
 
-import "github.com/zincsearch/zincsearch/pkg/zutils/json"
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
 
 type alias struct{}
 
@@ -43,3 +46,53 @@ func (t *alias) Get() (map[string][]string, error) {
 	err = json.Unmarshal(data[0], &als)
 	return als, err
 }
+
+// SetWriteIndexes saves the write index of the aliases
+func (t *alias) SetWriteIndexes(data map[string]string) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return db.Set("/write_aliases/write_index", buf)
+}
+
+// GetWriteIndexes returns the write index of the aliases
+func (t *alias) GetWriteIndexes() (map[string]string, error) {
+	data, err := db.List("/write_aliases/", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return map[string]string{}, nil
+	}
+
+	indexes := map[string]string{}
+	err = json.Unmarshal(data[0], &indexes)
+	return indexes, err
+}
+
+// SetOptions saves the filter and the routing of the indexes of the aliases
+func (t *alias) SetOptions(data map[string]map[string]*meta.AliasOptions) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return db.Set("/alias_options/options", buf)
+}
+
+// GetOptions returns the filter and the routing of the indexes of the aliases
+func (t *alias) GetOptions() (map[string]map[string]*meta.AliasOptions, error) {
+	data, err := db.List("/alias_options/", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return map[string]map[string]*meta.AliasOptions{}, nil
+	}
+
+	options := map[string]map[string]*meta.AliasOptions{}
+	err = json.Unmarshal(data[0], &options)
+	return options, err
+}
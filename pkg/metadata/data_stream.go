@@ -0,0 +1,58 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package metadata
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+type dataStream struct{}
+
+var DataStream = new(dataStream)
+
+func (t *dataStream) List(offset, limit int) ([]*meta.DataStream, error) {
+	data, err := db.List(t.key(""), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	streams := make([]*meta.DataStream, 0, len(data))
+	for _, d := range data {
+		stream := new(meta.DataStream)
+		err = json.Unmarshal(d, stream)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+func (t *dataStream) Set(name string, val *meta.DataStream) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return db.Set(t.key(name), data)
+}
+
+func (t *dataStream) Delete(name string) error {
+	return db.Delete(t.key(name))
+}
+
+func (t *dataStream) key(name string) string {
+	return "/data_stream/" + name
+}
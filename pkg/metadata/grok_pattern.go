@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package metadata
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+type grokPattern struct{}
+
+var GrokPattern = new(grokPattern)
+
+func (t *grokPattern) List(offset, limit int) ([]*meta.GrokPattern, error) {
+	data, err := db.List(t.key(""), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]*meta.GrokPattern, 0, len(data))
+	for _, d := range data {
+		pattern := new(meta.GrokPattern)
+		err = json.Unmarshal(d, pattern)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func (t *grokPattern) Get(id string) (*meta.GrokPattern, error) {
+	data, err := db.Get(t.key(id))
+	if err != nil {
+		return nil, err
+	}
+	pattern := new(meta.GrokPattern)
+	err = json.Unmarshal(data, pattern)
+	return pattern, err
+}
+
+func (t *grokPattern) Set(id string, val meta.GrokPattern) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return db.Set(t.key(id), data)
+}
+
+func (t *grokPattern) Delete(id string) error {
+	return db.Delete(t.key(id))
+}
+
+func (t *grokPattern) key(id string) string {
+	return "/grok_pattern/" + id
+}
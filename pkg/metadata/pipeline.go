@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package metadata
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+type pipeline struct{}
+
+var Pipeline = new(pipeline)
+
+func (t *pipeline) List(offset, limit int) ([]*meta.Pipeline, error) {
+	data, err := db.List(t.key(""), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	pipelines := make([]*meta.Pipeline, 0, len(data))
+	for _, d := range data {
+		pipeline := new(meta.Pipeline)
+		err = json.Unmarshal(d, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, nil
+}
+
+func (t *pipeline) Get(id string) (*meta.Pipeline, error) {
+	data, err := db.Get(t.key(id))
+	if err != nil {
+		return nil, err
+	}
+	pipeline := new(meta.Pipeline)
+	err = json.Unmarshal(data, pipeline)
+	return pipeline, err
+}
+
+func (t *pipeline) Set(id string, val meta.Pipeline) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return db.Set(t.key(id), data)
+}
+
+func (t *pipeline) Delete(id string) error {
+	return db.Delete(t.key(id))
+}
+
+func (t *pipeline) key(id string) string {
+	return "/pipeline/" + id
+}
@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package metadata
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+type session struct{}
+
+var Session = new(session)
+
+func (t *session) List(offset, limit int) ([]*meta.Session, error) {
+	data, err := db.List(t.key(""), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*meta.Session, 0, len(data))
+	for _, d := range data {
+		session := new(meta.Session)
+		err = json.Unmarshal(d, session)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (t *session) Get(id string) (*meta.Session, error) {
+	data, err := db.Get(t.key(id))
+	if err != nil {
+		return nil, err
+	}
+	session := new(meta.Session)
+	err = json.Unmarshal(data, session)
+	return session, err
+}
+
+func (t *session) Set(id string, val meta.Session) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return db.Set(t.key(id), data)
+}
+
+func (t *session) Delete(id string) error {
+	return db.Delete(t.key(id))
+}
+
+func (t *session) key(id string) string {
+	return "/session/" + id
+}
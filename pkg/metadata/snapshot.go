@@ -0,0 +1,67 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package metadata
+
+import (
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+type snapshotRepository struct{}
+
+var SnapshotRepository = new(snapshotRepository)
+
+func (t *snapshotRepository) List(offset, limit int) ([]*meta.SnapshotRepository, error) {
+	data, err := db.List(t.key(""), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]*meta.SnapshotRepository, 0, len(data))
+	for _, d := range data {
+		repo := new(meta.SnapshotRepository)
+		err = json.Unmarshal(d, repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+func (t *snapshotRepository) Get(name string) (*meta.SnapshotRepository, error) {
+	data, err := db.Get(t.key(name))
+	if err != nil {
+		return nil, err
+	}
+	repo := new(meta.SnapshotRepository)
+	err = json.Unmarshal(data, repo)
+	return repo, err
+}
+
+func (t *snapshotRepository) Set(name string, val meta.SnapshotRepository) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return db.Set(t.key(name), data)
+}
+
+func (t *snapshotRepository) Delete(name string) error {
+	return db.Delete(t.key(name))
+}
+
+func (t *snapshotRepository) key(name string) string {
+	return "/snapshot_repository/" + name
+}
@@ -0,0 +1,179 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package routes
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// DecompressMiddleware decompresses the gzip and deflate request bodies,
+// the body is decompressed while read so the bulk requests are streamed without buffering
+func DecompressMiddleware(c *gin.Context) {
+	encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.Next()
+		return
+	}
+
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(c.Request.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(c.Request.Body)
+	default:
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, meta.HTTPResponseError{Error: "unsupported content encoding [" + encoding + "]"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, meta.HTTPResponseError{Error: "invalid " + encoding + " body: " + err.Error()})
+		return
+	}
+
+	c.Request.Body = &decompressBody{ReadCloser: reader, body: c.Request.Body}
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	c.Next()
+}
+
+type decompressBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (b *decompressBody) Close() error {
+	err := b.ReadCloser.Close()
+	if bodyErr := b.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// CompressMiddleware gzips the responses larger than config.Global.HTTPCompressMinSize
+// for the clients accepting gzip, the smaller responses are sent as is
+func CompressMiddleware(c *gin.Context) {
+	if config.Global.HTTPCompressMinSize <= 0 || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		c.Next()
+		return
+	}
+
+	w := &gzipResponseWriter{ResponseWriter: c.Writer, minSize: config.Global.HTTPCompressMinSize}
+	c.Writer = w
+	defer func() {
+		w.finish()
+		c.Writer = w.ResponseWriter
+	}()
+	c.Next()
+}
+
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// gzip;q=0 means not acceptable
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if k, v, _ := strings.Cut(strings.TrimSpace(param), "="); k == "q" {
+				q, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the response until it reaches the min size, then it switches to gzip,
+// a flush switches to gzip right away so the streamed responses are compressed as they go
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     []byte
+	gz      *gzip.Writer
+	started bool
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	if w.started {
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.started {
+		_ = w.start(true)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start writes the buffered data, compressed unless the response is already encoded or partial
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.started = true
+	h := w.Header()
+	if compress && h.Get("Content-Encoding") == "" && w.Status() != http.StatusPartialContent {
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+	var err error
+	if len(w.buf) > 0 {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+func (w *gzipResponseWriter) finish() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
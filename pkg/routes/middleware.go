@@ -16,37 +16,148 @@
 package routes
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/zincsearch/zincsearch/pkg/auth"
 	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
 )
 
 func AuthMiddleware(permission string) func(c *gin.Context) {
 	auth.AddPermission(permission)
 	return func(c *gin.Context) {
-		// Get the Basic Authentication credentials
-		user, password, hasAuth := c.Request.BasicAuth()
-		if hasAuth {
-			if u, ok := auth.VerifyCredentials(user, password); ok {
-				if auth.VerifyRoleHasPermission(u.Role, permission) {
-					c.Next()
-				} else {
-					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No permission:" + permission})
-					return
-				}
-			} else {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Invalid credentials"})
+		user, principal, ok := authenticate(c)
+		if !ok {
+			return
+		}
+		auth.SetContextUser(c, user)
+		if !auth.VerifyRoleHasPermission(user.Role, permission) {
+			if !auth.HasIndexPermission(user.Role, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No permission:" + permission})
+				return
+			}
+			if !authorizeTarget(c, user, permission) {
 				return
 			}
+		}
+		if bucket := rateLimitBucket(permission); bucket != "" {
+			if ok, wait := auth.ZINC_RATE_LIMITER.Allow(principal, user.Role, bucket); !ok {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded for the " + bucket + " requests"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// rateLimitBucket returns the rate limit bucket of the permission, the reads and the writes
+// have their own buckets and the other apis are not limited
+func rateLimitBucket(permission string) string {
+	switch {
+	case strings.HasPrefix(permission, "search."), permission == "document.Get", permission == "cat.Count":
+		return auth.RateLimitSearch
+	case strings.HasPrefix(permission, "document."):
+		return auth.RateLimitIndex
+	}
+	return ""
+}
+
+// indexBodyPermissions are checked by the handlers against the indexes named in the request body
+var indexBodyPermissions = map[string]bool{
+	"document.Bulk":           true,
+	"document.ESBulk":         true,
+	"search.MultipleSearch":   true,
+	"search.MultiTermVectors": true,
+}
+
+// ownerPermissions access the resources of the user only, they are checked by the handlers
+var ownerPermissions = map[string]bool{
+	"search.GetAsyncSearch":    true,
+	"search.DeleteAsyncSearch": true,
+	"search.GetTask":           true,
+}
+
+// templatePermissions have a template name as target, they can't be granted on some indexes only
+var templatePermissions = map[string]bool{
+	"index.CreateTemplate":          true,
+	"index.GetTemplate":             true,
+	"index.DeleteTemplate":          true,
+	"index.CreateComponentTemplate": true,
+	"index.GetComponentTemplate":    true,
+	"index.DeleteComponentTemplate": true,
+}
+
+// authorizeTarget checks the user has the permission on the target indexes when it is granted
+// only on some indexes, a wildcard target is narrowed to the authorized indexes it matches.
+// The request is aborted with 403 when it touches an unauthorized index or has no target
+func authorizeTarget(c *gin.Context, user *meta.User, permission string) bool {
+	auth.SetContextIndexPermission(c, permission)
+	target, ix := targetParam(c)
+	if target == "" || templatePermissions[permission] {
+		if target == "" && (indexBodyPermissions[permission] || ownerPermissions[permission]) {
+			return true
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No permission:" + permission + " on all indices"})
+		return false
+	}
+	names, err := auth.AuthorizeIndexNames(user.Role, permission, strings.Split(target, ","), core.ResolveIndexName)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	if newTarget := strings.Join(names, ","); newTarget != target {
+		c.Params[ix].Value = newTarget
+	}
+	return true
+}
+
+// authenticate verifies the api key, the session or jwt bearer token or the basic authentication credentials,
+// the request is aborted if they are missing or invalid. The principal is the api key or the user of the rate limits
+func authenticate(c *gin.Context) (*meta.User, string, bool) {
+	scheme, credentials, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		key, ok := auth.VerifyAPIKey(credentials)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Invalid api key"})
+			return nil, "", false
+		}
+		return &meta.User{ID: key.Owner, Name: key.Name, Role: key.Role}, "api_key:" + key.ID, true
+	}
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		token := strings.TrimSpace(credentials)
+		var user *meta.User
+		var err error
+		if auth.IsSessionToken(token) {
+			user, err = auth.VerifySessionToken(token)
 		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Missing credentials"})
-			return
+			user, err = auth.VerifyJWT(token)
 		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Invalid token", "error": err.Error()})
+			return nil, "", false
+		}
+		return user, "user:" + user.ID, true
+	}
+
+	// Get the Basic Authentication credentials
+	userID, password, hasAuth := c.Request.BasicAuth()
+	if !hasAuth {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Missing credentials"})
+		return nil, "", false
+	}
+	user, ok := auth.VerifyCredentials(userID, password)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Invalid credentials"})
+		return nil, "", false
 	}
+	return user, "user:" + user.ID, true
 }
 
 func ESMiddleware(c *gin.Context) {
@@ -55,29 +166,46 @@ func ESMiddleware(c *gin.Context) {
 	c.Header("X-elastic-product", "Elasticsearch")
 }
 
+// IndexAliasMiddleware replaces the alias in the target with the indexes
+// it points to, the reads through an alias span all of them.
+// The alias is kept in the context for the searches applying its filter,
+// its search routing is the default routing of the request.
+// The aliases in a target listing several names are replaced likewise.
+// A data stream is replaced with its backing indexes.
 func IndexAliasMiddleware(c *gin.Context) {
-	target := ""
-	ix := 0
-
-	for i, entry := range c.Params {
-		if entry.Key == "target" {
-			target = entry.Value
-			ix = i
-			break
-		}
+	target, ix := targetParam(c)
+	if target == "" {
+		c.Next()
+		return
 	}
 
-	if target == "" {
+	if indexes, ok := core.ZINC_DATA_STREAM_LIST.GetIndexes(target); ok {
+		c.Params[ix].Value = strings.Join(indexes, ",")
 		c.Next()
 		return
 	}
 
 	indexes, ok := core.ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(target)
 	if !ok {
+		if strings.Contains(target, ",") {
+			names := strings.Split(target, ",")
+			for _, name := range names {
+				if _, ok := core.ZINC_INDEX_ALIAS_LIST.GetIndexesForAlias(name); ok {
+					c.Set(core.AliasContextKey, target)
+					break
+				}
+			}
+			c.Params[ix].Value = strings.Join(core.ResolveIndexNames(names), ",")
+		}
 		c.Next()
 		return
 	}
 
+	c.Set(core.AliasContextKey, target)
+	if routing := core.ZINC_INDEX_ALIAS_LIST.SearchRouting(target); routing != "" && c.Request.URL.Query().Get("routing") == "" {
+		setQueryParam(c, "routing", routing)
+	}
+
 	newTarget := strings.Join(indexes, ",")
 
 	if newTarget != "" {
@@ -85,3 +213,49 @@ func IndexAliasMiddleware(c *gin.Context) {
 	}
 	c.Next()
 }
+
+// IndexAliasWriteMiddleware replaces the alias or the data stream in the target
+// with its write index, the writes through an alias go to exactly one index
+func IndexAliasWriteMiddleware(c *gin.Context) {
+	target, ix := targetParam(c)
+	if target == "" {
+		c.Next()
+		return
+	}
+
+	index, err := core.ResolveWriteIndex(target)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, meta.HTTPResponseError{Error: err.Error()})
+		return
+	}
+	// the documents written through the alias use its index routing
+	if routing := core.ZINC_INDEX_ALIAS_LIST.IndexRouting(target); routing != "" {
+		if v := c.Request.URL.Query().Get("routing"); v == "" {
+			setQueryParam(c, "routing", routing)
+		} else if v != routing {
+			c.AbortWithStatusJSON(http.StatusBadRequest, meta.HTTPResponseError{
+				Error: fmt.Sprintf("alias [%s] has index routing associated with it [%s], and was provided with routing value [%s], rejecting operation", target, routing, v),
+			})
+			return
+		}
+	}
+	c.Params[ix].Value = index
+	c.Next()
+}
+
+// setQueryParam sets the query parameter of the request for the handlers,
+// it is read from the url as gin caches the query parameters on the first read
+func setQueryParam(c *gin.Context, key, value string) {
+	query := c.Request.URL.Query()
+	query.Set(key, value)
+	c.Request.URL.RawQuery = query.Encode()
+}
+
+func targetParam(c *gin.Context) (string, int) {
+	for i, entry := range c.Params {
+		if entry.Key == "target" {
+			return entry.Value, i
+		}
+	}
+	return "", 0
+}
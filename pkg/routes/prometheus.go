@@ -16,8 +16,15 @@
 package routes
 
 import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	ginprometheus "github.com/zincsearch/go-gin-prometheus"
+
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/core"
 )
@@ -28,6 +35,33 @@ func SetPrometheus(app *gin.Engine) {
 		return
 	}
 
-	p := ginprometheus.NewPrometheus("zinc", []*ginprometheus.Metric{core.ZINC_METRICS})
-	p.Use(app)
+	p := ginprometheus.NewPrometheus("zinc", []*ginprometheus.Metric{core.ZINC_METRICS, core.ZINC_SEARCH_METRICS, core.ZINC_WAL_FLUSH_METRICS})
+	// label requests by the route instead of the raw path, avoid a series per document id
+	p.ReqCntURLLabelMappingFn = func(c *gin.Context) string {
+		if path := c.FullPath(); path != "" {
+			return path
+		}
+		return "unknown"
+	}
+	prometheus.MustRegister(core.NewIndexCollector("zinc"))
+
+	app.Use(p.HandlerFunc())
+	app.GET(p.MetricsPath, PrometheusAuthMiddleware(config.Global.PrometheusToken), gin.WrapH(promhttp.Handler()))
+}
+
+// PrometheusAuthMiddleware requires the bearer token for scraping metrics, no auth if the token is empty
+func PrometheusAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		auth := c.GetHeader("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"auth": "Invalid metrics token"})
+			return
+		}
+		c.Next()
+	}
 }
@@ -31,9 +31,11 @@ import (
 	"github.com/zincsearch/zincsearch"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/handlers/auth"
+	"github.com/zincsearch/zincsearch/pkg/handlers/cat"
 	"github.com/zincsearch/zincsearch/pkg/handlers/document"
 	"github.com/zincsearch/zincsearch/pkg/handlers/index"
 	"github.com/zincsearch/zincsearch/pkg/handlers/search"
+	"github.com/zincsearch/zincsearch/pkg/handlers/snapshot"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/meta/elastic"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
@@ -44,11 +46,12 @@ func SetRoutes(r *gin.Engine) {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "DELETE", "PUT", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "authorization", "content-type"},
+		AllowHeaders:     []string{"Origin", "authorization", "content-type", "content-encoding"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(DecompressMiddleware, CompressMiddleware)
 
 	r.GET("/", meta.GUI)
 	r.GET("/version", meta.GetVersion)
@@ -86,6 +89,8 @@ func SetRoutes(r *gin.Engine) {
 
 	// auth
 	r.POST("/api/login", auth.Login)
+	r.POST("/api/refresh", auth.Refresh)
+	r.POST("/api/logout", auth.Logout)
 	r.POST("/api/user", AuthMiddleware("auth.CreateUpdateUser"), auth.CreateUpdateUser)
 	r.PUT("/api/user", AuthMiddleware("auth.CreateUpdateUser"), auth.CreateUpdateUser)
 	r.DELETE("/api/user/:id", AuthMiddleware("auth.DeleteUser"), auth.DeleteUser)
@@ -95,6 +100,9 @@ func SetRoutes(r *gin.Engine) {
 	r.POST("/api/role", AuthMiddleware("auth.CreateUpdateRole"), auth.CreateUpdateRole)
 	r.PUT("/api/role", AuthMiddleware("auth.CreateUpdateRole"), auth.CreateUpdateRole)
 	r.DELETE("/api/role/:id", AuthMiddleware("auth.DeleteRole"), auth.DeleteRole)
+	r.POST("/api/_security/api_key", AuthMiddleware("auth.CreateAPIKey"), auth.CreateAPIKey)
+	r.GET("/api/_security/api_key", AuthMiddleware("auth.ListAPIKeys"), auth.ListAPIKeys)
+	r.DELETE("/api/_security/api_key/:id", AuthMiddleware("auth.DeleteAPIKey"), auth.DeleteAPIKey)
 
 	// index
 	r.GET("/api/index", AuthMiddleware("index.List"), index.List)
@@ -155,7 +163,25 @@ func SetRoutes(r *gin.Engine) {
 	r.POST("/es/_msearch", AuthMiddleware("search.MultipleSearch"), ESMiddleware, IndexAliasMiddleware, search.MultipleSearch)
 	r.POST("/es/:target/_search", AuthMiddleware("search.SearchDSL"), ESMiddleware, IndexAliasMiddleware, search.SearchDSL)
 	r.POST("/es/:target/_msearch", AuthMiddleware("search.MultipleSearch"), ESMiddleware, IndexAliasMiddleware, search.MultipleSearch)
+	r.POST("/es/_async_search", AuthMiddleware("search.SubmitAsyncSearch"), ESMiddleware, search.SubmitAsyncSearch)
+	r.POST("/es/:target/_async_search", AuthMiddleware("search.SubmitAsyncSearch"), ESMiddleware, IndexAliasMiddleware, search.SubmitAsyncSearch)
+	r.GET("/es/_async_search/:id", AuthMiddleware("search.GetAsyncSearch"), ESMiddleware, search.GetAsyncSearch)
+	r.DELETE("/es/_async_search/:id", AuthMiddleware("search.DeleteAsyncSearch"), ESMiddleware, search.DeleteAsyncSearch)
+	r.POST("/es/_sql", AuthMiddleware("search.SQL"), ESMiddleware, search.SQL)
+	r.GET("/es/_validate/query", AuthMiddleware("search.ValidateQuery"), ESMiddleware, search.ValidateQuery)
+	r.POST("/es/_validate/query", AuthMiddleware("search.ValidateQuery"), ESMiddleware, search.ValidateQuery)
+	r.GET("/es/:target/_validate/query", AuthMiddleware("search.ValidateQuery"), ESMiddleware, IndexAliasMiddleware, search.ValidateQuery)
+	r.POST("/es/:target/_validate/query", AuthMiddleware("search.ValidateQuery"), ESMiddleware, IndexAliasMiddleware, search.ValidateQuery)
+	r.GET("/es/:target/_explain/:id", AuthMiddleware("search.Explain"), ESMiddleware, IndexAliasMiddleware, search.Explain)
+	r.POST("/es/:target/_explain/:id", AuthMiddleware("search.Explain"), ESMiddleware, IndexAliasMiddleware, search.Explain)
+	r.GET("/es/:target/_termvectors/:id", AuthMiddleware("search.TermVectors"), ESMiddleware, IndexAliasMiddleware, search.TermVectors)
+	r.POST("/es/:target/_termvectors/:id", AuthMiddleware("search.TermVectors"), ESMiddleware, IndexAliasMiddleware, search.TermVectors)
+	r.GET("/es/_mtermvectors", AuthMiddleware("search.MultiTermVectors"), ESMiddleware, search.MultiTermVectors)
+	r.POST("/es/_mtermvectors", AuthMiddleware("search.MultiTermVectors"), ESMiddleware, search.MultiTermVectors)
+	r.GET("/es/:target/_mtermvectors", AuthMiddleware("search.MultiTermVectors"), ESMiddleware, IndexAliasMiddleware, search.MultiTermVectors)
+	r.POST("/es/:target/_mtermvectors", AuthMiddleware("search.MultiTermVectors"), ESMiddleware, IndexAliasMiddleware, search.MultiTermVectors)
 	r.POST("/es/:target/_delete_by_query", AuthMiddleware("search.DeleteByQuery"), IndexAliasMiddleware, search.DeleteByQuery)
+	r.GET("/es/_tasks/:id", AuthMiddleware("search.GetTask"), ESMiddleware, search.GetTask)
 
 	r.GET("/es/_index_template", AuthMiddleware("index.ListTemplate"), ESMiddleware, index.ListTemplate)
 	r.POST("/es/_index_template", AuthMiddleware("index.CreateTemplate"), ESMiddleware, index.CreateTemplate)
@@ -163,40 +189,119 @@ func SetRoutes(r *gin.Engine) {
 	r.GET("/es/_index_template/:target", AuthMiddleware("index.GetTemplate"), ESMiddleware, index.GetTemplate)
 	r.HEAD("/es/_index_template/:target", AuthMiddleware("index.GetTemplate"), ESMiddleware, index.GetTemplate)
 	r.DELETE("/es/_index_template/:target", AuthMiddleware("index.DeleteTemplate"), ESMiddleware, index.DeleteTemplate)
+	r.GET("/es/_component_template", AuthMiddleware("index.ListComponentTemplate"), ESMiddleware, index.ListComponentTemplate)
+	r.PUT("/es/_component_template/:target", AuthMiddleware("index.CreateComponentTemplate"), ESMiddleware, index.CreateComponentTemplate)
+	r.POST("/es/_component_template/:target", AuthMiddleware("index.CreateComponentTemplate"), ESMiddleware, index.CreateComponentTemplate)
+	r.GET("/es/_component_template/:target", AuthMiddleware("index.GetComponentTemplate"), ESMiddleware, index.GetComponentTemplate)
+	r.HEAD("/es/_component_template/:target", AuthMiddleware("index.GetComponentTemplate"), ESMiddleware, index.GetComponentTemplate)
+	r.DELETE("/es/_component_template/:target", AuthMiddleware("index.DeleteComponentTemplate"), ESMiddleware, index.DeleteComponentTemplate)
 	// ES Compatible data stream
-	r.PUT("/es/_data_stream/:target", AuthMiddleware("elastic.PutDataStream"), ESMiddleware, elastic.PutDataStream)
-	r.GET("/es/_data_stream/:target", AuthMiddleware("elastic.GetDataStream"), ESMiddleware, elastic.GetDataStream)
-	r.HEAD("/es/_data_stream/:target", AuthMiddleware("elastic.GetDataStream"), ESMiddleware, elastic.GetDataStream)
+	r.GET("/es/_data_stream", AuthMiddleware("index.GetDataStream"), ESMiddleware, index.GetDataStream)
+	r.PUT("/es/_data_stream/:target", AuthMiddleware("index.PutDataStream"), ESMiddleware, index.PutDataStream)
+	r.GET("/es/_data_stream/:target", AuthMiddleware("index.GetDataStream"), ESMiddleware, index.GetDataStream)
+	r.HEAD("/es/_data_stream/:target", AuthMiddleware("index.GetDataStream"), ESMiddleware, index.GetDataStream)
+	r.DELETE("/es/_data_stream/:target", AuthMiddleware("index.DeleteDataStream"), ESMiddleware, index.DeleteDataStream)
 
 	r.PUT("/es/:target", AuthMiddleware("index.CreateES"), ESMiddleware, index.CreateES)
 	r.HEAD("/es/:target", AuthMiddleware("index.Exists"), ESMiddleware, index.Exists)
 
 	r.GET("/es/:target/_mapping", AuthMiddleware("index.GetESMapping"), ESMiddleware, index.GetESMapping)
+	r.GET("/es/_mapping/field/:field", AuthMiddleware("index.GetESMapping"), ESMiddleware, index.GetESFieldMapping)
+	r.GET("/es/:target/_mapping/field/:field", AuthMiddleware("index.GetESMapping"), ESMiddleware, IndexAliasMiddleware, index.GetESFieldMapping)
 	r.PUT("/es/:target/_mapping", AuthMiddleware("index.SetMapping"), ESMiddleware, index.SetMapping)
 
+	r.GET("/es/_field_caps", AuthMiddleware("index.FieldCaps"), ESMiddleware, index.FieldCaps)
+	r.POST("/es/_field_caps", AuthMiddleware("index.FieldCaps"), ESMiddleware, index.FieldCaps)
+	r.GET("/es/:target/_field_caps", AuthMiddleware("index.FieldCaps"), ESMiddleware, IndexAliasMiddleware, index.FieldCaps)
+	r.POST("/es/:target/_field_caps", AuthMiddleware("index.FieldCaps"), ESMiddleware, IndexAliasMiddleware, index.FieldCaps)
+
 	r.GET("/es/:target/_settings", AuthMiddleware("index.GetSettings"), ESMiddleware, index.GetSettings)
 	r.PUT("/es/:target/_settings", AuthMiddleware("index.SetSettings"), ESMiddleware, index.SetSettings)
 
+	// ES cat
+	r.GET("/es/_cat/health", AuthMiddleware("cat.Health"), ESMiddleware, cat.Health)
+	r.GET("/es/_cat/nodes", AuthMiddleware("cat.Nodes"), ESMiddleware, cat.Nodes)
+	r.GET("/es/_cat/indices", AuthMiddleware("cat.Indices"), ESMiddleware, cat.Indices)
+	r.GET("/es/_cat/indices/:target", AuthMiddleware("cat.Indices"), ESMiddleware, IndexAliasMiddleware, cat.Indices)
+
+	r.GET("/es/_cat/aliases", AuthMiddleware("cat.Aliases"), ESMiddleware, cat.Aliases)
+	r.GET("/es/_cat/aliases/:target_alias", AuthMiddleware("cat.Aliases"), ESMiddleware, cat.Aliases)
+	r.GET("/es/_cat/count", AuthMiddleware("cat.Count"), ESMiddleware, cat.Count)
+	r.GET("/es/_cat/count/:target", AuthMiddleware("cat.Count"), ESMiddleware, IndexAliasMiddleware, cat.Count)
+
+	r.GET("/es/_resolve/index/:target", AuthMiddleware("index.ResolveIndex"), ESMiddleware, index.ResolveIndex)
+
+	r.GET("/es/_stats", AuthMiddleware("index.Stats"), ESMiddleware, index.Stats)
+	r.GET("/es/_stats/:metric", AuthMiddleware("index.Stats"), ESMiddleware, index.Stats)
+	r.GET("/es/:target/_stats", AuthMiddleware("index.Stats"), ESMiddleware, IndexAliasMiddleware, index.Stats)
+	r.GET("/es/:target/_stats/:metric", AuthMiddleware("index.Stats"), ESMiddleware, IndexAliasMiddleware, index.Stats)
+
+	r.POST("/es/_forcemerge", AuthMiddleware("index.ForceMerge"), ESMiddleware, index.ForceMerge)
+	r.POST("/es/:target/_forcemerge", AuthMiddleware("index.ForceMerge"), ESMiddleware, IndexAliasMiddleware, index.ForceMerge)
+	r.POST("/es/_flush", AuthMiddleware("index.Flush"), ESMiddleware, index.Flush)
+	r.GET("/es/_flush", AuthMiddleware("index.Flush"), ESMiddleware, index.Flush)
+	r.POST("/es/:target/_flush", AuthMiddleware("index.Flush"), ESMiddleware, IndexAliasMiddleware, index.Flush)
+	r.GET("/es/:target/_flush", AuthMiddleware("index.Flush"), ESMiddleware, IndexAliasMiddleware, index.Flush)
+	r.POST("/es/:target/_close", AuthMiddleware("index.Close"), ESMiddleware, IndexAliasMiddleware, index.Close)
+	r.POST("/es/:target/_open", AuthMiddleware("index.Open"), ESMiddleware, IndexAliasMiddleware, index.Open)
+
 	r.POST("/es/_analyze", AuthMiddleware("index.Analyze"), ESMiddleware, index.Analyze)
 	r.POST("/es/:target/_analyze", AuthMiddleware("index.Analyze"), ESMiddleware, index.Analyze)
 
 	r.POST("/es/_aliases", AuthMiddleware("index.AddOrRemoveESAlias"), ESMiddleware, index.AddOrRemoveESAlias)
+	r.POST("/es/:target/_rollover", AuthMiddleware("index.Rollover"), ESMiddleware, index.Rollover)
+	r.POST("/es/:target/_rollover/:new_index", AuthMiddleware("index.Rollover"), ESMiddleware, index.Rollover)
 
 	r.GET("/es/_alias", AuthMiddleware("index.GetESAliases"), ESMiddleware, index.GetESAliases)
 	r.GET("/es/:target/_alias", AuthMiddleware("index.GetESAliases"), ESMiddleware, index.GetESAliases)
 	r.GET("/es/_alias/:target_alias", AuthMiddleware("index.GetESAliases"), ESMiddleware, index.GetESAliases)
 
+	// ES index lifecycle policies
+	r.GET("/es/_ilm/policy", AuthMiddleware("index.GetLifecyclePolicy"), ESMiddleware, index.GetLifecyclePolicy)
+	r.GET("/es/_ilm/policy/:name", AuthMiddleware("index.GetLifecyclePolicy"), ESMiddleware, index.GetLifecyclePolicy)
+	r.PUT("/es/_ilm/policy/:name", AuthMiddleware("index.PutLifecyclePolicy"), ESMiddleware, index.PutLifecyclePolicy)
+	r.DELETE("/es/_ilm/policy/:name", AuthMiddleware("index.DeleteLifecyclePolicy"), ESMiddleware, index.DeleteLifecyclePolicy)
+
+	// ES stored scripts
+	r.GET("/es/_scripts/:id", AuthMiddleware("search.GetScript"), ESMiddleware, search.GetScript)
+	r.PUT("/es/_scripts/:id", AuthMiddleware("search.PutScript"), ESMiddleware, search.PutScript)
+	r.POST("/es/_scripts/:id", AuthMiddleware("search.PutScript"), ESMiddleware, search.PutScript)
+	r.DELETE("/es/_scripts/:id", AuthMiddleware("search.DeleteScript"), ESMiddleware, search.DeleteScript)
+
+	// ES ingest pipelines
+	r.GET("/es/_ingest/pipeline", AuthMiddleware("document.GetPipeline"), ESMiddleware, document.GetPipeline)
+	r.GET("/es/_ingest/pipeline/:id", AuthMiddleware("document.GetPipeline"), ESMiddleware, document.GetPipeline)
+	r.PUT("/es/_ingest/pipeline/:id", AuthMiddleware("document.PutPipeline"), ESMiddleware, document.PutPipeline)
+	r.DELETE("/es/_ingest/pipeline/:id", AuthMiddleware("document.DeletePipeline"), ESMiddleware, document.DeletePipeline)
+	r.POST("/es/_ingest/pipeline/_simulate", AuthMiddleware("document.SimulatePipeline"), ESMiddleware, document.SimulatePipeline)
+	r.POST("/es/_ingest/pipeline/:id/_simulate", AuthMiddleware("document.SimulatePipeline"), ESMiddleware, document.SimulatePipeline)
+	r.GET("/es/_ingest/processor/grok", AuthMiddleware("document.GetGrokPatterns"), ESMiddleware, document.GetGrokPatterns)
+	r.PUT("/es/_ingest/processor/grok/:name", AuthMiddleware("document.PutGrokPattern"), ESMiddleware, document.PutGrokPattern)
+	r.DELETE("/es/_ingest/processor/grok/:name", AuthMiddleware("document.DeleteGrokPattern"), ESMiddleware, document.DeleteGrokPattern)
+
+	// ES snapshot
+	r.GET("/es/_snapshot", AuthMiddleware("snapshot.GetRepository"), ESMiddleware, snapshot.GetRepository)
+	r.GET("/es/_snapshot/:repo", AuthMiddleware("snapshot.GetRepository"), ESMiddleware, snapshot.GetRepository)
+	r.PUT("/es/_snapshot/:repo", AuthMiddleware("snapshot.PutRepository"), ESMiddleware, snapshot.PutRepository)
+	r.POST("/es/_snapshot/:repo", AuthMiddleware("snapshot.PutRepository"), ESMiddleware, snapshot.PutRepository)
+	r.DELETE("/es/_snapshot/:repo", AuthMiddleware("snapshot.DeleteRepository"), ESMiddleware, snapshot.DeleteRepository)
+	r.GET("/es/_snapshot/:repo/:snapshot", AuthMiddleware("snapshot.Get"), ESMiddleware, snapshot.Get)
+	r.PUT("/es/_snapshot/:repo/:snapshot", AuthMiddleware("snapshot.Create"), ESMiddleware, snapshot.Create)
+	r.POST("/es/_snapshot/:repo/:snapshot", AuthMiddleware("snapshot.Create"), ESMiddleware, snapshot.Create)
+	r.POST("/es/_snapshot/:repo/:snapshot/_restore", AuthMiddleware("snapshot.Restore"), ESMiddleware, snapshot.Restore)
+
 	// ES Bulk update/insert
 	r.POST("/es/_bulk", AuthMiddleware("document.ESBulk"), ESMiddleware, document.ESBulk)
-	r.POST("/es/:target/_bulk", AuthMiddleware("document.ESBulk"), ESMiddleware, document.ESBulk)
-	r.PUT("/es/:target/_bulk", AuthMiddleware("document.ESBulk"), ESMiddleware, document.ESBulk)
+	r.POST("/es/:target/_bulk", AuthMiddleware("document.ESBulk"), ESMiddleware, IndexAliasWriteMiddleware, document.ESBulk)
+	r.PUT("/es/:target/_bulk", AuthMiddleware("document.ESBulk"), ESMiddleware, IndexAliasWriteMiddleware, document.ESBulk)
+	r.POST("/es/_reindex", AuthMiddleware("document.Reindex"), ESMiddleware, document.Reindex)
 	r.POST("/es/:target/_refresh", AuthMiddleware("index.Refresh"), index.Refresh)
 	// ES Document
-	r.POST("/es/:target/_doc", AuthMiddleware("document.CreateUpdate"), ESMiddleware, document.CreateUpdate)        // create
-	r.PUT("/es/:target/_doc/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, document.CreateUpdate)     // create or update
-	r.PUT("/es/:target/_create/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, document.CreateUpdate)  // create
-	r.POST("/es/:target/_create/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, document.CreateUpdate) // create
-	r.POST("/es/:target/_update/:id", AuthMiddleware("document.Update"), ESMiddleware, document.Update)             // update part of document
-	r.DELETE("/es/:target/_doc/:id", AuthMiddleware("document.Delete"), ESMiddleware, document.Delete)              // delete
-	r.GET("/es/:target/_doc/:id", AuthMiddleware("document.Get"), ESMiddleware, document.Get)                       // get
+	r.POST("/es/:target/_doc", AuthMiddleware("document.CreateUpdate"), ESMiddleware, IndexAliasWriteMiddleware, document.CreateUpdate)        // create
+	r.PUT("/es/:target/_doc/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, IndexAliasWriteMiddleware, document.CreateUpdate)     // create or update
+	r.PUT("/es/:target/_create/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, IndexAliasWriteMiddleware, document.CreateUpdate)  // create
+	r.POST("/es/:target/_create/:id", AuthMiddleware("document.CreateUpdate"), ESMiddleware, IndexAliasWriteMiddleware, document.CreateUpdate) // create
+	r.POST("/es/:target/_update/:id", AuthMiddleware("document.Update"), ESMiddleware, IndexAliasWriteMiddleware, document.Update)             // update part of document
+	r.DELETE("/es/:target/_doc/:id", AuthMiddleware("document.Delete"), ESMiddleware, IndexAliasWriteMiddleware, document.Delete)              // delete
+	r.GET("/es/:target/_doc/:id", AuthMiddleware("document.Get"), ESMiddleware, document.Get)                                                  // get
 }
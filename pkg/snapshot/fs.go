@@ -0,0 +1,138 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+type fsRepository struct {
+	root string
+}
+
+// NewFSRepository returns a repository stores files in the local directory,
+// the directory must be in one of the directories of ZINC_SNAPSHOT_PATH_REPO
+func NewFSRepository(settings meta.SnapshotRepositorySettings) (Repository, error) {
+	if settings.Location == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] fs repository requires the [location] setting")
+	}
+	root, err := fsLocation(settings.Location, config.Global.SnapshotPathRepo)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &fsRepository{root: root}, nil
+}
+
+// fsLocation returns the directory of the location if it is in one of the allowed paths,
+// a relative location is resolved against the first path
+func fsLocation(location string, paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] location [%s] doesn't match any of the locations specified by ZINC_SNAPSHOT_PATH_REPO because it is empty", location))
+	}
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(paths[0], location)
+	}
+	location, err := filepath.Abs(location)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range paths {
+		p, err := filepath.Abs(strings.TrimSpace(p))
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(p, location)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return location, nil
+		}
+	}
+	return "", errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] location [%s] doesn't match any of the locations specified by ZINC_SNAPSHOT_PATH_REPO", location))
+}
+
+func (r *fsRepository) Put(key string, data io.Reader, size int64) error {
+	name := r.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	// write to a temporary file first, the key never has partial data
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+func (r *fsRepository) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(r.path(key))
+	if os.IsNotExist(err) {
+		return nil, errors.ErrNotFound
+	}
+	return f, err
+}
+
+func (r *fsRepository) Exists(key string) (bool, error) {
+	_, err := os.Stat(r.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *fsRepository) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := filepath.WalkDir(r.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		key, err := filepath.Rel(r.root, name)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (r *fsRepository) path(key string) string {
+	return filepath.Join(r.root, filepath.FromSlash(key))
+}
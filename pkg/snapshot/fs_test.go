@@ -0,0 +1,118 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+func TestNew(t *testing.T) {
+	location := t.TempDir()
+	defer func(paths []string) { config.Global.SnapshotPathRepo = paths }(config.Global.SnapshotPathRepo)
+	config.Global.SnapshotPathRepo = []string{location}
+	_, err := New(&meta.SnapshotRepository{Type: "hdfs"})
+	assert.Error(t, err)
+	_, err = New(&meta.SnapshotRepository{Type: meta.SnapshotRepositoryTypeFS})
+	assert.Error(t, err)
+	_, err = New(&meta.SnapshotRepository{Type: meta.SnapshotRepositoryTypeS3})
+	assert.Error(t, err)
+	r, err := New(&meta.SnapshotRepository{Type: meta.SnapshotRepositoryTypeFS, Settings: meta.SnapshotRepositorySettings{Location: location}})
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+}
+
+func TestFSRepository(t *testing.T) {
+	location := t.TempDir()
+	defer func(paths []string) { config.Global.SnapshotPathRepo = paths }(config.Global.SnapshotPathRepo)
+	config.Global.SnapshotPathRepo = []string{location}
+	r, err := NewFSRepository(meta.SnapshotRepositorySettings{Location: location})
+	assert.NoError(t, err)
+	testRepository(t, r)
+}
+
+func TestFSLocation(t *testing.T) {
+	_, err := fsLocation("/backups/repo", nil)
+	assert.Error(t, err)
+
+	paths := []string{"/backups", "/mnt/snapshots"}
+	for location, want := range map[string]string{
+		"/backups":                "/backups",
+		"/backups/repo":           "/backups/repo",
+		"/mnt/snapshots/a/b":      "/mnt/snapshots/a/b",
+		"repo":                    "/backups/repo",
+		"/backups/repo/../../etc": "",
+		"../etc":                  "",
+		"/backupsx":               "",
+		"/etc":                    "",
+	} {
+		got, err := fsLocation(location, paths)
+		if want == "" {
+			assert.Error(t, err, location)
+			continue
+		}
+		assert.NoError(t, err, location)
+		assert.Equal(t, want, got, location)
+	}
+}
+
+func testRepository(t *testing.T, r Repository) {
+	t.Run("put", func(t *testing.T) {
+		assert.NoError(t, r.Put("snapshots/s1.json", strings.NewReader("s1"), 2))
+		assert.NoError(t, r.Put("blobs/abc", strings.NewReader("blob"), 4))
+		// overwrite
+		assert.NoError(t, r.Put("snapshots/s1.json", strings.NewReader("s1-new"), 6))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rc, err := r.Get("snapshots/s1.json")
+		assert.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		assert.NoError(t, rc.Close())
+		assert.Equal(t, "s1-new", string(data))
+
+		_, err = r.Get("snapshots/s2.json")
+		assert.Equal(t, errors.ErrNotFound, err)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		ok, err := r.Exists("blobs/abc")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = r.Exists("blobs/def")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		keys, err := r.List("snapshots/")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"snapshots/s1.json"}, keys)
+		keys, err = r.List("")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"snapshots/s1.json", "blobs/abc"}, keys)
+		keys, err = r.List("nothing/")
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
@@ -0,0 +1,49 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+// Package snapshot provides the repositories to store index snapshots.
+package snapshot
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// Repository is a flat key value storage of snapshot files,
+// the keys are slash separated paths
+type Repository interface {
+	// Put stores the data of size bytes to the key
+	Put(key string, r io.Reader, size int64) error
+	// Get returns the data of the key, errors.ErrNotFound if not exists
+	Get(key string) (io.ReadCloser, error)
+	// Exists returns if the key exists
+	Exists(key string) (bool, error)
+	// List returns the keys with the prefix
+	List(prefix string) ([]string, error)
+}
+
+// New returns the repository for the settings
+func New(repo *meta.SnapshotRepository) (Repository, error) {
+	switch repo.Type {
+	case meta.SnapshotRepositoryTypeFS:
+		return NewFSRepository(repo.Settings)
+	case meta.SnapshotRepositoryTypeS3:
+		return NewS3Repository(repo.Settings)
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] repository type [%s] does not exist, supported types are [fs, s3]", repo.Type))
+	}
+}
@@ -0,0 +1,284 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package snapshot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+const (
+	s3DefaultRegion   = "us-east-1"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// s3Repository stores files in a bucket of S3 or a S3 compatible service,
+// the requests are signed with AWS signature version 4
+type s3Repository struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	basePath  string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3Repository returns a repository stores files in the S3 bucket
+func NewS3Repository(settings meta.SnapshotRepositorySettings) (Repository, error) {
+	if settings.Bucket == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[snapshot] s3 repository requires the [bucket] setting")
+	}
+	r := &s3Repository{
+		bucket:    settings.Bucket,
+		region:    settings.Region,
+		basePath:  strings.Trim(settings.BasePath, "/"),
+		accessKey: settings.AccessKey,
+		secretKey: settings.SecretKey,
+		client:    &http.Client{},
+	}
+	if r.region == "" {
+		r.region = s3DefaultRegion
+	}
+	if r.accessKey == "" {
+		r.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if r.secretKey == "" {
+		r.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	endpoint := settings.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + r.region + ".amazonaws.com"
+	}
+	var err error
+	if r.endpoint, err = url.Parse(endpoint); err != nil || r.endpoint.Host == "" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[snapshot] s3 repository has invalid [endpoint] [%s]", endpoint))
+	}
+	return r, nil
+}
+
+func (r *s3Repository) Put(key string, data io.Reader, size int64) error {
+	req, err := r.newRequest(http.MethodPut, r.objectKey(key), nil, data)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (r *s3Repository) Get(key string) (io.ReadCloser, error) {
+	req, err := r.newRequest(http.MethodGet, r.objectKey(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (r *s3Repository) Exists(key string) (bool, error) {
+	req, err := r.newRequest(http.MethodHead, r.objectKey(key), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := r.do(req)
+	if err == errors.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (r *s3Repository) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", r.objectKey(prefix))
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := r.newRequest(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := r.do(req)
+		if err != nil {
+			return nil, err
+		}
+		result := new(s3ListBucketResult)
+		err = xml.NewDecoder(resp.Body).Decode(result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range result.Contents {
+			key := content.Key
+			if r.basePath != "" {
+				key = strings.TrimPrefix(key, r.basePath+"/")
+			}
+			keys = append(keys, key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (r *s3Repository) objectKey(key string) string {
+	if r.basePath == "" {
+		return key
+	}
+	return r.basePath + "/" + key
+}
+
+// newRequest creates a path style request of the object in the bucket
+func (r *s3Repository) newRequest(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *r.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + r.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = ""
+	if query != nil {
+		u.RawQuery = s3CanonicalQuery(query)
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	r.sign(req, time.Now().UTC())
+	return req, nil
+}
+
+func (r *s3Repository) do(req *http.Request) (*http.Response, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errors.ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, errors.New(errors.ErrorTypeRuntimeException, fmt.Sprintf("[snapshot] s3 %s %s failed with status [%d]: %s", req.Method, req.URL.Path, resp.StatusCode, msg))
+	}
+	return resp, nil
+}
+
+// sign adds the AWS signature version 4 to the request, the payload is not signed
+func (r *s3Repository) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format(s3TimeFormat)
+	date := now.Format(s3DateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	if r.accessKey == "" {
+		return // anonymous access
+	}
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + s3UnsignedPayload + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	scope := date + "/" + r.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		s3Hex(sha256.Sum256([]byte(canonicalRequest))),
+	}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+r.secretKey), date)
+	key = s3HMAC(key, r.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", r.accessKey, scope, signedHeaders, signature))
+}
+
+// s3CanonicalQuery encodes the query sorted by key with the escaping of signature version 4
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3Hex(sum [32]byte) string {
+	return hex.EncodeToString(sum[:])
+}
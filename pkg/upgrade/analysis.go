@@ -0,0 +1,57 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// AnalysisVersion is the version of the meaning of the analysis settings, the indexes
+// store the version they are created with and keep the meaning of their settings when it changes:
+//
+//	1: the pattern tokenizer splits the text on the matches, it used to take the matches as the tokens
+const AnalysisVersion = 1
+
+// UpgradeAnalysis rewrites the analysis settings stored by an older version to keep their meaning,
+// the pattern tokenizers become regexp tokenizers which take the matches as the tokens.
+// It returns true if the index is changed and should be saved.
+func UpgradeAnalysis(index *meta.Index) bool {
+	if index.AnalysisVersion >= AnalysisVersion {
+		return false
+	}
+	index.AnalysisVersion = AnalysisVersion
+	if index.Settings == nil || index.Settings.Analysis == nil {
+		return true
+	}
+	for name, v := range index.Settings.Analysis.Tokenizer {
+		options, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if typ, _ := options["type"].(string); strings.ToLower(typ) != "pattern" {
+			continue
+		}
+		upgraded := make(map[string]interface{}, len(options))
+		for k, v := range options {
+			upgraded[k] = v
+		}
+		upgraded["type"] = "regexp"
+		index.Settings.Analysis.Tokenizer[name] = upgraded
+	}
+	return true
+}
@@ -16,6 +16,7 @@
 package aggregation
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strconv"
@@ -28,69 +29,112 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
-func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggregations, mappings *meta.Mappings) error {
+// Request adds the aggregations to the search request, the bucket aggregations
+// stop merging and creating buckets when the ctx of the search is cancelled
+func Request(ctx context.Context, req zincaggregation.SearchAggregation, aggs map[string]meta.Aggregations, mappings *meta.Mappings) error {
 	if len(aggs) == 0 {
 		return nil // not need aggregation
 	}
 	if mappings == nil {
 		return nil // mapping is empty
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	var err error
 	// handle aggregation
 	for name, agg := range aggs {
 		switch {
 		case agg.Avg != nil:
-			req.AddAggregation(name, aggregations.Avg(search.Field(agg.Avg.Field)))
+			src, _, err := fieldSource(agg.Avg.Field, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.Avg(src))
 		case agg.WeightedAvg != nil:
-			req.AddAggregation(name, aggregations.WeightedAvg(search.Field(agg.WeightedAvg.Field), search.Field(agg.WeightedAvg.WeightField)))
+			src, _, err := fieldSource(agg.WeightedAvg.Field, mappings)
+			if err != nil {
+				return err
+			}
+			weight, _, err := fieldSource(agg.WeightedAvg.WeightField, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.WeightedAvg(src, weight))
 		case agg.Max != nil:
-			req.AddAggregation(name, aggregations.Max(search.Field(agg.Max.Field)))
+			src, _, err := fieldSource(agg.Max.Field, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.Max(src))
 		case agg.Min != nil:
-			req.AddAggregation(name, aggregations.Min(search.Field(agg.Min.Field)))
+			src, _, err := fieldSource(agg.Min.Field, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.Min(src))
 		case agg.Sum != nil:
-			req.AddAggregation(name, aggregations.Sum(search.Field(agg.Sum.Field)))
+			src, _, err := fieldSource(agg.Sum.Field, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.Sum(src))
 		case agg.Count != nil:
 			req.AddAggregation(name, aggregations.CountMatches())
 		case agg.Cardinality != nil:
-			req.AddAggregation(name, aggregations.Cardinality(search.Field(agg.Cardinality.Field)))
+			src, _, err := fieldSource(agg.Cardinality.Field, mappings)
+			if err != nil {
+				return err
+			}
+			req.AddAggregation(name, aggregations.Cardinality(src))
 		case agg.Terms != nil:
 			if agg.Terms.Size == 0 {
 				agg.Terms.Size = config.Global.AggregationTermsSize
 			}
 			var subreq *zincaggregation.TermsAggregation
-			prop, _ := mappings.GetProperty(agg.Terms.Field)
-			switch prop.Type {
+			src, typ, err := fieldSource(agg.Terms.Field, mappings)
+			if err != nil {
+				return err
+			}
+			// the documents without a value of a runtime field are not counted
+			_, multi := src.(*runtime.Source)
+			switch typ {
 			case "text", "keyword":
-				subreq = zincaggregation.NewTermsAggregation(search.Field(agg.Terms.Field), zincaggregation.TextValueSource, agg.Terms.Size)
+				subreq = zincaggregation.NewTermsAggregation(src, termsValueType(zincaggregation.TextValueSource, multi), agg.Terms.Size)
 			case "numeric":
-				subreq = zincaggregation.NewTermsAggregation(search.Field(agg.Terms.Field), zincaggregation.NumericValueSource, agg.Terms.Size)
+				subreq = zincaggregation.NewTermsAggregation(src, termsValueType(zincaggregation.NumericValueSource, multi), agg.Terms.Size)
 			case "bool", "boolean":
-				subreq = zincaggregation.NewTermsAggregation(search.Field(agg.Terms.Field), zincaggregation.BooleanValueSource, agg.Terms.Size)
+				subreq = zincaggregation.NewTermsAggregation(src, termsValueType(zincaggregation.BooleanValueSource, multi), agg.Terms.Size)
 			default:
 				return errors.New(
 					errors.ErrorTypeParsingException,
-					fmt.Sprintf("[terms] aggregation doesn't support values of type: [%s:[%s]]", agg.Terms.Field, prop.Type),
+					fmt.Sprintf("[terms] aggregation doesn't support values of type: [%s:[%s]]", agg.Terms.Field, typ),
 				)
 			}
 			if len(agg.Aggregations) > 0 {
-				if err := Request(subreq, agg.Aggregations, mappings); err != nil {
+				if err := Request(ctx, subreq, agg.Aggregations, mappings); err != nil {
 					return err
 				}
 			}
+			subreq.SetContext(ctx)
 			req.AddAggregation(name, subreq)
 		case agg.Range != nil:
 			if len(agg.Range.Ranges) == 0 {
 				return errors.New(errors.ErrorTypeParsingException, "[range] aggregation needs ranges")
 			}
 			var subreq *aggregations.RangeAggregation
-			prop, _ := mappings.GetProperty(agg.Range.Field)
-			switch prop.Type {
+			src, typ, err := fieldSource(agg.Range.Field, mappings)
+			if err != nil {
+				return err
+			}
+			switch typ {
 			case "numeric":
-				subreq = aggregations.Ranges(search.Field(agg.Range.Field))
+				subreq = aggregations.Ranges(src)
 				for _, v := range agg.Range.Ranges {
 					subreq.AddRange(aggregations.Range(v.From, v.To))
 				}
@@ -123,17 +167,18 @@ func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggrega
 			switch prop.Type {
 			case "date", "time":
 				subreq = aggregations.DateRanges(search.Field(agg.DateRange.Field))
+				now := time.Now()
 				for _, v := range agg.DateRange.Ranges {
 					from := time.Time{}
 					to := time.Time{}
 					if v.From != "" {
-						from, err = time.ParseInLocation(format, v.From, timeZone)
+						from, err = zutils.ParseDateMath(v.From, format, timeZone, now, false)
 						if err != nil {
 							return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[date_range] range value from parse err %s", err.Error()))
 						}
 					}
 					if v.To != "" {
-						to, err = time.ParseInLocation(format, v.To, timeZone)
+						to, err = zutils.ParseDateMath(v.To, format, timeZone, now, false)
 						if err != nil {
 							return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[date_range] range value to parse err %s", err.Error()))
 						}
@@ -174,10 +219,11 @@ func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggrega
 				)
 			}
 			if len(agg.Aggregations) > 0 {
-				if err := Request(subreq, agg.Aggregations, mappings); err != nil {
+				if err := Request(ctx, subreq, agg.Aggregations, mappings); err != nil {
 					return err
 				}
 			}
+			subreq.SetContext(ctx)
 			req.AddAggregation(name, subreq)
 		case agg.DateHistogram != nil:
 			if agg.DateHistogram.Size == 0 {
@@ -259,10 +305,11 @@ func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggrega
 				)
 			}
 			if len(agg.Aggregations) > 0 {
-				if err := Request(subreq, agg.Aggregations, mappings); err != nil {
+				if err := Request(ctx, subreq, agg.Aggregations, mappings); err != nil {
 					return err
 				}
 			}
+			subreq.SetContext(ctx)
 			req.AddAggregation(name, subreq)
 		case agg.AutoDateHistogram != nil:
 			if agg.AutoDateHistogram.Buckets <= 0 {
@@ -315,7 +362,7 @@ func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggrega
 				)
 			}
 			if len(agg.Aggregations) > 0 {
-				if err := Request(subreq, agg.Aggregations, mappings); err != nil {
+				if err := Request(ctx, subreq, agg.Aggregations, mappings); err != nil {
 					return err
 				}
 			}
@@ -330,6 +377,53 @@ func Request(req zincaggregation.SearchAggregation, aggs map[string]meta.Aggrega
 	return nil
 }
 
+// termsValueType returns the multi-valued type of the single-valued type if multi is true
+func termsValueType(typ int, multi bool) int {
+	if !multi {
+		return typ
+	}
+	switch typ {
+	case zincaggregation.TextValueSource:
+		return zincaggregation.TextValuesSource
+	case zincaggregation.NumericValueSource:
+		return zincaggregation.NumericValuesSource
+	case zincaggregation.BooleanValueSource:
+		return zincaggregation.BooleanValuesSource
+	}
+	return typ
+}
+
+// valueSource is the text and numeric value source of a field
+type valueSource interface {
+	Fields() []string
+	Value(match *search.DocumentMatch) []byte
+	Values(match *search.DocumentMatch) [][]byte
+	Number(match *search.DocumentMatch) float64
+	Numbers(match *search.DocumentMatch) []float64
+}
+
+// fieldSource returns the value source of the field and its mapping type,
+// the values of a runtime field are computed from the doc values of the fields read by its script
+func fieldSource(field string, mappings *meta.Mappings) (valueSource, string, error) {
+	if f, ok, err := runtime.Compile(field, mappings); ok {
+		if err != nil {
+			return nil, "", err
+		}
+		switch f.Type() {
+		case runtime.TypeKeyword:
+			return f.Source(), "keyword", nil
+		case runtime.TypeBoolean:
+			return f.Source(), "bool", nil
+		case runtime.TypeDate:
+			return f.Source(), "date", nil
+		default:
+			return f.Source(), "numeric", nil
+		}
+	}
+	prop, _ := mappings.GetProperty(field)
+	return search.Field(field), prop.Type, nil
+}
+
 func Response(bucket *search.Bucket) (map[string]meta.AggregationResponse, error) {
 	resp := make(map[string]meta.AggregationResponse)
 	aggs := bucket.Aggregations()
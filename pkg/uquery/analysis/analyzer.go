@@ -22,7 +22,6 @@ import (
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/analyzer"
 	"github.com/blugelabs/bluge/analysis/lang/ar"
-	"github.com/blugelabs/bluge/analysis/lang/cjk"
 	"github.com/blugelabs/bluge/analysis/lang/ckb"
 	"github.com/blugelabs/bluge/analysis/lang/da"
 	"github.com/blugelabs/bluge/analysis/lang/de"
@@ -103,6 +102,15 @@ func RequestAnalyzer(data *meta.IndexAnalysis) (map[string]*analysis.Analyzer, e
 				ana, err = zincanalyzer.NewStopAnalyzer(map[string]interface{}{
 					"stopwords": v.Stopwords,
 				})
+			case "cjk":
+				ana, err = zincanalyzer.NewCJKAnalyzer(map[string]interface{}{
+					"stopwords": v.Stopwords,
+				})
+			case "icu_analyzer":
+				ana, err = zincanalyzer.NewICUAnalyzer(map[string]interface{}{
+					"method": v.Method,
+					"mode":   v.Mode,
+				})
 			default:
 				ana, err = QueryAnalyzer(nil, v.Type)
 				if ana == nil {
@@ -204,11 +212,13 @@ func QueryAnalyzer(data map[string]*analysis.Analyzer, name string) (*analysis.A
 		return chs.NewGseStandardAnalyzer(), nil
 	case "gse_search": // for Chinese support
 		return chs.NewGseSearchAnalyzer(), nil
+	case "icu_analyzer":
+		return zincanalyzer.NewICUAnalyzer(nil)
 		// language filters
 	case "ar", "arabic":
 		return ar.Analyzer(), nil
 	case "cjk": // for Asia language
-		return cjk.Analyzer(), nil
+		return zincanalyzer.NewCJKAnalyzer(nil)
 	case "ckb", "sorani":
 		return ckb.Analyzer(), nil
 	case "da", "danish":
@@ -251,6 +261,8 @@ func QueryAnalyzer(data map[string]*analysis.Analyzer, name string) (*analysis.A
 }
 
 // QueryAnalyzerForField returns the analyzer and searchAnalyzer for the given field.
+// The searchAnalyzer is the search_analyzer of the field, or the default_search analyzer of the index,
+// it is nil when neither exists and the analyzer should be used for the query also.
 func QueryAnalyzerForField(data map[string]*analysis.Analyzer, mappings *meta.Mappings, field string) (*analysis.Analyzer, *analysis.Analyzer) {
 	if field == "" {
 		return nil, nil
@@ -273,7 +285,12 @@ func QueryAnalyzerForField(data map[string]*analysis.Analyzer, mappings *meta.Ma
 	}
 
 	analyzer, _ := QueryAnalyzer(data, analyzerName)
-	searchAnalyzer, _ := QueryAnalyzer(data, searchAnalyzerName)
+	var searchAnalyzer *analysis.Analyzer
+	if searchAnalyzerName != "" {
+		searchAnalyzer, _ = QueryAnalyzer(data, searchAnalyzerName)
+	} else if v, ok := data["default_search"]; ok {
+		searchAnalyzer = v
+	}
 
 	return analyzer, searchAnalyzer
 }
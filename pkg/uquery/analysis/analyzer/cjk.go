@@ -0,0 +1,35 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package analyzer
+
+import (
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/lang/cjk"
+
+	zinctoken "github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func NewCJKAnalyzer(options interface{}) (*analysis.Analyzer, error) {
+	stopwords, _ := zutils.GetStringSliceFromMap(options, "stopwords")
+
+	ana := cjk.Analyzer()
+	if len(stopwords) > 0 {
+		ana.TokenFilters = append(ana.TokenFilters, zinctoken.NewStopTokenFilter(stopwords))
+	}
+
+	return ana, nil
+}
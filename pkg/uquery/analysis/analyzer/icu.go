@@ -0,0 +1,44 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/tokenizer"
+
+	zincchar "github.com/zincsearch/zincsearch/pkg/bluge/analysis/char"
+	zinctoken "github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// NewICUAnalyzer normalizes the text by the option [method] and [mode], splits it on the unicode word boundaries and folds the tokens
+func NewICUAnalyzer(options interface{}) (*analysis.Analyzer, error) {
+	method, _ := zutils.GetStringFromMap(options, "method")
+	mode, _ := zutils.GetStringFromMap(options, "mode")
+	normalizer, err := zinctoken.NewICUNormalizer(method, mode)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[analyzer] icu_analyzer option %s", err.Error()))
+	}
+
+	return &analysis.Analyzer{
+		CharFilters:  []analysis.CharFilter{zincchar.NewICUNormalizerCharFilter(normalizer)},
+		Tokenizer:    tokenizer.NewUnicodeTokenizer(),
+		TokenFilters: []analysis.TokenFilter{zinctoken.NewICUFoldingTokenFilter()},
+	}, nil
+}
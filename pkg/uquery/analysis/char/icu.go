@@ -0,0 +1,37 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package char
+
+import (
+	"fmt"
+
+	"github.com/blugelabs/bluge/analysis"
+
+	zincchar "github.com/zincsearch/zincsearch/pkg/bluge/analysis/char"
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func NewICUNormalizerCharFilter(options interface{}) (analysis.CharFilter, error) {
+	name, _ := zutils.GetStringFromMap(options, "name")
+	mode, _ := zutils.GetStringFromMap(options, "mode")
+	normalizer, err := token.NewICUNormalizer(name, mode)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[char_filter] icu_normalizer option %s", err.Error()))
+	}
+	return zincchar.NewICUNormalizerCharFilter(normalizer), nil
+}
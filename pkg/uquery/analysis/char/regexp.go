@@ -17,7 +17,6 @@ package char
 
 import (
 	"fmt"
-	"regexp"
 
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/char"
@@ -32,7 +31,8 @@ func NewRegexpCharFilter(options interface{}) (analysis.CharFilter, error) {
 		return nil, errors.New(errors.ErrorTypeParsingException, "[char_filter] regexp option [pattern] should be exists")
 	}
 	replacement, _ := zutils.GetStringFromMap(options, "replacement")
-	r, err := regexp.Compile(pattern)
+	flags, _ := zutils.GetStringFromMap(options, "flags")
+	r, err := zutils.CompileRegexp(pattern, flags)
 	if err != nil {
 		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[char_filter] regexp option [pattern] compile error: %s", err.Error()))
 	}
@@ -86,6 +86,8 @@ func RequestCharFilterSingle(name string, options interface{}) (analysis.CharFil
 		return char.NewASCIIFoldingFilter(), nil
 	case "html", "html_strip":
 		return char.NewHTMLCharFilter(), nil
+	case "icu_normalizer":
+		return zincchar.NewICUNormalizerCharFilter(options)
 	case "zero_width_non_joiner":
 		return char.NewZeroWidthNonJoinerCharFilter(), nil
 	case "regexp", "pattern", "pattern_replace":
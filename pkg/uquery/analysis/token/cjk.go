@@ -13,34 +13,16 @@
 * limitations under the License.
  */
 
-package elastic
+package token
 
 import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/lang/cjk"
 
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
-func GetDataStream(c *gin.Context) {
-	target := c.Param("target")
-	zutils.GinRenderJSON(c, http.StatusOK, gin.H{
-		"data_streams": []gin.H{
-			{
-				"name": target,
-				"timestamp_field": gin.H{
-					"name": "@timestamp",
-				},
-			},
-		},
-	})
-}
-
-func PutDataStream(c *gin.Context) {
-	target := c.Param("target")
-	zutils.GinRenderJSON(c, http.StatusOK, gin.H{
-		"name":    target,
-		"message": "ok",
-	})
+func NewCJKBigramTokenFilter(options interface{}) (analysis.TokenFilter, error) {
+	outputUnigrams, _ := zutils.GetBoolFromMap(options, "output_unigrams")
+	return cjk.NewBigramFilter(outputUnigrams), nil
 }
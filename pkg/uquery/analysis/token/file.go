@@ -0,0 +1,37 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+)
+
+// readAnalysisFile returns the lines of the file in the analysis path, the path can't be out of it
+func readAnalysisFile(path string) ([]string, error) {
+	if !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("[%s] should be a relative path in the analysis path", path)
+	}
+	data, err := os.ReadFile(filepath.Join(config.Global.AnalysisPath, path))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] read err %s", path, err.Error())
+	}
+	return strings.Split(string(data), "\n"), nil
+}
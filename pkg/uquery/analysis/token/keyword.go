@@ -16,6 +16,9 @@
 package token
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/token"
 
@@ -24,9 +27,24 @@ import (
 )
 
 func NewKeywordTokenFilter(options interface{}) (analysis.TokenFilter, error) {
-	keywords, err := zutils.GetStringSliceFromMap(options, "keywords")
-	if err != nil {
-		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] keyword option [keywords] should be an array of string")
+	var keywords []string
+	if path, _ := zutils.GetStringFromMap(options, "keywords_path"); path != "" {
+		lines, err := readAnalysisFile(path)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] keyword option [keywords_path] %s", err.Error()))
+		}
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				keywords = append(keywords, line)
+			}
+		}
+	}
+	if _, err := zutils.GetAnyFromMap(options, "keywords"); err == nil || keywords == nil {
+		words, err := zutils.GetStringSliceFromMap(options, "keywords")
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] keyword option [keywords] should be an array of string")
+		}
+		keywords = append(keywords, words...)
 	}
 	dict := analysis.NewTokenMap()
 	for _, word := range keywords {
@@ -17,8 +17,8 @@ package token
 
 import (
 	"github.com/blugelabs/bluge/analysis"
-	"github.com/blugelabs/bluge/analysis/token"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
@@ -26,14 +26,18 @@ import (
 func NewNgramTokenFilter(options interface{}) (analysis.TokenFilter, error) {
 	min, _ := zutils.GetFloatFromMap(options, "min_gram")
 	max, _ := zutils.GetFloatFromMap(options, "max_gram")
+	preserveOriginal, _ := zutils.GetBoolFromMap(options, "preserve_original")
 	if min == 0 {
 		min = 1
 	}
 	if max == 0 {
 		max = 2
 	}
+	if min < 1 {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] ngram option [min_gram] should be greater than 0")
+	}
 	if min > max {
 		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] ngram option [min_gram] should be not greater than [max_gram]")
 	}
-	return token.NewNgramFilter(int(min), int(max)), nil
+	return token.NewNgramFilter(int(min), int(max), preserveOriginal), nil
 }
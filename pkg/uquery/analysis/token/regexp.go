@@ -17,7 +17,6 @@ package token
 
 import (
 	"fmt"
-	"regexp"
 
 	"github.com/blugelabs/bluge/analysis"
 
@@ -32,10 +31,15 @@ func NewRegexpTokenFilter(options interface{}) (analysis.TokenFilter, error) {
 		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] regexp option [pattern] should be exists")
 	}
 	replacement, _ := zutils.GetStringFromMap(options, "replacement")
-	r, err := regexp.Compile(pattern)
+	flags, _ := zutils.GetStringFromMap(options, "flags")
+	all := true
+	if v, err := zutils.GetBoolFromMap(options, "all"); err == nil {
+		all = v
+	}
+	r, err := zutils.CompileRegexp(pattern, flags)
 	if err != nil {
 		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] regexp option [pattern] compile error: %s", err.Error()))
 	}
 
-	return token.NewRegexpTokenFilter(r, []byte(replacement)), nil
+	return token.NewRegexpTokenFilter(r, []byte(replacement), all), nil
 }
@@ -0,0 +1,122 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/lang/ar"
+	"github.com/blugelabs/bluge/analysis/lang/ckb"
+	"github.com/blugelabs/bluge/analysis/lang/de"
+	"github.com/blugelabs/bluge/analysis/lang/en"
+	"github.com/blugelabs/bluge/analysis/lang/es"
+	"github.com/blugelabs/bluge/analysis/lang/fr"
+	"github.com/blugelabs/bluge/analysis/lang/hi"
+	"github.com/blugelabs/bluge/analysis/lang/it"
+	"github.com/blugelabs/bluge/analysis/lang/pt"
+	blugetoken "github.com/blugelabs/bluge/analysis/token"
+
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// NewStemmerTokenFilter returns the stemmer of the option [language], default is the porter stemmer of english
+func NewStemmerTokenFilter(options interface{}) (analysis.TokenFilter, error) {
+	language, _ := zutils.GetStringFromMap(options, "language")
+	if language == "" {
+		language, _ = zutils.GetStringFromMap(options, "name")
+	}
+
+	var stemmer analysis.TokenFilter
+	switch strings.ToLower(language) {
+	case "", "english", "porter":
+		return blugetoken.NewPorterStemmer(), nil
+	case "porter2":
+		language = "english"
+	case "possessive_english":
+		return en.NewPossessiveFilter(), nil
+	case "arabic":
+		stemmer = ar.StemmerFilter()
+	case "german", "light_german":
+		stemmer = de.LightStemmerFilter()
+	case "german2":
+		language = "german"
+	case "light_spanish":
+		stemmer = es.LightStemmerFilter()
+	case "light_french":
+		stemmer = fr.LightStemmerFilter()
+	case "minimal_french":
+		stemmer = fr.MinimalStemmerFilter()
+	case "hindi":
+		stemmer = hi.StemmerFilter()
+	case "light_italian":
+		stemmer = it.LightStemmerFilter()
+	case "light_portuguese":
+		stemmer = pt.LightStemmerFilter()
+	case "sorani":
+		return ckb.StemmerFilter(), nil
+	}
+	if stemmer != nil {
+		return token.NewKeywordAwareFilter(stemmer), nil
+	}
+
+	filter, err := token.NewSnowballStemmerFilter(language)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] stemmer option [language] doesn't support [%s]", language))
+	}
+	return filter, nil
+}
+
+// NewSnowballTokenFilter returns the snowball stemmer of the option [language], default is english
+func NewSnowballTokenFilter(options interface{}) (analysis.TokenFilter, error) {
+	language, _ := zutils.GetStringFromMap(options, "language")
+	if language == "" {
+		language = "english"
+	}
+	filter, err := token.NewSnowballStemmerFilter(language)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] snowball option [language] doesn't support [%s]", language))
+	}
+	return filter, nil
+}
+
+func NewStemmerOverrideTokenFilter(options interface{}) (analysis.TokenFilter, error) {
+	var rules []string
+	if _, err := zutils.GetAnyFromMap(options, "rules"); err == nil {
+		rules, err = zutils.GetStringSliceFromMap(options, "rules")
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] stemmer_override option [rules] should be an array of string")
+		}
+	}
+	if path, _ := zutils.GetStringFromMap(options, "rules_path"); path != "" {
+		lines, err := readAnalysisFile(path)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] stemmer_override option [rules_path] %s", err.Error()))
+		}
+		rules = append(rules, lines...)
+	} else if rules == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] stemmer_override option [rules] or [rules_path] should be exists")
+	}
+
+	filter := token.NewStemmerOverrideFilter()
+	if err := filter.AddRules(rules); err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] stemmer_override %s", err.Error()))
+	}
+	return filter, nil
+}
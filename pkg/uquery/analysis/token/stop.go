@@ -16,13 +16,44 @@
 package token
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/blugelabs/bluge/analysis"
 
 	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 func NewStopTokenFilter(options interface{}) (analysis.TokenFilter, error) {
-	stopwords, _ := zutils.GetStringSliceFromMap(options, "stopwords")
+	var stopwords []string
+	if v, err := zutils.GetAnyFromMap(options, "stopwords"); err == nil {
+		switch v := v.(type) {
+		case string:
+			stopwords = []string{v}
+		default:
+			stopwords, err = zutils.GetStringSliceFromMap(options, "stopwords")
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] stop option [stopwords] should be a string or an array of string")
+			}
+		}
+	}
+	if path, _ := zutils.GetStringFromMap(options, "stopwords_path"); path != "" {
+		lines, err := readAnalysisFile(path)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] stop option [stopwords_path] %s", err.Error()))
+		}
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				stopwords = append(stopwords, line)
+			}
+		}
+	}
+
+	ignoreCase, _ := zutils.GetBoolFromMap(options, "ignore_case")
+	if ignoreCase {
+		return token.NewIgnoreCaseStopTokenFilter(stopwords), nil
+	}
 	return token.NewStopTokenFilter(stopwords), nil
 }
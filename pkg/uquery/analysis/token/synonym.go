@@ -0,0 +1,68 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package token
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge/analysis"
+
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/token"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func NewSynonymTokenFilter(options interface{}) (analysis.TokenFilter, error) {
+	var rules []string
+	if _, err := zutils.GetAnyFromMap(options, "synonyms"); err == nil {
+		synonyms, err := zutils.GetStringSliceFromMap(options, "synonyms")
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] synonym option [synonyms] should be an array of string")
+		}
+		rules = append(rules, synonyms...)
+	}
+	if path, _ := zutils.GetStringFromMap(options, "synonyms_path"); path != "" {
+		lines, err := readAnalysisFile(path)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] synonym option [synonyms_path] %s", err.Error()))
+		}
+		rules = append(rules, lines...)
+	} else if rules == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[token_filter] synonym option [synonyms] or [synonyms_path] should be exists")
+	}
+
+	expand := true
+	if v, err := zutils.GetBoolFromMap(options, "expand"); err == nil {
+		expand = v
+	}
+	format, _ := zutils.GetStringFromMap(options, "format")
+
+	filter := token.NewSynonymTokenFilter()
+	var err error
+	switch strings.ToLower(format) {
+	case "", "solr":
+		err = token.ParseSolrSynonyms(filter, rules, expand)
+	case "wordnet":
+		err = token.ParseWordnetSynonyms(filter, rules, expand)
+	default:
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] synonym option [format] doesn't support [%s]", format))
+	}
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[token_filter] synonym %s", err.Error()))
+	}
+	return filter, nil
+}
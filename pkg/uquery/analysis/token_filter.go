@@ -111,10 +111,14 @@ func RequestTokenFilterSingle(name string, options interface{}) (analysis.TokenF
 		return token.NewCamelCaseFilter(), nil
 	case "dict":
 		return zinctoken.NewDictTokenFilter(options)
-	case "edge_ngram":
+	case "edge_ngram", "edgengram":
 		return zinctoken.NewEdgeNgramTokenFilter(options)
 	case "elision":
 		return zinctoken.NewElisionTokenFilter(options)
+	case "icu_folding":
+		return zinctoken.NewICUFoldingTokenFilter()
+	case "icu_normalizer":
+		return zinctoken.NewICUNormalizerTokenFilter(options)
 	case "keyword", "keyword_marker":
 		return zinctoken.NewKeywordTokenFilter(options)
 	case "length":
@@ -123,8 +127,14 @@ func RequestTokenFilterSingle(name string, options interface{}) (analysis.TokenF
 		return token.NewLowerCaseFilter(), nil
 	case "ngram":
 		return zinctoken.NewNgramTokenFilter(options)
-	case "porter", "stemmer":
+	case "porter":
 		return token.NewPorterStemmer(), nil
+	case "stemmer":
+		return zinctoken.NewStemmerTokenFilter(options)
+	case "stemmer_override":
+		return zinctoken.NewStemmerOverrideTokenFilter(options)
+	case "snowball":
+		return zinctoken.NewSnowballTokenFilter(options)
 	case "reverse":
 		return token.NewReverseFilter(), nil
 	case "regexp", "pattern_replace":
@@ -135,6 +145,8 @@ func RequestTokenFilterSingle(name string, options interface{}) (analysis.TokenF
 		return zinctoken.NewTrimTokenFilter()
 	case "stop":
 		return zinctoken.NewStopTokenFilter(options)
+	case "synonym", "synonym_graph":
+		return zinctoken.NewSynonymTokenFilter(options)
 	case "truncate":
 		return zinctoken.NewTruncateTokenFilter(options)
 	case "unicodenorm":
@@ -151,7 +163,7 @@ func RequestTokenFilterSingle(name string, options interface{}) (analysis.TokenF
 	case "ar_stemmer", "arabic_stemmer":
 		return ar.StemmerFilter(), nil
 	case "cjk_bigram":
-		return cjk.NewBigramFilter(false), nil
+		return zinctoken.NewCJKBigramTokenFilter(options)
 	case "cjk_width":
 		return cjk.NewWidthFilter(), nil
 	case "ckb_normalization", "sorani_normalization":
@@ -89,11 +89,13 @@ func RequestTokenizerSingle(name string, options interface{}) (analysis.Tokenize
 		return zinctokenizer.NewNgramTokenizer(options)
 	case "path_hierarchy":
 		return zinctokenizer.NewPathHierarchyTokenizer(options)
-	case "regexp", "pattern":
+	case "pattern":
+		return zinctokenizer.NewPatternTokenizer(options)
+	case "regexp":
 		return zinctokenizer.NewRegexpTokenizer(options)
 	case "single", "keyword":
 		return tokenizer.NewSingleTokenTokenizer(), nil
-	case "unicode", "standard":
+	case "unicode", "standard", "icu_tokenizer":
 		return tokenizer.NewUnicodeTokenizer(), nil
 	case "web":
 		return tokenizer.NewWebTokenizer(), nil
@@ -0,0 +1,49 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package tokenizer
+
+import (
+	"fmt"
+
+	"github.com/blugelabs/bluge/analysis"
+
+	"github.com/zincsearch/zincsearch/pkg/bluge/analysis/tokenizer"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// NewPatternTokenizer splits the text by the option [pattern], or extracts the option [group] of the matches
+func NewPatternTokenizer(options interface{}) (analysis.Tokenizer, error) {
+	pattern, _ := zutils.GetStringFromMap(options, "pattern")
+	if len(pattern) == 0 {
+		pattern = "\\W+"
+	}
+	flags, _ := zutils.GetStringFromMap(options, "flags")
+	group := -1
+	if v, err := zutils.GetFloatFromMap(options, "group"); err == nil {
+		group = int(v)
+	}
+
+	r, err := zutils.CompileRegexp(pattern, flags)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[tokenizer] pattern option [pattern] compile error: %s", err.Error()))
+	}
+	if group > r.NumSubexp() {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[tokenizer] pattern option [group] %d is greater than the groups of the pattern", group))
+	}
+
+	return tokenizer.NewPatternTokenizer(r, group), nil
+}
@@ -0,0 +1,98 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package collapse
+
+import (
+	"fmt"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/search"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+const DefaultInnerHitsSize = 3
+
+// Request checks the collapse field and appends it to the sort order of the request,
+// then the collapse value of each hit is the last sort value.
+func Request(request *bluge.TopNSearch, collapse *meta.Collapse, mappings *meta.Mappings) error {
+	if collapse.Field == "" {
+		return errors.New(errors.ErrorTypeParsingException, "[collapse] field is required")
+	}
+	prop, ok := mappings.GetProperty(collapse.Field)
+	if !ok {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[collapse] no mapping found for field [%s]", collapse.Field))
+	}
+	switch prop.Type {
+	case "keyword", "numeric", "date", "time", "bool":
+	default:
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[collapse] field [%s] of type [%s] is not supported, it should be keyword or numeric", collapse.Field, prop.Type))
+	}
+	if !prop.Sortable {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[collapse] field [%s] is not sortable", collapse.Field))
+	}
+
+	if collapse.InnerHits != nil {
+		if collapse.InnerHits.Name == "" {
+			collapse.InnerHits.Name = collapse.Field
+		}
+		if collapse.InnerHits.Size <= 0 {
+			collapse.InnerHits.Size = DefaultInnerHitsSize
+		}
+		if collapse.InnerHits.From < 0 {
+			collapse.InnerHits.From = 0
+		}
+	}
+
+	order := request.SortOrder().Copy()
+	order = append(order, search.SortBy(search.Field(collapse.Field)))
+	request.SortByCustom(order)
+
+	return nil
+}
+
+// Key returns the collapse key of the hit
+func Key(doc *search.DocumentMatch) string {
+	if len(doc.SortValue) == 0 {
+		return ""
+	}
+	return string(doc.SortValue[len(doc.SortValue)-1])
+}
+
+// Value returns the collapse value of the hit decoded by the field type
+func Value(doc *search.DocumentMatch, typ string) interface{} {
+	key := Key(doc)
+	if key == "" {
+		return nil
+	}
+	switch typ {
+	case "numeric":
+		v, err := bluge.DecodeNumericFloat64([]byte(key))
+		if err != nil {
+			return nil
+		}
+		return v
+	case "date", "time":
+		v, err := bluge.DecodeDateTime([]byte(key))
+		if err != nil {
+			return nil
+		}
+		return v
+	default:
+		return key
+	}
+}
@@ -19,8 +19,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blugelabs/bluge/search"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
@@ -57,7 +63,9 @@ func Request(v []interface{}) ([]*meta.Field, error) {
 	return fields, nil
 }
 
-func Response(fields []*meta.Field, data []byte, mappings *meta.Mappings) map[string]interface{} {
+// Response returns the values of the fields from the source, the values are always arrays,
+// the nested objects are flattened with dotted paths and the date fields are formatted if required
+func Response(fields []*meta.Field, data []byte, timestamp time.Time, mappings *meta.Mappings) map[string]interface{} {
 	// return empty
 	if len(fields) == 0 {
 		return nil
@@ -69,43 +77,136 @@ func Response(fields []*meta.Field, data []byte, mappings *meta.Mappings) map[st
 		return nil
 	}
 
-	var field string
-	wildcard := false
+	values := make(map[string][]interface{})
+	flatten(values, "", ret)
+	// the multi-fields have the values of their parent field, they are returned only when requested by name
+	multiFields := make(map[string][]interface{})
+	for field, rv := range values {
+		prop, _ := mappings.GetProperty(field)
+		for sub := range prop.Fields {
+			multiFields[field+"."+sub] = rv
+		}
+	}
+	if !timestamp.IsZero() {
+		values[meta.TimeFieldName] = []interface{}{timestamp.Format(time.RFC3339Nano)}
+	}
+
 	results := make(map[string]interface{})
 	for _, v := range fields {
-		wildcard = false
-		field = v.Field
-		if strings.HasSuffix(field, "*") {
-			wildcard = true
+		if rv, ok := multiFields[v.Field]; ok {
+			results[v.Field] = rv
 		}
-		if rv, ok := ret[field]; ok {
+		for field, rv := range values {
+			if !source.MatchPattern(v.Field, field) {
+				continue
+			}
 			prop, _ := mappings.GetProperty(field)
-			if (prop.Type == "date" || prop.Type == "time") && v.Format != "" {
-				if t, err := time.Parse(prop.Format, rv.(string)); err == nil {
-					results[field] = []interface{}{t.Format(v.Format)}
-				} else {
-					results[field] = []interface{}{rv}
-				}
+			if field == meta.TimeFieldName {
+				prop.Type = "date"
+				prop.Format = time.RFC3339Nano
+			}
+			// the dates are returned in the requested format or the first format of the mapping
+			format := v.Format
+			if format == "" {
+				format = prop.Format
+			}
+			if (prop.Type == "date" || prop.Type == "time") && format != "" {
+				results[field] = formatDates(rv, prop, format)
 			} else {
-				results[field] = []interface{}{rv}
+				results[field] = rv
 			}
-		} else if wildcard {
-			for rk, rv := range ret {
-				if strings.HasPrefix(rk, field[:len(field)-1]) {
-					prop, _ := mappings.GetProperty(rk)
-					if (prop.Type == "date" || prop.Type == "time") && v.Format != "" {
-						if t, err := time.Parse(prop.Format, rv.(string)); err == nil {
-							results[rk] = []interface{}{t.Format(v.Format)}
-						} else {
-							results[rk] = []interface{}{rv}
-						}
-					} else {
-						results[rk] = []interface{}{rv}
-					}
-				}
+		}
+	}
+
+	return results
+}
+
+// flatten collects the leaf values of the object by the dotted paths
+func flatten(values map[string][]interface{}, path string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, v := range v {
+			if path != "" {
+				k = path + "." + k
 			}
+			flatten(values, k, v)
+		}
+	case []interface{}:
+		for _, v := range v {
+			flatten(values, path, v)
 		}
+	case nil:
+		// null values are not returned
+	default:
+		values[path] = append(values[path], v)
 	}
+}
 
+func formatDates(values []interface{}, prop meta.Property, format string) []interface{} {
+	results := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		t, err := zutils.ParseTime(v, prop.Format, prop.TimeZone)
+		if err != nil {
+			results = append(results, v)
+			continue
+		}
+		results = append(results, zutils.FormatTime(t, format))
+	}
 	return results
 }
+
+// Runtime adds the values of the requested runtime fields to the results,
+// the values are computed from the doc values of the hit
+func Runtime(results map[string]interface{}, fields []*meta.Field, doc *search.DocumentMatch, mappings *meta.Mappings) (map[string]interface{}, error) {
+	if len(fields) == 0 || mappings == nil {
+		return results, nil
+	}
+	for name := range mappings.ListRuntime() {
+		var format string
+		var matched bool
+		for _, v := range fields {
+			if source.MatchPattern(v.Field, name) {
+				matched = true
+				format = v.Format
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		f, _, err := runtime.Compile(name, mappings)
+		if err != nil {
+			return results, err
+		}
+		// the doc values may be loaded by the sort or the aggregations already
+		missing := make([]string, 0, len(f.Fields()))
+		for _, field := range f.Fields() {
+			if doc.DocValues(field) == nil {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			if err := doc.LoadDocumentValues(search.NewSearchContext(0, 0), missing); err != nil {
+				return results, err
+			}
+		}
+		values := make(zincquery.DocValues, len(f.Fields()))
+		for _, field := range f.Fields() {
+			values[field] = doc.DocValues(field)
+		}
+		v, ok := f.Value(values)
+		if !ok {
+			continue
+		}
+		if t, ok := v.(time.Time); ok && format != "" {
+			v = zutils.FormatTime(t, format)
+		} else {
+			v = f.Format(v)
+		}
+		if results == nil {
+			results = make(map[string]interface{})
+		}
+		results[name] = []interface{}{v}
+	}
+	return results, nil
+}
@@ -0,0 +1,138 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package fields
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+)
+
+// StoredFieldsNone disables the stored fields and the source of the hits
+const StoredFieldsNone = "_none_"
+
+// StoredRequest parses the stored_fields, a comma separated string or an array of field patterns
+func StoredRequest(v interface{}) ([]string, error) {
+	var patterns []string
+	switch v := v.(type) {
+	case []string:
+		patterns = v
+	case string:
+		patterns = strings.Split(v, ",")
+	case []interface{}:
+		patterns = make([]string, 0, len(v))
+		for _, field := range v {
+			field, ok := field.(string)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[stored_fields] value should be string or array of string")
+			}
+			patterns = append(patterns, field)
+		}
+	default:
+		return nil, errors.New(errors.ErrorTypeXContentParseException, "[stored_fields] value should be string or array of string")
+	}
+
+	fields := make([]string, 0, len(patterns))
+	for _, field := range patterns {
+		if field = strings.TrimSpace(field); field != "" && field != StoredFieldsNone {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// Stored collects the values of the requested fields stored in the index, the fields with store in the mappings
+type Stored struct {
+	patterns []string
+	mappings *meta.Mappings
+	values   map[string]interface{}
+}
+
+func NewStored(patterns []string, mappings *meta.Mappings) *Stored {
+	return &Stored{patterns: patterns, mappings: mappings}
+}
+
+// Visit adds the stored value of the field if it is requested
+func (s *Stored) Visit(field string, value []byte) {
+	if s == nil || len(s.patterns) == 0 {
+		return
+	}
+	prop, ok := s.mappings.GetProperty(field)
+	if !ok || !prop.Store {
+		return
+	}
+	matched := false
+	for _, pattern := range s.patterns {
+		if source.MatchPattern(pattern, field) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return
+	}
+	v, ok := storedValue(prop, value)
+	if !ok {
+		return
+	}
+	if s.values == nil {
+		s.values = make(map[string]interface{})
+	}
+	values, _ := s.values[field].([]interface{})
+	s.values[field] = append(values, v)
+}
+
+// Response returns the values of the stored fields, the values are always arrays like the fields
+func (s *Stored) Response() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.values
+}
+
+// storedValue decodes the stored value by the type of the field
+func storedValue(prop meta.Property, value []byte) (interface{}, bool) {
+	switch prop.Type {
+	case "text", "keyword":
+		return string(value), true
+	case "numeric":
+		v, err := bluge.DecodeNumericFloat64(value)
+		return v, err == nil
+	case "bool":
+		v, err := strconv.ParseBool(string(value))
+		return v, err == nil
+	case "date", "time":
+		v, err := bluge.DecodeDateTime(value)
+		if err != nil {
+			return nil, false
+		}
+		return v.Format(time.RFC3339Nano), true
+	case "geo_point":
+		lon, lat, err := bluge.DecodeGeoLonLat(value)
+		if err != nil {
+			return nil, false
+		}
+		return map[string]interface{}{"lat": lat, "lon": lon}, true
+	default:
+		return nil, false
+	}
+}
@@ -17,6 +17,7 @@ package mappings
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/blugelabs/bluge/analysis"
@@ -25,8 +26,8 @@ import (
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
-	"github.com/zincsearch/zincsearch/pkg/zutils/json"
 )
 
 func Request(analyzers map[string]*analysis.Analyzer, data map[string]interface{}) (*meta.Mappings, error) {
@@ -34,16 +35,41 @@ func Request(analyzers map[string]*analysis.Analyzer, data map[string]interface{
 		return nil, nil
 	}
 
-	if data["properties"] == nil {
+	if data["properties"] == nil && data["runtime"] == nil && data["dynamic_templates"] == nil && data["_source"] == nil {
 		return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] properties should be defined")
 	}
 
 	properties, ok := data["properties"].(map[string]interface{})
-	if !ok {
+	if !ok && data["properties"] != nil {
 		return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] properties should be an object")
 	}
 
 	mappings := meta.NewMappings()
+	if data["_source"] != nil {
+		source, err := sourceMapping(data["_source"])
+		if err != nil {
+			return nil, err
+		}
+		mappings.SetSource(source)
+	}
+	if data["runtime"] != nil {
+		fields, err := runtime.Request(data["runtime"])
+		if err != nil {
+			return nil, err
+		}
+		for field, v := range fields {
+			mappings.SetRuntime(field, v)
+		}
+	}
+	if data["dynamic_templates"] != nil {
+		templates, err := dynamicTemplates(analyzers, data["dynamic_templates"])
+		if err != nil {
+			return nil, err
+		}
+		for _, tpl := range templates {
+			mappings.SetDynamicTemplate(tpl)
+		}
+	}
 	for field, prop := range properties {
 		var propFields map[string]interface{}
 
@@ -64,6 +90,9 @@ func Request(analyzers map[string]*analysis.Analyzer, data map[string]interface{
 			} else {
 				return nil, err
 			}
+			if propType, ok := prop["type"].(string); ok && strings.ToLower(propType) == "nested" {
+				mappings.SetProperty(field, meta.NewProperty("nested"))
+			}
 
 			continue
 		}
@@ -75,132 +104,335 @@ func Request(analyzers map[string]*analysis.Analyzer, data map[string]interface{
 			}
 		}
 
-		propType, ok := prop["type"]
-		if !ok {
-			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] should be exists", "type"))
+		newProp, err := newProperty(field, prop)
+		if err != nil {
+			return nil, err
+		}
+		if newProp.Type == "" {
+			continue
 		}
 
-		propTypeStr, ok := propType.(string)
-		if !ok {
-			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] should be an string", "type"))
+		// multi-fields index the same value in different ways, e.g. a keyword field of a text field
+		fields, err := newFields(analyzers, field, propFields)
+		if err != nil {
+			return nil, err
 		}
+		for k, v := range fields {
+			newProp.AddField(k, v)
+		}
+		for k, v := range newProp.Fields {
+			mappings.SetProperty(field+"."+k, v)
+		}
+		mappings.SetProperty(field, newProp)
 
-		var newProp meta.Property
-		propTypeStr = strings.ToLower(propTypeStr)
-		switch propTypeStr {
-		case "text":
-			newProp = meta.NewProperty(propTypeStr)
+		if newProp.Type == "text" {
+			if err := checkAnalyzers(analyzers, newProp); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return mappings, nil
+}
 
-			if config.Global.EnableTextKeywordMapping {
-				p := meta.NewProperty("keyword")
-				newProp.AddField("keyword", p)
+// sourceMapping parses the _source mapping, only enabled is supported
+func sourceMapping(data interface{}) (*meta.SourceMapping, error) {
+	v, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] _source should be an object")
+	}
+	source := &meta.SourceMapping{Enabled: true}
+	for k, v := range v {
+		switch k {
+		case "enabled":
+			enabled, ok := v.(bool)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] _source.enabled should be a boolean")
 			}
-		case "keyword", "numeric", "bool", "date":
-			newProp = meta.NewProperty(propTypeStr)
-		case "constant_keyword":
-			newProp = meta.NewProperty("keyword")
-		case "match_only_text":
-			newProp = meta.NewProperty("text")
-		case "integer", "double", "long", "short", "int", "float":
-			newProp = meta.NewProperty("numeric")
-		case "boolean":
-			newProp = meta.NewProperty("bool")
-		case "time", "datetime":
-			newProp = meta.NewProperty("date")
-		case "flattened", "object", "nested", "wildcard", "byte", "alias", "geo_point", "ip", "ip_range", "scaled_float":
-			// ignore
+			source.Enabled = enabled
 		default:
-			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[mappings] properties [%s] doesn't support type [%s]", field, propTypeStr))
-		}
-
-		for k, v := range prop {
-			switch k {
-			case "type":
-				// handled
-			case "analyzer":
-				newProp.Analyzer = v.(string)
-			case "search_analyzer":
-				newProp.SearchAnalyzer = v.(string)
-			case "format":
-				newProp.Format = v.(string)
-			case "time_zone":
-				newProp.TimeZone = v.(string)
-				_, err := zutils.ParseTimeZone(newProp.TimeZone)
-				if err != nil {
-					return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] %s time_zone parse err %s", field, err.Error()))
-				}
-			case "index":
-				newProp.Index = v.(bool)
-			case "store":
-				newProp.Store = v.(bool)
-			case "sortable":
-				newProp.Sortable = v.(bool)
-			case "aggregatable":
-				newProp.Aggregatable = v.(bool)
-			case "highlightable":
-				newProp.Highlightable = v.(bool)
-			default:
-				// ignore unknown options
-				// return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] unknown option [%s]", field, k))
-			}
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] _source doesn't support option [%s]", k))
 		}
+	}
+	return source, nil
+}
 
-		if newProp.Highlightable {
-			newProp.Store = true
-		}
+// dynamicTemplates parses the dynamic templates in es style, a list of single key objects
+// [{"strings_as_keyword":{"match_mapping_type":"string","mapping":{"type":"keyword"}}}]
+func dynamicTemplates(analyzers map[string]*analysis.Analyzer, data interface{}) ([]*meta.DynamicTemplate, error) {
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] dynamic_templates should be an array")
+	}
 
-		if newProp.Type != "" {
-			mappings.SetProperty(field, newProp)
+	templates := make([]*meta.DynamicTemplate, 0, len(items))
+	for _, item := range items {
+		item, ok := item.(map[string]interface{})
+		if !ok || len(item) != 1 {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] dynamic_templates item should be an object with a single template")
 		}
-
-		if newProp.Type == "text" {
-			fields, err := convertToField(propFields)
+		for name, v := range item {
+			v, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] should be an object", name))
+			}
+			tpl, err := dynamicTemplate(analyzers, name, v)
 			if err != nil {
 				return nil, err
 			}
+			templates = append(templates, tpl)
+		}
+	}
+	return templates, nil
+}
 
-			for k, v := range fields {
-				newProp.AddField(k, v)
-				mappings.SetProperty(field+"."+k, v)
+func dynamicTemplate(analyzers map[string]*analysis.Analyzer, name string, data map[string]interface{}) (*meta.DynamicTemplate, error) {
+	tpl := &meta.DynamicTemplate{Name: name}
+	for k, v := range data {
+		if k == "mapping" {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] %s should be a string", name, k))
+		}
+		switch k {
+		case "match":
+			tpl.Match = s
+		case "unmatch":
+			tpl.Unmatch = s
+		case "path_match":
+			tpl.PathMatch = s
+		case "path_unmatch":
+			tpl.PathUnmatch = s
+		case "match_mapping_type":
+			switch s {
+			case "string", "long", "double", "boolean", "date", "*":
+				tpl.MatchMappingType = s
+			default:
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] doesn't support match_mapping_type [%s]", name, s))
 			}
-
-			// check analyzer
-			if newProp.Analyzer != "" {
-				if _, err := zincanalysis.QueryAnalyzer(analyzers, newProp.Analyzer); err != nil {
-					return nil, err
-				}
+		case "match_pattern":
+			if s != meta.DynamicTemplateMatchSimple && s != meta.DynamicTemplateMatchRegex {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] doesn't support match_pattern [%s]", name, s))
 			}
-			if newProp.SearchAnalyzer != "" {
-				if _, err := zincanalysis.QueryAnalyzer(analyzers, newProp.SearchAnalyzer); err != nil {
-					return nil, err
-				}
+			tpl.MatchPattern = s
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] unknown option [%s]", name, k))
+		}
+	}
+	if tpl.MatchPattern == meta.DynamicTemplateMatchRegex {
+		for _, pattern := range []string{tpl.Match, tpl.Unmatch, tpl.PathMatch, tpl.PathUnmatch} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] pattern [%s] is invalid", name, pattern)).Cause(err)
 			}
 		}
 	}
 
-	return mappings, nil
+	mapping, ok := data["mapping"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] mapping should be an object", name))
+	}
+	prop, err := newProperty(name, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if prop.Type == "" || prop.Type == "nested" {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] dynamic_templates [%s] doesn't support type [%v]", name, mapping["type"]))
+	}
+	if v, ok := mapping["fields"].(map[string]interface{}); ok {
+		fields, err := newFields(analyzers, name, v)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fields {
+			prop.AddField(k, v)
+		}
+	}
+	if prop.Type == "text" {
+		if err := checkAnalyzers(analyzers, prop); err != nil {
+			return nil, err
+		}
+	}
+	tpl.Mapping = prop
+	return tpl, nil
 }
 
-// convertToField converst v to type map[string]meta.Property.
-func convertToField(v map[string]interface{}) (map[string]meta.Property, error) {
-	r := make(map[string]meta.Property)
+// newProperty converts the mapping of the field to a property, the type is empty for the ignored types
+func newProperty(field string, prop map[string]interface{}) (meta.Property, error) {
+	var newProp meta.Property
+	propType, ok := prop["type"]
+	if !ok {
+		return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] should be exists", "type"))
+	}
 
-	if len(v) == 0 || v == nil {
-		return r, nil
+	propTypeStr, ok := propType.(string)
+	if !ok {
+		return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] should be an string", "type"))
 	}
 
-	// it seems inefficient to encode and then directly decode it.
-	// But in favor of code maintainability and to prevent that we need to maintain
-	// duplicated code its more "efficient" to parse the fields this way.
+	propTypeStr = strings.ToLower(propTypeStr)
+	switch propTypeStr {
+	case "text":
+		newProp = meta.NewProperty(propTypeStr)
+
+		if config.Global.EnableTextKeywordMapping {
+			p := meta.NewProperty("keyword")
+			newProp.AddField("keyword", p)
+		}
+	case "keyword", "numeric", "bool", "date", "completion", "geo_point":
+		newProp = meta.NewProperty(propTypeStr)
+	case "constant_keyword":
+		newProp = meta.NewProperty("keyword")
+	case "match_only_text":
+		newProp = meta.NewProperty("text")
+	case "integer", "double", "long", "short", "int", "float":
+		newProp = meta.NewProperty("numeric")
+	case "boolean":
+		newProp = meta.NewProperty("bool")
+	case "time", "datetime":
+		newProp = meta.NewProperty("date")
+	case "nested":
+		newProp = meta.NewProperty(propTypeStr)
+	case "flattened", "object", "wildcard", "byte", "alias", "ip", "ip_range", "scaled_float":
+		// ignore
+	default:
+		return newProp, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[mappings] properties [%s] doesn't support type [%s]", field, propTypeStr))
+	}
+
+	for k, v := range prop {
+		switch k {
+		case "type":
+			// handled
+		case "analyzer":
+			newProp.Analyzer = v.(string)
+		case "search_analyzer":
+			newProp.SearchAnalyzer = v.(string)
+		case "format":
+			newProp.Format = v.(string)
+		case "time_zone":
+			newProp.TimeZone = v.(string)
+			_, err := zutils.ParseTimeZone(newProp.TimeZone)
+			if err != nil {
+				return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] %s time_zone parse err %s", field, err.Error()))
+			}
+		case "index":
+			newProp.Index = v.(bool)
+		case "store":
+			newProp.Store = v.(bool)
+		case "sortable":
+			newProp.Sortable = v.(bool)
+		case "aggregatable":
+			newProp.Aggregatable = v.(bool)
+		case "highlightable":
+			newProp.Highlightable = v.(bool)
+		case "ignore_above":
+			n, err := zutils.ToInt(v)
+			if err != nil || n < 0 {
+				return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] ignore_above should be a non negative integer", field))
+			}
+			newProp.IgnoreAbove = n
+		case "copy_to":
+			switch v := v.(type) {
+			case string:
+				newProp.CopyTo = []string{v}
+			case []interface{}:
+				for _, target := range v {
+					target, ok := target.(string)
+					if !ok {
+						return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] copy_to should be a string or an array of string", field))
+					}
+					newProp.CopyTo = append(newProp.CopyTo, target)
+				}
+			default:
+				return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] copy_to should be a string or an array of string", field))
+			}
+		case "null_value":
+			newProp.NullValue = v
+		default:
+			// ignore unknown options
+			// return newProp, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] unknown option [%s]", field, k))
+		}
+	}
 
-	raw, err := json.Marshal(v)
+	if newProp.Highlightable {
+		newProp.Store = true
+	}
+
+	if err := checkNullValue(field, newProp); err != nil {
+		return newProp, err
+	}
+
+	return newProp, nil
+}
+
+// checkNullValue checks the null_value of the property can be indexed as the type of the property
+func checkNullValue(field string, prop meta.Property) error {
+	if prop.NullValue == nil || prop.Type == "" {
+		return nil
+	}
+	var err error
+	switch prop.NullValue.(type) {
+	case map[string]interface{}, []interface{}:
+		err = fmt.Errorf("null_value should be a scalar")
+	default:
+		switch prop.Type {
+		case "text", "keyword":
+			_, err = zutils.ToString(prop.NullValue)
+		case "numeric":
+			_, err = zutils.ToFloat64(prop.NullValue)
+		case "bool":
+			_, err = zutils.ToBool(prop.NullValue)
+		case "date":
+			_, err = zutils.ParseTime(prop.NullValue, prop.Format, prop.TimeZone)
+		default:
+			return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] doesn't support null_value for type [%s]", field, prop.Type))
+		}
+	}
 	if err != nil {
-		return nil, err
+		return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s] null_value [%v] is not a valid [%s] value", field, prop.NullValue, prop.Type))
 	}
+	return nil
+}
 
-	if err := json.Unmarshal(raw, &r); err != nil {
-		return nil, err
+// newFields converts the multi-fields of the field to properties, the multi-fields can't have their own fields
+func newFields(analyzers map[string]*analysis.Analyzer, field string, data map[string]interface{}) (map[string]meta.Property, error) {
+	fields := make(map[string]meta.Property, len(data))
+	for k, v := range data {
+		v, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s.%s] should be an object", field, k))
+		}
+		prop, err := newProperty(field+"."+k, v)
+		if err != nil {
+			return nil, err
+		}
+		switch prop.Type {
+		case "":
+			continue
+		case "nested":
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] properties [%s.%s] doesn't support type [nested] in fields", field, k))
+		case "text":
+			if err := checkAnalyzers(analyzers, prop); err != nil {
+				return nil, err
+			}
+		}
+		fields[k] = prop
 	}
+	return fields, nil
+}
 
-	return r, nil
+// checkAnalyzers checks the analyzers of the text property exist
+func checkAnalyzers(analyzers map[string]*analysis.Analyzer, prop meta.Property) error {
+	if prop.Analyzer != "" {
+		if _, err := zincanalysis.QueryAnalyzer(analyzers, prop.Analyzer); err != nil {
+			return err
+		}
+	}
+	if prop.SearchAnalyzer != "" {
+		if _, err := zincanalysis.QueryAnalyzer(analyzers, prop.SearchAnalyzer); err != nil {
+			return err
+		}
+	}
+	return nil
 }
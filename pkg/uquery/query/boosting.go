@@ -16,11 +16,92 @@
 package query
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
 
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
-func BoostingQuery(query map[string]interface{}) (bluge.Query, error) {
-	return nil, errors.New(errors.ErrorTypeNotImplemented, "[boosting] query doesn't support")
+func BoostingQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	var positive, negative bluge.Query
+	var negativeBoost, boost interface{}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "positive":
+			if positive, err = boostingClause(k, v, mappings, analyzers); err != nil {
+				return nil, err
+			}
+		case "negative":
+			if negative, err = boostingClause(k, v, mappings, analyzers); err != nil {
+				return nil, err
+			}
+		case "negative_boost":
+			negativeBoost = v
+		case "boost":
+			boost = v
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[boosting] unknown field [%s]", k))
+		}
+	}
+	if positive == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[boosting] requires 'positive' query")
+	}
+	if negative == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[boosting] requires 'negative' query")
+	}
+	if negativeBoost == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[boosting] requires 'negative_boost'")
+	}
+	nb, err := zutils.ToFloat64(negativeBoost)
+	if err != nil || nb < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[boosting] negative_boost should be a non-negative number")
+	}
+
+	boostingQuery := zincquery.NewBoostingQuery(positive, negative, nb)
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[boosting] boost should be a number").Cause(err)
+		}
+		boostingQuery.SetBoost(v)
+	}
+
+	return boostingQuery, nil
+}
+
+// boostingClause parses the positive or negative clause, multiple positive queries must all match,
+// any of multiple negative queries demotes the document
+func boostingClause(name string, v interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		q, err := Query(v, mappings, analyzers)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[boosting] failed to parse field [%s]", name)).Cause(err)
+		}
+		return q, nil
+	case []interface{}:
+		boolQuery := bluge.NewBooleanQuery()
+		for _, vv := range v {
+			q, err := Query(vv, mappings, analyzers)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[boosting] failed to parse field [%s]", name)).Cause(err)
+			}
+			if name == "positive" {
+				boolQuery.AddMust(q)
+			} else {
+				boolQuery.AddShould(q)
+			}
+		}
+		return boolQuery, nil
+	default:
+		return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[boosting] %s doesn't support values of type: %T", name, v))
+	}
 }
@@ -0,0 +1,66 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func ConstantScoreQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	var filter bluge.Query
+	var boost interface{}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "filter":
+			vv, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[constant_score] filter doesn't support values of type: %T", v))
+			}
+			if filter, err = Query(vv, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[constant_score] failed to parse field [filter]").Cause(err)
+			}
+		case "boost":
+			boost = v
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[constant_score] unknown field [%s]", k))
+		}
+	}
+	if filter == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[constant_score] requires a 'filter' element")
+	}
+
+	constantScoreQuery := zincquery.NewConstantScoreQuery(filter)
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[constant_score] boost should be a number").Cause(err)
+		}
+		constantScoreQuery.SetBoost(v)
+	}
+
+	return constantScoreQuery, nil
+}
@@ -0,0 +1,76 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func DisMaxQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	disMaxQuery := zincquery.NewDisMaxQuery()
+	hasQueries := false
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "queries":
+			var queries []interface{}
+			switch v := v.(type) {
+			case map[string]interface{}:
+				queries = []interface{}{v}
+			case []interface{}:
+				queries = v
+			default:
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[dis_max] queries doesn't support values of type: %T", v))
+			}
+			for _, q := range queries {
+				subq, err := Query(q, mappings, analyzers)
+				if err != nil {
+					return nil, errors.New(errors.ErrorTypeXContentParseException, "[dis_max] failed to parse field [queries]").Cause(err)
+				}
+				disMaxQuery.AddQuery(subq)
+			}
+			hasQueries = len(queries) > 0
+		case "tie_breaker":
+			v, err := zutils.ToFloat64(v)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[dis_max] tie_breaker should be a number").Cause(err)
+			}
+			disMaxQuery.SetTieBreaker(v)
+		case "boost":
+			v, err := zutils.ToFloat64(v)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[dis_max] boost should be a number").Cause(err)
+			}
+			disMaxQuery.SetBoost(v)
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[dis_max] unknown field [%s]", k))
+		}
+	}
+	if !hasQueries {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[dis_max] requires 'queries' field with at least one clause")
+	}
+
+	return disMaxQuery, nil
+}
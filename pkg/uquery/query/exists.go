@@ -19,8 +19,24 @@ import (
 	"github.com/blugelabs/bluge"
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 )
 
-func ExistsQuery(query map[string]interface{}) (bluge.Query, error) {
-	return nil, errors.New(errors.ErrorTypeNotImplemented, "[exists] query doesn't support")
+// ExistsQuery matches the documents having any indexed value for the mapped or runtime field
+func ExistsQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Query, error) {
+	field, _ := query["field"].(string)
+	if field == "" {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[exists] must be provided with a [field]")
+	}
+	if f, ok, err := runtime.Compile(field, mappings); ok {
+		if err != nil {
+			return nil, err
+		}
+		return RuntimeExistsQuery(f), nil
+	}
+	if _, ok := mappings.GetProperty(field); ok {
+		return bluge.NewWildcardQuery("*").SetField(field), nil
+	}
+	return bluge.NewMatchNoneQuery(), nil
 }
@@ -0,0 +1,378 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/numeric/geo"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func FunctionScoreQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	var subq bluge.Query
+	var err error
+	if v, ok := query["query"]; ok {
+		vv, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] query doesn't support values of type: %T", v))
+		}
+		if subq, err = Query(vv, mappings, analyzers); err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] failed to parse field [query]").Cause(err)
+		}
+	} else {
+		subq = bluge.NewMatchAllQuery()
+	}
+
+	fsQuery := zincquery.NewFunctionScoreQuery(subq)
+	shorthand := make(map[string]interface{})
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "query":
+			// parsed above
+		case "functions":
+			vv, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] functions doesn't support values of type: %T", v))
+			}
+			for _, f := range vv {
+				ff, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] function doesn't support values of type: %T", f))
+				}
+				fn, err := ScoreFunction(ff, mappings, analyzers)
+				if err != nil {
+					return nil, err
+				}
+				fsQuery.AddFunction(fn)
+			}
+		case "score_mode":
+			mode, _ := zutils.ToString(v)
+			switch mode {
+			case zincquery.ScoreModeMultiply, zincquery.ScoreModeSum, zincquery.ScoreModeAvg,
+				zincquery.ScoreModeFirst, zincquery.ScoreModeMax, zincquery.ScoreModeMin:
+				fsQuery.SetScoreMode(mode)
+			default:
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[function_score] illegal score_mode [%s]", mode))
+			}
+		case "boost_mode":
+			mode, _ := zutils.ToString(v)
+			switch mode {
+			case zincquery.BoostModeMultiply, zincquery.BoostModeReplace, zincquery.BoostModeSum,
+				zincquery.BoostModeAvg, zincquery.BoostModeMax, zincquery.BoostModeMin:
+				fsQuery.SetBoostMode(mode)
+			default:
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[function_score] illegal boost_mode [%s]", mode))
+			}
+		case "max_boost":
+			maxBoost, err := zutils.ToFloat64(v)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] max_boost should be a number").Cause(err)
+			}
+			fsQuery.SetMaxBoost(maxBoost)
+		case "min_score":
+			minScore, err := zutils.ToFloat64(v)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] min_score should be a number").Cause(err)
+			}
+			fsQuery.SetMinScore(minScore)
+		case "boost":
+			boost, err := zutils.ToFloat64(v)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] boost should be a number").Cause(err)
+			}
+			fsQuery.SetBoost(boost)
+		case "filter", "weight", "field_value_factor", "gauss", "exp", "linear", "script_score":
+			shorthand[k] = v
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] unknown field [%s]", k))
+		}
+	}
+
+	if len(shorthand) > 0 {
+		if _, ok := query["functions"]; ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, "[function_score] already found [functions] array, now encountering a single function")
+		}
+		fn, err := ScoreFunction(shorthand, mappings, analyzers)
+		if err != nil {
+			return nil, err
+		}
+		fsQuery.AddFunction(fn)
+	}
+
+	return fsQuery, nil
+}
+
+// ScoreFunction parses a function of the function_score query
+func ScoreFunction(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (*zincquery.Function, error) {
+	fn := &zincquery.Function{Weight: 1}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "filter":
+			vv, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] filter doesn't support values of type: %T", v))
+			}
+			if fn.Filter, err = Query(vv, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] failed to parse field [filter]").Cause(err)
+			}
+		case "weight":
+			if fn.Weight, err = zutils.ToFloat64(v); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] weight should be a number").Cause(err)
+			}
+		case "field_value_factor", "gauss", "exp", "linear", "script_score":
+			if fn.Score != nil {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[function_score] failed to parse function [%s], only one score function is allowed", k))
+			}
+			vv, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] %s doesn't support values of type: %T", k, v))
+			}
+			switch k {
+			case "field_value_factor":
+				fn.Score, err = FieldValueFactorFunction(vv, mappings)
+			case "script_score":
+				fn.Score, err = ScriptScoreFunction(vv, mappings)
+			default:
+				fn.Score, err = DecayFunction(k, vv, mappings)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[function_score] unknown function [%s]", k))
+		}
+	}
+	return fn, nil
+}
+
+func FieldValueFactorFunction(query map[string]interface{}, mappings *meta.Mappings) (zincquery.ScoreFunction, error) {
+	fn := &zincquery.FieldValueFactorFunction{Factor: 1}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "field":
+			fn.Field, _ = zutils.ToString(v)
+		case "factor":
+			if fn.Factor, err = zutils.ToFloat64(v); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[field_value_factor] factor should be a number").Cause(err)
+			}
+		case "modifier":
+			fn.Modifier, _ = zutils.ToString(v)
+			switch fn.Modifier {
+			case "none", "log", "log1p", "log2p", "ln", "ln1p", "ln2p", "square", "sqrt", "reciprocal":
+			default:
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[field_value_factor] illegal modifier [%s]", fn.Modifier))
+			}
+		case "missing":
+			if fn.Missing, err = zutils.ToFloat64(v); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[field_value_factor] missing should be a number").Cause(err)
+			}
+			fn.HasMissing = true
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[field_value_factor] unknown field [%s]", k))
+		}
+	}
+	if fn.Field == "" {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[field_value_factor] field is required")
+	}
+	if prop, ok := mappings.GetProperty(fn.Field); ok && prop.Type != "numeric" {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[field_value_factor] field [%s] is of type [%s], but only numeric types are supported", fn.Field, prop.Type))
+	}
+	return fn, nil
+}
+
+func DecayFunction(curve string, query map[string]interface{}, mappings *meta.Mappings) (zincquery.ScoreFunction, error) {
+	fn := &zincquery.DecayFunction{Curve: curve, MultiValueMode: "min"}
+	var params map[string]interface{}
+	for k, v := range query {
+		if strings.ToLower(k) == "multi_value_mode" {
+			fn.MultiValueMode, _ = zutils.ToString(v)
+			switch fn.MultiValueMode {
+			case "min", "max", "avg", "sum":
+			default:
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s] illegal multi_value_mode [%s]", curve, fn.MultiValueMode))
+			}
+			continue
+		}
+		if fn.Field != "" {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] query doesn't support multiple fields", curve))
+		}
+		vv, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] %s doesn't support values of type: %T", curve, k, v))
+		}
+		fn.Field = k
+		params = vv
+	}
+	if fn.Field == "" {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] field is required", curve))
+	}
+
+	prop, ok := mappings.GetProperty(fn.Field)
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s] unknown field [%s]", curve, fn.Field))
+	}
+	switch prop.Type {
+	case "numeric":
+		fn.Type = zincquery.FieldTypeNumeric
+	case "date", "time":
+		fn.Type = zincquery.FieldTypeDate
+	case "geo_point":
+		fn.Type = zincquery.FieldTypeGeoPoint
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException,
+			fmt.Sprintf("[%s] field [%s] is of type [%s], but only numeric, date and geo_point types are supported", curve, fn.Field, prop.Type))
+	}
+
+	fn.Decay = 0.5
+	var origin, scale, offset interface{}
+	for k, v := range params {
+		k := strings.ToLower(k)
+		switch k {
+		case "origin":
+			origin = v
+		case "scale":
+			scale = v
+		case "offset":
+			offset = v
+		case "decay":
+			decay, err := zutils.ToFloat64(v)
+			if err != nil || decay <= 0 || decay >= 1 {
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s] decay must be in the range (0..1), got [%v]", curve, v))
+			}
+			fn.Decay = decay
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] unknown field [%s]", curve, k))
+		}
+	}
+	if scale == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] scale is required", curve))
+	}
+
+	var err error
+	switch fn.Type {
+	case zincquery.FieldTypeNumeric:
+		if origin == nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] origin is required for numeric field [%s]", curve, fn.Field))
+		}
+		if fn.Origin, err = zutils.ToFloat64(origin); err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] origin should be a number", curve)).Cause(err)
+		}
+		if fn.Scale, err = zutils.ToFloat64(scale); err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] scale should be a number", curve)).Cause(err)
+		}
+		if offset != nil {
+			if fn.Offset, err = zutils.ToFloat64(offset); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] offset should be a number", curve)).Cause(err)
+			}
+		}
+	case zincquery.FieldTypeDate:
+		t := time.Now()
+		if s, ok := origin.(string); origin != nil && (!ok || s != "now") {
+			if t, err = zutils.ParseTime(origin, prop.Format, prop.TimeZone); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] origin parse err %s", curve, err.Error()))
+			}
+		}
+		fn.Origin = float64(t.UnixNano())
+		if fn.Scale, err = decayDuration(scale); err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] scale parse err %s", curve, err.Error()))
+		}
+		if offset != nil {
+			if fn.Offset, err = decayDuration(offset); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] offset parse err %s", curve, err.Error()))
+			}
+		}
+	case zincquery.FieldTypeGeoPoint:
+		var ok bool
+		if fn.OriginLon, fn.OriginLat, ok = geo.ExtractGeoPoint(origin); !ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] origin [%v] is not a geo point", curve, origin))
+		}
+		if fn.Scale, err = decayDistance(scale); err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] scale parse err %s", curve, err.Error()))
+		}
+		if offset != nil {
+			if fn.Offset, err = decayDistance(offset); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[%s] offset parse err %s", curve, err.Error()))
+			}
+		}
+	}
+	if fn.Scale <= 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s] scale must be greater than 0", curve))
+	}
+	if fn.Offset < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[%s] offset must be greater than or equal to 0", curve))
+	}
+
+	return fn, nil
+}
+
+func ScriptScoreFunction(query map[string]interface{}, mappings *meta.Mappings) (zincquery.ScoreFunction, error) {
+	var script *zincquery.Script
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "script":
+			if script, err = Script(v, mappings); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script_score] unknown field [%s]", k))
+		}
+	}
+	if script == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[script_score] script is required")
+	}
+	return script, nil
+}
+
+// decayDuration parses the duration of a date decay function in nanoseconds
+func decayDuration(v interface{}) (float64, error) {
+	s, err := zutils.ToString(v)
+	if err != nil {
+		return 0, err
+	}
+	d, err := zutils.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(d), nil
+}
+
+// decayDistance parses the distance of a geo_point decay function in meters
+func decayDistance(v interface{}) (float64, error) {
+	if f, err := zutils.ToFloat64(v); err == nil {
+		return f, nil
+	}
+	s, err := zutils.ToString(v)
+	if err != nil {
+		return 0, err
+	}
+	return geo.ParseDistance(s)
+}
@@ -23,9 +23,11 @@ import (
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/analysis/analyzer"
 
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 func MatchPhrasePrefixQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
@@ -33,8 +35,10 @@ func MatchPhrasePrefixQuery(query map[string]interface{}, mappings *meta.Mapping
 		return nil, errors.New(errors.ErrorTypeParsingException, "[match_phrase_prefix] query doesn't support multiple fields")
 	}
 
+	var err error
 	field := ""
 	value := new(meta.MatchPhrasePrefixQuery)
+	value.MaxExpansions = 50
 	value.Boost = -1.0
 	for k, v := range query {
 		field = k
@@ -49,18 +53,31 @@ func MatchPhrasePrefixQuery(query map[string]interface{}, mappings *meta.Mapping
 					value.Query = v.(string)
 				case "analyzer":
 					value.Analyzer = v.(string)
+				case "slop":
+					value.Slop, err = zutils.ToInt(v)
+				case "max_expansions":
+					value.MaxExpansions, err = zutils.ToInt(v)
 				case "boost":
 					value.Boost = v.(float64)
 				default:
 					// return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[match_phrase_prefix] unknown field [%s]", k))
 				}
+				if err != nil {
+					return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[match_phrase_prefix] [%s] has an invalid value", k)).Cause(err)
+				}
 			}
 		default:
 			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[match_phrase_prefix] %s doesn't support values of type: %T", k, v))
 		}
 	}
 
-	var err error
+	if value.Slop < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[match_phrase_prefix] slop should be a non-negative number")
+	}
+	if value.MaxExpansions <= 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[match_phrase_prefix] max_expansions should be a positive number")
+	}
+
 	var zer *analysis.Analyzer
 	if value.Analyzer != "" {
 		zer, err = zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
@@ -80,21 +97,20 @@ func MatchPhrasePrefixQuery(query map[string]interface{}, mappings *meta.Mapping
 		zer = analyzer.NewStandardAnalyzer()
 	}
 
+	// all but the last token are the phrase, the last one is the prefix
 	tokens := zer.Analyze([]byte(value.Query))
-	subq := bluge.NewBooleanQuery()
-	if len(tokens) > 0 {
-		subq.AddMust(bluge.NewPrefixQuery(string(tokens[len(tokens)-1].Term)).SetField(field))
+	if len(tokens) == 0 {
+		return bluge.NewMatchNoneQuery(), nil
 	}
-	if len(tokens) > 1 {
-		phrase := strings.Builder{}
-		for i := 0; i < len(tokens)-1; i++ {
-			phrase.WriteString(string(tokens[i].Term))
-			phrase.WriteString(" ")
-		}
-		subq.AddMust(bluge.NewMatchPhraseQuery(strings.TrimSpace(phrase.String())).SetField(field).SetAnalyzer(zer))
+	terms := make([]string, 0, len(tokens)-1)
+	for _, token := range tokens[:len(tokens)-1] {
+		terms = append(terms, string(token.Term))
 	}
+	subq := zincquery.NewPhrasePrefixQuery(field, terms, string(tokens[len(tokens)-1].Term)).
+		SetSlop(value.Slop).
+		SetMaxExpansions(value.MaxExpansions)
 	if value.Boost >= 0 {
-		subq.SetBoost(value.Boost)
+		return boostQuery(subq, value.Boost), nil
 	}
 
 	return subq, nil
@@ -21,7 +21,9 @@ import (
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/analyzer"
 
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
@@ -33,75 +35,290 @@ func MultiMatchQuery(query map[string]interface{}, mappings *meta.Mappings, anal
 	value.Boost = -1.0
 	for k, v := range query {
 		k := strings.ToLower(k)
+		var err error
 		switch k {
 		case "query":
-			value.Query = v.(string)
+			value.Query, err = zutils.ToString(v)
 		case "analyzer":
-			value.Analyzer = v.(string)
+			value.Analyzer, err = zutils.ToString(v)
 		case "fields":
-			if vv, ok := v.([]interface{}); ok {
-				for _, vvv := range vv {
-					value.Fields = append(value.Fields, vvv.(string))
+			switch v := v.(type) {
+			case string:
+				value.Fields = append(value.Fields, v)
+			case []interface{}:
+				for _, vv := range v {
+					field, err := zutils.ToString(vv)
+					if err != nil {
+						return nil, errors.New(errors.ErrorTypeParsingException, "[multi_match] [fields] should be an array of strings").Cause(err)
+					}
+					value.Fields = append(value.Fields, field)
 				}
+			default:
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[multi_match] [fields] should be an array of strings, got %T", v))
 			}
 		case "boost":
-			value.Boost = v.(float64)
+			value.Boost, err = zutils.ToFloat64(v)
 		case "type":
-			value.Type = v.(string)
+			value.Type, err = zutils.ToString(v)
 		case "operator":
-			value.Operator = v.(string)
+			value.Operator, err = zutils.ToString(v)
 		case "minimum_should_match":
 			value.MinimumShouldMatch = v
+		case "tie_breaker":
+			value.TieBreaker, err = zutils.ToFloat64(v)
+		case "fuzziness":
+			value.Fuzziness = v
+		case "prefix_length":
+			value.PrefixLength, err = zutils.ToFloat64(v)
+		case "slop":
+			value.Slop, err = zutils.ToInt(v)
+		case "max_expansions":
+			value.MaxExpansions, err = zutils.ToInt(v)
+		case "lenient":
+			value.Lenient, err = zutils.ToBool(v)
 		default:
 			// return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[multi_match] unknown field [%s]", k))
 		}
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[multi_match] [%s] has an invalid value", k)).Cause(err)
+		}
 	}
 
-	var zer *analysis.Analyzer
-	if value.Analyzer != "" {
-		zer, _ = zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
+	switch strings.ToUpper(value.Operator) {
+	case "", "OR", "AND":
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[multi_match] unknown operator %s", strings.ToUpper(value.Operator)))
+	}
+
+	if len(value.Fields) == 0 {
+		value.Fields = []string{"*"}
+	}
+	fields, err := parseFields("multi_match", value.Fields, mappings)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return bluge.NewMatchNoneQuery(), nil
 	}
 
-	var operator bluge.MatchQueryOperator = bluge.MatchQueryOperatorOr
-	if value.Operator != "" {
-		op := strings.ToUpper(value.Operator)
-		switch op {
-		case "OR":
-			operator = bluge.MatchQueryOperatorOr
-		case "AND":
-			operator = bluge.MatchQueryOperatorAnd
+	var q bluge.Query
+	switch strings.ToLower(value.Type) {
+	case "", "best_fields":
+		q, err = multiMatchFieldsQuery(value, fields, mappings, analyzers, "match", true)
+	case "most_fields":
+		q, err = multiMatchFieldsQuery(value, fields, mappings, analyzers, "match", false)
+	case "phrase":
+		q, err = multiMatchFieldsQuery(value, fields, mappings, analyzers, "match_phrase", true)
+	case "phrase_prefix":
+		q, err = multiMatchFieldsQuery(value, fields, mappings, analyzers, "match_phrase_prefix", true)
+	case "bool_prefix":
+		q, err = multiMatchFieldsQuery(value, fields, mappings, analyzers, "match_bool_prefix", false)
+	case "cross_fields":
+		q, err = multiMatchCrossFieldsQuery(value, fields, mappings, analyzers)
+	default:
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[multi_match] query does not support type %s", value.Type))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value.Boost >= 0 {
+		q = boostQuery(q, value.Boost)
+	}
+	return q, nil
+}
+
+// multiMatchFieldsQuery runs the query on every field, the scores of the fields are combined
+// with dis_max for best_fields and phrase types, or summed for most_fields
+func multiMatchFieldsQuery(
+	value *meta.MultiMatchQuery,
+	fields []boostedField,
+	mappings *meta.Mappings,
+	analyzers map[string]*analysis.Analyzer,
+	matchType string,
+	disMax bool,
+) (bluge.Query, error) {
+	queries := make([]bluge.Query, 0, len(fields))
+	for _, field := range fields {
+		var q bluge.Query
+		var err error
+		switch matchType {
+		case "match_phrase":
+			q, err = multiMatchPhraseQuery(value, field, mappings, analyzers)
 		default:
-			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[multi_match] unknown operator %s", op))
+			params := map[string]interface{}{"query": value.Query, "boost": field.boost}
+			if value.Analyzer != "" {
+				params["analyzer"] = value.Analyzer
+			}
+			if matchType == "match_phrase_prefix" {
+				if value.Slop > 0 {
+					params["slop"] = value.Slop
+				}
+				if value.MaxExpansions > 0 {
+					params["max_expansions"] = value.MaxExpansions
+				}
+			}
+			if matchType == "match" {
+				if value.Operator != "" {
+					params["operator"] = value.Operator
+				}
+				if value.MinimumShouldMatch != nil {
+					params["minimum_should_match"] = value.MinimumShouldMatch
+				}
+				if value.Fuzziness != nil {
+					params["fuzziness"] = value.Fuzziness
+				}
+				if value.PrefixLength > 0 {
+					params["prefix_length"] = value.PrefixLength
+				}
+			}
+			q, err = Query(map[string]interface{}{matchType: map[string]interface{}{field.name: params}}, mappings, analyzers)
 		}
+		if err != nil {
+			if value.Lenient {
+				continue
+			}
+			return nil, err
+		}
+		queries = append(queries, q)
 	}
 
-	subq := bluge.NewBooleanQuery()
-	if value.MinimumShouldMatch != nil {
-		minValue, err := zutils.CalculateMin(len(value.Fields), value.MinimumShouldMatch)
-		if err != nil {
-			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[multi_match] unsupported MinimumShouldMatch value: %v", err))
+	switch {
+	case len(queries) == 0:
+		return bluge.NewMatchNoneQuery(), nil
+	case len(queries) == 1:
+		return queries[0], nil
+	case disMax:
+		return zincquery.NewDisMaxQuery(queries...).SetTieBreaker(value.TieBreaker), nil
+	}
+	boolQuery := bluge.NewBooleanQuery().SetMinShould(1)
+	for _, q := range queries {
+		boolQuery.AddShould(q)
+	}
+	return boolQuery, nil
+}
+
+func multiMatchPhraseQuery(value *meta.MultiMatchQuery, field boostedField, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	zer, err := multiMatchAnalyzer(value, field.name, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	if zer == nil {
+		return bluge.NewTermQuery(value.Query).SetField(field.name).SetBoost(field.boost), nil
+	}
+	q := bluge.NewMatchPhraseQuery(value.Query).SetField(field.name).SetAnalyzer(zer).SetBoost(field.boost)
+	if value.Slop > 0 {
+		q.SetSlop(value.Slop)
+	}
+	return q, nil
+}
+
+// multiMatchCrossFieldsQuery analyzes the query once and searches every term in all the fields
+// as if they were one big field, the fields using different analyzers are combined with dis_max
+func multiMatchCrossFieldsQuery(value *meta.MultiMatchQuery, fields []boostedField, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	type fieldGroup struct {
+		name     string // name of the analyzer
+		analyzer *analysis.Analyzer
+		fields   []boostedField
+	}
+	groups := make([]*fieldGroup, 0, 1)
+	for _, field := range fields {
+		prop, _ := mappings.GetProperty(field.name)
+		if prop.Type != "" && prop.Type != "text" && prop.Type != "keyword" {
+			if value.Lenient {
+				continue
+			}
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[multi_match] cross_fields doesn't support field [%s] of type [%s]", field.name, prop.Type))
 		}
-		subq.SetMinShould(minValue) // lgtm[go/hardcoded-credentials]
+		name := value.Analyzer
+		switch {
+		case name != "":
+		case prop.Type == "keyword":
+			name = "keyword"
+		case prop.SearchAnalyzer != "":
+			name = prop.SearchAnalyzer
+		default:
+			name = prop.Analyzer
+		}
+		var group *fieldGroup
+		for _, g := range groups {
+			if g.name == name {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			zer, err := multiMatchAnalyzer(value, field.name, mappings, analyzers)
+			if err != nil {
+				return nil, err
+			}
+			group = &fieldGroup{name: name, analyzer: zer}
+			groups = append(groups, group)
+		}
+		group.fields = append(group.fields, field)
 	}
-	if value.Boost >= 0 {
-		subq.SetBoost(value.Boost)
-	}
-	for _, field := range value.Fields {
-		subqq := bluge.NewMatchQuery(value.Query).SetField(field).SetOperator(operator)
-		if zer != nil {
-			subqq.SetAnalyzer(zer)
-		} else {
-			indexZer, searchZer := zincanalysis.QueryAnalyzerForField(analyzers, mappings, field)
-			if zer == nil && searchZer != nil {
-				zer = searchZer
+
+	operatorAnd := strings.ToUpper(value.Operator) == "AND"
+	queries := make([]bluge.Query, 0, len(groups))
+	for _, group := range groups {
+		terms := []string{value.Query}
+		if group.analyzer != nil {
+			terms = terms[:0]
+			for _, token := range group.analyzer.Analyze([]byte(value.Query)) {
+				terms = append(terms, string(token.Term))
 			}
-			if zer == nil && indexZer != nil {
-				zer = indexZer
+		}
+		if len(terms) == 0 {
+			continue
+		}
+
+		boolQuery := bluge.NewBooleanQuery()
+		for _, term := range terms {
+			termQuery := zincquery.NewDisMaxQuery().SetTieBreaker(value.TieBreaker)
+			for _, field := range group.fields {
+				termQuery.AddQuery(bluge.NewTermQuery(term).SetField(field.name).SetBoost(field.boost))
+			}
+			if operatorAnd {
+				boolQuery.AddMust(termQuery)
+			} else {
+				boolQuery.AddShould(termQuery)
 			}
-			subqq.SetAnalyzer(zer)
 		}
-		subq.AddShould(subqq)
+		if !operatorAnd {
+			minShould := 1
+			if value.MinimumShouldMatch != nil {
+				var err error
+				if minShould, err = zutils.CalculateMin(len(terms), value.MinimumShouldMatch); err != nil {
+					return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[multi_match] unsupported MinimumShouldMatch value: %v", err))
+				}
+			}
+			boolQuery.SetMinShould(minShould)
+		}
+		queries = append(queries, boolQuery)
 	}
 
-	return subq, nil
+	switch len(queries) {
+	case 0:
+		return bluge.NewMatchNoneQuery(), nil
+	case 1:
+		return queries[0], nil
+	}
+	return zincquery.NewDisMaxQuery(queries...).SetTieBreaker(value.TieBreaker), nil
+}
+
+// multiMatchAnalyzer returns the analyzer of the field, nil for the keyword fields
+func multiMatchAnalyzer(value *meta.MultiMatchQuery, field string, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (*analysis.Analyzer, error) {
+	if value.Analyzer != "" {
+		return zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
+	}
+	if prop, ok := mappings.GetProperty(field); ok && prop.Type == "keyword" {
+		return nil, nil
+	}
+	indexZer, searchZer := zincanalysis.QueryAnalyzerForField(analyzers, mappings, field)
+	if searchZer != nil {
+		return searchZer, nil
+	}
+	if indexZer != nil {
+		return indexZer, nil
+	}
+	return analyzer.NewStandardAnalyzer(), nil
 }
@@ -0,0 +1,219 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// NestedInnerHits is a nested query which requests the matching nested objects of the hits
+type NestedInnerHits struct {
+	Name  string
+	From  int
+	Size  int
+	Query *zincquery.NestedQuery
+}
+
+func NestedQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	nestedQuery, _, err := parseNestedQuery(query, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	if nestedQuery == nil {
+		return bluge.NewMatchNoneQuery(), nil
+	}
+	return nestedQuery, nil
+}
+
+// NestedInnerHitsQueries returns all the nested queries with inner_hits in the query
+func NestedInnerHitsQueries(query interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) ([]*NestedInnerHits, error) {
+	if query == nil {
+		return nil, nil
+	}
+	if q, ok := query.(*meta.Query); ok {
+		data, err := json.Marshal(q)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeInvalidArgument, "query must be a map[string]interface{}")
+		}
+		var newQuery map[string]interface{}
+		if err = json.Unmarshal(data, &newQuery); err != nil {
+			return nil, errors.New(errors.ErrorTypeInvalidArgument, "query must be a map[string]interface{}")
+		}
+		query = newQuery
+	}
+
+	innerHits := make([]*NestedInnerHits, 0)
+	var walk func(v interface{}) error
+	walk = func(v interface{}) error {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, vv := range v {
+				if nested, ok := vv.(map[string]interface{}); ok && strings.ToLower(k) == "nested" && nested["inner_hits"] != nil {
+					nestedQuery, opts, err := parseNestedQuery(nested, mappings, analyzers)
+					if err != nil {
+						return err
+					}
+					if nestedQuery != nil {
+						innerHits = append(innerHits, &NestedInnerHits{Name: opts.Name, From: opts.From, Size: opts.Size, Query: nestedQuery})
+					}
+				}
+				if err := walk(vv); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, vv := range v {
+				if err := walk(vv); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(query); err != nil {
+		return nil, err
+	}
+	return innerHits, nil
+}
+
+// parseNestedQuery parses the nested query, returns nil query if the path is not mapped and ignore_unmapped is set
+func parseNestedQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (*zincquery.NestedQuery, *meta.NestedInnerHits, error) {
+	var path, scoreMode string
+	var subq, boost, innerHits interface{}
+	var ignoreUnmapped bool
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "path":
+			vv, ok := v.(string)
+			if !ok {
+				return nil, nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[nested] path doesn't support values of type: %T", v))
+			}
+			path = vv
+		case "query":
+			subq = v
+		case "score_mode":
+			vv, ok := v.(string)
+			if !ok {
+				return nil, nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[nested] score_mode doesn't support values of type: %T", v))
+			}
+			scoreMode = strings.ToLower(vv)
+		case "ignore_unmapped":
+			vv, ok := v.(bool)
+			if !ok {
+				return nil, nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[nested] ignore_unmapped doesn't support values of type: %T", v))
+			}
+			ignoreUnmapped = vv
+		case "inner_hits":
+			innerHits = v
+		case "boost":
+			boost = v
+		default:
+			return nil, nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[nested] unknown field [%s]", k))
+		}
+	}
+	if path == "" {
+		return nil, nil, errors.New(errors.ErrorTypeParsingException, "[nested] requires 'path' field")
+	}
+	if subq == nil {
+		return nil, nil, errors.New(errors.ErrorTypeParsingException, "[nested] requires 'query' field")
+	}
+	if prop, ok := mappings.GetProperty(path); !ok || prop.Type != "nested" {
+		if ignoreUnmapped {
+			return nil, nil, nil
+		}
+		if !ok {
+			return nil, nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[nested] failed to find nested object under path [%s]", path))
+		}
+		return nil, nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[nested] nested object under path [%s] is not of nested type", path))
+	}
+
+	childQuery, err := Query(subq, mappings, analyzers)
+	if err != nil {
+		return nil, nil, errors.New(errors.ErrorTypeXContentParseException, "[nested] failed to parse field [query]").Cause(err)
+	}
+	nestedQuery := zincquery.NewNestedQuery(path, childQuery)
+
+	switch scoreMode {
+	case "":
+	case zincquery.NestedScoreModeAvg, zincquery.NestedScoreModeMax, zincquery.NestedScoreModeMin,
+		zincquery.NestedScoreModeSum, zincquery.NestedScoreModeNone:
+		nestedQuery.SetScoreMode(scoreMode)
+	default:
+		return nil, nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[nested] illegal score_mode [%s]", scoreMode))
+	}
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, nil, errors.New(errors.ErrorTypeXContentParseException, "[nested] boost should be a number").Cause(err)
+		}
+		nestedQuery.SetBoost(v)
+	}
+
+	var opts *meta.NestedInnerHits
+	if innerHits != nil {
+		if opts, err = nestedInnerHits(path, innerHits); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return nestedQuery, opts, nil
+}
+
+// nestedInnerHits parses the inner_hits options, the name defaults to the path
+func nestedInnerHits(path string, v interface{}) (*meta.NestedInnerHits, error) {
+	value, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[nested] inner_hits doesn't support values of type: %T", v))
+	}
+	opts := &meta.NestedInnerHits{Name: path, Size: 3}
+	for k, v := range value {
+		k := strings.ToLower(k)
+		switch k {
+		case "name":
+			vv, ok := v.(string)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[inner_hits] name doesn't support values of type: %T", v))
+			}
+			opts.Name = vv
+		case "from":
+			vv, err := zutils.ToInt(v)
+			if err != nil || vv < 0 {
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[inner_hits] from should be a non-negative number")
+			}
+			opts.From = vv
+		case "size":
+			vv, err := zutils.ToInt(v)
+			if err != nil || vv < 0 {
+				return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[inner_hits] size should be a non-negative number")
+			}
+			opts.Size = vv
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[inner_hits] unknown field [%s]", k))
+		}
+	}
+	return opts, nil
+}
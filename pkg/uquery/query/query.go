@@ -22,6 +22,7 @@ import (
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
 
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/zutils/json"
@@ -61,15 +62,35 @@ func Query(query interface{}, mappings *meta.Mappings, analyzers map[string]*ana
 		if !ok {
 			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] query doesn't support value type %T", k, t))
 		}
+		var name string
+		if v, name, err = queryName(k, v); err != nil {
+			return nil, err
+		}
 		switch k {
 		case "bool":
 			if subq, err = BoolQuery(v, mappings, analyzers); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[bool] failed to parse field").Cause(err)
 			}
 		case "boosting":
-			if subq, err = BoostingQuery(v); err != nil {
+			if subq, err = BoostingQuery(v, mappings, analyzers); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[boosting] failed to parse field").Cause(err)
 			}
+		case "nested":
+			if subq, err = NestedQuery(v, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[nested] failed to parse field").Cause(err)
+			}
+		case "constant_score":
+			if subq, err = ConstantScoreQuery(v, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[constant_score] failed to parse field").Cause(err)
+			}
+		case "dis_max":
+			if subq, err = DisMaxQuery(v, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[dis_max] failed to parse field").Cause(err)
+			}
+		case "function_score":
+			if subq, err = FunctionScoreQuery(v, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[function_score] failed to parse field").Cause(err)
+			}
 		case "match":
 			if subq, err = MatchQuery(v, mappings, analyzers); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[match] failed to parse field").Cause(err)
@@ -110,8 +131,12 @@ func Query(query interface{}, mappings *meta.Mappings, analyzers map[string]*ana
 			if subq, err = SimpleQueryStringQuery(v, mappings, analyzers); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[simple_query_string] failed to parse field").Cause(err)
 			}
+		case "script_score":
+			if subq, err = ScriptScoreQuery(v, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[script_score] failed to parse field").Cause(err)
+			}
 		case "exists":
-			if subq, err = ExistsQuery(v); err != nil {
+			if subq, err = ExistsQuery(v, mappings); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[exists] failed to parse field").Cause(err)
 			}
 		case "ids":
@@ -150,6 +175,18 @@ func Query(query interface{}, mappings *meta.Mappings, analyzers map[string]*ana
 			if subq, err = TermsSetQuery(v); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[terms_set] failed to parse field").Cause(err)
 			}
+		case "span_term":
+			if subq, err = SpanTermQuery(v, mappings); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_term] failed to parse field").Cause(err)
+			}
+		case "span_near":
+			if subq, err = SpanNearQuery(v, mappings); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_near] failed to parse field").Cause(err)
+			}
+		case "span_first":
+			if subq, err = SpanFirstQuery(v, mappings); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_first] failed to parse field").Cause(err)
+			}
 		case "geo_bounding_box":
 			if subq, err = GeoBoundingBoxQuery(v); err != nil {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[geo_bounding_box] failed to parse field").Cause(err)
@@ -169,7 +206,52 @@ func Query(query interface{}, mappings *meta.Mappings, analyzers map[string]*ana
 		default:
 			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] query doesn't support", k))
 		}
+		if name != "" {
+			subq = zincquery.NewNamedQuery(name, subq)
+		}
 	}
 
 	return subq, nil
 }
+
+// queryName returns the query without its `_name` and the name,
+// the name is either in the query or in the options of the field
+func queryName(k string, query map[string]interface{}) (map[string]interface{}, string, error) {
+	without := func(m map[string]interface{}) (map[string]interface{}, string, error) {
+		name, ok := m["_name"].(string)
+		if !ok {
+			return nil, "", errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] query malformed, [_name] should be a string", k))
+		}
+		rv := make(map[string]interface{}, len(m)-1)
+		for kk, vv := range m {
+			if kk != "_name" {
+				rv[kk] = vv
+			}
+		}
+		return rv, name, nil
+	}
+
+	if _, ok := query["_name"]; ok {
+		return without(query)
+	}
+	for field, v := range query {
+		options, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := options["_name"]; !ok {
+			continue
+		}
+		options, name, err := without(options)
+		if err != nil {
+			return nil, "", err
+		}
+		rv := make(map[string]interface{}, len(query))
+		for kk, vv := range query {
+			rv[kk] = vv
+		}
+		rv[field] = options
+		return rv, name, nil
+	}
+	return query, "", nil
+}
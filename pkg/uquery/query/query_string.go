@@ -21,80 +21,98 @@ import (
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
-	"github.com/blugelabs/bluge/analysis/analyzer"
-	querystr "github.com/blugelabs/query_string"
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 func QueryStringQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
 	value := new(meta.QueryStringQuery)
+	value.Boost = -1.0
 	for k, v := range query {
 		k := strings.ToLower(k)
+		var err error
 		switch k {
 		case "query":
-			value.Query = v.(string)
+			value.Query, err = zutils.ToString(v)
 		case "analyzer":
-			value.Analyzer = v.(string)
+			value.Analyzer, err = zutils.ToString(v)
 		case "fields":
-			if vv, ok := v.([]interface{}); ok {
-				for _, vvv := range vv {
-					value.Fields = append(value.Fields, vvv.(string))
+			vv, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[query_string] [fields] should be an array of strings, got %T", v))
+			}
+			for _, vvv := range vv {
+				field, err := zutils.ToString(vvv)
+				if err != nil {
+					return nil, errors.New(errors.ErrorTypeParsingException, "[query_string] [fields] should be an array of strings").Cause(err)
 				}
+				value.Fields = append(value.Fields, field)
 			}
 		case "default_field":
-			value.DefaultField = v.(string)
+			value.DefaultField, err = zutils.ToString(v)
 		case "default_operator":
-			value.DefaultOperator = v.(string)
+			value.DefaultOperator, err = zutils.ToString(v)
 		case "boost":
-			value.Boost = v.(float64)
+			value.Boost, err = zutils.ToFloat64(v)
+		case "lenient":
+			value.Lenient, err = zutils.ToBool(v)
+		case "phrase_slop":
+			value.PhraseSlop, err = zutils.ToInt(v)
 		case "analyze_wildcard":
 			// noop
 		default:
 			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[query_string] unsupported children %s", k))
 		}
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[query_string] [%s] has an invalid value", k)).Cause(err)
+		}
 	}
 
-	options := querystr.DefaultOptions()
-
-	// TODO fields
-	// TODO default_field
-	// TODO default_operator
-	// TODO boost
-
-	zer, _ := zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
-	if zer == nil {
-		zer = analyzer.NewStandardAnalyzer()
+	parser := &queryStringParser{
+		name:       "query_string",
+		input:      []rune(value.Query),
+		lenient:    value.Lenient,
+		phraseSlop: value.PhraseSlop,
+		mappings:   mappings,
+		analyzers:  analyzers,
 	}
-	options.WithDefaultAnalyzer(zer)
-
-	if len(value.Fields) == 0 {
-		for field, prop := range mappings.ListProperty() {
-			if prop.Type == "text" || prop.Type == "keyword" {
-				value.Fields = append(value.Fields, field)
-			}
-		}
+	switch strings.ToUpper(value.DefaultOperator) {
+	case "", "OR":
+	case "AND":
+		parser.operatorAnd = true
+	default:
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[query_string] [default_operator] unknown operator [%s]", value.DefaultOperator))
 	}
-	for _, field := range value.Fields {
-		var zer *analysis.Analyzer
-		prop, _ := mappings.GetProperty(field)
-		if prop.Type == "keyword" {
-			zer = analyzer.NewKeywordAnalyzer()
-		} else {
-			indexZer, searchZer := zincanalysis.QueryAnalyzerForField(analyzers, mappings, field)
-			if zer == nil && searchZer != nil {
-				zer = searchZer
-			}
-			if zer == nil && indexZer != nil {
-				zer = indexZer
-			}
+	if value.Analyzer != "" {
+		zer, err := zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
+		if err != nil {
+			return nil, err
 		}
-		if zer != nil {
-			options.WithAnalyzerForField(field, zer)
+		parser.analyzer = zer
+	}
+
+	switch {
+	case len(value.Fields) > 0:
+		fields, err := parseFields("query_string", value.Fields, mappings)
+		if err != nil {
+			return nil, err
 		}
+		parser.fields = fields
+	case value.DefaultField != "":
+		parser.fields = parser.expandFields(value.DefaultField, 1)
+	default:
+		parser.fields = []boostedField{{name: "_all", boost: 1}}
 	}
 
-	return querystr.ParseQueryString(value.Query, options)
+	q, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	if value.Boost >= 0 {
+		q = boostQuery(q, value.Boost)
+	}
+	return q, nil
 }
@@ -0,0 +1,752 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/analyzer"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// boostedField is a field to search with its boost
+type boostedField struct {
+	name  string
+	boost float64
+}
+
+// parseFields parses the fields like "title^3", the field names with wildcards are expanded to the mapped fields
+func parseFields(queryName string, fields []string, mappings *meta.Mappings) ([]boostedField, error) {
+	rv := make([]boostedField, 0, len(fields))
+	for _, field := range fields {
+		boost := 1.0
+		if i := strings.LastIndex(field, "^"); i > 0 {
+			var err error
+			if boost, err = zutils.ToFloat64(field[i+1:]); err != nil {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] [fields] invalid boost of field [%s]", queryName, field))
+			}
+			field = field[:i]
+		}
+		rv = append(rv, expandFields(mappings, field, boost)...)
+	}
+	return rv, nil
+}
+
+// expandFields expands the field name with wildcards to the mapped text and keyword fields
+func expandFields(mappings *meta.Mappings, name string, boost float64) []boostedField {
+	if !strings.ContainsAny(name, "*?") {
+		return []boostedField{{name: name, boost: boost}}
+	}
+	fields := make([]boostedField, 0)
+	for field, prop := range mappings.ListProperty() {
+		if prop.Type != "text" && prop.Type != "keyword" {
+			continue
+		}
+		if ok, _ := path.Match(name, field); ok {
+			fields = append(fields, boostedField{name: field, boost: boost})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	return fields
+}
+
+// queryStringParser parses the lucene query syntax, e.g.
+//
+//	status:error AND level:>3
+//	title:(quick OR brown) -author:"john smith"~2 date:[2022-01-01 TO *} name:zin*^2
+type queryStringParser struct {
+	name        string // name of the query in the errors
+	input       []rune
+	pos         int
+	fields      []boostedField // default fields
+	operatorAnd bool           // default operator
+	lenient     bool
+	phraseSlop  int
+	analyzer    *analysis.Analyzer // analyzer of the query, overrides the field analyzers
+	mappings    *meta.Mappings
+	analyzers   map[string]*analysis.Analyzer
+	depth       int
+}
+
+const queryStringMaxDepth = 64
+
+func (p *queryStringParser) errorf(pos int, format string, args ...interface{}) error {
+	return errors.New(errors.ErrorTypeParsingException,
+		fmt.Sprintf("[%s] failed to parse query [%s]: %s at position %d", p.name, string(p.input), fmt.Sprintf(format, args...), pos))
+}
+
+func (p *queryStringParser) parse() (bluge.Query, error) {
+	p.skipSpace()
+	if p.eof() {
+		return bluge.NewMatchNoneQuery(), nil
+	}
+	q, err := p.parseOr(p.fields)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected [%c]", p.input[p.pos])
+	}
+	return q, nil
+}
+
+func (p *queryStringParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *queryStringParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *queryStringParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// consumeOperator consumes the operator if it's the next token, word operators must be followed by a separator
+func (p *queryStringParser) consumeOperator(ops ...string) bool {
+	for _, op := range ops {
+		end := p.pos + len(op)
+		if end > len(p.input) || string(p.input[p.pos:end]) != op {
+			continue
+		}
+		if unicode.IsLetter(rune(op[0])) && end < len(p.input) && !unicode.IsSpace(p.input[end]) && p.input[end] != '(' {
+			continue
+		}
+		p.pos = end
+		p.skipSpace()
+		return true
+	}
+	return false
+}
+
+// parseOr parses: and (OR and)*
+func (p *queryStringParser) parseOr(fields []boostedField) (bluge.Query, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > queryStringMaxDepth {
+		return nil, p.errorf(p.pos, "query is too complex")
+	}
+
+	q, err := p.parseAnd(fields)
+	if err != nil {
+		return nil, err
+	}
+	var boolQuery *bluge.BooleanQuery
+	for p.consumeOperator("OR", "||") {
+		if boolQuery == nil {
+			boolQuery = bluge.NewBooleanQuery().AddShould(q).SetMinShould(1)
+		}
+		if q, err = p.parseAnd(fields); err != nil {
+			return nil, err
+		}
+		boolQuery.AddShould(q)
+	}
+	if boolQuery != nil {
+		return boolQuery, nil
+	}
+	return q, nil
+}
+
+// parseAnd parses: clauses (AND clauses)*
+func (p *queryStringParser) parseAnd(fields []boostedField) (bluge.Query, error) {
+	q, err := p.parseClauses(fields)
+	if err != nil {
+		return nil, err
+	}
+	var boolQuery *bluge.BooleanQuery
+	for p.consumeOperator("AND", "&&") {
+		if boolQuery == nil {
+			boolQuery = bluge.NewBooleanQuery().AddMust(q)
+		}
+		if q, err = p.parseClauses(fields); err != nil {
+			return nil, err
+		}
+		boolQuery.AddMust(q)
+	}
+	if boolQuery != nil {
+		return boolQuery, nil
+	}
+	return q, nil
+}
+
+// parseClauses parses the clauses joined by the default operator: [+|-|!|NOT] clause ...
+func (p *queryStringParser) parseClauses(fields []boostedField) (bluge.Query, error) {
+	type clause struct {
+		modifier rune
+		query    bluge.Query
+	}
+	clauses := make([]clause, 0, 1)
+	for {
+		p.skipSpace()
+		if p.eof() || p.peek() == ')' {
+			break
+		}
+		start := p.pos
+		if p.consumeOperator("OR", "||", "AND", "&&") {
+			if len(clauses) == 0 {
+				return nil, p.errorf(start, "missing clause before [%s]", strings.TrimSpace(string(p.input[start:p.pos])))
+			}
+			p.pos = start
+			break
+		}
+
+		var modifier rune
+		switch {
+		case p.consumeOperator("NOT"):
+			modifier = '-'
+		case p.peek() == '+' || p.peek() == '-' || p.peek() == '!':
+			modifier = p.peek()
+			if modifier == '!' {
+				modifier = '-'
+			}
+			p.pos++
+			if p.eof() || unicode.IsSpace(p.peek()) {
+				return nil, p.errorf(start, "missing clause after [%c]", p.input[start])
+			}
+		}
+		q, err := p.parseClause(fields)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause{modifier: modifier, query: q})
+	}
+
+	if len(clauses) == 0 {
+		if p.eof() {
+			return nil, p.errorf(p.pos, "unexpected end of query")
+		}
+		return nil, p.errorf(p.pos, "missing clause before [%c]", p.peek())
+	}
+	if len(clauses) == 1 && clauses[0].modifier == 0 {
+		return clauses[0].query, nil
+	}
+
+	boolQuery := bluge.NewBooleanQuery()
+	hasMust := false
+	hasShould := false
+	for _, c := range clauses {
+		switch {
+		case c.modifier == '+' || c.modifier == 0 && p.operatorAnd:
+			boolQuery.AddMust(c.query)
+			hasMust = true
+		case c.modifier == '-':
+			boolQuery.AddMustNot(c.query)
+		default:
+			boolQuery.AddShould(c.query)
+			hasShould = true
+		}
+	}
+	if hasShould && !hasMust {
+		boolQuery.SetMinShould(1)
+	}
+	return boolQuery, nil
+}
+
+// parseClause parses: [field:] (group | value) [^boost]
+func (p *queryStringParser) parseClause(fields []boostedField) (bluge.Query, error) {
+	p.skipSpace()
+	start := p.pos
+
+	// field
+	if p.peek() != '(' && p.peek() != '"' && p.peek() != '/' {
+		name, err := p.readTerm()
+		if err != nil {
+			return nil, err
+		}
+		if name != "" && p.peek() == ':' {
+			p.pos++
+			if name == "_exists_" {
+				field, err := p.readTerm()
+				if err != nil {
+					return nil, err
+				}
+				if field == "" {
+					return nil, p.errorf(p.pos, "missing field name after [_exists_:]")
+				}
+				return p.boost(p.existsQuery(p.expandFields(field, 1)))
+			}
+			if name == "*" && p.peek() == '*' {
+				p.pos++
+				return p.boost(bluge.NewMatchAllQuery(), nil)
+			}
+			fields = p.expandFields(name, 1)
+			if len(fields) == 0 {
+				fields = []boostedField{{name: name, boost: 1}}
+			}
+			p.skipSpace()
+			if p.eof() {
+				return nil, p.errorf(p.pos, "missing value for field [%s]", name)
+			}
+		} else {
+			p.pos = start
+		}
+	}
+
+	// group
+	if p.peek() == '(' {
+		open := p.pos
+		p.pos++
+		q, err := p.parseOr(fields)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf(open, "unclosed [(]")
+		}
+		p.pos++
+		return p.boost(q, nil)
+	}
+	if p.peek() == ')' {
+		return nil, p.errorf(p.pos, "unexpected [)]")
+	}
+
+	return p.parseValue(fields)
+}
+
+// parseValue parses: "phrase"[~slop] | /regexp/ | range | [><]=?term | term[~fuzziness]
+func (p *queryStringParser) parseValue(fields []boostedField) (bluge.Query, error) {
+	start := p.pos
+	switch c := p.peek(); {
+	case c == '"':
+		phrase, err := p.readQuoted('"')
+		if err != nil {
+			return nil, err
+		}
+		slop := p.phraseSlop
+		if p.peek() == '~' {
+			p.pos++
+			n, err := p.readNumber(float64(slop))
+			if err != nil {
+				return nil, err
+			}
+			slop = int(n)
+		}
+		return p.boost(p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+			return p.phraseQuery(field.name, phrase, slop)
+		}))
+	case c == '/':
+		re, err := p.readQuoted('/')
+		if err != nil {
+			return nil, err
+		}
+		return p.boost(p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+			return bluge.NewRegexpQuery(re).SetField(field.name), nil
+		}))
+	case c == '[' || c == '{':
+		return p.parseRange(fields)
+	case c == '>' || c == '<':
+		p.pos++
+		inclusive := false
+		if p.peek() == '=' {
+			p.pos++
+			inclusive = true
+		}
+		term, err := p.readTerm()
+		if err != nil {
+			return nil, err
+		}
+		if term == "" {
+			return nil, p.errorf(p.pos, "missing value after [%s]", string(p.input[start:p.pos]))
+		}
+		return p.boost(p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+			if c == '>' {
+				return p.rangeQuery(field.name, term, "*", inclusive, false, start)
+			}
+			return p.rangeQuery(field.name, "*", term, false, inclusive, start)
+		}))
+	}
+
+	raw := p.pos
+	term, err := p.readTerm()
+	if err != nil {
+		return nil, err
+	}
+	if term == "" {
+		if p.eof() {
+			return nil, p.errorf(p.pos, "unexpected end of query")
+		}
+		return nil, p.errorf(p.pos, "unexpected [%c]", p.peek())
+	}
+	wildcard := p.hasWildcard(raw)
+	fuzziness := -1
+	if p.peek() == '~' {
+		p.pos++
+		n, err := p.readNumber(2)
+		if err != nil {
+			return nil, err
+		}
+		if n > 2 {
+			n = 2
+		}
+		fuzziness = int(n)
+	}
+	return p.boost(p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+		return p.termQuery(field.name, term, wildcard, fuzziness, start)
+	}))
+}
+
+// parseRange parses: [min TO max] with [] inclusive and {} exclusive bounds, * is unbounded
+func (p *queryStringParser) parseRange(fields []boostedField) (bluge.Query, error) {
+	start := p.pos
+	minInclusive := p.peek() == '['
+	p.pos++
+	p.skipSpace()
+	min, err := p.readRangeBound()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consumeOperator("TO") {
+		return nil, p.errorf(p.pos, "expected [TO] in range")
+	}
+	max, err := p.readRangeBound()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.peek() != ']' && p.peek() != '}' {
+		return nil, p.errorf(start, "unclosed range")
+	}
+	maxInclusive := p.peek() == ']'
+	p.pos++
+	if min == "" || max == "" {
+		return nil, p.errorf(start, "range bounds can't be empty")
+	}
+	return p.boost(p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+		return p.rangeQuery(field.name, min, max, minInclusive, maxInclusive, start)
+	}))
+}
+
+// boost parses the optional ^boost after the query
+func (p *queryStringParser) boost(q bluge.Query, err error) (bluge.Query, error) {
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '^' {
+		return q, nil
+	}
+	p.pos++
+	boost, err := p.readNumber(1)
+	if err != nil {
+		return nil, err
+	}
+	return boostQuery(q, boost), nil
+}
+
+// readTerm reads an unquoted term with escaped characters
+func (p *queryStringParser) readTerm() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		if c == '\\' {
+			if p.pos+1 >= len(p.input) {
+				return "", p.errorf(p.pos, "incomplete escape")
+			}
+			sb.WriteRune(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune(`()[]{}:^~"<>`, c) || sb.Len() == 0 && strings.ContainsRune("+-!/=", c) {
+			break
+		}
+		sb.WriteRune(c)
+		p.pos++
+	}
+	return sb.String(), nil
+}
+
+// readRangeBound reads a range bound which can be quoted and contain ':'
+func (p *queryStringParser) readRangeBound() (string, error) {
+	if p.peek() == '"' {
+		return p.readQuoted('"')
+	}
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		if c == '\\' && p.pos+1 < len(p.input) {
+			sb.WriteRune(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if unicode.IsSpace(c) || c == ']' || c == '}' {
+			break
+		}
+		sb.WriteRune(c)
+		p.pos++
+	}
+	return sb.String(), nil
+}
+
+// readQuoted reads a string between the quote characters
+func (p *queryStringParser) readQuoted(quote rune) (string, error) {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		if c == '\\' && p.pos+1 < len(p.input) {
+			if p.input[p.pos+1] != quote && quote == '/' {
+				sb.WriteRune(c) // keep regexp escapes
+			}
+			sb.WriteRune(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		p.pos++
+		if c == quote {
+			return sb.String(), nil
+		}
+		sb.WriteRune(c)
+	}
+	return "", p.errorf(start, "unclosed [%c]", quote)
+}
+
+// readNumber reads the number after ~ or ^, returns the default value if it's missing
+func (p *queryStringParser) readNumber(defaultValue float64) (float64, error) {
+	start := p.pos
+	for !p.eof() && (unicode.IsDigit(p.peek()) || p.peek() == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		return defaultValue, nil
+	}
+	v, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+	if err != nil {
+		return 0, p.errorf(start, "invalid number [%s]", string(p.input[start:p.pos]))
+	}
+	return v, nil
+}
+
+// hasWildcard checks the unescaped wildcards of the term read from the position
+func (p *queryStringParser) hasWildcard(start int) bool {
+	for i := start; i < p.pos; i++ {
+		switch p.input[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		}
+	}
+	return false
+}
+
+// expandFields expands the field name with wildcards to the mapped fields
+func (p *queryStringParser) expandFields(name string, boost float64) []boostedField {
+	return expandFields(p.mappings, name, boost)
+}
+
+// fieldsQuery combines the query of every field with OR
+func (p *queryStringParser) fieldsQuery(fields []boostedField, fn func(field boostedField) (bluge.Query, error)) (bluge.Query, error) {
+	queries := make([]bluge.Query, 0, len(fields))
+	for _, field := range fields {
+		q, err := fn(field)
+		if err != nil {
+			if p.lenient {
+				continue
+			}
+			return nil, err
+		}
+		queries = append(queries, boostQuery(q, field.boost))
+	}
+	switch len(queries) {
+	case 0:
+		return bluge.NewMatchNoneQuery(), nil
+	case 1:
+		return queries[0], nil
+	}
+	boolQuery := bluge.NewBooleanQuery().SetMinShould(1)
+	for _, q := range queries {
+		boolQuery.AddShould(q)
+	}
+	return boolQuery, nil
+}
+
+func (p *queryStringParser) existsQuery(fields []boostedField) (bluge.Query, error) {
+	return p.fieldsQuery(fields, func(field boostedField) (bluge.Query, error) {
+		return bluge.NewWildcardQuery("*").SetField(field.name), nil
+	})
+}
+
+// fieldAnalyzer returns the analyzer of the text field, nil for the other types
+func (p *queryStringParser) fieldAnalyzer(field string) *analysis.Analyzer {
+	prop, ok := p.mappings.GetProperty(field)
+	if ok && prop.Type != "text" {
+		return nil
+	}
+	if p.analyzer != nil {
+		return p.analyzer
+	}
+	indexZer, searchZer := zincanalysis.QueryAnalyzerForField(p.analyzers, p.mappings, field)
+	if searchZer != nil {
+		return searchZer
+	}
+	if indexZer != nil {
+		return indexZer
+	}
+	return analyzer.NewStandardAnalyzer()
+}
+
+func (p *queryStringParser) termQuery(field, term string, wildcard bool, fuzziness int, pos int) (bluge.Query, error) {
+	prop, _ := p.mappings.GetProperty(field)
+	switch prop.Type {
+	case "numeric", "date", "time":
+		if wildcard && term == "*" {
+			return bluge.NewWildcardQuery("*").SetField(field), nil
+		}
+		return p.rangeQuery(field, term, term, true, true, pos)
+	case "bool":
+		v, err := zutils.ToBool(term)
+		if err != nil {
+			return nil, p.errorf(pos, "field [%s] value [%s] is not a boolean", field, term)
+		}
+		return bluge.NewTermQuery(strconv.FormatBool(v)).SetField(field), nil
+	}
+
+	zer := p.fieldAnalyzer(field)
+	if wildcard {
+		if zer != nil {
+			term = strings.ToLower(term)
+		}
+		if strings.HasSuffix(term, "*") && !strings.ContainsAny(term[:len(term)-1], "*?") {
+			return bluge.NewPrefixQuery(term[:len(term)-1]).SetField(field), nil
+		}
+		return bluge.NewWildcardQuery(term).SetField(field), nil
+	}
+
+	terms := []string{term}
+	if zer != nil {
+		terms = terms[:0]
+		for _, token := range zer.Analyze([]byte(term)) {
+			terms = append(terms, string(token.Term))
+		}
+	}
+	queries := make([]bluge.Query, 0, len(terms))
+	for _, t := range terms {
+		if fuzziness >= 0 {
+			queries = append(queries, bluge.NewFuzzyQuery(t).SetField(field).SetFuzziness(fuzziness))
+		} else {
+			queries = append(queries, bluge.NewTermQuery(t).SetField(field))
+		}
+	}
+	switch len(queries) {
+	case 0:
+		return bluge.NewMatchNoneQuery(), nil
+	case 1:
+		return queries[0], nil
+	}
+	boolQuery := bluge.NewBooleanQuery()
+	for _, q := range queries {
+		if p.operatorAnd {
+			boolQuery.AddMust(q)
+		} else {
+			boolQuery.AddShould(q)
+		}
+	}
+	if !p.operatorAnd {
+		boolQuery.SetMinShould(1)
+	}
+	return boolQuery, nil
+}
+
+func (p *queryStringParser) phraseQuery(field, phrase string, slop int) (bluge.Query, error) {
+	zer := p.fieldAnalyzer(field)
+	if zer == nil {
+		prop, _ := p.mappings.GetProperty(field)
+		if prop.Type == "keyword" || prop.Type == "" {
+			return bluge.NewTermQuery(phrase).SetField(field), nil
+		}
+		return p.termQuery(field, phrase, false, -1, p.pos)
+	}
+	q := bluge.NewMatchPhraseQuery(phrase).SetField(field).SetAnalyzer(zer)
+	if slop > 0 {
+		q.SetSlop(slop)
+	}
+	return q, nil
+}
+
+func (p *queryStringParser) rangeQuery(field, min, max string, minInclusive, maxInclusive bool, pos int) (bluge.Query, error) {
+	if min == "*" && max == "*" {
+		return bluge.NewWildcardQuery("*").SetField(field), nil
+	}
+	prop, _ := p.mappings.GetProperty(field)
+	switch prop.Type {
+	case "numeric":
+		minValue, maxValue := bluge.MinNumeric, bluge.MaxNumeric
+		var err error
+		if min != "*" {
+			if minValue, err = strconv.ParseFloat(min, 64); err != nil {
+				return nil, p.errorf(pos, "field [%s] value [%s] is not a number", field, min)
+			}
+		}
+		if max != "*" {
+			if maxValue, err = strconv.ParseFloat(max, 64); err != nil {
+				return nil, p.errorf(pos, "field [%s] value [%s] is not a number", field, max)
+			}
+		}
+		return bluge.NewNumericRangeInclusiveQuery(minValue, maxValue, minInclusive, maxInclusive).SetField(field), nil
+	case "date", "time":
+		var minValue, maxValue time.Time
+		var err error
+		if min != "*" {
+			if minValue, err = zutils.ParseTime(min, prop.Format, prop.TimeZone); err != nil {
+				return nil, p.errorf(pos, "field [%s] value [%s] parse err: %s", field, min, err.Error())
+			}
+		}
+		if max != "*" {
+			if maxValue, err = zutils.ParseTime(max, prop.Format, prop.TimeZone); err != nil {
+				return nil, p.errorf(pos, "field [%s] value [%s] parse err: %s", field, max, err.Error())
+			}
+		}
+		return bluge.NewDateRangeInclusiveQuery(minValue, maxValue, minInclusive, maxInclusive).SetField(field), nil
+	default:
+		if min == "*" {
+			min = ""
+		}
+		if max == "*" {
+			max = ""
+		}
+		if p.fieldAnalyzer(field) != nil {
+			min, max = strings.ToLower(min), strings.ToLower(max)
+		}
+		return bluge.NewTermRangeInclusiveQuery(min, max, minInclusive, maxInclusive).SetField(field), nil
+	}
+}
+
+// boostQuery applies the boost to the query
+func boostQuery(q bluge.Query, boost float64) bluge.Query {
+	if boost == 1 {
+		return q
+	}
+	return bluge.NewBooleanQuery().AddMust(q).SetBoost(boost)
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
@@ -38,6 +39,12 @@ func RangeQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Qu
 		if !ok {
 			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[range] query doesn't support values of type: %T", v))
 		}
+		if f, ok, err := runtime.Compile(field, mappings); ok {
+			if err != nil {
+				return nil, err
+			}
+			return RuntimeRangeQuery(f, vv)
+		}
 		prop, _ := mappings.GetProperty(field)
 		switch prop.Type {
 		case "numeric":
@@ -151,64 +158,35 @@ func RangeQueryTime(field string, query map[string]interface{}, mappings *meta.M
 		}
 	}
 
-	var v float64
-
+	now := time.Now()
 	min := time.Time{}
 	max := time.Time{}
 	minInclusive := false
 	maxInclusive := false
 	if value.GT != nil {
-		if format == "epoch_millis" {
-			v, err = zutils.ToFloat64(value.GT)
-			min = time.UnixMilli(int64(v))
-		} else {
-			v, _ := zutils.ToString(value.GT)
-			min, err = time.ParseInLocation(format, v, timeZone)
-		}
-		if err != nil {
+		if min, err = rangeTimeValue(value.GT, format, timeZone, now, true); err != nil {
 			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[range] %s range.gt format err %s", field, err.Error()))
 		}
 	}
 	if value.GTE != nil {
 		minInclusive = true
-		if format == "epoch_millis" {
-			v, err = zutils.ToFloat64(value.GTE)
-			min = time.UnixMilli(int64(v))
-		} else {
-			v, _ := zutils.ToString(value.GTE)
-			min, err = time.ParseInLocation(format, v, timeZone)
-		}
-		if err != nil {
+		if min, err = rangeTimeValue(value.GTE, format, timeZone, now, false); err != nil {
 			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[range] %s range.gte format err %s", field, err.Error()))
 		}
 	}
 	if value.LT != nil {
-		if format == "epoch_millis" {
-			v, err = zutils.ToFloat64(value.LT)
-			max = time.UnixMilli(int64(v))
-		} else {
-			v, _ := zutils.ToString(value.LT)
-			max, err = time.ParseInLocation(format, v, timeZone)
-		}
-		if err != nil {
+		if max, err = rangeTimeValue(value.LT, format, timeZone, now, false); err != nil {
 			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[range] %s range.lt format err %s", field, err.Error()))
 		}
 	}
 	if value.LTE != nil {
 		maxInclusive = true
-		if format == "epoch_millis" {
-			v, err = zutils.ToFloat64(value.LTE)
-			max = time.UnixMilli(int64(v))
-		} else {
-			v, _ := zutils.ToString(value.LTE)
-			max, err = time.ParseInLocation(format, v, timeZone)
-		}
-		if err != nil {
+		if max, err = rangeTimeValue(value.LTE, format, timeZone, now, true); err != nil {
 			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[range] %s range.lte format err %s", field, err.Error()))
 		}
 	}
 	if max.IsZero() {
-		max = time.Now()
+		max = now
 	}
 	subq := bluge.NewDateRangeInclusiveQuery(min.UTC(), max.UTC(), minInclusive, maxInclusive).SetField(field)
 	if value.Boost >= 0 {
@@ -217,3 +195,22 @@ func RangeQueryTime(field string, query map[string]interface{}, mappings *meta.M
 
 	return subq, nil
 }
+
+// rangeTimeValue parses a bound of the date range, the value can be a date math expression like now-1h/h,
+// gt and lte round up to the end of the rounding unit, gte and lt round down
+func rangeTimeValue(v interface{}, format string, timeZone *time.Location, now time.Time, roundUp bool) (time.Time, error) {
+	if _, ok := v.(string); !ok {
+		for _, f := range zutils.DateFormats(format) {
+			switch f {
+			case "epoch_millis":
+				n, err := zutils.ToFloat64(v)
+				return time.UnixMilli(int64(n)), err
+			case "epoch_second":
+				n, err := zutils.ToFloat64(v)
+				return time.Unix(int64(n), 0), err
+			}
+		}
+	}
+	value, _ := zutils.ToString(v)
+	return zutils.ParseDateMath(value, format, timeZone, now, roundUp)
+}
@@ -0,0 +1,126 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// RuntimeTermsQuery matches the documents whose runtime field equals one of the values
+func RuntimeTermsQuery(f *runtime.Field, values []interface{}, boost float64) (bluge.Query, error) {
+	terms := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		term, err := f.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+
+	subq := zincquery.NewScriptFilterQuery(fmt.Sprintf("%s:%v", f.Name(), values), f.Fields(), func(values zincquery.DocValues) bool {
+		v, ok := f.Value(values)
+		if !ok {
+			return false
+		}
+		for _, term := range terms {
+			if f.Compare(v, term) == 0 {
+				return true
+			}
+		}
+		return false
+	})
+	if boost >= 0 {
+		subq.SetBoost(boost)
+	}
+	return subq, nil
+}
+
+// RuntimeRangeQuery matches the documents whose runtime field is in the range
+func RuntimeRangeQuery(f *runtime.Field, query map[string]interface{}) (bluge.Query, error) {
+	var format, timeZone string
+	boost := -1.0
+	bounds := make(map[string]interface{})
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "gt", "gte", "lt", "lte":
+			bounds[k] = v
+		case "format":
+			format, _ = v.(string)
+		case "time_zone":
+			timeZone, _ = v.(string)
+		case "boost":
+			boost, _ = zutils.ToFloat64(v)
+		default:
+			// return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[range] unknown field [%s]", k))
+		}
+	}
+	for k, v := range bounds {
+		var err error
+		if f.Type() == runtime.TypeDate && (format != "" || timeZone != "") {
+			v, err = zutils.ParseTime(v, format, timeZone)
+			if err != nil {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[range] %s value [%v] parse error", f.Name(), bounds[k])).Cause(err)
+			}
+		} else if v, err = f.Parse(v); err != nil {
+			return nil, err
+		}
+		bounds[k] = v
+	}
+
+	subq := zincquery.NewScriptFilterQuery(fmt.Sprintf("%s:%v", f.Name(), query), f.Fields(), func(values zincquery.DocValues) bool {
+		v, ok := f.Value(values)
+		if !ok {
+			return false
+		}
+		for k, bound := range bounds {
+			c := f.Compare(v, bound)
+			switch k {
+			case "gt":
+				ok = c > 0
+			case "gte":
+				ok = c >= 0
+			case "lt":
+				ok = c < 0
+			case "lte":
+				ok = c <= 0
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	})
+	if boost >= 0 {
+		subq.SetBoost(boost)
+	}
+	return subq, nil
+}
+
+// RuntimeExistsQuery matches the documents which have a value of the runtime field
+func RuntimeExistsQuery(f *runtime.Field) bluge.Query {
+	return zincquery.NewScriptFilterQuery("_exists_:"+f.Name(), f.Fields(), func(values zincquery.DocValues) bool {
+		_, ok := f.Value(values)
+		return ok
+	})
+}
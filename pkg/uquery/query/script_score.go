@@ -0,0 +1,180 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/metadata"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func ScriptScoreQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	var subq bluge.Query
+	var script *zincquery.Script
+	var minScore, boost interface{}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "query":
+			vv, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script_score] query doesn't support values of type: %T", v))
+			}
+			if subq, err = Query(vv, mappings, analyzers); err != nil {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[script_score] failed to parse field [query]").Cause(err)
+			}
+		case "script":
+			if script, err = Script(v, mappings); err != nil {
+				return nil, err
+			}
+		case "min_score":
+			minScore = v
+		case "boost":
+			boost = v
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script_score] unknown field [%s]", k))
+		}
+	}
+	if subq == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[script_score] query is required")
+	}
+	if script == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[script_score] script is required")
+	}
+
+	ssQuery := zincquery.NewFunctionScoreQuery(subq).
+		AddFunction(&zincquery.Function{Weight: 1, Score: script}).
+		SetBoostMode(zincquery.BoostModeReplace)
+	if minScore != nil {
+		v, err := zutils.ToFloat64(minScore)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[script_score] min_score should be a number").Cause(err)
+		}
+		ssQuery.SetMinScore(v)
+	}
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[script_score] boost should be a number").Cause(err)
+		}
+		ssQuery.SetBoost(v)
+	}
+
+	return ssQuery, nil
+}
+
+// Script compiles a script, the value can be the source, {"source": "", "params": {}}
+// or a stored script {"id": "", "params": {}}
+func Script(v interface{}, mappings *meta.Mappings) (*zincquery.Script, error) {
+	source, params, err := ScriptSource(v)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := zincquery.NewScript(source, params, func(field string) string {
+		prop, ok := mappings.GetProperty(field)
+		if !ok {
+			return ""
+		}
+		switch prop.Type {
+		case "date", "time":
+			return zincquery.FieldTypeDate
+		default:
+			return prop.Type
+		}
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[script] compile error").Cause(err)
+	}
+	return script, nil
+}
+
+// ScriptSource returns the source and the numeric params of a script, a stored script is loaded by id
+func ScriptSource(v interface{}) (string, map[string]float64, error) {
+	var source, id string
+	params := make(map[string]float64)
+	switch v := v.(type) {
+	case string:
+		source = v
+	case map[string]interface{}:
+		for k, vv := range v {
+			k := strings.ToLower(k)
+			switch k {
+			case "source":
+				source, _ = zutils.ToString(vv)
+			case "id":
+				id, _ = zutils.ToString(vv)
+			case "lang":
+				lang, _ := zutils.ToString(vv)
+				if lang != "painless" && lang != "expression" {
+					return "", nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] unsupported lang [%s]", lang))
+				}
+			case "params":
+				values, ok := vv.(map[string]interface{})
+				if !ok {
+					return "", nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script] params doesn't support values of type: %T", vv))
+				}
+				for name, param := range values {
+					value, err := zutils.ToFloat64(param)
+					if err != nil {
+						return "", nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] param [%s] should be a number", name))
+					}
+					params[name] = value
+				}
+			default:
+				return "", nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script] unknown field [%s]", k))
+			}
+		}
+	default:
+		return "", nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[script] doesn't support values of type: %T", v))
+	}
+
+	if id != "" {
+		if source != "" {
+			return "", nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[script] only one of [id] or [source] may be specified")
+		}
+		stored, err := metadata.StoredScript.Get(id)
+		if err != nil {
+			if err == errors.ErrKeyNotFound {
+				return "", nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] unable to find script [%s]", id))
+			}
+			return "", nil, err
+		}
+		source = stored.Script.Source
+		// the params of the request override the params of the stored script
+		for name, param := range stored.Script.Params {
+			if _, ok := params[name]; ok {
+				continue
+			}
+			value, err := zutils.ToFloat64(param)
+			if err != nil {
+				return "", nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[script] param [%s] should be a number", name))
+			}
+			params[name] = value
+		}
+	}
+
+	return source, params, nil
+}
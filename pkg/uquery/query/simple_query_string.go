@@ -16,12 +16,448 @@
 package query
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
 
+	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+// simple_query_string flags
+const (
+	simpleFlagAnd = 1 << iota
+	simpleFlagNot
+	simpleFlagOr
+	simpleFlagPrefix
+	simpleFlagPhrase
+	simpleFlagPrecedence
+	simpleFlagEscape
+	simpleFlagWhitespace
+	simpleFlagFuzzy
+	simpleFlagNear
+	simpleFlagAll  = 1<<iota - 1
+	simpleFlagNone = 0
 )
 
+var simpleQueryStringFlags = map[string]int{
+	"ALL":        simpleFlagAll,
+	"NONE":       simpleFlagNone,
+	"AND":        simpleFlagAnd,
+	"NOT":        simpleFlagNot,
+	"OR":         simpleFlagOr,
+	"PREFIX":     simpleFlagPrefix,
+	"PHRASE":     simpleFlagPhrase,
+	"PRECEDENCE": simpleFlagPrecedence,
+	"ESCAPE":     simpleFlagEscape,
+	"WHITESPACE": simpleFlagWhitespace,
+	"FUZZY":      simpleFlagFuzzy,
+	"NEAR":       simpleFlagNear,
+	"SLOP":       simpleFlagNear,
+}
+
 func SimpleQueryStringQuery(query map[string]interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
-	return QueryStringQuery(query, mappings, analyzers)
+	value := new(meta.SimpleQueryStringQuery)
+	value.Boost = -1.0
+	flags := simpleFlagAll
+	for k, v := range query {
+		k := strings.ToLower(k)
+		var err error
+		switch k {
+		case "query":
+			value.Query, err = zutils.ToString(v)
+		case "analyzer":
+			value.Analyzer, err = zutils.ToString(v)
+		case "fields":
+			vv, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[simple_query_string] [fields] should be an array of strings, got %T", v))
+			}
+			for _, vvv := range vv {
+				field, err := zutils.ToString(vvv)
+				if err != nil {
+					return nil, errors.New(errors.ErrorTypeParsingException, "[simple_query_string] [fields] should be an array of strings").Cause(err)
+				}
+				value.Fields = append(value.Fields, field)
+			}
+		case "default_operator":
+			value.DefaultOperator, err = zutils.ToString(v)
+		case "flags":
+			value.Flags = v
+			flags, err = simpleQueryStringFlag(v)
+		case "analyze_wildcard":
+			value.AnalyzeWildcard, err = zutils.ToBool(v)
+		case "lenient":
+			value.Lenient, err = zutils.ToBool(v)
+		case "all_fields":
+			value.AllFields, err = zutils.ToBool(v)
+		case "boost":
+			value.Boost, err = zutils.ToFloat64(v)
+		case "auto_generate_synonyms_phrase_query", "fuzzy_max_expansions", "fuzzy_prefix_length", "fuzzy_transpositions":
+			// noop
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[simple_query_string] unsupported children %s", k))
+		}
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[simple_query_string] [%s] has an invalid value", k)).Cause(err)
+		}
+	}
+
+	parser := &simpleQueryStringParser{
+		queryStringParser: queryStringParser{
+			name:      "simple_query_string",
+			input:     []rune(value.Query),
+			lenient:   value.Lenient,
+			mappings:  mappings,
+			analyzers: analyzers,
+		},
+		flags:           flags,
+		analyzeWildcard: value.AnalyzeWildcard,
+	}
+	switch strings.ToUpper(value.DefaultOperator) {
+	case "", "OR":
+	case "AND":
+		parser.operatorAnd = true
+	default:
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[simple_query_string] [default_operator] unknown operator [%s]", value.DefaultOperator))
+	}
+	if value.Analyzer != "" {
+		zer, err := zincanalysis.QueryAnalyzer(analyzers, value.Analyzer)
+		if err != nil {
+			return nil, err
+		}
+		parser.analyzer = zer
+	}
+
+	switch {
+	case len(value.Fields) > 0:
+		fields, err := parseFields("simple_query_string", value.Fields, mappings)
+		if err != nil {
+			return nil, err
+		}
+		parser.fields = fields
+	case value.AllFields:
+		parser.fields = parser.expandFields("*", 1)
+	default:
+		parser.fields = []boostedField{{name: "_all", boost: 1}}
+	}
+
+	q, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	if value.Boost >= 0 {
+		q = boostQuery(q, value.Boost)
+	}
+	return q, nil
+}
+
+// simpleQueryStringFlag parses the flags like "OR|AND|PREFIX", or the bitmask number
+func simpleQueryStringFlag(v interface{}) (int, error) {
+	if s, ok := v.(string); ok {
+		flags := 0
+		for _, name := range strings.Split(s, "|") {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			flag, ok := simpleQueryStringFlags[name]
+			if !ok {
+				return 0, fmt.Errorf("unknown flag [%s]", name)
+			}
+			if flag == simpleFlagAll {
+				return simpleFlagAll, nil
+			}
+			flags |= flag
+		}
+		return flags, nil
+	}
+	flags, err := zutils.ToInt(v)
+	if err != nil {
+		return 0, err
+	}
+	if flags < 0 {
+		return simpleFlagAll, nil
+	}
+	return flags & simpleFlagAll, nil
+}
+
+// simpleQueryStringParser parses the simple query syntax for the search boxes, e.g.
+//
+//	"fried eggs" +(eggplant | potato) -frittata bread~1 zin*
+//
+// it never fails on the syntax, the invalid operators are treated as the literal terms
+type simpleQueryStringParser struct {
+	queryStringParser
+	flags           int
+	analyzeWildcard bool
+}
+
+// simpleClause is a parsed clause with the operator that joins it to the previous clause
+type simpleClause struct {
+	operator rune // '+', '|' or 0 for the default operator
+	negate   bool
+	query    bluge.Query
+}
+
+func (p *simpleQueryStringParser) enabled(flag int) bool {
+	return p.flags&flag != 0
+}
+
+func (p *simpleQueryStringParser) parse() (bluge.Query, error) {
+	q, err := p.parseGroup(0)
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		return bluge.NewMatchNoneQuery(), nil
+	}
+	return q, nil
+}
+
+// parseGroup parses the clauses until the end of the query or the closing parenthesis
+func (p *simpleQueryStringParser) parseGroup(depth int) (bluge.Query, error) {
+	clauses := make([]simpleClause, 0, 1)
+	var operator rune
+	negate := false
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case p.isWhitespace(c):
+			p.pos++
+			continue
+		case c == '+' && p.enabled(simpleFlagAnd), c == '|' && p.enabled(simpleFlagOr):
+			p.pos++
+			operator = c
+			continue
+		case c == '-' && p.enabled(simpleFlagNot):
+			p.pos++
+			negate = !negate
+			continue
+		case c == ')' && p.enabled(simpleFlagPrecedence):
+			p.pos++
+			if depth > 0 {
+				return p.combine(clauses), nil
+			}
+			continue // unbalanced parenthesis
+		}
+
+		var q bluge.Query
+		var err error
+		switch {
+		case c == '(' && p.enabled(simpleFlagPrecedence) && depth < queryStringMaxDepth:
+			p.pos++
+			q, err = p.parseGroup(depth + 1)
+		case c == '"' && p.enabled(simpleFlagPhrase) && p.hasClosingQuote():
+			q, err = p.parsePhrase()
+		default:
+			q, err = p.parseTerm()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if q != nil {
+			clauses = append(clauses, simpleClause{operator: operator, negate: negate, query: q})
+		}
+		operator, negate = 0, false
+	}
+	return p.combine(clauses), nil
+}
+
+// combine joins the clauses, AND binds tighter than OR
+func (p *simpleQueryStringParser) combine(clauses []simpleClause) bluge.Query {
+	if len(clauses) == 0 {
+		return nil
+	}
+	groups := make([][]simpleClause, 0, 1)
+	for i, clause := range clauses {
+		operator := clause.operator
+		if operator == 0 {
+			operator = '|'
+			if p.operatorAnd {
+				operator = '+'
+			}
+		}
+		if i == 0 || operator == '|' {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], clause)
+	}
+
+	queries := make([]bluge.Query, 0, len(groups))
+	for _, group := range groups {
+		if len(group) == 1 && !group[0].negate {
+			queries = append(queries, group[0].query)
+			continue
+		}
+		boolQuery := bluge.NewBooleanQuery()
+		hasMust := false
+		for _, clause := range group {
+			if clause.negate {
+				boolQuery.AddMustNot(clause.query)
+			} else {
+				boolQuery.AddMust(clause.query)
+				hasMust = true
+			}
+		}
+		if !hasMust {
+			boolQuery.AddMust(bluge.NewMatchAllQuery())
+		}
+		queries = append(queries, boolQuery)
+	}
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	boolQuery := bluge.NewBooleanQuery().SetMinShould(1)
+	for _, q := range queries {
+		boolQuery.AddShould(q)
+	}
+	return boolQuery
+}
+
+func (p *simpleQueryStringParser) isWhitespace(c rune) bool {
+	return p.enabled(simpleFlagWhitespace) && unicode.IsSpace(c)
+}
+
+func (p *simpleQueryStringParser) hasClosingQuote() bool {
+	for i := p.pos + 1; i < len(p.input); i++ {
+		if p.input[i] == '\\' && p.enabled(simpleFlagEscape) {
+			i++
+			continue
+		}
+		if p.input[i] == '"' {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePhrase parses: "phrase"[~slop]
+func (p *simpleQueryStringParser) parsePhrase() (bluge.Query, error) {
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		p.pos++
+		if c == '\\' && p.enabled(simpleFlagEscape) && !p.eof() {
+			sb.WriteRune(p.input[p.pos])
+			p.pos++
+			continue
+		}
+		if c == '"' {
+			break
+		}
+		sb.WriteRune(c)
+	}
+	slop := 0
+	if p.enabled(simpleFlagNear) {
+		slop = p.readTilde(0)
+	}
+	phrase := sb.String()
+	return p.fieldsQuery(p.fields, func(field boostedField) (bluge.Query, error) {
+		return p.phraseQuery(field.name, phrase, slop)
+	})
+}
+
+// parseTerm parses: term[*][~fuzziness], returns nil if the term is empty
+func (p *simpleQueryStringParser) parseTerm() (bluge.Query, error) {
+	var sb strings.Builder
+	prefix := false
+	for !p.eof() {
+		c := p.input[p.pos]
+		if c == '\\' && p.enabled(simpleFlagEscape) {
+			p.pos++
+			if !p.eof() {
+				sb.WriteRune(p.input[p.pos])
+				p.pos++
+			}
+			prefix = false
+			continue
+		}
+		if p.isWhitespace(c) ||
+			(c == '+' && p.enabled(simpleFlagAnd)) ||
+			(c == '|' && p.enabled(simpleFlagOr)) ||
+			((c == '(' || c == ')') && p.enabled(simpleFlagPrecedence)) ||
+			(c == '"' && p.enabled(simpleFlagPhrase) && sb.Len() > 0) ||
+			(c == '~' && p.enabled(simpleFlagFuzzy) && sb.Len() > 0) {
+			break
+		}
+		sb.WriteRune(c)
+		prefix = c == '*'
+		p.pos++
+	}
+	term := sb.String()
+	fuzziness := -1
+	if p.enabled(simpleFlagFuzzy) {
+		fuzziness = p.readTilde(-1)
+	}
+	if prefix && p.enabled(simpleFlagPrefix) {
+		term = strings.TrimRight(term, "*")
+		if term == "" {
+			return bluge.NewMatchAllQuery(), nil
+		}
+		return p.fieldsQuery(p.fields, func(field boostedField) (bluge.Query, error) {
+			return p.prefixQuery(field.name, term)
+		})
+	}
+	if term == "" {
+		return nil, nil
+	}
+	return p.fieldsQuery(p.fields, func(field boostedField) (bluge.Query, error) {
+		return p.termQuery(field.name, term, false, fuzziness, p.pos)
+	})
+}
+
+// readTilde reads ~N after the term or phrase, ~ without number is 2
+func (p *simpleQueryStringParser) readTilde(defaultValue int) int {
+	if p.peek() != '~' {
+		return defaultValue
+	}
+	p.pos++
+	start := p.pos
+	for !p.eof() && unicode.IsDigit(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		return 2
+	}
+	n, _ := strconv.Atoi(string(p.input[start:p.pos]))
+	if defaultValue < 0 && n > 2 {
+		n = 2 // max edit distance
+	}
+	return n
+}
+
+// prefixQuery creates the prefix query, the prefix is analyzed if analyze_wildcard is enabled
+func (p *simpleQueryStringParser) prefixQuery(field, prefix string) (bluge.Query, error) {
+	prop, _ := p.mappings.GetProperty(field)
+	if prop.Type != "" && prop.Type != "text" && prop.Type != "keyword" {
+		return nil, p.errorf(p.pos, "can only use prefix queries on keyword and text fields, not on [%s] which is of type [%s]", field, prop.Type)
+	}
+	zer := p.fieldAnalyzer(field)
+	if zer == nil {
+		return bluge.NewPrefixQuery(prefix).SetField(field), nil
+	}
+	if !p.analyzeWildcard {
+		return bluge.NewPrefixQuery(strings.ToLower(prefix)).SetField(field), nil
+	}
+	tokens := zer.Analyze([]byte(prefix))
+	switch len(tokens) {
+	case 0:
+		return bluge.NewMatchNoneQuery(), nil
+	case 1:
+		return bluge.NewPrefixQuery(string(tokens[0].Term)).SetField(field), nil
+	}
+	boolQuery := bluge.NewBooleanQuery()
+	for _, token := range tokens[:len(tokens)-1] {
+		boolQuery.AddMust(bluge.NewTermQuery(string(token.Term)).SetField(field))
+	}
+	boolQuery.AddMust(bluge.NewPrefixQuery(string(tokens[len(tokens)-1].Term)).SetField(field))
+	return boolQuery, nil
 }
@@ -0,0 +1,218 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+func SpanTermQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Query, error) {
+	return spanTermQuery(query, mappings)
+}
+
+func SpanNearQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Query, error) {
+	return spanNearQuery(query, mappings)
+}
+
+func SpanFirstQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Query, error) {
+	return spanFirstQuery(query, mappings)
+}
+
+// spanQuery parses a span query used as a clause of another span query
+func spanQuery(query interface{}, mappings *meta.Mappings) (zincquery.SpanQuery, error) {
+	q, ok := query.(map[string]interface{})
+	if !ok || len(q) != 1 {
+		return nil, errors.New(errors.ErrorTypeParsingException, "span query clause should be an object with a single span query")
+	}
+	for k, v := range q {
+		k := strings.ToLower(k)
+		vv, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[%s] query doesn't support value type %T", k, v))
+		}
+		switch k {
+		case "span_term":
+			return spanTermQuery(vv, mappings)
+		case "span_near":
+			return spanNearQuery(vv, mappings)
+		case "span_first":
+			return spanFirstQuery(vv, mappings)
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("spanNear [clauses] must be of type span query, got [%s]", k))
+		}
+	}
+	return nil, nil
+}
+
+func spanTermQuery(query map[string]interface{}, mappings *meta.Mappings) (*zincquery.SpanTermQuery, error) {
+	if len(query) != 1 {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[span_term] query should have exactly one field")
+	}
+
+	var field, term string
+	var boost interface{}
+	for k, v := range query {
+		field = k
+		switch v := v.(type) {
+		case string:
+			term = v
+		case map[string]interface{}:
+			for k, v := range v {
+				k := strings.ToLower(k)
+				switch k {
+				case "value", "term":
+					term, _ = zutils.ToString(v)
+				case "boost":
+					boost = v
+				default:
+					return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[span_term] unknown field [%s]", k))
+				}
+			}
+		default:
+			term, _ = zutils.ToString(v)
+		}
+	}
+	if err := spanFieldHasPositions(field, mappings); err != nil {
+		return nil, err
+	}
+
+	spanQuery := zincquery.NewSpanTermQuery(field, term)
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_term] boost should be a number").Cause(err)
+		}
+		spanQuery.SetBoost(v)
+	}
+	return spanQuery, nil
+}
+
+func spanNearQuery(query map[string]interface{}, mappings *meta.Mappings) (*zincquery.SpanNearQuery, error) {
+	var clauses []zincquery.SpanQuery
+	var slop int
+	inOrder := true
+	var boost interface{}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "clauses":
+			vv, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[span_near] clauses doesn't support values of type: %T", v))
+			}
+			for _, clause := range vv {
+				q, err := spanQuery(clause, mappings)
+				if err != nil {
+					return nil, err
+				}
+				clauses = append(clauses, q)
+			}
+		case "slop":
+			if slop, err = zutils.ToInt(v); err != nil || slop < 0 {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_near] slop should be a non-negative number")
+			}
+		case "in_order":
+			vv, ok := v.(bool)
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[span_near] in_order doesn't support values of type: %T", v))
+			}
+			inOrder = vv
+		case "boost":
+			boost = v
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[span_near] unknown field [%s]", k))
+		}
+	}
+	if len(clauses) == 0 {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[span_near] must include [clauses]")
+	}
+	for _, clause := range clauses[1:] {
+		if clause.Field() != clauses[0].Field() {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[span_near] clauses must have same field")
+		}
+	}
+
+	spanQuery := zincquery.NewSpanNearQuery(clauses, slop, inOrder)
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_near] boost should be a number").Cause(err)
+		}
+		spanQuery.SetBoost(v)
+	}
+	return spanQuery, nil
+}
+
+func spanFirstQuery(query map[string]interface{}, mappings *meta.Mappings) (*zincquery.SpanFirstQuery, error) {
+	var match zincquery.SpanQuery
+	end := -1
+	var boost interface{}
+	var err error
+	for k, v := range query {
+		k := strings.ToLower(k)
+		switch k {
+		case "match":
+			if match, err = spanQuery(v, mappings); err != nil {
+				return nil, err
+			}
+		case "end":
+			if end, err = zutils.ToInt(v); err != nil || end < 0 {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_first] end should be a non-negative number")
+			}
+		case "boost":
+			boost = v
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[span_first] unknown field [%s]", k))
+		}
+	}
+	if match == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[span_first] must have [match] span query clause")
+	}
+	if end < 0 {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[span_first] must have [end] set for it")
+	}
+
+	spanQuery := zincquery.NewSpanFirstQuery(match, end)
+	if boost != nil {
+		v, err := zutils.ToFloat64(boost)
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[span_first] boost should be a number").Cause(err)
+		}
+		spanQuery.SetBoost(v)
+	}
+	return spanQuery, nil
+}
+
+// spanFieldHasPositions checks the field is indexed with positions, only text fields have them
+func spanFieldHasPositions(field string, mappings *meta.Mappings) error {
+	prop, ok := mappings.GetProperty(field)
+	if !ok {
+		return nil // not mapped, matches nothing
+	}
+	if prop.Type != "text" || !prop.Index {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("field [%s] was indexed without position data; cannot run span queries", field))
+	}
+	return nil
+}
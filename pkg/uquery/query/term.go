@@ -24,6 +24,7 @@ import (
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
@@ -65,6 +66,13 @@ func TermQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Que
 
 	// TODO: case_insensitive support
 
+	if f, ok, err := runtime.Compile(field, mappings); ok {
+		if err != nil {
+			return nil, err
+		}
+		return RuntimeTermsQuery(f, []interface{}{value.Value}, value.Boost)
+	}
+
 	prop, _ := mappings.GetProperty(field)
 	switch prop.Type {
 	case "numeric":
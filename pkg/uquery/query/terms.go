@@ -23,6 +23,7 @@ import (
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/runtime"
 )
 
 func TermsQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Query, error) {
@@ -72,6 +73,26 @@ func TermsQuery(query map[string]interface{}, mappings *meta.Mappings) (bluge.Qu
 		}
 	}
 
+	if f, ok, err := runtime.Compile(field, mappings); ok {
+		if err != nil {
+			return nil, err
+		}
+		terms := make([]interface{}, 0, len(values)+len(valueFloat)+len(valueInts)+len(valueBools))
+		for _, v := range values {
+			terms = append(terms, v)
+		}
+		for _, v := range valueFloat {
+			terms = append(terms, v)
+		}
+		for _, v := range valueInts {
+			terms = append(terms, v)
+		}
+		for _, v := range valueBools {
+			terms = append(terms, v)
+		}
+		return RuntimeTermsQuery(f, terms, boost)
+	}
+
 	subq := bluge.NewBooleanQuery()
 	for _, term := range values {
 		subqq, err := TermQueryText(field, &meta.TermQuery{Value: term})
@@ -31,6 +31,7 @@ func ParseQueryDSLFromV1(q *v1.ZincQuery) (*meta.ZincQuery, error) {
 	newquery.Explain = q.Explain
 	newquery.Highlight = q.Highlight
 	newquery.Source = q.Source
+	newquery.SearchAfter = q.SearchAfter
 
 	if q.SortFields != nil {
 		sort := make([]interface{}, 0, len(q.SortFields))
@@ -17,40 +17,68 @@ package uquery
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/analysis"
 	"github.com/blugelabs/bluge/search"
 
+	"github.com/zincsearch/zincsearch/pkg/bluge/profile"
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
 	"github.com/zincsearch/zincsearch/pkg/config"
 	"github.com/zincsearch/zincsearch/pkg/errors"
 	"github.com/zincsearch/zincsearch/pkg/meta"
 	"github.com/zincsearch/zincsearch/pkg/uquery/aggregation"
+	"github.com/zincsearch/zincsearch/pkg/uquery/collapse"
 	"github.com/zincsearch/zincsearch/pkg/uquery/fields"
 	"github.com/zincsearch/zincsearch/pkg/uquery/highlight"
 	"github.com/zincsearch/zincsearch/pkg/uquery/query"
+	"github.com/zincsearch/zincsearch/pkg/uquery/rescore"
 	"github.com/zincsearch/zincsearch/pkg/uquery/sort"
 	"github.com/zincsearch/zincsearch/pkg/uquery/source"
+	"github.com/zincsearch/zincsearch/pkg/uquery/suggest"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 // ParseQueryDSL parse query DSL and return searchRequest
 func ParseQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.SearchRequest, error) {
+	return ParseProfileQueryDSL(q, mappings, analyzers, nil)
+}
+
+// ParseProfileQueryDSL parse query DSL and return searchRequest, the query is wrapped for profiling if shard is not nil
+func ParseProfileQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer, shard *profile.Shard) (bluge.SearchRequest, error) {
+	return ParseBoostedQueryDSL(q, mappings, analyzers, shard, 1)
+}
+
+// ParseBoostedQueryDSL parse query DSL and return searchRequest whose scores are multiplied by the boost
+func ParseBoostedQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer, shard *profile.Shard, boost float64) (bluge.SearchRequest, error) {
 	// parse size
 	if q.Size > config.Global.MaxResults {
 		q.Size = config.Global.MaxResults
 	}
 
 	// parse query
-	query, err := query.Query(q.Query, mappings, analyzers)
+	query, err := ParseQuery(q, mappings, analyzers)
 	if err != nil {
 		return nil, err
 	}
-	if query == nil {
-		return nil, errors.New(errors.ErrorTypeNotImplemented, fmt.Sprintf("[%s] query doesn't support", q.Query))
+
+	// parse indices_boost
+	if _, err = IndicesBoost(q.IndicesBoost); err != nil {
+		return nil, err
+	}
+	if boost != 1 {
+		query = zincquery.NewFunctionScoreQuery(query).SetBoost(boost)
+	}
+
+	// parse min_score
+	if q.MinScore > 0 {
+		query = zincquery.NewMinScoreQuery(query, q.MinScore)
 	}
 
 	// create search request
-	request := bluge.NewTopNSearch(q.Size, query).WithStandardAggregations()
+	request := bluge.NewTopNSearch(q.Size, shard.WrapQuery(query)).WithStandardAggregations()
 
 	// parse highlight
 	if q.Highlight != nil {
@@ -58,6 +86,16 @@ func ParseQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[str
 		request.IncludeLocations()
 	}
 
+	// parse track_total_hits
+	if _, err = TrackTotalHits(q.TrackTotalHits); err != nil {
+		return nil, err
+	}
+
+	// parse timeout
+	if _, err = Timeout(q.Timeout); err != nil {
+		return nil, err
+	}
+
 	// parse from
 	if q.From > 0 {
 		request.SetFrom(q.From)
@@ -70,17 +108,48 @@ func ParseQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[str
 
 	// parse aggregations
 	if q.Aggregations != nil {
-		if err := aggregation.Request(request, q.Aggregations, mappings); err != nil {
+		if err := aggregation.Request(q.Context, request, q.Aggregations, mappings); err != nil {
 			return nil, err
 		}
 	}
 
 	// parse fields
 	if q.Fields != nil {
-		if v, ok := q.Fields.([]interface{}); ok {
+		switch v := q.Fields.(type) {
+		case []interface{}:
 			if q.Fields, err = fields.Request(v); err != nil {
 				return nil, err
 			}
+		case []*meta.Field:
+		default:
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[fields] value should be array")
+		}
+	}
+
+	// parse docvalue_fields, merge into fields
+	if q.DocValueFields != nil {
+		v, ok := q.DocValueFields.([]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeXContentParseException, "[docvalue_fields] value should be array")
+		}
+		docValueFields, err := fields.Request(v)
+		if err != nil {
+			return nil, err
+		}
+		if q.Fields == nil {
+			q.Fields = make([]*meta.Field, 0, len(docValueFields))
+		}
+		q.Fields = append(q.Fields.([]*meta.Field), docValueFields...)
+		q.DocValueFields = nil
+	}
+
+	// parse stored_fields, the source isn't returned with them unless it is requested
+	if q.StoredFields != nil {
+		if q.StoredFields, err = fields.StoredRequest(q.StoredFields); err != nil {
+			return nil, err
+		}
+		if q.Source == nil {
+			q.Source = false
 		}
 	}
 
@@ -99,8 +168,199 @@ func ParseQueryDSL(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[str
 		}
 	}
 
-	// pagenation
-	// TODO: search after PIT support
+	// parse rescore
+	if q.Rescore != nil {
+		if sorts, _ := q.Sort.(search.SortOrder); len(sorts) > 1 || len(sorts) == 1 && len(sorts[0].Fields()) > 0 {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "cannot use [sort] option in conjunction with [rescore]")
+		}
+		if q.Collapse != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "cannot use [collapse] in conjunction with [rescore]")
+		}
+		if q.SearchAfter != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[search_after] cannot be used in conjunction with [rescore]")
+		}
+		if _, err := rescore.Request(q.Rescore, mappings, analyzers); err != nil {
+			return nil, err
+		}
+	}
+
+	// parse collapse
+	if q.Collapse != nil {
+		if err := collapse.Request(request, q.Collapse, mappings); err != nil {
+			return nil, err
+		}
+	}
+
+	// parse suggest
+	if q.Suggest != nil {
+		if err := suggest.Request(q.Suggest); err != nil {
+			return nil, err
+		}
+	}
+
+	// parse search_after
+	if q.SearchAfter != nil {
+		if q.From > 0 {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[from] parameter must be set to 0 when [search_after] is used")
+		}
+		sorts, _ := q.Sort.(search.SortOrder)
+		after, err := sort.SearchAfter(q.SearchAfter, sorts, mappings)
+		if err != nil {
+			return nil, err
+		}
+		request.After(after)
+	}
+
+	return request, nil
+}
+
+// TrackTotalHits returns how many hits are counted exactly, -1 counts all of them
+func TrackTotalHits(v interface{}) (int, error) {
+	switch v := v.(type) {
+	case nil:
+		if config.Global.TrackTotalHits > 0 {
+			return config.Global.TrackTotalHits, nil
+		}
+		return -1, nil
+	case bool:
+		if v {
+			return -1, nil
+		}
+		return 0, nil
+	case float64:
+		if v < 0 {
+			return 0, errors.New(errors.ErrorTypeIllegalArgumentException, "[track_total_hits] must be positive")
+		}
+		return int(v), nil
+	case int:
+		if v < 0 {
+			return 0, errors.New(errors.ErrorTypeIllegalArgumentException, "[track_total_hits] must be positive")
+		}
+		return v, nil
+	default:
+		return 0, errors.New(errors.ErrorTypeXContentParseException, "[track_total_hits] value should be boolean or integer")
+	}
+}
+
+// Timeout returns the timeout of the search, it is a time value like "2s" or the seconds as a number.
+// The default timeout of the searches is used without it or with 0, and -1 disables the timeout
+func Timeout(v interface{}) (time.Duration, error) {
+	switch v := v.(type) {
+	case nil:
+		return config.Global.SearchTimeout, nil
+	case float64:
+		return Timeout(time.Duration(v * float64(time.Second)))
+	case int:
+		return Timeout(time.Duration(v) * time.Second)
+	case time.Duration:
+		if v == 0 {
+			return config.Global.SearchTimeout, nil
+		}
+		if v < 0 {
+			return 0, nil
+		}
+		return v, nil
+	case string:
+		if v == "-1" {
+			return 0, nil
+		}
+		// a time value needs a unit, the numbers are nanoseconds for zutils.ParseDuration
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil && v != "0" {
+			return 0, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[timeout] failed to parse [%s] as a time value, unit is missing", v))
+		}
+		d, err := zutils.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[timeout] failed to parse [%s] as a time value", v))
+		}
+		return Timeout(d)
+	default:
+		return 0, errors.New(errors.ErrorTypeXContentParseException, "[timeout] value should be a time value or a number of seconds")
+	}
+}
+
+// IndicesBoost returns the boosts of the indices_boost in order,
+// it is either an array of objects with one index each or an object
+func IndicesBoost(v interface{}) ([]meta.IndexBoost, error) {
+	parse := func(index string, v interface{}) (meta.IndexBoost, error) {
+		boost, ok := v.(float64)
+		if !ok {
+			return meta.IndexBoost{}, errors.New(errors.ErrorTypeXContentParseException, fmt.Sprintf("[indices_boost] boost of [%s] should be a number", index))
+		}
+		if boost < 0 {
+			return meta.IndexBoost{}, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[indices_boost] boost of [%s] must be positive", index))
+		}
+		return meta.IndexBoost{Index: index, Boost: boost}, nil
+	}
+
+	var boosts []meta.IndexBoost
+	switch v := v.(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range v {
+			item, ok := item.(map[string]interface{})
+			if !ok || len(item) != 1 {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[indices_boost] array items should be objects with one index")
+			}
+			for index, vv := range item {
+				boost, err := parse(index, vv)
+				if err != nil {
+					return nil, err
+				}
+				boosts = append(boosts, boost)
+			}
+		}
+	case map[string]interface{}:
+		for index, vv := range v {
+			boost, err := parse(index, vv)
+			if err != nil {
+				return nil, err
+			}
+			boosts = append(boosts, boost)
+		}
+	default:
+		return nil, errors.New(errors.ErrorTypeXContentParseException, "[indices_boost] value should be array or object")
+	}
+	return boosts, nil
+}
+
+// ParseExplainQuery parse query DSL and return searchRequest which only matches the document
+func ParseExplainQuery(q *meta.ZincQuery, docID string, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.SearchRequest, error) {
+	query, err := ParseQuery(q, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
 
+	filter := bluge.NewTermQuery(docID).SetField("_id")
+	request := bluge.NewTopNSearch(1, zincquery.NewFilteredQuery(query, filter)).ExplainScores()
 	return request, nil
 }
+
+// ParseQuery parse the query part of query DSL and return bluge query
+func ParseQuery(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	subq, err := query.Query(q.Query, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	if subq == nil {
+		return nil, errors.New(errors.ErrorTypeNotImplemented, fmt.Sprintf("[%s] query doesn't support", q.Query))
+	}
+	// the hidden nested documents only match through the nested query
+	if len(mappings.NestedPaths()) > 0 {
+		subq = bluge.NewBooleanQuery().AddMust(subq).AddMustNot(zincquery.NestedDocumentsQuery())
+	}
+	return subq, nil
+}
+
+// ParseNamedQueries returns the named queries of the query
+func ParseNamedQueries(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) ([]*zincquery.NamedQuery, error) {
+	subq, err := query.Query(q.Query, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	return zincquery.NamedQueries(subq), nil
+}
+
+// ParseNestedInnerHits returns the nested queries of the query which request inner hits
+func ParseNestedInnerHits(q *meta.ZincQuery, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) ([]*query.NestedInnerHits, error) {
+	return query.NestedInnerHitsQueries(q.Query, mappings, analyzers)
+}
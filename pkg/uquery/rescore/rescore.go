@@ -0,0 +1,96 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package rescore
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/query"
+)
+
+const DefaultWindowSize = 10
+
+const (
+	ScoreModeTotal    = "total"
+	ScoreModeMultiply = "multiply"
+	ScoreModeAvg      = "avg"
+	ScoreModeMax      = "max"
+	ScoreModeMin      = "min"
+)
+
+// Request checks the rescore, fills the defaults and returns the rescore query
+func Request(rescore *meta.Rescore, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	if rescore.Query == nil || rescore.Query.RescoreQuery == nil {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[rescore] requires [rescore_query]")
+	}
+	if rescore.WindowSize < 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[rescore] window_size must be positive")
+	}
+	if rescore.WindowSize == 0 {
+		rescore.WindowSize = DefaultWindowSize
+	}
+	if rescore.Query.QueryWeight == nil {
+		weight := 1.0
+		rescore.Query.QueryWeight = &weight
+	}
+	if rescore.Query.RescoreQueryWeight == nil {
+		weight := 1.0
+		rescore.Query.RescoreQueryWeight = &weight
+	}
+	rescore.Query.ScoreMode = strings.ToLower(rescore.Query.ScoreMode)
+	switch rescore.Query.ScoreMode {
+	case "":
+		rescore.Query.ScoreMode = ScoreModeTotal
+	case ScoreModeTotal, ScoreModeMultiply, ScoreModeAvg, ScoreModeMax, ScoreModeMin:
+	default:
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[rescore] illegal score_mode [%s]", rescore.Query.ScoreMode))
+	}
+
+	q, err := query.Query(rescore.Query.RescoreQuery, mappings, analyzers)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeXContentParseException, "[rescore] failed to parse [rescore_query]").Cause(err)
+	}
+	return q, nil
+}
+
+// Score combines the score of the query with the score of the rescore query,
+// the weighted score of the query is kept if the rescore query doesn't match
+func Score(rescore *meta.Rescore, score, rescoreScore float64, matched bool) float64 {
+	score *= *rescore.Query.QueryWeight
+	if !matched {
+		return score
+	}
+	rescoreScore *= *rescore.Query.RescoreQueryWeight
+	switch rescore.Query.ScoreMode {
+	case ScoreModeMultiply:
+		return score * rescoreScore
+	case ScoreModeAvg:
+		return (score + rescoreScore) / 2
+	case ScoreModeMax:
+		return math.Max(score, rescoreScore)
+	case ScoreModeMin:
+		return math.Min(score, rescoreScore)
+	default:
+		return score + rescoreScore
+	}
+}
@@ -0,0 +1,372 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge/search"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
+)
+
+const (
+	TypeKeyword = "keyword"
+	TypeLong    = "long"
+	TypeDouble  = "double"
+	TypeDate    = "date"
+	TypeBoolean = "boolean"
+)
+
+// Request parses the runtime fields of the mappings, e.g.
+//
+//	{"full_name": {"type": "keyword", "script": "doc['first'].value + ' ' + doc['last'].value"}}
+//
+// the script can also be an object with source and numeric params
+func Request(data interface{}) (map[string]meta.RuntimeField, error) {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrorTypeParsingException, "[mappings] runtime should be an object")
+	}
+
+	runtime := make(map[string]meta.RuntimeField, len(fields))
+	for name, v := range fields {
+		v, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] should be an object", name))
+		}
+		field := meta.RuntimeField{}
+		for k, v := range v {
+			switch strings.ToLower(k) {
+			case "type":
+				typ, _ := v.(string)
+				switch typ = strings.ToLower(typ); typ {
+				case TypeKeyword, TypeLong, TypeDouble, TypeDate, TypeBoolean:
+					field.Type = typ
+				default:
+					return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] doesn't support type [%v]", name, v))
+				}
+			case "script":
+				script, err := parseScript(name, v)
+				if err != nil {
+					return nil, err
+				}
+				field.Script = script
+			default:
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] unknown field [%s]", name, k))
+			}
+		}
+		if field.Type == "" {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] type should be defined", name))
+		}
+		if field.Script == nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] script should be defined", name))
+		}
+		if err := zincquery.ValidateScript(field.Script.Source); err != nil {
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] compile error", name)).Cause(err)
+		}
+		runtime[name] = field
+	}
+
+	return runtime, nil
+}
+
+func parseScript(name string, v interface{}) (*meta.Script, error) {
+	script := new(meta.Script)
+	switch v := v.(type) {
+	case string:
+		script.Source = v
+	case map[string]interface{}:
+		for k, vv := range v {
+			switch strings.ToLower(k) {
+			case "source":
+				script.Source, _ = vv.(string)
+			case "lang":
+				script.Lang, _ = vv.(string)
+				if script.Lang != "painless" && script.Lang != "expression" {
+					return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[mappings] runtime [%s] unsupported lang [%s]", name, script.Lang))
+				}
+			case "params":
+				params, ok := vv.(map[string]interface{})
+				if !ok {
+					return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] params should be an object", name))
+				}
+				for param, value := range params {
+					if _, err := zutils.ToFloat64(value); err != nil {
+						return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[mappings] runtime [%s] param [%s] should be a number", name, param))
+					}
+				}
+				script.Params = params
+			default:
+				return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] script unknown field [%s]", name, k))
+			}
+		}
+	default:
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] script should be a string or an object", name))
+	}
+	if script.Source == "" {
+		return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[mappings] runtime [%s] script source should be defined", name))
+	}
+	return script, nil
+}
+
+// Field is a runtime field compiled against the mappings of the index
+type Field struct {
+	name   string
+	typ    string
+	script *zincquery.Script
+	fields []string
+}
+
+// Compile returns the compiled runtime field, ok is false if the field is not a runtime field
+func Compile(name string, mappings *meta.Mappings) (*Field, bool, error) {
+	if mappings == nil {
+		return nil, false, nil
+	}
+	runtime, ok := mappings.GetRuntime(name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	params := make(map[string]float64, len(runtime.Script.Params))
+	for k, v := range runtime.Script.Params {
+		params[k], _ = zutils.ToFloat64(v)
+	}
+	script, err := zincquery.NewValueScript(runtime.Script.Source, params, func(field string) string {
+		prop, ok := mappings.GetProperty(field)
+		if !ok {
+			return ""
+		}
+		switch prop.Type {
+		case "date", "time":
+			return zincquery.FieldTypeDate
+		default:
+			return prop.Type
+		}
+	})
+	if err != nil {
+		return nil, true, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("runtime field [%s] compile error", name)).Cause(err)
+	}
+	if script.IsText() && runtime.Type != TypeKeyword {
+		return nil, true, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("runtime field [%s] of type [%s] should not return a string", name, runtime.Type))
+	}
+
+	return &Field{name: name, typ: runtime.Type, script: script, fields: script.Fields()}, true, nil
+}
+
+func (f *Field) Name() string {
+	return f.name
+}
+
+func (f *Field) Type() string {
+	return f.typ
+}
+
+// Fields returns the fields read by the script
+func (f *Field) Fields() []string {
+	return f.fields
+}
+
+// Value computes the value of the document, the field has no value if one of
+// the fields read by the script is missing in the document
+func (f *Field) Value(values zincquery.DocValues) (interface{}, bool) {
+	for _, field := range f.fields {
+		if len(values[field]) == 0 {
+			return nil, false
+		}
+	}
+	if f.typ == TypeKeyword {
+		return f.script.Text(0, values), true
+	}
+	v := f.script.Eval(0, values)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, false
+	}
+	switch f.typ {
+	case TypeLong:
+		return int64(v), true
+	case TypeDate:
+		return time.UnixMilli(int64(v)).UTC(), true
+	case TypeBoolean:
+		return v != 0, true
+	default:
+		return v, true
+	}
+}
+
+// Parse converts the value of a query to the type of the field
+func (f *Field) Parse(v interface{}) (interface{}, error) {
+	var value interface{}
+	var err error
+	switch f.typ {
+	case TypeKeyword:
+		value, err = zutils.ToString(v)
+	case TypeLong:
+		var n float64
+		n, err = zutils.ToFloat64(v)
+		value = int64(n)
+	case TypeDouble:
+		value, err = zutils.ToFloat64(v)
+	case TypeDate:
+		value, err = zutils.ParseTime(v, "", "")
+	case TypeBoolean:
+		value, err = zutils.ToBool(v)
+	}
+	if err != nil {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("runtime field [%s] of type [%s] can't parse value [%v]", f.name, f.typ, v)).Cause(err)
+	}
+	return value, nil
+}
+
+// Compare returns -1, 0 or 1 if a is less than, equal to or greater than b,
+// both values should be of the type of the field
+func (f *Field) Compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case int64:
+		return compare(a, b.(int64))
+	case float64:
+		return compare(a, b.(float64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case bool:
+		return compare(boolNumber(a), boolNumber(b.(bool)))
+	}
+	return 0
+}
+
+func compare[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolNumber(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// Format returns the value of the field in the search response
+func (f *Field) Format(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// Source returns the value source of the field for aggregations
+func (f *Field) Source() *Source {
+	return &Source{field: f}
+}
+
+// Source computes the runtime field from the doc values of the matched document,
+// it is a text and numeric value source of bluge aggregations
+type Source struct {
+	field *Field
+}
+
+func (s *Source) Fields() []string {
+	return s.field.fields
+}
+
+func (s *Source) value(match *search.DocumentMatch) (interface{}, bool) {
+	values := make(zincquery.DocValues, len(s.field.fields))
+	for _, field := range s.field.fields {
+		values[field] = match.DocValues(field)
+	}
+	return s.field.Value(values)
+}
+
+func (s *Source) Value(match *search.DocumentMatch) []byte {
+	v, ok := s.value(match)
+	if !ok {
+		return nil
+	}
+	switch v := v.(type) {
+	case string:
+		return []byte(v)
+	case int64:
+		return []byte(strconv.FormatInt(v, 10))
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64))
+	case time.Time:
+		return []byte(v.Format(time.RFC3339Nano))
+	case bool:
+		return []byte(strconv.FormatBool(v))
+	}
+	return nil
+}
+
+func (s *Source) Values(match *search.DocumentMatch) [][]byte {
+	v := s.Value(match)
+	if v == nil {
+		return nil
+	}
+	return [][]byte{v}
+}
+
+func (s *Source) Number(match *search.DocumentMatch) float64 {
+	v, _ := s.value(match)
+	n, _ := number(v)
+	return n
+}
+
+func (s *Source) Numbers(match *search.DocumentMatch) []float64 {
+	v, _ := s.value(match)
+	n, ok := number(v)
+	if !ok {
+		return nil
+	}
+	return []float64{n}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case time.Time:
+		return float64(v.UnixMilli()), true
+	case bool:
+		return float64(boolNumber(v)), true
+	}
+	return 0, false
+}
+
+func (s *Source) Dates(match *search.DocumentMatch) []time.Time {
+	v, ok := s.value(match)
+	if !ok {
+		return nil
+	}
+	if t, ok := v.(time.Time); ok {
+		return []time.Time{t}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package security
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/query"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// DocumentFilter returns the query of the documents of the index visible to the privileges,
+// a document is visible if it matches any of the matching privileges, nil means all the documents are visible
+func DocumentFilter(privileges []*meta.RoleIndices, index string) interface{} {
+	var filters []interface{}
+	for _, privilege := range privileges {
+		if !MatchIndex(privilege.Names, index) {
+			continue
+		}
+		if privilege.Query == nil {
+			return nil
+		}
+		filters = append(filters, documentQuery(privilege.Query))
+	}
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0]
+	default:
+		return map[string]interface{}{
+			"bool": map[string]interface{}{"should": filters, "minimum_should_match": 1},
+		}
+	}
+}
+
+// documentQuery returns the query of the privilege, the query can be an object or a json string
+func documentQuery(query interface{}) interface{} {
+	if s, ok := query.(string); ok {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			// match nothing rather than everything for the invalid queries
+			return map[string]interface{}{"match_none": map[string]interface{}{}}
+		}
+		return v
+	}
+	return toGeneric(query)
+}
+
+// MultiDocumentFilter returns the document filter shared by the indexes, the filter is
+// applied to the whole search so the indexes with different filters can't be searched together
+func MultiDocumentFilter(privileges []*meta.RoleIndices, indexes []string) (interface{}, error) {
+	if privileges == nil || len(indexes) == 0 {
+		return nil, nil
+	}
+	filter := DocumentFilter(privileges, indexes[0])
+	for _, index := range indexes[1:] {
+		if !reflect.DeepEqual(filter, DocumentFilter(privileges, index)) {
+			sort.Strings(indexes)
+			return nil, errors.New(errors.ErrorTypeSecurityException,
+				fmt.Sprintf("the indexes [%s] have different document level security, search them separately", strings.Join(indexes, ", ")))
+		}
+	}
+	return filter, nil
+}
+
+// DocumentQuery returns the parsed document filter for the searches which don't go through the query,
+// like the documents of the completion suggester, nil means all the documents are visible
+func DocumentQuery(filter interface{}, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (bluge.Query, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	return query.Query(filter, mappings, analyzers)
+}
+
+// Restrict adds the filter to the query as a mandatory filter, the documents not matching it
+// are neither returned nor aggregated nor counted
+func Restrict(query *meta.ZincQuery, filter interface{}) {
+	if filter == nil {
+		return
+	}
+	restricted := map[string]interface{}{"filter": []interface{}{filter}}
+	if query.Query != nil {
+		restricted["must"] = []interface{}{toGeneric(query.Query)}
+	}
+	query.Query = map[string]interface{}{"bool": restricted}
+}
@@ -0,0 +1,107 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package security
+
+import (
+	"path"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// AllField is the field containing the values of all the fields
+const AllField = "_all"
+
+// Fields are the field security rules of an index, nil allows all the fields
+type Fields struct {
+	rules []*meta.FieldSecurity
+}
+
+// FieldRules returns the field security of the privileges matching the index,
+// a field is visible if any of the matching privileges grants it
+func FieldRules(privileges []*meta.RoleIndices, index string) *Fields {
+	var rules []*meta.FieldSecurity
+	for _, privilege := range privileges {
+		if !MatchIndex(privilege.Names, index) {
+			continue
+		}
+		if privilege.FieldSecurity == nil {
+			return nil
+		}
+		rules = append(rules, privilege.FieldSecurity)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Fields{rules: rules}
+}
+
+// MatchIndex returns whether the index matches any of the names
+func MatchIndex(names []string, index string) bool {
+	for _, name := range names {
+		if ok, _ := path.Match(name, index); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unrestricted returns whether all the fields are visible
+func (f *Fields) Unrestricted() bool {
+	if f == nil {
+		return true
+	}
+	for _, rule := range f.rules {
+		if len(rule.Except) == 0 && matchField(rule.Grant, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed returns whether the field is visible, the sub fields inherit the rules of the parents,
+// the _all field is only visible without restrictions as it contains all the fields
+func (f *Fields) Allowed(field string) bool {
+	if f.Unrestricted() {
+		return true
+	}
+	if field == AllField {
+		return false
+	}
+	for _, rule := range f.rules {
+		if matchField(rule.Grant, field) && !matchField(rule.Except, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField returns whether the field or one of its parents matches the patterns
+func matchField(patterns []string, field string) bool {
+	for _, pattern := range patterns {
+		name := field
+		for {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+			i := strings.LastIndexByte(name, '.')
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+	return false
+}
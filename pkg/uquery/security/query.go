@@ -0,0 +1,290 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package security
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	zincquery "github.com/zincsearch/zincsearch/pkg/bluge/query"
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/uquery/query"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+// fieldQueries are the queries keyed by the field name, like {"term": {"name": "zinc"}}
+var fieldQueries = map[string]bool{
+	"match":               true,
+	"match_bool_prefix":   true,
+	"match_phrase":        true,
+	"match_phrase_prefix": true,
+	"range":               true,
+	"regexp":              true,
+	"prefix":              true,
+	"fuzzy":               true,
+	"wildcard":            true,
+	"term":                true,
+	"terms":               true,
+	"terms_set":           true,
+	"span_term":           true,
+	"geo_distance":        true,
+	"geo_bounding_box":    true,
+	"geo_polygon":         true,
+	"geo_shape":           true,
+}
+
+// fieldQueryOptions are the options of the field queries which are not fields
+var fieldQueryOptions = map[string]bool{
+	"boost":             true,
+	"_name":             true,
+	"distance":          true,
+	"distance_type":     true,
+	"validation_method": true,
+	"ignore_unmapped":   true,
+}
+
+var (
+	queryStringFieldRe = regexp.MustCompile(`(?:^|[\s(+\-!])([\w.*?@]+):`)
+	queryStringExistRe = regexp.MustCompile(`_exists_:([\w.*?@]+)`)
+)
+
+// Check returns a security exception if the query, the aggregations, the sort, the collapse
+// or the suggest of the query use a field which is not visible, the field names with wildcards
+// are expanded to the mapped fields. The returned fields and the highlights are filtered by Hit.
+func Check(query *meta.ZincQuery, fields *Fields, mappings *meta.Mappings) error {
+	if fields.Unrestricted() {
+		return nil
+	}
+
+	c := &checker{fields: fields, mappings: mappings}
+	c.query(toGeneric(query.Query))
+	if query.Rescore != nil {
+		c.query(toGeneric(query.Rescore))
+	}
+	c.aggregations(toGeneric(query.Aggregations))
+	c.sort(toGeneric(query.Sort))
+	if query.Collapse != nil {
+		c.field(query.Collapse.Field)
+	}
+	for _, suggest := range query.Suggest {
+		c.aggregations(toGeneric(suggest))
+	}
+
+	if c.denied != "" {
+		return errors.New(errors.ErrorTypeSecurityException, fmt.Sprintf("action [search] is unauthorized for the field [%s]", c.denied))
+	}
+	return nil
+}
+
+// toGeneric converts the typed values of the query to the json values
+func toGeneric(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, map[string]interface{}, []interface{}, string:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var rv interface{}
+	if err := json.Unmarshal(data, &rv); err != nil {
+		return nil
+	}
+	return rv
+}
+
+type checker struct {
+	fields   *Fields
+	mappings *meta.Mappings
+	denied   string
+}
+
+// field checks the field, the wildcards are expanded to the mapped fields
+func (c *checker) field(name string) {
+	if c.denied != "" || name == "" {
+		return
+	}
+	if i := strings.LastIndex(name, "^"); i > 0 {
+		name = name[:i]
+	}
+	if !strings.ContainsAny(name, "*?[") {
+		if !c.fields.Allowed(name) {
+			c.denied = name
+		}
+		return
+	}
+	if c.mappings == nil {
+		c.denied = name
+		return
+	}
+	for field := range c.mappings.ListProperty() {
+		if ok, _ := path.Match(name, field); ok && !c.fields.Allowed(field) {
+			c.denied = field
+			return
+		}
+	}
+}
+
+func (c *checker) query(v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, vv := range v {
+			c.query(vv)
+		}
+	case map[string]interface{}:
+		for k, vv := range v {
+			switch {
+			case fieldQueries[k]:
+				if m, ok := vv.(map[string]interface{}); ok {
+					for field := range m {
+						if !fieldQueryOptions[field] {
+							c.field(field)
+						}
+					}
+				}
+			case k == "query_string" || k == "simple_query_string":
+				c.queryString(vv)
+			case k == "multi_match":
+				// multi_match without fields searches all the fields like query_string
+				if m, ok := vv.(map[string]interface{}); ok {
+					if _, ok := m["fields"]; !ok {
+						c.field(AllField)
+					}
+					c.query(m)
+				}
+			case k == "field" || k == "default_field":
+				if s, ok := vv.(string); ok {
+					c.field(s)
+				}
+			case k == "fields":
+				c.queryFields(vv)
+			case k == "script":
+				c.script(vv)
+			default:
+				c.query(vv)
+			}
+		}
+	}
+}
+
+func (c *checker) queryString(v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	_, hasFields := m["fields"]
+	_, hasDefault := m["default_field"]
+	if !hasFields && !hasDefault {
+		c.field(AllField)
+	}
+	c.query(m)
+	if s, ok := m["query"].(string); ok {
+		for _, match := range queryStringFieldRe.FindAllStringSubmatch(s, -1) {
+			if match[1] != "_exists_" {
+				c.field(match[1])
+			}
+		}
+		for _, match := range queryStringExistRe.FindAllStringSubmatch(s, -1) {
+			c.field(match[1])
+		}
+	}
+}
+
+// script checks the fields read by the script, a stored script is loaded by id.
+// The script of the script query and the script sort is nested in the script key.
+func (c *checker) script(v interface{}) {
+	if m, ok := v.(map[string]interface{}); ok {
+		if vv, ok := m["script"]; ok {
+			c.script(vv)
+			return
+		}
+	}
+	source, _, err := query.ScriptSource(v)
+	if err != nil || source == "" {
+		return
+	}
+	// the script failing to compile fails the query too
+	fields, _ := zincquery.ScriptFields(source)
+	for _, field := range fields {
+		c.field(field)
+	}
+}
+
+// aggregations checks the fields of the aggregations and the suggesters
+func (c *checker) aggregations(v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, vv := range v {
+			c.aggregations(vv)
+		}
+	case map[string]interface{}:
+		for k, vv := range v {
+			switch k {
+			case "field", "weight_field":
+				if s, ok := vv.(string); ok {
+					c.field(s)
+				}
+			case "script":
+				c.script(vv)
+			default:
+				c.aggregations(vv)
+			}
+		}
+	}
+}
+
+func (c *checker) sort(v interface{}) {
+	switch v := v.(type) {
+	case string:
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimLeft(strings.TrimSpace(field), "+-")
+			if i := strings.IndexByte(field, ':'); i > 0 {
+				field = field[:i]
+			}
+			if field != "_score" && field != "_doc" {
+				c.field(field)
+			}
+		}
+	case []interface{}:
+		for _, vv := range v {
+			c.sort(vv)
+		}
+	case map[string]interface{}:
+		for field, vv := range v {
+			if field == "_script" {
+				c.script(vv)
+				continue
+			}
+			if field != "_score" && field != "_doc" {
+				c.field(field)
+			}
+		}
+	}
+}
+
+// queryFields checks the fields of the queries like ["title^3", "name"]
+func (c *checker) queryFields(v interface{}) {
+	switch v := v.(type) {
+	case string:
+		c.field(v)
+	case []interface{}:
+		for _, vv := range v {
+			c.queryFields(vv)
+		}
+	}
+}
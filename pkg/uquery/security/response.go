@@ -0,0 +1,111 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package security
+
+import "github.com/zincsearch/zincsearch/pkg/meta"
+
+// Hits removes the fields which are not visible from the hits and their inner hits
+func Hits(privileges []*meta.RoleIndices, hits []meta.Hit) {
+	if privileges == nil {
+		return
+	}
+	for i := range hits {
+		Hit(&hits[i], FieldRules(privileges, hits[i].Index))
+		for name, inner := range hits[i].InnerHits {
+			Hits(privileges, inner.Hits.Hits)
+			hits[i].InnerHits[name] = inner
+		}
+	}
+}
+
+// Suggest removes the fields which are not visible from the sources of the completion options
+func Suggest(privileges []*meta.RoleIndices, suggest map[string][]meta.SuggestResponse) {
+	if privileges == nil {
+		return
+	}
+	for _, entries := range suggest {
+		for i := range entries {
+			for j := range entries[i].Options {
+				option := &entries[i].Options[j]
+				if source, ok := option.Source.(map[string]interface{}); ok {
+					option.Source = Source(source, "", FieldRules(privileges, option.Index))
+				}
+			}
+		}
+	}
+}
+
+// Hit removes the fields which are not visible from the source, the fields and the highlight of the hit
+func Hit(hit *meta.Hit, fields *Fields) {
+	if fields.Unrestricted() {
+		return
+	}
+	prefix := ""
+	if hit.Nested != nil {
+		prefix = hit.Nested.Field + "."
+	}
+	if source, ok := hit.Source.(map[string]interface{}); ok {
+		hit.Source = Source(source, prefix, fields)
+	}
+	for field := range hit.Fields {
+		if !fields.Allowed(prefix + field) {
+			delete(hit.Fields, field)
+		}
+	}
+	for field := range hit.Highlight {
+		if !fields.Allowed(prefix + field) {
+			delete(hit.Highlight, field)
+		}
+	}
+}
+
+// Source returns the source with the visible fields, the objects and the arrays of objects
+// are filtered recursively and removed if none of their fields is visible
+func Source(source map[string]interface{}, prefix string, fields *Fields) map[string]interface{} {
+	if fields.Unrestricted() {
+		return source
+	}
+	rv := make(map[string]interface{}, len(source))
+	for k, v := range source {
+		if v, ok := sourceValue(v, prefix+k, fields); ok {
+			rv[k] = v
+		}
+	}
+	return rv
+}
+
+func sourceValue(v interface{}, field string, fields *Fields) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		obj := Source(v, field+".", fields)
+		if len(obj) == 0 && (len(v) > 0 || !fields.Allowed(field)) {
+			return nil, false
+		}
+		return obj, true
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, vv := range v {
+			if vv, ok := sourceValue(vv, field, fields); ok {
+				values = append(values, vv)
+			}
+		}
+		if len(values) == 0 && (len(v) > 0 || !fields.Allowed(field)) {
+			return nil, false
+		}
+		return values, true
+	default:
+		return v, fields.Allowed(field)
+	}
+}
@@ -16,11 +16,19 @@
 package sort
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/numeric"
 	"github.com/blugelabs/bluge/search"
 
 	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils"
 )
 
 func Request(v interface{}) (search.SortOrder, error) {
@@ -76,3 +84,123 @@ func Request(v interface{}) (search.SortOrder, error) {
 
 	return sorts, nil
 }
+
+// SearchAfter encodes the search_after values as the sort keys of the sorts,
+// the sorts must have the same length as the values and include `_id` as tiebreaker
+func SearchAfter(values []interface{}, sorts search.SortOrder, mappings *meta.Mappings) ([][]byte, error) {
+	if len(sorts) == 0 {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[search_after] requires an explicit [sort]")
+	}
+	if len(values) != len(sorts) {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] has %d value(s) but [sort] has %d", len(values), len(sorts)))
+	}
+	tiebreaker := false
+	for _, s := range sorts {
+		if fields := s.Fields(); len(fields) > 0 && fields[0] == "_id" {
+			tiebreaker = true
+		}
+	}
+	if !tiebreaker {
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, "[search_after] requires [sort] to include [_id] as tiebreaker")
+	}
+
+	after := make([][]byte, len(sorts))
+	for i, s := range sorts {
+		v, err := afterValue(s, values[i], mappings)
+		if err != nil {
+			return nil, err
+		}
+		after[i] = v
+	}
+	return after, nil
+}
+
+func afterValue(s *search.Sort, value interface{}, mappings *meta.Mappings) ([]byte, error) {
+	fields := s.Fields()
+	if len(fields) == 0 {
+		v, ok := value.(float64)
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] value [%v] of [_score] should be a number", value))
+		}
+		return numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(v), 0), nil
+	}
+	if value == nil {
+		// the key of the documents missing the field
+		return s.Value(&search.DocumentMatch{}), nil
+	}
+
+	field := fields[0]
+	prop, _ := mappings.GetProperty(field)
+	switch prop.Type {
+	case "numeric":
+		v, ok := value.(float64)
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] value [%v] of [%s] should be a number", value, field))
+		}
+		return numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(v), 0), nil
+	case "date", "time":
+		var t time.Time
+		var err error
+		if v, ok := value.(string); ok {
+			if t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				t, err = zutils.ParseTime(v, prop.Format, prop.TimeZone)
+			}
+		} else {
+			t, err = zutils.ParseTime(value, "", "")
+		}
+		if err != nil {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] value [%v] of [%s] should be a date", value, field))
+		}
+		return numeric.MustNewPrefixCodedInt64(t.UnixNano(), 0), nil
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return []byte(strconv.FormatBool(v)), nil
+		case string:
+			return []byte(v), nil
+		}
+		return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] value [%v] of [%s] should be a boolean", value, field))
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return nil, errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[search_after] value [%v] of [%s] should be a string", value, field))
+		}
+		return []byte(v), nil
+	}
+}
+
+// Response returns the sort values of the document, they can be used as search_after to fetch the next page
+func Response(sorts search.SortOrder, doc *search.DocumentMatch, mappings *meta.Mappings) []interface{} {
+	values := make([]interface{}, len(sorts))
+	for i, s := range sorts {
+		fields := s.Fields()
+		if len(fields) == 0 {
+			values[i] = doc.Score
+			continue
+		}
+		if i >= len(doc.SortValue) {
+			continue
+		}
+		key := doc.SortValue[i]
+		if bytes.Equal(key, s.Value(&search.DocumentMatch{})) {
+			// missing field
+			continue
+		}
+		prop, _ := mappings.GetProperty(fields[0])
+		switch prop.Type {
+		case "numeric":
+			if v, err := bluge.DecodeNumericFloat64(key); err == nil {
+				values[i] = v
+			}
+		case "date", "time":
+			if v, err := bluge.DecodeDateTime(key); err == nil {
+				values[i] = v
+			}
+		case "bool":
+			values[i] = string(key) == "true"
+		default:
+			values[i] = string(key)
+		}
+	}
+	return values
+}
@@ -32,23 +32,55 @@ func Request(v interface{}) (*meta.Source, error) {
 		return v, nil
 	}
 
+	var err error
 	switch v := v.(type) {
 	case bool:
 		source.Enable = v
+	case string, []string, []interface{}:
+		if source.Fields, err = stringList(v); err != nil {
+			return nil, err
+		}
+	case map[string]interface{}:
+		for k, v := range v {
+			switch k {
+			case "includes", "include":
+				if source.Fields, err = stringList(v); err != nil {
+					return nil, err
+				}
+			case "excludes", "exclude":
+				if source.Excludes, err = stringList(v); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[_source] unknown field ["+k+"]")
+			}
+		}
+	default:
+		return nil, errors.New(errors.ErrorTypeXContentParseException, "[_source] value should be boolean, []string or object with includes and excludes")
+	}
+
+	return source, nil
+}
+
+func stringList(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
 	case []interface{}:
-		source.Fields = make([]string, 0, len(v))
+		fields := make([]string, 0, len(v))
 		for _, field := range v {
 			if v, ok := field.(string); ok {
-				source.Fields = append(source.Fields, v)
+				fields = append(fields, v)
 			} else {
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[_source] value should be boolean or []string")
 			}
 		}
+		return fields, nil
 	default:
 		return nil, errors.New(errors.ErrorTypeXContentParseException, "[_source] value should be boolean or []string")
 	}
-
-	return source, nil
 }
 
 func Response(source *meta.Source, data []byte) map[string]interface{} {
@@ -65,27 +97,116 @@ func Response(source *meta.Source, data []byte) map[string]interface{} {
 	}
 
 	// return all fields
-	if len(source.Fields) == 0 {
+	if len(source.Fields) == 0 && len(source.Excludes) == 0 {
 		return ret
 	}
 
-	wildcard := false
-	rets := make(map[string]interface{})
-	for _, field := range source.Fields {
-		wildcard = false
-		if strings.HasSuffix(field, "*") {
-			wildcard = true
+	return filterObject(ret, "", source.Fields, source.Excludes)
+}
+
+// Filter returns the object filtered by the source fields, nil if the source is disabled
+func Filter(source *meta.Source, obj map[string]interface{}) map[string]interface{} {
+	if !source.Enable {
+		return nil
+	}
+	if len(source.Fields) == 0 && len(source.Excludes) == 0 {
+		return obj
+	}
+	return filterObject(obj, "", source.Fields, source.Excludes)
+}
+
+// Excluded returns true if the field is removed from the source
+func Excluded(source *meta.Source, field string) bool {
+	return source != nil && matchAny(source.Excludes, field)
+}
+
+// filterObject prunes the object by the field paths, includes is empty if the object is fully included
+func filterObject(obj map[string]interface{}, prefix string, includes, excludes []string) map[string]interface{} {
+	ret := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		path := prefix + k
+		if matchAny(excludes, path) {
+			continue
 		}
-		if _, ok := ret[field]; ok {
-			rets[field] = ret[field]
-		} else if wildcard {
-			for k, v := range ret {
-				if strings.HasPrefix(k, field[:len(field)-1]) {
-					rets[k] = v
-				}
+		if len(includes) == 0 || matchAny(includes, path) {
+			ret[k], _ = filterValue(v, path, nil, excludes)
+			continue
+		}
+		if !matchAnyChild(includes, path) {
+			continue
+		}
+		if v, ok := filterValue(v, path, includes, excludes); ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// filterValue prunes the children of the value, returns false if nothing of a partially included value remains
+func filterValue(v interface{}, path string, includes, excludes []string) (interface{}, bool) {
+	if len(includes) == 0 && !matchAnyChild(excludes, path) {
+		return v, true
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		obj := filterObject(v, path+".", includes, excludes)
+		return obj, len(includes) == 0 || len(obj) > 0
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item, ok := filterValue(item, path, includes, excludes); ok {
+				items = append(items, item)
 			}
 		}
+		return items, len(includes) == 0 || len(items) > 0
+	default:
+		return v, len(includes) == 0
 	}
+}
 
-	return rets
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyChild returns true if any pattern may match the descendant fields of the path
+func matchAnyChild(patterns []string, path string) bool {
+	prefix := path + "."
+	for _, pattern := range patterns {
+		literal := pattern
+		if i := strings.Index(pattern, "*"); i >= 0 {
+			literal = pattern[:i]
+			if strings.HasPrefix(prefix, literal) {
+				return true
+			}
+		}
+		if strings.HasPrefix(literal, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPattern matches the field path with the pattern, * matches any characters
+func MatchPattern(pattern, path string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == path
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	for i := 1; i < len(parts)-1; i++ {
+		j := strings.Index(path, parts[i])
+		if j < 0 {
+			return false
+		}
+		path = path[j+len(parts[i]):]
+	}
+	return strings.HasSuffix(path, parts[len(parts)-1])
 }
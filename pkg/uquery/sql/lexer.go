@@ -0,0 +1,135 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package sql translates a small SQL dialect to the query DSL:
+// SELECT with WHERE, GROUP BY, ORDER BY and LIMIT over a single index.
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// is reports whether the token is the given keyword or symbol
+func (t token) is(value string) bool {
+	switch t.kind {
+	case tokenIdent:
+		return strings.EqualFold(t.value, value)
+	case tokenSymbol:
+		return t.value == value
+	}
+	return false
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return "[" + t.value + "]"
+}
+
+var symbols = []string{"<=", ">=", "<>", "!=", "=", "<", ">", "(", ")", ",", "*", "-", ";"}
+
+func tokenize(s string) ([]token, error) {
+	tokens := make([]token, 0)
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			value, n, err := readQuoted(rs, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = n
+		case r == '"' || r == '`':
+			value, n, err := readQuoted(rs, i, r)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenQuotedIdent, value: value, pos: i})
+			i = n
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(rs[start:i]), pos: start})
+		case isIdentRune(r):
+			start := i
+			for i < len(rs) && (isIdentRune(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: string(rs[start:i]), pos: start})
+		default:
+			matched := false
+			for _, sym := range symbols {
+				if strings.HasPrefix(string(rs[i:]), sym) {
+					tokens = append(tokens, token{kind: tokenSymbol, value: sym, pos: i})
+					i += len(sym)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("line 1:%d: unexpected character [%c]", i+1, r)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(rs)}), nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '@'
+}
+
+// readQuoted reads a value quoted by q, a doubled quote escapes itself
+func readQuoted(rs []rune, start int, q rune) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(rs); i++ {
+		if rs[i] != q {
+			sb.WriteRune(rs[i])
+			continue
+		}
+		if i+1 < len(rs) && rs[i+1] == q {
+			sb.WriteRune(q)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("line 1:%d: unterminated quoted value", start+1)
+}
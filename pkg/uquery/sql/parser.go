@@ -0,0 +1,440 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query is a parsed SELECT statement
+type Query struct {
+	Fields  []*Field
+	Index   string
+	Where   Expr
+	GroupBy []string
+	OrderBy []*Order
+	Limit   int // -1 when no LIMIT
+}
+
+// Field is a selected column, either a field or an aggregate function
+type Field struct {
+	Name     string // field name, `*` for all fields or COUNT(*)
+	Function string // COUNT, COUNT_DISTINCT, SUM, AVG, MIN, MAX or empty
+	Alias    string
+}
+
+// Column returns the name of the column in the result
+func (f *Field) Column() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.expression()
+}
+
+func (f *Field) expression() string {
+	switch f.Function {
+	case "":
+		return f.Name
+	case "COUNT_DISTINCT":
+		return "COUNT(DISTINCT " + f.Name + ")"
+	default:
+		return f.Function + "(" + f.Name + ")"
+	}
+}
+
+// IsAggregate reports whether the column is an aggregate function
+func (f *Field) IsAggregate() bool {
+	return f.Function != ""
+}
+
+// Order is an ORDER BY item, Field is a field, alias or aggregate expression
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+var functions = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "BY": true, "ORDER": true, "LIMIT": true,
+	"AND": true, "OR": true, "NOT": true, "IN": true, "LIKE": true, "BETWEEN": true, "IS": true, "NULL": true,
+	"AS": true, "ASC": true, "DESC": true, "DISTINCT": true, "TRUE": true, "FALSE": true,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a SELECT statement
+func Parse(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.parseQuery()
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the token if it is the given keyword or symbol
+func (p *parser) accept(value string) bool {
+	if p.peek().is(value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(value string) error {
+	if !p.accept(value) {
+		return p.unexpected("[" + value + "]")
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expecting string) error {
+	t := p.peek()
+	return fmt.Errorf("line 1:%d: mismatched input %s expecting %s", t.pos+1, t, expecting)
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{Limit: -1}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	for {
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		q.Fields = append(q.Fields, f)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	switch t.kind {
+	case tokenIdent, tokenQuotedIdent, tokenString:
+		q.Index = t.value
+	default:
+		p.pos--
+		return nil, p.unexpected("index name")
+	}
+
+	if p.accept("WHERE") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		q.Where = expr
+	}
+
+	if p.accept("GROUP") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			q.GroupBy = append(q.GroupBy, name)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.accept("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			f, err := p.parseFieldExpression()
+			if err != nil {
+				return nil, err
+			}
+			order := &Order{Field: f.expression()}
+			if p.accept("DESC") {
+				order.Desc = true
+			} else {
+				p.accept("ASC")
+			}
+			q.OrderBy = append(q.OrderBy, order)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.accept("LIMIT") {
+		t := p.next()
+		limit, err := strconv.Atoi(t.value)
+		if t.kind != tokenNumber || err != nil {
+			p.pos--
+			return nil, p.unexpected("integer")
+		}
+		q.Limit = limit
+	}
+
+	p.accept(";")
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("end of input")
+	}
+	return q, nil
+}
+
+// parseField parses a selected column with an optional alias
+func (p *parser) parseField() (*Field, error) {
+	f, err := p.parseFieldExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept("AS") {
+		if f.Alias, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	} else if t := p.peek(); t.kind == tokenQuotedIdent || (t.kind == tokenIdent && !keywords[strings.ToUpper(t.value)]) {
+		f.Alias = p.next().value
+	}
+	return f, nil
+}
+
+// parseFieldExpression parses `*`, a field or an aggregate function call
+func (p *parser) parseFieldExpression() (*Field, error) {
+	if p.accept("*") {
+		return &Field{Name: "*"}, nil
+	}
+	t := p.peek()
+	name := strings.ToUpper(t.value)
+	if t.kind == tokenIdent && functions[name] && p.tokens[p.pos+1].is("(") {
+		p.pos += 2
+		f := &Field{Function: name}
+		switch {
+		case name == "COUNT" && p.accept("*"):
+			f.Name = "*"
+		case name == "COUNT" && p.accept("DISTINCT"):
+			f.Function = "COUNT_DISTINCT"
+			fallthrough
+		default:
+			field, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			f.Name = field
+		}
+		if name == "COUNT" && f.Function == "COUNT" && f.Name != "*" {
+			return nil, fmt.Errorf("line 1:%d: only COUNT(*) and COUNT(DISTINCT field) are supported", t.pos+1)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	field, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &Field{Name: field}, nil
+}
+
+func (p *parser) parseIdent() (string, error) {
+	t := p.peek()
+	if t.kind == tokenQuotedIdent || (t.kind == tokenIdent && !keywords[strings.ToUpper(t.value)]) {
+		p.pos++
+		return t.value, nil
+	}
+	return "", p.unexpected("identifier")
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.accept("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: expr}, nil
+	}
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate parses a comparison, IN, LIKE, BETWEEN or IS NULL predicate on a field
+func (p *parser) parsePredicate() (Expr, error) {
+	field, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenSymbol {
+		switch t.value {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			value, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			op := t.value
+			if op == "<>" {
+				op = "!="
+			}
+			if op == "!=" {
+				return &notExpr{expr: &compareExpr{field: field, op: "=", value: value}}, nil
+			}
+			return &compareExpr{field: field, op: op, value: value}, nil
+		}
+	}
+
+	not := p.accept("NOT")
+	var expr Expr
+	switch {
+	case p.accept("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		in := &inExpr{field: field}
+		for {
+			value, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			in.values = append(in.values, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		expr = in
+	case p.accept("LIKE"):
+		t := p.next()
+		if t.kind != tokenString {
+			p.pos--
+			return nil, p.unexpected("string")
+		}
+		expr = &likeExpr{field: field, pattern: t.value}
+	case p.accept("BETWEEN"):
+		from, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		to, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		expr = &betweenExpr{field: field, from: from, to: to}
+	case !not && p.accept("IS"):
+		not = !p.accept("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		expr = &existsExpr{field: field}
+	default:
+		return nil, p.unexpected("comparison, IN, LIKE, BETWEEN or IS")
+	}
+	if not {
+		return &notExpr{expr: expr}, nil
+	}
+	return expr, nil
+}
+
+// parseLiteral parses a string, number or boolean
+func (p *parser) parseLiteral() (interface{}, error) {
+	negative := p.accept("-")
+	t := p.next()
+	switch {
+	case t.kind == tokenNumber:
+		v, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line 1:%d: invalid number [%s]", t.pos+1, t.value)
+		}
+		if negative {
+			v = -v
+		}
+		return v, nil
+	case negative:
+	case t.kind == tokenString:
+		return t.value, nil
+	case t.is("TRUE"):
+		return true, nil
+	case t.is("FALSE"):
+		return false, nil
+	}
+	p.pos--
+	return nil, p.unexpected("literal")
+}
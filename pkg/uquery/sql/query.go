@@ -0,0 +1,338 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// DefaultLimit the number of rows returned without LIMIT
+const DefaultLimit = 1000
+
+// Expr is a WHERE condition
+type Expr interface {
+	// query returns the condition as query DSL
+	query(mappings *meta.Mappings) (map[string]interface{}, error)
+}
+
+type logicExpr struct {
+	or          bool
+	left, right Expr
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+type compareExpr struct {
+	field string
+	op    string // =, <, <=, > or >=
+	value interface{}
+}
+
+type inExpr struct {
+	field  string
+	values []interface{}
+}
+
+type likeExpr struct {
+	field   string
+	pattern string
+}
+
+type betweenExpr struct {
+	field    string
+	from, to interface{}
+}
+
+type existsExpr struct {
+	field string
+}
+
+func (e *logicExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	left, err := e.left.query(mappings)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.query(mappings)
+	if err != nil {
+		return nil, err
+	}
+	if e.or {
+		return map[string]interface{}{"bool": map[string]interface{}{
+			"should":               []interface{}{left, right},
+			"minimum_should_match": 1,
+		}}, nil
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{left, right}}}, nil
+}
+
+func (e *notExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	q, err := e.expr.query(mappings)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{
+		"must":     []interface{}{map[string]interface{}{"match_all": map[string]interface{}{}}},
+		"must_not": []interface{}{q},
+	}}, nil
+}
+
+func (e *compareExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	prop, err := property(mappings, e.field)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=":
+		field, exact := exactField(mappings, e.field, prop)
+		if !exact {
+			return map[string]interface{}{"match_phrase": map[string]interface{}{field: e.value}}, nil
+		}
+		return map[string]interface{}{"term": map[string]interface{}{field: e.value}}, nil
+	default:
+		op := map[string]string{"<": "lt", "<=": "lte", ">": "gt", ">=": "gte"}[e.op]
+		return map[string]interface{}{"range": map[string]interface{}{e.field: map[string]interface{}{op: e.value}}}, nil
+	}
+}
+
+func (e *inExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	prop, err := property(mappings, e.field)
+	if err != nil {
+		return nil, err
+	}
+	field, _ := exactField(mappings, e.field, prop)
+	return map[string]interface{}{"terms": map[string]interface{}{field: e.values}}, nil
+}
+
+func (e *likeExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	prop, err := property(mappings, e.field)
+	if err != nil {
+		return nil, err
+	}
+	field, _ := exactField(mappings, e.field, prop)
+	pattern := strings.NewReplacer("%", "*", "_", "?").Replace(e.pattern)
+	return map[string]interface{}{"wildcard": map[string]interface{}{field: map[string]interface{}{"value": pattern}}}, nil
+}
+
+func (e *betweenExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	if _, err := property(mappings, e.field); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"range": map[string]interface{}{e.field: map[string]interface{}{"gte": e.from, "lte": e.to}}}, nil
+}
+
+func (e *existsExpr) query(mappings *meta.Mappings) (map[string]interface{}, error) {
+	if _, err := property(mappings, e.field); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"exists": map[string]interface{}{"field": e.field}}, nil
+}
+
+// property returns the mapping of the field, and an error for unknown fields
+func property(mappings *meta.Mappings, field string) (meta.Property, error) {
+	if field == meta.IDFieldName {
+		return meta.Property{Type: "keyword"}, nil
+	}
+	prop, ok := mappings.GetProperty(field)
+	if !ok {
+		return prop, fmt.Errorf("unknown column [%s]", field)
+	}
+	return prop, nil
+}
+
+// exactField returns the field used for exact matching, sorting and grouping,
+// that is the keyword sub field of a text field, exact is false for a text field without it.
+func exactField(mappings *meta.Mappings, field string, prop meta.Property) (string, bool) {
+	if prop.Type != "text" {
+		return field, true
+	}
+	for name, sub := range prop.Fields {
+		if sub.Type == "keyword" {
+			if _, ok := mappings.GetProperty(field + "." + name); ok {
+				return field + "." + name, true
+			}
+		}
+	}
+	return field, false
+}
+
+// IsAggregate reports whether the query returns aggregated rows
+func (q *Query) IsAggregate() bool {
+	if len(q.GroupBy) > 0 {
+		return true
+	}
+	for _, f := range q.Fields {
+		if f.IsAggregate() {
+			return true
+		}
+	}
+	return false
+}
+
+// Request translates the query to a search request
+func (q *Query) Request(mappings *meta.Mappings) (*meta.ZincQuery, error) {
+	req := &meta.ZincQuery{
+		Query:          map[string]interface{}{"match_all": map[string]interface{}{}},
+		Size:           DefaultLimit,
+		TrackTotalHits: true,
+	}
+	if q.Limit >= 0 {
+		req.Size = q.Limit
+	}
+	if q.Where != nil {
+		query, err := q.Where.query(mappings)
+		if err != nil {
+			return nil, err
+		}
+		req.Query = query
+	}
+
+	if !q.IsAggregate() {
+		for _, f := range q.Fields {
+			if f.Name == "*" {
+				continue
+			}
+			if _, err := property(mappings, f.Name); err != nil {
+				return nil, err
+			}
+		}
+		sorts := make([]interface{}, 0, len(q.OrderBy))
+		for _, order := range q.OrderBy {
+			name := q.resolveAlias(order.Field)
+			prop, err := property(mappings, name)
+			if err != nil {
+				return nil, err
+			}
+			field, exact := exactField(mappings, name, prop)
+			if !exact {
+				return nil, fmt.Errorf("cannot sort on text field [%s]", name)
+			}
+			if order.Desc {
+				field = "-" + field
+			}
+			sorts = append(sorts, field)
+		}
+		if len(sorts) > 0 {
+			req.Sort = sorts
+		}
+		return req, nil
+	}
+
+	// aggregations do not need hits
+	req.Size = 0
+	for _, f := range q.Fields {
+		switch {
+		case f.Name == "*" && f.Function == "":
+			return nil, fmt.Errorf("cannot select [*] with aggregations")
+		case !f.IsAggregate():
+			if !q.isGroupField(f.Name) {
+				return nil, fmt.Errorf("cannot use non-grouped column [%s], expected [%s]", f.Name, strings.Join(q.GroupBy, ", "))
+			}
+		case f.Name != "*":
+			if _, err := property(mappings, f.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, order := range q.OrderBy {
+		if q.column(order.Field) < 0 && !q.isGroupField(order.Field) {
+			return nil, fmt.Errorf("cannot order by [%s], it is neither selected nor grouped", order.Field)
+		}
+	}
+
+	metrics := make(map[string]meta.Aggregations)
+	for i, f := range q.Fields {
+		metric := &meta.AggregationMetric{Field: f.Name}
+		switch f.Function {
+		case "SUM":
+			metrics[metricName(i)] = meta.Aggregations{Sum: metric}
+		case "AVG":
+			metrics[metricName(i)] = meta.Aggregations{Avg: metric}
+		case "MIN":
+			metrics[metricName(i)] = meta.Aggregations{Min: metric}
+		case "MAX":
+			metrics[metricName(i)] = meta.Aggregations{Max: metric}
+		case "COUNT_DISTINCT":
+			prop, _ := property(mappings, f.Name)
+			metric.Field, _ = exactField(mappings, f.Name, prop)
+			metrics[metricName(i)] = meta.Aggregations{Cardinality: metric}
+		}
+	}
+
+	// nest a terms aggregation per group field, the metrics go in the innermost one
+	aggs := metrics
+	for i := len(q.GroupBy) - 1; i >= 0; i-- {
+		prop, err := property(mappings, q.GroupBy[i])
+		if err != nil {
+			return nil, err
+		}
+		field, _ := exactField(mappings, q.GroupBy[i], prop)
+		aggs = map[string]meta.Aggregations{groupName(i): {
+			Terms:        &meta.AggregationsTerms{Field: field, Size: config.Global.AggregationTermsSize},
+			Aggregations: aggs,
+		}}
+	}
+	req.Aggregations = aggs
+
+	return req, nil
+}
+
+// column returns the index of the selected column with the name, alias or expression
+func (q *Query) column(name string) int {
+	for i, f := range q.Fields {
+		if f.Alias == name {
+			return i
+		}
+	}
+	for i, f := range q.Fields {
+		if strings.EqualFold(f.expression(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveAlias returns the field of a selected column alias
+func (q *Query) resolveAlias(name string) string {
+	for _, f := range q.Fields {
+		if f.Alias == name && !f.IsAggregate() {
+			return f.Name
+		}
+	}
+	return name
+}
+
+func (q *Query) isGroupField(name string) bool {
+	for _, g := range q.GroupBy {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+func groupName(i int) string {
+	return fmt.Sprintf("group_%d", i)
+}
+
+func metricName(i int) string {
+	return fmt.Sprintf("metric_%d", i)
+}
@@ -0,0 +1,279 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zincsearch/zincsearch/pkg/meta"
+)
+
+// Response converts a search response to columns and rows
+func (q *Query) Response(resp *meta.SearchResponse, mappings *meta.Mappings) (*meta.SQLResponse, error) {
+	if q.IsAggregate() {
+		return q.aggregateResponse(resp, mappings)
+	}
+
+	fields := make([]string, 0, len(q.Fields))
+	columns := make([]meta.SQLColumn, 0, len(q.Fields))
+	for _, f := range q.Fields {
+		if f.Name == "*" {
+			for _, name := range allFields(mappings) {
+				fields = append(fields, name)
+				columns = append(columns, meta.SQLColumn{Name: name, Type: columnType(mappings, name, "")})
+			}
+			continue
+		}
+		fields = append(fields, f.Name)
+		columns = append(columns, meta.SQLColumn{Name: f.Column(), Type: columnType(mappings, f.Name, "")})
+	}
+
+	rows := make([][]interface{}, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		source, _ := hit.Source.(map[string]interface{})
+		row := make([]interface{}, len(fields))
+		for i, field := range fields {
+			if field == meta.IDFieldName {
+				row[i] = hit.ID
+				continue
+			}
+			row[i] = sourceValue(source, field)
+		}
+		rows = append(rows, row)
+	}
+
+	return &meta.SQLResponse{Columns: columns, Rows: rows}, nil
+}
+
+func (q *Query) aggregateResponse(resp *meta.SearchResponse, mappings *meta.Mappings) (*meta.SQLResponse, error) {
+	columns := make([]meta.SQLColumn, 0, len(q.Fields))
+	for _, f := range q.Fields {
+		columns = append(columns, meta.SQLColumn{Name: f.Column(), Type: columnType(mappings, f.Name, f.Function)})
+	}
+
+	// one row per innermost bucket, or a single row without groups
+	groups := make([][]interface{}, 0)
+	rows := make([][]interface{}, 0)
+	var walk func(aggs map[string]interface{}, level int, keys []interface{}, count int64) error
+	walk = func(aggs map[string]interface{}, level int, keys []interface{}, count int64) error {
+		if level == len(q.GroupBy) {
+			row := make([]interface{}, len(q.Fields))
+			for i, f := range q.Fields {
+				switch f.Function {
+				case "":
+					for j, g := range q.GroupBy {
+						if g == f.Name {
+							row[i] = keys[j]
+						}
+					}
+				case "COUNT":
+					row[i] = count
+				case "COUNT_DISTINCT":
+					row[i] = int64(metricValue(aggs[metricName(i)]))
+				default:
+					row[i] = metricValue(aggs[metricName(i)])
+				}
+			}
+			groups = append(groups, keys)
+			rows = append(rows, row)
+			return nil
+		}
+		agg, ok := aggs[groupName(level)].(meta.AggregationResponse)
+		if !ok {
+			return nil
+		}
+		buckets, ok := agg.Buckets.([]map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected buckets of [%s]", q.GroupBy[level])
+		}
+		for _, bucket := range buckets {
+			docCount, _ := bucket["doc_count"].(uint64)
+			key := append(append([]interface{}{}, keys...), bucket["key"])
+			if err := walk(bucket, level+1, key, int64(docCount)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	top := make(map[string]interface{}, len(resp.Aggregations))
+	for name, agg := range resp.Aggregations {
+		top[name] = agg
+	}
+	if err := walk(top, 0, nil, int64(resp.Hits.Total.Value)); err != nil {
+		return nil, err
+	}
+
+	// groups are sorted by key unless ordered explicitly
+	orders := q.OrderBy
+	if len(orders) == 0 {
+		for _, g := range q.GroupBy {
+			orders = append(orders, &Order{Field: g})
+		}
+	}
+	value := func(i int, order *Order) interface{} {
+		if col := q.column(order.Field); col >= 0 {
+			return rows[i][col]
+		}
+		for j, g := range q.GroupBy {
+			if g == order.Field {
+				return groups[i][j]
+			}
+		}
+		return nil
+	}
+	index := make([]int, len(rows))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool {
+		for _, order := range orders {
+			cmp := compareValue(value(index[a], order), value(index[b], order))
+			if cmp == 0 {
+				continue
+			}
+			if order.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	sorted := make([][]interface{}, 0, len(rows))
+	for _, i := range index {
+		sorted = append(sorted, rows[i])
+	}
+	if q.Limit >= 0 && len(sorted) > q.Limit {
+		sorted = sorted[:q.Limit]
+	}
+
+	return &meta.SQLResponse{Columns: columns, Rows: sorted}, nil
+}
+
+func metricValue(v interface{}) float64 {
+	agg, ok := v.(meta.AggregationResponse)
+	if !ok {
+		return 0
+	}
+	f, _ := agg.Value.(float64)
+	return f
+}
+
+// allFields returns the fields of `SELECT *`, without internal and sub fields
+func allFields(mappings *meta.Mappings) []string {
+	props := mappings.ListProperty()
+	subFields := make(map[string]bool)
+	for name, prop := range props {
+		for sub := range prop.Fields {
+			subFields[name+"."+sub] = true
+		}
+	}
+	fields := make([]string, 0, len(props))
+	for name := range props {
+		if subFields[name] || strings.HasPrefix(name, "_") {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// columnType returns the SQL type of a column
+func columnType(mappings *meta.Mappings, field, function string) string {
+	switch function {
+	case "COUNT", "COUNT_DISTINCT":
+		return "long"
+	case "SUM", "AVG", "MIN", "MAX":
+		return "double"
+	}
+	if field == meta.IDFieldName {
+		return "keyword"
+	}
+	prop, _ := mappings.GetProperty(field)
+	switch prop.Type {
+	case "numeric":
+		return "double"
+	case "date":
+		return "datetime"
+	case "bool", "boolean":
+		return "boolean"
+	case "":
+		return "null"
+	}
+	return prop.Type
+}
+
+// sourceValue returns the value of a dotted field in the source,
+// the source may hold it flattened or as nested objects
+func sourceValue(source map[string]interface{}, field string) interface{} {
+	if v, ok := source[field]; ok {
+		return v
+	}
+	for i := 0; i < len(field); i++ {
+		if field[i] != '.' {
+			continue
+		}
+		if sub, ok := source[field[:i]].(map[string]interface{}); ok {
+			if v := sourceValue(sub, field[i+1:]); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// compareValue orders nil first, then numbers, then strings
+func compareValue(a, b interface{}) int {
+	fa, aNum := toFloat(a)
+	fb, bNum := toFloat(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case aNum && bNum:
+		if fa < fb {
+			return -1
+		}
+		if fa > fb {
+			return 1
+		}
+		return 0
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,233 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blugelabs/bluge"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+const (
+	DefaultFuzziness          = 1
+	DefaultFuzzyPrefixLength  = 1
+	completionTermSeparator   = "\x00"
+	completionTermWeightWidth = 10
+)
+
+// CompletionTerm returns the term indexed for an input of a completion field.
+// The weight is appended to the input, so that a prefix walk of the term dictionary,
+// which is a finite-state transducer in each segment, can rank the completions without loading documents.
+func CompletionTerm(input string, weight int) string {
+	return strings.ToLower(input) + completionTermSeparator + fmt.Sprintf("%0*d", completionTermWeightWidth, weight)
+}
+
+// ParseCompletionTerm splits an indexed completion term into the input and the weight
+func ParseCompletionTerm(term string) (string, int, bool) {
+	i := strings.LastIndex(term, completionTermSeparator)
+	if i < 0 {
+		return "", 0, false
+	}
+	weight, err := strconv.Atoi(term[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return term[:i], weight, true
+}
+
+func completionRequest(c *meta.CompletionSuggester) error {
+	if c.Field == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[completion] suggester requires [field]")
+	}
+	if c.Size <= 0 {
+		c.Size = DefaultSize
+	}
+	if c.Fuzzy != nil {
+		if c.Fuzzy.Fuzziness == 0 {
+			c.Fuzzy.Fuzziness = DefaultFuzziness
+		}
+		if c.Fuzzy.Fuzziness < 1 || c.Fuzzy.Fuzziness > 2 {
+			return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[completion] fuzziness must be 1 or 2, but got [%d]", c.Fuzzy.Fuzziness))
+		}
+		if c.Fuzzy.PrefixLength == 0 {
+			c.Fuzzy.PrefixLength = DefaultFuzzyPrefixLength
+		}
+	}
+	return nil
+}
+
+// completion is a suggestion found in the term dictionary
+type completion struct {
+	term   string
+	input  string
+	weight int
+}
+
+func completionResponse(readers []*bluge.Reader, prefix string, c *meta.CompletionSuggester, filter bluge.Query) ([]meta.SuggestResponse, error) {
+	entry := meta.SuggestResponse{
+		Text:    prefix,
+		Offset:  0,
+		Length:  len(prefix),
+		Options: []meta.SuggestOption{},
+	}
+
+	prefix = strings.ToLower(prefix)
+	prefixRunes := []rune(prefix)
+	var start []byte
+	fuzziness := 0
+	if c.Fuzzy != nil {
+		fuzziness = c.Fuzzy.Fuzziness
+		if len(prefixRunes) >= c.Fuzzy.PrefixLength {
+			start = []byte(string(prefixRunes[:c.Fuzzy.PrefixLength]))
+		}
+	} else {
+		start = []byte(prefix)
+	}
+	var end []byte
+	if len(start) > 0 {
+		end = prefixEnd(start)
+	}
+
+	found := make(map[string]*completion)
+	for _, r := range readers {
+		it, err := r.DictionaryIterator(c.Field, nil, start, end)
+		if err != nil {
+			return nil, err
+		}
+		dictEntry, err := it.Next()
+		for err == nil && dictEntry != nil {
+			term := dictEntry.Term()
+			if _, ok := found[term]; !ok {
+				input, weight, ok := ParseCompletionTerm(term)
+				if ok && (fuzziness == 0 || fuzzyPrefix(prefixRunes, []rune(input), fuzziness)) {
+					found[term] = &completion{term: term, input: input, weight: weight}
+				}
+			}
+			dictEntry, err = it.Next()
+		}
+		_ = it.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	completions := make([]*completion, 0, len(found))
+	for _, v := range found {
+		completions = append(completions, v)
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		if completions[i].weight != completions[j].weight {
+			return completions[i].weight > completions[j].weight
+		}
+		return completions[i].input < completions[j].input
+	})
+
+	seen := make(map[string]struct{})
+	for _, v := range completions {
+		if len(entry.Options) >= c.Size {
+			break
+		}
+		if c.SkipDuplicates {
+			if _, ok := seen[v.input]; ok {
+				continue
+			}
+		}
+		options, err := completionDocuments(readers, c.Field, v, c.Size-len(entry.Options), filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(options) == 0 {
+			continue // the documents are deleted or not visible
+		}
+		if c.SkipDuplicates {
+			seen[v.input] = struct{}{}
+			options = options[:1]
+		}
+		entry.Options = append(entry.Options, options...)
+	}
+
+	return []meta.SuggestResponse{entry}, nil
+}
+
+// completionDocuments loads at most size documents which have the completion and match the filter, nil matches all
+func completionDocuments(readers []*bluge.Reader, field string, c *completion, size int, filter bluge.Query) ([]meta.SuggestOption, error) {
+	var query bluge.Query = bluge.NewTermQuery(c.term).SetField(field)
+	if filter != nil {
+		query = bluge.NewBooleanQuery().AddMust(query, filter)
+	}
+	options := make([]meta.SuggestOption, 0)
+	for _, r := range readers {
+		if len(options) >= size {
+			break
+		}
+		request := bluge.NewTopNSearch(size-len(options), query)
+		dmi, err := r.Search(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		next, err := dmi.Next()
+		for err == nil && next != nil {
+			option := meta.SuggestOption{
+				Text:     c.input,
+				Score:    float64(c.weight),
+				DocScore: float64(c.weight),
+			}
+			err = next.VisitStoredFields(func(field string, value []byte) bool {
+				switch field {
+				case "_id":
+					option.ID = string(value)
+				case "_index":
+					option.Index = string(value)
+				case "_source":
+					source := make(map[string]interface{})
+					_ = json.Unmarshal(value, &source)
+					option.Source = source
+				}
+				return true
+			})
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, option)
+			next, err = dmi.Next()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+// fuzzyPrefix returns true if a prefix of the input is within fuzziness edits of the prefix
+func fuzzyPrefix(prefix, input []rune, fuzziness int) bool {
+	for n := len(prefix) - fuzziness; n <= len(prefix)+fuzziness; n++ {
+		if n < 0 || n > len(input) {
+			continue
+		}
+		if distance(prefix, input[:n]) <= fuzziness {
+			return true
+		}
+	}
+	return false
+}
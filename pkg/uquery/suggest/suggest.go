@@ -0,0 +1,527 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/vellum/levenshtein"
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/analysis"
+
+	"github.com/zincsearch/zincsearch/pkg/errors"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	zincanalysis "github.com/zincsearch/zincsearch/pkg/uquery/analysis"
+)
+
+const (
+	DefaultSize          = 5
+	DefaultMaxEdits      = 2
+	DefaultPrefixLength  = 1
+	DefaultMinWordLength = 4
+	DefaultMaxErrors     = 1.0
+
+	// PhraseCandidates is the max number of corrections of each token of the phrase,
+	// the generator size of the phrase suggester
+	PhraseCandidates = 5
+
+	SortScore     = "score"
+	SortFrequency = "frequency"
+
+	ModeMissing = "missing"
+	ModePopular = "popular"
+	ModeAlways  = "always"
+)
+
+// Request validates the suggesters and fills the default values
+func Request(suggests map[string]*meta.Suggest) error {
+	for name, s := range suggests {
+		if s == nil {
+			return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[suggest] suggester [%s] is empty", name))
+		}
+		n := 0
+		for _, ok := range []bool{s.Term != nil, s.Phrase != nil, s.Completion != nil} {
+			if ok {
+				n++
+			}
+		}
+		if n != 1 {
+			return errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[suggest] suggester [%s] must have exactly one of [term], [phrase] or [completion]", name))
+		}
+		if s.Term != nil {
+			if err := termRequest(s.Term); err != nil {
+				return errors.New(errors.ErrorTypeXContentParseException, "[term] failed to parse field").Cause(err)
+			}
+		}
+		if s.Phrase != nil {
+			if err := phraseRequest(s.Phrase); err != nil {
+				return errors.New(errors.ErrorTypeXContentParseException, "[phrase] failed to parse field").Cause(err)
+			}
+		}
+		if s.Completion != nil {
+			if err := completionRequest(s.Completion); err != nil {
+				return errors.New(errors.ErrorTypeXContentParseException, "[completion] failed to parse field").Cause(err)
+			}
+		}
+	}
+	return nil
+}
+
+func termRequest(t *meta.TermSuggester) error {
+	if t.Field == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[term] suggester requires [field]")
+	}
+	if t.Size <= 0 {
+		t.Size = DefaultSize
+	}
+	if t.MaxEdits == 0 {
+		t.MaxEdits = DefaultMaxEdits
+	}
+	if t.MaxEdits < 1 || t.MaxEdits > 2 {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[term] max_edits must be 1 or 2, but got [%d]", t.MaxEdits))
+	}
+	if t.PrefixLength == 0 {
+		t.PrefixLength = DefaultPrefixLength
+	}
+	if t.MinWordLength == 0 {
+		t.MinWordLength = DefaultMinWordLength
+	}
+	t.Sort = strings.ToLower(t.Sort)
+	switch t.Sort {
+	case "":
+		t.Sort = SortScore
+	case SortScore, SortFrequency:
+	default:
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[term] unknown sort [%s]", t.Sort))
+	}
+	t.SuggestMode = strings.ToLower(t.SuggestMode)
+	switch t.SuggestMode {
+	case "":
+		t.SuggestMode = ModeMissing
+	case ModeMissing, ModePopular, ModeAlways:
+	default:
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[term] unknown suggest_mode [%s]", t.SuggestMode))
+	}
+	return nil
+}
+
+func phraseRequest(p *meta.PhraseSuggester) error {
+	if p.Field == "" {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, "[phrase] suggester requires [field]")
+	}
+	if p.Size <= 0 {
+		p.Size = DefaultSize
+	}
+	if p.MaxErrors <= 0 {
+		p.MaxErrors = DefaultMaxErrors
+	}
+	if p.MaxEdits == 0 {
+		p.MaxEdits = DefaultMaxEdits
+	}
+	if p.MaxEdits < 1 || p.MaxEdits > 2 {
+		return errors.New(errors.ErrorTypeIllegalArgumentException, fmt.Sprintf("[phrase] max_edits must be 1 or 2, but got [%d]", p.MaxEdits))
+	}
+	if p.PrefixLength == 0 {
+		p.PrefixLength = DefaultPrefixLength
+	}
+	return nil
+}
+
+// Response runs the suggesters against the dictionaries of the readers,
+// the suggestions come from the documents matching the filter, nil matches all
+func Response(readers []*bluge.Reader, suggests map[string]*meta.Suggest, filter bluge.Query, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (map[string][]meta.SuggestResponse, error) {
+	resp := make(map[string][]meta.SuggestResponse, len(suggests))
+	for name, s := range suggests {
+		var err error
+		switch {
+		case s.Term != nil:
+			resp[name], err = termResponse(readers, s.Text, s.Term, filter, mappings, analyzers)
+		case s.Phrase != nil:
+			resp[name], err = phraseResponse(readers, s.Text, s.Phrase, filter, mappings, analyzers)
+		default:
+			prefix := s.Prefix
+			if prefix == "" {
+				prefix = s.Text
+			}
+			resp[name], err = completionResponse(readers, prefix, s.Completion, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func termResponse(readers []*bluge.Reader, text string, t *meta.TermSuggester, filter bluge.Query, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) ([]meta.SuggestResponse, error) {
+	tokens, err := analyze(text, t.Field, t.Analyzer, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]meta.SuggestResponse, 0, len(tokens))
+	for _, token := range tokens {
+		term := string(token.Term)
+		entry := meta.SuggestResponse{
+			Text:    term,
+			Offset:  token.Start,
+			Length:  token.End - token.Start,
+			Options: []meta.SuggestOption{},
+		}
+		if len([]rune(term)) < t.MinWordLength {
+			resp = append(resp, entry)
+			continue
+		}
+
+		candidates, err := lookup(readers, t.Field, term, t.PrefixLength, t.MaxEdits, filter)
+		if err != nil {
+			return nil, err
+		}
+		freq, exists := candidates[term]
+		if exists && t.SuggestMode == ModeMissing {
+			resp = append(resp, entry)
+			continue
+		}
+		for candidate, candidateFreq := range candidates {
+			if candidate == term {
+				continue
+			}
+			if t.SuggestMode == ModePopular && candidateFreq <= freq {
+				continue
+			}
+			entry.Options = append(entry.Options, meta.SuggestOption{
+				Text:  candidate,
+				Score: similarity(term, candidate),
+				Freq:  candidateFreq,
+			})
+		}
+		sortOptions(entry.Options, t.Sort)
+		if len(entry.Options) > t.Size {
+			entry.Options = entry.Options[:t.Size]
+		}
+		resp = append(resp, entry)
+	}
+	return resp, nil
+}
+
+// phraseCandidate is a possible replacement of a token in the phrase
+type phraseCandidate struct {
+	term  string
+	score float64
+}
+
+// phraseCorrection is a partial correction of the phrase, the corrected terms of the first tokens
+type phraseCorrection struct {
+	terms []string
+	errs  int
+	score float64
+}
+
+func phraseResponse(readers []*bluge.Reader, text string, p *meta.PhraseSuggester, filter bluge.Query, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) ([]meta.SuggestResponse, error) {
+	tokens, err := analyze(text, p.Field, p.Analyzer, mappings, analyzers)
+	if err != nil {
+		return nil, err
+	}
+	entry := meta.SuggestResponse{
+		Text:    text,
+		Offset:  0,
+		Length:  len(text),
+		Options: []meta.SuggestOption{},
+	}
+	if len(tokens) == 0 {
+		return []meta.SuggestResponse{entry}, nil
+	}
+
+	maxErrors := int(p.MaxErrors)
+	if p.MaxErrors < 1 {
+		maxErrors = int(math.Ceil(p.MaxErrors * float64(len(tokens))))
+	}
+	if maxErrors > len(tokens) {
+		maxErrors = len(tokens)
+	}
+
+	// candidates of each token, the first one is always the token itself
+	terms := make([]string, len(tokens))
+	candidates := make([][]phraseCandidate, len(tokens))
+	for i, token := range tokens {
+		term := string(token.Term)
+		terms[i] = term
+		found, err := lookup(readers, p.Field, term, p.PrefixLength, p.MaxEdits, filter)
+		if err != nil {
+			return nil, err
+		}
+		var maxFreq uint64
+		for _, freq := range found {
+			if freq > maxFreq {
+				maxFreq = freq
+			}
+		}
+		corrections := make([]phraseCandidate, 0, len(found))
+		for candidate, freq := range found {
+			if candidate == term {
+				continue
+			}
+			corrections = append(corrections, phraseCandidate{
+				term:  candidate,
+				score: similarity(term, candidate) * frequencyScore(freq, maxFreq),
+			})
+		}
+		sort.Slice(corrections, func(i, j int) bool {
+			if corrections[i].score != corrections[j].score {
+				return corrections[i].score > corrections[j].score
+			}
+			return corrections[i].term < corrections[j].term
+		})
+		if len(corrections) > PhraseCandidates {
+			corrections = corrections[:PhraseCandidates]
+		}
+		candidates[i] = append([]phraseCandidate{{term: term, score: frequencyScore(found[term], maxFreq)}}, corrections...)
+	}
+
+	// the corrections are extended token by token, only the best ones are kept at each token
+	// so that the work grows linearly with the tokens rather than with the combinations of the candidates
+	beam := p.Size * PhraseCandidates
+	corrections := []phraseCorrection{{terms: make([]string, 0), score: 1}}
+	for i := range tokens {
+		next := make([]phraseCorrection, 0, len(corrections)*len(candidates[i]))
+		for _, c := range corrections {
+			for j, candidate := range candidates[i] {
+				errs := c.errs
+				if j > 0 {
+					errs++
+				}
+				if errs > maxErrors {
+					continue
+				}
+				terms := make([]string, len(c.terms), len(c.terms)+1)
+				copy(terms, c.terms)
+				next = append(next, phraseCorrection{terms: append(terms, candidate.term), errs: errs, score: c.score * candidate.score})
+			}
+		}
+		sort.SliceStable(next, func(i, j int) bool { return next[i].score > next[j].score })
+		if len(next) > beam {
+			next = next[:beam]
+		}
+		corrections = next
+	}
+
+	for _, c := range corrections {
+		if c.errs == 0 {
+			continue
+		}
+		option := meta.SuggestOption{
+			Text:  strings.Join(c.terms, " "),
+			Score: c.score,
+		}
+		if p.Highlight != nil {
+			highlighted := make([]string, len(c.terms))
+			for j, term := range c.terms {
+				if term != terms[j] {
+					term = p.Highlight.PreTag + term + p.Highlight.PostTag
+				}
+				highlighted[j] = term
+			}
+			option.Highlighted = strings.Join(highlighted, " ")
+		}
+		entry.Options = append(entry.Options, option)
+	}
+
+	sortOptions(entry.Options, SortScore)
+	if len(entry.Options) > p.Size {
+		entry.Options = entry.Options[:p.Size]
+	}
+	return []meta.SuggestResponse{entry}, nil
+}
+
+// analyze splits the text into tokens with the analyzer of the field
+func analyze(text, field, analyzerName string, mappings *meta.Mappings, analyzers map[string]*analysis.Analyzer) (analysis.TokenStream, error) {
+	var err error
+	var zer *analysis.Analyzer
+	if analyzerName != "" {
+		zer, err = zincanalysis.QueryAnalyzer(analyzers, analyzerName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		indexZer, searchZer := zincanalysis.QueryAnalyzerForField(analyzers, mappings, field)
+		if searchZer != nil {
+			zer = searchZer
+		} else if indexZer != nil {
+			zer = indexZer
+		}
+	}
+	if zer == nil {
+		if zer, err = zincanalysis.QueryAnalyzer(analyzers, "standard"); err != nil {
+			return nil, err
+		}
+	}
+	return zer.Analyze([]byte(text)), nil
+}
+
+// levenshteinBuilders build the automatons of the terms within the edits, the builders are
+// expensive to create but reusable and thread-safe, so they are created once for each max edits
+var levenshteinBuilders = struct {
+	sync.Mutex
+	builders map[int]*levenshtein.LevenshteinAutomatonBuilder
+}{builders: make(map[int]*levenshtein.LevenshteinAutomatonBuilder)}
+
+func levenshteinAutomaton(term string, maxEdits int) (*levenshtein.DFA, error) {
+	levenshteinBuilders.Lock()
+	builder, ok := levenshteinBuilders.builders[maxEdits]
+	if !ok {
+		var err error
+		if builder, err = levenshtein.NewLevenshteinAutomatonBuilder(uint8(maxEdits), false); err != nil {
+			levenshteinBuilders.Unlock()
+			return nil, err
+		}
+		levenshteinBuilders.builders[maxEdits] = builder
+	}
+	levenshteinBuilders.Unlock()
+	return builder.BuildDfa(term, uint8(maxEdits))
+}
+
+// lookup returns the dictionary terms of the field within maxEdits of the term, with doc frequency.
+// The dictionary is walked with a levenshtein automaton, so only the terms within the edits are visited.
+// With a filter the frequency counts the documents matching the filter and the terms of no such document are skipped.
+func lookup(readers []*bluge.Reader, field, term string, prefixLength, maxEdits int, filter bluge.Query) (map[string]uint64, error) {
+	var start, end []byte
+	runes := []rune(term)
+	if prefixLength > 0 && len(runes) >= prefixLength {
+		start = []byte(string(runes[:prefixLength]))
+		end = prefixEnd(start)
+	}
+	automaton, err := levenshteinAutomaton(term, maxEdits)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]uint64)
+	for _, r := range readers {
+		it, err := r.DictionaryIterator(field, automaton, start, end)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := it.Next()
+		for err == nil && entry != nil {
+			count := entry.Count()
+			if filter != nil {
+				count, err = filteredCount(r, field, entry.Term(), filter)
+				if err != nil {
+					break
+				}
+			}
+			if count > 0 {
+				found[entry.Term()] += count
+			}
+			entry, err = it.Next()
+		}
+		_ = it.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// filteredCount returns the number of documents which have the term and match the filter
+func filteredCount(r *bluge.Reader, field, term string, filter bluge.Query) (uint64, error) {
+	query := bluge.NewBooleanQuery().AddMust(bluge.NewTermQuery(term).SetField(field), filter)
+	dmi, err := r.Search(context.Background(), bluge.NewTopNSearch(0, query).WithStandardAggregations())
+	if err != nil {
+		return 0, err
+	}
+	return dmi.Aggregations().Count(), nil
+}
+
+// prefixEnd returns the smallest key greater than all keys with the prefix
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// distance returns the levenshtein distance of a and b
+func distance(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// similarity returns a score between 0 and 1, 1 means the same
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	n := len(ra)
+	if len(rb) > n {
+		n = len(rb)
+	}
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(distance(ra, rb))/float64(n)
+}
+
+// frequencyScore prefers the terms that appear more often, a missing term gets a low score
+func frequencyScore(freq, maxFreq uint64) float64 {
+	return float64(freq+1) / float64(maxFreq+1)
+}
+
+func sortOptions(options []meta.SuggestOption, by string) {
+	sort.Slice(options, func(i, j int) bool {
+		if by == SortFrequency && options[i].Freq != options[j].Freq {
+			return options[i].Freq > options[j].Freq
+		}
+		if options[i].Score != options[j].Score {
+			return options[i].Score > options[j].Score
+		}
+		if options[i].Freq != options[j].Freq {
+			return options[i].Freq > options[j].Freq
+		}
+		return options[i].Text < options[j].Text
+	})
+}
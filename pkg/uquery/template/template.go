@@ -48,6 +48,18 @@ func Request(data map[string]interface{}) (*meta.IndexTemplate, error) {
 			for _, pattern := range patterns {
 				template.IndexPatterns = append(template.IndexPatterns, pattern.(string))
 			}
+		case "composed_of":
+			names, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[template] composed_of value should be an array of string")
+			}
+			for _, name := range names {
+				name, ok := name.(string)
+				if !ok {
+					return nil, errors.New(errors.ErrorTypeXContentParseException, "[template] composed_of value should be an array of string")
+				}
+				template.ComposedOf = append(template.ComposedOf, name)
+			}
 		case "priority":
 			switch v := v.(type) {
 			case string:
@@ -69,8 +81,10 @@ func Request(data map[string]interface{}) (*meta.IndexTemplate, error) {
 				if err != nil {
 					return nil, err
 				}
-				template.Template.Settings = tmpIndex.Settings
-				template.Template.Mappings = tmpIndex.Mappings
+				if tmpIndex != nil {
+					template.Template.Settings = tmpIndex.Settings
+					template.Template.Mappings = tmpIndex.Mappings
+				}
 			default:
 				return nil, errors.New(errors.ErrorTypeXContentParseException, "[template] template value should be an object")
 			}
@@ -85,3 +99,41 @@ func Request(data map[string]interface{}) (*meta.IndexTemplate, error) {
 
 	return template, nil
 }
+
+// ComponentRequest parses the body of a component template, only the template is used,
+// the version and _meta are accepted for compatibility
+func ComponentRequest(data map[string]interface{}) (*meta.IndexComponentTemplate, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if data["template"] == nil {
+		return nil, errors.New(errors.ErrorTypeXContentParseException, "[component_template] template should be defined")
+	}
+
+	template := new(meta.IndexComponentTemplate)
+	for k, v := range data {
+		k = strings.ToLower(k)
+		switch k {
+		case "name", "version", "_meta":
+			// ignore
+		case "template":
+			v, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New(errors.ErrorTypeXContentParseException, "[component_template] template value should be an object")
+			}
+			tmpIndex, err := index.Request(v)
+			if err != nil {
+				return nil, err
+			}
+			if tmpIndex != nil {
+				template.Template.Settings = tmpIndex.Settings
+				template.Template.Mappings = tmpIndex.Mappings
+			}
+		default:
+			return nil, errors.New(errors.ErrorTypeParsingException, fmt.Sprintf("[component_template] unknown option [%s]", k))
+		}
+	}
+
+	return template, nil
+}
@@ -16,6 +16,7 @@
 package timerange
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -73,68 +74,44 @@ func RangeQueryTime(field string, query map[string]interface{}) (int64, int64) {
 		}
 	}
 
+	now := time.Now()
 	min := time.Time{}
 	max := time.Time{}
 	if value.GT != nil {
-		if format == "epoch_millis" {
-			num, err := zutils.ToInt(value.GT)
-			if err != nil {
-				return 0, 0
-			}
-
-			min = time.UnixMilli(int64(num))
-		} else {
-			min, err = time.ParseInLocation(format, value.GT.(string), timeZone)
-		}
-		if err != nil {
+		if min, err = timeValue(value.GT, format, timeZone, now, true); err != nil {
 			return 0, 0
 		}
 	}
 	if value.GTE != nil {
-		if format == "epoch_millis" {
-			num, err := zutils.ToInt(value.GTE)
-			if err != nil {
-				return 0, 0
-			}
-
-			min = time.UnixMilli(int64(num))
-		} else {
-			min, err = time.ParseInLocation(format, value.GTE.(string), timeZone)
-		}
-		if err != nil {
+		if min, err = timeValue(value.GTE, format, timeZone, now, false); err != nil {
 			return 0, 0
 		}
 	}
 	if value.LT != nil {
-		if format == "epoch_millis" {
-			num, err := zutils.ToInt(value.LT)
-			if err != nil {
-				return 0, 0
-			}
-
-			max = time.UnixMilli(int64(num))
-		} else {
-			max, err = time.ParseInLocation(format, value.LT.(string), timeZone)
-		}
-		if err != nil {
+		if max, err = timeValue(value.LT, format, timeZone, now, false); err != nil {
 			return 0, 0
 		}
 	}
 	if value.LTE != nil {
-		if format == "epoch_millis" {
-			num, err := zutils.ToInt(value.LTE)
-			if err != nil {
-				return 0, 0
-			}
-
-			max = time.UnixMilli(int64(num))
-		} else {
-			max, err = time.ParseInLocation(format, value.LTE.(string), timeZone)
-		}
-		if err != nil {
+		if max, err = timeValue(value.LTE, format, timeZone, now, true); err != nil {
 			return 0, 0
 		}
 	}
 
 	return min.UTC().UnixNano(), max.UTC().UnixNano()
 }
+
+// timeValue parses a bound of the time range, supports date math like now-1h/h
+func timeValue(v interface{}, format string, timeZone *time.Location, now time.Time, roundUp bool) (time.Time, error) {
+	if format == "epoch_millis" {
+		if _, ok := v.(string); !ok {
+			num, err := zutils.ToInt(v)
+			return time.UnixMilli(int64(num)), err
+		}
+	}
+	value, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("time range value should be a string, got %T", v)
+	}
+	return zutils.ParseDateMath(value, format, timeZone, now, roundUp)
+}
@@ -0,0 +1,157 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package zutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDateMath parses a date with optional date math, the anchor is `now` or a date followed by `||`,
+// then any number of offsets like `+1d`, `-30m` and roundings like `/d`, eg.: now-1h/h, 2022-01-01||+1M/d
+//
+// The rounding and the dates without time zone use the timeZone, when roundUp is set the date is
+// rounded up to the last millisecond of the unit, it's used by the gt and lte bounds of range queries.
+func ParseDateMath(value, format string, timeZone *time.Location, now time.Time, roundUp bool) (time.Time, error) {
+	if timeZone == nil {
+		timeZone = time.UTC
+	}
+
+	var t time.Time
+	var expr string
+	var err error
+	if strings.HasPrefix(value, "now") {
+		t = now.In(timeZone)
+		expr = value[3:]
+	} else {
+		date := value
+		if i := strings.Index(value, "||"); i >= 0 {
+			date, expr = value[:i], value[i+2:]
+		}
+		if t, err = parseDate(date, format, timeZone); err != nil {
+			return t, err
+		}
+	}
+
+	for i := 0; i < len(expr); {
+		op := expr[i]
+		i++
+		switch op {
+		case '/':
+			if i >= len(expr) {
+				return t, fmt.Errorf("truncated date math [%s]", value)
+			}
+			if t, err = roundDate(t, expr[i], roundUp); err != nil {
+				return t, fmt.Errorf("%s in date math [%s]", err.Error(), value)
+			}
+			i++
+		case '+', '-':
+			j := i
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			n := 1
+			if j > i {
+				n, _ = strconv.Atoi(expr[i:j])
+			}
+			if j >= len(expr) {
+				return t, fmt.Errorf("truncated date math [%s]", value)
+			}
+			if op == '-' {
+				n = -n
+			}
+			if t, err = addDate(t, expr[j], n); err != nil {
+				return t, fmt.Errorf("%s in date math [%s]", err.Error(), value)
+			}
+			i = j + 1
+		default:
+			return t, fmt.Errorf("operator [%c] not supported in date math [%s]", op, value)
+		}
+	}
+
+	return t, nil
+}
+
+// parseDate parses the date by any of the `||` separated formats
+func parseDate(value, format string, timeZone *time.Location) (time.Time, error) {
+	var err error
+	for _, f := range DateFormats(format) {
+		var t time.Time
+		if f == "epoch_millis" {
+			var v float64
+			if v, err = strconv.ParseFloat(value, 64); err == nil {
+				return time.UnixMilli(int64(v)).In(timeZone), nil
+			}
+			continue
+		}
+		if t, err = parseTimeFormat(value, f, timeZone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func addDate(t time.Time, unit byte, n int) (time.Time, error) {
+	switch unit {
+	case 'y':
+		return t.AddDate(n, 0, 0), nil
+	case 'M':
+		return t.AddDate(0, n, 0), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'h', 'H':
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case 'm':
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case 's':
+		return t.Add(time.Duration(n) * time.Second), nil
+	default:
+		return t, fmt.Errorf("unit [%c] not supported", unit)
+	}
+}
+
+func roundDate(t time.Time, unit byte, roundUp bool) (time.Time, error) {
+	y, m, d := t.Date()
+	loc := t.Location()
+	var start time.Time
+	switch unit {
+	case 'y':
+		start = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	case 'M':
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case 'w':
+		start = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case 'd':
+		start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case 'h', 'H':
+		start = time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case 'm':
+		start = time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case 's':
+		start = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	default:
+		return t, fmt.Errorf("unit [%c] not supported", unit)
+	}
+	if !roundUp {
+		return start, nil
+	}
+	end, _ := addDate(start, unit, 1)
+	return end.Add(-time.Millisecond), nil
+}
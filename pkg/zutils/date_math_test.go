@@ -0,0 +1,68 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package zutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDateMath(t *testing.T) {
+	now := time.Date(2022, 6, 15, 10, 35, 20, 0, time.UTC)
+	shanghai, _ := ParseTimeZone("+08:00")
+	tests := []struct {
+		name     string
+		value    string
+		format   string
+		timeZone *time.Location
+		roundUp  bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "now", value: "now", want: now},
+		{name: "now-1h", value: "now-1h", want: now.Add(-time.Hour)},
+		{name: "now+1d", value: "now+1d", want: now.AddDate(0, 0, 1)},
+		{name: "now-30m", value: "now-30m", want: now.Add(-30 * time.Minute)},
+		{name: "now-1h/h", value: "now-1h/h", want: time.Date(2022, 6, 15, 9, 0, 0, 0, time.UTC)},
+		{name: "now/d round up", value: "now/d", roundUp: true, want: time.Date(2022, 6, 15, 23, 59, 59, 999000000, time.UTC)},
+		{name: "now/M", value: "now/M", want: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "now/w", value: "now/w", want: time.Date(2022, 6, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "now/y+1M", value: "now/y+1M", want: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "now/d with time_zone", value: "now/d", timeZone: shanghai, want: time.Date(2022, 6, 15, 0, 0, 0, 0, shanghai)},
+		{name: "date anchor", value: "2022-01-31T00:00:00Z||+1M/d", format: time.RFC3339, want: time.Date(2022, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "date", value: "2022-01-02", format: "2006-01-02", timeZone: shanghai, want: time.Date(2022, 1, 2, 0, 0, 0, 0, shanghai)},
+		{name: "epoch_millis", value: "1655289320000||/h", format: "epoch_millis", want: time.Date(2022, 6, 15, 10, 0, 0, 0, time.UTC)},
+		{name: "multiple formats", value: "2022-01-02||+1d", format: time.RFC3339 + "||2006-01-02", want: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "named format", value: "2022-01-02T03:04", format: "strict_date_optional_time||epoch_millis", want: time.Date(2022, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{name: "unknown unit", value: "now-1x", wantErr: true},
+		{name: "unknown operator", value: "now*1d", wantErr: true},
+		{name: "truncated", value: "now-1", wantErr: true},
+		{name: "invalid date", value: "2022-13-01||+1d", format: "2006-01-02", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateMath(tt.value, tt.format, tt.timeZone, now, tt.roundUp)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
@@ -9,16 +9,25 @@ import (
 	"strings"
 )
 
+// combination is one `condition<value` part of a minimum_should_match
+// expression, a simple value is kept as a combination with condition 0.
 type combination struct {
 	condition int
-	count     int
+	value     string
 }
 
-var regex = regexp.MustCompile(`(\d+)<([-+]?\d+%?)`)
+var (
+	valueRegex       = regexp.MustCompile(`^[-+]?\d+%?$`)
+	combinationRegex = regexp.MustCompile(`^(\d+)<([-+]?\d+%?)$`)
+)
 
 // CalculateMin
 // calculate the MinimumShouldMatch value with given expr and sub query count.
 func CalculateMin(subCount int, v interface{}) (res int, err error) {
+	conditions, err := parseMinShould(v)
+	if err != nil {
+		return 0, err
+	}
 	if subCount == 0 {
 		return 1, nil
 	}
@@ -35,96 +44,68 @@ func CalculateMin(subCount int, v interface{}) (res int, err error) {
 			return
 		}
 	}()
-	switch x := v.(type) {
-	case int64, int, float64:
-		m := 0
-		switch val := v.(type) {
-		case int:
-			m = val
-		case int64:
-			m = int(val)
-		case float64:
-			m = int(math.Floor(val))
-		}
-		if m < 0 {
-			m = subCount + m
+
+	for i, condition := range conditions {
+		// only match first
+		if subCount <= condition.condition {
+			return subCount, nil
 		}
-		return m, nil
-	case []string:
-		conditions := make([]combination, len(x))
-		for i, str := range x {
-			match := regex.FindStringSubmatch(str)
-			if match != nil {
-				leftPart := match[1]
-				rightPart := match[2]
-				condition, err := strconv.ParseInt(leftPart, 10, 64)
-				if err != nil {
-					return 0, fmt.Errorf("cannot parse the condition value: %w", err)
-				}
-				count, err := getPartValue(subCount, rightPart)
-				if err != nil {
-					return 0, fmt.Errorf("cannot parse the clauses count: %w", err)
-				}
-				conditions[i] = combination{
-					condition: int(condition),
-					count:     count,
-				}
-			} else {
-				return 0, fmt.Errorf("invalid MinimumShould value: %s", x)
+		// we are the last one or less than next, we matched
+		if i == len(conditions)-1 || subCount <= conditions[i+1].condition {
+			count, err := getPartValue(subCount, condition.value)
+			if err != nil {
+				return 0, fmt.Errorf("cannot parse the clauses count: %w", err)
 			}
+			return count, nil
 		}
+	}
+	return 0, fmt.Errorf("invalid MinimumShouldMatch value: %v", v)
+}
 
-		sort.Slice(conditions, func(i, j int) bool {
-			return conditions[i].condition < conditions[j].condition
-		})
-
-		for i, condition := range conditions {
-			// only match first
-			if subCount <= condition.condition {
-				return subCount, nil
-			}
-			// we are the last one, matched
-			if i == len(conditions)-1 {
-				return condition.count, nil
-			}
-			// less than next, we matched
-			if subCount <= conditions[i+1].condition {
-				return condition.count, nil
-			}
-		}
-		return 0, fmt.Errorf("invalid MinimumShould value: %v", x)
+// parseMinShould validates the expression and returns its combinations
+// sorted by condition, so errors surface whatever the clause count is.
+func parseMinShould(v interface{}) ([]combination, error) {
+	switch x := v.(type) {
+	case int:
+		return []combination{{value: strconv.Itoa(x)}}, nil
+	case int64:
+		return []combination{{value: strconv.FormatInt(x, 10)}}, nil
+	case float64:
+		return []combination{{value: strconv.Itoa(int(math.Floor(x)))}}, nil
 	case string:
-		combinations := strings.Split(x, " ")
-		if len(combinations) > 1 {
-			return CalculateMin(subCount, combinations)
+		parts := strings.Fields(x)
+		if len(parts) == 1 && valueRegex.MatchString(parts[0]) {
+			if _, err := getPartValue(0, parts[0]); err != nil {
+				return nil, fmt.Errorf("invalid MinimumShouldMatch value: %s: %w", x, err)
+			}
+			return []combination{{value: parts[0]}}, nil
 		}
-		// simple expr
-		if res, err := getPartValue(subCount, x); err == nil {
-			return res, nil
+		return parseMinShould(parts)
+	case []string:
+		if len(x) == 0 {
+			return nil, fmt.Errorf("invalid MinimumShouldMatch value: empty expression")
 		}
-
-		// complex, we use regex
-		match := regex.FindStringSubmatch(x)
-		if match != nil {
-			leftPart := match[1]
-			rightPart := match[2]
-			condition, err := strconv.ParseInt(leftPart, 10, 64)
-			if err != nil {
-				return 0, fmt.Errorf("cannot parse the condition value: %w", err)
+		conditions := make([]combination, len(x))
+		for i, str := range x {
+			match := combinationRegex.FindStringSubmatch(str)
+			if match == nil {
+				return nil, fmt.Errorf("invalid MinimumShouldMatch value: %s", str)
 			}
-			count, err := getPartValue(subCount, rightPart)
+			condition, err := strconv.Atoi(match[1])
 			if err != nil {
-				return 0, fmt.Errorf("cannot parse the clauses count: %w", err)
+				return nil, fmt.Errorf("cannot parse the condition value: %w", err)
 			}
-			if subCount <= int(condition) {
-				return subCount, nil
+			if _, err := getPartValue(0, match[2]); err != nil {
+				return nil, fmt.Errorf("cannot parse the clauses count: %w", err)
 			}
-			return count, nil
-		} else {
-			return 0, fmt.Errorf("invalid MinimumShould value: %s", x)
+			conditions[i] = combination{condition: condition, value: match[2]}
 		}
+		sort.Slice(conditions, func(i, j int) bool {
+			return conditions[i].condition < conditions[j].condition
+		})
+		return conditions, nil
 	default:
-		return 0, fmt.Errorf("invalid MinimumShouldMatch value")
+		return nil, fmt.Errorf("invalid MinimumShouldMatch value: %v", v)
 	}
 }
 
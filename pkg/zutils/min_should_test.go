@@ -44,6 +44,7 @@ func TestCalculateMin(t *testing.T) {
 		{subCount: 2, value: "2<-25% 9<-3", want: 2},
 		{subCount: 5, value: "4<-25% 9<-3", want: 4},
 		{subCount: 10, value: "4<-40% 9<-3", want: 7},
+		{subCount: 12, value: " 9<-3  4<-40% ", want: 9},
 	}
 	for _, c := range cases {
 		v, err := CalculateMin(c.subCount, c.value)
@@ -51,3 +52,25 @@ func TestCalculateMin(t *testing.T) {
 		assert.Equal(t, c.want, v)
 	}
 }
+
+func TestCalculateMin_Invalid(t *testing.T) {
+	cases := []struct {
+		subCount int
+		value    interface{}
+	}{
+		{subCount: 5, value: "abc"},
+		{subCount: 5, value: "75.5%"},
+		{subCount: 5, value: "%"},
+		{subCount: 5, value: "x2<75%"},
+		{subCount: 5, value: "2<75%y"},
+		{subCount: 5, value: "2<75% 4"},
+		{subCount: 5, value: ""},
+		{subCount: 5, value: true},
+		// the expression is validated even without clauses
+		{subCount: 0, value: "3<"},
+	}
+	for _, c := range cases {
+		_, err := CalculateMin(c.subCount, c.value)
+		assert.Error(t, err, c.value)
+	}
+}
@@ -0,0 +1,48 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package zutils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CompileRegexp compiles the pattern with the java regex flags separated by |, like CASE_INSENSITIVE|DOTALL
+func CompileRegexp(pattern, flags string) (*regexp.Regexp, error) {
+	var inline string
+	for _, flag := range strings.Split(flags, "|") {
+		switch strings.ToUpper(strings.TrimSpace(flag)) {
+		case "":
+		case "CASE_INSENSITIVE":
+			inline += "i"
+		case "MULTILINE":
+			inline += "m"
+		case "DOTALL":
+			inline += "s"
+		case "LITERAL":
+			pattern = regexp.QuoteMeta(pattern)
+		case "UNICODE_CASE", "UNICODE_CHARACTER_CLASS", "CANON_EQ":
+			// the go regexp always works on unicode
+		default:
+			return nil, fmt.Errorf("regexp flag [%s] is not supported", strings.TrimSpace(flag))
+		}
+	}
+	if inline != "" {
+		pattern = "(?" + inline + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,52 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package zutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		flags   string
+		input   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "no flags", pattern: "^abc$", input: "ABC", want: false},
+		{name: "case insensitive", pattern: "^abc$", flags: "CASE_INSENSITIVE", input: "ABC", want: true},
+		{name: "multiple flags", pattern: "^a.c$", flags: "CASE_INSENSITIVE|DOTALL", input: "A\nC", want: true},
+		{name: "literal", pattern: "a.c", flags: "LITERAL", input: "abc", want: false},
+		{name: "unicode flags", pattern: "a", flags: "UNICODE_CASE|UNICODE_CHARACTER_CLASS", input: "a", want: true},
+		{name: "not supported flag", pattern: "a", flags: "COMMENTS", wantErr: true},
+		{name: "invalid pattern", pattern: "a(", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := CompileRegexp(tt.pattern, tt.flags)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, r.MatchString(tt.input))
+		})
+	}
+}
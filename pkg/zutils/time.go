@@ -112,11 +112,7 @@ func ParseTime(value interface{}, format, timeZone string) (time.Time, error) {
 	}
 
 	var err error
-	timFormat := time.RFC3339
 	timZone := time.UTC
-	if format != "" {
-		timFormat = format
-	}
 	if timeZone != "" {
 		timZone, err = ParseTimeZone(timeZone)
 		if err != nil {
@@ -124,21 +120,105 @@ func ParseTime(value interface{}, format, timeZone string) (time.Time, error) {
 		}
 	}
 
-	if timFormat == "epoch_millis" {
-		v, err := ToInt(vStr)
+	formats := DateFormats(format)
+	if len(formats) == 1 {
+		return parseTimeFormat(vStr, formats[0], timZone)
+	}
+	for _, f := range formats {
+		if t, err := parseTimeFormat(vStr, f, timZone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] doesn't match any of the formats", format, vStr)
+}
+
+// optionalTimeLayouts are tried in order for the strict_date_optional_time format
+var optionalTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// dateFormatLayouts are the layouts of the named date formats
+var dateFormatLayouts = map[string]string{
+	"strict_date_optional_time":  "2006-01-02T15:04:05.000Z07:00",
+	"date_optional_time":         "2006-01-02T15:04:05.000Z07:00",
+	"strict_date_time":           "2006-01-02T15:04:05.000Z07:00",
+	"date_time":                  "2006-01-02T15:04:05.000Z07:00",
+	"strict_date_time_no_millis": time.RFC3339,
+	"date_time_no_millis":        time.RFC3339,
+	"strict_date":                "2006-01-02",
+	"date":                       "2006-01-02",
+	"basic_date":                 "20060102",
+}
+
+// DateFormats splits the `||` separated formats of a date field, an empty format is RFC3339
+func DateFormats(format string) []string {
+	if format == "" {
+		return []string{time.RFC3339}
+	}
+	formats := strings.Split(format, "||")
+	for i := range formats {
+		formats[i] = strings.TrimSpace(formats[i])
+	}
+	return formats
+}
+
+// FormatTime formats the time by the first of the `||` separated formats,
+// the epoch formats return numbers, the named formats are converted to layouts
+func FormatTime(t time.Time, format string) interface{} {
+	format = DateFormats(format)[0]
+	switch format {
+	case "epoch_millis":
+		return t.UnixMilli()
+	case "epoch_second":
+		return t.Unix()
+	}
+	if layout, ok := dateFormatLayouts[format]; ok {
+		format = layout
+	}
+	return t.Format(format)
+}
+
+// parseTimeFormat parses the value by a single format
+func parseTimeFormat(value, format string, timeZone *time.Location) (time.Time, error) {
+	switch format {
+	case "epoch_millis", "epoch_second":
+		v, err := ToInt(value)
 		if err != nil {
-			return time.Time{}, fmt.Errorf("time format is [epoch_millis] but the value [%s] can't convert to int", vStr)
+			return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] can't convert to int", format, value)
 		}
-		if t := Unix(int64(v)); t.IsZero() {
-			return time.Time{}, fmt.Errorf("time format is [epoch_millis] but the value [%s] is not a valid timestamp", vStr)
+		var t time.Time
+		if format == "epoch_second" {
+			t = time.Unix(int64(v), 0)
 		} else {
-			return t, nil
+			t = Unix(int64(v))
 		}
+		if t.IsZero() {
+			return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] is not a valid timestamp", format, value)
+		}
+		return t.In(timeZone), nil
+	case "strict_date_optional_time", "date_optional_time":
+		for _, layout := range optionalTimeLayouts {
+			if t, err := time.ParseInLocation(layout, value, timeZone); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] is not a valid date", format, value)
 	}
 
-	t, err := time.ParseInLocation(timFormat, vStr, timZone)
+	layout := format
+	if v, ok := dateFormatLayouts[format]; ok {
+		layout = v
+		if format == "strict_date_time" || format == "date_time" {
+			// the fraction of second is optional in RFC3339 when parsing
+			layout = time.RFC3339
+		}
+	}
+	t, err := time.ParseInLocation(layout, value, timeZone)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] parse err: %s", timFormat, vStr, err.Error())
+		return time.Time{}, fmt.Errorf("time format is [%s] but the value [%s] parse err: %s", format, value, err.Error())
 	}
 	return t, nil
 }
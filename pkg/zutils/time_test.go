@@ -295,6 +295,45 @@ func TestParseTime(t *testing.T) {
 			want:    now,
 			wantErr: false,
 		},
+		{
+			name: "ParseTime multiple formats",
+			args: args{
+				value:    now.Format(time.RFC1123Z),
+				format:   "epoch_millis||" + time.RFC1123Z,
+				timeZone: "",
+			},
+			want:    now,
+			wantErr: false,
+		},
+		{
+			name: "ParseTime multiple formats epoch_second",
+			args: args{
+				value:    fmt.Sprintf("%d", now.Unix()),
+				format:   "strict_date_optional_time||epoch_second",
+				timeZone: "",
+			},
+			want:    now,
+			wantErr: false,
+		},
+		{
+			name: "ParseTime strict_date_optional_time",
+			args: args{
+				value:    now.UTC().Format("2006-01-02T15:04:05"),
+				format:   "strict_date_optional_time",
+				timeZone: "",
+			},
+			want:    now,
+			wantErr: false,
+		},
+		{
+			name: "ParseTime no format matches",
+			args: args{
+				value:    "xxx",
+				format:   "strict_date_optional_time||epoch_millis",
+				timeZone: "",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -309,3 +348,23 @@ func TestParseTime(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2022, 6, 15, 10, 35, 20, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   interface{}
+	}{
+		{format: "", want: "2022-06-15T10:35:20Z"},
+		{format: "2006-01-02||epoch_millis", want: "2022-06-15"},
+		{format: "epoch_millis||2006-01-02", want: now.UnixMilli()},
+		{format: "epoch_second", want: now.Unix()},
+		{format: "strict_date_optional_time", want: "2022-06-15T10:35:20.000Z"},
+		{format: "basic_date", want: "20220615"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatTime(now, tt.format))
+		})
+	}
+}
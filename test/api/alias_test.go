@@ -0,0 +1,90 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestAliasFilter(t *testing.T) {
+	index := "alias_filter_test"
+	alias := "alias_filter_test-acme"
+	// a single shard holds the documents of any routing
+	resp := request("PUT", "/es/"+index, bytes.NewBufferString(`{"settings":{"number_of_shards":1}}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	for id, tenant := range map[string]string{"1": "acme", "2": "acme", "3": "globex"} {
+		resp := request("PUT", "/es/"+index+"/_doc/"+id+"?refresh=true", bytes.NewBufferString(`{"tenant":"`+tenant+`"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	}
+	defer request("DELETE", "/api/index/"+index, nil)
+	defer func() {
+		assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, []string{index}))
+	}()
+
+	resp = request("POST", "/es/_aliases", bytes.NewBufferString(`{"actions":[{"add":{"index":"`+index+`","alias":"`+alias+`","filter":{"term":{"tenant":"acme"}},"routing":"r1"}}]}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	total := func(resp []byte) int {
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp, result))
+		return result.Hits.Total.Value
+	}
+
+	t.Run("search", func(t *testing.T) {
+		resp := request("POST", "/es/"+alias+"/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 2, total(resp.Body.Bytes()))
+	})
+
+	t.Run("multiple search", func(t *testing.T) {
+		resp := request("POST", "/es/_msearch", bytes.NewBufferString("{\"index\":\""+alias+"\"}\n{\"query\":{\"term\":{\"tenant\":\"globex\"}}}\n"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"total":{"value":0`)
+	})
+
+	t.Run("write with index routing", func(t *testing.T) {
+		resp := request("PUT", "/es/"+alias+"/_doc/4?refresh=true", bytes.NewBufferString(`{"tenant":"acme"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = request("GET", "/es/"+index+"/_doc/4?routing=r1", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"_routing":"r1"`)
+
+		resp = request("PUT", "/es/"+alias+"/_doc/5?routing=r2", bytes.NewBufferString(`{"tenant":"acme"}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("delete by query", func(t *testing.T) {
+		resp := request("POST", "/es/"+alias+"/_delete_by_query?refresh=true", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = request("POST", "/es/"+index+"/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 1, total(resp.Body.Bytes()))
+	})
+
+	t.Run("get aliases", func(t *testing.T) {
+		resp := request("GET", "/es/"+index+"/_alias", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"filter":{"term":{"tenant":"acme"}}`)
+	})
+}
@@ -582,7 +582,7 @@ func TestAnalyze(t *testing.T) {
 					"tokenizer": {
 					  "my_tokenizer": {
 						"type": "pattern",
-						"pattern": "[^,]+"
+						"pattern": ","
 					  }
 					}
 				  }
@@ -626,7 +626,8 @@ func TestAnalyze(t *testing.T) {
 					"tokenizer": {
 					  "my_tokenizer": {
 						"type": "pattern",
-						"pattern": "((?:\\\\\"|[^\", ])+)"
+						"pattern": "\"((?:\\\\\"|[^\"]|\\\\\")+)\"",
+						"group": 1
 					  }
 					}
 				  }
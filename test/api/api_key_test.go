@@ -0,0 +1,84 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func apiKeyRequest(method, api, encoded string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, api, nil)
+	req.Header.Set("Authorization", "ApiKey "+encoded)
+	w := httptest.NewRecorder()
+	server().ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKey(t *testing.T) {
+	_, err := auth.CreateRole("apikeyreader", "Api Key Reader", []string{"index.List"})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, auth.DeleteRole("apikeyreader"))
+	}()
+
+	resp := request("POST", "/api/_security/api_key", bytes.NewBufferString(`{"name":"reader","role":"apikeyreader","expiration":"1d"}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	key := new(meta.APIKeyResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), key))
+	assert.NotEmpty(t, key.APIKey)
+	assert.NotNil(t, key.Expiration)
+
+	t.Run("authenticate with api key", func(t *testing.T) {
+		resp := apiKeyRequest("GET", "/api/index", key.Encoded)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = apiKeyRequest("GET", "/api/user", key.Encoded)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = apiKeyRequest("GET", "/api/index", auth.EncodeAPIKey(key.ID, "invalid"))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("list api keys", func(t *testing.T) {
+		resp := request("GET", "/api/_security/api_key", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), key.ID)
+		assert.NotContains(t, resp.Body.String(), "hash")
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		resp := request("POST", "/api/_security/api_key", bytes.NewBufferString(`{"name":"reader","expiration":"soon"}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = request("POST", "/api/_security/api_key", bytes.NewBufferString(`{"role":"admin"}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("revoke api key", func(t *testing.T) {
+		resp := request("DELETE", "/api/_security/api_key/"+key.ID, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = apiKeyRequest("GET", "/api/index", key.Encoded)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		resp = request("DELETE", "/api/_security/api_key/"+key.ID, nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
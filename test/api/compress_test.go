@@ -0,0 +1,89 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compressedRequest(method, api, encoding string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, api, bytes.NewReader(body))
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Content-Encoding", encoding)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	server().ServeHTTP(w, req)
+	return w
+}
+
+func TestCompression(t *testing.T) {
+	index := "TestCompression.index_1"
+	data := strings.ReplaceAll(bulkData, "games3", index)
+
+	t.Run("gzip request and response", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		_, _ = zw.Write([]byte(data))
+		_ = zw.Close()
+		resp := compressedRequest("POST", "/es/_bulk", "gzip", buf.Bytes())
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+		zr, err := gzip.NewReader(resp.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"_index":"`+index+`"`)
+	})
+
+	t.Run("deflate request", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		zw := zlib.NewWriter(buf)
+		_, _ = zw.Write([]byte(`{"query":{"match_all":{}}}`))
+		_ = zw.Close()
+		resp := compressedRequest("POST", "/es/"+index+"/_search", "deflate", buf.Bytes())
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("small response is not compressed", func(t *testing.T) {
+		resp := compressedRequest("PUT", "/es/_ingest/pipeline/TestCompression", "identity", []byte(`{"processors":[]}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"acknowledged":true}`, resp.Body.String())
+		resp = request("DELETE", "/es/_ingest/pipeline/TestCompression", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		resp := compressedRequest("POST", "/es/_bulk", "gzip", []byte(data))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = compressedRequest("POST", "/es/_bulk", "br", []byte(data))
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		resp := request("DELETE", "/api/index/"+index, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
@@ -0,0 +1,126 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+	"github.com/zincsearch/zincsearch/pkg/handlers/document"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestDataStream(t *testing.T) {
+	name := "data_stream_test"
+	gen1 := core.DataStreamIndexName(name, 1)
+	gen2 := core.DataStreamIndexName(name, 2)
+	resp := request("PUT", "/es/_data_stream/"+name, nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer request("DELETE", "/es/_data_stream/"+name, nil)
+
+	total := func(resp []byte) int {
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp, result))
+		return result.Hits.Total.Value
+	}
+
+	t.Run("append", func(t *testing.T) {
+		resp := request("POST", "/es/"+name+"/_doc?refresh=true", bytes.NewBufferString(`{"@timestamp":"2023-01-01T00:00:00Z","message":"first"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"_index":"`+gen1+`"`)
+
+		resp = request("POST", "/es/"+name+"/_doc", bytes.NewBufferString(`{"message":"no timestamp"}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = request("PUT", "/es/"+name+"/_create/1?refresh=true", bytes.NewBufferString(`{"@timestamp":"2023-01-01T00:00:01Z","message":"second"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = request("PUT", "/es/"+name+"/_create/1", bytes.NewBufferString(`{"@timestamp":"2023-01-01T00:00:01Z","message":"again"}`))
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("reject updates and deletes", func(t *testing.T) {
+		resp := request("PUT", "/es/"+name+"/_doc/1", bytes.NewBufferString(`{"@timestamp":"2023-01-01T00:00:01Z","message":"replace"}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = request("POST", "/es/"+name+"/_update/1", bytes.NewBufferString(`{"doc":{"message":"update"}}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = request("DELETE", "/es/"+name+"/_doc/1", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		body := `{"create":{"_index":"` + name + `"}}
+{"@timestamp":"2023-01-01T00:00:02Z","message":"third"}
+{"index":{"_index":"` + name + `","_id":"2"}}
+{"@timestamp":"2023-01-01T00:00:03Z","message":"rejected"}
+{"delete":{"_index":"` + name + `","_id":"1"}}
+`
+		resp := request("POST", "/es/_bulk?refresh=true", bytes.NewBufferString(body))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(document.BulkResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.True(t, result.Errors)
+		assert.Len(t, result.Items, 3)
+		assert.Nil(t, result.Items[0]["create"].Error)
+		assert.NotNil(t, result.Items[1]["index"].Error)
+		assert.NotNil(t, result.Items[2]["delete"].Error)
+	})
+
+	t.Run("rollover", func(t *testing.T) {
+		resp := request("POST", "/es/"+name+"/_rollover", bytes.NewBufferString(""))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"new_index":"`+gen2+`"`)
+
+		resp = request("POST", "/es/"+name+"/_doc?refresh=true", bytes.NewBufferString(`{"@timestamp":"2023-01-02T00:00:00Z","message":"fourth"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"_index":"`+gen2+`"`)
+
+		// the reads span all generations
+		resp = request("POST", "/es/"+name+"/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 4, total(resp.Body.Bytes()))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		resp := request("GET", "/es/_data_stream/"+name, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(meta.DataStreamsResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Len(t, result.DataStreams, 1)
+		assert.Equal(t, []string{gen1, gen2}, result.DataStreams[0].IndexNames())
+		assert.Equal(t, int64(2), result.DataStreams[0].Generation)
+
+		resp = request("GET", "/es/_data_stream/data_stream_*", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"name":"`+name+`"`)
+
+		resp = request("GET", "/es/_data_stream/data_stream_not_exist", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := request("DELETE", "/es/_data_stream/"+name, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		_, ok := core.GetIndex(gen1)
+		assert.False(t, ok)
+		resp = request("GET", "/es/_data_stream/"+name, nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -0,0 +1,93 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestDocumentSecurity(t *testing.T) {
+	index := "document_security_test"
+	for id, tenant := range map[string]string{"1": "acme", "2": "acme", "3": "globex"} {
+		resp := request("PUT", "/api/"+index+"/_doc/"+id, bytes.NewBufferString(`{"tenant":"`+tenant+`"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	}
+	defer request("DELETE", "/api/index/"+index, nil)
+	time.Sleep(time.Second)
+
+	resp := request("POST", "/api/role", bytes.NewBufferString(`{
+		"_id": "acmereader",
+		"name": "Acme Reader",
+		"permission": ["search.SearchDSL", "search.DeleteByQuery", "document.Get", "cat.Count"],
+		"indices": [{"names": ["document_security_*"], "query": {"match": {"tenant": "acme"}}}]
+	}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer func() {
+		assert.NoError(t, auth.DeleteRole("acmereader"))
+	}()
+
+	key, secret, err := auth.CreateAPIKey("acme", "acmereader", "admin", 0)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, auth.DeleteAPIKey(key.ID))
+	}()
+	encoded := auth.EncodeAPIKey(key.ID, secret)
+
+	t.Run("search", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/"+index+"/_search", encoded, `{"query":{"match":{"tenant":"globex"}}}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Equal(t, 0, result.Hits.Total.Value)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("GET", "/es/_cat/count/"+index+"?format=json", encoded, "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"count":"2"`)
+	})
+
+	t.Run("get document", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("GET", "/api/"+index+"/_doc/1", encoded, "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = apiKeyRequestWithBody("GET", "/api/"+index+"/_doc/3", encoded, "")
+		assert.NotEqual(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("delete by query", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/"+index+"/_delete_by_query", encoded, `{"query":{"match_all":{}}}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		time.Sleep(time.Second)
+		resp = request("POST", "/es/"+index+"/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Equal(t, 1, result.Hits.Total.Value)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		resp := request("POST", "/api/role", bytes.NewBufferString(`{"_id":"invaliddls","indices":[{"names":["x"],"query":"tenant:acme"}]}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
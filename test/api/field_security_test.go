@@ -0,0 +1,98 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/meta"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestFieldSecurity(t *testing.T) {
+	index := "field_security_test"
+	resp := request("PUT", "/api/"+index+"/_doc/1", bytes.NewBufferString(`{"name":"alice","email":"alice@example.com","address":{"street":"main","city":"paris"}}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer request("DELETE", "/api/index/"+index, nil)
+	time.Sleep(time.Second)
+
+	resp = request("POST", "/api/role", bytes.NewBufferString(`{
+		"_id": "piireader",
+		"name": "PII Reader",
+		"permission": ["search.SearchDSL", "document.Get"],
+		"indices": [{"names": ["field_security_*"], "field_security": {"grant": ["*"], "except": ["email", "address.street"]}}]
+	}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer func() {
+		assert.NoError(t, auth.DeleteRole("piireader"))
+	}()
+
+	key, secret, err := auth.CreateAPIKey("pii", "piireader", "admin", 0)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, auth.DeleteAPIKey(key.ID))
+	}()
+	encoded := auth.EncodeAPIKey(key.ID, secret)
+
+	t.Run("strip source", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/"+index+"/_search", encoded, `{"query":{"match":{"name":"alice"}}}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Len(t, result.Hits.Hits, 1)
+		source := result.Hits.Hits[0].Source.(map[string]interface{})
+		assert.NotContains(t, source, "email")
+		assert.Equal(t, map[string]interface{}{"city": "paris"}, source["address"])
+
+		resp = request("POST", "/es/"+index+"/_search", bytes.NewBufferString(`{"query":{"match":{"name":"alice"}}}`))
+		assert.Contains(t, resp.Body.String(), "alice@example.com")
+	})
+
+	t.Run("strip document", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("GET", "/api/"+index+"/_doc/1", encoded, "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "alice@example.com")
+		assert.Contains(t, resp.Body.String(), "paris")
+	})
+
+	t.Run("block query", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/"+index+"/_search", encoded, `{"query":{"match":{"email":"alice"}}}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = apiKeyRequestWithBody("POST", "/es/"+index+"/_search", encoded, `{"aggs":{"streets":{"terms":{"field":"address.street"}}}}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		resp := request("POST", "/api/role", bytes.NewBufferString(`{"_id":"invalidfls","indices":[{"names":["x"],"field_security":{"except":["a"]}}]}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func apiKeyRequestWithBody(method, api, encoded, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "ApiKey "+encoded)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server().ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,112 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+)
+
+func TestIndexPermission(t *testing.T) {
+	for _, index := range []string{"index_permission_logs-1", "index_permission_secret"} {
+		resp := request("PUT", "/api/"+index+"/_doc/1", bytes.NewBufferString(`{"message":"hello"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		defer request("DELETE", "/api/index/"+index, nil)
+	}
+	time.Sleep(time.Second)
+
+	resp := request("POST", "/api/role", bytes.NewBufferString(`{
+		"_id": "logswriter",
+		"name": "Logs Writer",
+		"permission": [],
+		"indices": [{"names": ["index_permission_logs-*"], "privileges": ["search.*", "document.*"]}]
+	}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer func() {
+		assert.NoError(t, auth.DeleteRole("logswriter"))
+	}()
+
+	key, secret, err := auth.CreateAPIKey("logs", "logswriter", "admin", 0)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, auth.DeleteAPIKey(key.ID))
+	}()
+	encoded := auth.EncodeAPIKey(key.ID, secret)
+
+	t.Run("search authorized index", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/index_permission_logs-1/_search", encoded, `{"query":{"match_all":{}}}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("search unauthorized index", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/index_permission_secret/_search", encoded, `{"query":{"match_all":{}}}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "is unauthorized for the index [index_permission_secret]")
+	})
+
+	t.Run("search wildcard", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/index_permission_*/_search", encoded, `{"query":{"match_all":{}}}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"value":1`)
+	})
+
+	t.Run("search without target", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("POST", "/es/_search", encoded, `{"query":{"match_all":{}}}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("get document", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("GET", "/api/index_permission_logs-1/_doc/1", encoded, "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = apiKeyRequestWithBody("GET", "/api/index_permission_secret/_doc/1", encoded, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("delete index", func(t *testing.T) {
+		resp := apiKeyRequestWithBody("DELETE", "/api/index/index_permission_logs-1", encoded, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		body := `{"index":{"_index":"index_permission_logs-1","_id":"2"}}
+{"message":"allowed"}
+{"index":{"_index":"index_permission_secret","_id":"2"}}
+{"message":"denied"}
+`
+		resp := apiKeyRequestWithBody("POST", "/es/_bulk", encoded, body)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"errors":true`)
+		assert.Contains(t, resp.Body.String(), `"status":403`)
+		assert.Contains(t, resp.Body.String(), `"status":200`)
+	})
+
+	t.Run("msearch", func(t *testing.T) {
+		body := `{"index":"index_permission_logs-1"}
+{"query":{"match_all":{}}}
+{"index":"index_permission_secret"}
+{"query":{"match_all":{}}}
+`
+		resp := apiKeyRequestWithBody("POST", "/es/_msearch", encoded, body)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "is unauthorized for the index [index_permission_secret]")
+	})
+}
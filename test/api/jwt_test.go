@@ -0,0 +1,64 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/config"
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestJWT(t *testing.T) {
+	old := config.Global.JWT
+	defer func() { config.Global.JWT = old }()
+	config.Global.JWT.Secret = "testjwtsecret"
+	config.Global.JWT.UserClaim = "sub"
+	config.Global.JWT.RoleClaim = "role"
+
+	token := func(claims map[string]interface{}) string {
+		c, _ := json.Marshal(claims)
+		signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(c)
+		mac := hmac.New(sha256.New, []byte(config.Global.JWT.Secret))
+		mac.Write([]byte(signed))
+		return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	bearerRequest := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/index", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server().ServeHTTP(w, req)
+		return w
+	}
+
+	resp := bearerRequest(token(map[string]interface{}{"sub": "jwtuser", "role": "admin", "exp": time.Now().Add(time.Hour).Unix()}))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = bearerRequest(token(map[string]interface{}{"sub": "jwtuser", "role": "admin", "exp": time.Now().Add(-time.Hour).Unix()}))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "expired")
+
+	resp = bearerRequest("invalid")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
@@ -0,0 +1,89 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/core"
+)
+
+func TestLifecyclePolicy(t *testing.T) {
+	policy := "lifecycle_policy_test"
+	stream := "lifecycle-test-logs"
+	gen1 := core.DataStreamIndexName(stream, 1)
+	gen2 := core.DataStreamIndexName(stream, 2)
+
+	t.Run("put", func(t *testing.T) {
+		resp := request("PUT", "/es/_ilm/policy/"+policy, bytes.NewBufferString(`{"policy":{"phases":{"warm":{"actions":{}}}}}`))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = request("PUT", "/es/_ilm/policy/"+policy, bytes.NewBufferString(`{"policy":{"phases":{
+			"hot":{"actions":{"rollover":{"max_docs":1}}},
+			"delete":{"min_age":"1h","actions":{"delete":{}}}}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		resp := request("GET", "/es/_ilm/policy/"+policy, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"rollover":{"max_docs":1}`)
+		assert.Contains(t, resp.Body.String(), `"version":1`)
+		resp = request("GET", "/es/_ilm/policy", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"`+policy+`":`)
+		resp = request("GET", "/es/_ilm/policy/lifecycle_policy_not_exist", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("attach by template", func(t *testing.T) {
+		resp := request("PUT", "/es/_index_template/"+policy, bytes.NewBufferString(`{"index_patterns":["lifecycle-test-*"],"data_stream":{},
+			"template":{"settings":{"index.lifecycle.name":"`+policy+`"}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		defer request("DELETE", "/es/_index_template/"+policy, nil)
+
+		resp = request("PUT", "/es/_data_stream/"+stream, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		index, ok := core.GetIndex(gen1)
+		assert.True(t, ok)
+		assert.Equal(t, policy, index.GetLifecyclePolicy())
+
+		resp = request("POST", "/es/"+stream+"/_doc?refresh=true", bytes.NewBufferString(`{"@timestamp":"2023-01-01T00:00:00Z"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		core.ApplyLifecyclePolicies(time.Now())
+		_, ok = core.GetIndex(gen2)
+		assert.True(t, ok)
+		core.ApplyLifecyclePolicies(time.Now().Add(time.Hour * 2))
+		_, ok = core.GetIndex(gen1)
+		assert.False(t, ok)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// the policy is in use by the backing index
+		resp := request("DELETE", "/es/_ilm/policy/"+policy, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		resp = request("DELETE", "/es/_data_stream/"+stream, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = request("DELETE", "/es/_ilm/policy/"+policy, nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = request("DELETE", "/es/_ilm/policy/"+policy, nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
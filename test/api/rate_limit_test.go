@@ -0,0 +1,53 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/auth"
+	"github.com/zincsearch/zincsearch/pkg/config"
+)
+
+func TestRateLimit(t *testing.T) {
+	old := config.Global.RateLimit
+	defer func() {
+		config.Global.RateLimit = old
+		auth.ZINC_RATE_LIMITER.Reset()
+	}()
+	auth.ZINC_RATE_LIMITER.Reset()
+	config.Global.RateLimit.SearchRate = 1
+	config.Global.RateLimit.SearchBurst = 1
+
+	resp := request("PUT", "/api/rate_limit_test/_doc/1", bytes.NewBufferString(`{"name":"zinc"}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer request("DELETE", "/api/index/rate_limit_test", nil)
+
+	resp = request("POST", "/es/rate_limit_test/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = request("POST", "/es/rate_limit_test/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+
+	resp = request("GET", "/api/index", nil)
+	assert.Equal(t, http.StatusOK, resp.Code, "the apis without a bucket are not limited")
+	resp = request("PUT", "/api/rate_limit_test/_doc/2", bytes.NewBufferString(`{"name":"zinc"}`))
+	assert.Equal(t, http.StatusOK, resp.Code, "the index requests have their own bucket")
+}
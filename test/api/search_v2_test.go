@@ -238,3 +238,132 @@ func TestSearchV2(t *testing.T) {
 		})
 	})
 }
+
+func TestSearchV2_Wildcards(t *testing.T) {
+	indexes := []string{"wildcard_test-2024-01-app", "wildcard_test-2024-02-app", "wildcard_test-2024-02-db"}
+	alias := "wildcard_test-alias"
+	for _, index := range indexes {
+		resp := request("PUT", "/es/"+index+"/_doc/1?refresh=true", bytes.NewBufferString(`{"name":"`+index+`"}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		defer request("DELETE", "/api/index/"+index, nil)
+	}
+	assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.AddIndexesToAlias(alias, indexes[2:]))
+	defer func() {
+		assert.NoError(t, core.ZINC_INDEX_ALIAS_LIST.RemoveIndexesFromAlias(alias, indexes[2:]))
+	}()
+
+	total := func(resp []byte) int {
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp, result))
+		return result.Hits.Total.Value
+	}
+
+	t.Run("search with a wildcard in the middle", func(t *testing.T) {
+		resp := request("POST", "/es/wildcard_test-*-app/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 2, total(resp.Body.Bytes()))
+	})
+
+	t.Run("search with an alias and an index", func(t *testing.T) {
+		resp := request("POST", "/es/"+alias+","+indexes[0]+"/_search", bytes.NewBufferString(`{"query":{"match_all":{}}}`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 2, total(resp.Body.Bytes()))
+	})
+
+	t.Run("count", func(t *testing.T) {
+		resp := request("GET", "/es/_cat/count/wildcard_test-2024-02-*,"+alias+"?h=count", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "2\n", resp.Body.String())
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		resp := request("GET", "/es/"+alias+",wildcard_test-*-01-*/_stats", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(meta.IndexStatsResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Len(t, result.Indices, 2)
+		assert.Contains(t, result.Indices, indexes[0])
+		assert.Contains(t, result.Indices, indexes[2])
+	})
+
+	t.Run("delete with a wildcard in the middle", func(t *testing.T) {
+		resp := request("DELETE", "/api/index/wildcard_test-*-db", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		_, ok := core.GetIndex(indexes[2])
+		assert.False(t, ok)
+		_, ok = core.GetIndex(indexes[0])
+		assert.True(t, ok)
+	})
+}
+
+func TestSearchV2_IndicesOptions(t *testing.T) {
+	index := "indices_options_test-2024"
+	resp := request("PUT", "/es/"+index+"/_doc/1?refresh=true", bytes.NewBufferString(`{"name":"a"}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	defer request("DELETE", "/api/index/"+index, nil)
+
+	query := `{"query":{"match_all":{}}}`
+	t.Run("search a wildcard matching no index", func(t *testing.T) {
+		resp := request("POST", "/es/indices_options_test-2025-*/_search", bytes.NewBufferString(query))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"hits":[]`)
+
+		resp = request("POST", "/es/indices_options_test-2025-*/_search?allow_no_indices=false", bytes.NewBufferString(query))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("search ignoring a missing index", func(t *testing.T) {
+		resp := request("POST", "/es/indices_options_test-2025/_search", bytes.NewBufferString(query))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = request("POST", "/es/indices_options_test-2025,"+index+"/_search?ignore_unavailable=true", bytes.NewBufferString(query))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := new(meta.SearchResponse)
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+		assert.Equal(t, 1, result.Hits.Total.Value)
+	})
+
+	t.Run("multiple search", func(t *testing.T) {
+		body := "{\"index\":\"indices_options_test-2025-*\"}\n" + query + "\n" +
+			"{\"index\":\"indices_options_test-2025-*\",\"allow_no_indices\":false}\n" + query + "\n"
+		resp := request("POST", "/es/_msearch", bytes.NewBufferString(body))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		result := struct {
+			Responses []*meta.SearchResponse `json:"responses"`
+		}{}
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		assert.Len(t, result.Responses, 2)
+		assert.Equal(t, http.StatusOK, result.Responses[0].Status)
+		assert.Equal(t, http.StatusNotFound, result.Responses[1].Status)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		resp := request("GET", "/es/_cat/count/indices_options_test-2025-*?h=count", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "0\n", resp.Body.String())
+
+		resp = request("GET", "/es/_cat/count/indices_options_test-2025,"+index+"?h=count&ignore_unavailable=true", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "1\n", resp.Body.String())
+
+		resp = request("GET", "/es/_cat/count/indices_options_test-2025-*?allow_no_indices=false", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		resp := request("GET", "/es/indices_options_test-2025-*/_stats", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"indices":{}`)
+
+		resp = request("GET", "/es/indices_options_test-2025,"+index+"/_stats?ignore_unavailable=true", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"`+index+`"`)
+
+		resp = request("GET", "/es/indices_options_test-*/_stats?expand_wildcards=closed", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"indices":{}`)
+
+		resp = request("GET", "/es/indices_options_test-*/_stats?expand_wildcards=x", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,73 @@
+/* Copyright 2022 Zinc Labs Inc. and Contributors
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*     http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zincsearch/zincsearch/pkg/zutils/json"
+)
+
+func TestSession(t *testing.T) {
+	bearerRequest := func(method, api, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server().ServeHTTP(w, req)
+		return w
+	}
+
+	resp := request("POST", "/api/login", bytes.NewBufferString(`{"_id":"`+username+`","password":"`+password+`"}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	login := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &login))
+	accessToken, _ := login["access_token"].(string)
+	refreshToken, _ := login["refresh_token"].(string)
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, refreshToken)
+	assert.Equal(t, "Bearer", login["token_type"])
+
+	resp = bearerRequest("GET", "/api/index", accessToken, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = request("POST", "/api/refresh", bytes.NewBufferString(`{"refresh_token":"`+refreshToken+`"}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	refresh := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &refresh))
+	newAccessToken, _ := refresh["access_token"].(string)
+	assert.NotEmpty(t, newAccessToken)
+
+	resp = bearerRequest("GET", "/api/index", accessToken, "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = bearerRequest("GET", "/api/index", newAccessToken, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = request("POST", "/api/refresh", bytes.NewBufferString(`{"refresh_token":"invalid.token"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = bearerRequest("POST", "/api/logout", newAccessToken, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = bearerRequest("GET", "/api/index", newAccessToken, "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = request("POST", "/api/refresh", bytes.NewBufferString(`{"refresh_token":"`+refreshToken+`"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}